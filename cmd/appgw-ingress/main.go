@@ -23,6 +23,7 @@ import (
 	"github.com/spf13/pflag"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
 	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
@@ -38,6 +39,7 @@ import (
 	istio "github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/istio_crd_client/clientset/versioned"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/fips"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/httpserver"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/k8scontext"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/metricstore"
@@ -58,6 +60,7 @@ var (
 	resyncPeriod   = flags.Duration("sync-period", resyncPause, "Interval at which to re-list and confirm cloud resources.")
 	versionInfo    = flags.Bool("version", false, "Print version")
 	verbosity      = flags.Int(verbosityFlag, 1, "Set logging verbosity level")
+	cleanup        = flags.Bool("cleanup", false, "Remove the config owned by this AGIC instance from App Gateway and exit; used when AGIC is uninstalled.")
 )
 
 var allowedSkus = map[n.ApplicationGatewayTier]interface{}{
@@ -85,15 +88,33 @@ func main() {
 	_ = flag.Lookup("logtostderr").Value.Set("true")
 	_ = flag.Set("v", strconv.Itoa(*verbosity))
 
+	if env.EnableFIPS {
+		if err := fips.Enable(); err != nil {
+			glog.Fatal(err)
+		}
+	}
+
+	annotations.SetPrefix(env.AnnotationPrefix)
+	annotations.EnableNginxTranslation(env.EnableNginxAnnotations)
+	annotations.SetLogFilter(annotations.ParseLogFilter(env.LogAnnotationsAllowlist), annotations.ParseLogFilter(env.LogAnnotationsDenylist))
+
 	apiConfig := getKubeClientConfig()
 	kubeClient := kubernetes.NewForConfigOrDie(apiConfig)
 	crdClient := versioned.NewForConfigOrDie(apiConfig)
 	istioCrdClient := istio.NewForConfigOrDie(apiConfig)
 	recorder := getEventRecorder(kubeClient)
+	if interval := events.GetEventDedupInterval(env); interval > 0 {
+		recorder = events.NewDedupRecorder(recorder, interval)
+	}
 	namespaces := getNamespacesToWatch(env.WatchNamespace)
 	metricStore := metricstore.NewMetricStore(env)
 	metricStore.Start()
-	k8sContext := k8scontext.NewContext(kubeClient, crdClient, istioCrdClient, namespaces, *resyncPeriod, metricStore)
+	workQueueDepth, err := strconv.Atoi(env.EventQueueDepth)
+	if err != nil {
+		workQueueDepth = k8scontext.DefaultWorkQueueDepth
+	}
+	k8sContext := k8scontext.NewContext(kubeClient, crdClient, istioCrdClient, namespaces, *resyncPeriod, workQueueDepth, metricStore)
+	k8sContext.SetDynamicClient(dynamic.NewForConfigOrDie(apiConfig))
 	agicPod := k8sContext.GetAGICPod(env)
 
 	// get the details from Azure Context
@@ -107,6 +128,9 @@ func main() {
 	// adjust env variable
 	if env.AppGwResourceID != "" {
 		subscriptionID, resourceGroupName, applicationGatewayName := azure.ParseResourceID(env.AppGwResourceID)
+		if differs(env.SubscriptionID, string(subscriptionID)) || differs(env.ResourceGroupName, string(resourceGroupName)) || differs(env.AppGwName, string(applicationGatewayName)) {
+			glog.Warningf("%s takes precedence over %s, %s and %s, which refer to a different App Gateway", environment.AppGwResourceIDVarName, environment.SubscriptionIDVarName, environment.ResourceGroupNameVarName, environment.AppGwNameVarName)
+		}
 		env.SubscriptionID = string(subscriptionID)
 		env.ResourceGroupName = string(resourceGroupName)
 		env.AppGwName = string(applicationGatewayName)
@@ -124,7 +148,7 @@ func main() {
 	if err := environment.ValidateEnv(env); err != nil {
 		errorLine := fmt.Sprint("Error while initializing values from environment. Please check helm configuration for missing values: ", err)
 		if agicPod != nil {
-			recorder.Event(agicPod, v1.EventTypeWarning, events.ReasonValidatonError, errorLine)
+			events.Warning(recorder, agicPod, events.ReasonValidatonError, errorLine)
 		}
 		glog.Fatal(errorLine)
 	}
@@ -152,13 +176,29 @@ func main() {
 	if authorizer, err = azure.GetAuthorizerWithRetry(env.AuthLocation, env.UseManagedIdentityForPod, azContext, maxAuthRetryCount, retryPause); err != nil {
 		errorLine := fmt.Sprint("Failed obtaining authentication token for Azure Resource Manager: ", err)
 		if agicPod != nil {
-			recorder.Event(agicPod, v1.EventTypeWarning, events.ReasonARMAuthFailure, errorLine)
+			events.Warning(recorder, agicPod, events.ReasonARMAuthFailure, errorLine)
 		}
 		glog.Fatal(errorLine)
 	} else {
 		azClient.SetAuthorizer(authorizer)
 	}
 
+	azClient.SetUpdatePolling(getSeconds(env.UpdatePollInterval, azure.DefaultUpdatePollInterval), getSeconds(env.UpdateTimeout, azure.DefaultUpdateTimeout))
+
+	if env.StagingResourceID != "" {
+		subscriptionID, resourceGroupName, applicationGatewayName := azure.ParseResourceID(env.StagingResourceID)
+		stagingAzClient := azure.NewAzClient(subscriptionID, resourceGroupName, applicationGatewayName)
+		stagingAzClient.SetAuthorizer(authorizer)
+		stagingAzClient.SetUpdatePolling(getSeconds(env.UpdatePollInterval, azure.DefaultUpdatePollInterval), getSeconds(env.UpdateTimeout, azure.DefaultUpdateTimeout))
+		stagingIdentifier := appgw.Identifier{
+			SubscriptionID: string(subscriptionID),
+			ResourceGroup:  string(resourceGroupName),
+			AppGwName:      string(applicationGatewayName),
+		}
+		appGwIngressController.SetStagingGateway(stagingAzClient, stagingIdentifier, env.StagingProbeURL)
+		glog.Infof("Changed configs are applied to staging App Gateway %s first", env.StagingResourceID)
+	}
+
 	if err = azure.WaitForAzureAuth(azClient, maxAuthRetryCount, retryPause); err != nil {
 		if err == azure.ErrAppGatewayNotFound && env.EnableDeployAppGateway {
 			if env.AppGwSubnetID != "" {
@@ -170,19 +210,28 @@ func main() {
 			if err != nil {
 				errorLine := fmt.Sprint("Failed in deploying App gateway", err)
 				if agicPod != nil {
-					recorder.Event(agicPod, v1.EventTypeWarning, events.ReasonFailedDeployingAppGw, errorLine)
+					events.Warning(recorder, agicPod, events.ReasonFailedDeployingAppGw, errorLine)
 				}
 				glog.Fatal(errorLine)
 			}
 		} else {
 			errorLine := fmt.Sprint("Failed authenticating with Azure Resource Manager: ", err)
 			if agicPod != nil {
-				recorder.Event(agicPod, v1.EventTypeWarning, events.ReasonARMAuthFailure, errorLine)
+				events.Warning(recorder, agicPod, events.ReasonARMAuthFailure, errorLine)
 			}
 			glog.Fatal(errorLine)
 		}
 	}
 
+	// Surface common gateway setup errors early rather than as ARM errors while applying the config.
+	if appGw, err := azClient.GetGateway(); err == nil {
+		for _, err := range azure.ValidateGateway(azClient, appGw) {
+			if agicPod != nil {
+				events.Warning(recorder, agicPod, events.ReasonValidatonError, err.Error())
+			}
+		}
+	}
+
 	// namespace validations
 	if err := validateNamespaces(namespaces, kubeClient); err != nil {
 		glog.Fatal(err) // side-effect: will panic on non-existent namespace
@@ -202,17 +251,31 @@ func main() {
 	if _, exists := allowedSkus[appGw.Sku.Tier]; !exists {
 		errorLine := fmt.Sprintf("App Gateway SKU Tier %s is not supported by AGIC version %s; (v0.10.0 supports App Gwy v1)", appGw.Sku.Tier, appgw.GetVersion())
 		if agicPod != nil {
-			recorder.Event(agicPod, v1.EventTypeWarning, events.UnsupportedAppGatewaySKUTier, errorLine)
+			events.Warning(recorder, agicPod, events.ReasonUnsupportedAppGatewaySKUTier, errorLine)
 		}
 		// Slow down the cycling of the AGIC pod.
 		time.Sleep(5 * time.Second)
 		glog.Fatal(errorLine)
 	}
 
+	if *cleanup {
+		if err := appGwIngressController.Cleanup(env); err != nil {
+			glog.Fatal("Could not remove AGIC config from App Gateway: ", err)
+		}
+		appGwIngressController.Stop()
+		httpServer.Stop()
+		glog.Info("Removed AGIC config from App Gateway. Goodbye!")
+		return
+	}
+
+	if env.EnableDebugServer {
+		httpserver.NewDebugServer(appGwIngressController, env.DebugServerAddress).Start()
+	}
+
 	if err := appGwIngressController.Start(env); err != nil {
 		errorLine := fmt.Sprint("Could not start AGIC: ", err)
 		if agicPod != nil {
-			recorder.Event(agicPod, v1.EventTypeWarning, events.ReasonARMAuthFailure, errorLine)
+			events.Warning(recorder, agicPod, events.ReasonARMAuthFailure, errorLine)
 		}
 		glog.Fatal(errorLine)
 	}
@@ -226,6 +289,11 @@ func main() {
 	glog.Info("Goodbye!")
 }
 
+// differs tells whether an optional setting is set to a different value.
+func differs(setting, value string) bool {
+	return setting != "" && !strings.EqualFold(setting, value)
+}
+
 func validateNamespaces(namespaces []string, kubeClient *kubernetes.Clientset) error {
 	var nonExistent []string
 	for _, ns := range namespaces {
@@ -306,3 +374,11 @@ func getVerbosity(flagVerbosity int, envVerbosity string) int {
 	glog.Infof("Using verbosity level %d from environment variable %s", envVerbosityInt, environment.VerbosityLevelVarName)
 	return envVerbosityInt
 }
+
+func getSeconds(envSeconds string, defaultDuration time.Duration) time.Duration {
+	seconds, err := strconv.Atoi(envSeconds)
+	if err != nil || seconds <= 0 {
+		return defaultDuration
+	}
+	return time.Duration(seconds) * time.Second
+}
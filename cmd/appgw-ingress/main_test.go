@@ -7,6 +7,7 @@ package main
 
 import (
 	"testing"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -52,6 +53,16 @@ var _ = Describe("Test functions used in main.go", func() {
 		})
 	})
 
+	Context("test getSeconds", func() {
+		It("should return the duration based on an environment variable", func() {
+			Expect(getSeconds("90", time.Minute)).To(Equal(90 * time.Second))
+		})
+		It("should return the default duration when the environment variable is not valid", func() {
+			Expect(getSeconds("", time.Minute)).To(Equal(time.Minute))
+			Expect(getSeconds("abc", time.Minute)).To(Equal(time.Minute))
+		})
+	})
+
 	Context("test validateNamespaces", func() {
 		It("should validate the namespaces", func() {
 			actual := validateNamespaces([]string{}, &kubernetes.Clientset{})
@@ -457,7 +457,7 @@ var _ = ginkgo.Describe("Tests `appgw.ConfigBuilder`", func() {
 			tests.Namespace,
 			tests.OtherNamespace,
 		}
-		ctxt = k8scontext.NewContext(k8sClient, crdClient, istioCrdClient, namespaces, 1000*time.Second, metricstore.NewFakeMetricStore())
+		ctxt = k8scontext.NewContext(k8sClient, crdClient, istioCrdClient, namespaces, 1000*time.Second, k8scontext.DefaultWorkQueueDepth, metricstore.NewFakeMetricStore())
 
 		secKey := utils.GetResourceKey(ingressSecret.Namespace, ingressSecret.Name)
 		_ = ctxt.CertificateSecretStore.ConvertSecret(secKey, ingressSecret)
@@ -0,0 +1,33 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package annotations
+
+import (
+	"k8s.io/api/extensions/v1beta1"
+)
+
+// WithDefaults returns the ingress with the default annotations it does not have. The defaults are keyed by the name
+// of the annotation without its prefix, such as "request-timeout"; an annotation of the ingress, with either prefix or
+// translated from NGINX, takes precedence over the default. The ingress is copied when defaults are added to it.
+func WithDefaults(ing *v1beta1.Ingress, defaults map[string]string) *v1beta1.Ingress {
+	var withDefaults *v1beta1.Ingress
+	for name, val := range defaults {
+		if _, _, exists := lookup(ing, ApplicationGatewayPrefix+"/"+name); exists {
+			continue
+		}
+		if withDefaults == nil {
+			withDefaults = ing.DeepCopy()
+			if withDefaults.Annotations == nil {
+				withDefaults.Annotations = make(map[string]string)
+			}
+		}
+		withDefaults.Annotations[annotationPrefix+"/"+name] = val
+	}
+	if withDefaults == nil {
+		return ing
+	}
+	return withDefaults
+}
@@ -0,0 +1,66 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package annotations
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Test default annotations", func() {
+	newIngress := func(annotations map[string]string) *v1beta1.Ingress {
+		return &v1beta1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: annotations,
+			},
+		}
+	}
+	defaults := map[string]string{
+		"ssl-redirect":    "true",
+		"request-timeout": "60",
+	}
+
+	AfterEach(func() {
+		SetPrefix(ApplicationGatewayPrefix)
+		EnableNginxTranslation(false)
+	})
+
+	It("applies the defaults to an ingress without annotations", func() {
+		ing := WithDefaults(newIngress(nil), defaults)
+		Expect(IsSslRedirect(ing)).To(BeTrue())
+		Expect(RequestTimeout(ing)).To(Equal(int32(60)))
+	})
+
+	It("prefers the annotations of the ingress", func() {
+		ing := WithDefaults(newIngress(map[string]string{SslRedirectKey: "false"}), defaults)
+		Expect(IsSslRedirect(ing)).To(BeFalse())
+		Expect(RequestTimeout(ing)).To(Equal(int32(60)))
+	})
+
+	It("prefers the annotations of the ingress with the custom prefix", func() {
+		SetPrefix("agic.contoso.com")
+		ing := WithDefaults(newIngress(map[string]string{"agic.contoso.com/request-timeout": "45"}), defaults)
+		Expect(RequestTimeout(ing)).To(Equal(int32(45)))
+		Expect(ing.Annotations).To(HaveKeyWithValue("agic.contoso.com/ssl-redirect", "true"))
+	})
+
+	It("prefers the NGINX annotations of the ingress", func() {
+		EnableNginxTranslation(true)
+		ing := WithDefaults(newIngress(map[string]string{NginxPrefix + "/ssl-redirect": "false"}), defaults)
+		Expect(IsSslRedirect(ing)).To(BeFalse())
+	})
+
+	It("does not modify the ingress", func() {
+		original := newIngress(map[string]string{SslRedirectKey: "false"})
+		Expect(WithDefaults(original, defaults)).ToNot(BeIdenticalTo(original))
+		Expect(original.Annotations).To(Equal(map[string]string{SslRedirectKey: "false"}))
+
+		complete := newIngress(map[string]string{SslRedirectKey: "false", RequestTimeoutKey: "45"})
+		Expect(WithDefaults(complete, defaults)).To(BeIdenticalTo(complete))
+	})
+})
@@ -31,10 +31,10 @@ func IsMissingAnnotations(e error) bool {
 	return e == ErrMissingAnnotations
 }
 
-// NewInvalidAnnotationContent returns a new InvalidContent error
+// NewInvalidAnnotationContent returns a new InvalidContent error; the value is left out when it is not to be logged
 func NewInvalidAnnotationContent(name string, val interface{}) error {
 	return InvalidContent{
-		Name: fmt.Sprintf("the annotation %v does not contain a valid value (%v)", name, val),
+		Name: fmt.Sprintf("the annotation %v does not contain a valid value (%v)", name, LogValue(name, val)),
 	}
 }
 
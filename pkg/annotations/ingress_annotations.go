@@ -6,11 +6,13 @@
 package annotations
 
 import (
+	"net/url"
 	"strconv"
 	"strings"
 
 	"github.com/knative/pkg/apis/istio/v1alpha3"
 	"k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 const (
@@ -36,6 +38,10 @@ const (
 	// SslRedirectKey defines the key for defining with SSL redirect should be turned on for an HTTP endpoint.
 	SslRedirectKey = ApplicationGatewayPrefix + "/ssl-redirect"
 
+	// SslRedirectExcludePathsKey defines the key for a comma separated list of paths, which will not be redirected
+	// to HTTPS when ssl-redirect is turned on. These paths will be served over HTTP by their backends.
+	SslRedirectExcludePathsKey = ApplicationGatewayPrefix + "/ssl-redirect-exclude-paths"
+
 	// UsePrivateIPKey defines the key to determine whether to use private ip with the ingress.
 	UsePrivateIPKey = ApplicationGatewayPrefix + "/use-private-ip"
 
@@ -59,12 +65,112 @@ const (
 	// annotations that will tell the ingress controller whether it should act on this ingress resource or not.
 	ApplicationGatewayIngressClass = "azure/application-gateway"
 
+	// ApplicationGatewayIngressClassName is the name of the IngressClass of AGIC. An ingress of the networking.k8s.io/v1
+	// API with this ingressClassName is handled as if its `IngressClassKey` annotation was ApplicationGatewayIngressClass.
+	ApplicationGatewayIngressClassName = "azure-application-gateway"
+
 	// FirewallPolicy is the key part of a key/value Ingress annotation.
 	// The value of this is an ID of a Firewall Policy. The Firewall Policy must be already defined in Azure.
 	// The policy will be attached to all URL paths declared in the annotated Ingress resource.
 	FirewallPolicy = ApplicationGatewayPrefix + "/waf-policy-for-path"
+
+	// RequireSNIKey defines the key to require Server Name Indication on the HTTPS listeners of the ingress.
+	// Clients not sending SNI will be rejected. Only valid on listeners with a host name (multi-site listeners).
+	RequireSNIKey = ApplicationGatewayPrefix + "/require-sni"
+
+	// DisableHealthProbeKey defines the key to suppress the generation of health probes for the backends of the ingress.
+	// App Gateway's default probing of the HTTP settings is used instead.
+	DisableHealthProbeKey = ApplicationGatewayPrefix + "/disable-health-probe"
+
+	// ManageBackendOnlyKey defines the key to limit AGIC to reconciling the backend pools of the ingress.
+	// Listeners, rules, path maps, redirects, HTTP settings and probes already on the gateway are left as found.
+	ManageBackendOnlyKey = ApplicationGatewayPrefix + "/manage-backend-only"
+
+	// RequestBufferingKey defines the key to toggle App Gateway's buffering of requests for the backends of the ingress.
+	RequestBufferingKey = ApplicationGatewayPrefix + "/request-buffering"
+
+	// ResponseBufferingKey defines the key to toggle App Gateway's buffering of responses for the backends of the ingress.
+	ResponseBufferingKey = ApplicationGatewayPrefix + "/response-buffering"
+
+	// AdditionalBackendServicesKey defines the key for a list of services, in the namespace of the ingress, whose endpoints
+	// are merged into the backend pools of the ingress.
+	AdditionalBackendServicesKey = ApplicationGatewayPrefix + "/additional-backend-services"
+
+	// MaxConnectionsKey defines the key to cap the concurrent connections App Gateway opens to each backend of the ingress.
+	MaxConnectionsKey = ApplicationGatewayPrefix + "/max-connections"
+
+	// HealthProbePortKey defines the key to override the port the health probes of the backends of the ingress target.
+	HealthProbePortKey = ApplicationGatewayPrefix + "/health-probe-port"
+
+	// HealthProbeProtocolKey defines the key to override the protocol of the health probes of the backends of the ingress,
+	// independently of the protocol of the HTTP settings.
+	HealthProbeProtocolKey = ApplicationGatewayPrefix + "/health-probe-protocol"
+
+	// BackendHostNameKey defines the key for the host header App Gateway sends to the backends of the ingress.
+	BackendHostNameKey = ApplicationGatewayPrefix + "/backend-hostname"
+
+	// PickHostNameFromBackendKey defines the key to make App Gateway send the host name of the backend address
+	// as the host header to the backends of the ingress.
+	PickHostNameFromBackendKey = ApplicationGatewayPrefix + "/pick-host-name-from-backend"
+
+	// BackendHostPortKey defines the key to append the port of the backend to the host header App Gateway sends to
+	// the backends of the ingress.
+	BackendHostPortKey = ApplicationGatewayPrefix + "/backend-host-port"
+
+	// HealthProbeHostNameKey defines the key for the host header the health probes of the backends of the ingress send.
+	HealthProbeHostNameKey = ApplicationGatewayPrefix + "/health-probe-hostname"
+
+	// HealthProbePickHostNameKey defines the key to make the health probes of the backends of the ingress send the host
+	// name of their HTTP settings.
+	HealthProbePickHostNameKey = ApplicationGatewayPrefix + "/health-probe-pick-host-name-from-backend-http-settings"
+
+	// HealthProbeMatchBodyKey defines the key for a string the body of a healthy response to the health probes must contain.
+	HealthProbeMatchBodyKey = ApplicationGatewayPrefix + "/health-probe-match-body"
+
+	// HealthProbeStatusCodesKey defines the key for the status codes of a healthy response to the health probes,
+	// as a comma separated list of codes and ranges, such as "200-299,404".
+	HealthProbeStatusCodesKey = ApplicationGatewayPrefix + "/health-probe-status-codes"
+
+	// BackendServiceSelectorKey defines the key for a label selector of services, in the namespace of the ingress,
+	// whose endpoints are merged into the backend pools of the ingress.
+	BackendServiceSelectorKey = ApplicationGatewayPrefix + "/backend-service-selector"
+
+	// ListenerPortKey defines the key for the frontend port of the listeners of the ingress: the HTTPS listeners of an
+	// ingress with TLS, the HTTP listeners otherwise.
+	ListenerPortKey = ApplicationGatewayPrefix + "/listener-port"
+
+	// HTTPListenerPortKey defines the key for the frontend port of the HTTP listeners of the ingress, including the
+	// listeners redirecting to HTTPS.
+	HTTPListenerPortKey = ApplicationGatewayPrefix + "/http-listener-port"
+
+	// MaintenancePageURLKey defines the key for the URL of the page App Gateway serves, instead of its 502 error, on
+	// the listeners of the ingress when no backend answers.
+	MaintenancePageURLKey = ApplicationGatewayPrefix + "/maintenance-page-url"
+
+	// AppliedConfigHashKey is the key of the annotation AGIC writes on the ingresses, with APPGW_STAMP_CONFIG_HASH, to
+	// record the hash of the App Gateway config it last applied for them. AGIC does not read it.
+	AppliedConfigHashKey = ApplicationGatewayPrefix + "/applied-config-hash"
+
+	// HTTPRouteKey is the key of the annotation AGIC sets on the ingresses it translates from HTTPRoutes of the
+	// Gateway API, to the apiVersion of the route. These ingresses do not exist in the cluster.
+	HTTPRouteKey = ApplicationGatewayPrefix + "/httproute"
 )
 
+// HTTPRouteAPIVersion returns the apiVersion of the HTTPRoute the ingress was translated from, or "" for an ingress of
+// the cluster.
+func HTTPRouteAPIVersion(ing *v1beta1.Ingress) string {
+	return ing.Annotations[HTTPRouteKey]
+}
+
+// HTTPRouteNamePrefix is prepended to the name of the ingresses translated from HTTPRoutes, so that an HTTPRoute and
+// an ingress of the same name and namespace generate distinct App Gateway resources.
+const HTTPRouteNamePrefix = "httproute-"
+
+// HTTPRouteName returns the name of the HTTPRoute the ingress was translated from.
+func HTTPRouteName(ing *v1beta1.Ingress) string {
+	return strings.TrimPrefix(ing.Name, HTTPRouteNamePrefix)
+}
+
 // ProtocolEnum is the type for protocol
 type ProtocolEnum int
 
@@ -74,12 +180,21 @@ const (
 
 	// HTTPS is enum for https protocol
 	HTTPS
+
+	// GRPC is enum for gRPC, which App Gateway does not support, as it speaks HTTP/1.1 to the backends
+	GRPC
 )
 
 // ProtocolEnumLookup is a reverse map of the EventType enums; used for logging purposes
 var ProtocolEnumLookup = map[string]ProtocolEnum{
 	"http":  HTTP,
 	"https": HTTPS,
+	"grpc":  GRPC,
+}
+
+// IngressClass returns the ingress class of the ingress, which is empty when it has none.
+func IngressClass(ing *v1beta1.Ingress) string {
+	return ing.Annotations[IngressClassKey]
 }
 
 // IsApplicationGatewayIngress checks if the Ingress resource can be handled by the Application Gateway ingress controller.
@@ -102,6 +217,22 @@ func IsSslRedirect(ing *v1beta1.Ingress) (bool, error) {
 	return parseBool(ing, SslRedirectKey)
 }
 
+// SslRedirectExcludePaths provides the list of paths, which will not be redirected to HTTPS.
+func SslRedirectExcludePaths(ing *v1beta1.Ingress) ([]string, error) {
+	val, err := parseString(ing, SslRedirectExcludePathsKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, path := range strings.Split(val, ",") {
+		if path = strings.TrimSpace(path); len(path) > 0 {
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
+
 // BackendPathPrefix override path
 func BackendPathPrefix(ing *v1beta1.Ingress) (string, error) {
 	return parseString(ing, BackendPathPrefixKey)
@@ -167,29 +298,166 @@ func WAFPolicy(ing *v1beta1.Ingress) (string, error) {
 	return parseString(ing, FirewallPolicy)
 }
 
+// RequireSNI determines whether the HTTPS listeners of the ingress should require Server Name Indication.
+func RequireSNI(ing *v1beta1.Ingress) (bool, error) {
+	return parseBool(ing, RequireSNIKey)
+}
+
+// IsHealthProbeDisabled determines whether AGIC should not generate health probes for the backends of the ingress.
+func IsHealthProbeDisabled(ing *v1beta1.Ingress) (bool, error) {
+	return parseBool(ing, DisableHealthProbeKey)
+}
+
+// IsManageBackendOnly determines whether AGIC should only reconcile the backend pools of the ingress.
+func IsManageBackendOnly(ing *v1beta1.Ingress) (bool, error) {
+	return parseBool(ing, ManageBackendOnlyKey)
+}
+
+// IsRequestBuffering determines whether App Gateway should buffer the requests to the backends of the ingress.
+func IsRequestBuffering(ing *v1beta1.Ingress) (bool, error) {
+	return parseBool(ing, RequestBufferingKey)
+}
+
+// IsResponseBuffering determines whether App Gateway should buffer the responses of the backends of the ingress.
+func IsResponseBuffering(ing *v1beta1.Ingress) (bool, error) {
+	return parseBool(ing, ResponseBufferingKey)
+}
+
+// AdditionalBackendServices provides the services, whose endpoints are merged into the backend pools of the ingress.
+func AdditionalBackendServices(ing *v1beta1.Ingress) ([]string, error) {
+	val, err := parseString(ing, AdditionalBackendServicesKey)
+	if err != nil {
+		return nil, err
+	}
+	var services []string
+	for _, service := range strings.Split(val, ",") {
+		if service = strings.TrimSpace(service); len(service) > 0 {
+			services = append(services, service)
+		}
+	}
+	return services, nil
+}
+
+// MaxConnections provides the maximum number of concurrent connections to each backend of the ingress.
+func MaxConnections(ing *v1beta1.Ingress) (int32, error) {
+	return parseInt32(ing, MaxConnectionsKey)
+}
+
+// HealthProbePort provides the port the health probes of the backends of the ingress target.
+func HealthProbePort(ing *v1beta1.Ingress) (int32, error) {
+	return parseInt32(ing, HealthProbePortKey)
+}
+
+// BackendHostName provides the host header App Gateway sends to the backends of the ingress.
+func BackendHostName(ing *v1beta1.Ingress) (string, error) {
+	return parseString(ing, BackendHostNameKey)
+}
+
+// PickHostNameFromBackend determines whether App Gateway sends the host name of the backend address as the host header.
+func PickHostNameFromBackend(ing *v1beta1.Ingress) (bool, error) {
+	return parseBool(ing, PickHostNameFromBackendKey)
+}
+
+// BackendHostPort determines whether App Gateway appends the port of the backend to the host header.
+func BackendHostPort(ing *v1beta1.Ingress) (bool, error) {
+	return parseBool(ing, BackendHostPortKey)
+}
+
+// HealthProbeProtocol provides the protocol of the health probes of the backends of the ingress: http or https.
+func HealthProbeProtocol(ing *v1beta1.Ingress) (ProtocolEnum, error) {
+	protocol, err := parseString(ing, HealthProbeProtocolKey)
+	if err != nil {
+		return HTTP, err
+	}
+
+	if protocolEnum, ok := ProtocolEnumLookup[strings.ToLower(protocol)]; ok && protocolEnum != GRPC {
+		return protocolEnum, nil
+	}
+
+	return HTTP, NewInvalidAnnotationContent(HealthProbeProtocolKey, protocol)
+}
+
+// HealthProbeHostName provides the host header the health probes of the backends of the ingress send.
+func HealthProbeHostName(ing *v1beta1.Ingress) (string, error) {
+	return parseString(ing, HealthProbeHostNameKey)
+}
+
+// HealthProbePickHostName determines whether the health probes send the host name of their HTTP settings.
+func HealthProbePickHostName(ing *v1beta1.Ingress) (bool, error) {
+	return parseBool(ing, HealthProbePickHostNameKey)
+}
+
+// HealthProbeMatchBody provides the string the body of a healthy response to the health probes must contain.
+func HealthProbeMatchBody(ing *v1beta1.Ingress) (string, error) {
+	return parseString(ing, HealthProbeMatchBodyKey)
+}
+
+// HealthProbeStatusCodes provides the status codes of a healthy response to the health probes.
+func HealthProbeStatusCodes(ing *v1beta1.Ingress) (string, error) {
+	return parseString(ing, HealthProbeStatusCodesKey)
+}
+
+// BackendServiceSelector provides the label selector of the services, whose endpoints are merged into the backend pools
+// of the ingress. A selector without requirements is invalid, as it would select all services.
+func BackendServiceSelector(ing *v1beta1.Ingress) (labels.Selector, error) {
+	if val, key, ok := lookup(ing, BackendServiceSelectorKey); ok {
+		if selector, err := labels.Parse(val); err == nil && !selector.Empty() {
+			return selector, nil
+		}
+		return nil, NewInvalidAnnotationContent(key, val)
+	}
+	return nil, ErrMissingAnnotations
+}
+
+// ListenerPort provides the frontend port the listeners of the ingress are created on, instead of 80 or 443.
+func ListenerPort(ing *v1beta1.Ingress) (int32, error) {
+	return parseInt32(ing, ListenerPortKey)
+}
+
+// HTTPListenerPort provides the frontend port the HTTP listeners of the ingress are created on, instead of 80.
+func HTTPListenerPort(ing *v1beta1.Ingress) (int32, error) {
+	return parseInt32(ing, HTTPListenerPortKey)
+}
+
+// MaintenancePageURL provides the URL of the maintenance page of the listeners of the ingress. App Gateway fetches
+// custom error pages over HTTP(S), and only HTML pages: the URL must be absolute and end with .htm or .html.
+func MaintenancePageURL(ing *v1beta1.Ingress) (string, error) {
+	if val, key, ok := lookup(ing, MaintenancePageURLKey); ok {
+		pageURL, err := url.Parse(val)
+		if err != nil || (pageURL.Scheme != "http" && pageURL.Scheme != "https") || pageURL.Host == "" {
+			return "", NewInvalidAnnotationContent(key, val)
+		}
+		if path := strings.ToLower(pageURL.Path); !strings.HasSuffix(path, ".htm") && !strings.HasSuffix(path, ".html") {
+			return "", NewInvalidAnnotationContent(key, val)
+		}
+		return val, nil
+	}
+	return "", ErrMissingAnnotations
+}
+
 func parseBool(ing *v1beta1.Ingress, name string) (bool, error) {
-	if val, ok := ing.Annotations[name]; ok {
+	if val, key, ok := lookup(ing, name); ok {
 		if boolVal, err := strconv.ParseBool(val); err == nil {
 			return boolVal, nil
 		}
-		return false, NewInvalidAnnotationContent(name, val)
+		return false, NewInvalidAnnotationContent(key, val)
 	}
 	return false, ErrMissingAnnotations
 }
 
 func parseString(ing *v1beta1.Ingress, name string) (string, error) {
-	if val, ok := ing.Annotations[name]; ok {
+	if val, _, ok := lookup(ing, name); ok {
 		return val, nil
 	}
 	return "", ErrMissingAnnotations
 }
 
 func parseInt32(ing *v1beta1.Ingress, name string) (int32, error) {
-	if val, ok := ing.Annotations[name]; ok {
+	if val, key, ok := lookup(ing, name); ok {
 		if intVal, err := strconv.Atoi(val); err == nil {
 			return int32(intVal), nil
 		}
-		return 0, NewInvalidAnnotationContent(name, val)
+		return 0, NewInvalidAnnotationContent(key, val)
 	}
 
 	return 0, ErrMissingAnnotations
@@ -42,6 +42,21 @@ var _ = Describe("Test ingress annotation functions", func() {
 		"appgw.ingress.kubernetes.io/connection-draining-timeout": "3456",
 		"appgw.ingress.kubernetes.io/backend-path-prefix":         "prefix-here",
 		"appgw.ingress.kubernetes.io/hostname-extension":          "www.bye.com, www.b*.com",
+		"appgw.ingress.kubernetes.io/require-sni":                 "true",
+		"appgw.ingress.kubernetes.io/ssl-redirect-exclude-paths":  "/health, /legacy/*,",
+		"appgw.ingress.kubernetes.io/disable-health-probe":        "true",
+		"appgw.ingress.kubernetes.io/manage-backend-only":         "true",
+		"appgw.ingress.kubernetes.io/request-buffering":           "true",
+		"appgw.ingress.kubernetes.io/response-buffering":          "false",
+		"appgw.ingress.kubernetes.io/max-connections":             "250",
+		"appgw.ingress.kubernetes.io/health-probe-port":           "8081",
+		"appgw.ingress.kubernetes.io/health-probe-protocol":       "HTTPS",
+		"appgw.ingress.kubernetes.io/backend-hostname":            "mesh.contoso.com",
+		"appgw.ingress.kubernetes.io/pick-host-name-from-backend": "true",
+		"appgw.ingress.kubernetes.io/backend-host-port":           "true",
+		"appgw.ingress.kubernetes.io/health-probe-match-body":     "Healthy",
+		"appgw.ingress.kubernetes.io/health-probe-status-codes":   "200-299,404",
+		"appgw.ingress.kubernetes.io/listener-port":               "8443",
 		"kubernetes.io/ingress.class":                             "azure/application-gateway",
 		"appgw.ingress.istio.io/v1alpha3":                         "azure/application-gateway",
 		"falseKey":                                                "false",
@@ -185,6 +200,302 @@ var _ = Describe("Test ingress annotation functions", func() {
 		})
 	})
 
+	Context("test SslRedirectExcludePaths", func() {
+		It("returns error when ingress has no annotations", func() {
+			ing := &v1beta1.Ingress{}
+			actual, err := SslRedirectExcludePaths(ing)
+			Expect(err).To(HaveOccurred())
+			Expect(actual).To(BeNil())
+		})
+		It("returns the trimmed list of paths", func() {
+			actual, err := SslRedirectExcludePaths(ing)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actual).To(Equal([]string{"/health", "/legacy/*"}))
+		})
+	})
+
+	Context("test RequireSNI", func() {
+		It("returns error when ingress has no annotations", func() {
+			ing := &v1beta1.Ingress{}
+			actual, err := RequireSNI(ing)
+			Expect(err).To(HaveOccurred())
+			Expect(actual).To(Equal(false))
+		})
+		It("returns true with correct annotation", func() {
+			actual, err := RequireSNI(ing)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actual).To(Equal(true))
+		})
+	})
+
+	Context("test IsHealthProbeDisabled", func() {
+		It("returns error when ingress has no annotations", func() {
+			ing := &v1beta1.Ingress{}
+			actual, err := IsHealthProbeDisabled(ing)
+			Expect(err).To(HaveOccurred())
+			Expect(actual).To(Equal(false))
+		})
+		It("returns true with correct annotation", func() {
+			actual, err := IsHealthProbeDisabled(ing)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actual).To(Equal(true))
+		})
+	})
+
+	Context("test IsManageBackendOnly", func() {
+		It("returns error when ingress has no annotations", func() {
+			ing := &v1beta1.Ingress{}
+			actual, err := IsManageBackendOnly(ing)
+			Expect(err).To(HaveOccurred())
+			Expect(actual).To(Equal(false))
+		})
+		It("returns true with correct annotation", func() {
+			actual, err := IsManageBackendOnly(ing)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actual).To(Equal(true))
+		})
+	})
+
+	Context("test IsRequestBuffering", func() {
+		It("returns error when ingress has no annotations", func() {
+			ing := &v1beta1.Ingress{}
+			actual, err := IsRequestBuffering(ing)
+			Expect(err).To(HaveOccurred())
+			Expect(actual).To(Equal(false))
+		})
+		It("returns true with correct annotation", func() {
+			actual, err := IsRequestBuffering(ing)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actual).To(Equal(true))
+		})
+	})
+
+	Context("test IsResponseBuffering", func() {
+		It("returns error when ingress has no annotations", func() {
+			ing := &v1beta1.Ingress{}
+			actual, err := IsResponseBuffering(ing)
+			Expect(err).To(HaveOccurred())
+			Expect(actual).To(Equal(false))
+		})
+		It("returns false with correct annotation", func() {
+			actual, err := IsResponseBuffering(ing)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actual).To(Equal(false))
+		})
+	})
+
+	Context("test MaxConnections", func() {
+		It("returns error when ingress has no annotations", func() {
+			ing := &v1beta1.Ingress{}
+			actual, err := MaxConnections(ing)
+			Expect(err).To(HaveOccurred())
+			Expect(actual).To(Equal(int32(0)))
+		})
+		It("returns the limit with correct annotation", func() {
+			actual, err := MaxConnections(ing)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actual).To(Equal(int32(250)))
+		})
+	})
+
+	Context("test HealthProbePort", func() {
+		It("returns error when ingress has no annotations", func() {
+			ing := &v1beta1.Ingress{}
+			actual, err := HealthProbePort(ing)
+			Expect(err).To(HaveOccurred())
+			Expect(actual).To(Equal(int32(0)))
+		})
+		It("returns the port with correct annotation", func() {
+			actual, err := HealthProbePort(ing)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actual).To(Equal(int32(8081)))
+		})
+	})
+
+	Context("test HealthProbeProtocol", func() {
+		It("returns error when ingress has no annotations", func() {
+			ing := &v1beta1.Ingress{}
+			actual, err := HealthProbeProtocol(ing)
+			Expect(err).To(HaveOccurred())
+			Expect(actual).To(Equal(HTTP))
+		})
+		It("returns the protocol with correct annotation", func() {
+			actual, err := HealthProbeProtocol(ing)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actual).To(Equal(HTTPS))
+		})
+		It("returns error for a protocol health probes do not support", func() {
+			for _, protocol := range []string{"grpc", "tcp"} {
+				ing := &v1beta1.Ingress{
+					ObjectMeta: v1.ObjectMeta{
+						Annotations: map[string]string{HealthProbeProtocolKey: protocol},
+					},
+				}
+				actual, err := HealthProbeProtocol(ing)
+				Expect(err).To(HaveOccurred())
+				Expect(actual).To(Equal(HTTP))
+			}
+		})
+	})
+
+	Context("test ListenerPort", func() {
+		It("returns error when ingress has no annotations", func() {
+			ing := &v1beta1.Ingress{}
+			actual, err := ListenerPort(ing)
+			Expect(err).To(HaveOccurred())
+			Expect(actual).To(Equal(int32(0)))
+		})
+		It("returns the port with correct annotation", func() {
+			actual, err := ListenerPort(ing)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actual).To(Equal(int32(8443)))
+		})
+	})
+
+	Context("test BackendHostName", func() {
+		It("returns error when ingress has no annotations", func() {
+			ing := &v1beta1.Ingress{}
+			actual, err := BackendHostName(ing)
+			Expect(err).To(HaveOccurred())
+			Expect(actual).To(Equal(""))
+		})
+		It("returns the host name with correct annotation", func() {
+			actual, err := BackendHostName(ing)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actual).To(Equal("mesh.contoso.com"))
+		})
+	})
+
+	Context("test PickHostNameFromBackend", func() {
+		It("returns error when ingress has no annotations", func() {
+			ing := &v1beta1.Ingress{}
+			actual, err := PickHostNameFromBackend(ing)
+			Expect(err).To(HaveOccurred())
+			Expect(actual).To(Equal(false))
+		})
+		It("returns true with correct annotation", func() {
+			actual, err := PickHostNameFromBackend(ing)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actual).To(Equal(true))
+		})
+	})
+
+	Context("test BackendHostPort", func() {
+		It("returns error when ingress has no annotations", func() {
+			ing := &v1beta1.Ingress{}
+			actual, err := BackendHostPort(ing)
+			Expect(err).To(HaveOccurred())
+			Expect(actual).To(Equal(false))
+		})
+		It("returns true with correct annotation", func() {
+			actual, err := BackendHostPort(ing)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actual).To(Equal(true))
+		})
+	})
+
+	Context("test HealthProbeMatchBody", func() {
+		It("returns error when ingress has no annotations", func() {
+			ing := &v1beta1.Ingress{}
+			actual, err := HealthProbeMatchBody(ing)
+			Expect(err).To(HaveOccurred())
+			Expect(actual).To(Equal(""))
+		})
+		It("returns the body with correct annotation", func() {
+			actual, err := HealthProbeMatchBody(ing)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actual).To(Equal("Healthy"))
+		})
+	})
+
+	Context("test HealthProbeStatusCodes", func() {
+		It("returns error when ingress has no annotations", func() {
+			ing := &v1beta1.Ingress{}
+			actual, err := HealthProbeStatusCodes(ing)
+			Expect(err).To(HaveOccurred())
+			Expect(actual).To(Equal(""))
+		})
+		It("returns the status codes with correct annotation", func() {
+			actual, err := HealthProbeStatusCodes(ing)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actual).To(Equal("200-299,404"))
+		})
+	})
+
+	Context("test BackendServiceSelector", func() {
+		newIngress := func(selector string) *v1beta1.Ingress {
+			return &v1beta1.Ingress{
+				ObjectMeta: v1.ObjectMeta{
+					Annotations: map[string]string{BackendServiceSelectorKey: selector},
+				},
+			}
+		}
+		It("returns error when ingress has no annotations", func() {
+			ing := &v1beta1.Ingress{}
+			actual, err := BackendServiceSelector(ing)
+			Expect(err).To(Equal(ErrMissingAnnotations))
+			Expect(actual).To(BeNil())
+		})
+		It("returns the selector", func() {
+			actual, err := BackendServiceSelector(newIngress("app=web, tier in (frontend, edge)"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actual.String()).To(Equal("app=web,tier in (edge,frontend)"))
+		})
+		It("returns error for an invalid or empty selector", func() {
+			for _, selector := range []string{"=web", "tier in frontend", "", " "} {
+				actual, err := BackendServiceSelector(newIngress(selector))
+				Expect(IsInvalidContent(err)).To(BeTrue(), selector)
+				Expect(actual).To(BeNil())
+			}
+		})
+	})
+
+	Context("test MaintenancePageURL", func() {
+		newIngress := func(pageURL string) *v1beta1.Ingress {
+			return &v1beta1.Ingress{
+				ObjectMeta: v1.ObjectMeta{
+					Annotations: map[string]string{MaintenancePageURLKey: pageURL},
+				},
+			}
+		}
+		It("returns error when ingress has no annotations", func() {
+			_, err := MaintenancePageURL(&v1beta1.Ingress{})
+			Expect(err).To(Equal(ErrMissingAnnotations))
+		})
+		It("returns the URL of an HTML page", func() {
+			for _, pageURL := range []string{"https://contoso.blob.core.windows.net/pages/maintenance.html", "http://status.contoso.com/Down.HTM"} {
+				actual, err := MaintenancePageURL(newIngress(pageURL))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(actual).To(Equal(pageURL))
+			}
+		})
+		It("returns error for a relative URL, another scheme or a page which is not HTML", func() {
+			for _, pageURL := range []string{"/maintenance.html", "ftp://contoso.com/maintenance.html", "https://status.contoso.com/", "https://status.contoso.com/maintenance.png", "", "https://%zz"} {
+				_, err := MaintenancePageURL(newIngress(pageURL))
+				Expect(IsInvalidContent(err)).To(BeTrue(), pageURL)
+			}
+		})
+	})
+
+	Context("test BackendProtocol", func() {
+		It("returns error when ingress has no annotations", func() {
+			ing := &v1beta1.Ingress{}
+			actual, err := BackendProtocol(ing)
+			Expect(err).To(HaveOccurred())
+			Expect(actual).To(Equal(HTTP))
+		})
+		It("returns gRPC with correct annotation", func() {
+			ing := &v1beta1.Ingress{
+				ObjectMeta: v1.ObjectMeta{
+					Annotations: map[string]string{BackendProtocolKey: "GRPC"},
+				},
+			}
+			actual, err := BackendProtocol(ing)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(actual).To(Equal(GRPC))
+		})
+	})
+
 	Context("test GetHostNameExtensions", func() {
 		It("returns error when ingress has no annotations", func() {
 			ing := &v1beta1.Ingress{}
@@ -0,0 +1,72 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package annotations
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces the values of the annotations, which AGIC does not log.
+const Redacted = "<redacted>"
+
+// logAllowlist and logDenylist hold the annotation keys, whose values are logged or redacted; see SetLogFilter.
+var logAllowlist []string
+var logDenylist []string
+
+// secretKey matches the keys of annotations, which look like they hold secrets.
+var secretKey = regexp.MustCompile(`(?i)(secret|passw(or)?d|token|credential|api-?key|private-?key|connection-?string|signature)`)
+
+// secretValue matches the values, which look like they hold secrets, whatever the key of their annotation.
+var secretValue = regexp.MustCompile(`(?i)(bearer\s|basic\s|(password|secret|token|sig)=|-----BEGIN)`)
+
+// SetLogFilter sets the keys of the annotations, whose values AGIC logs, and of those, whose values it redacts. A key
+// ending with "*" matches the keys it prefixes. When the allowlist is empty, the values of all annotations are logged,
+// except for the denied ones and the ones, which look like they hold secrets. An allowed key is logged even if it looks
+// like it holds a secret; a denied key is redacted even if it is allowed.
+func SetLogFilter(allowlist, denylist []string) {
+	logAllowlist = allowlist
+	logDenylist = denylist
+}
+
+// LogValue returns the value of the annotation with the given key as it may be logged: as it is, or Redacted.
+func LogValue(key string, val interface{}) string {
+	value := fmt.Sprint(val)
+	if matchesAny(logDenylist, key) {
+		return Redacted
+	}
+	if matchesAny(logAllowlist, key) {
+		return value
+	}
+	if len(logAllowlist) != 0 || secretKey.MatchString(key) || secretValue.MatchString(value) {
+		return Redacted
+	}
+	return value
+}
+
+// ParseLogFilter splits a comma separated list of annotation keys, such as the value of APPGW_LOG_ANNOTATIONS_ALLOWLIST.
+func ParseLogFilter(keys string) []string {
+	var parsed []string
+	for _, key := range strings.Split(keys, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			parsed = append(parsed, key)
+		}
+	}
+	return parsed
+}
+
+func matchesAny(patterns []string, key string) bool {
+	for _, pattern := range patterns {
+		if strings.HasSuffix(pattern, "*") && strings.HasPrefix(key, strings.TrimSuffix(pattern, "*")) {
+			return true
+		}
+		if pattern == key {
+			return true
+		}
+	}
+	return false
+}
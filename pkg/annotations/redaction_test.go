@@ -0,0 +1,57 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package annotations
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Test the redaction of logged annotation values", func() {
+	AfterEach(func() {
+		SetLogFilter(nil, nil)
+	})
+
+	Context("without allowlist or denylist", func() {
+		It("logs the values of ordinary annotations", func() {
+			Expect(LogValue(BackendPathPrefixKey, "/api/")).To(Equal("/api/"))
+			Expect(LogValue(RequestTimeoutKey, 45)).To(Equal("45"))
+		})
+
+		It("redacts the values of annotations, which look like they hold secrets", func() {
+			Expect(LogValue("example.com/api-key", "abc")).To(Equal(Redacted))
+			Expect(LogValue("example.com/client-secret", "abc")).To(Equal(Redacted))
+			Expect(LogValue("example.com/auth-header", "Bearer eyJhbGciOi")).To(Equal(Redacted))
+			Expect(LogValue("example.com/backend-url", "https://contoso.blob.core.windows.net/?sv=1&sig=abc")).To(Equal(Redacted))
+		})
+	})
+
+	It("redacts the values of denied annotations", func() {
+		SetLogFilter(nil, ParseLogFilter(" "+BackendHostNameKey+", example.com/* "))
+		Expect(LogValue(BackendHostNameKey, "internal.contoso.com")).To(Equal(Redacted))
+		Expect(LogValue("example.com/anything", "value")).To(Equal(Redacted))
+		Expect(LogValue(BackendPathPrefixKey, "/api/")).To(Equal("/api/"))
+	})
+
+	It("logs the values of allowed annotations only", func() {
+		SetLogFilter(ParseLogFilter(BackendPathPrefixKey+",example.com/token"), nil)
+		Expect(LogValue(BackendPathPrefixKey, "/api/")).To(Equal("/api/"))
+		Expect(LogValue("example.com/token", "not-a-secret")).To(Equal("not-a-secret"))
+		Expect(LogValue(BackendHostNameKey, "internal.contoso.com")).To(Equal(Redacted))
+	})
+
+	It("redacts denied annotations even if they are allowed", func() {
+		SetLogFilter(ParseLogFilter(BackendPathPrefixKey), ParseLogFilter(BackendPathPrefixKey))
+		Expect(LogValue(BackendPathPrefixKey, "/api/")).To(Equal(Redacted))
+	})
+
+	It("leaves the redacted values out of the invalid content errors", func() {
+		SetLogFilter(nil, ParseLogFilter(BackendHostNameKey))
+		err := NewInvalidAnnotationContent(BackendHostNameKey, "internal.contoso.com")
+		Expect(err.Error()).ToNot(ContainSubstring("internal.contoso.com"))
+		Expect(err.Error()).To(ContainSubstring(Redacted))
+	})
+})
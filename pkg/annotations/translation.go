@@ -0,0 +1,145 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package annotations
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/golang/glog"
+	"k8s.io/api/extensions/v1beta1"
+)
+
+// NginxPrefix is the prefix of the annotations of the NGINX Ingress Controller.
+const NginxPrefix = "nginx.ingress.kubernetes.io"
+
+// annotationPrefix replaces ApplicationGatewayPrefix in the keys AGIC reads; see SetPrefix.
+var annotationPrefix = ApplicationGatewayPrefix
+
+// nginxTranslation enables the reading of NGINX annotations; see EnableNginxTranslation.
+var nginxTranslation = false
+
+// translator converts the value of an NGINX annotation to the value of the equivalent AGIC annotation.
+// It returns false when the value has no AGIC equivalent.
+type translator func(val string) (string, bool)
+
+type nginxAnnotation struct {
+	key       string
+	translate translator
+}
+
+// nginxAnnotations maps AGIC annotations to their NGINX equivalents, in order of preference.
+var nginxAnnotations = map[string][]nginxAnnotation{
+	SslRedirectKey: {
+		{NginxPrefix + "/force-ssl-redirect", identity},
+		{NginxPrefix + "/ssl-redirect", identity},
+	},
+	BackendProtocolKey: {
+		{NginxPrefix + "/backend-protocol", func(val string) (string, bool) {
+			protocol := strings.ToLower(val)
+			_, ok := ProtocolEnumLookup[protocol]
+			return protocol, ok
+		}},
+	},
+	CookieBasedAffinityKey: {
+		{NginxPrefix + "/affinity", func(val string) (string, bool) {
+			return "true", val == "cookie"
+		}},
+	},
+	RequestTimeoutKey: {
+		{NginxPrefix + "/proxy-read-timeout", func(val string) (string, bool) {
+			_, err := strconv.Atoi(val)
+			return val, err == nil
+		}},
+	},
+	BackendPathPrefixKey: {
+		// Rewrites using regex capture groups have no App Gateway equivalent.
+		{NginxPrefix + "/rewrite-target", func(val string) (string, bool) {
+			return val, !strings.Contains(val, "$")
+		}},
+	},
+}
+
+func identity(val string) (string, bool) {
+	return val, true
+}
+
+// SetPrefix sets the prefix of the annotations AGIC reads, instead of appgw.ingress.kubernetes.io.
+// Annotations with the default prefix are still read when the annotation with the custom prefix is absent.
+func SetPrefix(prefix string) {
+	annotationPrefix = prefix
+}
+
+// EnableNginxTranslation enables reading the NGINX equivalents of the AGIC annotations absent from an ingress.
+func EnableNginxTranslation(enabled bool) {
+	nginxTranslation = enabled
+}
+
+// IsRelevant determines whether AGIC reads the annotation with the given key.
+// Changes to other annotations, which other controllers (cert-manager, external-dns) make, do not affect the App Gateway config.
+// Neither does the applied-config-hash annotation, which AGIC itself writes after applying the config.
+func IsRelevant(key string) bool {
+	if key == IngressClassKey {
+		return true
+	}
+	if key == AppliedConfigHashKey {
+		return false
+	}
+	prefixes := []string{ApplicationGatewayPrefix, annotationPrefix}
+	if nginxTranslation {
+		prefixes = append(prefixes, NginxPrefix)
+	}
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(key, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// HasApplicationGatewayAnnotations determines whether the ingress has any annotation with the prefix of AGIC, which
+// suggests it is meant for AGIC, whatever its ingress class.
+func HasApplicationGatewayAnnotations(ing *v1beta1.Ingress) bool {
+	for key := range ing.Annotations {
+		if key == AppliedConfigHashKey {
+			continue
+		}
+		if strings.HasPrefix(key, ApplicationGatewayPrefix+"/") || strings.HasPrefix(key, annotationPrefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// lookup returns the value of the AGIC annotation with the given key, along with the key it was read from.
+func lookup(ing *v1beta1.Ingress, name string) (string, string, bool) {
+	if annotationPrefix != ApplicationGatewayPrefix && strings.HasPrefix(name, ApplicationGatewayPrefix+"/") {
+		prefixed := annotationPrefix + strings.TrimPrefix(name, ApplicationGatewayPrefix)
+		if val, ok := ing.Annotations[prefixed]; ok {
+			return val, prefixed, true
+		}
+	}
+
+	if val, ok := ing.Annotations[name]; ok {
+		return val, name, true
+	}
+
+	if nginxTranslation {
+		for _, nginx := range nginxAnnotations[name] {
+			val, ok := ing.Annotations[nginx.key]
+			if !ok {
+				continue
+			}
+			if translated, ok := nginx.translate(val); ok {
+				glog.V(5).Infof("Translated annotation %s: %s of ingress %s/%s to %s: %s", nginx.key, LogValue(nginx.key, val), ing.Namespace, ing.Name, name, LogValue(name, translated))
+				return translated, nginx.key, true
+			}
+			glog.V(3).Infof("Annotation %s: %s of ingress %s/%s has no App Gateway equivalent; ignoring", nginx.key, LogValue(nginx.key, val), ing.Namespace, ing.Name)
+		}
+	}
+
+	return "", name, false
+}
@@ -0,0 +1,156 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package annotations
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Test annotation prefix and NGINX translation", func() {
+	newIngress := func(annotations map[string]string) *v1beta1.Ingress {
+		return &v1beta1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: annotations,
+			},
+		}
+	}
+
+	AfterEach(func() {
+		SetPrefix(ApplicationGatewayPrefix)
+		EnableNginxTranslation(false)
+	})
+
+	Context("test SetPrefix", func() {
+		It("reads annotations with the custom prefix", func() {
+			SetPrefix("agic.contoso.com")
+			ing := newIngress(map[string]string{
+				"agic.contoso.com/ssl-redirect":    "true",
+				"agic.contoso.com/request-timeout": "45",
+			})
+			Expect(IsSslRedirect(ing)).To(BeTrue())
+			Expect(RequestTimeout(ing)).To(Equal(int32(45)))
+		})
+
+		It("prefers the custom prefix over the default prefix", func() {
+			SetPrefix("agic.contoso.com")
+			ing := newIngress(map[string]string{
+				"agic.contoso.com/backend-path-prefix": "/custom/",
+				BackendPathPrefixKey:                   "/default/",
+			})
+			Expect(BackendPathPrefix(ing)).To(Equal("/custom/"))
+		})
+
+		It("falls back to the default prefix", func() {
+			SetPrefix("agic.contoso.com")
+			ing := newIngress(map[string]string{
+				BackendPathPrefixKey: "/default/",
+			})
+			Expect(BackendPathPrefix(ing)).To(Equal("/default/"))
+		})
+
+		It("names the custom key in errors", func() {
+			SetPrefix("agic.contoso.com")
+			ing := newIngress(map[string]string{
+				"agic.contoso.com/ssl-redirect": "maybe",
+			})
+			_, err := IsSslRedirect(ing)
+			Expect(err).To(Equal(NewInvalidAnnotationContent("agic.contoso.com/ssl-redirect", "maybe")))
+		})
+
+		It("does not change the ingress class key", func() {
+			SetPrefix("agic.contoso.com")
+			ing := newIngress(map[string]string{
+				IngressClassKey: ApplicationGatewayIngressClass,
+			})
+			Expect(IsApplicationGatewayIngress(ing)).To(BeTrue())
+		})
+	})
+
+	Context("test EnableNginxTranslation", func() {
+		nginxAnnotations := map[string]string{
+			"nginx.ingress.kubernetes.io/ssl-redirect":       "true",
+			"nginx.ingress.kubernetes.io/backend-protocol":   "HTTPS",
+			"nginx.ingress.kubernetes.io/affinity":           "cookie",
+			"nginx.ingress.kubernetes.io/proxy-read-timeout": "120",
+			"nginx.ingress.kubernetes.io/rewrite-target":     "/api/",
+		}
+
+		It("ignores NGINX annotations by default", func() {
+			ing := newIngress(nginxAnnotations)
+			_, err := IsSslRedirect(ing)
+			Expect(IsMissingAnnotations(err)).To(BeTrue())
+			_, err = BackendPathPrefix(ing)
+			Expect(IsMissingAnnotations(err)).To(BeTrue())
+		})
+
+		It("translates NGINX annotations to their AGIC equivalents", func() {
+			EnableNginxTranslation(true)
+			ing := newIngress(nginxAnnotations)
+			Expect(IsSslRedirect(ing)).To(BeTrue())
+			Expect(BackendProtocol(ing)).To(Equal(HTTPS))
+			Expect(IsCookieBasedAffinity(ing)).To(BeTrue())
+			Expect(RequestTimeout(ing)).To(Equal(int32(120)))
+			Expect(BackendPathPrefix(ing)).To(Equal("/api/"))
+		})
+
+		It("prefers AGIC annotations over NGINX annotations", func() {
+			EnableNginxTranslation(true)
+			ing := newIngress(map[string]string{
+				"nginx.ingress.kubernetes.io/proxy-read-timeout": "120",
+				RequestTimeoutKey: "30",
+			})
+			Expect(RequestTimeout(ing)).To(Equal(int32(30)))
+		})
+
+		It("prefers force-ssl-redirect over ssl-redirect", func() {
+			EnableNginxTranslation(true)
+			ing := newIngress(map[string]string{
+				"nginx.ingress.kubernetes.io/force-ssl-redirect": "true",
+				"nginx.ingress.kubernetes.io/ssl-redirect":       "false",
+			})
+			Expect(IsSslRedirect(ing)).To(BeTrue())
+		})
+
+		It("ignores NGINX values without an AGIC equivalent", func() {
+			EnableNginxTranslation(true)
+			ing := newIngress(map[string]string{
+				"nginx.ingress.kubernetes.io/backend-protocol": "AJP",
+				"nginx.ingress.kubernetes.io/affinity":         "none",
+				"nginx.ingress.kubernetes.io/rewrite-target":   "/$2",
+			})
+			_, err := BackendProtocol(ing)
+			Expect(IsMissingAnnotations(err)).To(BeTrue())
+			_, err = IsCookieBasedAffinity(ing)
+			Expect(IsMissingAnnotations(err)).To(BeTrue())
+			_, err = BackendPathPrefix(ing)
+			Expect(IsMissingAnnotations(err)).To(BeTrue())
+		})
+	})
+
+	Context("test IsRelevant", func() {
+		It("recognizes the annotations AGIC reads", func() {
+			Expect(IsRelevant(IngressClassKey)).To(BeTrue())
+			Expect(IsRelevant(RequestTimeoutKey)).To(BeTrue())
+			Expect(IsRelevant("cert-manager.io/issuer")).To(BeFalse())
+			Expect(IsRelevant("nginx.ingress.kubernetes.io/affinity")).To(BeFalse())
+			Expect(IsRelevant("agic.contoso.com/request-timeout")).To(BeFalse())
+		})
+
+		It("does not recognize the applied-config-hash annotation AGIC writes", func() {
+			Expect(IsRelevant(AppliedConfigHashKey)).To(BeFalse())
+		})
+
+		It("recognizes custom prefixed and NGINX annotations when enabled", func() {
+			SetPrefix("agic.contoso.com")
+			EnableNginxTranslation(true)
+			Expect(IsRelevant("agic.contoso.com/request-timeout")).To(BeTrue())
+			Expect(IsRelevant("nginx.ingress.kubernetes.io/affinity")).To(BeTrue())
+		})
+	})
+})
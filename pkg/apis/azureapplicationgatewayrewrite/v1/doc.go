@@ -0,0 +1,10 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+// +k8s:deepcopy-gen=package,register
+// +groupName=azureapplicationgatewayrewrites.appgw.ingress.k8s.io
+
+// Package v1 is the v1 version of the API.
+package v1
@@ -0,0 +1,99 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// AzureApplicationGatewayRewrite is a set of rewrite rules attached to the routing rules generated for an ingress
+type AzureApplicationGatewayRewrite struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec AzureApplicationGatewayRewriteSpec `json:"spec"`
+}
+
+// AzureApplicationGatewayRewriteSpec defines the rewrite rules and the ingress listeners they apply to.
+type AzureApplicationGatewayRewriteSpec struct {
+	// Ingress is the name of the ingress, in the namespace of this resource, whose routing rules get the rewrite rules
+	Ingress string `json:"ingress"`
+
+	// +optional
+	// Host limits the rewrite rules to the listener of this host; All listeners of the ingress are used when empty
+	Host string `json:"host,omitempty"`
+
+	// Rules is the ordered list of rewrite rules; The position in the list is the rule sequence
+	Rules []RewriteRule `json:"rules"`
+}
+
+// RewriteRule is a set of actions applied to a request when all conditions match.
+type RewriteRule struct {
+	// Name of the rewrite rule
+	Name string `json:"name"`
+
+	// +optional
+	// Conditions which must all match for the actions to be applied
+	Conditions []RewriteCondition `json:"conditions,omitempty"`
+
+	// Actions applied to the matching request
+	Actions RewriteActions `json:"actions"`
+}
+
+// RewriteCondition matches a regular expression against the query string of the request, or the method of the request
+// against a list of HTTP methods; Exactly one of QueryString and RequestMethods is set.
+type RewriteCondition struct {
+	// +optional
+	// QueryString is the regular expression matched against the query string of the request
+	QueryString string `json:"queryString,omitempty"`
+
+	// +optional
+	// RequestMethods are the HTTP methods, such as GET or POST, one of which the request must have
+	RequestMethods []string `json:"requestMethods,omitempty"`
+
+	// +optional
+	// IgnoreCase makes the match case insensitive
+	IgnoreCase bool `json:"ignoreCase,omitempty"`
+
+	// +optional
+	// Negate makes the condition match when the pattern does not match
+	Negate bool `json:"negate,omitempty"`
+}
+
+// RewriteActions are the changes applied to the request forwarded to the backend.
+type RewriteActions struct {
+	// +optional
+	// RequestHeaders are set on the request sent to the backend
+	RequestHeaders []RewriteHeader `json:"requestHeaders,omitempty"`
+
+	// +optional
+	// URLPath replaces the path of the request sent to the backend; Not supported by the Application Gateway API used by AGIC yet
+	URLPath string `json:"urlPath,omitempty"`
+}
+
+// RewriteHeader is a header name and the value it is set to.
+type RewriteHeader struct {
+	// Name of the header
+	Name string `json:"name"`
+
+	// Value of the header; May reference server variables such as {var_query_string}
+	Value string `json:"value"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// AzureApplicationGatewayRewriteList is the list of rewrites
+type AzureApplicationGatewayRewriteList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []AzureApplicationGatewayRewrite `json:"items"`
+}
@@ -0,0 +1,162 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"crypto/md5"
+	"fmt"
+	"sort"
+	"strings"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/sorter"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/utils"
+)
+
+// getAdditionalBackendServices returns the sorted, unique services listed by the additional-backend-services annotation
+// of the backend's ingress, other than the backend's own service.
+func getAdditionalBackendServices(backendID backendIdentifier) []string {
+	services, err := annotations.AdditionalBackendServices(backendID.Ingress)
+	if err != nil {
+		return nil
+	}
+	unique := make(map[string]interface{})
+	for _, service := range services {
+		if service != backendID.serviceIdentifier.Name {
+			unique[service] = nil
+		}
+	}
+	var additional []string
+	for service := range unique {
+		additional = append(additional, service)
+	}
+	sort.Strings(additional)
+	return additional
+}
+
+// getSelectedBackendServices returns the sorted services in the namespace of the backend's ingress, which match the
+// backend-service-selector annotation of the ingress, other than the backend's own service.
+func (c *appGwConfigBuilder) getSelectedBackendServices(backendID backendIdentifier) []string {
+	selector, err := annotations.BackendServiceSelector(backendID.Ingress)
+	if err != nil {
+		return nil
+	}
+	var selected []string
+	for _, service := range c.k8sContext.ListServices() {
+		if service.Namespace != backendID.Namespace || service.Name == backendID.serviceIdentifier.Name {
+			continue
+		}
+		if selector.Matches(labels.Set(service.Labels)) {
+			selected = append(selected, service.Name)
+		}
+	}
+	sort.Strings(selected)
+	return selected
+}
+
+// getServiceSelectorSuffix returns a short hash of the backend-service-selector annotation of the backend's ingress,
+// or "" without a valid selector. Pools are named after the selector rather than the services it matches, so that a pool
+// keeps its name while services matching the selector come and go.
+func getServiceSelectorSuffix(backendID backendIdentifier) string {
+	selector, err := annotations.BackendServiceSelector(backendID.Ingress)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("sel-%x", md5.Sum([]byte(selector.String())))[:12]
+}
+
+// getAddressPoolName returns the name of the backend pool of the backend.
+// Backends merging additional or selected services get a pool of their own, distinct from the pool of the service alone.
+// Endpoints can not be merged into the pools of ExternalName services.
+func (c *appGwConfigBuilder) getAddressPoolName(backendID backendIdentifier, serviceBackendPair serviceBackendPortPair) string {
+	serviceName := backendID.serviceFullName()
+	if !c.isExternalNameBackend(backendID) {
+		if additional := getAdditionalBackendServices(backendID); len(additional) > 0 {
+			serviceName = fmt.Sprintf("%s-%s", serviceName, strings.Join(additional, "-"))
+		}
+		if suffix := getServiceSelectorSuffix(backendID); suffix != "" {
+			serviceName = fmt.Sprintf("%s-%s", serviceName, suffix)
+		}
+	}
+	return generateAddressPoolName(serviceName, backendID.Backend.ServicePort.String(), serviceBackendPair.BackendPort)
+}
+
+// mergeAdditionalServices adds the endpoints of the additional and selected services of the backend to its pool.
+// The services must serve the backend port of the pool, which the HTTP settings and probe of the backend target.
+// Addresses shared by several services are added once.
+func (c *appGwConfigBuilder) mergeAdditionalServices(cbCtx *ConfigBuilderContext, backendID backendIdentifier, serviceBackendPair serviceBackendPortPair, pool *n.ApplicationGatewayBackendAddressPool) {
+	additional := getAdditionalBackendServices(backendID)
+	if _, err := annotations.BackendServiceSelector(backendID.Ingress); annotations.IsInvalidContent(err) {
+		glog.Errorf("[%s/%s] %s", backendID.Ingress.Namespace, backendID.Ingress.Name, err)
+		events.Warning(c.recorder, backendID.Ingress, events.ReasonInvalidAnnotation, err.Error())
+	} else if err == nil {
+		selected := c.getSelectedBackendServices(backendID)
+		if len(selected) == 0 {
+			glog.V(3).Infof("[%s/%s] No other service matches annotation %s; pool %s only holds the endpoints of service %s", backendID.Ingress.Namespace, backendID.Ingress.Name, annotations.BackendServiceSelectorKey, *pool.Name, backendID.serviceKey())
+		}
+		additional = mergeServiceNames(additional, selected)
+	}
+	if len(additional) == 0 {
+		return
+	}
+
+	addresses := *pool.BackendAddresses
+	for _, serviceName := range additional {
+		serviceKey := utils.GetResourceKey(backendID.Namespace, serviceName)
+		if service := c.k8sContext.GetService(serviceKey); service == nil {
+			logLine := fmt.Sprintf("Service %s listed by annotation %s of ingress %s/%s not found", serviceKey, annotations.AdditionalBackendServicesKey, backendID.Ingress.Namespace, backendID.Ingress.Name)
+			glog.Error(logLine)
+			events.Warning(c.recorder, backendID.Ingress, events.ReasonServiceNotFound, logLine)
+			continue
+		}
+
+		endpoints, err := c.k8sContext.GetEndpointsByService(serviceKey)
+		if err != nil {
+			logLine := fmt.Sprintf("Failed fetching endpoints for service: %s", serviceKey)
+			glog.Error(logLine)
+			events.Warning(c.recorder, backendID.Ingress, events.ReasonEndpointsEmpty, logLine)
+			continue
+		}
+
+		merged := false
+		for _, subset := range endpoints.Subsets {
+			if _, portExists := getUniqueTCPPorts(subset)[serviceBackendPair.BackendPort]; portExists {
+				addresses = mergeAddresses(addresses, *getAddressesForSubset(c.activeSubset(cbCtx, backendID, subset)))
+				merged = true
+			}
+		}
+		if !merged {
+			logLine := fmt.Sprintf("Service %s does not have endpoints for backend target port %d of pool %s", serviceKey, serviceBackendPair.BackendPort, *pool.Name)
+			glog.Error(logLine)
+			events.Warning(c.recorder, backendID.Ingress, events.ReasonBackendPortTargetMatch, logLine)
+		}
+	}
+
+	sort.Sort(sorter.ByIPFQDN(addresses))
+	pool.BackendAddresses = &addresses
+}
+
+// mergeServiceNames returns the sorted union of two lists of service names.
+func mergeServiceNames(names, others []string) []string {
+	unique := make(map[string]interface{})
+	for _, name := range names {
+		unique[name] = nil
+	}
+	for _, name := range others {
+		unique[name] = nil
+	}
+	var merged []string
+	for name := range unique {
+		merged = append(merged, name)
+	}
+	sort.Strings(merged)
+	return merged
+}
@@ -0,0 +1,262 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
+)
+
+var _ = Describe("Test additional-backend-services annotation", func() {
+	const meshService = "mesh-service"
+
+	var configBuilder appGwConfigBuilder
+	var recorder *record.FakeRecorder
+	var ingress *v1beta1.Ingress
+	var cbCtx *ConfigBuilderContext
+
+	newEndpoints := func(name string, ips ...string) *v1.Endpoints {
+		endpoints := tests.NewEndpointsFixture()
+		endpoints.Name = name
+		endpoints.Subsets[0].Addresses = nil
+		for _, ip := range ips {
+			endpoints.Subsets[0].Addresses = append(endpoints.Subsets[0].Addresses, v1.EndpointAddress{IP: ip})
+		}
+		return endpoints
+	}
+
+	poolAddresses := func() map[string][]string {
+		addresses := make(map[string][]string)
+		for _, pool := range *configBuilder.appGw.BackendAddressPools {
+			if *pool.Name == DefaultBackendAddressPoolName {
+				continue
+			}
+			addresses[*pool.Name] = nil
+			for _, address := range *pool.BackendAddresses {
+				addresses[*pool.Name] = append(addresses[*pool.Name], *address.IPAddress)
+			}
+		}
+		return addresses
+	}
+
+	BeforeEach(func() {
+		configBuilder = newConfigBuilderFixture(nil)
+		recorder = record.NewFakeRecorder(100)
+		configBuilder.recorder = recorder
+
+		service := tests.NewServiceFixture(*tests.NewServicePortsFixture()...)
+		mesh := tests.NewServiceFixture(*tests.NewServicePortsFixture()...)
+		mesh.Name = meshService
+		_ = configBuilder.k8sContext.Caches.Service.Add(service)
+		_ = configBuilder.k8sContext.Caches.Service.Add(mesh)
+		_ = configBuilder.k8sContext.Caches.Endpoints.Add(newEndpoints(tests.ServiceName, "10.0.0.1", "10.0.0.2"))
+		_ = configBuilder.k8sContext.Caches.Endpoints.Add(newEndpoints(meshService, "10.0.0.2", "10.0.1.1"))
+
+		ingress = tests.NewIngressFixture()
+		ingress.Spec.Rules = ingress.Spec.Rules[:1]
+		ingress.Spec.Rules[0].HTTP.Paths = ingress.Spec.Rules[0].HTTP.Paths[:1]
+		_ = configBuilder.k8sContext.Caches.Ingress.Add(ingress)
+
+		cbCtx = &ConfigBuilderContext{
+			IngressList:           []*v1beta1.Ingress{ingress},
+			ServiceList:           []*v1.Service{service, mesh},
+			EnvVariables:          environment.GetFakeEnv(),
+			DefaultAddressPoolID:  to.StringPtr(configBuilder.appGwIdentifier.AddressPoolID(DefaultBackendAddressPoolName)),
+			DefaultHTTPSettingsID: to.StringPtr(configBuilder.appGwIdentifier.HTTPSettingsID(DefaultBackendHTTPSettingsName)),
+			ExistingPortsByNumber: make(map[Port]n.ApplicationGatewayFrontendPort),
+		}
+	})
+
+	It("parses the annotation", func() {
+		ingress.Annotations[annotations.AdditionalBackendServicesKey] = " mesh-b, mesh-a,,mesh-b, " + tests.ServiceName
+		backendID := generateBackendID(ingress, &ingress.Spec.Rules[0], &ingress.Spec.Rules[0].HTTP.Paths[0], &ingress.Spec.Rules[0].HTTP.Paths[0].Backend)
+		Expect(getAdditionalBackendServices(backendID)).To(Equal([]string{"mesh-a", "mesh-b"}))
+	})
+
+	It("merges the endpoints of two services into one pool", func() {
+		ingress.Annotations[annotations.AdditionalBackendServicesKey] = meshService
+		Expect(configBuilder.BackendAddressPools(cbCtx)).ToNot(HaveOccurred())
+
+		pools := poolAddresses()
+		Expect(pools).To(HaveLen(1))
+		for name, addresses := range pools {
+			Expect(name).To(ContainSubstring(meshService))
+			Expect(addresses).To(Equal([]string{"10.0.0.1", "10.0.0.2", "10.0.1.1"}))
+		}
+
+		// Path rules and HTTP settings target the merged pool, with the settings and probe of the backend.
+		Expect(configBuilder.BackendHTTPSettingsCollection(cbCtx)).ToNot(HaveOccurred())
+		Expect(configBuilder.Listeners(cbCtx)).ToNot(HaveOccurred())
+		Expect(configBuilder.RequestRoutingRules(cbCtx)).ToNot(HaveOccurred())
+		for _, pathMap := range *configBuilder.appGw.URLPathMaps {
+			for _, pathRule := range *pathMap.PathRules {
+				if pathRule.BackendAddressPool == nil {
+					// redirect rules have no pool
+					continue
+				}
+				Expect(*pathRule.BackendAddressPool.ID).To(ContainSubstring(meshService))
+			}
+		}
+	})
+
+	It("keeps the pool of the service alone when there are no additional services", func() {
+		Expect(configBuilder.BackendAddressPools(cbCtx)).ToNot(HaveOccurred())
+		for name, addresses := range poolAddresses() {
+			Expect(name).ToNot(ContainSubstring(meshService))
+			Expect(addresses).To(Equal([]string{"10.0.0.1", "10.0.0.2"}))
+		}
+	})
+
+	It("emits an event for a service which does not exist", func() {
+		ingress.Annotations[annotations.AdditionalBackendServicesKey] = "missing-service, " + meshService
+		Expect(configBuilder.BackendAddressPools(cbCtx)).ToNot(HaveOccurred())
+		for _, addresses := range poolAddresses() {
+			Expect(addresses).To(Equal([]string{"10.0.0.1", "10.0.0.2", "10.0.1.1"}))
+		}
+
+		var recorded []string
+		for len(recorder.Events) > 0 {
+			recorded = append(recorded, <-recorder.Events)
+		}
+		Expect(recorded).To(ContainElement(ContainSubstring("missing-service")))
+	})
+})
+
+var _ = Describe("Test backend-service-selector annotation", func() {
+	var configBuilder appGwConfigBuilder
+	var recorder *record.FakeRecorder
+	var ingress *v1beta1.Ingress
+	var cbCtx *ConfigBuilderContext
+
+	newService := func(namespace, name string, labels map[string]string, ips ...string) {
+		service := tests.NewServiceFixture(*tests.NewServicePortsFixture()...)
+		service.Namespace = namespace
+		service.Name = name
+		service.Labels = labels
+		_ = configBuilder.k8sContext.Caches.Service.Add(service)
+
+		endpoints := tests.NewEndpointsFixture()
+		endpoints.Namespace = namespace
+		endpoints.Name = name
+		endpoints.Subsets[0].Addresses = nil
+		for _, ip := range ips {
+			endpoints.Subsets[0].Addresses = append(endpoints.Subsets[0].Addresses, v1.EndpointAddress{IP: ip})
+		}
+		_ = configBuilder.k8sContext.Caches.Endpoints.Add(endpoints)
+	}
+
+	poolAddresses := func() map[string][]string {
+		addresses := make(map[string][]string)
+		for _, pool := range *configBuilder.appGw.BackendAddressPools {
+			if *pool.Name == DefaultBackendAddressPoolName {
+				continue
+			}
+			addresses[*pool.Name] = nil
+			for _, address := range *pool.BackendAddresses {
+				addresses[*pool.Name] = append(addresses[*pool.Name], *address.IPAddress)
+			}
+		}
+		return addresses
+	}
+
+	backendID := func() backendIdentifier {
+		return generateBackendID(ingress, &ingress.Spec.Rules[0], &ingress.Spec.Rules[0].HTTP.Paths[0], &ingress.Spec.Rules[0].HTTP.Paths[0].Backend)
+	}
+
+	BeforeEach(func() {
+		configBuilder = newConfigBuilderFixture(nil)
+		recorder = record.NewFakeRecorder(100)
+		configBuilder.recorder = recorder
+
+		newService(tests.Namespace, tests.ServiceName, map[string]string{"group": "web"}, "10.0.0.1", "10.0.0.2")
+		newService(tests.Namespace, "web-a", map[string]string{"group": "web", "version": "a"}, "10.0.1.1")
+		newService(tests.Namespace, "web-b", map[string]string{"group": "web", "version": "b"}, "10.0.0.2", "10.0.2.1")
+		newService(tests.Namespace, "api", map[string]string{"group": "api"}, "10.0.3.1")
+		newService("other-namespace", "web-c", map[string]string{"group": "web"}, "10.0.4.1")
+
+		ingress = tests.NewIngressFixture()
+		ingress.Spec.Rules = ingress.Spec.Rules[:1]
+		ingress.Spec.Rules[0].HTTP.Paths = ingress.Spec.Rules[0].HTTP.Paths[:1]
+		_ = configBuilder.k8sContext.Caches.Ingress.Add(ingress)
+
+		cbCtx = &ConfigBuilderContext{
+			IngressList:           []*v1beta1.Ingress{ingress},
+			ServiceList:           configBuilder.k8sContext.ListServices(),
+			EnvVariables:          environment.GetFakeEnv(),
+			DefaultAddressPoolID:  to.StringPtr(configBuilder.appGwIdentifier.AddressPoolID(DefaultBackendAddressPoolName)),
+			DefaultHTTPSettingsID: to.StringPtr(configBuilder.appGwIdentifier.HTTPSettingsID(DefaultBackendHTTPSettingsName)),
+			ExistingPortsByNumber: make(map[Port]n.ApplicationGatewayFrontendPort),
+		}
+	})
+
+	It("resolves the services in the namespace of the ingress matching the selector", func() {
+		ingress.Annotations[annotations.BackendServiceSelectorKey] = "group=web"
+		Expect(configBuilder.getSelectedBackendServices(backendID())).To(Equal([]string{"web-a", "web-b"}))
+
+		ingress.Annotations[annotations.BackendServiceSelectorKey] = "group in (web, api), version notin (b)"
+		Expect(configBuilder.getSelectedBackendServices(backendID())).To(Equal([]string{"api", "web-a"}))
+	})
+
+	It("merges the endpoints of the selected services into one pool", func() {
+		ingress.Annotations[annotations.BackendServiceSelectorKey] = "group=web"
+		Expect(configBuilder.BackendAddressPools(cbCtx)).ToNot(HaveOccurred())
+
+		pools := poolAddresses()
+		Expect(pools).To(HaveLen(1))
+		for name, addresses := range pools {
+			Expect(name).To(ContainSubstring(getServiceSelectorSuffix(backendID())))
+			Expect(addresses).To(Equal([]string{"10.0.0.1", "10.0.0.2", "10.0.1.1", "10.0.2.1"}))
+		}
+		Expect(recorder.Events).To(BeEmpty())
+	})
+
+	It("keeps the name of the pool while services matching the selector come and go", func() {
+		ingress.Annotations[annotations.BackendServiceSelectorKey] = "group=web"
+		Expect(configBuilder.BackendAddressPools(cbCtx)).ToNot(HaveOccurred())
+		before := poolAddresses()
+
+		newService(tests.Namespace, "web-d", map[string]string{"group": "web"}, "10.0.5.1")
+		configBuilder.mem = memoization{}
+		Expect(configBuilder.BackendAddressPools(cbCtx)).ToNot(HaveOccurred())
+		after := poolAddresses()
+
+		Expect(after).To(HaveLen(1))
+		for name, addresses := range before {
+			Expect(after).To(HaveKey(name))
+			Expect(after[name]).To(Equal(append(addresses, "10.0.5.1")))
+		}
+	})
+
+	It("keeps the endpoints of the backend's service when no other service matches", func() {
+		ingress.Annotations[annotations.BackendServiceSelectorKey] = "group=none"
+		Expect(configBuilder.BackendAddressPools(cbCtx)).ToNot(HaveOccurred())
+		for _, addresses := range poolAddresses() {
+			Expect(addresses).To(Equal([]string{"10.0.0.1", "10.0.0.2"}))
+		}
+		Expect(recorder.Events).To(BeEmpty())
+	})
+
+	It("emits an event for an invalid selector", func() {
+		ingress.Annotations[annotations.BackendServiceSelectorKey] = "group in web"
+		Expect(configBuilder.BackendAddressPools(cbCtx)).ToNot(HaveOccurred())
+		for name, addresses := range poolAddresses() {
+			Expect(name).ToNot(ContainSubstring("sel-"))
+			Expect(addresses).To(Equal([]string{"10.0.0.1", "10.0.0.2"}))
+		}
+		Expect(recorder.Events).To(HaveLen(1))
+		Expect(<-recorder.Events).To(ContainSubstring(string(events.ReasonInvalidAnnotation)))
+	})
+})
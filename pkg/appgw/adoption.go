@@ -0,0 +1,380 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/golang/glog"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/brownfield"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/sorter"
+)
+
+// adoptionCandidate is an object of the generated or the existing config, which can be matched to its counterpart by
+// its key: what the object does, rather than its name.
+type adoptionCandidate struct {
+	name string
+	id   string
+	key  string
+
+	// rename gives the generated object the name and the ID of the existing object it adopts.
+	rename func(name, id string)
+}
+
+// adoptExistingResources gives the generated objects, which match an object of the existing config, the name of the
+// existing object. The objects of a hand-built App Gateway are so updated in place, rather than deleted and recreated
+// under the names AGIC generates, which interrupts the traffic they serve. Objects are matched by listener (frontend
+// IP, port, protocol and hosts), by the listener of their routing rule, by the listeners and paths which route to
+// backend pools, and by the port, protocol, host and path of HTTP settings and probes. Generated objects without a
+// match keep their names.
+// Adoption is stateless: each build matches the objects again, so the adopted names are kept across restarts.
+func (c *appGwConfigBuilder) adoptExistingResources(existing brownfield.ExistingResources) {
+	renamedIDs := make(map[string]string)
+	var adopted []string
+
+	var probes []adoptionCandidate
+	if c.appGw.Probes != nil {
+		for idx := range *c.appGw.Probes {
+			probe := &(*c.appGw.Probes)[idx]
+			probes = append(probes, adoptionCandidate{name: *probe.Name, id: to.String(probe.ID), key: probeAdoptionKey(*probe),
+				rename: func(name, id string) { probe.Name, probe.ID = to.StringPtr(name), to.StringPtr(id) }})
+		}
+	}
+	var existingProbes []adoptionCandidate
+	for _, probe := range existing.Probes {
+		existingProbes = append(existingProbes, adoptionCandidate{name: *probe.Name, id: to.String(probe.ID), key: probeAdoptionKey(probe)})
+	}
+	adopted = append(adopted, adopt(probes, existingProbes, renamedIDs)...)
+
+	var settings []adoptionCandidate
+	if c.appGw.BackendHTTPSettingsCollection != nil {
+		for idx := range *c.appGw.BackendHTTPSettingsCollection {
+			setting := &(*c.appGw.BackendHTTPSettingsCollection)[idx]
+			settings = append(settings, adoptionCandidate{name: *setting.Name, id: to.String(setting.ID), key: settingsAdoptionKey(*setting),
+				rename: func(name, id string) { setting.Name, setting.ID = to.StringPtr(name), to.StringPtr(id) }})
+		}
+	}
+	var existingSettings []adoptionCandidate
+	for _, setting := range existing.HTTPSettings {
+		existingSettings = append(existingSettings, adoptionCandidate{name: *setting.Name, id: to.String(setting.ID), key: settingsAdoptionKey(setting)})
+	}
+	adopted = append(adopted, adopt(settings, existingSettings, renamedIDs)...)
+
+	// Pools are matched by the listeners and paths they serve, which, unlike their addresses, outlive the pods.
+	var generatedListeners []n.ApplicationGatewayHTTPListener
+	if c.appGw.HTTPListeners != nil {
+		generatedListeners = *c.appGw.HTTPListeners
+	}
+	var generatedRules []n.ApplicationGatewayRequestRoutingRule
+	if c.appGw.RequestRoutingRules != nil {
+		generatedRules = *c.appGw.RequestRoutingRules
+	}
+	var generatedPathMaps []n.ApplicationGatewayURLPathMap
+	if c.appGw.URLPathMaps != nil {
+		generatedPathMaps = *c.appGw.URLPathMaps
+	}
+	generatedPoolKeys := poolAdoptionKeys(generatedListeners, generatedRules, generatedPathMaps)
+	existingPoolKeys := poolAdoptionKeys(existing.Listeners, existing.RoutingRules, existing.URLPathMaps)
+
+	var pools []adoptionCandidate
+	if c.appGw.BackendAddressPools != nil {
+		for idx := range *c.appGw.BackendAddressPools {
+			pool := &(*c.appGw.BackendAddressPools)[idx]
+			pools = append(pools, adoptionCandidate{name: *pool.Name, id: to.String(pool.ID), key: generatedPoolKeys[strings.ToLower(to.String(pool.ID))],
+				rename: func(name, id string) { pool.Name, pool.ID = to.StringPtr(name), to.StringPtr(id) }})
+		}
+	}
+	var existingPools []adoptionCandidate
+	for _, pool := range existing.BackendPools {
+		existingPools = append(existingPools, adoptionCandidate{name: *pool.Name, id: to.String(pool.ID), key: existingPoolKeys[strings.ToLower(to.String(pool.ID))]})
+	}
+	adopted = append(adopted, adopt(pools, existingPools, renamedIDs)...)
+
+	var listeners []adoptionCandidate
+	if c.appGw.HTTPListeners != nil {
+		for idx := range *c.appGw.HTTPListeners {
+			listener := &(*c.appGw.HTTPListeners)[idx]
+			listeners = append(listeners, adoptionCandidate{name: *listener.Name, id: to.String(listener.ID), key: listenerAdoptionKey(*listener),
+				rename: func(name, id string) { listener.Name, listener.ID = to.StringPtr(name), to.StringPtr(id) }})
+		}
+	}
+	var existingListeners []adoptionCandidate
+	for _, listener := range existing.Listeners {
+		existingListeners = append(existingListeners, adoptionCandidate{name: *listener.Name, id: to.String(listener.ID), key: listenerAdoptionKey(listener)})
+	}
+	adopted = append(adopted, adopt(listeners, existingListeners, renamedIDs)...)
+
+	// Routing rules, and the path maps they refer to, are matched by the listener of the rule.
+	c.replaceRenamedReferences(renamedIDs)
+
+	var rules []adoptionCandidate
+	generatedPathMapKeys := make(map[string]string)
+	if c.appGw.RequestRoutingRules != nil {
+		for idx := range *c.appGw.RequestRoutingRules {
+			rule := &(*c.appGw.RequestRoutingRules)[idx]
+			key := ruleAdoptionKey(*rule)
+			rules = append(rules, adoptionCandidate{name: *rule.Name, id: to.String(rule.ID), key: key,
+				rename: func(name, id string) { rule.Name, rule.ID = to.StringPtr(name), to.StringPtr(id) }})
+			if rule.URLPathMap != nil && rule.URLPathMap.ID != nil {
+				generatedPathMapKeys[*rule.URLPathMap.ID] = key
+			}
+		}
+	}
+	var existingRules []adoptionCandidate
+	existingPathMapKeys := make(map[string]string)
+	for _, rule := range existing.RoutingRules {
+		key := ruleAdoptionKey(rule)
+		existingRules = append(existingRules, adoptionCandidate{name: *rule.Name, id: to.String(rule.ID), key: key})
+		if rule.URLPathMap != nil && rule.URLPathMap.ID != nil {
+			existingPathMapKeys[*rule.URLPathMap.ID] = key
+		}
+	}
+	adopted = append(adopted, adopt(rules, existingRules, renamedIDs)...)
+
+	var pathMaps []adoptionCandidate
+	if c.appGw.URLPathMaps != nil {
+		for idx := range *c.appGw.URLPathMaps {
+			pathMap := &(*c.appGw.URLPathMaps)[idx]
+			pathMaps = append(pathMaps, adoptionCandidate{name: *pathMap.Name, id: to.String(pathMap.ID), key: generatedPathMapKeys[to.String(pathMap.ID)],
+				rename: func(name, id string) { pathMap.Name, pathMap.ID = to.StringPtr(name), to.StringPtr(id) }})
+		}
+	}
+	var existingPathMaps []adoptionCandidate
+	for _, pathMap := range existing.URLPathMaps {
+		existingPathMaps = append(existingPathMaps, adoptionCandidate{name: *pathMap.Name, id: to.String(pathMap.ID), key: existingPathMapKeys[to.String(pathMap.ID)]})
+	}
+	adopted = append(adopted, adopt(pathMaps, existingPathMaps, renamedIDs)...)
+
+	c.replaceRenamedReferences(renamedIDs)
+
+	if len(adopted) == 0 {
+		return
+	}
+
+	// The adopted objects are sorted by their new names, as the generated ones are.
+	if c.appGw.Probes != nil {
+		sort.Sort(sorter.ByHealthProbeName(*c.appGw.Probes))
+	}
+	if c.appGw.BackendHTTPSettingsCollection != nil {
+		sort.Sort(sorter.BySettingsName(*c.appGw.BackendHTTPSettingsCollection))
+	}
+	if c.appGw.BackendAddressPools != nil {
+		sort.Sort(sorter.ByBackendPoolName(*c.appGw.BackendAddressPools))
+	}
+	if c.appGw.HTTPListeners != nil {
+		sort.Sort(sorter.ByListenerName(*c.appGw.HTTPListeners))
+	}
+	if c.appGw.RequestRoutingRules != nil {
+		sort.Sort(sorter.ByRequestRoutingRuleName(*c.appGw.RequestRoutingRules))
+		c.sortBasicListenerRulesLast(*c.appGw.RequestRoutingRules)
+	}
+	if c.appGw.URLPathMaps != nil {
+		sort.Sort(sorter.ByPathMap(*c.appGw.URLPathMaps))
+	}
+
+	glog.V(3).Infof("[adoption] Adopted objects of the existing App Gateway config: %s", strings.Join(adopted, ", "))
+}
+
+// adopt renames each generated object, which is not on the App Gateway under its own name, after the first existing
+// object of the same key, which no generated object is named after. It returns the names of the adopted objects.
+func adopt(generated, existing []adoptionCandidate, renamedIDs map[string]string) []string {
+	generatedNames := make(map[string]interface{})
+	for _, candidate := range generated {
+		generatedNames[candidate.name] = nil
+	}
+
+	sort.Slice(existing, func(i, j int) bool { return existing[i].name < existing[j].name })
+	existingNames := make(map[string]interface{})
+	existingByKey := make(map[string][]adoptionCandidate)
+	for _, candidate := range existing {
+		existingNames[candidate.name] = nil
+		if _, exists := generatedNames[candidate.name]; exists || candidate.key == "" {
+			continue
+		}
+		existingByKey[candidate.key] = append(existingByKey[candidate.key], candidate)
+	}
+
+	sort.Slice(generated, func(i, j int) bool { return generated[i].name < generated[j].name })
+	var adopted []string
+	for _, candidate := range generated {
+		if _, exists := existingNames[candidate.name]; exists || candidate.key == "" {
+			continue
+		}
+		// The default objects of AGIC are looked up by their names.
+		if strings.HasPrefix(candidate.name, agPrefix+"default") {
+			continue
+		}
+		matches := existingByKey[candidate.key]
+		if len(matches) == 0 {
+			continue
+		}
+		match := matches[0]
+		existingByKey[candidate.key] = matches[1:]
+		candidate.rename(match.name, match.id)
+		if candidate.id != "" {
+			renamedIDs[candidate.id] = match.id
+		}
+		adopted = append(adopted, match.name)
+	}
+	return adopted
+}
+
+// replaceRenamedReferences points the references to the renamed objects to their new IDs.
+func (c *appGwConfigBuilder) replaceRenamedReferences(renamedIDs map[string]string) {
+	replace := func(ref *n.SubResource) {
+		if ref == nil || ref.ID == nil {
+			return
+		}
+		if id, exists := renamedIDs[*ref.ID]; exists {
+			ref.ID = to.StringPtr(id)
+		}
+	}
+
+	if c.appGw.BackendHTTPSettingsCollection != nil {
+		for idx := range *c.appGw.BackendHTTPSettingsCollection {
+			replace((*c.appGw.BackendHTTPSettingsCollection)[idx].Probe)
+		}
+	}
+	if c.appGw.RequestRoutingRules != nil {
+		for idx := range *c.appGw.RequestRoutingRules {
+			rule := &(*c.appGw.RequestRoutingRules)[idx]
+			replace(rule.HTTPListener)
+			replace(rule.BackendAddressPool)
+			replace(rule.BackendHTTPSettings)
+			replace(rule.URLPathMap)
+		}
+	}
+	if c.appGw.URLPathMaps != nil {
+		for idx := range *c.appGw.URLPathMaps {
+			pathMap := &(*c.appGw.URLPathMaps)[idx]
+			replace(pathMap.DefaultBackendAddressPool)
+			replace(pathMap.DefaultBackendHTTPSettings)
+			if pathMap.PathRules == nil {
+				continue
+			}
+			for ruleIdx := range *pathMap.PathRules {
+				pathRule := &(*pathMap.PathRules)[ruleIdx]
+				replace(pathRule.BackendAddressPool)
+				replace(pathRule.BackendHTTPSettings)
+			}
+		}
+	}
+	if c.appGw.RedirectConfigurations != nil {
+		for idx := range *c.appGw.RedirectConfigurations {
+			replace((*c.appGw.RedirectConfigurations)[idx].TargetListener)
+		}
+	}
+}
+
+func probeAdoptionKey(probe n.ApplicationGatewayProbe) string {
+	if probe.ApplicationGatewayProbePropertiesFormat == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s|%s|%s|%d|%t", probe.Protocol, strings.ToLower(to.String(probe.Host)), to.String(probe.Path),
+		to.Int32(probe.Port), to.Bool(probe.PickHostNameFromBackendHTTPSettings))
+}
+
+func settingsAdoptionKey(setting n.ApplicationGatewayBackendHTTPSettings) string {
+	if setting.ApplicationGatewayBackendHTTPSettingsPropertiesFormat == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s|%d|%s|%s|%t", setting.Protocol, to.Int32(setting.Port), strings.ToLower(to.String(setting.HostName)),
+		to.String(setting.Path), to.Bool(setting.PickHostNameFromBackendAddress))
+}
+
+// poolAdoptionKeys maps the lowercase ID of each pool to its key: the listeners and paths of the routing rules and the
+// path rules, which send traffic to the pool. Pools no rule refers to can not be told apart, and are not adopted.
+func poolAdoptionKeys(listeners []n.ApplicationGatewayHTTPListener, rules []n.ApplicationGatewayRequestRoutingRule, pathMaps []n.ApplicationGatewayURLPathMap) map[string]string {
+	listenerKeys := make(map[string]string)
+	for _, listener := range listeners {
+		listenerKeys[strings.ToLower(to.String(listener.ID))] = listenerAdoptionKey(listener)
+	}
+	pathMapsByID := make(map[string]n.ApplicationGatewayURLPathMap)
+	for _, pathMap := range pathMaps {
+		pathMapsByID[strings.ToLower(to.String(pathMap.ID))] = pathMap
+	}
+
+	references := make(map[string][]string)
+	refer := func(pool *n.SubResource, reference string) {
+		if pool == nil || pool.ID == nil {
+			return
+		}
+		poolID := strings.ToLower(*pool.ID)
+		references[poolID] = append(references[poolID], reference)
+	}
+	for _, rule := range rules {
+		if rule.ApplicationGatewayRequestRoutingRulePropertiesFormat == nil || rule.HTTPListener == nil {
+			continue
+		}
+		listenerKey, exists := listenerKeys[strings.ToLower(to.String(rule.HTTPListener.ID))]
+		if !exists {
+			continue
+		}
+		refer(rule.BackendAddressPool, listenerKey)
+		if rule.URLPathMap == nil {
+			continue
+		}
+		pathMap, exists := pathMapsByID[strings.ToLower(to.String(rule.URLPathMap.ID))]
+		if !exists || pathMap.ApplicationGatewayURLPathMapPropertiesFormat == nil {
+			continue
+		}
+		refer(pathMap.DefaultBackendAddressPool, listenerKey)
+		if pathMap.PathRules == nil {
+			continue
+		}
+		for _, pathRule := range *pathMap.PathRules {
+			if pathRule.ApplicationGatewayPathRulePropertiesFormat == nil || pathRule.Paths == nil {
+				continue
+			}
+			paths := append([]string(nil), *pathRule.Paths...)
+			sort.Strings(paths)
+			refer(pathRule.BackendAddressPool, listenerKey+"|"+strings.Join(paths, ","))
+		}
+	}
+
+	keys := make(map[string]string)
+	for poolID, poolReferences := range references {
+		sort.Strings(poolReferences)
+		keys[poolID] = strings.Join(poolReferences, ";")
+	}
+	return keys
+}
+
+func listenerAdoptionKey(listener n.ApplicationGatewayHTTPListener) string {
+	if listener.ApplicationGatewayHTTPListenerPropertiesFormat == nil {
+		return ""
+	}
+	var hosts []string
+	if listener.HostName != nil {
+		hosts = append(hosts, strings.ToLower(*listener.HostName))
+	}
+	if listener.Hostnames != nil {
+		for _, host := range *listener.Hostnames {
+			hosts = append(hosts, strings.ToLower(host))
+		}
+	}
+	sort.Strings(hosts)
+	var frontendIP, frontendPort string
+	if listener.FrontendIPConfiguration != nil {
+		frontendIP = strings.ToLower(to.String(listener.FrontendIPConfiguration.ID))
+	}
+	if listener.FrontendPort != nil {
+		frontendPort = strings.ToLower(to.String(listener.FrontendPort.ID))
+	}
+	return fmt.Sprintf("%s|%s|%s|%s", frontendIP, frontendPort, listener.Protocol, strings.Join(hosts, ","))
+}
+
+func ruleAdoptionKey(rule n.ApplicationGatewayRequestRoutingRule) string {
+	if rule.ApplicationGatewayRequestRoutingRulePropertiesFormat == nil || rule.HTTPListener == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s|%s", rule.RuleType, strings.ToLower(to.String(rule.HTTPListener.ID)))
+}
@@ -0,0 +1,214 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"fmt"
+	"strings"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/brownfield"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
+)
+
+var _ = Describe("adoption of the objects of a hand-built App Gateway", func() {
+	// The IP of the pod behind the service of the ingress; a rollout of the pods changes it.
+	podIP := "10.9.8.7"
+
+	BeforeEach(func() {
+		podIP = "10.9.8.7"
+	})
+
+	// Builds the config of an ingress with a rule per host on top of the given App Gateway.
+	build := func(appGw n.ApplicationGatewayPropertiesFormat, adoptExistingConfig bool, hosts ...string) (*appGwConfigBuilder, n.ApplicationGatewayPropertiesFormat) {
+		cb := newConfigBuilderFixture(nil)
+		cb.appGw.ApplicationGatewayPropertiesFormat = &appGw
+
+		endpoint := tests.NewEndpointsFixture()
+		endpoint.Subsets[0].Addresses[0].IP = podIP
+		service := tests.NewServiceFixture(*tests.NewServicePortsFixture()...)
+		ingress := tests.NewIngressFixture()
+		ingress.Annotations[annotations.SslRedirectKey] = "false"
+		ingress.Spec.TLS = nil
+		rule := ingress.Spec.Rules[0]
+		ingress.Spec.Rules = nil
+		for _, host := range hosts {
+			hostRule := *rule.DeepCopy()
+			hostRule.Host = host
+			ingress.Spec.Rules = append(ingress.Spec.Rules, hostRule)
+		}
+		_ = cb.k8sContext.Caches.Endpoints.Add(endpoint)
+		_ = cb.k8sContext.Caches.Service.Add(service)
+		_ = cb.k8sContext.Caches.Ingress.Add(ingress)
+
+		env := environment.GetFakeEnv()
+		env.AdoptExistingConfig = adoptExistingConfig
+		cbCtx := &ConfigBuilderContext{
+			IngressList:           []*v1beta1.Ingress{ingress},
+			ServiceList:           []*v1.Service{service},
+			EnvVariables:          env,
+			DefaultAddressPoolID:  to.StringPtr(cb.appGwIdentifier.AddressPoolID(DefaultBackendAddressPoolName)),
+			DefaultHTTPSettingsID: to.StringPtr(cb.appGwIdentifier.HTTPSettingsID(DefaultBackendHTTPSettingsName)),
+			ExistingPortsByNumber: make(map[Port]n.ApplicationGatewayFrontendPort),
+		}
+		if appGw.FrontendPorts != nil {
+			for _, port := range *appGw.FrontendPorts {
+				cbCtx.ExistingPortsByNumber[Port(*port.Port)] = port
+			}
+		}
+
+		// Same steps as Build(), which also tags the gateway and requires a Kubernetes client.
+		existing := brownfield.NewExistingResources(cb.appGw, nil, nil)
+		Expect(cb.HealthProbesCollection(cbCtx)).ToNot(HaveOccurred())
+		Expect(cb.BackendHTTPSettingsCollection(cbCtx)).ToNot(HaveOccurred())
+		Expect(cb.BackendAddressPools(cbCtx)).ToNot(HaveOccurred())
+		Expect(cb.Listeners(cbCtx)).ToNot(HaveOccurred())
+		Expect(cb.RequestRoutingRules(cbCtx)).ToNot(HaveOccurred())
+		if adoptExistingConfig {
+			cb.adoptExistingResources(existing)
+		}
+		return &cb, *cb.appGw.ApplicationGatewayPropertiesFormat
+	}
+
+	// handBuilt renames the objects AGIC generated for the hosts, as if they had been created by hand.
+	handBuilt := func(hosts ...string) n.ApplicationGatewayPropertiesFormat {
+		cb, appGw := build(*NewAppGwyConfigFixture(), false, hosts...)
+		renamedIDs := make(map[string]string)
+		rename := func(kind string, idx int, name, id **string) {
+			if strings.HasPrefix(**name, "defaultprobe-") {
+				return
+			}
+			handName := fmt.Sprintf("hand-%s-%d", kind, idx)
+			handID := strings.TrimSuffix(**id, **name) + handName
+			renamedIDs[**id] = handID
+			*name, *id = to.StringPtr(handName), to.StringPtr(handID)
+		}
+		for idx := range *appGw.Probes {
+			rename("probe", idx, &(*appGw.Probes)[idx].Name, &(*appGw.Probes)[idx].ID)
+		}
+		for idx := range *appGw.BackendAddressPools {
+			if *(*appGw.BackendAddressPools)[idx].Name != DefaultBackendAddressPoolName {
+				rename("pool", idx, &(*appGw.BackendAddressPools)[idx].Name, &(*appGw.BackendAddressPools)[idx].ID)
+			}
+		}
+		for idx := range *appGw.BackendHTTPSettingsCollection {
+			if *(*appGw.BackendHTTPSettingsCollection)[idx].Name != DefaultBackendHTTPSettingsName {
+				rename("settings", idx, &(*appGw.BackendHTTPSettingsCollection)[idx].Name, &(*appGw.BackendHTTPSettingsCollection)[idx].ID)
+			}
+		}
+		for idx := range *appGw.HTTPListeners {
+			rename("listener", idx, &(*appGw.HTTPListeners)[idx].Name, &(*appGw.HTTPListeners)[idx].ID)
+		}
+		for idx := range *appGw.RequestRoutingRules {
+			rename("rule", idx, &(*appGw.RequestRoutingRules)[idx].Name, &(*appGw.RequestRoutingRules)[idx].ID)
+		}
+		for idx := range *appGw.URLPathMaps {
+			rename("pathmap", idx, &(*appGw.URLPathMaps)[idx].Name, &(*appGw.URLPathMaps)[idx].ID)
+		}
+		cb.replaceRenamedReferences(renamedIDs)
+		return appGw
+	}
+
+	namesOf := func(appGw n.ApplicationGatewayPropertiesFormat) []string {
+		var names []string
+		for _, listener := range *appGw.HTTPListeners {
+			names = append(names, *listener.Name)
+		}
+		for _, rule := range *appGw.RequestRoutingRules {
+			names = append(names, *rule.Name)
+		}
+		for _, pool := range *appGw.BackendAddressPools {
+			names = append(names, *pool.Name)
+		}
+		for _, settings := range *appGw.BackendHTTPSettingsCollection {
+			names = append(names, *settings.Name)
+		}
+		for _, probe := range *appGw.Probes {
+			names = append(names, *probe.Name)
+		}
+		for _, pathMap := range *appGw.URLPathMaps {
+			names = append(names, *pathMap.Name)
+		}
+		return names
+	}
+
+	It("keeps the names of the matching objects of the App Gateway", func() {
+		existing := handBuilt("a.contoso.com")
+		_, appGw := build(existing, true, "a.contoso.com")
+
+		Expect(namesOf(appGw)).To(ConsistOf(namesOf(existing)))
+		rule := (*appGw.RequestRoutingRules)[0]
+		Expect(*rule.HTTPListener.ID).To(HaveSuffix("/httpListeners/hand-listener-0"))
+		Expect(*rule.URLPathMap.ID).To(HaveSuffix("/urlPathMaps/hand-pathmap-0"))
+		pathRule := (*(*appGw.URLPathMaps)[0].PathRules)[0]
+		Expect(*pathRule.BackendAddressPool.ID).To(ContainSubstring("/backendAddressPools/hand-pool-"))
+		Expect(*pathRule.BackendHTTPSettings.ID).To(ContainSubstring("/backendHttpSettingsCollection/hand-settings-"))
+		for _, settings := range *appGw.BackendHTTPSettingsCollection {
+			if *settings.Name != DefaultBackendHTTPSettingsName {
+				Expect(*settings.Probe.ID).To(ContainSubstring("/probes/hand-probe-"))
+			}
+		}
+	})
+
+	It("creates the objects without a match under the names AGIC generates", func() {
+		existing := handBuilt("a.contoso.com")
+		_, appGw := build(existing, true, "a.contoso.com", "b.contoso.com")
+
+		var listeners []string
+		for _, listener := range *appGw.HTTPListeners {
+			listeners = append(listeners, *listener.Name)
+		}
+		Expect(listeners).To(HaveLen(2))
+		Expect(listeners).To(ContainElement("hand-listener-0"))
+		Expect(listeners).To(ContainElement(HavePrefix(agPrefix + prefixListener + "-")))
+		Expect(len(*appGw.RequestRoutingRules)).To(Equal(2))
+		Expect(namesOf(appGw)).To(ContainElement("hand-rule-0"))
+	})
+
+	It("replaces the objects of the App Gateway without adoption", func() {
+		existing := handBuilt("a.contoso.com")
+		_, appGw := build(existing, false, "a.contoso.com")
+		for _, name := range namesOf(appGw) {
+			Expect(name).ToNot(HavePrefix("hand-"))
+		}
+	})
+
+	It("adopts again on the next build", func() {
+		existing := handBuilt("a.contoso.com")
+		_, appGw := build(existing, true, "a.contoso.com")
+		_, appGw2 := build(appGw, true, "a.contoso.com")
+		Expect(appGw2).To(Equal(appGw))
+	})
+
+	It("keeps the adopted pools when the pods are replaced", func() {
+		existing := handBuilt("a.contoso.com")
+		_, appGw := build(existing, true, "a.contoso.com")
+
+		podIP = "10.9.8.6"
+		_, appGw2 := build(appGw, true, "a.contoso.com")
+
+		Expect(namesOf(appGw2)).To(ConsistOf(namesOf(existing)))
+		var addresses []string
+		for _, pool := range *appGw2.BackendAddressPools {
+			if *pool.Name == DefaultBackendAddressPoolName {
+				continue
+			}
+			Expect(*pool.Name).To(HavePrefix("hand-pool-"))
+			for _, address := range *pool.BackendAddresses {
+				addresses = append(addresses, *address.IPAddress)
+			}
+		}
+		Expect(addresses).To(ConsistOf("10.9.8.6"))
+	})
+})
@@ -395,8 +395,8 @@ var _ = Describe("Tests `appgw.ConfigBuilder`", func() {
 		for {
 			select {
 			case event := <-ctxt.Work:
-				// Check if we got an event of type secret.
-				if _, ok := event.Value.(*v1beta1.Ingress); ok {
+				// Check if we got an event of type ingress, or the event enqueued once the caches are synced.
+				if _, ok := event.Value.(*v1beta1.Ingress); ok || event.Value == nil {
 					return
 				}
 			}
@@ -443,7 +443,7 @@ var _ = Describe("Tests `appgw.ConfigBuilder`", func() {
 
 		// Create a `k8scontext` to start listiening to ingress resources.
 
-		ctxt = k8scontext.NewContext(k8sClient, crdClient, istioCrdClient, []string{ingressNS}, 1000*time.Second, metricstore.NewFakeMetricStore())
+		ctxt = k8scontext.NewContext(k8sClient, crdClient, istioCrdClient, []string{ingressNS}, 1000*time.Second, k8scontext.DefaultWorkQueueDepth, metricstore.NewFakeMetricStore())
 		Expect(ctxt).ShouldNot(BeNil(), "Unable to create `k8scontext`")
 
 		// Initialize the `ConfigBuilder`
@@ -0,0 +1,213 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/golang/glog"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+)
+
+// maxAutoscaleCapacity is the largest maximum capacity App Gateway v2 accepts.
+const maxAutoscaleCapacity = 125
+
+// AutoscaleSchedule sets the minimum and maximum capacity of an autoscaling App Gateway by the time of day, following
+// the windows of APPGW_AUTOSCALE_SCHEDULE. The capacity is changed at most once per cooldown; a change due within the
+// cooldown is made once it ends. Outside of the windows, the capacity is left as it is.
+// AutoscaleSchedule outlives the config builder; it is shared by consecutive builds.
+type AutoscaleSchedule struct {
+	sync.Mutex
+	changedAt   time.Time
+	changingAt  time.Time
+	nextAt      time.Time
+	reconcileAt time.Time
+}
+
+// autoscaleWindow is the capacity of App Gateway from one minute of the day (UTC) until another.
+type autoscaleWindow struct {
+	from, until time.Duration
+	min, max    int32
+}
+
+// NewAutoscaleSchedule creates a new AutoscaleSchedule struct.
+func NewAutoscaleSchedule() *AutoscaleSchedule {
+	return &AutoscaleSchedule{}
+}
+
+// GetAutoscaleCooldown returns the cooldown set with APPGW_AUTOSCALE_COOLDOWN_SECONDS, or 0 when it is not set.
+func GetAutoscaleCooldown(env environment.EnvVariables) time.Duration {
+	if env.AutoscaleCooldown == "" {
+		return 0
+	}
+	seconds, err := strconv.Atoi(env.AutoscaleCooldown)
+	if err != nil {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// parseAutoscaleSchedule parses the comma separated windows "HH:MM-HH:MM=MIN-MAX"; a window ending before it starts
+// ends on the next day.
+func parseAutoscaleSchedule(schedule string) ([]autoscaleWindow, error) {
+	var windows []autoscaleWindow
+	for _, entry := range strings.Split(schedule, ",") {
+		entry = strings.TrimSpace(entry)
+		times, capacity := splitPair(entry, "=")
+		from, until := splitPair(times, "-")
+		minimum, maximum := splitPair(capacity, "-")
+
+		var window autoscaleWindow
+		var err error
+		if window.from, err = parseTimeOfDay(from); err != nil {
+			return nil, fmt.Errorf("window %q: %s", entry, err)
+		}
+		if window.until, err = parseTimeOfDay(until); err != nil {
+			return nil, fmt.Errorf("window %q: %s", entry, err)
+		}
+		min, minErr := strconv.Atoi(minimum)
+		max, maxErr := strconv.Atoi(maximum)
+		if minErr != nil || maxErr != nil || min < 0 || max < 2 || max > maxAutoscaleCapacity || min > max {
+			return nil, fmt.Errorf("window %q: capacity must be MIN-MAX with 0 <= MIN <= MAX, 2 <= MAX <= %d", entry, maxAutoscaleCapacity)
+		}
+		window.min, window.max = int32(min), int32(max)
+		windows = append(windows, window)
+	}
+	return windows, nil
+}
+
+// splitPair splits the string in two around the first separator.
+func splitPair(s, separator string) (string, string) {
+	parts := strings.SplitN(s, separator, 2)
+	if len(parts) != 2 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
+
+// parseTimeOfDay parses "HH:MM", from "00:00" to "24:00", into the time since midnight.
+func parseTimeOfDay(s string) (time.Duration, error) {
+	hours, minutes := splitPair(s, ":")
+	h, hErr := strconv.Atoi(hours)
+	m, mErr := strconv.Atoi(minutes)
+	if hErr != nil || mErr != nil || h < 0 || m < 0 || m > 59 || h*60+m > 24*60 {
+		return 0, fmt.Errorf("%q is not a time of day HH:MM", s)
+	}
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute, nil
+}
+
+// contains determines whether the time of day falls within the window.
+func (w autoscaleWindow) contains(timeOfDay time.Duration) bool {
+	if w.from <= w.until {
+		return w.from <= timeOfDay && timeOfDay < w.until
+	}
+	return w.from <= timeOfDay || timeOfDay < w.until
+}
+
+// capacityAt returns the capacity of the first window the time falls within, and when any window starts or ends next.
+func capacityAt(windows []autoscaleWindow, now time.Time) (*autoscaleWindow, time.Time) {
+	now = now.UTC()
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	timeOfDay := now.Sub(midnight)
+
+	var current *autoscaleWindow
+	var next time.Time
+	for i := range windows {
+		if current == nil && windows[i].contains(timeOfDay) {
+			current = &windows[i]
+		}
+		for _, boundary := range []time.Duration{windows[i].from, windows[i].until} {
+			at := midnight.Add(boundary)
+			if !at.After(now) {
+				at = at.Add(24 * time.Hour)
+			}
+			if next.IsZero() || at.Before(next) {
+				next = at
+			}
+		}
+	}
+	return current, next
+}
+
+// applyAutoscaleSchedule sets the minimum and maximum capacity of App Gateway to those of the current window of the
+// schedule. App Gateways with a fixed capacity are left untouched.
+func (c *appGwConfigBuilder) applyAutoscaleSchedule(cbCtx *ConfigBuilderContext) {
+	schedule := cbCtx.AutoscaleSchedule
+	if schedule == nil || cbCtx.EnvVariables.AutoscaleSchedule == "" {
+		return
+	}
+	schedule.Lock()
+	defer schedule.Unlock()
+	schedule.changingAt = time.Time{}
+
+	windows, err := parseAutoscaleSchedule(cbCtx.EnvVariables.AutoscaleSchedule)
+	if err != nil {
+		glog.Errorf("Autoscale schedule %q is not applied: %s", cbCtx.EnvVariables.AutoscaleSchedule, err)
+		return
+	}
+	if c.appGw.AutoscaleConfiguration == nil {
+		glog.Warningf("Autoscale schedule is not applied; App Gateway %s does not autoscale", to.String(c.appGw.Name))
+		return
+	}
+
+	now := c.clock.Now()
+	window, next := capacityAt(windows, now)
+	schedule.nextAt = next
+	if window == nil {
+		return
+	}
+
+	current := c.appGw.AutoscaleConfiguration
+	if current.MinCapacity != nil && *current.MinCapacity == window.min && current.MaxCapacity != nil && *current.MaxCapacity == window.max {
+		return
+	}
+	if cooldownEnd := schedule.changedAt.Add(GetAutoscaleCooldown(cbCtx.EnvVariables)); !schedule.changedAt.IsZero() && now.Before(cooldownEnd) {
+		glog.V(3).Infof("Autoscale capacity %d-%d is applied once the cooldown ends at %s", window.min, window.max, cooldownEnd)
+		if cooldownEnd.Before(schedule.nextAt) {
+			schedule.nextAt = cooldownEnd
+		}
+		return
+	}
+
+	glog.Infof("Autoscale capacity of App Gateway %s changes from %d-%d to %d-%d", to.String(c.appGw.Name),
+		to.Int32(current.MinCapacity), to.Int32(current.MaxCapacity), window.min, window.max)
+	c.appGw.AutoscaleConfiguration = &n.ApplicationGatewayAutoscaleConfiguration{
+		MinCapacity: to.Int32Ptr(window.min),
+		MaxCapacity: to.Int32Ptr(window.max),
+	}
+	schedule.changingAt = now
+}
+
+// Applied records that the config of the last build was applied to App Gateway, which starts the cooldown of the
+// capacity change it made, if any.
+func (s *AutoscaleSchedule) Applied() {
+	s.Lock()
+	defer s.Unlock()
+	if !s.changingAt.IsZero() {
+		s.changedAt = s.changingAt
+		s.changingAt = time.Time{}
+	}
+}
+
+// PendingReconcile returns the delay until a window of the schedule starts or ends, or the cooldown of a capacity
+// change due ends, unless a reconcile was already requested for it. The caller is expected to reconcile after the
+// delay, so that the capacity follows the schedule even if nothing else changes.
+func (s *AutoscaleSchedule) PendingReconcile(now time.Time) (time.Duration, bool) {
+	s.Lock()
+	defer s.Unlock()
+	if s.nextAt.IsZero() || s.nextAt.Equal(s.reconcileAt) {
+		return 0, false
+	}
+	s.reconcileAt = s.nextAt
+	return s.nextAt.Sub(now), true
+}
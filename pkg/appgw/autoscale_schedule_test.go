@@ -0,0 +1,146 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"time"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+)
+
+var _ = Describe("Test the autoscale schedule of App Gateway", func() {
+	const cooldown = 15 * time.Minute
+
+	var schedule *AutoscaleSchedule
+	var clock *fakeClock
+	var capacity *n.ApplicationGatewayAutoscaleConfiguration
+
+	at := func(hour, minute int) time.Time {
+		return time.Date(2020, 1, 1, hour, minute, 0, 0, time.UTC)
+	}
+
+	// build applies the schedule to an App Gateway with the current capacity, which becomes the resulting capacity.
+	build := func(windows string) {
+		cb := newConfigBuilderFixture(nil)
+		cb.clock = clock
+		cb.appGw.AutoscaleConfiguration = capacity
+		env := environment.GetFakeEnv()
+		env.AutoscaleSchedule = windows
+		env.AutoscaleCooldown = "900"
+		cb.applyAutoscaleSchedule(&ConfigBuilderContext{
+			EnvVariables:      env,
+			AutoscaleSchedule: schedule,
+		})
+		capacity = cb.appGw.AutoscaleConfiguration
+	}
+
+	autoscale := func(min, max int32) *n.ApplicationGatewayAutoscaleConfiguration {
+		return &n.ApplicationGatewayAutoscaleConfiguration{
+			MinCapacity: to.Int32Ptr(min),
+			MaxCapacity: to.Int32Ptr(max),
+		}
+	}
+
+	BeforeEach(func() {
+		schedule = NewAutoscaleSchedule()
+		clock = &fakeClock{now: at(9, 0)}
+		capacity = autoscale(0, 10)
+	})
+
+	It("sets the capacity of the current window", func() {
+		build("08:00-20:00=4-20,20:00-08:00=2-10")
+		Expect(capacity).To(Equal(autoscale(4, 20)))
+
+		clock.now = at(21, 0)
+		build("08:00-20:00=4-20,20:00-08:00=2-10")
+		Expect(capacity).To(Equal(autoscale(2, 10)))
+
+		clock.now = at(3, 0)
+		build("08:00-20:00=4-20,20:00-08:00=2-10")
+		Expect(capacity).To(Equal(autoscale(2, 10)), "the window ends on the next day")
+	})
+
+	It("leaves the capacity outside of the windows as it is", func() {
+		build("18:00-20:00=4-20")
+		Expect(capacity).To(Equal(autoscale(0, 10)))
+	})
+
+	It("changes the capacity at most once per cooldown", func() {
+		build("08:00-09:10=4-20,09:10-10:00=6-30")
+		Expect(capacity).To(Equal(autoscale(4, 20)))
+		schedule.Applied()
+
+		clock.now = at(9, 10)
+		build("08:00-09:10=4-20,09:10-10:00=6-30")
+		Expect(capacity).To(Equal(autoscale(4, 20)), "the change is due within the cooldown")
+		delay, pending := schedule.PendingReconcile(clock.now)
+		Expect(pending).To(BeTrue())
+		Expect(delay).To(Equal(5 * time.Minute))
+
+		clock.now = clock.now.Add(delay)
+		build("08:00-09:10=4-20,09:10-10:00=6-30")
+		Expect(capacity).To(Equal(autoscale(6, 30)))
+	})
+
+	It("starts the cooldown only once the capacity change is applied", func() {
+		build("08:00-09:10=4-20,09:10-10:00=6-30")
+		Expect(capacity).To(Equal(autoscale(4, 20)))
+
+		// The config was not applied to App Gateway, so the capacity it had remains and no cooldown has started.
+		capacity = autoscale(0, 10)
+		clock.now = at(9, 10)
+		build("08:00-09:10=4-20,09:10-10:00=6-30")
+		Expect(capacity).To(Equal(autoscale(6, 30)))
+		schedule.Applied()
+
+		clock.now = at(9, 20)
+		build("08:00-09:10=4-20,09:20-10:00=8-40")
+		Expect(capacity).To(Equal(autoscale(6, 30)), "the change is due within the cooldown")
+	})
+
+	It("requests a reconcile when the next window starts", func() {
+		build("08:00-20:00=4-20,20:00-08:00=2-10")
+		delay, pending := schedule.PendingReconcile(clock.now)
+		Expect(pending).To(BeTrue())
+		Expect(delay).To(Equal(11 * time.Hour))
+
+		_, pending = schedule.PendingReconcile(clock.now)
+		Expect(pending).To(BeFalse(), "the reconcile was already requested")
+	})
+
+	It("leaves App Gateways with a fixed capacity untouched", func() {
+		capacity = nil
+		build("08:00-20:00=4-20")
+		Expect(capacity).To(BeNil())
+		_, pending := schedule.PendingReconcile(clock.now)
+		Expect(pending).To(BeFalse())
+	})
+
+	It("rejects the schedules beyond the capacity of App Gateway", func() {
+		for _, windows := range []string{"08:00-20:00=4-200", "08:00-20:00=20-4", "08:00-20:00=0-1", "08:00-25:00=4-20", "08:60-20:00=4-20"} {
+			_, err := parseAutoscaleSchedule(windows)
+			Expect(err).To(HaveOccurred(), windows)
+			build(windows)
+			Expect(capacity).To(Equal(autoscale(0, 10)), windows)
+		}
+
+		windows, err := parseAutoscaleSchedule("00:00-24:00=0-125")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(windows).To(Equal([]autoscaleWindow{{from: 0, until: 24 * time.Hour, min: 0, max: 125}}))
+	})
+
+	It("reads the cooldown from the environment", func() {
+		env := environment.GetFakeEnv()
+		Expect(GetAutoscaleCooldown(env)).To(BeZero())
+		env.AutoscaleCooldown = "900"
+		Expect(GetAutoscaleCooldown(env)).To(Equal(cooldown))
+	})
+})
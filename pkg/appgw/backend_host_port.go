@@ -0,0 +1,166 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"fmt"
+	"sort"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/golang/glog"
+	"k8s.io/api/extensions/v1beta1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+)
+
+// rewriteHostVariable is the App Gateway server variable holding the host name of the request.
+const rewriteHostVariable = "{var_host}"
+
+// The rule sequence of the host header rewrite; it runs after the rules of an AzureApplicationGatewayRewrite.
+const rewriteHostPortRuleSequence = 1000
+
+// getBackendHostPort returns whether the ingress asks for the port of the backend to be appended to the host header.
+// Rewriting the host header requires a v2 App Gateway, and contradicts sending the host name of the backend address.
+func getBackendHostPort(ingress *v1beta1.Ingress, sku *n.ApplicationGatewaySku, settings *n.ApplicationGatewayBackendHTTPSettings) (bool, error) {
+	hostPort, err := annotations.BackendHostPort(ingress)
+	if annotations.IsMissingAnnotations(err) || (err == nil && !hostPort) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if sku == nil || (sku.Tier != n.ApplicationGatewayTierStandardV2 && sku.Tier != n.ApplicationGatewayTierWAFV2) {
+		return false, ErrBackendHostPortRequiresV2
+	}
+	if settings.PickHostNameFromBackendAddress != nil && *settings.PickHostNameFromBackendAddress {
+		return false, ErrBackendHostPortConflict
+	}
+	return true, nil
+}
+
+// getHostPortRewriteRuleSets generates a rewrite rule set appending the backend port to the host header for each port
+// of the backends of ingresses annotated with backend-host-port, and attaches it to the routing rules and path rules
+// of these backends only. The host is the backend-hostname of the ingress if set, or the host of the request.
+// A routing rule which already has a rewrite is left unchanged.
+func (c *appGwConfigBuilder) getHostPortRewriteRuleSets(cbCtx *ConfigBuilderContext) []n.ApplicationGatewayRewriteRuleSet {
+	_, settingsByBackend, _, _ := c.getBackendsAndSettingsMap(cbCtx)
+
+	ruleSetsByName := make(map[string]*n.ApplicationGatewayRewriteRuleSet)
+	ingressBySettingsID := make(map[string]*v1beta1.Ingress)
+	ruleSetBySettingsID := make(map[string]*n.ApplicationGatewayRewriteRuleSet)
+	reported := make(map[string]interface{})
+	report := func(ingress *v1beta1.Ingress, err error) {
+		key := fmt.Sprintf("%s/%s %s", ingress.Namespace, ingress.Name, err)
+		if _, exists := reported[key]; exists {
+			return
+		}
+		reported[key] = nil
+		glog.Errorf("[%s/%s] %s", ingress.Namespace, ingress.Name, err)
+		events.Warning(c.recorder, ingress, events.ReasonInvalidAnnotation, err.Error())
+	}
+
+	for backendID, settings := range settingsByBackend {
+		hostPort, err := getBackendHostPort(backendID.Ingress, c.appGw.Sku, settings)
+		if err != nil {
+			report(backendID.Ingress, err)
+		}
+		if !hostPort || settings.Port == nil {
+			continue
+		}
+
+		host := rewriteHostVariable
+		if settings.HostName != nil {
+			host = *settings.HostName
+		}
+		name := generateHostPortRewriteRuleSetName(backendID.Ingress.Namespace, backendID.Ingress.Name, *settings.Port)
+		if _, exists := ruleSetsByName[name]; !exists {
+			ruleSetsByName[name] = &n.ApplicationGatewayRewriteRuleSet{
+				Etag: to.StringPtr("*"),
+				Name: to.StringPtr(name),
+				ID:   to.StringPtr(c.appGwIdentifier.rewriteRuleSetID(name)),
+				ApplicationGatewayRewriteRuleSetPropertiesFormat: &n.ApplicationGatewayRewriteRuleSetPropertiesFormat{
+					RewriteRules: &[]n.ApplicationGatewayRewriteRule{
+						{
+							Name:         to.StringPtr("host-port"),
+							RuleSequence: to.Int32Ptr(rewriteHostPortRuleSequence),
+							Conditions:   &[]n.ApplicationGatewayRewriteRuleCondition{},
+							ActionSet: &n.ApplicationGatewayRewriteRuleActionSet{
+								RequestHeaderConfigurations: &[]n.ApplicationGatewayHeaderConfiguration{
+									{
+										HeaderName:  to.StringPtr("Host"),
+										HeaderValue: to.StringPtr(fmt.Sprintf("%s:%d", host, *settings.Port)),
+									},
+								},
+							},
+						},
+					},
+				},
+			}
+		}
+		ruleSetBySettingsID[*settings.ID] = ruleSetsByName[name]
+		ingressBySettingsID[*settings.ID] = backendID.Ingress
+	}
+	if len(ruleSetBySettingsID) == 0 {
+		return nil
+	}
+
+	attached := make(map[string]interface{})
+	attach := func(settings *n.SubResource, redirect *n.SubResource, ruleSet **n.SubResource) {
+		if settings == nil || settings.ID == nil || redirect != nil {
+			return
+		}
+		hostPortRuleSet, exists := ruleSetBySettingsID[*settings.ID]
+		if !exists {
+			return
+		}
+		if *ruleSet != nil {
+			report(ingressBySettingsID[*settings.ID], ErrBackendHostPortRewriteConflict)
+			return
+		}
+		*ruleSet = resourceRef(*hostPortRuleSet.ID)
+		attached[*hostPortRuleSet.Name] = nil
+	}
+
+	if c.appGw.RequestRoutingRules != nil {
+		for idx := range *c.appGw.RequestRoutingRules {
+			rule := &(*c.appGw.RequestRoutingRules)[idx]
+			if rule.ApplicationGatewayRequestRoutingRulePropertiesFormat != nil && rule.RuleType == n.Basic {
+				attach(rule.BackendHTTPSettings, rule.RedirectConfiguration, &rule.RewriteRuleSet)
+			}
+		}
+	}
+	if c.appGw.URLPathMaps != nil {
+		for idx := range *c.appGw.URLPathMaps {
+			pathMap := &(*c.appGw.URLPathMaps)[idx]
+			if pathMap.ApplicationGatewayURLPathMapPropertiesFormat == nil {
+				continue
+			}
+			attach(pathMap.DefaultBackendHTTPSettings, pathMap.DefaultRedirectConfiguration, &pathMap.DefaultRewriteRuleSet)
+			if pathMap.PathRules == nil {
+				continue
+			}
+			for ruleIdx := range *pathMap.PathRules {
+				pathRule := &(*pathMap.PathRules)[ruleIdx]
+				if pathRule.ApplicationGatewayPathRulePropertiesFormat != nil {
+					attach(pathRule.BackendHTTPSettings, pathRule.RedirectConfiguration, &pathRule.RewriteRuleSet)
+				}
+			}
+		}
+	}
+
+	var ruleSets []n.ApplicationGatewayRewriteRuleSet
+	for name, ruleSet := range ruleSetsByName {
+		if _, exists := attached[name]; exists {
+			ruleSets = append(ruleSets, *ruleSet)
+		}
+	}
+	sort.Slice(ruleSets, func(i, j int) bool {
+		return *ruleSets[i].Name < *ruleSets[j].Name
+	})
+	return ruleSets
+}
@@ -0,0 +1,194 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"fmt"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	rwv1 "github.com/Azure/application-gateway-kubernetes-ingress/pkg/apis/azureapplicationgatewayrewrite/v1"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
+)
+
+var _ = Describe("backend-host-port annotation", func() {
+	var configBuilder appGwConfigBuilder
+	var recorder *record.FakeRecorder
+	var ingress *v1beta1.Ingress
+	var cbCtx *ConfigBuilderContext
+
+	build := func() {
+		Expect(configBuilder.BackendHTTPSettingsCollection(cbCtx)).ToNot(HaveOccurred())
+		Expect(configBuilder.BackendAddressPools(cbCtx)).ToNot(HaveOccurred())
+		Expect(configBuilder.Listeners(cbCtx)).ToNot(HaveOccurred())
+		Expect(configBuilder.RequestRoutingRules(cbCtx)).ToNot(HaveOccurred())
+		configBuilder.RewriteRuleSets(cbCtx)
+	}
+
+	type hostRewrite struct {
+		backendPort int32
+		hostHeader  string
+	}
+
+	// rewrittenHostHeaders returns the host header each rewritten path rule sends to its backend, by path map and path rule name.
+	rewrittenHostHeaders := func() map[string]hostRewrite {
+		settingsPorts := make(map[string]int32)
+		for _, settings := range *configBuilder.appGw.BackendHTTPSettingsCollection {
+			settingsPorts[*settings.ID] = *settings.Port
+		}
+		hostHeaders := make(map[string]string)
+		if configBuilder.appGw.RewriteRuleSets != nil {
+			for _, ruleSet := range *configBuilder.appGw.RewriteRuleSets {
+				for _, rule := range *ruleSet.RewriteRules {
+					for _, header := range *rule.ActionSet.RequestHeaderConfigurations {
+						if *header.HeaderName == "Host" {
+							hostHeaders[*ruleSet.ID] = *header.HeaderValue
+						}
+					}
+				}
+			}
+		}
+
+		rewritten := make(map[string]hostRewrite)
+		for _, pathMap := range *configBuilder.appGw.URLPathMaps {
+			for _, pathRule := range *pathMap.PathRules {
+				if pathRule.RewriteRuleSet == nil {
+					continue
+				}
+				if _, exists := hostHeaders[*pathRule.RewriteRuleSet.ID]; exists {
+					rewritten[*pathMap.Name+"/"+*pathRule.Name] = hostRewrite{
+						backendPort: settingsPorts[*pathRule.BackendHTTPSettings.ID],
+						hostHeader:  hostHeaders[*pathRule.RewriteRuleSet.ID],
+					}
+				}
+			}
+		}
+		return rewritten
+	}
+
+	BeforeEach(func() {
+		configBuilder = newConfigBuilderFixture(nil)
+		recorder = record.NewFakeRecorder(100)
+		configBuilder.recorder = recorder
+
+		service := tests.NewServiceFixture(*tests.NewServicePortsFixture()...)
+		_ = configBuilder.k8sContext.Caches.Service.Add(service)
+		_ = configBuilder.k8sContext.Caches.Endpoints.Add(tests.NewEndpointsFixture())
+
+		ingress = tests.NewIngressFixture()
+		ingress.Spec.TLS = nil
+		delete(ingress.Annotations, annotations.SslRedirectKey)
+		ingress.Spec.Rules[1].Host = tests.OtherHost
+		ingress.Annotations[annotations.BackendHostPortKey] = "true"
+		_ = configBuilder.k8sContext.Caches.Ingress.Add(ingress)
+
+		cbCtx = &ConfigBuilderContext{
+			IngressList:           []*v1beta1.Ingress{ingress},
+			ServiceList:           []*v1.Service{service},
+			EnvVariables:          environment.GetFakeEnv(),
+			DefaultAddressPoolID:  to.StringPtr(configBuilder.appGwIdentifier.AddressPoolID(DefaultBackendAddressPoolName)),
+			DefaultHTTPSettingsID: to.StringPtr(configBuilder.appGwIdentifier.HTTPSettingsID(DefaultBackendHTTPSettingsName)),
+			ExistingPortsByNumber: make(map[Port]n.ApplicationGatewayFrontendPort),
+		}
+	})
+
+	It("appends the port of the backend to the host of the request", func() {
+		build()
+		rewritten := rewrittenHostHeaders()
+		Expect(rewritten).To(HaveLen(2))
+		for _, rewrite := range rewritten {
+			Expect(rewrite.hostHeader).To(Equal(fmt.Sprintf("{var_host}:%d", rewrite.backendPort)))
+		}
+
+		for _, ruleSet := range *configBuilder.appGw.RewriteRuleSets {
+			rules := *ruleSet.RewriteRules
+			Expect(rules).To(HaveLen(1))
+			Expect(*rules[0].RuleSequence).To(Equal(int32(1000)))
+			Expect(*rules[0].Conditions).To(BeEmpty())
+		}
+	})
+
+	It("rewrites only the rules of the backends of the ingress", func() {
+		build()
+		for _, pathMap := range *configBuilder.appGw.URLPathMaps {
+			// The default of the path maps is the default backend of AGIC.
+			Expect(*pathMap.DefaultBackendHTTPSettings.ID).To(Equal(*cbCtx.DefaultHTTPSettingsID))
+			Expect(pathMap.DefaultRewriteRuleSet).To(BeNil())
+		}
+	})
+
+	It("appends the port to the backend-hostname", func() {
+		ingress.Annotations[annotations.BackendHostNameKey] = "www.contoso.com"
+		build()
+		rewritten := rewrittenHostHeaders()
+		Expect(rewritten).To(HaveLen(2))
+		for _, rewrite := range rewritten {
+			Expect(rewrite.hostHeader).To(Equal(fmt.Sprintf("www.contoso.com:%d", rewrite.backendPort)))
+		}
+	})
+
+	It("is ignored unless true", func() {
+		ingress.Annotations[annotations.BackendHostPortKey] = "false"
+		build()
+		Expect(configBuilder.appGw.RewriteRuleSets).To(BeNil())
+	})
+
+	It("is ignored on a v1 App Gateway", func() {
+		configBuilder.appGw.Sku = &n.ApplicationGatewaySku{
+			Name: n.StandardMedium,
+			Tier: n.ApplicationGatewayTierStandard,
+		}
+		build()
+		Expect(configBuilder.appGw.RewriteRuleSets).To(BeNil())
+		Expect(recorder.Events).To(Receive(ContainSubstring("APPG035")))
+	})
+
+	It("can not be combined with pick-host-name-from-backend", func() {
+		ingress.Annotations[annotations.PickHostNameFromBackendKey] = "true"
+		build()
+		Expect(configBuilder.appGw.RewriteRuleSets).To(BeNil())
+		Expect(recorder.Events).To(Receive(ContainSubstring("APPG036")))
+	})
+
+	It("leaves the rules with an AzureApplicationGatewayRewrite unchanged", func() {
+		cbCtx.Rewrites = []*rwv1.AzureApplicationGatewayRewrite{
+			{
+				ObjectMeta: metav1.ObjectMeta{Namespace: tests.Namespace, Name: "tracing"},
+				Spec: rwv1.AzureApplicationGatewayRewriteSpec{
+					Ingress: tests.Name,
+					Host:    tests.OtherHost,
+					Rules: []rwv1.RewriteRule{
+						{
+							Name: "trace",
+							Actions: rwv1.RewriteActions{
+								RequestHeaders: []rwv1.RewriteHeader{{Name: "X-Trace", Value: "1"}},
+							},
+						},
+					},
+				},
+			},
+		}
+		build()
+
+		var names []string
+		for _, ruleSet := range *configBuilder.appGw.RewriteRuleSets {
+			names = append(names, *ruleSet.Name)
+		}
+		Expect(names).To(HaveLen(2))
+		Expect(names).To(ContainElement(generateRewriteRuleSetName(tests.Namespace, "tracing")))
+		Expect(rewrittenHostHeaders()).To(HaveLen(1))
+		Expect(recorder.Events).To(Receive(ContainSubstring("APPG037")))
+	})
+})
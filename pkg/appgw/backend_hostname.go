@@ -0,0 +1,29 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"k8s.io/api/extensions/v1beta1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+)
+
+// getPickHostNameFromBackend returns whether the ingress explicitly asks App Gateway to send the host name of the
+// backend address as the host header, or nil when the ingress leaves it to AGIC.
+// An explicit backend-hostname takes precedence; asking for both is reported as a conflict.
+func getPickHostNameFromBackend(ingress *v1beta1.Ingress) (*bool, error) {
+	pick, err := annotations.PickHostNameFromBackend(ingress)
+	if annotations.IsMissingAnnotations(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := annotations.BackendHostName(ingress); pick && err == nil {
+		return nil, ErrPickHostNameConflict
+	}
+	return &pick, nil
+}
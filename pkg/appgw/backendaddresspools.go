@@ -15,12 +15,20 @@ import (
 	v1 "k8s.io/api/core/v1"
 
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/brownfield"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/sorter"
 )
 
 func (c *appGwConfigBuilder) BackendAddressPools(cbCtx *ConfigBuilderContext) error {
 	pools := c.getPools(cbCtx)
+	c.reportClusterIPFallbacks(cbCtx, pools)
+	if cbCtx.PoolDrains != nil && c.appGw.BackendAddressPools != nil {
+		pools = c.drainSwappedPools(cbCtx, pools, *c.appGw.BackendAddressPools)
+	}
+	if cbCtx.PoolRemovals != nil && c.appGw.BackendAddressPools != nil {
+		pools = c.deferPoolRemovals(cbCtx, pools, *c.appGw.BackendAddressPools)
+	}
 	if pools != nil {
 		sort.Sort(sorter.ByBackendPoolName(pools))
 	}
@@ -43,7 +51,7 @@ func (c appGwConfigBuilder) getPools(cbCtx *ConfigBuilderContext) []n.Applicatio
 		glog.Error("Error fetching Backends and Settings: ", err)
 	}
 	for backendID, serviceBackendPair := range serviceBackendPairMap {
-		if pool := c.getBackendAddressPool(backendID, serviceBackendPair, managedPoolsByName); pool != nil {
+		if pool := c.getBackendAddressPool(cbCtx, backendID, serviceBackendPair, managedPoolsByName); pool != nil {
 			managedPoolsByName[*pool.Name] = pool
 			glog.V(5).Infof("Created backend pool %s for service %s", *pool.Name, backendID.serviceKey())
 		}
@@ -90,39 +98,74 @@ func (c *appGwConfigBuilder) newBackendPoolMap(cbCtx *ConfigBuilderContext) map[
 	_, _, serviceBackendPairMap, _ := c.getBackendsAndSettingsMap(cbCtx)
 	for backendID, serviceBackendPair := range serviceBackendPairMap {
 		backendPoolMap[backendID] = &defaultPool
-		if pool := c.getBackendAddressPool(backendID, serviceBackendPair, addressPools); pool != nil {
+		if pool := c.getBackendAddressPool(cbCtx, backendID, serviceBackendPair, addressPools); pool != nil {
 			backendPoolMap[backendID] = pool
 		}
 	}
 	return backendPoolMap
 }
 
-func (c *appGwConfigBuilder) getBackendAddressPool(backendID backendIdentifier, serviceBackendPair serviceBackendPortPair, addressPools map[string]*n.ApplicationGatewayBackendAddressPool) *n.ApplicationGatewayBackendAddressPool {
+func (c *appGwConfigBuilder) getBackendAddressPool(cbCtx *ConfigBuilderContext, backendID backendIdentifier, serviceBackendPair serviceBackendPortPair, addressPools map[string]*n.ApplicationGatewayBackendAddressPool) *n.ApplicationGatewayBackendAddressPool {
+	if c.isExternalNameBackend(backendID) {
+		return c.getExternalNameBackendAddressPool(cbCtx, backendID, serviceBackendPair, addressPools)
+	}
+
 	endpoints, err := c.k8sContext.GetEndpointsByService(backendID.serviceKey())
 	if err != nil {
 		logLine := fmt.Sprintf("Failed fetching endpoints for service: %s", backendID.serviceKey())
 		glog.Errorf(logLine)
-		c.recorder.Event(backendID.Ingress, v1.EventTypeWarning, events.ReasonEndpointsEmpty, logLine)
+		events.Warning(c.recorder, backendID.Ingress, events.ReasonEndpointsEmpty, logLine)
 		return nil
 	}
 
 	for _, subset := range endpoints.Subsets {
 		if _, portExists := getUniqueTCPPorts(subset)[serviceBackendPair.BackendPort]; portExists {
-			poolName := generateAddressPoolName(backendID.serviceFullName(), backendID.Backend.ServicePort.String(), serviceBackendPair.BackendPort)
+			poolName := c.getAddressPoolName(backendID, serviceBackendPair)
 			// The same service might be referenced in multiple ingress resources, this might result in multiple `serviceBackendPairMap` having the same service key but different
 			// ingress resource. Thus, while generating the backend address pool, we should make sure that we are generating unique backend address pools.
 			if pool, ok := addressPools[poolName]; ok {
 				return pool
 			}
-			return c.newPool(poolName, subset)
+			if clusterIP, ready := c.clusterIPFallback(cbCtx, backendID); clusterIP != "" {
+				glog.V(3).Infof("Service %s has %d ready endpoints, more than %s allows in a backend pool; pool %s holds its cluster IP %s", backendID.serviceKey(), ready, environment.MaxPoolAddressesVarName, poolName, clusterIP)
+				return c.newPool(poolName, v1.EndpointSubset{Addresses: []v1.EndpointAddress{{IP: clusterIP}}})
+			}
+			pool := c.newPool(poolName, c.activeSubset(cbCtx, backendID, subset))
+			c.mergeAdditionalServices(cbCtx, backendID, serviceBackendPair, pool)
+			return pool
 		}
 		logLine := fmt.Sprintf("Backend target port %d does not have matching endpoint port", serviceBackendPair.BackendPort)
 		glog.Error(logLine)
-		c.recorder.Event(backendID.Ingress, v1.EventTypeWarning, events.ReasonBackendPortTargetMatch, logLine)
+		events.Warning(c.recorder, backendID.Ingress, events.ReasonBackendPortTargetMatch, logLine)
 	}
 	return nil
 }
 
+// activeSubset leaves the pods, which are terminating, out of the endpoints when connection draining is enabled on the
+// backend: App Gateway stops sending new requests to them right away, and drains the requests in flight, instead of
+// waiting for the pods to be removed from the endpoints once they are gone.
+func (c *appGwConfigBuilder) activeSubset(cbCtx *ConfigBuilderContext, backendID backendIdentifier, subset v1.EndpointSubset) v1.EndpointSubset {
+	if draining, _, _ := getConnectionDraining(backendID.Ingress, cbCtx.EnvVariables); !draining {
+		return subset
+	}
+	active := subset
+	active.Addresses = c.withoutTerminatingPods(subset.Addresses)
+	active.NotReadyAddresses = c.withoutTerminatingPods(subset.NotReadyAddresses)
+	return active
+}
+
+func (c *appGwConfigBuilder) withoutTerminatingPods(addresses []v1.EndpointAddress) []v1.EndpointAddress {
+	var active []v1.EndpointAddress
+	for _, address := range addresses {
+		if c.k8sContext.IsPodTerminating(address) {
+			glog.V(3).Infof("Pod %s/%s is terminating; address %s is drained from the backend pool", address.TargetRef.Namespace, address.TargetRef.Name, address.IP)
+			continue
+		}
+		active = append(active, address)
+	}
+	return active
+}
+
 func getUniqueTCPPorts(subset v1.EndpointSubset) map[Port]interface{} {
 	ports := make(map[Port]interface{})
 	for _, endpointsPort := range subset.Ports {
@@ -6,6 +6,8 @@
 package appgw
 
 import (
+	"time"
+
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/utils"
 	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
 	"github.com/Azure/go-autorest/autorest/to"
@@ -14,7 +16,13 @@ import (
 	. "github.com/onsi/gomega"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/cache"
 
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/k8scontext"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
 )
 
@@ -148,7 +156,7 @@ var _ = Describe("Test the creation of Backend Pools from Ingress definition", f
 		}
 
 		// -- Action --
-		actual := cb.getBackendAddressPool(backendID, serviceBackendPair, addressPools)
+		actual := cb.getBackendAddressPool(cbCtx, backendID, serviceBackendPair, addressPools)
 
 		It("should have constructed correct ApplicationGatewayBackendAddressPool", func() {
 			// The order here is deliberate -- ensure this is properly sorted
@@ -189,7 +197,7 @@ var _ = Describe("Test the creation of Backend Pools from Ingress definition", f
 		})
 
 		It("Should get listener config from istio", func() {
-			actual := cb.getListenerConfigsFromIstio(istioGateways, istioVirtualServices)
+			actual := cb.getListenerConfigsFromIstio(environment.GetFakeEnv(), istioGateways, istioVirtualServices)
 			expected := map[listenerIdentifier]listenerAzConfig{
 				listenerIdentifier{FrontendPort: 80, HostName: "", UsePrivateIP: false}: {
 					Protocol:                     "Http",
@@ -263,4 +271,229 @@ var _ = Describe("Test the creation of Backend Pools from Ingress definition", f
 			Expect(err).ToNot(HaveOccurred())
 		})
 	})
+
+	Context("backend pools for ExternalName services", func() {
+		newExternalNameFixture := func(externalName string) (appGwConfigBuilder, *ConfigBuilderContext) {
+			cb := newConfigBuilderFixture(nil)
+			service := tests.NewServiceFixture()
+			service.Spec.Type = v1.ServiceTypeExternalName
+			service.Spec.ExternalName = externalName
+			service.Spec.Selector = nil
+			_ = cb.k8sContext.Caches.Service.Add(service)
+
+			ingress := tests.NewIngressFixture()
+			_ = cb.k8sContext.Caches.Ingress.Add(ingress)
+			cbCtx := &ConfigBuilderContext{
+				IngressList:           []*v1beta1.Ingress{ingress},
+				ServiceList:           []*v1.Service{service},
+				DefaultAddressPoolID:  to.StringPtr("xx"),
+				DefaultHTTPSettingsID: to.StringPtr("yy"),
+			}
+			return cb, cbCtx
+		}
+
+		It("should create a pool with the FQDN of the service", func() {
+			cb, cbCtx := newExternalNameFixture("api.contoso.com")
+			_ = cb.BackendAddressPools(cbCtx)
+
+			// One pool for each of the ports the ingress uses, in addition to the default pool
+			Expect(len(*cb.appGw.BackendAddressPools)).To(Equal(3))
+			for _, pool := range *cb.appGw.BackendAddressPools {
+				if *pool.Name != DefaultBackendAddressPoolName {
+					Expect(*pool.BackendAddresses).To(Equal([]n.ApplicationGatewayBackendAddress{{Fqdn: to.StringPtr("api.contoso.com")}}))
+				}
+			}
+		})
+
+		It("should pick the host name from the backend address", func() {
+			cb, cbCtx := newExternalNameFixture("api.contoso.com")
+			_ = cb.HealthProbesCollection(cbCtx)
+			_ = cb.BackendHTTPSettingsCollection(cbCtx)
+
+			for _, setting := range *cb.appGw.BackendHTTPSettingsCollection {
+				if *setting.Name == DefaultBackendHTTPSettingsName {
+					continue
+				}
+				Expect(*setting.Port).To(Or(Equal(int32(80)), Equal(int32(443))))
+				Expect(*setting.PickHostNameFromBackendAddress).To(BeTrue())
+			}
+			for _, probe := range *cb.appGw.Probes {
+				if *probe.Name == defaultProbeName(n.HTTP) || *probe.Name == defaultProbeName(n.HTTPS) {
+					continue
+				}
+				Expect(probe.Host).To(BeNil())
+				Expect(*probe.PickHostNameFromBackendHTTPSettings).To(BeTrue())
+			}
+		})
+
+		It("should not create a pool for an invalid FQDN", func() {
+			cb, cbCtx := newExternalNameFixture("not a valid -host-")
+			_ = cb.BackendAddressPools(cbCtx)
+
+			Expect(*cb.appGw.BackendAddressPools).To(ConsistOf(defaultBackendAddressPool(cb.appGwIdentifier)))
+		})
+	})
+
+	Context("backend pools for headless services", func() {
+		cb := newConfigBuilderFixture(nil)
+		service := tests.NewServiceFixture(*tests.NewServicePortsFixture()...)
+		service.Spec.ClusterIP = v1.ClusterIPNone
+		_ = cb.k8sContext.Caches.Service.Add(service)
+
+		endpoints := tests.NewEndpointsFixture()
+		endpoints.Subsets[0].Addresses = []v1.EndpointAddress{{IP: "10.9.8.7"}, {IP: "10.9.8.6"}}
+		_ = cb.k8sContext.Caches.Endpoints.Add(endpoints)
+
+		ingress := tests.NewIngressFixture()
+		_ = cb.k8sContext.Caches.Ingress.Add(ingress)
+		cbCtx := &ConfigBuilderContext{
+			IngressList:           []*v1beta1.Ingress{ingress},
+			ServiceList:           []*v1.Service{service},
+			DefaultAddressPoolID:  to.StringPtr("xx"),
+			DefaultHTTPSettingsID: to.StringPtr("yy"),
+		}
+		_ = cb.BackendAddressPools(cbCtx)
+
+		It("should use the addresses of the pods", func() {
+			var addresses []n.ApplicationGatewayBackendAddress
+			for _, pool := range *cb.appGw.BackendAddressPools {
+				if *pool.Name != DefaultBackendAddressPoolName {
+					addresses = append(addresses, *pool.BackendAddresses...)
+				}
+			}
+			Expect(addresses).To(ContainElement(n.ApplicationGatewayBackendAddress{IPAddress: to.StringPtr("10.9.8.7")}))
+			Expect(addresses).To(ContainElement(n.ApplicationGatewayBackendAddress{IPAddress: to.StringPtr("10.9.8.6")}))
+		})
+	})
+
+	Context("backend pools for ServiceImports of multi-cluster services", func() {
+		cb := newConfigBuilderFixture(nil)
+		cb.k8sContext.Caches.ServiceImport = cache.NewStore(cache.MetaNamespaceKeyFunc)
+		cb.k8sContext.Caches.EndpointSlice = cache.NewStore(cache.MetaNamespaceKeyFunc)
+		_ = cb.k8sContext.Caches.ServiceImport.Add(&unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "multicluster.x-k8s.io/v1alpha1",
+			"kind":       "ServiceImport",
+			"metadata":   map[string]interface{}{"name": tests.ServiceName, "namespace": tests.Namespace},
+			"spec": map[string]interface{}{
+				"ports": []interface{}{
+					map[string]interface{}{"name": "http", "port": int64(80), "protocol": "TCP"},
+					map[string]interface{}{"name": "https", "port": int64(443), "protocol": "TCP"},
+				},
+			},
+		}})
+		_ = cb.k8sContext.Caches.EndpointSlice.Add(&unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "discovery.k8s.io/v1",
+			"kind":       "EndpointSlice",
+			"metadata": map[string]interface{}{
+				"name":      tests.ServiceName + "-west",
+				"namespace": tests.Namespace,
+				"labels":    map[string]interface{}{k8scontext.MultiClusterServiceNameLabel: tests.ServiceName},
+			},
+			"addressType": "IPv4",
+			"ports": []interface{}{
+				map[string]interface{}{"name": "http", "port": int64(8080), "protocol": "TCP"},
+				map[string]interface{}{"name": "https", "port": int64(8443), "protocol": "TCP"},
+			},
+			"endpoints": []interface{}{
+				map[string]interface{}{"addresses": []interface{}{"10.20.0.4"}, "conditions": map[string]interface{}{"ready": true}},
+				map[string]interface{}{"addresses": []interface{}{"10.20.0.5"}},
+			},
+		}})
+
+		ingress := tests.NewIngressFixture()
+		_ = cb.k8sContext.Caches.Ingress.Add(ingress)
+		cbCtx := &ConfigBuilderContext{
+			IngressList:           []*v1beta1.Ingress{ingress},
+			ServiceList:           cb.k8sContext.ListServices(),
+			DefaultAddressPoolID:  to.StringPtr("xx"),
+			DefaultHTTPSettingsID: to.StringPtr("yy"),
+		}
+		_ = cb.BackendAddressPools(cbCtx)
+		_ = cb.BackendHTTPSettingsCollection(cbCtx)
+
+		It("should resolve the service of the backends to the ServiceImport", func() {
+			Expect(cbCtx.ServiceList).To(HaveLen(1))
+			Expect(cbCtx.ServiceList[0].Name).To(Equal(tests.ServiceName))
+		})
+
+		It("should use the addresses of the EndpointSlices", func() {
+			Expect(len(*cb.appGw.BackendAddressPools)).To(Equal(3))
+			for _, pool := range *cb.appGw.BackendAddressPools {
+				if *pool.Name == DefaultBackendAddressPoolName {
+					continue
+				}
+				Expect(*pool.BackendAddresses).To(Equal([]n.ApplicationGatewayBackendAddress{
+					{IPAddress: to.StringPtr("10.20.0.4")},
+					{IPAddress: to.StringPtr("10.20.0.5")},
+				}))
+			}
+		})
+
+		It("should target the ports of the EndpointSlices", func() {
+			var ports []int32
+			for _, setting := range *cb.appGw.BackendHTTPSettingsCollection {
+				if *setting.Name != DefaultBackendHTTPSettingsName {
+					ports = append(ports, *setting.Port)
+				}
+			}
+			Expect(ports).To(ConsistOf(int32(8080), int32(8443)))
+		})
+	})
+
+	Context("backend pools with terminating pods", func() {
+		var cb appGwConfigBuilder
+		var cbCtx *ConfigBuilderContext
+		var ingress *v1beta1.Ingress
+
+		BeforeEach(func() {
+			cb = newConfigBuilderFixture(nil)
+			service := tests.NewServiceFixture(*tests.NewServicePortsFixture()...)
+			_ = cb.k8sContext.Caches.Service.Add(service)
+
+			healthy := tests.NewPodTestFixture(tests.Namespace, "healthy")
+			terminating := tests.NewPodTestFixture(tests.Namespace, "terminating")
+			terminating.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+			_ = cb.k8sContext.Caches.Pods.Add(&healthy)
+			_ = cb.k8sContext.Caches.Pods.Add(&terminating)
+
+			endpoints := tests.NewEndpointsFixture()
+			endpoints.Subsets[0].Addresses = []v1.EndpointAddress{
+				{IP: "10.9.8.7", TargetRef: &v1.ObjectReference{Kind: "Pod", Namespace: tests.Namespace, Name: "healthy"}},
+				{IP: "10.9.8.6", TargetRef: &v1.ObjectReference{Kind: "Pod", Namespace: tests.Namespace, Name: "terminating"}},
+			}
+			_ = cb.k8sContext.Caches.Endpoints.Add(endpoints)
+
+			ingress = tests.NewIngressFixture()
+			cbCtx = &ConfigBuilderContext{
+				IngressList:           []*v1beta1.Ingress{ingress},
+				ServiceList:           []*v1.Service{service},
+				DefaultAddressPoolID:  to.StringPtr("xx"),
+				DefaultHTTPSettingsID: to.StringPtr("yy"),
+			}
+		})
+
+		poolAddresses := func() []n.ApplicationGatewayBackendAddress {
+			_ = cb.BackendAddressPools(cbCtx)
+			var addresses []n.ApplicationGatewayBackendAddress
+			for _, pool := range *cb.appGw.BackendAddressPools {
+				if *pool.Name != DefaultBackendAddressPoolName {
+					addresses = append(addresses, *pool.BackendAddresses...)
+				}
+			}
+			return addresses
+		}
+
+		It("should leave the terminating pods out of the pool when connection draining is enabled", func() {
+			ingress.Annotations[annotations.ConnectionDrainingKey] = "true"
+			addresses := poolAddresses()
+			Expect(addresses).To(ContainElement(n.ApplicationGatewayBackendAddress{IPAddress: to.StringPtr("10.9.8.7")}))
+			Expect(addresses).ToNot(ContainElement(n.ApplicationGatewayBackendAddress{IPAddress: to.StringPtr("10.9.8.6")}))
+		})
+
+		It("should keep the terminating pods in the pool without connection draining", func() {
+			addresses := poolAddresses()
+			Expect(addresses).To(ContainElement(n.ApplicationGatewayBackendAddress{IPAddress: to.StringPtr("10.9.8.7")}))
+			Expect(addresses).To(ContainElement(n.ApplicationGatewayBackendAddress{IPAddress: to.StringPtr("10.9.8.6")}))
+		})
+	})
 })
@@ -8,15 +8,19 @@ package appgw
 import (
 	"fmt"
 	"sort"
+	"strconv"
+	"strings"
 
 	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
 	"github.com/Azure/go-autorest/autorest/to"
 	"github.com/golang/glog"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/brownfield"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/sorter"
 )
@@ -27,6 +31,21 @@ const (
 )
 
 func (c *appGwConfigBuilder) BackendHTTPSettingsCollection(cbCtx *ConfigBuilderContext) error {
+	for _, ingress := range cbCtx.IngressList {
+		if err := validateBuffering(ingress, c.appGw.Sku); err != nil {
+			glog.Errorf("[%s/%s] %s", ingress.Namespace, ingress.Name, err)
+			events.Warning(c.recorder, ingress, events.ReasonInvalidAnnotation, err.Error())
+		}
+		if err := validateGRPC(ingress); err != nil {
+			glog.Errorf("[%s/%s] %s", ingress.Namespace, ingress.Name, err)
+			events.Warning(c.recorder, ingress, events.ReasonInvalidAnnotation, err.Error())
+		}
+		if err := validateMaxConnections(ingress); err != nil {
+			glog.Errorf("[%s/%s] %s", ingress.Namespace, ingress.Name, err)
+			events.Warning(c.recorder, ingress, events.ReasonInvalidAnnotation, err.Error())
+		}
+	}
+
 	agicHTTPSettings, _, _, err := c.getBackendsAndSettingsMap(cbCtx)
 
 	if cbCtx.EnvVariables.EnableBrownfieldDeployment {
@@ -83,7 +102,7 @@ func (c *appGwConfigBuilder) getBackendsAndSettingsMap(cbCtx *ConfigBuilderConte
 		if service == nil {
 			// This should never happen since newBackendIdsFiltered() already filters out backends for non-existent Services
 			logLine := fmt.Sprintf("Unable to get the service [%s]", backendID.serviceKey())
-			c.recorder.Event(backendID.Ingress, v1.EventTypeWarning, events.ReasonServiceNotFound, logLine)
+			events.Warning(c.recorder, backendID.Ingress, events.ReasonServiceNotFound, logLine)
 			glog.Errorf(logLine)
 			pair := serviceBackendPortPair{
 				ServicePort: Port(backendID.Backend.ServicePort.IntVal),
@@ -91,55 +110,21 @@ func (c *appGwConfigBuilder) getBackendsAndSettingsMap(cbCtx *ConfigBuilderConte
 			}
 			resolvedBackendPorts[pair] = nil
 		} else {
-			for _, sp := range service.Spec.Ports {
-				// find the backend port number
-				// check if any service ports matches the specified ports
-				if sp.Protocol != v1.ProtocolTCP {
-					// ignore UDP ports
-					continue
-				}
-				if fmt.Sprint(sp.Port) == backendID.Backend.ServicePort.String() ||
-					sp.Name == backendID.Backend.ServicePort.String() ||
-					sp.TargetPort.String() == backendID.Backend.ServicePort.String() {
-					// matched a service port with a port from the service
+			resolvedBackendPorts = c.resolveBackendPorts(backendID, service)
+		}
 
-					if sp.TargetPort.String() == "" {
-						// targetPort is not defined, by default targetPort == port
-						pair := serviceBackendPortPair{
-							ServicePort: Port(sp.Port),
-							BackendPort: Port(sp.Port),
-						}
-						resolvedBackendPorts[pair] = nil
-					} else {
-						// target port is defined as name or port number
-						if sp.TargetPort.Type == intstr.Int {
-							// port is defined as port number
-							pair := serviceBackendPortPair{
-								ServicePort: Port(sp.Port),
-								BackendPort: Port(sp.TargetPort.IntVal),
-							}
-							resolvedBackendPorts[pair] = nil
-						} else {
-							// if service port is defined by name, need to resolve
-							glog.V(5).Infof("resolving port name [%s] for service [%s] and service port [%s] for Ingress [%s]", sp.Name, backendID.serviceKey(), backendID.Backend.ServicePort.String(), backendID.Ingress.Name)
-							targetPortsResolved := c.resolvePortName(sp.Name, &backendID)
-							for targetPort := range targetPortsResolved {
-								pair := serviceBackendPortPair{
-									ServicePort: Port(sp.Port),
-									BackendPort: Port(targetPort),
-								}
-								resolvedBackendPorts[pair] = nil
-							}
-						}
-					}
-					break
-				}
+		if len(resolvedBackendPorts) == 0 && service != nil && service.Spec.Type == v1.ServiceTypeExternalName && backendID.Backend.ServicePort.Type == intstr.Int {
+			// ExternalName services need not declare ports; the port of the Ingress backend is the port of the external host.
+			pair := serviceBackendPortPair{
+				ServicePort: Port(backendID.Backend.ServicePort.IntVal),
+				BackendPort: Port(backendID.Backend.ServicePort.IntVal),
 			}
+			resolvedBackendPorts[pair] = nil
 		}
 
 		if len(resolvedBackendPorts) == 0 {
 			logLine := fmt.Sprintf("unable to resolve any backend port for service [%s] and service port [%s] for Ingress [%s]", backendID.serviceKey(), backendID.Backend.ServicePort.String(), backendID.Ingress.Name)
-			c.recorder.Event(backendID.Ingress, v1.EventTypeWarning, events.ReasonPortResolutionError, logLine)
+			events.Warning(c.recorder, backendID.Ingress, events.ReasonPortResolutionError, logLine)
 			glog.Error(logLine)
 
 			unresolvedBackendID = append(unresolvedBackendID, backendID)
@@ -169,7 +154,7 @@ func (c *appGwConfigBuilder) getBackendsAndSettingsMap(cbCtx *ConfigBuilderConte
 			// more than one possible backend port exposed through ingress
 			logLine := fmt.Sprintf("service:port [%s:%s] has more than one service-backend port binding",
 				backendID.serviceKey(), backendID.Backend.ServicePort.String())
-			c.recorder.Event(backendID.Ingress, v1.EventTypeWarning, events.ReasonPortResolutionError, logLine)
+			events.Warning(c.recorder, backendID.Ingress, events.ReasonPortResolutionError, logLine)
 			glog.Warning(logLine)
 			return nil, nil, nil, ErrMultipleServiceBackendPortBinding
 		}
@@ -181,7 +166,12 @@ func (c *appGwConfigBuilder) getBackendsAndSettingsMap(cbCtx *ConfigBuilderConte
 		}
 
 		finalServiceBackendPairMap[backendID] = uniquePair
-		httpSettings := c.generateHTTPSettings(backendID, uniquePair.BackendPort, cbCtx)
+		port := uniquePair.BackendPort
+		if clusterIP, _ := c.clusterIPFallback(cbCtx, backendID); clusterIP != "" {
+			// The cluster IP serves the port of the service, rather than the port of the pods.
+			port = uniquePair.ServicePort
+		}
+		httpSettings := c.generateHTTPSettings(backendID, port, cbCtx)
 		glog.V(5).Infof("Created backend http settings %s for ingress %s/%s and service %s", *httpSettings.Name, backendID.Ingress.Namespace, backendID.Ingress.Name, backendID.serviceKey())
 		httpSettingsCollection[*httpSettings.Name] = httpSettings
 		backendHTTPSettingsMap[backendID] = &httpSettings
@@ -198,6 +188,94 @@ func (c *appGwConfigBuilder) getBackendsAndSettingsMap(cbCtx *ConfigBuilderConte
 	return httpSettings, backendHTTPSettingsMap, finalServiceBackendPairMap, nil
 }
 
+// resolveBackendPorts finds the service port the backend refers to and resolves the port on the pods it targets.
+func (c *appGwConfigBuilder) resolveBackendPorts(backendID backendIdentifier, service *v1.Service) map[serviceBackendPortPair]interface{} {
+	resolvedBackendPorts := make(map[serviceBackendPortPair]interface{})
+	for _, sp := range service.Spec.Ports {
+		// find the backend port number
+		// check if any service ports matches the specified ports
+		if sp.Protocol != v1.ProtocolTCP {
+			// ignore UDP ports
+			continue
+		}
+		if fmt.Sprint(sp.Port) == backendID.Backend.ServicePort.String() ||
+			sp.Name == backendID.Backend.ServicePort.String() ||
+			sp.TargetPort.String() == backendID.Backend.ServicePort.String() {
+			// matched a service port with a port from the service
+
+			if sp.TargetPort.String() == "" {
+				// targetPort is not defined, by default targetPort == port
+				pair := serviceBackendPortPair{
+					ServicePort: Port(sp.Port),
+					BackendPort: Port(sp.Port),
+				}
+				resolvedBackendPorts[pair] = nil
+			} else {
+				// target port is defined as name or port number
+				if sp.TargetPort.Type == intstr.Int {
+					// port is defined as port number
+					pair := serviceBackendPortPair{
+						ServicePort: Port(sp.Port),
+						BackendPort: Port(sp.TargetPort.IntVal),
+					}
+					resolvedBackendPorts[pair] = nil
+				} else {
+					// if service port is defined by name, need to resolve
+					glog.V(5).Infof("resolving port name [%s] for service [%s] and service port [%s] for Ingress [%s]", sp.Name, backendID.serviceKey(), backendID.Backend.ServicePort.String(), backendID.Ingress.Name)
+					targetPortsResolved := c.resolvePortName(sp.Name, &backendID)
+					for targetPort := range targetPortsResolved {
+						pair := serviceBackendPortPair{
+							ServicePort: Port(sp.Port),
+							BackendPort: Port(targetPort),
+						}
+						resolvedBackendPorts[pair] = nil
+					}
+				}
+			}
+			break
+		}
+	}
+	return resolvedBackendPorts
+}
+
+// getDefaultRequestTimeout returns the request timeout set with APPGW_DEFAULT_REQUEST_TIMEOUT_SECONDS, or nil when it is not set.
+func getDefaultRequestTimeout(env environment.EnvVariables) *int32 {
+	if env.DefaultRequestTimeout == "" {
+		return nil
+	}
+	timeout, err := strconv.ParseInt(env.DefaultRequestTimeout, 10, 32)
+	if err != nil {
+		return nil
+	}
+	return to.Int32Ptr(int32(timeout))
+}
+
+// getConnectionDraining returns whether connection draining is enabled on the backends of the ingress, and the drain
+// timeout in seconds. The annotations of the ingress take precedence over APPGW_DEFAULT_CONNECTION_DRAINING and
+// APPGW_DEFAULT_CONNECTION_DRAINING_TIMEOUT_SECONDS. An invalid annotation is returned as an error, and ignored.
+func getConnectionDraining(ingress *v1beta1.Ingress, env environment.EnvVariables) (bool, int32, error) {
+	var invalidErr error
+
+	enabled := env.DefaultConnectionDraining
+	if draining, err := annotations.IsConnectionDraining(ingress); err == nil {
+		enabled = draining
+	} else if !annotations.IsMissingAnnotations(err) {
+		invalidErr = err
+	}
+
+	timeout := int32(DefaultConnDrainTimeoutInSec)
+	if defaultTimeout, err := strconv.ParseInt(env.DefaultDrainTimeout, 10, 32); err == nil {
+		timeout = int32(defaultTimeout)
+	}
+	if drainTimeout, err := annotations.ConnectionDrainingTimeout(ingress); err == nil {
+		timeout = drainTimeout
+	} else if !annotations.IsMissingAnnotations(err) && invalidErr == nil {
+		invalidErr = err
+	}
+
+	return enabled, timeout, invalidErr
+}
+
 func (c *appGwConfigBuilder) generateHTTPSettings(backendID backendIdentifier, port Port, cbCtx *ConfigBuilderContext) n.ApplicationGatewayBackendHTTPSettings {
 	httpSettingsName := generateHTTPSettingsName(backendID.serviceFullName(), backendID.Backend.ServicePort.String(), port, backendID.Ingress.Name)
 	httpSettings := n.ApplicationGatewayBackendHTTPSettings{
@@ -218,42 +296,65 @@ func (c *appGwConfigBuilder) generateHTTPSettings(backendID backendIdentifier, p
 		httpSettings.ApplicationGatewayBackendHTTPSettingsPropertiesFormat.Probe = resourceRef(probeID)
 	}
 
+	if hostName, err := annotations.BackendHostName(backendID.Ingress); err == nil {
+		httpSettings.HostName = to.StringPtr(hostName)
+	} else if !annotations.IsMissingAnnotations(err) {
+		events.Warning(c.recorder, backendID.Ingress, events.ReasonInvalidAnnotation, err.Error())
+	}
+
+	if pick, err := getPickHostNameFromBackend(backendID.Ingress); err != nil {
+		events.Warning(c.recorder, backendID.Ingress, events.ReasonInvalidAnnotation, err.Error())
+	} else if pick != nil {
+		httpSettings.PickHostNameFromBackendAddress = pick
+	} else if c.isExternalNameBackend(backendID) && httpSettings.HostName == nil {
+		// The external host is expected to serve its own host name rather than the host of the Ingress.
+		httpSettings.PickHostNameFromBackendAddress = to.BoolPtr(true)
+		if GetFQDNRefreshInterval(cbCtx.EnvVariables) != 0 && cbCtx.FQDNResolutions != nil {
+			// The pool holds the IPs of the external host; its host name is set, rather than picked from the pool.
+			service := c.k8sContext.GetService(backendID.serviceKey())
+			httpSettings.HostName = to.StringPtr(strings.TrimSuffix(service.Spec.ExternalName, "."))
+			httpSettings.PickHostNameFromBackendAddress = nil
+		}
+	}
+
 	if pathPrefix, err := annotations.BackendPathPrefix(backendID.Ingress); err == nil {
 		httpSettings.Path = to.StringPtr(pathPrefix)
 	} else if !annotations.IsMissingAnnotations(err) {
-		c.recorder.Event(backendID.Ingress, v1.EventTypeWarning, events.ReasonInvalidAnnotation, err.Error())
+		events.Warning(c.recorder, backendID.Ingress, events.ReasonInvalidAnnotation, err.Error())
 	}
 
-	if isConnDrain, err := annotations.IsConnectionDraining(backendID.Ingress); err == nil && isConnDrain {
+	draining, drainTimeout, err := getConnectionDraining(backendID.Ingress, cbCtx.EnvVariables)
+	if err != nil {
+		events.Warning(c.recorder, backendID.Ingress, events.ReasonInvalidAnnotation, err.Error())
+	}
+	if draining {
 		httpSettings.ConnectionDraining = &n.ApplicationGatewayConnectionDraining{
-			Enabled: to.BoolPtr(true),
-		}
-
-		if connDrainTimeout, err := annotations.ConnectionDrainingTimeout(backendID.Ingress); err == nil {
-			httpSettings.ConnectionDraining.DrainTimeoutInSec = to.Int32Ptr(connDrainTimeout)
-		} else {
-			httpSettings.ConnectionDraining.DrainTimeoutInSec = to.Int32Ptr(DefaultConnDrainTimeoutInSec)
+			Enabled:           to.BoolPtr(true),
+			DrainTimeoutInSec: to.Int32Ptr(drainTimeout),
 		}
-	} else if err != nil && !annotations.IsMissingAnnotations(err) {
-		c.recorder.Event(backendID.Ingress, v1.EventTypeWarning, events.ReasonInvalidAnnotation, err.Error())
 	}
 
 	if affinity, err := annotations.IsCookieBasedAffinity(backendID.Ingress); err == nil && affinity {
 		httpSettings.CookieBasedAffinity = n.Enabled
 	} else if err != nil && !annotations.IsMissingAnnotations(err) {
-		c.recorder.Event(backendID.Ingress, v1.EventTypeWarning, events.ReasonInvalidAnnotation, err.Error())
+		events.Warning(c.recorder, backendID.Ingress, events.ReasonInvalidAnnotation, err.Error())
 	}
 
+	// The request-timeout annotation takes precedence over APPGW_DEFAULT_REQUEST_TIMEOUT_SECONDS;
+	// without either, App Gateway applies its own default of 30 seconds.
 	if reqTimeout, err := annotations.RequestTimeout(backendID.Ingress); err == nil {
 		httpSettings.RequestTimeout = to.Int32Ptr(reqTimeout)
-	} else if !annotations.IsMissingAnnotations(err) {
-		c.recorder.Event(backendID.Ingress, v1.EventTypeWarning, events.ReasonInvalidAnnotation, err.Error())
+	} else {
+		if !annotations.IsMissingAnnotations(err) {
+			events.Warning(c.recorder, backendID.Ingress, events.ReasonInvalidAnnotation, err.Error())
+		}
+		httpSettings.RequestTimeout = getDefaultRequestTimeout(cbCtx.EnvVariables)
 	}
 
 	if backendProtocol, err := annotations.BackendProtocol(backendID.Ingress); err == nil && backendProtocol == annotations.HTTPS {
 		httpSettings.Protocol = n.HTTPS
 	} else if err != nil && !annotations.IsMissingAnnotations(err) {
-		c.recorder.Event(backendID.Ingress, v1.EventTypeWarning, events.ReasonInvalidAnnotation, err.Error())
+		events.Warning(c.recorder, backendID.Ingress, events.ReasonInvalidAnnotation, err.Error())
 	}
 
 	return httpSettings
@@ -13,8 +13,11 @@ import (
 	. "github.com/onsi/gomega"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/api/extensions/v1beta1"
+	"k8s.io/client-go/tools/record"
 
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/utils"
 )
@@ -76,3 +79,431 @@ var _ = Describe("Test the creation of Backend http settings from Ingress defini
 		})
 	})
 })
+
+var _ = Describe("Test request and response buffering annotations", func() {
+	v2SKU := &n.ApplicationGatewaySku{Tier: n.ApplicationGatewayTierStandardV2}
+	v1SKU := &n.ApplicationGatewaySku{Tier: n.ApplicationGatewayTierStandard}
+
+	Context("test getBufferingConfig", func() {
+		It("defaults to buffering requests and responses", func() {
+			config, err := getBufferingConfig(tests.NewIngressFixture())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config).To(Equal(bufferingConfig{Request: true, Response: true}))
+		})
+
+		It("maps the annotations to the buffering config", func() {
+			ingress := tests.NewIngressFixture()
+			ingress.Annotations[annotations.RequestBufferingKey] = "false"
+			ingress.Annotations[annotations.ResponseBufferingKey] = "true"
+			config, err := getBufferingConfig(ingress)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config).To(Equal(bufferingConfig{Request: false, Response: true}))
+
+			ingress.Annotations[annotations.RequestBufferingKey] = "true"
+			ingress.Annotations[annotations.ResponseBufferingKey] = "false"
+			config, err = getBufferingConfig(ingress)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config).To(Equal(bufferingConfig{Request: true, Response: false}))
+		})
+
+		It("returns an error for invalid annotation values", func() {
+			ingress := tests.NewIngressFixture()
+			ingress.Annotations[annotations.ResponseBufferingKey] = "nope"
+			_, err := getBufferingConfig(ingress)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("test validateBuffering", func() {
+		It("accepts the default behavior on any SKU", func() {
+			ingress := tests.NewIngressFixture()
+			Expect(validateBuffering(ingress, v1SKU)).ToNot(HaveOccurred())
+			ingress.Annotations[annotations.RequestBufferingKey] = "true"
+			Expect(validateBuffering(ingress, v1SKU)).ToNot(HaveOccurred())
+		})
+
+		It("rejects disabling buffering on a v1 SKU", func() {
+			ingress := tests.NewIngressFixture()
+			ingress.Annotations[annotations.RequestBufferingKey] = "false"
+			Expect(validateBuffering(ingress, v1SKU)).To(Equal(ErrBufferingRequiresV2SKU))
+		})
+
+		It("rejects disabling buffering with the API version in use", func() {
+			ingress := tests.NewIngressFixture()
+			ingress.Annotations[annotations.ResponseBufferingKey] = "false"
+			Expect(validateBuffering(ingress, v2SKU)).To(Equal(ErrBufferingNotSupported))
+		})
+
+		It("leaves the HTTP settings unchanged and emits an event", func() {
+			ingress := tests.NewIngressFixture()
+			ingress.Annotations[annotations.RequestBufferingKey] = "false"
+			recorder := record.NewFakeRecorder(10)
+			configBuilder := newConfigBuilderFixture(nil)
+			configBuilder.recorder = recorder
+			service := tests.NewServiceFixture(*tests.NewServicePortsFixture()...)
+			_ = configBuilder.k8sContext.Caches.Endpoints.Add(tests.NewEndpointsFixture())
+			_ = configBuilder.k8sContext.Caches.Service.Add(service)
+			cbCtx := &ConfigBuilderContext{
+				IngressList:           []*v1beta1.Ingress{ingress},
+				ServiceList:           []*v1.Service{service},
+				DefaultAddressPoolID:  to.StringPtr("xx"),
+				DefaultHTTPSettingsID: to.StringPtr("yy"),
+			}
+
+			Expect(configBuilder.BackendHTTPSettingsCollection(cbCtx)).ToNot(HaveOccurred())
+			Expect(*configBuilder.appGw.BackendHTTPSettingsCollection).ToNot(BeEmpty())
+			Expect(recorder.Events).To(Receive(ContainSubstring("APPG019")))
+		})
+	})
+
+	Context("test gRPC backends", func() {
+		var recorder *record.FakeRecorder
+		var configBuilder appGwConfigBuilder
+		var cbCtx *ConfigBuilderContext
+
+		BeforeEach(func() {
+			ingress := tests.NewIngressFixture()
+			ingress.Annotations[annotations.BackendProtocolKey] = "grpc"
+			recorder = record.NewFakeRecorder(100)
+			configBuilder = newConfigBuilderFixture(nil)
+			configBuilder.recorder = recorder
+			service := tests.NewServiceFixture(*tests.NewServicePortsFixture()...)
+			_ = configBuilder.k8sContext.Caches.Endpoints.Add(tests.NewEndpointsFixture())
+			_ = configBuilder.k8sContext.Caches.Service.Add(service)
+			_ = configBuilder.k8sContext.Caches.Ingress.Add(ingress)
+			cbCtx = &ConfigBuilderContext{
+				IngressList:           []*v1beta1.Ingress{ingress},
+				ServiceList:           []*v1.Service{service},
+				DefaultAddressPoolID:  to.StringPtr("xx"),
+				DefaultHTTPSettingsID: to.StringPtr("yy"),
+			}
+		})
+
+		It("rejects gRPC backends", func() {
+			ingress := tests.NewIngressFixture()
+			Expect(validateGRPC(ingress)).ToNot(HaveOccurred())
+			ingress.Annotations[annotations.BackendProtocolKey] = "grpc"
+			Expect(validateGRPC(ingress)).To(Equal(ErrGRPCNotSupported))
+		})
+
+		It("generates HTTP probes and HTTP settings and emits a single event", func() {
+			Expect(configBuilder.HealthProbesCollection(cbCtx)).ToNot(HaveOccurred())
+			Expect(configBuilder.BackendHTTPSettingsCollection(cbCtx)).ToNot(HaveOccurred())
+
+			for _, probe := range *configBuilder.appGw.Probes {
+				if !isDefaultProbeName(*probe.Name) {
+					Expect(probe.Protocol).To(Equal(n.HTTP), *probe.Name)
+				}
+			}
+			for _, setting := range *configBuilder.appGw.BackendHTTPSettingsCollection {
+				Expect(setting.Protocol).To(Equal(n.HTTP), *setting.Name)
+			}
+			Expect(recorder.Events).To(HaveLen(1))
+			event := <-recorder.Events
+			Expect(event).To(HavePrefix("Warning " + string(events.ReasonInvalidAnnotation)))
+			Expect(event).To(ContainSubstring("APPG020"))
+		})
+
+		It("rejects the gRPC backend protocol translated from the NGINX annotation", func() {
+			annotations.EnableNginxTranslation(true)
+			defer annotations.EnableNginxTranslation(false)
+			ingress := tests.NewIngressFixture()
+			ingress.Annotations["nginx.ingress.kubernetes.io/backend-protocol"] = "GRPC"
+			Expect(validateGRPC(ingress)).To(Equal(ErrGRPCNotSupported))
+		})
+	})
+})
+
+var _ = Describe("Test max-connections annotation", func() {
+	Context("test getMaxConnections", func() {
+		It("has no limit without the annotation", func() {
+			limit, err := getMaxConnections(tests.NewIngressFixture())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(limit).To(BeNil())
+		})
+
+		It("maps the annotation to the limit", func() {
+			ingress := tests.NewIngressFixture()
+			ingress.Annotations[annotations.MaxConnectionsKey] = "250"
+			limit, err := getMaxConnections(ingress)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(limit).To(Equal(to.Int32Ptr(250)))
+		})
+
+		It("rejects values outside of the allowed range", func() {
+			ingress := tests.NewIngressFixture()
+			for _, value := range []string{"0", "-5", "65536", "many"} {
+				ingress.Annotations[annotations.MaxConnectionsKey] = value
+				_, err := getMaxConnections(ingress)
+				Expect(err).To(Equal(ErrInvalidMaxConnections), value)
+			}
+		})
+	})
+
+	Context("test validateMaxConnections", func() {
+		It("reports a valid limit as unsupported with the API version in use", func() {
+			ingress := tests.NewIngressFixture()
+			Expect(validateMaxConnections(ingress)).ToNot(HaveOccurred())
+			ingress.Annotations[annotations.MaxConnectionsKey] = "1"
+			Expect(validateMaxConnections(ingress)).To(Equal(ErrMaxConnectionsNotSupported))
+		})
+
+		It("leaves the HTTP settings unchanged and emits an event", func() {
+			ingress := tests.NewIngressFixture()
+			ingress.Annotations[annotations.MaxConnectionsKey] = "100"
+			recorder := record.NewFakeRecorder(10)
+			configBuilder := newConfigBuilderFixture(nil)
+			configBuilder.recorder = recorder
+			service := tests.NewServiceFixture(*tests.NewServicePortsFixture()...)
+			_ = configBuilder.k8sContext.Caches.Endpoints.Add(tests.NewEndpointsFixture())
+			_ = configBuilder.k8sContext.Caches.Service.Add(service)
+			cbCtx := &ConfigBuilderContext{
+				IngressList:           []*v1beta1.Ingress{ingress},
+				ServiceList:           []*v1.Service{service},
+				DefaultAddressPoolID:  to.StringPtr("xx"),
+				DefaultHTTPSettingsID: to.StringPtr("yy"),
+			}
+
+			Expect(configBuilder.BackendHTTPSettingsCollection(cbCtx)).ToNot(HaveOccurred())
+			Expect(*configBuilder.appGw.BackendHTTPSettingsCollection).ToNot(BeEmpty())
+			Expect(recorder.Events).To(Receive(ContainSubstring("APPG025")))
+		})
+	})
+})
+
+var _ = Describe("Test pick-host-name-from-backend annotation", func() {
+	var recorder *record.FakeRecorder
+	var configBuilder appGwConfigBuilder
+	var ingress *v1beta1.Ingress
+	var cbCtx *ConfigBuilderContext
+
+	BeforeEach(func() {
+		ingress = tests.NewIngressFixture()
+		recorder = record.NewFakeRecorder(10)
+		configBuilder = newConfigBuilderFixture(nil)
+		configBuilder.recorder = recorder
+		service := tests.NewServiceFixture(*tests.NewServicePortsFixture()...)
+		_ = configBuilder.k8sContext.Caches.Endpoints.Add(tests.NewEndpointsFixture())
+		_ = configBuilder.k8sContext.Caches.Service.Add(service)
+		cbCtx = &ConfigBuilderContext{
+			IngressList:           []*v1beta1.Ingress{ingress},
+			ServiceList:           []*v1.Service{service},
+			DefaultAddressPoolID:  to.StringPtr("xx"),
+			DefaultHTTPSettingsID: to.StringPtr("yy"),
+		}
+	})
+
+	agicSettings := func() []n.ApplicationGatewayBackendHTTPSettings {
+		Expect(configBuilder.BackendHTTPSettingsCollection(cbCtx)).ToNot(HaveOccurred())
+		var settings []n.ApplicationGatewayBackendHTTPSettings
+		for _, setting := range *configBuilder.appGw.BackendHTTPSettingsCollection {
+			if *setting.Name != DefaultBackendHTTPSettingsName {
+				settings = append(settings, setting)
+			}
+		}
+		Expect(settings).ToNot(BeEmpty())
+		return settings
+	}
+
+	It("leaves the flag unset without the annotation", func() {
+		for _, setting := range agicSettings() {
+			Expect(setting.PickHostNameFromBackendAddress).To(BeNil(), *setting.Name)
+			Expect(setting.HostName).To(BeNil(), *setting.Name)
+		}
+	})
+
+	It("sets the flag from the annotation", func() {
+		ingress.Annotations[annotations.PickHostNameFromBackendKey] = "true"
+		for _, setting := range agicSettings() {
+			Expect(setting.PickHostNameFromBackendAddress).To(Equal(to.BoolPtr(true)), *setting.Name)
+		}
+	})
+
+	It("clears the flag with the annotation set to false", func() {
+		ingress.Annotations[annotations.PickHostNameFromBackendKey] = "false"
+		for _, setting := range agicSettings() {
+			Expect(setting.PickHostNameFromBackendAddress).To(Equal(to.BoolPtr(false)), *setting.Name)
+		}
+	})
+
+	It("sets the host name from the backend-hostname annotation", func() {
+		ingress.Annotations[annotations.BackendHostNameKey] = "mesh.contoso.com"
+		ingress.Annotations[annotations.PickHostNameFromBackendKey] = "false"
+		for _, setting := range agicSettings() {
+			Expect(setting.HostName).To(Equal(to.StringPtr("mesh.contoso.com")), *setting.Name)
+			Expect(setting.PickHostNameFromBackendAddress).To(Equal(to.BoolPtr(false)), *setting.Name)
+		}
+		Expect(recorder.Events).ToNot(Receive())
+	})
+
+	It("rejects the flag when combined with backend-hostname", func() {
+		ingress.Annotations[annotations.BackendHostNameKey] = "mesh.contoso.com"
+		ingress.Annotations[annotations.PickHostNameFromBackendKey] = "true"
+		_, err := getPickHostNameFromBackend(ingress)
+		Expect(err).To(Equal(ErrPickHostNameConflict))
+
+		for _, setting := range agicSettings() {
+			Expect(setting.HostName).To(Equal(to.StringPtr("mesh.contoso.com")), *setting.Name)
+			Expect(setting.PickHostNameFromBackendAddress).To(BeNil(), *setting.Name)
+		}
+		Expect(recorder.Events).To(Receive(ContainSubstring("APPG026")))
+	})
+})
+
+var _ = Describe("Test the default request timeout", func() {
+	var recorder *record.FakeRecorder
+	var configBuilder appGwConfigBuilder
+	var ingress *v1beta1.Ingress
+	var cbCtx *ConfigBuilderContext
+
+	BeforeEach(func() {
+		ingress = tests.NewIngressFixture()
+		recorder = record.NewFakeRecorder(10)
+		configBuilder = newConfigBuilderFixture(nil)
+		configBuilder.recorder = recorder
+		service := tests.NewServiceFixture(*tests.NewServicePortsFixture()...)
+		_ = configBuilder.k8sContext.Caches.Endpoints.Add(tests.NewEndpointsFixture())
+		_ = configBuilder.k8sContext.Caches.Service.Add(service)
+		cbCtx = &ConfigBuilderContext{
+			IngressList:           []*v1beta1.Ingress{ingress},
+			ServiceList:           []*v1.Service{service},
+			EnvVariables:          environment.GetFakeEnv(),
+			DefaultAddressPoolID:  to.StringPtr("xx"),
+			DefaultHTTPSettingsID: to.StringPtr("yy"),
+		}
+	})
+
+	requestTimeouts := func() []*int32 {
+		Expect(configBuilder.BackendHTTPSettingsCollection(cbCtx)).ToNot(HaveOccurred())
+		var timeouts []*int32
+		for _, setting := range *configBuilder.appGw.BackendHTTPSettingsCollection {
+			if *setting.Name != DefaultBackendHTTPSettingsName {
+				timeouts = append(timeouts, setting.RequestTimeout)
+			}
+		}
+		Expect(timeouts).ToNot(BeEmpty())
+		return timeouts
+	}
+
+	It("leaves the timeout to App Gateway without the annotation and the environment variable", func() {
+		for _, timeout := range requestTimeouts() {
+			Expect(timeout).To(BeNil())
+		}
+	})
+
+	It("applies the default to ingresses without the annotation", func() {
+		cbCtx.EnvVariables.DefaultRequestTimeout = "120"
+		for _, timeout := range requestTimeouts() {
+			Expect(timeout).To(Equal(to.Int32Ptr(120)))
+		}
+	})
+
+	It("gives precedence to the annotation", func() {
+		cbCtx.EnvVariables.DefaultRequestTimeout = "120"
+		ingress.Annotations[annotations.RequestTimeoutKey] = "15"
+		for _, timeout := range requestTimeouts() {
+			Expect(timeout).To(Equal(to.Int32Ptr(15)))
+		}
+	})
+
+	It("applies the default when the annotation is invalid", func() {
+		cbCtx.EnvVariables.DefaultRequestTimeout = "120"
+		ingress.Annotations[annotations.RequestTimeoutKey] = "fifteen"
+		for _, timeout := range requestTimeouts() {
+			Expect(timeout).To(Equal(to.Int32Ptr(120)))
+		}
+		Expect(recorder.Events).To(Receive(ContainSubstring(string(events.ReasonInvalidAnnotation))))
+	})
+})
+
+var _ = Describe("Test the default connection draining", func() {
+	var recorder *record.FakeRecorder
+	var configBuilder appGwConfigBuilder
+	var ingress *v1beta1.Ingress
+	var cbCtx *ConfigBuilderContext
+
+	BeforeEach(func() {
+		ingress = tests.NewIngressFixture()
+		recorder = record.NewFakeRecorder(10)
+		configBuilder = newConfigBuilderFixture(nil)
+		configBuilder.recorder = recorder
+		service := tests.NewServiceFixture(*tests.NewServicePortsFixture()...)
+		_ = configBuilder.k8sContext.Caches.Endpoints.Add(tests.NewEndpointsFixture())
+		_ = configBuilder.k8sContext.Caches.Service.Add(service)
+		cbCtx = &ConfigBuilderContext{
+			IngressList:           []*v1beta1.Ingress{ingress},
+			ServiceList:           []*v1.Service{service},
+			EnvVariables:          environment.GetFakeEnv(),
+			DefaultAddressPoolID:  to.StringPtr("xx"),
+			DefaultHTTPSettingsID: to.StringPtr("yy"),
+		}
+	})
+
+	connectionDrainings := func() []*n.ApplicationGatewayConnectionDraining {
+		Expect(configBuilder.BackendHTTPSettingsCollection(cbCtx)).ToNot(HaveOccurred())
+		var drainings []*n.ApplicationGatewayConnectionDraining
+		for _, setting := range *configBuilder.appGw.BackendHTTPSettingsCollection {
+			if *setting.Name != DefaultBackendHTTPSettingsName {
+				drainings = append(drainings, setting.ConnectionDraining)
+			}
+		}
+		Expect(drainings).ToNot(BeEmpty())
+		return drainings
+	}
+
+	It("does not drain without the annotations and the environment variables", func() {
+		for _, draining := range connectionDrainings() {
+			Expect(draining).To(BeNil())
+		}
+	})
+
+	It("drains the backends of all ingresses when enabled globally", func() {
+		cbCtx.EnvVariables.DefaultConnectionDraining = true
+		cbCtx.EnvVariables.DefaultDrainTimeout = "90"
+		for _, draining := range connectionDrainings() {
+			Expect(*draining.Enabled).To(BeTrue())
+			Expect(*draining.DrainTimeoutInSec).To(Equal(int32(90)))
+		}
+	})
+
+	It("drains for 30 seconds when enabled globally without a timeout", func() {
+		cbCtx.EnvVariables.DefaultConnectionDraining = true
+		for _, draining := range connectionDrainings() {
+			Expect(*draining.DrainTimeoutInSec).To(Equal(int32(DefaultConnDrainTimeoutInSec)))
+		}
+	})
+
+	It("gives precedence to the annotations", func() {
+		cbCtx.EnvVariables.DefaultConnectionDraining = true
+		cbCtx.EnvVariables.DefaultDrainTimeout = "90"
+		ingress.Annotations[annotations.ConnectionDrainingTimeoutKey] = "15"
+		for _, draining := range connectionDrainings() {
+			Expect(*draining.DrainTimeoutInSec).To(Equal(int32(15)))
+		}
+
+		configBuilder.mem = memoization{}
+		ingress.Annotations[annotations.ConnectionDrainingKey] = "false"
+		for _, draining := range connectionDrainings() {
+			Expect(draining).To(BeNil())
+		}
+	})
+
+	It("applies the global default timeout to an ingress enabling draining", func() {
+		cbCtx.EnvVariables.DefaultDrainTimeout = "90"
+		ingress.Annotations[annotations.ConnectionDrainingKey] = "true"
+		for _, draining := range connectionDrainings() {
+			Expect(*draining.DrainTimeoutInSec).To(Equal(int32(90)))
+		}
+	})
+
+	It("applies the defaults when the annotations are invalid", func() {
+		cbCtx.EnvVariables.DefaultConnectionDraining = true
+		cbCtx.EnvVariables.DefaultDrainTimeout = "90"
+		ingress.Annotations[annotations.ConnectionDrainingKey] = "sometimes"
+		for _, draining := range connectionDrainings() {
+			Expect(*draining.Enabled).To(BeTrue())
+			Expect(*draining.DrainTimeoutInSec).To(Equal(int32(90)))
+		}
+		Expect(recorder.Events).To(Receive(ContainSubstring(string(events.ReasonInvalidAnnotation))))
+	})
+})
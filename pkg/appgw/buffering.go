@@ -0,0 +1,52 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"k8s.io/api/extensions/v1beta1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+)
+
+// bufferingConfig is the buffering requested by the annotations of an ingress.
+// App Gateway buffers both requests and responses unless told otherwise.
+type bufferingConfig struct {
+	Request  bool
+	Response bool
+}
+
+func getBufferingConfig(ingress *v1beta1.Ingress) (bufferingConfig, error) {
+	config := bufferingConfig{Request: true, Response: true}
+	if request, err := annotations.IsRequestBuffering(ingress); err == nil {
+		config.Request = request
+	} else if !annotations.IsMissingAnnotations(err) {
+		return config, err
+	}
+	if response, err := annotations.IsResponseBuffering(ingress); err == nil {
+		config.Response = response
+	} else if !annotations.IsMissingAnnotations(err) {
+		return config, err
+	}
+	return config, nil
+}
+
+// validateBuffering ensures the buffering requested by the ingress can be honored.
+// Buffering can only be turned off on v2 SKUs, with an API version newer than the one AGIC is built with;
+// until AGIC moves to such an API version, disabling buffering is reported as an error and the default is kept.
+func validateBuffering(ingress *v1beta1.Ingress, sku *n.ApplicationGatewaySku) error {
+	config, err := getBufferingConfig(ingress)
+	if err != nil {
+		return err
+	}
+	if config.Request && config.Response {
+		return nil
+	}
+	if sku != nil && (sku.Tier == n.ApplicationGatewayTierStandard || sku.Tier == n.ApplicationGatewayTierWAF) {
+		return ErrBufferingRequiresV2SKU
+	}
+	return ErrBufferingNotSupported
+}
@@ -0,0 +1,73 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"sort"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/sorter"
+)
+
+// sortUnorderedLists sorts the list-valued fields of the config, whose order App Gateway ignores: the addresses of the
+// backend pools, the status codes of the probes and the certificates of the HTTP settings. App Gateway returns them in
+// arbitrary order, and the Kubernetes resources they are built from list them in arbitrary order too; equivalent
+// configs then compare equal, and are not applied again.
+// Lists, whose order App Gateway follows, such as the path rules of a path map, are left as they are, and so are the
+// host names of the listeners, which are listed in the order of the ingress.
+func (c *appGwConfigBuilder) sortUnorderedLists() {
+	if c.appGw.BackendAddressPools != nil {
+		for _, pool := range *c.appGw.BackendAddressPools {
+			if pool.ApplicationGatewayBackendAddressPoolPropertiesFormat == nil || pool.BackendAddresses == nil {
+				continue
+			}
+			addresses := append([]n.ApplicationGatewayBackendAddress{}, *pool.BackendAddresses...)
+			sort.Sort(sorter.ByIPFQDN(addresses))
+			pool.BackendAddresses = &addresses
+		}
+	}
+
+	if c.appGw.Probes != nil {
+		for _, probe := range *c.appGw.Probes {
+			if probe.ApplicationGatewayProbePropertiesFormat == nil || probe.Match == nil || probe.Match.StatusCodes == nil {
+				continue
+			}
+			statusCodes := append([]string{}, *probe.Match.StatusCodes...)
+			sort.Strings(statusCodes)
+			probe.Match.StatusCodes = &statusCodes
+		}
+	}
+
+	if c.appGw.BackendHTTPSettingsCollection != nil {
+		for _, settings := range *c.appGw.BackendHTTPSettingsCollection {
+			if settings.ApplicationGatewayBackendHTTPSettingsPropertiesFormat == nil {
+				continue
+			}
+			settings.TrustedRootCertificates = sortedSubResources(settings.TrustedRootCertificates)
+			settings.AuthenticationCertificates = sortedSubResources(settings.AuthenticationCertificates)
+		}
+	}
+}
+
+// sortedSubResources returns a copy of the references sorted by ID.
+func sortedSubResources(resources *[]n.SubResource) *[]n.SubResource {
+	if resources == nil {
+		return nil
+	}
+	sorted := append([]n.SubResource{}, *resources...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		var left, right string
+		if sorted[i].ID != nil {
+			left = *sorted[i].ID
+		}
+		if sorted[j].ID != nil {
+			right = *sorted[j].ID
+		}
+		return left < right
+	})
+	return &sorted
+}
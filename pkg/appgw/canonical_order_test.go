@@ -0,0 +1,114 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
+)
+
+var _ = Describe("Test the canonical order of the lists of the config", func() {
+	// build builds the config with the endpoints of the service at the given IPs, on an App Gateway with a pool of
+	// another owner with the given addresses, and returns its JSON.
+	build := func(endpointIPs []string, existingAddresses []string) string {
+		cb := newConfigBuilderFixture(nil)
+		var addresses []n.ApplicationGatewayBackendAddress
+		for _, ip := range existingAddresses {
+			addresses = append(addresses, n.ApplicationGatewayBackendAddress{IPAddress: to.StringPtr(ip)})
+		}
+		cb.appGw.BackendAddressPools = &[]n.ApplicationGatewayBackendAddressPool{
+			{
+				Name: to.StringPtr("brownfield-pool"),
+				ApplicationGatewayBackendAddressPoolPropertiesFormat: &n.ApplicationGatewayBackendAddressPoolPropertiesFormat{
+					BackendAddresses: &addresses,
+				},
+			},
+		}
+
+		service := tests.NewServiceFixture(*tests.NewServicePortsFixture()...)
+		_ = cb.k8sContext.Caches.Service.Add(service)
+		endpoints := tests.NewEndpointsFixture()
+		endpoints.Subsets[0].Addresses = nil
+		for _, ip := range endpointIPs {
+			endpoints.Subsets[0].Addresses = append(endpoints.Subsets[0].Addresses, v1.EndpointAddress{IP: ip})
+		}
+		_ = cb.k8sContext.Caches.Endpoints.Add(endpoints)
+
+		env := environment.GetFakeEnv()
+		env.EnableMultiInstance = true
+		cbCtx := &ConfigBuilderContext{
+			IngressList:           []*v1beta1.Ingress{tests.NewIngressFixture()},
+			ServiceList:           []*v1.Service{service},
+			EnvVariables:          env,
+			DefaultAddressPoolID:  to.StringPtr("xx"),
+			DefaultHTTPSettingsID: to.StringPtr("yy"),
+		}
+		Expect(cb.build(cbCtx)).ToNot(HaveOccurred())
+		jsonBlob, err := cb.appGw.MarshalJSON()
+		Expect(err).ToNot(HaveOccurred())
+		return string(jsonBlob)
+	}
+
+	It("builds the same config for endpoints listed in another order", func() {
+		config := build([]string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}, []string{"192.168.0.1", "192.168.0.2"})
+		Expect(config).To(ContainSubstring("192.168.0.1"), "the pool of another owner is kept")
+		Expect(build([]string{"10.0.0.3", "10.0.0.1", "10.0.0.2"}, []string{"192.168.0.1", "192.168.0.2"})).To(Equal(config))
+		Expect(build([]string{"10.0.0.2", "10.0.0.3", "10.0.0.1"}, []string{"192.168.0.2", "192.168.0.1"})).To(Equal(config),
+			"the addresses of the pools App Gateway returns are sorted too")
+	})
+
+	It("sorts the lists App Gateway does not order", func() {
+		cb := newConfigBuilderFixture(nil)
+		cb.appGw.BackendAddressPools = &[]n.ApplicationGatewayBackendAddressPool{
+			{
+				ApplicationGatewayBackendAddressPoolPropertiesFormat: &n.ApplicationGatewayBackendAddressPoolPropertiesFormat{
+					BackendAddresses: &[]n.ApplicationGatewayBackendAddress{
+						{IPAddress: to.StringPtr("10.0.0.2")},
+						{Fqdn: to.StringPtr("contoso.com")},
+						{IPAddress: to.StringPtr("10.0.0.1")},
+					},
+				},
+			},
+		}
+		cb.appGw.Probes = &[]n.ApplicationGatewayProbe{
+			{
+				ApplicationGatewayProbePropertiesFormat: &n.ApplicationGatewayProbePropertiesFormat{
+					Match: &n.ApplicationGatewayProbeHealthResponseMatch{
+						StatusCodes: &[]string{"401", "200-399"},
+					},
+				},
+			},
+		}
+		cb.appGw.BackendHTTPSettingsCollection = &[]n.ApplicationGatewayBackendHTTPSettings{
+			{
+				ApplicationGatewayBackendHTTPSettingsPropertiesFormat: &n.ApplicationGatewayBackendHTTPSettingsPropertiesFormat{
+					TrustedRootCertificates: &[]n.SubResource{{ID: to.StringPtr("root-b")}, {ID: to.StringPtr("root-a")}},
+				},
+			},
+		}
+
+		cb.sortUnorderedLists()
+
+		Expect(*(*cb.appGw.BackendAddressPools)[0].BackendAddresses).To(Equal([]n.ApplicationGatewayBackendAddress{
+			{IPAddress: to.StringPtr("10.0.0.1")},
+			{IPAddress: to.StringPtr("10.0.0.2")},
+			{Fqdn: to.StringPtr("contoso.com")},
+		}))
+		Expect(*(*cb.appGw.Probes)[0].Match.StatusCodes).To(Equal([]string{"200-399", "401"}))
+		Expect(*(*cb.appGw.BackendHTTPSettingsCollection)[0].TrustedRootCertificates).To(Equal([]n.SubResource{
+			{ID: to.StringPtr("root-a")},
+			{ID: to.StringPtr("root-b")},
+		}))
+		Expect((*cb.appGw.BackendHTTPSettingsCollection)[0].AuthenticationCertificates).To(BeNil())
+	})
+})
@@ -12,6 +12,7 @@ import (
 
 	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
 	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/golang/glog"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/api/extensions/v1beta1"
 
@@ -20,6 +21,14 @@ import (
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/sorter"
 )
 
+// Reasons a TLS secret referenced by an ingress yields no certificate; these label the invalid TLS secret metric.
+const (
+	tlsSecretMissing     = "missing"
+	tlsSecretWrongType   = "wrong-type"
+	tlsSecretUnparseable = "unparseable"
+	tlsSecretPending     = "pending-issuance"
+)
+
 // getSslCertificates obtains all SSL Certificates for the given Ingress object.
 func (c *appGwConfigBuilder) getSslCertificates(cbCtx *ConfigBuilderContext) *[]n.ApplicationGatewaySslCertificate {
 	if c.mem.certs != nil {
@@ -28,9 +37,13 @@ func (c *appGwConfigBuilder) getSslCertificates(cbCtx *ConfigBuilderContext) *[]
 	secretIDCertificateMap := make(map[secretIdentifier]*string)
 
 	for _, ingress := range cbCtx.IngressList {
+		c.reportInvalidTLSSecrets(ingress, cbCtx)
 		for k, v := range c.getSecretToCertificateMap(ingress) {
 			secretIDCertificateMap[k] = v
 		}
+		for k, v := range c.getAutoSelectedSecretToCertificateMap(ingress, cbCtx.EnvVariables) {
+			secretIDCertificateMap[k] = v
+		}
 	}
 
 	var sslCertificates []n.ApplicationGatewaySslCertificate
@@ -49,6 +62,15 @@ func (c *appGwConfigBuilder) getSslCertificates(cbCtx *ConfigBuilderContext) *[]
 	return &sslCertificates
 }
 
+// getPfxCertificate returns the certificate of the TLS secret. The secret of a cert-manager Certificate yields no
+// certificate until cert-manager issued it.
+func (c *appGwConfigBuilder) getPfxCertificate(secretKey string) []byte {
+	if c.k8sContext.IsCertificatePending(secretKey) {
+		return nil
+	}
+	return c.k8sContext.CertificateSecretStore.GetPfxCertificate(secretKey)
+}
+
 func (c *appGwConfigBuilder) getSecretToCertificateMap(ingress *v1beta1.Ingress) map[secretIdentifier]*string {
 	secretIDCertificateMap := make(map[secretIdentifier]*string)
 	for _, tls := range ingress.Spec.TLS {
@@ -62,16 +84,66 @@ func (c *appGwConfigBuilder) getSecretToCertificateMap(ingress *v1beta1.Ingress)
 		}
 
 		// add hostname-tlsSecret mapping to a per-ingress map
-		if cert := c.k8sContext.CertificateSecretStore.GetPfxCertificate(tlsSecret.secretKey()); cert != nil {
+		if cert := c.getPfxCertificate(tlsSecret.secretKey()); cert != nil {
 			secretIDCertificateMap[tlsSecret] = to.StringPtr(base64.StdEncoding.EncodeToString(cert))
-		} else {
-			logLine := fmt.Sprintf("Unable to find the secret associated to secretId: [%s]", tlsSecret.secretKey())
-			c.recorder.Event(ingress, v1.EventTypeWarning, events.ReasonSecretNotFound, logLine)
 		}
 	}
 	return secretIDCertificateMap
 }
 
+// reportInvalidTLSSecrets emits an event on the ingress and counts each TLS secret of the ingress, which yields no
+// certificate. Hosts of such a secret get no HTTPS listener; the rest of the config is not affected.
+func (c *appGwConfigBuilder) reportInvalidTLSSecrets(ingress *v1beta1.Ingress, cbCtx *ConfigBuilderContext) {
+	for _, tls := range ingress.Spec.TLS {
+		if len(tls.SecretName) == 0 {
+			continue
+		}
+
+		tlsSecret := secretIdentifier{
+			Name:      tls.SecretName,
+			Namespace: ingress.Namespace,
+		}
+		if cert := c.getPfxCertificate(tlsSecret.secretKey()); cert != nil {
+			continue
+		}
+
+		reason := c.getInvalidTLSSecretReason(tlsSecret.secretKey())
+		if cbCtx.MetricStore != nil {
+			cbCtx.MetricStore.IncInvalidTLSSecretCounter(reason)
+		}
+		if reason == tlsSecretPending {
+			logLine := fmt.Sprintf("The certificate of secretId: [%s] is not issued by cert-manager yet; its hosts get an HTTPS listener once it is", tlsSecret.secretKey())
+			glog.V(3).Infof("[%s/%s] %s", ingress.Namespace, ingress.Name, logLine)
+			events.Normal(c.recorder, ingress, events.ReasonCertificatePending, logLine)
+			continue
+		}
+		if reason == tlsSecretMissing {
+			logLine := fmt.Sprintf("Unable to find the secret associated to secretId: [%s]", tlsSecret.secretKey())
+			glog.Warningf("[%s/%s] %s", ingress.Namespace, ingress.Name, logLine)
+			events.Warning(c.recorder, ingress, events.ReasonSecretNotFound, logLine)
+			continue
+		}
+		logLine := fmt.Sprintf("Unable to use the secret associated to secretId: [%s] as a TLS certificate (%s)", tlsSecret.secretKey(), reason)
+		glog.Warningf("[%s/%s] %s", ingress.Namespace, ingress.Name, logLine)
+		events.Warning(c.recorder, ingress, events.ReasonInvalidSecret, logLine)
+	}
+}
+
+// getInvalidTLSSecretReason tells why a TLS secret yields no certificate.
+func (c *appGwConfigBuilder) getInvalidTLSSecretReason(secretKey string) string {
+	if c.k8sContext.IsCertificatePending(secretKey) {
+		return tlsSecretPending
+	}
+	secret, exists, err := c.k8sContext.Caches.Secret.GetByKey(secretKey)
+	if err != nil || !exists {
+		return tlsSecretMissing
+	}
+	if secret.(*v1.Secret).Type != v1.SecretTypeTLS {
+		return tlsSecretWrongType
+	}
+	return tlsSecretUnparseable
+}
+
 func (c *appGwConfigBuilder) getCertificate(ingress *v1beta1.Ingress, hostname string, hostnameSecretIDMap map[string]secretIdentifier) (*string, *secretIdentifier) {
 	if hostnameSecretIDMap == nil {
 		return nil, nil
@@ -107,7 +179,7 @@ func (c *appGwConfigBuilder) newHostToSecretMap(ingress *v1beta1.Ingress) map[st
 		}
 
 		// add hostname-tlsSecret mapping to a per-ingress map
-		cert := c.k8sContext.CertificateSecretStore.GetPfxCertificate(tlsSecret.secretKey())
+		cert := c.getPfxCertificate(tlsSecret.secretKey())
 		if cert == nil {
 			continue
 		}
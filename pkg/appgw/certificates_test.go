@@ -1,9 +1,17 @@
 package appgw
 
 import (
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/metricstore"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
 )
 
 // appgw_suite_test.go launches these Ginkgo tests
@@ -77,3 +85,150 @@ var _ = Describe("Testing function newHostToSecretMap", func() {
 		})
 	})
 })
+
+// invalidTLSSecretCounter counts the invalid TLS secrets by reason.
+type invalidTLSSecretCounter struct {
+	metricstore.MetricStore
+	reasons map[string]int
+}
+
+func (ms *invalidTLSSecretCounter) IncInvalidTLSSecretCounter(reason string) {
+	ms.reasons[reason]++
+}
+
+var _ = Describe("Testing the reporting of invalid TLS secrets", func() {
+	const brokenSecret = "broken-secret"
+
+	var cb appGwConfigBuilder
+	var recorder *record.FakeRecorder
+	var counter *invalidTLSSecretCounter
+	var cbCtx *ConfigBuilderContext
+
+	newSecret := func(secretType v1.SecretType) *v1.Secret {
+		return &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      brokenSecret,
+				Namespace: tests.Namespace,
+			},
+			Type: secretType,
+			Data: map[string][]byte{"tls.crt": []byte("not a certificate")},
+		}
+	}
+
+	BeforeEach(func() {
+		cb = newConfigBuilderFixture(nil)
+		recorder = record.NewFakeRecorder(10)
+		cb.recorder = recorder
+		counter = &invalidTLSSecretCounter{
+			MetricStore: metricstore.NewFakeMetricStore(),
+			reasons:     make(map[string]int),
+		}
+
+		ingress := tests.NewIngressFixture()
+		ingress.Spec.TLS = append(ingress.Spec.TLS, v1beta1.IngressTLS{
+			Hosts:      []string{"broken.contoso.com"},
+			SecretName: brokenSecret,
+		})
+		cbCtx = &ConfigBuilderContext{
+			IngressList: []*v1beta1.Ingress{ingress},
+			MetricStore: counter,
+		}
+	})
+
+	It("reports a missing secret and keeps the other certificates", func() {
+		certificates := cb.getSslCertificates(cbCtx)
+		Expect(*certificates).To(HaveLen(1))
+		Expect(counter.reasons).To(Equal(map[string]int{tlsSecretMissing: 1}))
+		Expect(recorder.Events).To(Receive(And(
+			HavePrefix(v1.EventTypeWarning+" "+string(events.ReasonSecretNotFound)),
+			ContainSubstring(brokenSecret),
+		)))
+		Expect(recorder.Events).ToNot(Receive())
+	})
+
+	It("reports a malformed secret", func() {
+		_ = cb.k8sContext.Caches.Secret.Add(newSecret(v1.SecretTypeTLS))
+		certificates := cb.getSslCertificates(cbCtx)
+		Expect(*certificates).To(HaveLen(1))
+		Expect(counter.reasons).To(Equal(map[string]int{tlsSecretUnparseable: 1}))
+		Expect(recorder.Events).To(Receive(HavePrefix(v1.EventTypeWarning + " " + string(events.ReasonInvalidSecret))))
+	})
+
+	It("reports a secret which is not of type kubernetes.io/tls", func() {
+		_ = cb.k8sContext.Caches.Secret.Add(newSecret(v1.SecretTypeOpaque))
+		cb.getSslCertificates(cbCtx)
+		Expect(counter.reasons).To(Equal(map[string]int{tlsSecretWrongType: 1}))
+		Expect(recorder.Events).To(Receive(HavePrefix(v1.EventTypeWarning + " " + string(events.ReasonInvalidSecret))))
+	})
+})
+
+var _ = Describe("Testing the cert-manager Certificates of TLS secrets", func() {
+	var cb appGwConfigBuilder
+	var recorder *record.FakeRecorder
+	var counter *invalidTLSSecretCounter
+	var cbCtx *ConfigBuilderContext
+
+	newCertificate := func(secretName string, ready string, notAfter string) *unstructured.Unstructured {
+		certificate := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "cert-manager.io/v1",
+			"kind":       "Certificate",
+			"metadata": map[string]interface{}{
+				"name":      "contoso",
+				"namespace": tests.Namespace,
+			},
+			"spec": map[string]interface{}{
+				"secretName": secretName,
+			},
+			"status": map[string]interface{}{
+				"conditions": []interface{}{
+					map[string]interface{}{"type": "Ready", "status": ready},
+				},
+			},
+		}}
+		if notAfter != "" {
+			_ = unstructured.SetNestedField(certificate.Object, notAfter, "status", "notAfter")
+		}
+		return certificate
+	}
+
+	BeforeEach(func() {
+		cb = newConfigBuilderFixture(nil)
+		cb.k8sContext.Caches.Certificate = cache.NewStore(keyFunc)
+		recorder = record.NewFakeRecorder(10)
+		cb.recorder = recorder
+		counter = &invalidTLSSecretCounter{
+			MetricStore: metricstore.NewFakeMetricStore(),
+			reasons:     make(map[string]int),
+		}
+		cbCtx = &ConfigBuilderContext{
+			IngressList: []*v1beta1.Ingress{tests.NewIngressFixture()},
+			MetricStore: counter,
+		}
+	})
+
+	It("defers the certificate until it is issued", func() {
+		_ = cb.k8sContext.Caches.Certificate.Add(newCertificate(tests.NameOfSecret, "False", ""))
+		Expect(*cb.getSslCertificates(cbCtx)).To(BeEmpty())
+		Expect(cb.newHostToSecretMap(cbCtx.IngressList[0])).To(BeEmpty())
+		Expect(counter.reasons).To(HaveKey(tlsSecretPending))
+		Expect(counter.reasons).To(HaveLen(1))
+		Expect(recorder.Events).To(Receive(HavePrefix("Normal CertificatePending")))
+	})
+
+	It("binds the certificate once it is ready", func() {
+		_ = cb.k8sContext.Caches.Certificate.Add(newCertificate(tests.NameOfSecret, "True", "2030-01-01T00:00:00Z"))
+		Expect(*cb.getSslCertificates(cbCtx)).To(HaveLen(1))
+		Expect(counter.reasons).To(BeEmpty())
+		Expect(recorder.Events).ToNot(Receive())
+	})
+
+	It("keeps the certificate issued before while it is renewed", func() {
+		_ = cb.k8sContext.Caches.Certificate.Add(newCertificate(tests.NameOfSecret, "False", "2030-01-01T00:00:00Z"))
+		Expect(*cb.getSslCertificates(cbCtx)).To(HaveLen(1))
+	})
+
+	It("ignores the Certificates of other secrets", func() {
+		_ = cb.k8sContext.Caches.Certificate.Add(newCertificate("other-secret", "False", ""))
+		Expect(*cb.getSslCertificates(cbCtx)).To(HaveLen(1))
+	})
+})
@@ -0,0 +1,40 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/azure/tags"
+)
+
+// Cleanup gets a pointer to the App Gateway config without the objects owned by this AGIC instance; it is used when AGIC
+// is uninstalled. The config is built as if there were no ingresses, keeping the objects AGIC does not own: objects of
+// other AGIC instances, objects created out-of-band and, with brownfield deployment, objects of AzureIngressProhibitedTargets.
+// App Gateway requires at least one listener, rule, backend pool and HTTP setting, so AGIC's default ones are left in place.
+func (c *appGwConfigBuilder) Cleanup(cbCtx *ConfigBuilderContext) (*n.ApplicationGateway, error) {
+	cleanupCtx := *cbCtx
+	cleanupCtx.IngressList = nil
+	cleanupCtx.ServiceList = nil
+	cleanupCtx.Rewrites = nil
+	cleanupCtx.EnvVariables.EnableMultiInstance = true
+	cleanupCtx.EnvVariables.EnableIstioIntegration = false
+
+	if err := c.build(&cleanupCtx); err != nil {
+		return nil, err
+	}
+
+	c.removeTags()
+
+	return &c.appGw, nil
+}
+
+// removeTags removes the tags identifying the App Gateway as managed by AGIC.
+func (c *appGwConfigBuilder) removeTags() {
+	for _, tag := range []string{tags.ManagedByK8sIngress, tags.IngressForAKSClusterID, tags.LastUpdatedByK8sIngress} {
+		delete(c.appGw.Tags, tag)
+	}
+}
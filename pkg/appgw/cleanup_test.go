@@ -0,0 +1,154 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"sort"
+	"strings"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/azure/tags"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
+)
+
+var _ = Describe("Test cleanup of the objects owned by an AGIC instance", func() {
+	// newBuilder returns a config builder with one ingress for the host, on top of the given App Gateway config.
+	newBuilder := func(host string, appGw n.ApplicationGatewayPropertiesFormat) (*appGwConfigBuilder, *ConfigBuilderContext) {
+		cb := newConfigBuilderFixture(nil)
+		cb.appGw.ApplicationGatewayPropertiesFormat = &appGw
+
+		service := tests.NewServiceFixture(*tests.NewServicePortsFixture()...)
+		ingress := tests.NewIngressFixture()
+		ingress.Annotations[annotations.SslRedirectKey] = "false"
+		ingress.Spec.TLS = nil
+		ingress.Spec.Rules = ingress.Spec.Rules[:1]
+		ingress.Spec.Rules[0].Host = host
+		_ = cb.k8sContext.Caches.Endpoints.Add(tests.NewEndpointsFixture())
+		_ = cb.k8sContext.Caches.Service.Add(service)
+		_ = cb.k8sContext.Caches.Ingress.Add(ingress)
+
+		env := environment.GetFakeEnv()
+		env.EnableMultiInstance = true
+		cbCtx := &ConfigBuilderContext{
+			IngressList:           []*v1beta1.Ingress{ingress},
+			ServiceList:           []*v1.Service{service},
+			EnvVariables:          env,
+			DefaultAddressPoolID:  to.StringPtr(cb.appGwIdentifier.AddressPoolID(DefaultBackendAddressPoolName)),
+			DefaultHTTPSettingsID: to.StringPtr(cb.appGwIdentifier.HTTPSettingsID(DefaultBackendHTTPSettingsName)),
+			ExistingPortsByNumber: make(map[Port]n.ApplicationGatewayFrontendPort),
+		}
+		if appGw.FrontendPorts != nil {
+			for _, port := range *appGw.FrontendPorts {
+				cbCtx.ExistingPortsByNumber[Port(*port.Port)] = port
+			}
+		}
+		return &cb, cbCtx
+	}
+
+	withPrefix := func(prefix string, f func()) {
+		originalPrefix := agPrefix
+		agPrefix = prefix
+		defer func() { agPrefix = originalPrefix }()
+		f()
+	}
+
+	build := func(prefix string, host string, appGw n.ApplicationGatewayPropertiesFormat) n.ApplicationGatewayPropertiesFormat {
+		var result n.ApplicationGatewayPropertiesFormat
+		withPrefix(prefix, func() {
+			cb, cbCtx := newBuilder(host, appGw)
+			Expect(cb.build(cbCtx)).ToNot(HaveOccurred())
+			result = *cb.appGw.ApplicationGatewayPropertiesFormat
+		})
+		return result
+	}
+
+	namesOf := func(appGw n.ApplicationGatewayPropertiesFormat) []string {
+		var names []string
+		for _, listener := range *appGw.HTTPListeners {
+			names = append(names, *listener.Name)
+		}
+		for _, rule := range *appGw.RequestRoutingRules {
+			names = append(names, *rule.Name)
+		}
+		for _, pathMap := range *appGw.URLPathMaps {
+			names = append(names, *pathMap.Name)
+		}
+		for _, pool := range *appGw.BackendAddressPools {
+			names = append(names, *pool.Name)
+		}
+		for _, setting := range *appGw.BackendHTTPSettingsCollection {
+			names = append(names, *setting.Name)
+		}
+		for _, probe := range *appGw.Probes {
+			names = append(names, *probe.Name)
+		}
+		for _, port := range *appGw.FrontendPorts {
+			names = append(names, *port.Name)
+		}
+		return names
+	}
+
+	filterByPrefix := func(names []string, prefix string, owned bool) []string {
+		var filtered []string
+		for _, name := range names {
+			if strings.HasPrefix(name, prefix) == owned {
+				filtered = append(filtered, name)
+			}
+		}
+		sort.Strings(filtered)
+		return filtered
+	}
+
+	var shared n.ApplicationGatewayPropertiesFormat
+	var defaults n.ApplicationGatewayPropertiesFormat
+	var cleaned *n.ApplicationGateway
+
+	BeforeEach(func() {
+		shared = build("b-", "b.contoso.com", build("a-", "a.contoso.com", *NewAppGwyConfigFixture()))
+
+		withPrefix("a-", func() {
+			// The objects of an instance without ingresses are the defaults App Gateway can not do without.
+			cb, cbCtx := newBuilder("", *NewAppGwyConfigFixture())
+			cbCtx.IngressList = nil
+			cbCtx.ServiceList = nil
+			Expect(cb.build(cbCtx)).ToNot(HaveOccurred())
+			defaults = *cb.appGw.ApplicationGatewayPropertiesFormat
+
+			cb, cbCtx = newBuilder("a.contoso.com", shared)
+			cb.appGw.Tags = map[string]*string{
+				tags.ManagedByK8sIngress: to.StringPtr("a"),
+				"owner":                  to.StringPtr("team"),
+			}
+			var err error
+			cleaned, err = cb.Cleanup(cbCtx)
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	It("removes the objects of the instance, except for the defaults", func() {
+		owned := filterByPrefix(namesOf(*cleaned.ApplicationGatewayPropertiesFormat), "a-", true)
+		Expect(owned).To(Equal(filterByPrefix(namesOf(defaults), "a-", true)))
+		Expect(namesOf(shared)).To(ContainElement(HavePrefix("a-pool-")))
+		Expect(owned).ToNot(ContainElement(HavePrefix("a-pool-")))
+	})
+
+	It("keeps the objects of the other instance", func() {
+		Expect(filterByPrefix(namesOf(*cleaned.ApplicationGatewayPropertiesFormat), "a-", false)).To(Equal(filterByPrefix(namesOf(shared), "a-", false)))
+	})
+
+	It("removes the tags of AGIC", func() {
+		Expect(cleaned.Tags).ToNot(HaveKey(tags.ManagedByK8sIngress))
+		Expect(cleaned.Tags).To(HaveKey("owner"))
+	})
+})
@@ -0,0 +1,118 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"strconv"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/golang/glog"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+)
+
+// getMaxPoolAddresses returns the number of ready endpoints set with APPGW_MAX_POOL_ADDRESSES, above which the pool of
+// a service holds its cluster IP, or 0 when it is not set.
+func getMaxPoolAddresses(env environment.EnvVariables) int {
+	if env.MaxPoolAddresses == "" {
+		return 0
+	}
+	max, err := strconv.Atoi(env.MaxPoolAddresses)
+	if err != nil {
+		return 0
+	}
+	return max
+}
+
+// clusterIPFallback returns the cluster IP of the service of the backend, when the service has more ready endpoints
+// than its backend pool may hold; App Gateway then sends the requests to the cluster IP, which balances them among the
+// pods. It returns an empty string, when the pool holds the IPs of the pods, along with the number of ready endpoints.
+func (c *appGwConfigBuilder) clusterIPFallback(cbCtx *ConfigBuilderContext, backendID backendIdentifier) (string, int) {
+	max := getMaxPoolAddresses(cbCtx.EnvVariables)
+	if max == 0 {
+		return "", 0
+	}
+	service := c.k8sContext.GetService(backendID.serviceKey())
+	if service == nil || service.Spec.Type == v1.ServiceTypeExternalName || service.Spec.ClusterIP == "" || service.Spec.ClusterIP == v1.ClusterIPNone {
+		// Headless services have no cluster IP to fall back to.
+		return "", 0
+	}
+	endpoints, err := c.k8sContext.GetEndpointsByService(backendID.serviceKey())
+	if err != nil {
+		return "", 0
+	}
+
+	ready := make(map[string]interface{})
+	for _, subset := range endpoints.Subsets {
+		for _, address := range subset.Addresses {
+			if len(address.IP) != 0 {
+				ready[address.IP] = nil
+			} else if len(address.Hostname) != 0 {
+				ready[address.Hostname] = nil
+			}
+		}
+	}
+	if len(ready) <= max {
+		return "", len(ready)
+	}
+	return service.Spec.ClusterIP, len(ready)
+}
+
+// reportClusterIPFallbacks logs the backend pools, which switch between the IPs of the pods and the cluster IP of their
+// service, and counts the services, whose pool holds their cluster IP.
+func (c *appGwConfigBuilder) reportClusterIPFallbacks(cbCtx *ConfigBuilderContext, pools []n.ApplicationGatewayBackendAddressPool) {
+	if getMaxPoolAddresses(cbCtx.EnvVariables) == 0 {
+		return
+	}
+
+	existingPools := make(map[string]n.ApplicationGatewayBackendAddressPool)
+	if c.appGw.BackendAddressPools != nil {
+		for _, pool := range *c.appGw.BackendAddressPools {
+			existingPools[*pool.Name] = pool
+		}
+	}
+
+	clusterIPByPool := make(map[string]string)
+	fallbackServices := make(map[string]interface{})
+	_, _, serviceBackendPairMap, _ := c.getBackendsAndSettingsMap(cbCtx)
+	for backendID, serviceBackendPair := range serviceBackendPairMap {
+		service := c.k8sContext.GetService(backendID.serviceKey())
+		if service == nil {
+			continue
+		}
+		clusterIPByPool[c.getAddressPoolName(backendID, serviceBackendPair)] = service.Spec.ClusterIP
+		if clusterIP, _ := c.clusterIPFallback(cbCtx, backendID); clusterIP != "" {
+			fallbackServices[backendID.serviceKey()] = nil
+		}
+	}
+
+	for _, pool := range pools {
+		clusterIP, managed := clusterIPByPool[*pool.Name]
+		existing, exists := existingPools[*pool.Name]
+		if !managed || !exists {
+			continue
+		}
+		wasClusterIP, isClusterIP := holdsOnlyAddress(existing, clusterIP), holdsOnlyAddress(pool, clusterIP)
+		if !wasClusterIP && isClusterIP {
+			glog.Infof("Backend pool %s switches from the IPs of the pods to the cluster IP %s of their service", *pool.Name, clusterIP)
+		} else if wasClusterIP && !isClusterIP {
+			glog.Infof("Backend pool %s switches back from the cluster IP %s to the IPs of the pods of the service", *pool.Name, clusterIP)
+		}
+	}
+
+	if cbCtx.MetricStore != nil {
+		cbCtx.MetricStore.SetClusterIPFallbackServices(len(fallbackServices))
+	}
+}
+
+func holdsOnlyAddress(pool n.ApplicationGatewayBackendAddressPool, ip string) bool {
+	if ip == "" || pool.ApplicationGatewayBackendAddressPoolPropertiesFormat == nil || pool.BackendAddresses == nil {
+		return false
+	}
+	addresses := *pool.BackendAddresses
+	return len(addresses) == 1 && addresses[0].IPAddress != nil && *addresses[0].IPAddress == ip
+}
@@ -0,0 +1,130 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"fmt"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/metricstore"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
+)
+
+// clusterIPFallbackGauge records the number of services, whose pool holds their cluster IP.
+type clusterIPFallbackGauge struct {
+	metricstore.MetricStore
+	services int
+}
+
+func (ms *clusterIPFallbackGauge) SetClusterIPFallbackServices(count int) {
+	ms.services = count
+}
+
+var _ = Describe("Falling back to the cluster IP of services with too many endpoints", func() {
+	const clusterIP = "10.0.0.42"
+
+	var cb appGwConfigBuilder
+	var cbCtx *ConfigBuilderContext
+	var gauge *clusterIPFallbackGauge
+
+	setReadyEndpoints := func(count int) {
+		endpoints := tests.NewEndpointsFixture()
+		endpoints.Subsets[0].Addresses = nil
+		for i := 0; i < count; i++ {
+			endpoints.Subsets[0].Addresses = append(endpoints.Subsets[0].Addresses, v1.EndpointAddress{IP: fmt.Sprintf("10.9.8.%d", i+1)})
+		}
+		_ = cb.k8sContext.Caches.Endpoints.Update(endpoints)
+	}
+
+	build := func() ([]n.ApplicationGatewayBackendAddress, n.ApplicationGatewayBackendHTTPSettings) {
+		cb.mem = memoization{}
+		Expect(cb.BackendHTTPSettingsCollection(cbCtx)).ToNot(HaveOccurred())
+		Expect(cb.BackendAddressPools(cbCtx)).ToNot(HaveOccurred())
+
+		var addresses []n.ApplicationGatewayBackendAddress
+		for _, pool := range *cb.appGw.BackendAddressPools {
+			if *pool.Name != DefaultBackendAddressPoolName {
+				addresses = append(addresses, *pool.BackendAddresses...)
+			}
+		}
+		var settings []n.ApplicationGatewayBackendHTTPSettings
+		for _, setting := range *cb.appGw.BackendHTTPSettingsCollection {
+			if *setting.Name != DefaultBackendHTTPSettingsName {
+				settings = append(settings, setting)
+			}
+		}
+		Expect(settings).To(HaveLen(1))
+		return addresses, settings[0]
+	}
+
+	BeforeEach(func() {
+		cb = newConfigBuilderFixture(nil)
+		service := tests.NewServiceFixture(*tests.NewServicePortsFixture()...)
+		service.Spec.ClusterIP = clusterIP
+		_ = cb.k8sContext.Caches.Service.Add(service)
+		_ = cb.k8sContext.Caches.Endpoints.Add(tests.NewEndpointsFixture())
+		setReadyEndpoints(3)
+
+		ingress := tests.NewIngressFixture()
+		ingress.Spec.Rules = ingress.Spec.Rules[:1]
+		ingress.Spec.Rules[0].HTTP.Paths = ingress.Spec.Rules[0].HTTP.Paths[:1]
+		gauge = &clusterIPFallbackGauge{MetricStore: metricstore.NewFakeMetricStore()}
+		env := environment.GetFakeEnv()
+		env.MaxPoolAddresses = "2"
+		cbCtx = &ConfigBuilderContext{
+			IngressList:           []*v1beta1.Ingress{ingress},
+			ServiceList:           []*v1.Service{service},
+			EnvVariables:          env,
+			MetricStore:           gauge,
+			DefaultAddressPoolID:  to.StringPtr("xx"),
+			DefaultHTTPSettingsID: to.StringPtr("yy"),
+		}
+	})
+
+	It("holds the cluster IP in the pool of a service with more ready endpoints than the maximum", func() {
+		addresses, settings := build()
+		Expect(addresses).To(Equal([]n.ApplicationGatewayBackendAddress{{IPAddress: to.StringPtr(clusterIP)}}))
+		Expect(*settings.Port).To(Equal(int32(80)), "the cluster IP serves the port of the service")
+		Expect(gauge.services).To(Equal(1))
+	})
+
+	It("switches back to the IPs of the pods when the endpoints shrink", func() {
+		_, _ = build()
+
+		setReadyEndpoints(2)
+		addresses, settings := build()
+		Expect(addresses).To(ConsistOf(
+			n.ApplicationGatewayBackendAddress{IPAddress: to.StringPtr("10.9.8.1")},
+			n.ApplicationGatewayBackendAddress{IPAddress: to.StringPtr("10.9.8.2")},
+		))
+		Expect(*settings.Port).To(Equal(int32(tests.ContainerPort)))
+		Expect(gauge.services).To(Equal(0))
+	})
+
+	It("keeps the IPs of the pods of a headless service", func() {
+		service := tests.NewServiceFixture(*tests.NewServicePortsFixture()...)
+		service.Spec.ClusterIP = v1.ClusterIPNone
+		_ = cb.k8sContext.Caches.Service.Update(service)
+
+		addresses, _ := build()
+		Expect(addresses).To(HaveLen(3))
+		Expect(gauge.services).To(Equal(0))
+	})
+
+	It("keeps the IPs of the pods without a maximum", func() {
+		cbCtx.EnvVariables.MaxPoolAddresses = ""
+		addresses, settings := build()
+		Expect(addresses).To(HaveLen(3))
+		Expect(*settings.Port).To(Equal(int32(tests.ContainerPort)))
+	})
+})
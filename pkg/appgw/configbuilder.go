@@ -19,6 +19,7 @@ import (
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/azure"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/azure/tags"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/brownfield"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/k8scontext"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/version"
@@ -33,6 +34,7 @@ type Clock interface {
 type ConfigBuilder interface {
 	PreBuildValidate(cbCtx *ConfigBuilderContext) error
 	Build(cbCtx *ConfigBuilderContext) (*n.ApplicationGateway, error)
+	Cleanup(cbCtx *ConfigBuilderContext) (*n.ApplicationGateway, error)
 	PostBuildValidate(cbCtx *ConfigBuilderContext) error
 }
 
@@ -51,6 +53,9 @@ type memoization struct {
 	certs                        *[]n.ApplicationGatewaySslCertificate
 	redirectConfigs              *[]n.ApplicationGatewayRedirectConfiguration
 	ports                        *[]n.ApplicationGatewayFrontendPort
+
+	// listenerPortsInUse is collected from the ingresses of the build along with the listener configs; see getListenerPort.
+	listenerPortsInUse listenerPortsInUse
 }
 
 type appGwConfigBuilder struct {
@@ -60,6 +65,12 @@ type appGwConfigBuilder struct {
 	recorder        record.EventRecorder
 	mem             memoization
 	clock           Clock
+
+	// resolvedCache, when set, holds what earlier builds resolved from the Kubernetes resources; see ResolvedCache.
+	resolvedCache *ResolvedCache
+
+	// recordedConflicts are the conflicts between ingresses the build already emitted an event for.
+	recordedConflicts map[conflictKey]interface{}
 }
 
 // NewConfigBuilder construct a builder
@@ -75,23 +86,38 @@ func NewConfigBuilder(context *k8scontext.Context, appGwIdentifier *Identifier,
 
 // Build gets a pointer to updated ApplicationGatewayPropertiesFormat.
 func (c *appGwConfigBuilder) Build(cbCtx *ConfigBuilderContext) (*n.ApplicationGateway, error) {
+	if err := c.build(cbCtx); err != nil {
+		return nil, err
+	}
+
+	c.addTags()
+
+	return &c.appGw, nil
+}
+
+func (c *appGwConfigBuilder) build(cbCtx *ConfigBuilderContext) error {
+	c.resolvedCache = cbCtx.ResolvedCache
+
+	// Snapshot of the existing config, before any of it is replaced with the generated config.
+	existing := brownfield.NewExistingResources(c.appGw, nil, nil)
+
 	err := c.HealthProbesCollection(cbCtx)
 	if err != nil {
 		glog.Errorf("unable to generate Health Probes, error [%v]", err)
-		return nil, ErrGeneratingProbes
+		return ErrGeneratingProbes
 	}
 
 	err = c.BackendHTTPSettingsCollection(cbCtx)
 	if err != nil {
 		glog.Errorf("unable to generate backend http settings, error [%v]", err)
-		return nil, ErrGeneratingBackendSettings
+		return ErrGeneratingBackendSettings
 	}
 
 	// BackendAddressPools depend on BackendHTTPSettings
 	err = c.BackendAddressPools(cbCtx)
 	if err != nil {
 		glog.Errorf("unable to generate backend address pools, error [%v]", err)
-		return nil, ErrCreatingBackendPools
+		return ErrCreatingBackendPools
 	}
 
 	// Listener configures the frontend listeners
@@ -101,19 +127,48 @@ func (c *appGwConfigBuilder) Build(cbCtx *ConfigBuilderContext) (*n.ApplicationG
 	err = c.Listeners(cbCtx)
 	if err != nil {
 		glog.Errorf("unable to generate frontend listeners, error [%v]", err)
-		return nil, ErrGeneratingListeners
+		return ErrGeneratingListeners
 	}
 
 	// SSL redirection configurations created elsewhere will be attached to the appropriate rule in this step.
 	err = c.RequestRoutingRules(cbCtx)
 	if err != nil {
 		glog.Errorf("unable to generate request routing rules, error [%v]", err)
-		return nil, ErrGeneratingRoutingRules
+		return ErrGeneratingRoutingRules
 	}
 
-	c.addTags()
+	// Rewrite rule sets are attached to the routing rules and path maps generated in the step above.
+	c.RewriteRuleSets(cbCtx)
 
-	return &c.appGw, nil
+	// Listeners routing identically share one path map, once nothing else changes the path maps per listener.
+	c.shareURLPathMaps(cbCtx)
+
+	// Ingresses annotated with manage-backend-only keep the listeners, rules and settings found on the gateway.
+	c.preserveBackendOnlyIngresses(cbCtx, existing)
+
+	// The generated objects matching objects of a hand-built App Gateway take over their names.
+	if cbCtx.EnvVariables.AdoptExistingConfig {
+		c.adoptExistingResources(existing)
+	}
+
+	// Other AGIC instances may be sharing this App Gateway; leave their objects untouched.
+	if cbCtx.EnvVariables.EnableMultiInstance {
+		c.retainUnownedResources(existing)
+	}
+
+	// Priorities are assigned once the rules AGIC keeps from the existing gateway are known.
+	if err := c.setRulePriorities(cbCtx, existing); err != nil {
+		glog.Errorf("unable to set request routing rule priorities, error [%v]", err)
+		return err
+	}
+
+	// The capacity of App Gateway follows the autoscale schedule, when one is set.
+	c.applyAutoscaleSchedule(cbCtx)
+
+	// Lists App Gateway does not order are sorted last, so that equivalent configs compare equal.
+	c.sortUnorderedLists()
+
+	return nil
 }
 
 type valFunc func(eventRecorder record.EventRecorder, config *n.ApplicationGatewayPropertiesFormat, envVariables environment.EnvVariables, ingressList []*v1beta1.Ingress, serviceList []*v1.Service) error
@@ -123,6 +178,7 @@ func (c *appGwConfigBuilder) PreBuildValidate(cbCtx *ConfigBuilderContext) error
 
 	validationFunctions := []valFunc{
 		validateServiceDefinition,
+		validateTLSHosts,
 	}
 
 	return c.runValidationFunctions(cbCtx, validationFunctions)
@@ -0,0 +1,133 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"strings"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
+)
+
+var _ = Describe("Test the default backend of an ingress", func() {
+	const defaultService = "default-service"
+
+	var configBuilder appGwConfigBuilder
+	var recorder *record.FakeRecorder
+	var ingress *v1beta1.Ingress
+	var cbCtx *ConfigBuilderContext
+
+	BeforeEach(func() {
+		configBuilder = newConfigBuilderFixture(nil)
+		recorder = record.NewFakeRecorder(100)
+		configBuilder.recorder = recorder
+
+		service := tests.NewServiceFixture(*tests.NewServicePortsFixture()...)
+		defaultSvc := tests.NewServiceFixture(*tests.NewServicePortsFixture()...)
+		defaultSvc.Name = defaultService
+		defaultEndpoints := tests.NewEndpointsFixture()
+		defaultEndpoints.Name = defaultService
+		defaultEndpoints.Subsets[0].Addresses = []v1.EndpointAddress{{IP: "10.9.9.9"}}
+		_ = configBuilder.k8sContext.Caches.Service.Add(service)
+		_ = configBuilder.k8sContext.Caches.Service.Add(defaultSvc)
+		_ = configBuilder.k8sContext.Caches.Endpoints.Add(tests.NewEndpointsFixture())
+		_ = configBuilder.k8sContext.Caches.Endpoints.Add(defaultEndpoints)
+
+		ingress = tests.NewIngressFixture()
+		ingress.Annotations[annotations.SslRedirectKey] = "false"
+		ingress.Spec.TLS = nil
+		ingress.Spec.Rules = ingress.Spec.Rules[:1]
+		ingress.Spec.Backend = tests.NewIngressBackendFixture(defaultService, 80)
+		_ = configBuilder.k8sContext.Caches.Ingress.Add(ingress)
+
+		cbCtx = &ConfigBuilderContext{
+			IngressList:           []*v1beta1.Ingress{ingress},
+			ServiceList:           []*v1.Service{service, defaultSvc},
+			EnvVariables:          environment.GetFakeEnv(),
+			DefaultAddressPoolID:  to.StringPtr(configBuilder.appGwIdentifier.AddressPoolID(DefaultBackendAddressPoolName)),
+			DefaultHTTPSettingsID: to.StringPtr(configBuilder.appGwIdentifier.HTTPSettingsID(DefaultBackendHTTPSettingsName)),
+			ExistingPortsByNumber: make(map[Port]n.ApplicationGatewayFrontendPort),
+		}
+	})
+
+	build := func() {
+		Expect(configBuilder.HealthProbesCollection(cbCtx)).ToNot(HaveOccurred())
+		Expect(configBuilder.BackendHTTPSettingsCollection(cbCtx)).ToNot(HaveOccurred())
+		Expect(configBuilder.BackendAddressPools(cbCtx)).ToNot(HaveOccurred())
+		Expect(configBuilder.Listeners(cbCtx)).ToNot(HaveOccurred())
+		Expect(configBuilder.RequestRoutingRules(cbCtx)).ToNot(HaveOccurred())
+	}
+
+	It("routes the paths of the host to their backend and the rest to the default backend", func() {
+		build()
+		Expect(*configBuilder.appGw.URLPathMaps).To(HaveLen(1))
+		pathMap := (*configBuilder.appGw.URLPathMaps)[0]
+		Expect(*pathMap.DefaultBackendAddressPool.ID).To(ContainSubstring(defaultService))
+		Expect(*pathMap.DefaultBackendHTTPSettings.ID).To(ContainSubstring(defaultService))
+		Expect(*pathMap.PathRules).To(HaveLen(1))
+		pathRule := (*pathMap.PathRules)[0]
+		Expect(*pathRule.Paths).To(Equal([]string{tests.URLPath1}))
+		Expect(*pathRule.BackendAddressPool.ID).To(ContainSubstring(tests.ServiceName))
+		Expect(*pathRule.BackendAddressPool.ID).ToNot(ContainSubstring(defaultService))
+
+		var poolNames []string
+		for _, pool := range *configBuilder.appGw.BackendAddressPools {
+			poolNames = append(poolNames, *pool.Name)
+		}
+		Expect(strings.Join(poolNames, ",")).To(ContainSubstring(defaultService))
+	})
+	It("routes a host without paths to the default backend", func() {
+		ingress.Spec.Rules = append(ingress.Spec.Rules, v1beta1.IngressRule{Host: "other.contoso.com"})
+		build()
+
+		var listenerName string
+		for _, listener := range *configBuilder.appGw.HTTPListeners {
+			if listener.HostName != nil && *listener.HostName == "other.contoso.com" {
+				listenerName = *listener.Name
+			}
+		}
+		Expect(listenerName).ToNot(BeEmpty())
+
+		var rules []n.ApplicationGatewayRequestRoutingRule
+		for _, rule := range *configBuilder.appGw.RequestRoutingRules {
+			if strings.HasSuffix(*rule.HTTPListener.ID, "/"+listenerName) {
+				rules = append(rules, rule)
+			}
+		}
+		Expect(rules).To(HaveLen(1))
+		Expect(rules[0].RuleType).To(Equal(n.Basic))
+		Expect(*rules[0].BackendAddressPool.ID).To(ContainSubstring(defaultService))
+	})
+
+	It("skips a host without paths when the ingress has no default backend", func() {
+		ingress.Spec.Backend = nil
+		ingress.Spec.Rules = append(ingress.Spec.Rules, v1beta1.IngressRule{Host: "other.contoso.com"})
+		build()
+		for _, listener := range *configBuilder.appGw.HTTPListeners {
+			Expect(listener.HostName).ToNot(Equal(to.StringPtr("other.contoso.com")))
+		}
+	})
+
+	It("emits an event when the default backend service does not exist", func() {
+		ingress.Spec.Backend = tests.NewIngressBackendFixture("missing-service", 80)
+		Expect(configBuilder.PreBuildValidate(cbCtx)).ToNot(HaveOccurred())
+		Expect(recorder.Events).To(Receive(ContainSubstring("missing-service")))
+
+		// Paths without a backend fall back to the default backend of AGIC, rather than failing the config.
+		build()
+		pathMap := (*configBuilder.appGw.URLPathMaps)[0]
+		Expect(pathMap.DefaultBackendAddressPool.ID).To(Equal(cbCtx.DefaultAddressPoolID))
+	})
+})
@@ -52,4 +52,85 @@ var (
 
 	// ErrCreatingBackendPools is an error.
 	ErrCreatingBackendPools = errors.New("unable to generate backend address pools (APPG015)")
+
+	// ErrRequireSNIWithoutHostname is an error.
+	ErrRequireSNIWithoutHostname = errors.New("require-sni is only allowed on HTTPS listeners with a host name; a listener without a host name serves a single certificate to all clients (APPG016)")
+
+	// ErrRequireSNIWithoutTLS is an error.
+	ErrRequireSNIWithoutTLS = errors.New("require-sni is only allowed on ingresses with TLS configured (APPG017)")
+
+	// ErrBufferingRequiresV2SKU is an error.
+	ErrBufferingRequiresV2SKU = errors.New("request and response buffering can only be configured on Standard_v2 and WAF_v2 App Gateways; buffering remains enabled (APPG018)")
+
+	// ErrBufferingNotSupported is an error.
+	ErrBufferingNotSupported = errors.New("request and response buffering can not be configured with App Gateway API version 2019-09-01 used by AGIC; buffering remains enabled (APPG019)")
+
+	// ErrGRPCNotSupported is an error.
+	ErrGRPCNotSupported = errors.New("backend-protocol grpc is not supported, as App Gateway speaks HTTP/1.1 to the backends while gRPC requires HTTP/2; the annotation is ignored and the backends are proxied over HTTP (APPG020)")
+
+	// ErrPriorityRulesInClassicMode is an error.
+	ErrPriorityRulesInClassicMode = errors.New("App Gateway has request routing rules with a priority, which AGIC does not manage, while AGIC is configured for classic rule evaluation; set APPGW_ROUTING_RULE_EVALUATION to priority or remove the priority of these rules (APPG022)")
+
+	// ErrClassicRulesInPriorityMode is an error.
+	ErrClassicRulesInPriorityMode = errors.New("App Gateway has request routing rules without a priority, which AGIC does not manage, while AGIC is configured for priority rule evaluation; set APPGW_ROUTING_RULE_EVALUATION to classic or assign a priority to these rules (APPG023)")
+
+	// ErrInvalidMaxConnections is an error.
+	ErrInvalidMaxConnections = errors.New("max-connections must be an integer between 1 and 65535; the annotation is ignored (APPG024)")
+
+	// ErrMaxConnectionsNotSupported is an error.
+	ErrMaxConnectionsNotSupported = errors.New("a limit on connections to the backends can not be configured with App Gateway API version 2019-09-01 used by AGIC; connections are not limited (APPG025)")
+
+	// ErrPickHostNameConflict is an error.
+	ErrPickHostNameConflict = errors.New("pick-host-name-from-backend can not be combined with backend-hostname; the host header is set to backend-hostname (APPG026)")
+
+	// ErrInvalidRewriteCondition is an error.
+	ErrInvalidRewriteCondition = errors.New("a rewrite condition must have either a queryString, which is a valid regular expression, or requestMethods; the rewrite is ignored (APPG027)")
+
+	// ErrRewriteURLPathNotSupported is an error.
+	ErrRewriteURLPathNotSupported = errors.New("the URL path can not be rewritten with App Gateway API version 2019-09-01 used by AGIC; the rewrite is ignored (APPG028)")
+
+	// ErrInvalidRewriteHeader is an error.
+	ErrInvalidRewriteHeader = errors.New("the name of a rewritten request header must be a valid HTTP header name; the rewrite is ignored (APPG029)")
+
+	// ErrInvalidProbeMatchBody is an error.
+	ErrInvalidProbeMatchBody = errors.New("health-probe-match-body must not be longer than 4090 characters; the annotation is ignored (APPG030)")
+
+	// ErrInvalidProbeStatusCodes is an error.
+	ErrInvalidProbeStatusCodes = errors.New("health-probe-status-codes must be a comma separated list of status codes and ranges between 200 and 499, such as 200-299,404; the annotation is ignored (APPG031)")
+
+	// ErrInvalidListenerPort is an error.
+	ErrInvalidListenerPort = errors.New("listener-port must be an integer between 1 and 65535; the listeners of the ingress are created on the default ports (APPG032)")
+
+	// ErrListenerPortReserved is an error.
+	ErrListenerPortReserved = errors.New("listener-port is reserved by App Gateway, which uses ports 65200 - 65535 on v2 SKUs and 65503 - 65534 on v1 SKUs; the listeners of the ingress are created on the default ports (APPG033)")
+
+	// ErrFrontendIPConfigurationNotFound is an error.
+	ErrFrontendIPConfigurationNotFound = errors.New("The frontend IP configuration named by APPGW_FRONTEND_IP_CONFIGURATION must be present in the Application Gateway FrontendIPConfiguration (APPG034)")
+
+	// ErrBackendHostPortRequiresV2 is an error.
+	ErrBackendHostPortRequiresV2 = errors.New("backend-host-port rewrites the host header, which requires a Standard_v2 or WAF_v2 App Gateway; the annotation is ignored (APPG035)")
+
+	// ErrBackendHostPortConflict is an error.
+	ErrBackendHostPortConflict = errors.New("backend-host-port can not be combined with picking the host name from the backend address; the annotation is ignored (APPG036)")
+
+	// ErrBackendHostPortRewriteConflict is an error.
+	ErrBackendHostPortRewriteConflict = errors.New("backend-host-port can not be applied to a routing rule which already has a rewrite; the host header of the rule is not rewritten (APPG037)")
+
+	// ErrInvalidRewriteMethod is an error.
+	ErrInvalidRewriteMethod = errors.New("the requestMethods of a rewrite condition must be HTTP methods in upper case, such as GET or POST; the rewrite is ignored (APPG038)")
+
+	// ErrProbePickHostNameConflict is an error.
+	ErrProbePickHostNameConflict = errors.New("health-probe-pick-host-name-from-backend-http-settings can not be combined with health-probe-hostname; the health probes send the health-probe-hostname (APPG039)")
+
+	// ErrProbePickHostNameWithoutHostName is an error.
+	ErrProbePickHostNameWithoutHostName = errors.New("health-probe-pick-host-name-from-backend-http-settings requires HTTP settings with a host name, from backend-hostname or pick-host-name-from-backend; the annotation is ignored (APPG040)")
+
+	// ErrInvalidHTTPListenerPort is an error.
+	ErrInvalidHTTPListenerPort = errors.New("http-listener-port must be an integer between 1 and 65535, which App Gateway does not reserve; the HTTP listeners of the ingress are created on the default port (APPG041)")
+
+	// ErrHTTPListenerPortConflict is an error.
+	ErrHTTPListenerPortConflict = errors.New("http-listener-port is the port of HTTPS listeners, of this or other ingresses; the HTTP listeners of the ingress are created on the default port (APPG042)")
+
+	// ErrListenerPortProtocolConflict is an error.
+	ErrListenerPortProtocolConflict = errors.New("listener-port is a port of listeners of the other protocol, of this or other ingresses, while a frontend port serves one protocol; the listeners of the ingress are created on the default ports (APPG043)")
 )
@@ -0,0 +1,80 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/golang/glog"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+)
+
+var fqdnRegex = regexp.MustCompile(`^([a-zA-Z0-9]([-a-zA-Z0-9]{0,61}[a-zA-Z0-9])?\.)*[a-zA-Z0-9]([-a-zA-Z0-9]{0,61}[a-zA-Z0-9])?\.?$`)
+
+// isExternalNameBackend determines whether the backend is a service of type ExternalName.
+// ExternalName services are DNS aliases and have no endpoints; App Gateway routes to the FQDN of the service instead.
+func (c *appGwConfigBuilder) isExternalNameBackend(backendID backendIdentifier) bool {
+	service := c.k8sContext.GetService(backendID.serviceKey())
+	return service != nil && service.Spec.Type == v1.ServiceTypeExternalName
+}
+
+// getExternalNameBackendAddressPool creates a backend pool with the FQDN of the ExternalName service as its only address,
+// or with the IPs of the FQDN, when AGIC resolves it.
+func (c *appGwConfigBuilder) getExternalNameBackendAddressPool(cbCtx *ConfigBuilderContext, backendID backendIdentifier, serviceBackendPair serviceBackendPortPair, addressPools map[string]*n.ApplicationGatewayBackendAddressPool) *n.ApplicationGatewayBackendAddressPool {
+	service := c.k8sContext.GetService(backendID.serviceKey())
+	if !isValidFQDN(service.Spec.ExternalName) {
+		logLine := fmt.Sprintf("Service %s of type ExternalName has an invalid externalName %q", backendID.serviceKey(), service.Spec.ExternalName)
+		glog.Error(logLine)
+		events.Warning(c.recorder, backendID.Ingress, events.ReasonInvalidExternalName, logLine)
+		return nil
+	}
+
+	poolName := generateAddressPoolName(backendID.serviceFullName(), backendID.Backend.ServicePort.String(), serviceBackendPair.BackendPort)
+	if pool, ok := addressPools[poolName]; ok {
+		return pool
+	}
+	addresses := []n.ApplicationGatewayBackendAddress{
+		{Fqdn: to.StringPtr(service.Spec.ExternalName)},
+	}
+	if ips := c.resolveExternalName(cbCtx, service); len(ips) != 0 {
+		addresses = nil
+		for _, ip := range ips {
+			addresses = append(addresses, n.ApplicationGatewayBackendAddress{IPAddress: to.StringPtr(ip)})
+		}
+	}
+	return &n.ApplicationGatewayBackendAddressPool{
+		Etag: to.StringPtr("*"),
+		Name: &poolName,
+		ID:   to.StringPtr(c.appGwIdentifier.AddressPoolID(poolName)),
+		ApplicationGatewayBackendAddressPoolPropertiesFormat: &n.ApplicationGatewayBackendAddressPoolPropertiesFormat{
+			BackendAddresses: &addresses,
+		},
+	}
+}
+
+// resolveExternalName returns the IPs of the FQDN of the ExternalName service, when AGIC resolves FQDNs, and counts
+// their changes. It returns no IPs, when the FQDN is left to App Gateway to resolve.
+func (c *appGwConfigBuilder) resolveExternalName(cbCtx *ConfigBuilderContext, service *v1.Service) []string {
+	interval := GetFQDNRefreshInterval(cbCtx.EnvVariables)
+	if cbCtx.FQDNResolutions == nil || interval == 0 {
+		return nil
+	}
+	ips, changed := cbCtx.FQDNResolutions.resolve(strings.TrimSuffix(service.Spec.ExternalName, "."), interval, c.clock.Now())
+	if changed && cbCtx.MetricStore != nil {
+		cbCtx.MetricStore.IncFQDNResolutionChangeCounter()
+	}
+	return ips
+}
+
+func isValidFQDN(name string) bool {
+	return len(name) != 0 && len(name) <= 253 && fqdnRegex.MatchString(name)
+}
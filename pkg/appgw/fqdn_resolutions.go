@@ -0,0 +1,124 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+)
+
+// fqdnResolveTimeout bounds the resolution of one FQDN during a build.
+const fqdnResolveTimeout = 5 * time.Second
+
+// Resolver resolves host names to IP addresses; net.DefaultResolver implements it.
+type Resolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// FQDNResolutions resolves the FQDNs of ExternalName services for their backend pools, which then hold IPs rather
+// than the FQDN. App Gateway resolves an FQDN in a pool itself, but does not notice when its IPs change; the FQDNs are
+// resolved again once the refresh interval ends, and the pools follow their IPs.
+// A failed resolution keeps the IPs of the last successful one, so the pool is not emptied by a DNS outage.
+// FQDNResolutions outlives the config builder; it is shared by consecutive builds.
+type FQDNResolutions struct {
+	sync.Mutex
+	resolver    Resolver
+	resolved    map[string]fqdnResolution
+	reconcileAt time.Time
+}
+
+type fqdnResolution struct {
+	ips []string
+	at  time.Time
+}
+
+// NewFQDNResolutions creates a new FQDNResolutions struct, which resolves with the given resolver.
+func NewFQDNResolutions(resolver Resolver) *FQDNResolutions {
+	return &FQDNResolutions{
+		resolver: resolver,
+		resolved: make(map[string]fqdnResolution),
+	}
+}
+
+// GetFQDNRefreshInterval returns the interval set with APPGW_FQDN_REFRESH_INTERVAL_SECONDS, or 0 when it is not set.
+func GetFQDNRefreshInterval(env environment.EnvVariables) time.Duration {
+	if env.FQDNRefreshInterval == "" {
+		return 0
+	}
+	seconds, err := strconv.Atoi(env.FQDNRefreshInterval)
+	if err != nil {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// resolve returns the IPs of the FQDN, sorted, which are resolved again once the interval since the last resolution
+// ends. It also returns whether the IPs changed since the last resolution. No IPs are returned when the FQDN was never
+// resolved successfully.
+func (r *FQDNResolutions) resolve(fqdn string, interval time.Duration, now time.Time) ([]string, bool) {
+	r.Lock()
+	defer r.Unlock()
+
+	last, exists := r.resolved[fqdn]
+	if exists && now.Before(last.at.Add(interval)) {
+		return last.ips, false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), fqdnResolveTimeout)
+	defer cancel()
+	addresses, err := r.resolver.LookupIPAddr(ctx, fqdn)
+	if err != nil || len(addresses) == 0 {
+		glog.Warningf("Unable to resolve FQDN %s, keeping %d IPs of the last resolution: %v", fqdn, len(last.ips), err)
+		r.resolved[fqdn] = fqdnResolution{ips: last.ips, at: now}
+		return last.ips, false
+	}
+
+	ips := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		ips = append(ips, address.IP.String())
+	}
+	sort.Strings(ips)
+	r.resolved[fqdn] = fqdnResolution{ips: ips, at: now}
+
+	changed := exists && len(last.ips) != 0 && !reflect.DeepEqual(last.ips, ips)
+	if changed {
+		glog.Infof("IPs of FQDN %s changed from %v to %v", fqdn, last.ips, ips)
+	}
+	return ips, changed
+}
+
+// PendingReconcile returns the delay until the earliest refresh of a resolved FQDN, unless a reconcile was already
+// requested for it. The caller is expected to reconcile after the delay, so that the FQDNs are resolved again even if
+// nothing else changes. The FQDNs, which were not resolved for a whole interval, are no longer in use; they are dropped.
+func (r *FQDNResolutions) PendingReconcile(interval time.Duration, now time.Time) (time.Duration, bool) {
+	r.Lock()
+	defer r.Unlock()
+	var earliest time.Time
+	for fqdn, resolution := range r.resolved {
+		refreshAt := resolution.at.Add(interval)
+		if refreshAt.Add(interval).Before(now) {
+			delete(r.resolved, fqdn)
+			continue
+		}
+		if earliest.IsZero() || refreshAt.Before(earliest) {
+			earliest = refreshAt
+		}
+	}
+	if earliest.IsZero() || earliest.Equal(r.reconcileAt) {
+		return 0, false
+	}
+	r.reconcileAt = earliest
+	return earliest.Sub(now), true
+}
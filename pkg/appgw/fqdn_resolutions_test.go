@@ -0,0 +1,171 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/metricstore"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
+)
+
+// fakeResolver resolves the host names to the IPs it is given, or fails with its error.
+type fakeResolver struct {
+	ips     map[string][]string
+	err     error
+	lookups int
+}
+
+func (r *fakeResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	r.lookups++
+	if r.err != nil {
+		return nil, r.err
+	}
+	var addresses []net.IPAddr
+	for _, ip := range r.ips[host] {
+		addresses = append(addresses, net.IPAddr{IP: net.ParseIP(ip)})
+	}
+	return addresses, nil
+}
+
+// fqdnResolutionChangeCounter counts the changes of the IPs of the resolved FQDNs.
+type fqdnResolutionChangeCounter struct {
+	metricstore.MetricStore
+	changes int
+}
+
+func (ms *fqdnResolutionChangeCounter) IncFQDNResolutionChangeCounter() {
+	ms.changes++
+}
+
+var _ = Describe("Test the resolution of the FQDNs of ExternalName services", func() {
+	const fqdn = "api.contoso.com"
+	const interval = 60 * time.Second
+
+	var resolver *fakeResolver
+	var resolutions *FQDNResolutions
+	var clock *fakeClock
+	var counter *fqdnResolutionChangeCounter
+
+	// build returns the addresses of the pools of the ExternalName service and its HTTP settings.
+	build := func() ([]n.ApplicationGatewayBackendAddress, []n.ApplicationGatewayBackendHTTPSettings) {
+		cb := newConfigBuilderFixture(nil)
+		cb.clock = clock
+		service := tests.NewServiceFixture()
+		service.Spec.Type = v1.ServiceTypeExternalName
+		service.Spec.ExternalName = fqdn
+		service.Spec.Selector = nil
+		_ = cb.k8sContext.Caches.Service.Add(service)
+
+		ingress := tests.NewIngressFixture()
+		ingress.Spec.Rules = ingress.Spec.Rules[:1]
+		ingress.Spec.Rules[0].HTTP.Paths = ingress.Spec.Rules[0].HTTP.Paths[:1]
+		env := environment.GetFakeEnv()
+		env.FQDNRefreshInterval = "60"
+		cbCtx := &ConfigBuilderContext{
+			IngressList:           []*v1beta1.Ingress{ingress},
+			ServiceList:           []*v1.Service{service},
+			EnvVariables:          env,
+			FQDNResolutions:       resolutions,
+			MetricStore:           counter,
+			DefaultAddressPoolID:  to.StringPtr("xx"),
+			DefaultHTTPSettingsID: to.StringPtr("yy"),
+		}
+		Expect(cb.BackendHTTPSettingsCollection(cbCtx)).ToNot(HaveOccurred())
+		Expect(cb.BackendAddressPools(cbCtx)).ToNot(HaveOccurred())
+
+		var addresses []n.ApplicationGatewayBackendAddress
+		for _, pool := range *cb.appGw.BackendAddressPools {
+			if *pool.Name != DefaultBackendAddressPoolName {
+				addresses = append(addresses, *pool.BackendAddresses...)
+			}
+		}
+		var settings []n.ApplicationGatewayBackendHTTPSettings
+		for _, setting := range *cb.appGw.BackendHTTPSettingsCollection {
+			if *setting.Name != DefaultBackendHTTPSettingsName {
+				settings = append(settings, setting)
+			}
+		}
+		return addresses, settings
+	}
+
+	ipAddresses := func(ips ...string) []n.ApplicationGatewayBackendAddress {
+		var addresses []n.ApplicationGatewayBackendAddress
+		for _, ip := range ips {
+			addresses = append(addresses, n.ApplicationGatewayBackendAddress{IPAddress: to.StringPtr(ip)})
+		}
+		return addresses
+	}
+
+	BeforeEach(func() {
+		resolver = &fakeResolver{ips: map[string][]string{fqdn: {"20.0.0.2", "20.0.0.1"}}}
+		resolutions = NewFQDNResolutions(resolver)
+		clock = &fakeClock{now: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+		counter = &fqdnResolutionChangeCounter{MetricStore: metricstore.NewFakeMetricStore()}
+	})
+
+	It("puts the IPs of the FQDN in the pool, with the FQDN as the host name", func() {
+		addresses, settings := build()
+		Expect(addresses).To(Equal(ipAddresses("20.0.0.1", "20.0.0.2")))
+		Expect(settings).To(HaveLen(1))
+		Expect(*settings[0].HostName).To(Equal(fqdn))
+		Expect(settings[0].PickHostNameFromBackendAddress).To(BeNil())
+	})
+
+	It("follows the IPs of the FQDN once the refresh interval ends", func() {
+		_, _ = build()
+		resolver.ips[fqdn] = []string{"20.0.0.3"}
+
+		clock.now = clock.now.Add(interval / 2)
+		addresses, _ := build()
+		Expect(addresses).To(Equal(ipAddresses("20.0.0.1", "20.0.0.2")), "the IPs are not resolved again within the interval")
+
+		clock.now = clock.now.Add(interval)
+		addresses, _ = build()
+		Expect(addresses).To(Equal(ipAddresses("20.0.0.3")))
+		Expect(counter.changes).To(Equal(1))
+	})
+
+	It("keeps the IPs of the last resolution when the FQDN can not be resolved", func() {
+		_, _ = build()
+		resolver.err = errors.New("no such host")
+
+		clock.now = clock.now.Add(interval)
+		addresses, _ := build()
+		Expect(addresses).To(Equal(ipAddresses("20.0.0.1", "20.0.0.2")))
+		Expect(counter.changes).To(BeZero())
+	})
+
+	It("leaves the FQDN in the pool when it was never resolved", func() {
+		resolver.err = errors.New("no such host")
+		addresses, _ := build()
+		Expect(addresses).To(Equal([]n.ApplicationGatewayBackendAddress{{Fqdn: to.StringPtr(fqdn)}}))
+	})
+
+	It("requests a reconcile for the next refresh", func() {
+		_, _ = build()
+		delay, pending := resolutions.PendingReconcile(interval, clock.now)
+		Expect(pending).To(BeTrue())
+		Expect(delay).To(Equal(interval))
+
+		_, pending = resolutions.PendingReconcile(interval, clock.now)
+		Expect(pending).To(BeFalse(), "the reconcile was already requested")
+
+		_, pending = resolutions.PendingReconcile(interval, clock.now.Add(3*interval))
+		Expect(pending).To(BeFalse(), "the FQDN is no longer in use")
+	})
+})
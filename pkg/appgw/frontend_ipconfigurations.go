@@ -1,35 +1,105 @@
 package appgw
 
 import (
+	"strings"
+
 	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
 )
 
-// LookupIPConfigurationByType gets the public or private address depending upon privateIP parameter.
+// LookupIPConfigurationByType gets the public or private address depending upon privateIP parameter. When the gateway
+// has several configurations of that kind, it gets the first one ARM lists, which keeps the same order across updates.
 func LookupIPConfigurationByType(frontendIPConfigurations *[]n.ApplicationGatewayFrontendIPConfiguration, privateIP bool) *n.ApplicationGatewayFrontendIPConfiguration {
+	if frontendIPConfigurations == nil {
+		return nil
+	}
 	for _, ip := range *frontendIPConfigurations {
-		if ip.ApplicationGatewayFrontendIPConfigurationPropertiesFormat != nil &&
-			((privateIP && ip.PrivateIPAddress != nil) ||
-				(!privateIP && ip.PublicIPAddress != nil)) {
+		if isIPConfigurationOfType(&ip, privateIP) {
 			return &ip
 		}
 	}
+	return nil
+}
 
+// lookupIPConfigurationOfOwnedListeners gets the public or private frontend IP configuration, which the first listener
+// generated by this AGIC instance using that kind of IP is bound to; or nil, when there is no such listener.
+func lookupIPConfigurationOfOwnedListeners(frontendIPConfigurations *[]n.ApplicationGatewayFrontendIPConfiguration, listeners *[]n.ApplicationGatewayHTTPListener, privateIP bool) *n.ApplicationGatewayFrontendIPConfiguration {
+	if listeners == nil {
+		return nil
+	}
+	for _, listener := range *listeners {
+		if !isOwnedResource(listener.Name) || listener.ApplicationGatewayHTTPListenerPropertiesFormat == nil || listener.FrontendIPConfiguration == nil {
+			continue
+		}
+		ip := LookupIPConfigurationByID(frontendIPConfigurations, listener.FrontendIPConfiguration.ID)
+		if isIPConfigurationOfType(ip, privateIP) {
+			return ip
+		}
+	}
 	return nil
 }
 
-// LookupIPConfigurationByID gets by ID.
+func isIPConfigurationOfType(ip *n.ApplicationGatewayFrontendIPConfiguration, privateIP bool) bool {
+	return ip != nil && ip.ApplicationGatewayFrontendIPConfigurationPropertiesFormat != nil &&
+		((privateIP && ip.PrivateIPAddress != nil) || (!privateIP && ip.PublicIPAddress != nil))
+}
+
+// LookupIPConfigurationByID gets by ID. ARM does not preserve the case of the resource IDs, so they are compared
+// regardless of case.
 func LookupIPConfigurationByID(frontendIPConfigurations *[]n.ApplicationGatewayFrontendIPConfiguration, ID *string) *n.ApplicationGatewayFrontendIPConfiguration {
+	if frontendIPConfigurations == nil || ID == nil {
+		return nil
+	}
+	for _, ip := range *frontendIPConfigurations {
+		if ip.ID != nil && strings.EqualFold(*ip.ID, *ID) {
+			return &ip
+		}
+	}
+	return nil
+}
+
+// LookupIPConfigurationByName gets by name.
+func LookupIPConfigurationByName(frontendIPConfigurations *[]n.ApplicationGatewayFrontendIPConfiguration, name string) *n.ApplicationGatewayFrontendIPConfiguration {
 	for _, ip := range *frontendIPConfigurations {
-		if *ip.ID == *ID {
+		if ip.Name != nil && *ip.Name == name {
 			return &ip
 		}
 	}
 	return nil
 }
 
+// LookupIPConfigurationForListener gets the frontend IP configuration listeners using a private or public IP bind to:
+// the one named by APPGW_FRONTEND_IP_CONFIGURATION when it is of that kind; otherwise the one the listeners of this AGIC
+// instance, among the given listeners of the gateway, are already bound to, so that the listeners do not move to
+// another IP; otherwise the first one of that kind.
+func LookupIPConfigurationForListener(frontendIPConfigurations *[]n.ApplicationGatewayFrontendIPConfiguration, listeners *[]n.ApplicationGatewayHTTPListener, env environment.EnvVariables, privateIP bool) *n.ApplicationGatewayFrontendIPConfiguration {
+	if env.FrontendIPConfiguration != "" {
+		ip := LookupIPConfigurationByName(frontendIPConfigurations, env.FrontendIPConfiguration)
+		if ip != nil && IsPrivateIPConfiguration(ip) == privateIP {
+			return ip
+		}
+	}
+	if ip := lookupIPConfigurationOfOwnedListeners(frontendIPConfigurations, listeners, privateIP); ip != nil {
+		return ip
+	}
+	return LookupIPConfigurationByType(frontendIPConfigurations, privateIP)
+}
+
+// UsePrivateIPByDefault returns true if ingresses not annotated with use-private-ip use the private IP: when
+// APPGW_FRONTEND_IP_CONFIGURATION names a private IP configuration, or else when USE_PRIVATE_IP is true.
+func UsePrivateIPByDefault(frontendIPConfigurations *[]n.ApplicationGatewayFrontendIPConfiguration, env environment.EnvVariables) bool {
+	if env.FrontendIPConfiguration != "" {
+		if ip := LookupIPConfigurationByName(frontendIPConfigurations, env.FrontendIPConfiguration); ip != nil {
+			return IsPrivateIPConfiguration(ip)
+		}
+	}
+	return env.UsePrivateIP == "true"
+}
+
 // IsPrivateIPConfiguration returns true if frontendIPConfiguration uses private IP
 func IsPrivateIPConfiguration(frontendIPConfiguration *n.ApplicationGatewayFrontendIPConfiguration) bool {
-	if frontendIPConfiguration.ApplicationGatewayFrontendIPConfigurationPropertiesFormat != nil && frontendIPConfiguration.PrivateIPAddress != nil {
+	if frontendIPConfiguration != nil && frontendIPConfiguration.ApplicationGatewayFrontendIPConfigurationPropertiesFormat != nil && frontendIPConfiguration.PrivateIPAddress != nil {
 		return true
 	}
 	return false
@@ -0,0 +1,115 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"strings"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/api/extensions/v1beta1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
+)
+
+var _ = Describe("choice of the frontend IP configuration of the listeners", func() {
+	publicIP := func(name string) n.ApplicationGatewayFrontendIPConfiguration {
+		return n.ApplicationGatewayFrontendIPConfiguration{
+			Name: to.StringPtr(name),
+			ID:   to.StringPtr("/x/y/z/frontendIPConfigurations/" + name),
+			ApplicationGatewayFrontendIPConfigurationPropertiesFormat: &n.ApplicationGatewayFrontendIPConfigurationPropertiesFormat{
+				PublicIPAddress: &n.SubResource{ID: to.StringPtr("/x/y/z/publicIPAddresses/" + name)},
+			},
+		}
+	}
+	privateIP := func(name string) n.ApplicationGatewayFrontendIPConfiguration {
+		return n.ApplicationGatewayFrontendIPConfiguration{
+			Name: to.StringPtr(name),
+			ID:   to.StringPtr("/x/y/z/frontendIPConfigurations/" + name),
+			ApplicationGatewayFrontendIPConfigurationPropertiesFormat: &n.ApplicationGatewayFrontendIPConfigurationPropertiesFormat{
+				PrivateIPAddress: to.StringPtr("10.0.0.10"),
+			},
+		}
+	}
+
+	listenerOn := func(name string, ip n.ApplicationGatewayFrontendIPConfiguration) n.ApplicationGatewayHTTPListener {
+		return n.ApplicationGatewayHTTPListener{
+			Name: to.StringPtr(name),
+			ApplicationGatewayHTTPListenerPropertiesFormat: &n.ApplicationGatewayHTTPListenerPropertiesFormat{
+				FrontendIPConfiguration: resourceRef(*ip.ID),
+			},
+		}
+	}
+
+	It("chooses the first configuration of the kind ARM lists", func() {
+		configs := []n.ApplicationGatewayFrontendIPConfiguration{publicIP("public-b"), privateIP("private"), publicIP("Public-a")}
+		reversed := []n.ApplicationGatewayFrontendIPConfiguration{configs[2], configs[1], configs[0]}
+		env := environment.GetFakeEnv()
+		Expect(*LookupIPConfigurationForListener(&configs, nil, env, false).Name).To(Equal("public-b"))
+		Expect(*LookupIPConfigurationForListener(&reversed, nil, env, false).Name).To(Equal("Public-a"))
+		Expect(*LookupIPConfigurationForListener(&configs, nil, env, true).Name).To(Equal("private"))
+	})
+
+	It("keeps the configuration the listeners of AGIC are already bound to", func() {
+		configs := []n.ApplicationGatewayFrontendIPConfiguration{publicIP("public-a"), publicIP("public-b"), privateIP("private")}
+		env := environment.GetFakeEnv()
+
+		listeners := []n.ApplicationGatewayHTTPListener{
+			listenerOn("portal-listener", configs[0]),
+			listenerOn(agPrefix+prefixListener+"-existing", configs[1]),
+		}
+		Expect(*LookupIPConfigurationForListener(&configs, &listeners, env, false).Name).To(Equal("public-b"))
+		Expect(*LookupIPConfigurationForListener(&configs, &listeners, env, true).Name).To(Equal("private"))
+
+		unowned := []n.ApplicationGatewayHTTPListener{listenerOn("portal-listener", configs[1])}
+		Expect(*LookupIPConfigurationForListener(&configs, &unowned, env, false).Name).To(Equal("public-a"))
+
+		env.FrontendIPConfiguration = "public-a"
+		Expect(*LookupIPConfigurationForListener(&configs, &listeners, env, false).Name).To(Equal("public-a"))
+	})
+
+	It("does not move the listeners of an existing gateway to another frontend IP on upgrade", func() {
+		cb := newConfigBuilderFixture(nil)
+		// The listeners were bound to the first public IP ARM listed, which is not the first one by name.
+		configs := []n.ApplicationGatewayFrontendIPConfiguration{publicIP("public-a"), publicIP("public-b")}
+		existingListenerID, existingListenerName := newTestListenerID(Port(80), []string{tests.Host}, false)
+		existing := []n.ApplicationGatewayHTTPListener{listenerOn(existingListenerName, configs[1])}
+		cb.appGw.FrontendIPConfigurations = &configs
+		cb.appGw.HTTPListeners = &existing
+		cbCtx := &ConfigBuilderContext{
+			IngressList:  []*v1beta1.Ingress{tests.NewIngressFixture()},
+			EnvVariables: environment.GetFakeEnv(),
+		}
+
+		listeners, _ := cb.getListeners(cbCtx)
+		Expect(*listeners).ToNot(BeEmpty())
+		names := make(map[string]interface{})
+		for _, listener := range *listeners {
+			names[*listener.Name] = nil
+			Expect(*listener.FrontendIPConfiguration.ID).To(Equal(*configs[1].ID))
+		}
+		Expect(names).To(HaveKey(generateListenerName(existingListenerID)))
+	})
+
+	It("chooses the configuration named by APPGW_FRONTEND_IP_CONFIGURATION", func() {
+		configs := []n.ApplicationGatewayFrontendIPConfiguration{publicIP("public-a"), publicIP("public-b")}
+		env := environment.GetFakeEnv()
+		env.FrontendIPConfiguration = "public-b"
+		Expect(*LookupIPConfigurationForListener(&configs, nil, env, false).Name).To(Equal("public-b"))
+	})
+
+	It("finds a configuration by ID regardless of case", func() {
+		configs := *NewAppGwyConfigFixture().FrontendIPConfigurations
+		ip := LookupIPConfigurationByID(&configs, to.StringPtr(strings.ToUpper(tests.PrivateIPID)))
+		Expect(ip).ToNot(BeNil())
+		Expect(IsPrivateIPConfiguration(ip)).To(BeTrue())
+		Expect(LookupIPConfigurationByID(&configs, to.StringPtr("/x/y/z/unknown"))).To(BeNil())
+		Expect(IsPrivateIPConfiguration(nil)).To(BeFalse())
+	})
+})
@@ -6,6 +6,7 @@
 package appgw
 
 import (
+	"fmt"
 	"sort"
 
 	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
@@ -15,6 +16,7 @@ import (
 
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/brownfield"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/sorter"
 )
 
@@ -61,9 +63,15 @@ func (c *appGwConfigBuilder) getListeners(cbCtx *ConfigBuilderContext) (*[]n.App
 			sslCertificateID := c.appGwIdentifier.sslCertificateID(config.Secret.secretFullName())
 			listener.SslCertificate = resourceRef(sslCertificateID)
 		}
+		if config.Protocol == n.HTTPS && config.RequireServerNameIndication {
+			listener.RequireServerNameIndication = to.BoolPtr(true)
+		}
 		if config.FirewallPolicy != "" {
 			listener.FirewallPolicy = &n.SubResource{ID: to.StringPtr(config.FirewallPolicy)}
 		}
+		if config.MaintenancePageURL != "" {
+			listener.CustomErrorConfigurations = getMaintenancePageErrors(config.MaintenancePageURL)
+		}
 		listeners = append(listeners, *listener)
 	}
 
@@ -113,19 +121,52 @@ func (c *appGwConfigBuilder) getListenerConfigs(cbCtx *ConfigBuilderContext) map
 
 	// TODO(draychev): Emit an error event if 2 namespaces define different TLS for the same domain!
 	allListeners := make(map[listenerIdentifier]listenerAzConfig)
-	for _, ingress := range cbCtx.IngressList {
+	owners := make(map[listenerIdentifier]*v1beta1.Ingress)
+	c.mem.listenerPortsInUse = c.newListenerPortsInUse(cbCtx.IngressList, cbCtx.EnvVariables)
+	for _, ingress := range sortIngressesByPrecedence(cbCtx.IngressList) {
 		glog.V(5).Infof("Processing Rules for Ingress: %s/%s", ingress.Namespace, ingress.Name)
 		azListenerConfigs := c.getListenersFromIngress(ingress, cbCtx.EnvVariables)
+		if err := validateRequireSNI(ingress, azListenerConfigs); err != nil {
+			glog.Errorf("[%s/%s] %s", ingress.Namespace, ingress.Name, err)
+			events.Warning(c.recorder, ingress, events.ReasonInvalidAnnotation, err.Error())
+		}
+		if err := c.validateListenerPort(ingress, cbCtx.EnvVariables); err != nil {
+			glog.Errorf("[%s/%s] %s", ingress.Namespace, ingress.Name, err)
+			events.Warning(c.recorder, ingress, events.ReasonInvalidAnnotation, err.Error())
+		}
+		if _, err := c.getHTTPListenerPortAnnotation(ingress); err != nil {
+			glog.Errorf("[%s/%s] %s", ingress.Namespace, ingress.Name, err)
+			events.Warning(c.recorder, ingress, events.ReasonInvalidAnnotation, err.Error())
+		}
+		maintenancePageURL, err := annotations.MaintenancePageURL(ingress)
+		if err != nil && !annotations.IsMissingAnnotations(err) {
+			glog.Errorf("[%s/%s] %s", ingress.Namespace, ingress.Name, err)
+			events.Warning(c.recorder, ingress, events.ReasonInvalidAnnotation, err.Error())
+		}
 		for listenerID, azConfig := range azListenerConfigs {
 			if cbCtx.EnvVariables.AttachWAFPolicyToListener {
 				attachFirewallPolicy(cbCtx, ingress, &azConfig)
 			}
-			allListeners[listenerID] = azConfig
+			azConfig.MaintenancePageURL = maintenancePageURL
+
+			// Ingresses are processed in the order of their precedence: the first one defines the listener.
+			existing, exists := allListeners[listenerID]
+			if !exists {
+				allListeners[listenerID] = azConfig
+				owners[listenerID] = ingress
+				continue
+			}
+			if c.isCertificateConflict(existing, azConfig) {
+				c.recordConflict(ingress, owners[listenerID], listenerID, fmt.Sprintf("the TLS certificate %s", azConfig.Secret.secretKey()))
+				continue
+			}
+			allListeners[listenerID] = c.mergeListenerConfig(ingress, owners[listenerID], listenerID, existing, azConfig)
 		}
 	}
 
-	// App Gateway must have at least one listener - the default one!
-	if len(allListeners) == 0 {
+	// App Gateway must have at least one listener - the default one! It also answers the gateway health path.
+	_, hasDefaultListener := allListeners[defaultFrontendListenerIdentifier(cbCtx.EnvVariables)]
+	if len(allListeners) == 0 || (!hasDefaultListener && isGatewayHealthPathEnabled(cbCtx.EnvVariables)) {
 		listenerConfig := listenerAzConfig{
 			// Default protocol
 			Protocol: n.HTTP,
@@ -133,7 +174,7 @@ func (c *appGwConfigBuilder) getListenerConfigs(cbCtx *ConfigBuilderContext) map
 		if cbCtx.EnvVariables.AttachWAFPolicyToListener {
 			attachFirewallPolicy(cbCtx, nil, &listenerConfig)
 		}
-		allListeners[defaultFrontendListenerIdentifier()] = listenerConfig
+		allListeners[defaultFrontendListenerIdentifier(cbCtx.EnvVariables)] = listenerConfig
 	}
 
 	c.mem.listenerConfigs = &allListeners
@@ -141,7 +182,7 @@ func (c *appGwConfigBuilder) getListenerConfigs(cbCtx *ConfigBuilderContext) map
 }
 
 func (c *appGwConfigBuilder) newListener(cbCtx *ConfigBuilderContext, listenerID listenerIdentifier, protocol n.ApplicationGatewayProtocol, portsByNumber map[Port]n.ApplicationGatewayFrontendPort) (*n.ApplicationGatewayHTTPListener, *n.ApplicationGatewayFrontendPort, error) {
-	frontIPConfiguration := *LookupIPConfigurationByType(c.appGw.FrontendIPConfigurations, listenerID.UsePrivateIP)
+	frontIPConfiguration := *LookupIPConfigurationForListener(c.appGw.FrontendIPConfigurations, c.appGw.HTTPListeners, cbCtx.EnvVariables, listenerID.UsePrivateIP)
 	portNumber := listenerID.FrontendPort
 	var frontendPort n.ApplicationGatewayFrontendPort
 	var exists bool
@@ -163,7 +204,6 @@ func (c *appGwConfigBuilder) newListener(cbCtx *ConfigBuilderContext, listenerID
 		Name: to.StringPtr(listenerName),
 		ID:   to.StringPtr(c.appGwIdentifier.listenerID(listenerName)),
 		ApplicationGatewayHTTPListenerPropertiesFormat: &n.ApplicationGatewayHTTPListenerPropertiesFormat{
-			// TODO: expose this to external configuration
 			FrontendIPConfiguration: resourceRef(*frontIPConfiguration.ID),
 			FrontendPort:            resourceRef(*frontendPort.ID),
 			Protocol:                protocol,
@@ -225,18 +265,72 @@ func (c *appGwConfigBuilder) groupListenersByListenerIdentifier(cbCtx *ConfigBui
 	return listenersByID
 }
 
+// validateRequireSNI ensures the require-sni annotation is only used where App Gateway can honor it:
+// HTTPS listeners with a host name. A listener without a host name serves a single certificate.
+func validateRequireSNI(ingress *v1beta1.Ingress, listenerConfigs map[listenerIdentifier]listenerAzConfig) error {
+	requireSNI, err := annotations.RequireSNI(ingress)
+	if err != nil {
+		if annotations.IsMissingAnnotations(err) {
+			return nil
+		}
+		return err
+	}
+	if !requireSNI {
+		return nil
+	}
+
+	hasHTTPS := false
+	for listenerID, config := range listenerConfigs {
+		if config.Protocol != n.HTTPS {
+			continue
+		}
+		hasHTTPS = true
+		if len(listenerID.getHostNames()) == 0 {
+			return ErrRequireSNIWithoutHostname
+		}
+	}
+
+	if !hasHTTPS {
+		return ErrRequireSNIWithoutTLS
+	}
+	return nil
+}
+
+// isBasicListener determines whether the listener has no host name and accepts requests for all hosts on its port.
+func isBasicListener(listener n.ApplicationGatewayHTTPListener) bool {
+	if listener.ApplicationGatewayHTTPListenerPropertiesFormat == nil {
+		return false
+	}
+	hasHostName := listener.HostName != nil && *listener.HostName != ""
+	hasHostNames := listener.Hostnames != nil && len(*listener.Hostnames) != 0
+	return !hasHostName && !hasHostNames
+}
+
 func attachFirewallPolicy(cbCtx *ConfigBuilderContext, ingress *v1beta1.Ingress, azConfig *listenerAzConfig) {
 	if ingress != nil {
-		if policy, err := annotations.WAFPolicy(ingress); err == nil && policy != "" {
+		if policy, exists := getFirewallPolicy(cbCtx, ingress); exists && policy != "" {
 			azConfig.FirewallPolicy = policy
 		}
 	} else {
 		// See if we have an ingress annotated with a Firewall Policy; Attach it to the listener
 		for _, ingress := range cbCtx.IngressList {
-			if policy, err := annotations.WAFPolicy(ingress); err == nil && policy != "" {
+			if policy, exists := getFirewallPolicy(cbCtx, ingress); exists && policy != "" {
 				azConfig.FirewallPolicy = policy
 				break
 			}
 		}
 	}
 }
+
+// getFirewallPolicy returns the WAF policy the ingress is annotated with, unless the annotation is missing or the
+// policy no longer exists.
+func getFirewallPolicy(cbCtx *ConfigBuilderContext, ingress *v1beta1.Ingress) (string, bool) {
+	policy, err := annotations.WAFPolicy(ingress)
+	if err != nil {
+		return "", false
+	}
+	if _, dangling := cbCtx.DanglingFirewallPolicies[policy]; dangling {
+		return "", false
+	}
+	return policy, true
+}
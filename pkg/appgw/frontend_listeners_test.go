@@ -14,6 +14,8 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
 
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
@@ -325,6 +327,68 @@ var _ = Describe("MutateAppGateway ingress rules and parse frontend listener con
 		})
 	})
 
+	Context("bind the listeners to the frontend IP configuration named by APPGW_FRONTEND_IP_CONFIGURATION", func() {
+		var cb appGwConfigBuilder
+		var cbCtx *ConfigBuilderContext
+
+		BeforeEach(func() {
+			certs := newCertsFixture()
+			cb = newConfigBuilderFixture(&certs)
+			cbCtx = &ConfigBuilderContext{
+				IngressList:           []*v1beta1.Ingress{tests.NewIngressFixture()},
+				EnvVariables:          environment.GetFakeEnv(),
+				DefaultAddressPoolID:  to.StringPtr("xx"),
+				DefaultHTTPSettingsID: to.StringPtr("yy"),
+			}
+		})
+
+		listenerIPConfigurations := func() []string {
+			listeners, _ := cb.getListeners(cbCtx)
+			var ids []string
+			for _, listener := range *listeners {
+				ids = append(ids, *listener.FrontendIPConfiguration.ID)
+			}
+			return ids
+		}
+
+		It("should bind the listeners to the named private IP configuration", func() {
+			cbCtx.EnvVariables.FrontendIPConfiguration = "yy3"
+			Expect(listenerIPConfigurations()).To(Equal([]string{tests.PrivateIPID, tests.PrivateIPID}))
+
+			for listenerID := range cb.groupListenersByListenerIdentifier(cbCtx) {
+				Expect(listenerID.UsePrivateIP).To(BeTrue())
+			}
+		})
+
+		It("should bind the listeners to the named public IP configuration even with USE_PRIVATE_IP", func() {
+			cbCtx.EnvVariables.FrontendIPConfiguration = "xx3"
+			cbCtx.EnvVariables.UsePrivateIP = "true"
+			Expect(listenerIPConfigurations()).To(Equal([]string{tests.PublicIPID, tests.PublicIPID}))
+		})
+
+		It("should select the named configuration among several of the same kind", func() {
+			*cb.appGw.FrontendIPConfigurations = append(*cb.appGw.FrontendIPConfigurations, n.ApplicationGatewayFrontendIPConfiguration{
+				Name: to.StringPtr("zz3"),
+				ID:   to.StringPtr("--front-end-ip-id-3--"),
+				ApplicationGatewayFrontendIPConfigurationPropertiesFormat: &n.ApplicationGatewayFrontendIPConfigurationPropertiesFormat{
+					PrivateIPAddress: to.StringPtr("def"),
+				},
+			})
+			cbCtx.EnvVariables.FrontendIPConfiguration = "zz3"
+			Expect(listenerIPConfigurations()).To(Equal([]string{"--front-end-ip-id-3--", "--front-end-ip-id-3--"}))
+		})
+
+		It("should bind ingresses annotated with use-private-ip to the private IP configuration", func() {
+			cbCtx.EnvVariables.FrontendIPConfiguration = "xx3"
+			cbCtx.IngressList[0].Annotations[annotations.UsePrivateIPKey] = "true"
+			Expect(listenerIPConfigurations()).To(Equal([]string{tests.PrivateIPID, tests.PrivateIPID}))
+		})
+
+		It("should select the frontend IP configuration by kind when unset", func() {
+			Expect(listenerIPConfigurations()).To(Equal([]string{tests.PublicIPID, tests.PublicIPID}))
+		})
+	})
+
 	Context("many listeners, same port", func() {
 		It("should create only one listener", func() {
 			certs := newCertsFixture()
@@ -356,6 +420,73 @@ var _ = Describe("MutateAppGateway ingress rules and parse frontend listener con
 		})
 	})
 
+	Context("require-sni annotation", func() {
+		It("should require SNI only on the HTTPS listener with a host name", func() {
+			certs := newCertsFixture()
+			cb := newConfigBuilderFixture(&certs)
+			ingress := tests.NewIngressFixture()
+			ingress.Annotations[annotations.RequireSNIKey] = "true"
+			cbCtx := &ConfigBuilderContext{
+				IngressList:           []*v1beta1.Ingress{ingress},
+				EnvVariables:          envVariables,
+				DefaultAddressPoolID:  to.StringPtr("xx"),
+				DefaultHTTPSettingsID: to.StringPtr("yy"),
+			}
+
+			listeners, _ := cb.getListeners(cbCtx)
+			Expect(len(*listeners)).To(Equal(2))
+
+			expectedListener443.RequireServerNameIndication = to.BoolPtr(true)
+			Expect(*listeners).To(ContainElement(expectedListener443))
+			Expect(*listeners).To(ContainElement(expectedListener80))
+		})
+
+		It("should accept HTTPS listeners with a host name", func() {
+			ingress := tests.NewIngressFixture()
+			ingress.Annotations[annotations.RequireSNIKey] = "true"
+			configs := map[listenerIdentifier]listenerAzConfig{
+				listenerID443: listenerAzConfigWithSSL,
+				listenerID80:  listenerAzConfigNoSSL,
+			}
+			Expect(validateRequireSNI(ingress, configs)).ToNot(HaveOccurred())
+		})
+
+		It("should reject HTTPS listeners without a host name", func() {
+			ingress := tests.NewIngressFixture()
+			ingress.Annotations[annotations.RequireSNIKey] = "true"
+			configs := map[listenerIdentifier]listenerAzConfig{
+				{FrontendPort: Port(443)}: listenerAzConfigWithSSL,
+			}
+			Expect(validateRequireSNI(ingress, configs)).To(Equal(ErrRequireSNIWithoutHostname))
+		})
+
+		It("should reject ingresses without TLS", func() {
+			ingress := tests.NewIngressFixture()
+			ingress.Annotations[annotations.RequireSNIKey] = "true"
+			configs := map[listenerIdentifier]listenerAzConfig{
+				listenerID80: listenerAzConfigNoSSL,
+			}
+			Expect(validateRequireSNI(ingress, configs)).To(Equal(ErrRequireSNIWithoutTLS))
+		})
+
+		It("should not set the flag when the annotation is missing or invalid", func() {
+			certs := newCertsFixture()
+			cb := newConfigBuilderFixture(&certs)
+			ingress := tests.NewIngressFixture()
+			ingress.Annotations[annotations.RequireSNIKey] = "yes please"
+			cbCtx := &ConfigBuilderContext{
+				IngressList:           []*v1beta1.Ingress{ingress},
+				EnvVariables:          envVariables,
+				DefaultAddressPoolID:  to.StringPtr("xx"),
+				DefaultHTTPSettingsID: to.StringPtr("yy"),
+			}
+
+			configs := cb.getListenerConfigs(cbCtx)
+			Expect(validateRequireSNI(ingress, configs)).To(HaveOccurred())
+			Expect(configs[listenerID443].RequireServerNameIndication).To(BeFalse())
+		})
+	})
+
 	Context("create a new App Gateway HTTP Listener for V1 gateway", func() {
 		ing1 := tests.NewIngressFixture()
 		ing2 := tests.NewIngressFixture()
@@ -413,3 +544,144 @@ var _ = Describe("MutateAppGateway ingress rules and parse frontend listener con
 		})
 	})
 })
+
+var _ = Describe("two ingresses with different certificates for the same host", func() {
+	var cb appGwConfigBuilder
+	var recorder *record.FakeRecorder
+	var cbCtx *ConfigBuilderContext
+	var ingressOld *v1beta1.Ingress
+	var ingressNew *v1beta1.Ingress
+	listenerID443, _ := newTestListenerID(Port(443), []string{"hello.com"}, false)
+	oldSecret := secretIdentifier{Namespace: tests.Namespace, Name: tests.NameOfSecret}
+	newSecret := secretIdentifier{Namespace: tests.Namespace, Name: "other-secret"}
+
+	BeforeEach(func() {
+		certs := newCertsFixture()
+		certs[newSecret.secretKey()] = []byte("abc")
+		cb = newConfigBuilderFixture(&certs)
+		recorder = record.NewFakeRecorder(100)
+		cb.recorder = recorder
+
+		// the newer ingress comes first by name; the older one takes precedence
+		ingressOld = tests.NewIngressTestFixtureBasic(tests.Namespace, "b-old", true)
+		ingressOld.CreationTimestamp = metav1.NewTime(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+		ingressNew = tests.NewIngressTestFixtureBasic(tests.Namespace, "a-new", true)
+		ingressNew.CreationTimestamp = metav1.NewTime(time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC))
+		ingressNew.Spec.TLS[0].SecretName = newSecret.Name
+
+		cbCtx = &ConfigBuilderContext{
+			IngressList:           []*v1beta1.Ingress{ingressNew, ingressOld},
+			EnvVariables:          environment.GetFakeEnv(),
+			DefaultAddressPoolID:  to.StringPtr("xx"),
+			DefaultHTTPSettingsID: to.StringPtr("yy"),
+		}
+	})
+
+	It("binds the certificate of the ingress with precedence and warns the other one", func() {
+		configs := cb.getListenerConfigs(cbCtx)
+		Expect(configs[listenerID443].Protocol).To(Equal(n.HTTPS))
+		Expect(configs[listenerID443].Secret).To(Equal(oldSecret))
+
+		Expect(recorder.Events).To(HaveLen(1))
+		event := <-recorder.Events
+		Expect(event).To(HavePrefix("Warning ConflictingIngress Ingress --namespace--/a-new defines the TLS certificate --namespace--/other-secret for host hello.com and port 443"))
+		Expect(event).To(ContainSubstring("Ingress --namespace--/b-old takes precedence"))
+	})
+
+	It("does not warn when both secrets hold the same certificate", func() {
+		for _, secretID := range []secretIdentifier{oldSecret, newSecret} {
+			secret := tests.NewSecretTestFixture()
+			secret.Namespace, secret.Name = secretID.Namespace, secretID.Name
+			_ = cb.k8sContext.Caches.Secret.Add(secret)
+		}
+
+		configs := cb.getListenerConfigs(cbCtx)
+		Expect(configs[listenerID443].Protocol).To(Equal(n.HTTPS))
+		Expect(recorder.Events).To(BeEmpty())
+	})
+})
+
+var _ = Describe("Test the WAF policies of the listeners", func() {
+	policyID := "/subscriptions/xxxx/resourceGroups/xxxx/providers/Microsoft.Network/ApplicationGatewayWebApplicationFirewallPolicies/policy"
+	var cbCtx *ConfigBuilderContext
+
+	BeforeEach(func() {
+		ingress := tests.NewIngressFixture()
+		ingress.Annotations[annotations.FirewallPolicy] = policyID
+		env := environment.GetFakeEnv()
+		env.AttachWAFPolicyToListener = true
+		cbCtx = &ConfigBuilderContext{
+			IngressList:           []*v1beta1.Ingress{ingress},
+			EnvVariables:          env,
+			DefaultAddressPoolID:  to.StringPtr("xx"),
+			DefaultHTTPSettingsID: to.StringPtr("yy"),
+		}
+	})
+
+	It("attaches the WAF policy of the ingress to its listeners", func() {
+		certs := newCertsFixture()
+		cb := newConfigBuilderFixture(&certs)
+		for _, config := range cb.getListenerConfigs(cbCtx) {
+			Expect(config.FirewallPolicy).To(Equal(policyID))
+		}
+	})
+
+	It("leaves out the WAF policy, which no longer exists", func() {
+		cbCtx.DanglingFirewallPolicies = map[string]interface{}{policyID: nil}
+		certs := newCertsFixture()
+		cb := newConfigBuilderFixture(&certs)
+		configs := cb.getListenerConfigs(cbCtx)
+		Expect(configs).ToNot(BeEmpty())
+		for _, config := range configs {
+			Expect(config.FirewallPolicy).To(BeEmpty())
+		}
+		_, exists := getFirewallPolicy(cbCtx, cbCtx.IngressList[0])
+		Expect(exists).To(BeFalse(), "the path rules do not get the policy either")
+	})
+
+	Context("with two ingresses sharing the listeners", func() {
+		otherPolicyID := policyID + "-other"
+		var cb appGwConfigBuilder
+		var recorder *record.FakeRecorder
+		var ingressOld *v1beta1.Ingress
+		var ingressNew *v1beta1.Ingress
+
+		BeforeEach(func() {
+			certs := newCertsFixture()
+			cb = newConfigBuilderFixture(&certs)
+			recorder = record.NewFakeRecorder(100)
+			cb.recorder = recorder
+
+			// the newer ingress comes first by name and last by precedence
+			ingressOld = cbCtx.IngressList[0]
+			ingressOld.Name = "b-old"
+			ingressOld.CreationTimestamp = metav1.NewTime(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+			ingressNew = tests.NewIngressFixture()
+			ingressNew.Name = "a-new"
+			ingressNew.CreationTimestamp = metav1.NewTime(time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC))
+			cbCtx.IngressList = []*v1beta1.Ingress{ingressNew, ingressOld}
+		})
+
+		It("keeps the WAF policy of the ingress with precedence and warns the other one", func() {
+			ingressNew.Annotations[annotations.FirewallPolicy] = otherPolicyID
+			configs := cb.getListenerConfigs(cbCtx)
+			Expect(configs).ToNot(BeEmpty())
+			for _, config := range configs {
+				Expect(config.FirewallPolicy).To(Equal(policyID))
+			}
+			Expect(recorder.Events).ToNot(BeEmpty())
+			event := <-recorder.Events
+			Expect(event).To(HavePrefix("Warning ConflictingIngress Ingress --namespace--/a-new defines the WAF policy " + otherPolicyID))
+			Expect(event).To(ContainSubstring("Ingress --namespace--/b-old takes precedence"))
+		})
+
+		It("takes the WAF policy, which the ingress with precedence leaves unset, from the other one", func() {
+			delete(ingressOld.Annotations, annotations.FirewallPolicy)
+			ingressNew.Annotations[annotations.FirewallPolicy] = otherPolicyID
+			for _, config := range cb.getListenerConfigs(cbCtx) {
+				Expect(config.FirewallPolicy).To(Equal(otherPolicyID))
+			}
+			Expect(recorder.Events).To(BeEmpty())
+		})
+	})
+})
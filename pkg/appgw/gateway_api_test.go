@@ -0,0 +1,99 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/k8scontext"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
+)
+
+var _ = Describe("App Gateway config of an HTTPRoute of the Gateway API", func() {
+	gateway := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "gateway.networking.k8s.io/v1",
+		"kind":       "Gateway",
+		"metadata":   map[string]interface{}{"name": "agic", "namespace": tests.Namespace},
+		"spec": map[string]interface{}{
+			"gatewayClassName": annotations.ApplicationGatewayIngressClassName,
+			"listeners": []interface{}{
+				map[string]interface{}{"name": "http", "port": int64(8080), "protocol": "HTTP"},
+			},
+		},
+	}}
+	route := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "gateway.networking.k8s.io/v1",
+		"kind":       "HTTPRoute",
+		"metadata":   map[string]interface{}{"name": "web", "namespace": tests.Namespace},
+		"spec": map[string]interface{}{
+			"parentRefs": []interface{}{map[string]interface{}{"name": "agic"}},
+			"hostnames":  []interface{}{tests.Host},
+			"rules": []interface{}{
+				map[string]interface{}{
+					"matches": []interface{}{
+						map[string]interface{}{"path": map[string]interface{}{"type": "PathPrefix", "value": "/api"}},
+						map[string]interface{}{"path": map[string]interface{}{"type": "Exact", "value": "/login"}},
+					},
+					"backendRefs": []interface{}{map[string]interface{}{"name": tests.ServiceName, "port": int64(80)}},
+				},
+			},
+		},
+	}}
+
+	ingress, err := k8scontext.FromHTTPRoute(route, []*unstructured.Unstructured{gateway})
+
+	certs := newCertsFixture()
+	cb := newConfigBuilderFixture(&certs)
+	service := tests.NewServiceFixture(*tests.NewServicePortsFixture()...)
+	_ = cb.k8sContext.Caches.Service.Add(service)
+	_ = cb.k8sContext.Caches.Endpoints.Add(tests.NewEndpointsFixture())
+
+	cbCtx := &ConfigBuilderContext{
+		IngressList:           []*v1beta1.Ingress{ingress},
+		ServiceList:           []*v1.Service{service},
+		EnvVariables:          environment.GetFakeEnv(),
+		DefaultAddressPoolID:  to.StringPtr("xx"),
+		DefaultHTTPSettingsID: to.StringPtr("yy"),
+	}
+	_ = cb.BackendHTTPSettingsCollection(cbCtx)
+	_ = cb.BackendAddressPools(cbCtx)
+	_ = cb.Listeners(cbCtx)
+	_ = cb.RequestRoutingRules(cbCtx)
+
+	listenerID8080, listenerName := newTestListenerID(Port(8080), []string{tests.Host}, false)
+
+	It("translates the HTTPRoute", func() {
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ingress).ToNot(BeNil())
+	})
+
+	It("creates an HTTP listener for the hostname on the port of the Gateway listener", func() {
+		var names []string
+		for _, listener := range *cb.appGw.HTTPListeners {
+			names = append(names, *listener.Name)
+		}
+		Expect(names).To(ConsistOf(listenerName))
+		Expect(cb.groupListenersByListenerIdentifier(cbCtx)[listenerID8080].Protocol).To(Equal(n.HTTP))
+	})
+
+	It("routes the path matches to the backendRef", func() {
+		pathRules := *cb.getPathMaps(cbCtx)[listenerID8080].PathRules
+		var paths []string
+		for _, rule := range pathRules {
+			paths = append(paths, *rule.Paths...)
+			Expect(*rule.BackendAddressPool.ID).To(ContainSubstring(tests.ServiceName))
+		}
+		Expect(paths).To(ConsistOf("/api*", "/login"))
+	})
+})
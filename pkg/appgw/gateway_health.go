@@ -0,0 +1,97 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"fmt"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/golang/glog"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+)
+
+// gatewayHealthName is the name of the redirect configuration and of the path rule, which answer the gateway health path.
+// It has the prefix of the redirect configurations AGIC owns.
+func gatewayHealthName() string {
+	return formatPropName(fmt.Sprintf("%s%s-gateway-health", agPrefix, prefixRedirect))
+}
+
+// isGatewayHealthPathEnabled determines whether App Gateway answers the path set with APPGW_GATEWAY_HEALTH_PATH.
+func isGatewayHealthPathEnabled(env environment.EnvVariables) bool {
+	return len(env.GatewayHealthPath) != 0 && len(env.GatewayHealthTargetURL) != 0
+}
+
+// newGatewayHealthRedirectConfig creates the redirect to APPGW_GATEWAY_HEALTH_TARGET_URL. App Gateway can not answer a
+// request with a fixed response, so it answers the gateway health path with a redirect, which does not depend on the
+// health of any backend.
+func (c *appGwConfigBuilder) newGatewayHealthRedirectConfig(env environment.EnvVariables) n.ApplicationGatewayRedirectConfiguration {
+	return n.ApplicationGatewayRedirectConfiguration{
+		Etag: to.StringPtr("*"),
+		Name: to.StringPtr(gatewayHealthName()),
+		ID:   to.StringPtr(c.appGwIdentifier.redirectConfigurationID(gatewayHealthName())),
+		ApplicationGatewayRedirectConfigurationPropertiesFormat: &n.ApplicationGatewayRedirectConfigurationPropertiesFormat{
+			RedirectType:       n.Found,
+			TargetURL:          to.StringPtr(env.GatewayHealthTargetURL),
+			IncludePath:        to.BoolPtr(false),
+			IncludeQueryString: to.BoolPtr(false),
+		},
+	}
+}
+
+// addGatewayHealthPathRule adds the path rule of the gateway health path to the path map of the listener without a host
+// name on the HTTP listener port, which receives the requests for the frontend IP of App Gateway. The path map is
+// created when no ingress defines it. A path of an ingress, which is the gateway health path, takes precedence over it.
+func (c *appGwConfigBuilder) addGatewayHealthPathRule(cbCtx *ConfigBuilderContext, urlPathMaps map[listenerIdentifier]*n.ApplicationGatewayURLPathMap) {
+	if !isGatewayHealthPathEnabled(cbCtx.EnvVariables) {
+		return
+	}
+
+	listenerID := defaultFrontendListenerIdentifier(cbCtx.EnvVariables)
+	pathMap, exists := urlPathMaps[listenerID]
+	if !exists {
+		defaultAddressPoolID := c.appGwIdentifier.AddressPoolID(DefaultBackendAddressPoolName)
+		defaultHTTPSettingsID := c.appGwIdentifier.HTTPSettingsID(DefaultBackendHTTPSettingsName)
+		pathMap = &n.ApplicationGatewayURLPathMap{
+			Etag: to.StringPtr("*"),
+			Name: to.StringPtr(generateURLPathMapName(listenerID)),
+			ID:   to.StringPtr(c.appGwIdentifier.urlPathMapID(generateURLPathMapName(listenerID))),
+			ApplicationGatewayURLPathMapPropertiesFormat: &n.ApplicationGatewayURLPathMapPropertiesFormat{
+				DefaultBackendAddressPool:  &n.SubResource{ID: &defaultAddressPoolID},
+				DefaultBackendHTTPSettings: &n.SubResource{ID: &defaultHTTPSettingsID},
+			},
+		}
+		urlPathMaps[listenerID] = pathMap
+	}
+
+	var pathRules []n.ApplicationGatewayPathRule
+	if pathMap.PathRules != nil {
+		pathRules = append(pathRules, *pathMap.PathRules...)
+	}
+	for _, pathRule := range pathRules {
+		if pathRule.Paths == nil {
+			continue
+		}
+		for _, path := range *pathRule.Paths {
+			if path == cbCtx.EnvVariables.GatewayHealthPath {
+				glog.Errorf("Gateway health path %s is not answered by App Gateway; path rule %s routes it to a backend", path, *pathRule.Name)
+				return
+			}
+		}
+	}
+
+	pathRules = append(pathRules, n.ApplicationGatewayPathRule{
+		Etag: to.StringPtr("*"),
+		Name: to.StringPtr(gatewayHealthName()),
+		ApplicationGatewayPathRulePropertiesFormat: &n.ApplicationGatewayPathRulePropertiesFormat{
+			Paths:                 &[]string{cbCtx.EnvVariables.GatewayHealthPath},
+			RedirectConfiguration: resourceRef(c.appGwIdentifier.redirectConfigurationID(gatewayHealthName())),
+		},
+	})
+	pathMap.PathRules = &pathRules
+	glog.V(5).Infof("Attached gateway health path %s to url path map %s", cbCtx.EnvVariables.GatewayHealthPath, *pathMap.Name)
+}
@@ -0,0 +1,135 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
+)
+
+var _ = Describe("Gateway health path", func() {
+	const healthPath = "/agw-health"
+	const targetURL = "https://status.contoso.com/"
+
+	var cb appGwConfigBuilder
+	var cbCtx *ConfigBuilderContext
+	var ingress *v1beta1.Ingress
+
+	BeforeEach(func() {
+		cb = newConfigBuilderFixture(nil)
+		service := tests.NewServiceFixture(*tests.NewServicePortsFixture()...)
+		_ = cb.k8sContext.Caches.Service.Add(service)
+		_ = cb.k8sContext.Caches.Endpoints.Add(tests.NewEndpointsFixture())
+
+		ingress = tests.NewIngressFixture()
+		ingress.Annotations[annotations.SslRedirectKey] = "false"
+		ingress.Spec.TLS = nil
+		backend := tests.NewIngressBackendFixture(tests.ServiceName, 80)
+		ingress.Spec.Rules = []v1beta1.IngressRule{tests.NewIngressRuleFixture("www.contoso.com", "/api/*", *backend)}
+
+		env := environment.GetFakeEnv()
+		env.GatewayHealthPath = healthPath
+		env.GatewayHealthTargetURL = targetURL
+		cbCtx = &ConfigBuilderContext{
+			IngressList:           []*v1beta1.Ingress{ingress},
+			ServiceList:           []*v1.Service{service},
+			EnvVariables:          env,
+			DefaultAddressPoolID:  to.StringPtr("xx"),
+			DefaultHTTPSettingsID: to.StringPtr("yy"),
+		}
+	})
+
+	build := func() {
+		Expect(cb.BackendHTTPSettingsCollection(cbCtx)).ToNot(HaveOccurred())
+		Expect(cb.BackendAddressPools(cbCtx)).ToNot(HaveOccurred())
+		Expect(cb.Listeners(cbCtx)).ToNot(HaveOccurred())
+		Expect(cb.RequestRoutingRules(cbCtx)).ToNot(HaveOccurred())
+	}
+
+	// healthPathRule returns the path rule of the gateway health path in the path map of the rule of the listener
+	// without a host name.
+	healthPathRule := func() *n.ApplicationGatewayPathRule {
+		listenerID := cb.appGwIdentifier.listenerID(generateListenerName(defaultFrontendListenerIdentifier(environment.GetFakeEnv())))
+		for _, rule := range *cb.appGw.RequestRoutingRules {
+			if *rule.HTTPListener.ID != listenerID {
+				continue
+			}
+			Expect(rule.RuleType).To(Equal(n.PathBasedRouting))
+			for _, pathMap := range *cb.appGw.URLPathMaps {
+				if cb.appGwIdentifier.urlPathMapID(*pathMap.Name) != *rule.URLPathMap.ID {
+					continue
+				}
+				for _, pathRule := range *pathMap.PathRules {
+					if *pathRule.Name == gatewayHealthName() {
+						return &pathRule
+					}
+				}
+			}
+		}
+		return nil
+	}
+
+	It("creates the redirect answering the gateway health path", func() {
+		build()
+		Expect(*cb.appGw.RedirectConfigurations).To(ContainElement(n.ApplicationGatewayRedirectConfiguration{
+			Etag: to.StringPtr("*"),
+			Name: to.StringPtr(gatewayHealthName()),
+			ID:   to.StringPtr(cb.appGwIdentifier.redirectConfigurationID(gatewayHealthName())),
+			ApplicationGatewayRedirectConfigurationPropertiesFormat: &n.ApplicationGatewayRedirectConfigurationPropertiesFormat{
+				RedirectType:       n.Found,
+				TargetURL:          to.StringPtr(targetURL),
+				IncludePath:        to.BoolPtr(false),
+				IncludeQueryString: to.BoolPtr(false),
+			},
+		}))
+	})
+
+	It("creates the listener without a host name for the gateway health path", func() {
+		build()
+		var hostNames []string
+		for _, listener := range *cb.appGw.HTTPListeners {
+			hostNames = append(hostNames, to.String(listener.HostName))
+		}
+		Expect(hostNames).To(ConsistOf("www.contoso.com", ""))
+
+		pathRule := healthPathRule()
+		Expect(pathRule).ToNot(BeNil())
+		Expect(*pathRule.Paths).To(Equal([]string{healthPath}))
+		Expect(*pathRule.RedirectConfiguration.ID).To(Equal(cb.appGwIdentifier.redirectConfigurationID(gatewayHealthName())))
+		Expect(pathRule.BackendAddressPool).To(BeNil())
+	})
+
+	It("adds the gateway health path to the paths of an ingress without a host", func() {
+		ingress.Spec.Rules[0].Host = ""
+		build()
+		Expect(*cb.appGw.HTTPListeners).To(HaveLen(1))
+		Expect(healthPathRule()).ToNot(BeNil())
+	})
+
+	It("leaves the gateway health path to an ingress routing it to a backend", func() {
+		ingress.Spec.Rules[0].Host = ""
+		ingress.Spec.Rules[0].HTTP.Paths[0].Path = healthPath
+		build()
+		Expect(healthPathRule()).To(BeNil())
+	})
+
+	It("does not answer the gateway health path without the setting", func() {
+		cbCtx.EnvVariables.GatewayHealthPath = ""
+		build()
+		Expect(*cb.appGw.HTTPListeners).To(HaveLen(1))
+		for _, redirect := range *cb.appGw.RedirectConfigurations {
+			Expect(*redirect.Name).ToNot(Equal(gatewayHealthName()))
+		}
+	})
+})
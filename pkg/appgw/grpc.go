@@ -0,0 +1,22 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"k8s.io/api/extensions/v1beta1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+)
+
+// validateGRPC rejects gRPC backends of the ingress.
+// App Gateway speaks HTTP/1.1 to the backends, while gRPC requires HTTP/2; the annotation is ignored and the backends
+// get the default HTTP settings and probes.
+func validateGRPC(ingress *v1beta1.Ingress) error {
+	if protocol, _ := annotations.BackendProtocol(ingress); protocol != annotations.GRPC {
+		return nil
+	}
+	return ErrGRPCNotSupported
+}
@@ -0,0 +1,46 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+)
+
+// getProbePickHostName returns whether the ingress explicitly asks the health probes of the backend to send the host
+// name of their HTTP settings, or nil when the ingress leaves it to AGIC.
+// An explicit health-probe-hostname takes precedence; asking for both is reported as a conflict. App Gateway rejects
+// probes picking the host name from HTTP settings, which have none.
+func (c *appGwConfigBuilder) getProbePickHostName(backendID backendIdentifier) (*bool, error) {
+	pick, err := annotations.HealthProbePickHostName(backendID.Ingress)
+	if annotations.IsMissingAnnotations(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !pick {
+		return &pick, nil
+	}
+	if _, err := annotations.HealthProbeHostName(backendID.Ingress); err == nil {
+		return nil, ErrProbePickHostNameConflict
+	}
+	if !c.settingsHaveHostName(backendID) {
+		return nil, ErrProbePickHostNameWithoutHostName
+	}
+	return &pick, nil
+}
+
+// settingsHaveHostName tells whether the HTTP settings of the backend set the host name, or pick it from the backend
+// address.
+func (c *appGwConfigBuilder) settingsHaveHostName(backendID backendIdentifier) bool {
+	if _, err := annotations.BackendHostName(backendID.Ingress); err == nil {
+		return true
+	}
+	if pick, err := getPickHostNameFromBackend(backendID.Ingress); err == nil && pick != nil {
+		return *pick
+	}
+	return c.isExternalNameBackend(backendID)
+}
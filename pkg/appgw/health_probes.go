@@ -18,6 +18,7 @@ import (
 
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/brownfield"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/sorter"
 )
 
@@ -56,7 +57,20 @@ func (c *appGwConfigBuilder) newProbesMap(cbCtx *ConfigBuilderContext) (map[stri
 	glog.V(5).Info("Created default HTTPS probe ", *defaultHTTPProbe.Name)
 
 	for backendID := range c.newBackendIdsFiltered(cbCtx) {
+		if disabled, _ := annotations.IsHealthProbeDisabled(backendID.Ingress); disabled {
+			// HTTP settings without a probe fall back to App Gateway's default probing.
+			glog.V(5).Infof("Health probe disabled for ingress %s/%s and service %s", backendID.Ingress.Namespace, backendID.Ingress.Name, backendID.serviceKey())
+			continue
+		}
+
 		probe := c.generateHealthProbe(backendID)
+		c.warnMissingReadinessProbe(cbCtx, backendID, probe)
+		if clusterIP, _ := c.clusterIPFallback(cbCtx, backendID); probe != nil && clusterIP != "" {
+			if _, err := annotations.HealthProbePort(backendID.Ingress); err != nil {
+				// The port of the container probe is not served by the cluster IP; probe the port of the HTTP settings.
+				probe.Port = nil
+			}
+		}
 
 		if probe != nil {
 			probesMap[backendID] = probe
@@ -107,8 +121,9 @@ func (c *appGwConfigBuilder) generateHealthProbe(backendID backendIdentifier) *n
 		if len(k8sProbeForServiceContainer.Handler.HTTPGet.Path) != 0 {
 			probe.Path = to.StringPtr(k8sProbeForServiceContainer.Handler.HTTPGet.Path)
 		}
-		if len(k8sProbeForServiceContainer.Handler.HTTPGet.Port.String()) != 0 {
-			probe.Port = to.Int32Ptr(k8sProbeForServiceContainer.Handler.HTTPGet.Port.IntVal)
+		if port := k8sProbeForServiceContainer.Handler.HTTPGet.Port; port.Type == intstr.Int && port.IntVal != 0 {
+			// A named port, which the container does not declare, leaves the probe on the port of the HTTP settings.
+			probe.Port = to.Int32Ptr(port.IntVal)
 		}
 		if k8sProbeForServiceContainer.Handler.HTTPGet.Scheme == v1.URISchemeHTTPS {
 			probe.Protocol = n.HTTPS
@@ -128,6 +143,64 @@ func (c *appGwConfigBuilder) generateHealthProbe(backendID backendIdentifier) *n
 		probe.Path = to.StringPtr(strings.TrimRight(*probe.Path, "*"))
 	}
 
+	if protocol, err := annotations.HealthProbeProtocol(backendID.Ingress); err == nil {
+		// The protocol of the probe is set apart from the protocol of the HTTP settings, and of the container probe.
+		probe.Protocol = n.HTTP
+		if protocol == annotations.HTTPS {
+			probe.Protocol = n.HTTPS
+		}
+	} else if !annotations.IsMissingAnnotations(err) {
+		glog.Errorf("[%s/%s] %s", backendID.Ingress.Namespace, backendID.Ingress.Name, err)
+		events.Warning(c.recorder, backendID.Ingress, events.ReasonInvalidAnnotation, err.Error())
+	}
+
+	if port, err := annotations.HealthProbePort(backendID.Ingress); err == nil {
+		if port > 0 && port <= 65535 {
+			probe.Port = to.Int32Ptr(port)
+		} else {
+			logLine := fmt.Sprintf("health-probe-port must be between 1 and 65535, found %d; the annotation is ignored", port)
+			glog.Errorf("[%s/%s] %s", backendID.Ingress.Namespace, backendID.Ingress.Name, logLine)
+			events.Warning(c.recorder, backendID.Ingress, events.ReasonInvalidAnnotation, logLine)
+		}
+	} else if !annotations.IsMissingAnnotations(err) {
+		glog.Errorf("[%s/%s] %s", backendID.Ingress.Namespace, backendID.Ingress.Name, err)
+		events.Warning(c.recorder, backendID.Ingress, events.ReasonInvalidAnnotation, err.Error())
+	}
+
+	statusCodes, err := getProbeStatusCodes(backendID.Ingress)
+	if err != nil {
+		glog.Errorf("[%s/%s] %s", backendID.Ingress.Namespace, backendID.Ingress.Name, err)
+		events.Warning(c.recorder, backendID.Ingress, events.ReasonInvalidAnnotation, err.Error())
+	}
+	body, err := getProbeMatchBody(backendID.Ingress)
+	if err != nil {
+		glog.Errorf("[%s/%s] %s", backendID.Ingress.Namespace, backendID.Ingress.Name, err)
+		events.Warning(c.recorder, backendID.Ingress, events.ReasonInvalidAnnotation, err.Error())
+	}
+	probe.Match = newProbeMatch(body, statusCodes)
+
+	hostName, hostNameErr := annotations.HealthProbeHostName(backendID.Ingress)
+	if hostNameErr == nil {
+		probe.Host = to.StringPtr(hostName)
+	} else if !annotations.IsMissingAnnotations(hostNameErr) {
+		glog.Errorf("[%s/%s] %s", backendID.Ingress.Namespace, backendID.Ingress.Name, hostNameErr)
+		events.Warning(c.recorder, backendID.Ingress, events.ReasonInvalidAnnotation, hostNameErr.Error())
+	}
+
+	pick, err := c.getProbePickHostName(backendID)
+	if err != nil {
+		glog.Errorf("[%s/%s] %s", backendID.Ingress.Namespace, backendID.Ingress.Name, err)
+		events.Warning(c.recorder, backendID.Ingress, events.ReasonInvalidAnnotation, err.Error())
+	}
+	if pick != nil && *pick {
+		probe.Host = nil
+		probe.PickHostNameFromBackendHTTPSettings = to.BoolPtr(true)
+	} else if pick == nil && hostNameErr != nil && c.isExternalNameBackend(backendID) {
+		// Probe the external host with the host name the HTTP settings pick from the backend address.
+		probe.Host = nil
+		probe.PickHostNameFromBackendHTTPSettings = to.BoolPtr(true)
+	}
+
 	// For V1 gateway, port property is not supported
 	if c.appGw.Sku.Tier == n.ApplicationGatewayTierStandard || c.appGw.Sku.Tier == n.ApplicationGatewayTierWAF {
 		probe.Port = nil
@@ -137,39 +210,59 @@ func (c *appGwConfigBuilder) generateHealthProbe(backendID backendIdentifier) *n
 }
 
 func (c *appGwConfigBuilder) getProbeForServiceContainer(service *v1.Service, backendID backendIdentifier) *v1.Probe {
-	// find all the target ports used by the service
+	// find the target port the HTTP settings of the backend use
 	allPorts := make(map[int32]interface{})
-	for _, sp := range service.Spec.Ports {
-		if sp.Protocol != v1.ProtocolTCP {
-			continue
-		}
-
-		if fmt.Sprint(sp.Port) == backendID.Backend.ServicePort.String() ||
-			sp.Name == backendID.Backend.ServicePort.String() ||
-			sp.TargetPort.String() == backendID.Backend.ServicePort.String() {
-
-			// Matched a service port in the service
-			if sp.TargetPort.String() == "" {
-				allPorts[sp.Port] = nil
-			} else if sp.TargetPort.Type == intstr.Int {
-				// port is defined as port number
-				allPorts[sp.TargetPort.IntVal] = nil
-			} else {
-				for targetPort := range c.resolvePortName(sp.Name, &backendID) {
-					allPorts[targetPort] = nil
-				}
-			}
-		}
+	for pair := range c.resolveBackendPorts(backendID, service) {
+		allPorts[int32(pair.BackendPort)] = nil
 	}
+	return c.getCachedProbeForServiceContainer(service, allPorts)
+}
 
+// findProbeForServiceContainer returns the probe of the containers of the pods of the service, which listen on one of
+// the ports.
+func (c *appGwConfigBuilder) findProbeForServiceContainer(service *v1.Service, allPorts map[int32]interface{}) *v1.Probe {
 	podList := c.k8sContext.ListPodsByServiceSelector(service)
+	sort.Slice(podList, func(i, j int) bool { return podList[i].Name < podList[j].Name })
 
-	if len(podList) == 0 {
+	// During a rollout the pods of the service may run versions, which probe different paths.
+	probesByPath := make(map[string][]*v1.Probe)
+	for _, pod := range podList {
+		if probe := getProbeForPodContainer(pod, allPorts); probe != nil {
+			probesByPath[probe.HTTPGet.Path] = append(probesByPath[probe.HTTPGet.Path], probe)
+		}
+	}
+	if len(probesByPath) == 0 {
 		return nil
 	}
 
+	// Probe the path of the most pods; on a tie, the first path in alphabetical order.
+	var paths []string
+	for path := range probesByPath {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	majorityPath := paths[0]
+	for _, path := range paths {
+		if len(probesByPath[path]) > len(probesByPath[majorityPath]) {
+			majorityPath = path
+		}
+	}
+	if len(paths) > 1 {
+		counts := make([]string, 0, len(paths))
+		for _, path := range paths {
+			counts = append(counts, fmt.Sprintf("%s (%d pods)", path, len(probesByPath[path])))
+		}
+		glog.Warningf("Pods of service %s/%s probe different paths, as during a rollout: %s; App Gateway probes %s until the pods agree",
+			service.Namespace, service.Name, strings.Join(counts, ", "), majorityPath)
+	}
+	return probesByPath[majorityPath][0]
+}
+
+// getProbeForPodContainer returns the readiness probe, or else the liveness probe, of the container of the pod, which
+// listens on one of the ports.
+func getProbeForPodContainer(pod *v1.Pod, allPorts map[int32]interface{}) *v1.Probe {
 	// use the target port to figure out the container and use it's readiness/liveness probe
-	for _, container := range podList[0].Spec.Containers {
+	for _, container := range pod.Spec.Containers {
 		for _, port := range container.Ports {
 			if _, ok := allPorts[port.ContainerPort]; !ok {
 				continue
@@ -183,8 +276,10 @@ func (c *appGwConfigBuilder) getProbeForServiceContainer(service *v1.Service, ba
 				probe = container.LivenessProbe
 			}
 
-			// if probe port is named, resolve it by going through container port and set it in the probe itself
+			// if probe port is named, resolve it by going through container port and set it in a copy of the probe,
+			// leaving the pod in the cache untouched
 			if probe != nil && probe.HTTPGet.Port.String() != "" && probe.HTTPGet.Port.Type == intstr.String {
+				probe = probe.DeepCopy()
 				for _, port := range container.Ports {
 					if port.Name == probe.HTTPGet.Port.StrVal {
 						probe.HTTPGet.Port = intstr.IntOrString{
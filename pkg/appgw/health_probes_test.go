@@ -7,6 +7,7 @@ package appgw
 
 import (
 	"fmt"
+	"strings"
 
 	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
 	"github.com/Azure/go-autorest/autorest/to"
@@ -14,7 +15,11 @@ import (
 	. "github.com/onsi/gomega"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
 
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests/fixtures"
 )
@@ -165,6 +170,386 @@ var _ = Describe("configure App Gateway health probes", func() {
 		})
 	})
 
+	Context("health probe generation disabled with an annotation", func() {
+		cb := newConfigBuilderFixture(nil)
+
+		endpoints := tests.NewEndpointsFixture()
+		_ = cb.k8sContext.Caches.Endpoints.Add(endpoints)
+
+		service := tests.NewServiceFixture(*tests.NewServicePortsFixture()...)
+		_ = cb.k8sContext.Caches.Service.Add(service)
+
+		pod := tests.NewPodFixture(tests.ServiceName, tests.Namespace, tests.ContainerName, tests.ContainerPort)
+		_ = cb.k8sContext.Caches.Pods.Add(pod)
+
+		ingress := tests.NewIngressFixture()
+		ingress.Annotations[annotations.DisableHealthProbeKey] = "true"
+
+		cbCtx := &ConfigBuilderContext{
+			IngressList:           []*v1beta1.Ingress{ingress},
+			ServiceList:           serviceList,
+			DefaultAddressPoolID:  to.StringPtr("xx"),
+			DefaultHTTPSettingsID: to.StringPtr("yy"),
+		}
+
+		// !! Action !!
+		_ = cb.HealthProbesCollection(cbCtx)
+		_ = cb.BackendHTTPSettingsCollection(cbCtx)
+		actualProbes := cb.appGw.Probes
+		actualSettings := cb.appGw.BackendHTTPSettingsCollection
+
+		It("should only have the default probes", func() {
+			Expect(len(*actualProbes)).To(Equal(2), fmt.Sprintf("Actual probes: %+v", *actualProbes))
+			Expect(*actualProbes).To(ContainElement(defaultProbe(cb.appGwIdentifier, n.HTTP)))
+			Expect(*actualProbes).To(ContainElement(defaultProbe(cb.appGwIdentifier, n.HTTPS)))
+		})
+
+		It("should only reference existing probes in HTTP settings", func() {
+			Expect(len(*actualSettings)).To(BeNumerically(">", 1))
+			probeIDs := make(map[string]interface{})
+			for _, probe := range *actualProbes {
+				probeIDs[*probe.ID] = nil
+			}
+			for _, setting := range *actualSettings {
+				if *setting.Name == DefaultBackendHTTPSettingsName {
+					continue
+				}
+				Expect(setting.Probe).To(BeNil(), fmt.Sprintf("HTTP setting %s should use the default probing", *setting.Name))
+			}
+			for _, setting := range *actualSettings {
+				if setting.Probe != nil {
+					Expect(probeIDs).To(HaveKey(*setting.Probe.ID))
+				}
+			}
+		})
+	})
+
+	Context("probe the target port of the backend on a pod with several ports", func() {
+		var cb appGwConfigBuilder
+		var ingress *v1beta1.Ingress
+		var service *v1.Service
+		var pod *v1.Pod
+
+		backendFor := func(servicePort string) backendIdentifier {
+			rule := &ingress.Spec.Rules[0]
+			path := &rule.HTTP.Paths[0]
+			path.Backend.ServicePort = intstr.FromString(servicePort)
+			return generateBackendID(ingress, rule, path, &path.Backend)
+		}
+
+		BeforeEach(func() {
+			cb = newConfigBuilderFixture(nil)
+			cb.recorder = record.NewFakeRecorder(100)
+
+			// The service sends "web" to the named container port "http" and "metrics" to port 9100.
+			service = tests.NewServiceFixture(
+				v1.ServicePort{Name: "web", Protocol: v1.ProtocolTCP, Port: 80, TargetPort: intstr.FromString("http")},
+				v1.ServicePort{Name: "metrics", Protocol: v1.ProtocolTCP, Port: 9100, TargetPort: intstr.FromInt(9100)},
+			)
+			_ = cb.k8sContext.Caches.Service.Add(service)
+
+			endpoints := tests.NewEndpointsFixture()
+			endpoints.Subsets[0].Ports = []v1.EndpointPort{
+				{Name: "web", Protocol: v1.ProtocolTCP, Port: 8080},
+				{Name: "metrics", Protocol: v1.ProtocolTCP, Port: 9100},
+			}
+			_ = cb.k8sContext.Caches.Endpoints.Add(endpoints)
+
+			// The sidecar is listed first and probes its own port.
+			sidecarProbe := tests.NewProbeFixture("exporter")
+			sidecarProbe.HTTPGet.Port = intstr.FromInt(9100)
+			sidecarProbe.HTTPGet.Path = "/metrics"
+			appProbe := tests.NewProbeFixture("app")
+			appProbe.HTTPGet.Port = intstr.FromString("http")
+			pod = tests.NewPodFixture(tests.ServiceName, tests.Namespace, tests.ContainerName, tests.ContainerPort)
+			pod.Spec.Containers = []v1.Container{
+				{
+					Name:           "exporter",
+					Ports:          []v1.ContainerPort{{Name: "metrics", ContainerPort: 9100}},
+					ReadinessProbe: sidecarProbe,
+				},
+				{
+					Name:           "app",
+					Ports:          []v1.ContainerPort{{Name: "http", ContainerPort: 8080}},
+					ReadinessProbe: appProbe,
+				},
+			}
+			_ = cb.k8sContext.Caches.Pods.Add(pod)
+
+			ingress = tests.NewIngressFixture()
+		})
+
+		It("probes the numeric port of the container the service port targets", func() {
+			probe := cb.generateHealthProbe(backendFor("web"))
+			Expect(*probe.Port).To(Equal(int32(8080)))
+			Expect(*probe.Path).To(Equal(tests.HealthPath))
+
+			probe = cb.generateHealthProbe(backendFor("metrics"))
+			Expect(*probe.Port).To(Equal(int32(9100)))
+			Expect(*probe.Path).To(Equal("/metrics"))
+		})
+
+		It("does not modify the pod in the cache", func() {
+			_ = cb.generateHealthProbe(backendFor("web"))
+			Expect(pod.Spec.Containers[1].ReadinessProbe.HTTPGet.Port).To(Equal(intstr.FromString("http")))
+		})
+
+		It("leaves the probe on the port of the HTTP settings when the probe port can not be resolved", func() {
+			pod.Spec.Containers[1].ReadinessProbe.HTTPGet.Port = intstr.FromString("undeclared")
+			probe := cb.generateHealthProbe(backendFor("web"))
+			Expect(probe.Port).To(BeNil())
+		})
+
+		It("uses the port of the health-probe-port annotation", func() {
+			ingress.Annotations[annotations.HealthProbePortKey] = "7070"
+			probe := cb.generateHealthProbe(backendFor("web"))
+			Expect(*probe.Port).To(Equal(int32(7070)))
+		})
+
+		It("ignores an invalid health-probe-port annotation", func() {
+			ingress.Annotations[annotations.HealthProbePortKey] = "70000"
+			probe := cb.generateHealthProbe(backendFor("web"))
+			Expect(*probe.Port).To(Equal(int32(8080)))
+		})
+
+		It("probes over HTTP on another port a backend served over HTTPS", func() {
+			ingress.Annotations[annotations.BackendProtocolKey] = "https"
+			ingress.Annotations[annotations.HealthProbeProtocolKey] = "http"
+			ingress.Annotations[annotations.HealthProbePortKey] = "9100"
+			backendID := backendFor("web")
+
+			settings := cb.generateHTTPSettings(backendID, 8080, &ConfigBuilderContext{EnvVariables: environment.GetFakeEnv()})
+			Expect(settings.Protocol).To(Equal(n.HTTPS))
+			Expect(*settings.Port).To(Equal(int32(8080)))
+
+			probe := cb.generateHealthProbe(backendID)
+			Expect(probe.Protocol).To(Equal(n.HTTP))
+			Expect(*probe.Port).To(Equal(int32(9100)))
+		})
+
+		It("probes over HTTPS a backend served over HTTP", func() {
+			ingress.Annotations[annotations.HealthProbeProtocolKey] = "https"
+			probe := cb.generateHealthProbe(backendFor("web"))
+			Expect(probe.Protocol).To(Equal(n.HTTPS))
+			Expect(*probe.Port).To(Equal(int32(8080)))
+		})
+
+		It("overrides the scheme of the container probe", func() {
+			pod.Spec.Containers[1].ReadinessProbe.HTTPGet.Scheme = v1.URISchemeHTTPS
+			ingress.Annotations[annotations.HealthProbeProtocolKey] = "http"
+			probe := cb.generateHealthProbe(backendFor("web"))
+			Expect(probe.Protocol).To(Equal(n.HTTP))
+		})
+
+		It("ignores an invalid health-probe-protocol annotation", func() {
+			ingress.Annotations[annotations.BackendProtocolKey] = "https"
+			ingress.Annotations[annotations.HealthProbeProtocolKey] = "grpc"
+			probe := cb.generateHealthProbe(backendFor("web"))
+			Expect(probe.Protocol).To(Equal(n.HTTPS))
+			Expect(cb.recorder.(*record.FakeRecorder).Events).To(Receive(ContainSubstring(annotations.HealthProbeProtocolKey)))
+		})
+
+		It("follows a change to the target port of the service", func() {
+			ingress.Spec.Rules = ingress.Spec.Rules[:1]
+			ingress.Spec.Rules[0].HTTP.Paths = ingress.Spec.Rules[0].HTTP.Paths[:1]
+			backendID := backendFor("metrics")
+			cbCtx := &ConfigBuilderContext{
+				IngressList:           []*v1beta1.Ingress{ingress},
+				ServiceList:           []*v1.Service{service},
+				DefaultAddressPoolID:  to.StringPtr("xx"),
+				DefaultHTTPSettingsID: to.StringPtr("yy"),
+			}
+			_, settingsPerBackend, _, err := cb.getBackendsAndSettingsMap(cbCtx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(*settingsPerBackend[backendID].Port).To(Equal(int32(9100)))
+
+			// The metrics exporter moves to port 9200; the pods are rolled out before the service is updated.
+			pod.Spec.Containers[0].Ports[0].ContainerPort = 9200
+			pod.Spec.Containers[0].ReadinessProbe.HTTPGet.Port = intstr.FromInt(9200)
+			_ = cb.k8sContext.Caches.Pods.Update(pod)
+			service.Spec.Ports[1].TargetPort = intstr.FromInt(9200)
+			_ = cb.k8sContext.Caches.Service.Update(service)
+
+			// Each reconcile builds the config with a new config builder.
+			cb.mem = memoization{}
+			_, settingsPerBackend, _, err = cb.getBackendsAndSettingsMap(cbCtx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(*settingsPerBackend[backendID].Port).To(Equal(int32(9200)))
+			Expect(*cb.generateHealthProbe(backendID).Port).To(Equal(int32(9200)))
+		})
+
+		It("follows a change to the named container port, with the probes cached across reconciles", func() {
+			ingress.Spec.Rules = ingress.Spec.Rules[:1]
+			ingress.Spec.Rules[0].HTTP.Paths = ingress.Spec.Rules[0].HTTP.Paths[:1]
+			backendID := backendFor("web")
+			cbCtx := &ConfigBuilderContext{
+				IngressList:           []*v1beta1.Ingress{ingress},
+				ServiceList:           []*v1.Service{service},
+				DefaultAddressPoolID:  to.StringPtr("xx"),
+				DefaultHTTPSettingsID: to.StringPtr("yy"),
+			}
+			cb.resolvedCache = NewResolvedCache(DefaultResolvedCacheSize)
+			_, settingsPerBackend, _, err := cb.getBackendsAndSettingsMap(cbCtx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(*settingsPerBackend[backendID].Port).To(Equal(int32(8080)))
+			Expect(*cb.generateHealthProbe(backendID).Port).To(Equal(int32(8080)))
+
+			// The app moves its "http" port to 8081; the service, which targets the port by name, does not change.
+			pod.Spec.Containers[1].Ports[0].ContainerPort = 8081
+			_ = cb.k8sContext.Caches.Pods.Update(pod)
+			endpoints := tests.NewEndpointsFixture()
+			endpoints.Subsets[0].Ports = []v1.EndpointPort{
+				{Name: "web", Protocol: v1.ProtocolTCP, Port: 8081},
+				{Name: "metrics", Protocol: v1.ProtocolTCP, Port: 9100},
+			}
+			_ = cb.k8sContext.Caches.Endpoints.Update(endpoints)
+
+			cb.mem = memoization{}
+			_, settingsPerBackend, _, err = cb.getBackendsAndSettingsMap(cbCtx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(*settingsPerBackend[backendID].Port).To(Equal(int32(8081)))
+			Expect(*cb.generateHealthProbe(backendID).Port).To(Equal(int32(8081)))
+		})
+
+		It("has no match without the match annotations", func() {
+			probe := cb.generateHealthProbe(backendFor("web"))
+			Expect(probe.Match).To(BeNil())
+		})
+
+		It("matches the body with the default status codes", func() {
+			ingress.Annotations[annotations.HealthProbeMatchBodyKey] = "status: healthy"
+			probe := cb.generateHealthProbe(backendFor("web"))
+			Expect(*probe.Match.Body).To(Equal("status: healthy"))
+			Expect(*probe.Match.StatusCodes).To(Equal([]string{"200-399"}))
+		})
+
+		It("combines the body match with the status codes", func() {
+			ingress.Annotations[annotations.HealthProbeMatchBodyKey] = "status: healthy"
+			ingress.Annotations[annotations.HealthProbeStatusCodesKey] = " 200-299, 404,"
+			probe := cb.generateHealthProbe(backendFor("web"))
+			Expect(*probe.Match.Body).To(Equal("status: healthy"))
+			Expect(*probe.Match.StatusCodes).To(Equal([]string{"200-299", "404"}))
+		})
+
+		It("matches the status codes without a body", func() {
+			ingress.Annotations[annotations.HealthProbeStatusCodesKey] = "200"
+			probe := cb.generateHealthProbe(backendFor("web"))
+			Expect(probe.Match.Body).To(BeNil())
+			Expect(*probe.Match.StatusCodes).To(Equal([]string{"200"}))
+		})
+
+		It("ignores a body longer than App Gateway allows", func() {
+			ingress.Annotations[annotations.HealthProbeMatchBodyKey] = strings.Repeat("x", 4091)
+			ingress.Annotations[annotations.HealthProbeStatusCodesKey] = "200-299"
+			probe := cb.generateHealthProbe(backendFor("web"))
+			Expect(probe.Match.Body).To(BeNil())
+			Expect(*probe.Match.StatusCodes).To(Equal([]string{"200-299"}))
+			Expect(cb.recorder.(*record.FakeRecorder).Events).To(Receive(ContainSubstring("APPG030")))
+		})
+
+		It("sends the host name of the HTTP settings", func() {
+			ingress.Annotations[annotations.BackendHostNameKey] = "internal.contoso.com"
+			ingress.Annotations[annotations.HealthProbePickHostNameKey] = "true"
+			probe := cb.generateHealthProbe(backendFor("web"))
+			Expect(probe.Host).To(BeNil())
+			Expect(*probe.PickHostNameFromBackendHTTPSettings).To(BeTrue())
+		})
+
+		It("sends the host name of the health-probe-hostname annotation", func() {
+			ingress.Annotations[annotations.BackendHostNameKey] = "internal.contoso.com"
+			ingress.Annotations[annotations.HealthProbeHostNameKey] = "health.contoso.com"
+			probe := cb.generateHealthProbe(backendFor("web"))
+			Expect(*probe.Host).To(Equal("health.contoso.com"))
+			Expect(probe.PickHostNameFromBackendHTTPSettings).To(BeNil())
+		})
+
+		It("sends the health-probe-hostname when the probe is also asked to pick the host name", func() {
+			ingress.Annotations[annotations.BackendHostNameKey] = "internal.contoso.com"
+			ingress.Annotations[annotations.HealthProbeHostNameKey] = "health.contoso.com"
+			ingress.Annotations[annotations.HealthProbePickHostNameKey] = "true"
+			probe := cb.generateHealthProbe(backendFor("web"))
+			Expect(*probe.Host).To(Equal("health.contoso.com"))
+			Expect(probe.PickHostNameFromBackendHTTPSettings).To(BeNil())
+			Expect(cb.recorder.(*record.FakeRecorder).Events).To(Receive(ContainSubstring("APPG039")))
+		})
+
+		It("does not pick the host name of HTTP settings without one", func() {
+			ingress.Annotations[annotations.HealthProbePickHostNameKey] = "true"
+			probe := cb.generateHealthProbe(backendFor("web"))
+			Expect(*probe.Host).To(Equal(ingress.Spec.Rules[0].Host))
+			Expect(probe.PickHostNameFromBackendHTTPSettings).To(BeNil())
+			Expect(cb.recorder.(*record.FakeRecorder).Events).To(Receive(ContainSubstring("APPG040")))
+
+			ingress.Annotations[annotations.PickHostNameFromBackendKey] = "true"
+			probe = cb.generateHealthProbe(backendFor("web"))
+			Expect(probe.Host).To(BeNil())
+			Expect(*probe.PickHostNameFromBackendHTTPSettings).To(BeTrue())
+		})
+
+		It("ignores invalid status codes", func() {
+			for _, statusCodes := range []string{"100-200", "200-500", "299-200", "2xx", ","} {
+				ingress.Annotations[annotations.HealthProbeStatusCodesKey] = statusCodes
+				codes, err := getProbeStatusCodes(ingress)
+				Expect(codes).To(BeNil(), statusCodes)
+				Expect(err).To(Equal(ErrInvalidProbeStatusCodes), statusCodes)
+			}
+		})
+	})
+
+	Context("probe pods of several versions during a rollout", func() {
+		var cb appGwConfigBuilder
+		var ingress *v1beta1.Ingress
+		var backendID backendIdentifier
+
+		addPod := func(name string, path string) {
+			pod := tests.NewPodFixture(tests.ServiceName, tests.Namespace, tests.ContainerName, tests.ContainerPort)
+			pod.Name = name
+			pod.Spec.Containers[0].ReadinessProbe.HTTPGet.Path = path
+			_ = cb.k8sContext.Caches.Pods.Add(pod)
+		}
+
+		BeforeEach(func() {
+			cb = newConfigBuilderFixture(nil)
+			cb.recorder = record.NewFakeRecorder(100)
+			_ = cb.k8sContext.Caches.Endpoints.Add(tests.NewEndpointsFixture())
+			_ = cb.k8sContext.Caches.Service.Add(tests.NewServiceFixture(*tests.NewServicePortsFixture()...))
+
+			ingress = tests.NewIngressFixture()
+			rule := &ingress.Spec.Rules[0]
+			path := &rule.HTTP.Paths[0]
+			backendID = generateBackendID(ingress, rule, path, &path.Backend)
+		})
+
+		It("probes the path of the most pods", func() {
+			addPod("web-v1-a", "/healthz")
+			addPod("web-v2-a", "/v2/healthz")
+			addPod("web-v2-b", "/v2/healthz")
+			Expect(*cb.generateHealthProbe(backendID).Path).To(Equal("/v2/healthz"))
+
+			addPod("web-v1-b", "/healthz")
+			addPod("web-v1-c", "/healthz")
+			Expect(*cb.generateHealthProbe(backendID).Path).To(Equal("/healthz"))
+		})
+
+		It("breaks a tie by the path", func() {
+			addPod("web-v2-a", "/v2/healthz")
+			addPod("web-v1-a", "/healthz")
+			for i := 0; i < 10; i++ {
+				Expect(*cb.generateHealthProbe(backendID).Path).To(Equal("/healthz"))
+			}
+		})
+
+		It("settles on the new path once the rollout completes", func() {
+			addPod("web-v1-a", "/healthz")
+			addPod("web-v2-a", "/v2/healthz")
+			Expect(*cb.generateHealthProbe(backendID).Path).To(Equal("/healthz"))
+
+			pod, _, _ := cb.k8sContext.Caches.Pods.GetByKey(tests.Namespace + "/web-v1-a")
+			_ = cb.k8sContext.Caches.Pods.Delete(pod)
+			Expect(*cb.generateHealthProbe(backendID).Path).To(Equal("/v2/healthz"))
+		})
+	})
+
 	Context("test generateHealthProbe()", func() {
 		cb := newConfigBuilderFixture(nil)
 		be := backendIdentifier{
@@ -80,6 +80,10 @@ func (agw Identifier) requestRoutingRuleID(settingsName string) string {
 	return agw.gatewayResourceID("requestRoutingRules", settingsName)
 }
 
+func (agw Identifier) rewriteRuleSetID(ruleSetName string) string {
+	return agw.gatewayResourceID("rewriteRuleSets", ruleSetName)
+}
+
 func resourceRef(id string) *n.SubResource {
 	return &n.SubResource{ID: to.StringPtr(id)}
 }
@@ -0,0 +1,172 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/golang/glog"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+)
+
+// sortIngressesByPrecedence returns the ingresses in the order in which they claim hosts and paths:
+// the oldest ingress (by creationTimestamp) first; ties are broken by namespace, then by name.
+func sortIngressesByPrecedence(ingresses []*v1beta1.Ingress) []*v1beta1.Ingress {
+	sorted := make([]*v1beta1.Ingress, len(ingresses))
+	copy(sorted, ingresses)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if !sorted[i].CreationTimestamp.Equal(&sorted[j].CreationTimestamp) {
+			return sorted[i].CreationTimestamp.Before(&sorted[j].CreationTimestamp)
+		}
+		if sorted[i].Namespace != sorted[j].Namespace {
+			return sorted[i].Namespace < sorted[j].Namespace
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
+
+// pathOwners tracks which ingress claimed the default backend and each path of a listener.
+type pathOwners struct {
+	defaults map[listenerIdentifier]*v1beta1.Ingress
+	paths    map[listenerIdentifier]map[string]*v1beta1.Ingress
+}
+
+func newPathOwners() *pathOwners {
+	return &pathOwners{
+		defaults: make(map[listenerIdentifier]*v1beta1.Ingress),
+		paths:    make(map[listenerIdentifier]map[string]*v1beta1.Ingress),
+	}
+}
+
+// resolveConflicts removes from the path map of the ingress the default backend and the paths of the listener, which
+// were already claimed by an ingress with higher precedence. A Warning event names the conflict and the winner.
+// Ingresses must be processed in the order of sortIngressesByPrecedence.
+func (c *appGwConfigBuilder) resolveConflicts(owners *pathOwners, listenerID listenerIdentifier, ingress *v1beta1.Ingress, pathMap *n.ApplicationGatewayURLPathMap, cbCtx *ConfigBuilderContext) {
+	hasDefault := pathMap.DefaultRedirectConfiguration != nil ||
+		(pathMap.DefaultBackendAddressPool != nil && *pathMap.DefaultBackendAddressPool.ID != *cbCtx.DefaultAddressPoolID)
+	if hasDefault {
+		if winner, exists := owners.defaults[listenerID]; exists && winner != ingress {
+			c.recordConflict(ingress, winner, listenerID, "the default backend")
+			pathMap.DefaultRedirectConfiguration = nil
+			pathMap.DefaultBackendAddressPool = nil
+			pathMap.DefaultBackendHTTPSettings = nil
+		} else {
+			owners.defaults[listenerID] = ingress
+		}
+	}
+
+	if pathMap.PathRules == nil {
+		return
+	}
+	if _, exists := owners.paths[listenerID]; !exists {
+		owners.paths[listenerID] = make(map[string]*v1beta1.Ingress)
+	}
+	var pathRules []n.ApplicationGatewayPathRule
+	for _, pathRule := range *pathMap.PathRules {
+		if pathRule.Paths == nil {
+			pathRules = append(pathRules, pathRule)
+			continue
+		}
+		var paths []string
+		for _, path := range *pathRule.Paths {
+			if winner, exists := owners.paths[listenerID][path]; exists && winner != ingress {
+				c.recordConflict(ingress, winner, listenerID, fmt.Sprintf("path %s", path))
+				continue
+			}
+			owners.paths[listenerID][path] = ingress
+			paths = append(paths, path)
+		}
+		if len(paths) == 0 {
+			continue
+		}
+		pathRule.Paths = &paths
+		pathRules = append(pathRules, pathRule)
+	}
+	pathMap.PathRules = &pathRules
+}
+
+// isCertificateConflict determines whether two ingresses bind different certificates to the same HTTPS listener.
+// A listener serves a single certificate; secrets holding the same certificate and key are not a conflict.
+func (c *appGwConfigBuilder) isCertificateConflict(existing listenerAzConfig, config listenerAzConfig) bool {
+	if existing.Protocol != n.HTTPS || config.Protocol != n.HTTPS || existing.Secret == config.Secret {
+		return false
+	}
+	return !c.isSameCertificate(existing.Secret, config.Secret)
+}
+
+func (c *appGwConfigBuilder) isSameCertificate(secretID secretIdentifier, otherSecretID secretIdentifier) bool {
+	secret, exists, err := c.k8sContext.Caches.Secret.GetByKey(secretID.secretKey())
+	if err != nil || !exists {
+		return false
+	}
+	otherSecret, exists, err := c.k8sContext.Caches.Secret.GetByKey(otherSecretID.secretKey())
+	if err != nil || !exists {
+		return false
+	}
+	data := secret.(*v1.Secret).Data
+	otherData := otherSecret.(*v1.Secret).Data
+	return len(data[v1.TLSCertKey]) != 0 &&
+		bytes.Equal(data[v1.TLSCertKey], otherData[v1.TLSCertKey]) &&
+		bytes.Equal(data[v1.TLSPrivateKeyKey], otherData[v1.TLSPrivateKeyKey])
+}
+
+// mergeListenerConfig merges the config, which an ingress without precedence defines for a listener, into the config
+// of the ingresses taking precedence. The listener keeps their protocol, certificate, WAF policy and maintenance page,
+// and takes the WAF policy and maintenance page they leave unset from the ingress. It redirects to HTTPS, and requires
+// SNI, when any of the ingresses asks for it.
+func (c *appGwConfigBuilder) mergeListenerConfig(ingress *v1beta1.Ingress, owner *v1beta1.Ingress, listenerID listenerIdentifier, existing listenerAzConfig, config listenerAzConfig) listenerAzConfig {
+	merged := existing
+	if merged.SslRedirectConfigurationName == "" {
+		merged.SslRedirectConfigurationName = config.SslRedirectConfigurationName
+	}
+	merged.RequireServerNameIndication = existing.RequireServerNameIndication || config.RequireServerNameIndication
+	if merged.FirewallPolicy == "" {
+		merged.FirewallPolicy = config.FirewallPolicy
+	} else if config.FirewallPolicy != "" && config.FirewallPolicy != merged.FirewallPolicy {
+		c.recordConflict(ingress, owner, listenerID, fmt.Sprintf("the WAF policy %s", config.FirewallPolicy))
+	}
+	if merged.MaintenancePageURL == "" {
+		merged.MaintenancePageURL = config.MaintenancePageURL
+	} else if config.MaintenancePageURL != "" && config.MaintenancePageURL != merged.MaintenancePageURL {
+		c.recordConflict(ingress, owner, listenerID, fmt.Sprintf("the maintenance page %s", config.MaintenancePageURL))
+	}
+	return merged
+}
+
+// conflictKey identifies a conflict, which is recorded once per build however many times the ingresses are walked.
+type conflictKey struct {
+	ingress    string
+	listenerID listenerIdentifier
+	what       string
+}
+
+func (c *appGwConfigBuilder) recordConflict(loser *v1beta1.Ingress, winner *v1beta1.Ingress, listenerID listenerIdentifier, what string) {
+	key := conflictKey{ingress: loser.Namespace + "/" + loser.Name, listenerID: listenerID, what: what}
+	if _, exists := c.recordedConflicts[key]; exists {
+		return
+	}
+	if c.recordedConflicts == nil {
+		c.recordedConflicts = make(map[conflictKey]interface{})
+	}
+	c.recordedConflicts[key] = nil
+
+	host := strings.Join(listenerID.getHostNames(), ",")
+	if host == "" {
+		host = "*"
+	}
+	logLine := fmt.Sprintf("Ingress %s/%s defines %s for host %s and port %d, which is also defined by Ingress %s/%s. Ingress %s/%s takes precedence (oldest creationTimestamp, then namespace/name); the definition in %s/%s is ignored.",
+		loser.Namespace, loser.Name, what, host, listenerID.FrontendPort, winner.Namespace, winner.Name, winner.Namespace, winner.Name, loser.Namespace, loser.Name)
+	glog.Warning(logLine)
+	events.Warning(c.recorder, loser, events.ReasonConflictingIngress, logLine)
+}
@@ -17,8 +17,8 @@ import (
 func (c *appGwConfigBuilder) getListenersFromIngress(ingress *v1beta1.Ingress, env environment.EnvVariables) map[listenerIdentifier]listenerAzConfig {
 	listeners := make(map[listenerIdentifier]listenerAzConfig)
 	for ruleIdx := range ingress.Spec.Rules {
-		rule := &ingress.Spec.Rules[ruleIdx]
-		if rule.HTTP == nil {
+		rule := getHTTPRule(ingress, &ingress.Spec.Rules[ruleIdx])
+		if rule == nil {
 			continue
 		}
 
@@ -30,22 +30,46 @@ func (c *appGwConfigBuilder) getListenersFromIngress(ingress *v1beta1.Ingress, e
 	return listeners
 }
 
+// getHTTPRule returns the rule to build listeners and path maps from. A rule with a host and no paths is served
+// entirely by the default backend of the ingress; without a default backend, there is nothing to route to.
+func getHTTPRule(ingress *v1beta1.Ingress, rule *v1beta1.IngressRule) *v1beta1.IngressRule {
+	if rule.HTTP != nil {
+		return rule
+	}
+	if ingress.Spec.Backend == nil {
+		return nil
+	}
+	return &v1beta1.IngressRule{
+		Host: rule.Host,
+		IngressRuleValue: v1beta1.IngressRuleValue{
+			HTTP: &v1beta1.HTTPIngressRuleValue{},
+		},
+	}
+}
+
 func (c *appGwConfigBuilder) processIngressRule(rule *v1beta1.IngressRule, ingress *v1beta1.Ingress, env environment.EnvVariables) (map[Port]interface{}, map[listenerIdentifier]listenerAzConfig) {
 	frontendPorts := make(map[Port]interface{})
 	ingressHostnameSecretIDMap := c.newHostToSecretMap(ingress)
 	listeners := make(map[listenerIdentifier]listenerAzConfig)
 
-	// Private IP is used when either annotation use-private-ip or USE_PRIVATE_IP env variable is true.
+	// Private IP is used when either annotation use-private-ip is true, or APPGW_FRONTEND_IP_CONFIGURATION names a private
+	// IP configuration, or USE_PRIVATE_IP env variable is true.
 	usePrivateIPFromAnnotation, _ := annotations.UsePrivateIP(ingress)
-	usePrivateIPForIngress := usePrivateIPFromAnnotation || env.UsePrivateIP == "true"
+	usePrivateIPForIngress := usePrivateIPFromAnnotation || UsePrivateIPByDefault(c.appGw.FrontendIPConfigurations, env)
 
 	cert, secID := c.getCertificate(ingress, rule.Host, ingressHostnameSecretIDMap)
+	if cert == nil && env.AutoSelectTLSSecrets && rule.Host != "" {
+		cert, secID = c.getAutoSelectedCertificate(ingress, rule.Host)
+	}
 	hasTLS := cert != nil
-	sslRedirect, _ := annotations.IsSslRedirect(ingress)
+	sslRedirect := IsSslRedirect(ingress, env)
+	requireSNI, _ := annotations.RequireSNI(ingress)
+	// An invalid listener port is reported by getListenerConfigs; the listeners are then created on the default ports.
+	listenerPort, _ := c.getListenerPort(ingress, env)
 	// If a certificate is available we enable only HTTPS; unless ingress is annotated with ssl-redirect - then
 	// we enable HTTPS as well as HTTP, and redirect HTTP to HTTPS.
 	if hasTLS {
-		listenerID := generateListenerID(ingress, rule, n.HTTPS, nil, usePrivateIPForIngress)
+		listenerID := generateListenerID(ingress, rule, n.HTTPS, listenerPort, usePrivateIPForIngress)
 		frontendPorts[Port(listenerID.FrontendPort)] = nil
 		// Only associate the Listener with a Redirect if redirect is enabled
 		redirect := ""
@@ -57,12 +81,16 @@ func (c *appGwConfigBuilder) processIngressRule(rule *v1beta1.IngressRule, ingre
 			Protocol:                     n.HTTPS,
 			Secret:                       *secID,
 			SslRedirectConfigurationName: redirect,
+			// SNI can only be required on a multi-site listener; see validateRequireSNI
+			RequireServerNameIndication: requireSNI && len(listenerID.getHostNames()) != 0,
 		}
 	}
 
 	// Enable HTTP only if HTTPS is not configured OR if ingress annotated with 'ssl-redirect'
 	if sslRedirect || !hasTLS {
-		listenerID := generateListenerID(ingress, rule, n.HTTP, nil, usePrivateIPForIngress)
+		// The listener port applies to the HTTPS listener; HTTP requests are redirected to it from the HTTP listener port.
+		httpPort := c.getHTTPListenerPort(ingress, env, hasTLS)
+		listenerID := generateListenerID(ingress, rule, n.HTTP, &httpPort, usePrivateIPForIngress)
 		frontendPorts[Port(listenerID.FrontendPort)] = nil
 		listeners[listenerID] = listenerAzConfig{
 			Protocol: n.HTTP,
@@ -0,0 +1,125 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"k8s.io/api/extensions/v1beta1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/utils"
+)
+
+// IngressTombstones keeps the ingresses, which were removed, for the pruning grace period: the config is built as if
+// they were still there, so their App Gateway objects are not pruned. An ingress recreated within the grace period
+// replaces its tombstone and reuses the objects; otherwise they are pruned once the grace period ends.
+// IngressTombstones outlives the config builder; it is shared by consecutive builds.
+type IngressTombstones struct {
+	sync.Mutex
+	known       map[string]*v1beta1.Ingress
+	tombstones  map[string]ingressTombstone
+	reconcileAt time.Time
+}
+
+type ingressTombstone struct {
+	ingress *v1beta1.Ingress
+	until   time.Time
+}
+
+// NewIngressTombstones creates a new IngressTombstones struct.
+func NewIngressTombstones() *IngressTombstones {
+	return &IngressTombstones{
+		known:      make(map[string]*v1beta1.Ingress),
+		tombstones: make(map[string]ingressTombstone),
+	}
+}
+
+// GetPruningGracePeriod returns the grace period set with APPGW_PRUNING_GRACE_PERIOD_SECONDS, or 0 when it is not set.
+func GetPruningGracePeriod(env environment.EnvVariables) time.Duration {
+	if env.PruningGracePeriod == "" {
+		return 0
+	}
+	seconds, err := strconv.Atoi(env.PruningGracePeriod)
+	if err != nil {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// Retain returns the ingresses along with the tombstones of the ingresses, which were removed within the grace period.
+// An ingress missing from the ingresses given to the previous call becomes a tombstone.
+func (t *IngressTombstones) Retain(ingresses []*v1beta1.Ingress, gracePeriod time.Duration, now time.Time) []*v1beta1.Ingress {
+	t.Lock()
+	defer t.Unlock()
+
+	current := make(map[string]*v1beta1.Ingress)
+	for _, ingress := range ingresses {
+		current[utils.GetResourceKey(ingress.Namespace, ingress.Name)] = ingress
+	}
+	previous := t.known
+	t.known = current
+
+	if gracePeriod == 0 {
+		t.tombstones = make(map[string]ingressTombstone)
+		return ingresses
+	}
+
+	for key, ingress := range previous {
+		if _, exists := current[key]; !exists {
+			glog.V(3).Infof("Ingress %s was removed; its App Gateway objects are kept for %+v", key, gracePeriod)
+			t.tombstones[key] = ingressTombstone{
+				ingress: ingress,
+				until:   now.Add(gracePeriod),
+			}
+		}
+	}
+
+	retained := append([]*v1beta1.Ingress{}, ingresses...)
+	for key, tombstone := range t.tombstones {
+		if _, exists := current[key]; exists {
+			glog.V(3).Infof("Ingress %s was recreated within the grace period; it reuses the App Gateway objects", key)
+			delete(t.tombstones, key)
+			continue
+		}
+		if !now.Before(tombstone.until) {
+			glog.V(3).Infof("Grace period of removed ingress %s ended; its App Gateway objects are pruned", key)
+			delete(t.tombstones, key)
+			continue
+		}
+		retained = append(retained, tombstone.ingress)
+	}
+	return retained
+}
+
+// IsTombstone determines whether the ingress is the tombstone of a removed ingress, rather than a live one.
+func (t *IngressTombstones) IsTombstone(ingress *v1beta1.Ingress) bool {
+	t.Lock()
+	defer t.Unlock()
+	tombstone, exists := t.tombstones[utils.GetResourceKey(ingress.Namespace, ingress.Name)]
+	return exists && tombstone.ingress == ingress
+}
+
+// PendingReconcile returns the delay until the earliest grace period ends, unless a reconcile was already requested for it.
+// The caller is expected to reconcile after the delay, so that the objects are pruned even if nothing else changes.
+func (t *IngressTombstones) PendingReconcile(now time.Time) (time.Duration, bool) {
+	t.Lock()
+	defer t.Unlock()
+	var earliest time.Time
+	for _, tombstone := range t.tombstones {
+		if earliest.IsZero() || tombstone.until.Before(earliest) {
+			earliest = tombstone.until
+		}
+	}
+	if earliest.IsZero() || earliest.Equal(t.reconcileAt) {
+		return 0, false
+	}
+	t.reconcileAt = earliest
+	return earliest.Sub(now), true
+}
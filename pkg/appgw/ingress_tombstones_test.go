@@ -0,0 +1,114 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"time"
+
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
+)
+
+var _ = Describe("Test the pruning grace period of removed ingresses", func() {
+	const gracePeriod = 60 * time.Second
+
+	var tombstones *IngressTombstones
+	var now time.Time
+	var kept, removed *v1beta1.Ingress
+
+	newIngress := func(name, host string) *v1beta1.Ingress {
+		ingress := tests.NewIngressFixture()
+		ingress.Name = name
+		ingress.Annotations[annotations.SslRedirectKey] = "false"
+		ingress.Spec.TLS = nil
+		backend := tests.NewIngressBackendFixture(tests.ServiceName, 80)
+		ingress.Spec.Rules = []v1beta1.IngressRule{tests.NewIngressRuleFixture(host, "/", *backend)}
+		return ingress
+	}
+
+	// listenerNames builds the listeners for the ingresses and returns their names.
+	listenerNames := func(ingresses []*v1beta1.Ingress) []string {
+		cb := newConfigBuilderFixture(nil)
+		service := tests.NewServiceFixture(*tests.NewServicePortsFixture()...)
+		_ = cb.k8sContext.Caches.Service.Add(service)
+		_ = cb.k8sContext.Caches.Endpoints.Add(tests.NewEndpointsFixture())
+		cbCtx := &ConfigBuilderContext{
+			IngressList:           ingresses,
+			ServiceList:           []*v1.Service{service},
+			EnvVariables:          environment.GetFakeEnv(),
+			DefaultAddressPoolID:  to.StringPtr("xx"),
+			DefaultHTTPSettingsID: to.StringPtr("yy"),
+		}
+		Expect(cb.Listeners(cbCtx)).ToNot(HaveOccurred())
+		var names []string
+		for _, listener := range *cb.appGw.HTTPListeners {
+			names = append(names, *listener.Name)
+		}
+		return names
+	}
+
+	BeforeEach(func() {
+		tombstones = NewIngressTombstones()
+		now = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		kept = newIngress("kept", "kept.contoso.com")
+		removed = newIngress("removed", "removed.contoso.com")
+		Expect(tombstones.Retain([]*v1beta1.Ingress{kept, removed}, gracePeriod, now)).To(HaveLen(2))
+	})
+
+	It("keeps the objects of an ingress removed and recreated within the grace period", func() {
+		listeners := listenerNames([]*v1beta1.Ingress{kept, removed})
+
+		now = now.Add(time.Second)
+		retained := tombstones.Retain([]*v1beta1.Ingress{kept}, gracePeriod, now)
+		Expect(retained).To(ConsistOf(kept, removed))
+		Expect(listenerNames(retained)).To(ConsistOf(listeners))
+
+		now = now.Add(10 * time.Second)
+		recreated := newIngress("removed", "removed.contoso.com")
+		retained = tombstones.Retain([]*v1beta1.Ingress{kept, recreated}, gracePeriod, now)
+		Expect(retained).To(HaveLen(2))
+		Expect(retained).To(ContainElement(BeIdenticalTo(recreated)), "the recreated ingress replaces its tombstone")
+		Expect(listenerNames(retained)).To(ConsistOf(listeners))
+
+		_, pending := tombstones.PendingReconcile(now)
+		Expect(pending).To(BeFalse())
+	})
+
+	It("prunes the objects of a removed ingress once the grace period ends", func() {
+		retained := tombstones.Retain([]*v1beta1.Ingress{kept}, gracePeriod, now)
+		Expect(retained).To(ConsistOf(kept, removed))
+
+		delay, pending := tombstones.PendingReconcile(now)
+		Expect(pending).To(BeTrue())
+		Expect(delay).To(Equal(gracePeriod))
+		_, pending = tombstones.PendingReconcile(now)
+		Expect(pending).To(BeFalse(), "the reconcile was already requested")
+
+		now = now.Add(gracePeriod)
+		Expect(tombstones.Retain([]*v1beta1.Ingress{kept}, gracePeriod, now)).To(ConsistOf(kept))
+		Expect(tombstones.Retain([]*v1beta1.Ingress{kept}, gracePeriod, now)).To(ConsistOf(kept))
+	})
+
+	It("prunes the objects of a removed ingress right away without a grace period", func() {
+		Expect(tombstones.Retain([]*v1beta1.Ingress{kept}, 0, now)).To(ConsistOf(kept))
+		_, pending := tombstones.PendingReconcile(now)
+		Expect(pending).To(BeFalse())
+	})
+
+	It("reads the grace period from the environment", func() {
+		env := environment.GetFakeEnv()
+		Expect(GetPruningGracePeriod(env)).To(BeZero())
+		env.PruningGracePeriod = "90"
+		Expect(GetPruningGracePeriod(env)).To(Equal(90 * time.Second))
+	})
+})
@@ -13,7 +13,6 @@ import (
 	"crypto/md5"
 	"fmt"
 	"math"
-	"regexp"
 
 	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
 	"github.com/Azure/go-autorest/autorest/to"
@@ -34,6 +33,7 @@ const (
 	prefixRoutingRule  = "rr"
 	prefixRedirect     = "sslr"
 	prefixPathRule     = "pr"
+	prefixRewrite      = "rw"
 )
 
 const (
@@ -71,9 +71,7 @@ type secretIdentifier struct {
 	Name      string
 }
 
-// Max length for a property name is 80 characters. We hash w/ MD5 when length is > 80, which is 32 characters
-var agPrefixValidator = regexp.MustCompile(`^[0-9a-zA-Z\-]{0,47}$`)
-var agPrefix = environment.GetEnvironmentVariable("APPGW_CONFIG_NAME_PREFIX", "", agPrefixValidator)
+var agPrefix = environment.GetEnvironmentVariable(environment.ConfigNamePrefixVarName, "", environment.ConfigNamePrefixValidator)
 
 // create xxx -> xxxconfiguration mappings to contain all the information
 type listenerAzConfig struct {
@@ -81,6 +79,8 @@ type listenerAzConfig struct {
 	Secret                       secretIdentifier
 	SslRedirectConfigurationName string
 	FirewallPolicy               string
+	RequireServerNameIndication  bool
+	MaintenancePageURL           string
 }
 
 // formatPropName ensures that the string generated is not longer than 80 characters.
@@ -150,6 +150,14 @@ func generateSSLRedirectConfigurationName(targetListener listenerIdentifier) str
 	return formatPropName(fmt.Sprintf("%s%s-%s", agPrefix, prefixRedirect, generateListenerName(targetListener)))
 }
 
+func generateRewriteRuleSetName(namespace, name string) string {
+	return formatPropName(fmt.Sprintf("%s%s-%s-%s", agPrefix, prefixRewrite, namespace, name))
+}
+
+func generateHostPortRewriteRuleSetName(namespace, ingress string, port int32) string {
+	return formatPropName(fmt.Sprintf("%s%s-host-port-%s-%s-%d", agPrefix, prefixRewrite, namespace, ingress, port))
+}
+
 func generatePathRuleName(namespace, ingress, suffix string) string {
 	return formatPropName(fmt.Sprintf("%s%s-%s-%s-%s", agPrefix, prefixPathRule, namespace, ingress, suffix))
 }
@@ -209,9 +217,11 @@ func defaultBackendAddressPool(appGWIdentifier Identifier) n.ApplicationGatewayB
 	}
 }
 
-func defaultFrontendListenerIdentifier() listenerIdentifier {
+// defaultFrontendListenerIdentifier returns the identifier of the listener without a host name on the HTTP listener port,
+// which receives the requests for the frontend IP of App Gateway.
+func defaultFrontendListenerIdentifier(env environment.EnvVariables) listenerIdentifier {
 	return listenerIdentifier{
-		FrontendPort: Port(80),
+		FrontendPort: getDefaultHTTPListenerPort(env),
 		HostName:     "",
 	}
 }
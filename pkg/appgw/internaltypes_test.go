@@ -7,6 +7,7 @@ package appgw
 
 import (
 	"fmt"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/utils"
 	. "github.com/onsi/ginkgo"
@@ -199,19 +200,19 @@ var _ = Describe("Test string key generators", func() {
 	Context("test agPrefix sanitizer", func() {
 		It("should fail for long strings", func() {
 			// ensure this is setup correctly
-			Expect(agPrefixValidator.MatchString(veryLongString)).To(BeFalse())
+			Expect(environment.ConfigNamePrefixValidator.MatchString(veryLongString)).To(BeFalse())
 		})
 		It("should pass for short alphanumeric strings", func() {
 			// ensure this is setup correctly
-			Expect(agPrefixValidator.MatchString("abc-xyz")).To(BeTrue())
+			Expect(environment.ConfigNamePrefixValidator.MatchString("abc-xyz")).To(BeTrue())
 		})
 		It("should pass for empty strings", func() {
 			// ensure this is setup correctly
-			Expect(agPrefixValidator.MatchString("")).To(BeTrue())
+			Expect(environment.ConfigNamePrefixValidator.MatchString("")).To(BeTrue())
 		})
 		It("should fail for non alphanumeric strings", func() {
 			// ensure this is setup correctly
-			Expect(agPrefixValidator.MatchString("omega----Ω")).To(BeFalse())
+			Expect(environment.ConfigNamePrefixValidator.MatchString("omega----Ω")).To(BeFalse())
 		})
 	})
 
@@ -9,9 +9,11 @@ import (
 	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
 	"github.com/golang/glog"
 	"github.com/knative/pkg/apis/istio/v1alpha3"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
 )
 
-func (c *appGwConfigBuilder) getListenerConfigsFromIstio(istioGateways []*v1alpha3.Gateway, istioVirtualServices []*v1alpha3.VirtualService) map[listenerIdentifier]listenerAzConfig {
+func (c *appGwConfigBuilder) getListenerConfigsFromIstio(env environment.EnvVariables, istioGateways []*v1alpha3.Gateway, istioVirtualServices []*v1alpha3.VirtualService) map[listenerIdentifier]listenerAzConfig {
 	knownHosts := make(map[string]interface{})
 	for _, virtualService := range istioVirtualServices {
 		for _, host := range virtualService.Spec.Hosts {
@@ -41,7 +43,7 @@ func (c *appGwConfigBuilder) getListenerConfigsFromIstio(istioGateways []*v1alph
 
 	// App Gateway must have at least one listener - the default one!
 	if len(allListeners) == 0 {
-		allListeners[defaultFrontendListenerIdentifier()] = listenerAzConfig{
+		allListeners[defaultFrontendListenerIdentifier(env)] = listenerAzConfig{
 			// Default protocol
 			Protocol: n.HTTP,
 		}
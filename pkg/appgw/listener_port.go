@@ -0,0 +1,185 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"strconv"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"k8s.io/api/extensions/v1beta1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+)
+
+const (
+	minListenerPort int32 = 1
+	maxListenerPort int32 = 65535
+)
+
+// listenerPortsInUse holds the protocols of the listeners, which the ingresses of a build request on each frontend port.
+type listenerPortsInUse map[Port]map[n.ApplicationGatewayProtocol]interface{}
+
+func (inUse listenerPortsInUse) add(port Port, protocol n.ApplicationGatewayProtocol) {
+	if _, exists := inUse[port]; !exists {
+		inUse[port] = make(map[n.ApplicationGatewayProtocol]interface{})
+	}
+	inUse[port][protocol] = nil
+}
+
+// has determines whether listeners of the protocol are requested on the port.
+func (inUse listenerPortsInUse) has(port Port, protocol n.ApplicationGatewayProtocol) bool {
+	_, exists := inUse[port][protocol]
+	return exists
+}
+
+// newListenerPortsInUse collects the frontend ports, which the ingresses request for their listeners of each protocol.
+// The requested ports are collected before any conflict between them is resolved.
+func (c *appGwConfigBuilder) newListenerPortsInUse(ingressList []*v1beta1.Ingress, env environment.EnvVariables) listenerPortsInUse {
+	inUse := make(listenerPortsInUse)
+	for _, ingress := range ingressList {
+		listenerPort, _ := parseListenerPort(ingress, c.appGw.Sku)
+		httpPort, _ := parseHTTPListenerPort(ingress, c.appGw.Sku)
+		if len(ingress.Spec.TLS) != 0 {
+			inUse.add(portOrDefault(listenerPort, Port(443)), n.HTTPS)
+			if IsSslRedirect(ingress, env) {
+				inUse.add(portOrDefault(httpPort, getDefaultHTTPListenerPort(env)), n.HTTP)
+			}
+			continue
+		}
+		if httpPort == nil {
+			httpPort = listenerPort
+		}
+		inUse.add(portOrDefault(httpPort, getDefaultHTTPListenerPort(env)), n.HTTP)
+	}
+	return inUse
+}
+
+func portOrDefault(port *Port, defaultPort Port) Port {
+	if port != nil {
+		return *port
+	}
+	return defaultPort
+}
+
+// parseListenerPort returns the frontend port requested by the ingress for its listeners, or nil when the ingress does
+// not set one.
+func parseListenerPort(ingress *v1beta1.Ingress, sku *n.ApplicationGatewaySku) (*Port, error) {
+	port, err := annotations.ListenerPort(ingress)
+	if annotations.IsMissingAnnotations(err) {
+		return nil, nil
+	}
+	if err != nil || port < minListenerPort || port > maxListenerPort {
+		return nil, ErrInvalidListenerPort
+	}
+	if isReservedListenerPort(port, sku) {
+		return nil, ErrListenerPortReserved
+	}
+	listenerPort := Port(port)
+	return &listenerPort, nil
+}
+
+// isReservedListenerPort determines whether App Gateway reserves the port for its infrastructure.
+func isReservedListenerPort(port int32, sku *n.ApplicationGatewaySku) bool {
+	if sku != nil && (sku.Tier == n.ApplicationGatewayTierStandard || sku.Tier == n.ApplicationGatewayTierWAF) {
+		return port >= 65503 && port <= 65534
+	}
+	return port >= 65200
+}
+
+// getListenerPort returns the frontend port of the listeners of the ingress, or nil when they are created on the default
+// ports. A frontend port serves the listeners of one protocol: the port can not be the port of the HTTP listeners of an
+// ingress with TLS, nor the default HTTPS port for an ingress without TLS, nor a port other ingresses request for the
+// listeners of the other protocol.
+func (c *appGwConfigBuilder) getListenerPort(ingress *v1beta1.Ingress, env environment.EnvVariables) (*Port, error) {
+	port, err := parseListenerPort(ingress, c.appGw.Sku)
+	if port == nil || err != nil {
+		return nil, err
+	}
+	otherProtocol, otherPort := n.HTTPS, Port(443)
+	if len(ingress.Spec.TLS) != 0 {
+		otherProtocol, otherPort = n.HTTP, c.getHTTPListenerPort(ingress, env, true)
+	}
+	if *port == otherPort || c.mem.listenerPortsInUse.has(*port, otherProtocol) {
+		return nil, ErrListenerPortProtocolConflict
+	}
+	return port, nil
+}
+
+// validateListenerPort ensures the App Gateway can create listeners on the frontend port requested by the ingress.
+func (c *appGwConfigBuilder) validateListenerPort(ingress *v1beta1.Ingress, env environment.EnvVariables) error {
+	_, err := c.getListenerPort(ingress, env)
+	return err
+}
+
+// getDefaultHTTPListenerPort returns the frontend port set with APPGW_HTTP_LISTENER_PORT, or 80 when it is not set.
+func getDefaultHTTPListenerPort(env environment.EnvVariables) Port {
+	if env.HTTPListenerPort == "" {
+		return Port(80)
+	}
+	port, err := strconv.Atoi(env.HTTPListenerPort)
+	if err != nil {
+		return Port(80)
+	}
+	return Port(port)
+}
+
+// getHTTPListenerPortAnnotation returns the frontend port requested by the ingress for its HTTP listeners, or nil when
+// the ingress does not set one.
+func (c *appGwConfigBuilder) getHTTPListenerPortAnnotation(ingress *v1beta1.Ingress) (*Port, error) {
+	port, err := parseHTTPListenerPort(ingress, c.appGw.Sku)
+	if port == nil || err != nil {
+		return nil, err
+	}
+	// A frontend port serves the listeners of one protocol: the port can not be the port of the HTTPS listeners of this
+	// ingress, nor of any other ingress of the build.
+	if len(ingress.Spec.TLS) != 0 {
+		if listenerPort, _ := parseListenerPort(ingress, c.appGw.Sku); *port == portOrDefault(listenerPort, Port(443)) {
+			return nil, ErrHTTPListenerPortConflict
+		}
+	}
+	if c.mem.listenerPortsInUse.has(*port, n.HTTPS) {
+		return nil, ErrHTTPListenerPortConflict
+	}
+	return port, nil
+}
+
+// parseHTTPListenerPort returns the frontend port requested by the ingress for its HTTP listeners, or nil when the
+// ingress does not set one.
+func parseHTTPListenerPort(ingress *v1beta1.Ingress, sku *n.ApplicationGatewaySku) (*Port, error) {
+	port, err := annotations.HTTPListenerPort(ingress)
+	if annotations.IsMissingAnnotations(err) {
+		return nil, nil
+	}
+	if err != nil || port < minListenerPort || port > maxListenerPort || isReservedListenerPort(port, sku) {
+		return nil, ErrInvalidHTTPListenerPort
+	}
+	httpPort := Port(port)
+	return &httpPort, nil
+}
+
+// getHTTPListenerPort returns the frontend port of the HTTP listeners of the ingress: the port of its
+// http-listener-port annotation, or else of its listener-port annotation when its listeners do not have TLS, or else
+// the port set with APPGW_HTTP_LISTENER_PORT, or 80.
+func (c *appGwConfigBuilder) getHTTPListenerPort(ingress *v1beta1.Ingress, env environment.EnvVariables, hasTLS bool) Port {
+	if port, _ := c.getHTTPListenerPortAnnotation(ingress); port != nil {
+		return *port
+	}
+	if !hasTLS {
+		if port, _ := c.getListenerPort(ingress, env); port != nil {
+			return *port
+		}
+	}
+	return getDefaultHTTPListenerPort(env)
+}
+
+// getHTTPSListenerPort returns the port of the HTTPS listeners of the ingress, which its HTTP listeners redirect to.
+func (c *appGwConfigBuilder) getHTTPSListenerPort(ingress *v1beta1.Ingress, env environment.EnvVariables) Port {
+	if port, _ := c.getListenerPort(ingress, env); port != nil {
+		return *port
+	}
+	return Port(443)
+}
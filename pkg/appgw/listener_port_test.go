@@ -0,0 +1,362 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
+)
+
+var _ = Describe("listener-port annotation", func() {
+	v1SKU := &n.ApplicationGatewaySku{Tier: n.ApplicationGatewayTierStandard}
+	v2SKU := &n.ApplicationGatewaySku{Tier: n.ApplicationGatewayTierStandardV2}
+
+	Context("validate the port", func() {
+		It("is not set without the annotation", func() {
+			port, err := parseListenerPort(tests.NewIngressFixture(), v2SKU)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(port).To(BeNil())
+		})
+
+		It("accepts ports App Gateway allows", func() {
+			for _, value := range []string{"1", "8080", "8443", "65199"} {
+				ingress := tests.NewIngressFixture()
+				ingress.Annotations[annotations.ListenerPortKey] = value
+				port, err := parseListenerPort(ingress, v2SKU)
+				Expect(err).ToNot(HaveOccurred(), value)
+				Expect(port).ToNot(BeNil(), value)
+			}
+		})
+
+		It("rejects ports out of range", func() {
+			for _, value := range []string{"0", "-1", "65536", "https"} {
+				ingress := tests.NewIngressFixture()
+				ingress.Annotations[annotations.ListenerPortKey] = value
+				port, err := parseListenerPort(ingress, v2SKU)
+				Expect(err).To(Equal(ErrInvalidListenerPort), value)
+				Expect(port).To(BeNil(), value)
+			}
+		})
+
+		It("rejects ports App Gateway reserves", func() {
+			ingress := tests.NewIngressFixture()
+			ingress.Annotations[annotations.ListenerPortKey] = "65300"
+			_, err := parseListenerPort(ingress, v2SKU)
+			Expect(err).To(Equal(ErrListenerPortReserved))
+			_, err = parseListenerPort(ingress, v1SKU)
+			Expect(err).ToNot(HaveOccurred())
+
+			ingress.Annotations[annotations.ListenerPortKey] = "65510"
+			_, err = parseListenerPort(ingress, v1SKU)
+			Expect(err).To(Equal(ErrListenerPortReserved))
+		})
+	})
+
+	Context("a port of listeners of the other protocol", func() {
+		env := environment.GetFakeEnv()
+
+		plainIngress := func(name string) *v1beta1.Ingress {
+			ingress := tests.NewIngressFixture()
+			ingress.Name = name
+			ingress.Spec.TLS = nil
+			ingress.Annotations[annotations.SslRedirectKey] = "false"
+			return ingress
+		}
+
+		newBuilder := func(ingresses ...*v1beta1.Ingress) appGwConfigBuilder {
+			cb := newConfigBuilderFixture(nil)
+			cb.mem.listenerPortsInUse = cb.newListenerPortsInUse(ingresses, env)
+			return cb
+		}
+
+		It("rejects the HTTP port on an ingress with TLS", func() {
+			ingress := tests.NewIngressFixture()
+			ingress.Annotations[annotations.ListenerPortKey] = "80"
+			cb := newBuilder(ingress)
+			Expect(cb.validateListenerPort(ingress, env)).To(Equal(ErrListenerPortProtocolConflict))
+			Expect(cb.getHTTPSListenerPort(ingress, env)).To(Equal(Port(443)))
+
+			// The HTTP port of the ingress is the port of APPGW_HTTP_LISTENER_PORT, unless it sets its own.
+			envHTTP8080 := environment.GetFakeEnv()
+			envHTTP8080.HTTPListenerPort = "8080"
+			ingress.Annotations[annotations.ListenerPortKey] = "8080"
+			Expect(cb.validateListenerPort(ingress, envHTTP8080)).To(Equal(ErrListenerPortProtocolConflict))
+			ingress.Annotations[annotations.HTTPListenerPortKey] = "8081"
+			Expect(cb.validateListenerPort(ingress, envHTTP8080)).ToNot(HaveOccurred())
+		})
+
+		It("rejects the HTTPS port on an ingress without TLS", func() {
+			ingress := plainIngress("plain")
+			ingress.Annotations[annotations.ListenerPortKey] = "443"
+			cb := newBuilder(ingress)
+			Expect(cb.validateListenerPort(ingress, env)).To(Equal(ErrListenerPortProtocolConflict))
+			Expect(cb.getHTTPListenerPort(ingress, env, false)).To(Equal(Port(80)))
+		})
+
+		It("rejects a port other ingresses request for the other protocol", func() {
+			httpsIngress := tests.NewIngressFixture()
+			httpsIngress.Annotations[annotations.ListenerPortKey] = "8443"
+			httpIngress := plainIngress("plain")
+			httpIngress.Annotations[annotations.ListenerPortKey] = "8443"
+			otherHTTPIngress := plainIngress("other")
+			otherHTTPIngress.Annotations[annotations.ListenerPortKey] = "8080"
+			cb := newBuilder(httpsIngress, httpIngress, otherHTTPIngress)
+
+			Expect(cb.validateListenerPort(httpsIngress, env)).To(Equal(ErrListenerPortProtocolConflict))
+			Expect(cb.validateListenerPort(httpIngress, env)).To(Equal(ErrListenerPortProtocolConflict))
+			Expect(cb.validateListenerPort(otherHTTPIngress, env)).ToNot(HaveOccurred())
+
+			// Ingresses share a port for the listeners of the same protocol.
+			sameProtocol := plainIngress("same-protocol")
+			sameProtocol.Annotations[annotations.ListenerPortKey] = "8080"
+			cb = newBuilder(otherHTTPIngress, sameProtocol)
+			Expect(cb.validateListenerPort(sameProtocol, env)).ToNot(HaveOccurred())
+		})
+	})
+
+	Context("a host served on two custom ports", func() {
+		certs := newCertsFixture()
+		cb := newConfigBuilderFixture(&certs)
+
+		service := tests.NewServiceFixture(*tests.NewServicePortsFixture()...)
+		otherService := tests.NewServiceFixture(*tests.NewServicePortsFixture()...)
+		otherService.Name = "other"
+		otherEndpoints := tests.NewEndpointsFixture()
+		otherEndpoints.Name = "other"
+		_ = cb.k8sContext.Caches.Service.Add(service)
+		_ = cb.k8sContext.Caches.Service.Add(otherService)
+		_ = cb.k8sContext.Caches.Endpoints.Add(tests.NewEndpointsFixture())
+		_ = cb.k8sContext.Caches.Endpoints.Add(otherEndpoints)
+
+		ingress8443 := tests.NewIngressFixture()
+		ingress8443.Annotations[annotations.ListenerPortKey] = "8443"
+
+		ingress9443 := tests.NewIngressFixture()
+		ingress9443.Name = "other"
+		ingress9443.Annotations[annotations.ListenerPortKey] = "9443"
+		ingress9443.Spec.Rules = []v1beta1.IngressRule{
+			tests.NewIngressRuleFixture(tests.Host, tests.URLPath3, *tests.NewIngressBackendFixture("other", 80)),
+		}
+
+		cbCtx := &ConfigBuilderContext{
+			IngressList:           []*v1beta1.Ingress{ingress8443, ingress9443},
+			ServiceList:           []*v1.Service{service, otherService},
+			EnvVariables:          environment.GetFakeEnv(),
+			DefaultAddressPoolID:  to.StringPtr("xx"),
+			DefaultHTTPSettingsID: to.StringPtr("yy"),
+		}
+
+		_ = cb.BackendHTTPSettingsCollection(cbCtx)
+		_ = cb.BackendAddressPools(cbCtx)
+		_ = cb.Listeners(cbCtx)
+		_ = cb.RequestRoutingRules(cbCtx)
+
+		listenerID8443, _ := newTestListenerID(Port(8443), []string{tests.Host}, false)
+		listenerID9443, _ := newTestListenerID(Port(9443), []string{tests.Host}, false)
+		listenerID80, _ := newTestListenerID(Port(80), []string{tests.Host}, false)
+
+		It("creates the frontend ports", func() {
+			var ports []int32
+			for _, port := range *cb.appGw.FrontendPorts {
+				ports = append(ports, *port.Port)
+			}
+			Expect(ports).To(ConsistOf(int32(80), int32(8443), int32(9443)))
+		})
+
+		It("creates an HTTPS listener on each port and redirects HTTP to them", func() {
+			listeners := cb.groupListenersByListenerIdentifier(cbCtx)
+			Expect(listeners).To(HaveLen(3))
+			Expect(listeners[listenerID8443].Protocol).To(Equal(n.HTTPS))
+			Expect(listeners[listenerID9443].Protocol).To(Equal(n.HTTPS))
+			Expect(listeners[listenerID80].Protocol).To(Equal(n.HTTP))
+		})
+
+		It("routes each port to its own backends", func() {
+			pathMaps := cb.getPathMaps(cbCtx)
+
+			Expect(*pathMaps[listenerID8443].PathRules).To(HaveLen(2))
+			for _, rule := range *pathMaps[listenerID8443].PathRules {
+				Expect(*rule.BackendAddressPool.ID).To(ContainSubstring(tests.ServiceName))
+			}
+
+			Expect(*pathMaps[listenerID9443].PathRules).To(HaveLen(1))
+			rule := (*pathMaps[listenerID9443].PathRules)[0]
+			Expect(*rule.BackendAddressPool.ID).To(ContainSubstring("-other-80-"))
+		})
+
+		It("redirects HTTP to the HTTPS listener on the custom port", func() {
+			redirectName := generateSSLRedirectConfigurationName(listenerID8443)
+			var redirectNames []string
+			for _, redirect := range *cb.getRedirectConfigurations(cbCtx) {
+				redirectNames = append(redirectNames, *redirect.Name)
+			}
+			Expect(redirectNames).To(ContainElement(redirectName))
+		})
+	})
+})
+
+var _ = Describe("HTTP listener port", func() {
+	// Builds the listeners and routing rules of the ingress.
+	build := func(ingress *v1beta1.Ingress, env environment.EnvVariables) (*appGwConfigBuilder, *ConfigBuilderContext) {
+		certs := newCertsFixture()
+		cb := newConfigBuilderFixture(&certs)
+		service := tests.NewServiceFixture(*tests.NewServicePortsFixture()...)
+		_ = cb.k8sContext.Caches.Service.Add(service)
+		_ = cb.k8sContext.Caches.Endpoints.Add(tests.NewEndpointsFixture())
+
+		cbCtx := &ConfigBuilderContext{
+			IngressList:           []*v1beta1.Ingress{ingress},
+			ServiceList:           []*v1.Service{service},
+			EnvVariables:          env,
+			DefaultAddressPoolID:  to.StringPtr("xx"),
+			DefaultHTTPSettingsID: to.StringPtr("yy"),
+		}
+		Expect(cb.BackendHTTPSettingsCollection(cbCtx)).To(Succeed())
+		Expect(cb.BackendAddressPools(cbCtx)).To(Succeed())
+		Expect(cb.Listeners(cbCtx)).To(Succeed())
+		Expect(cb.RequestRoutingRules(cbCtx)).To(Succeed())
+		return &cb, cbCtx
+	}
+
+	frontendPorts := func(cb *appGwConfigBuilder) []int32 {
+		var ports []int32
+		for _, port := range *cb.appGw.FrontendPorts {
+			ports = append(ports, *port.Port)
+		}
+		return ports
+	}
+
+	plainIngress := func() *v1beta1.Ingress {
+		ingress := tests.NewIngressFixture()
+		ingress.Spec.TLS = nil
+		ingress.Annotations[annotations.SslRedirectKey] = "false"
+		return ingress
+	}
+
+	listenerID443, _ := newTestListenerID(Port(443), []string{tests.Host}, false)
+	listenerID8080, _ := newTestListenerID(Port(8080), []string{tests.Host}, false)
+
+	Context("validate the port", func() {
+		It("accepts ports App Gateway allows", func() {
+			cb := newConfigBuilderFixture(nil)
+			ingress := tests.NewIngressFixture()
+			ingress.Annotations[annotations.HTTPListenerPortKey] = "8080"
+			port, err := cb.getHTTPListenerPortAnnotation(ingress)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(*port).To(Equal(Port(8080)))
+		})
+
+		It("rejects ports out of range and ports App Gateway reserves", func() {
+			cb := newConfigBuilderFixture(nil)
+			for _, value := range []string{"0", "65536", "65300", "http"} {
+				ingress := tests.NewIngressFixture()
+				ingress.Annotations[annotations.HTTPListenerPortKey] = value
+				port, err := cb.getHTTPListenerPortAnnotation(ingress)
+				Expect(err).To(Equal(ErrInvalidHTTPListenerPort), value)
+				Expect(port).To(BeNil(), value)
+			}
+		})
+
+		It("rejects the port of the HTTPS listeners of other ingresses", func() {
+			httpsIngress := tests.NewIngressFixture()
+			httpsIngress.Name = "https"
+			httpsIngress.Annotations[annotations.ListenerPortKey] = "8443"
+			ingress := plainIngress()
+			ingress.Annotations[annotations.HTTPListenerPortKey] = "8443"
+			cb := newConfigBuilderFixture(nil)
+			cb.mem.listenerPortsInUse = cb.newListenerPortsInUse([]*v1beta1.Ingress{httpsIngress, ingress}, environment.GetFakeEnv())
+
+			_, err := cb.getHTTPListenerPortAnnotation(ingress)
+			Expect(err).To(Equal(ErrHTTPListenerPortConflict))
+			Expect(cb.getHTTPListenerPort(ingress, environment.GetFakeEnv(), false)).To(Equal(Port(80)))
+
+			// The default HTTPS port of an ingress with TLS, which does not set its own.
+			ingress.Annotations[annotations.HTTPListenerPortKey] = "443"
+			_, err = cb.getHTTPListenerPortAnnotation(ingress)
+			Expect(err).ToNot(HaveOccurred())
+			cb.mem.listenerPortsInUse = cb.newListenerPortsInUse([]*v1beta1.Ingress{tests.NewIngressFixture(), ingress}, environment.GetFakeEnv())
+			_, err = cb.getHTTPListenerPortAnnotation(ingress)
+			Expect(err).To(Equal(ErrHTTPListenerPortConflict))
+		})
+
+		It("rejects the port of the HTTPS listeners of the ingress", func() {
+			cb := newConfigBuilderFixture(nil)
+			ingress := tests.NewIngressFixture()
+			ingress.Annotations[annotations.ListenerPortKey] = "8443"
+			ingress.Annotations[annotations.HTTPListenerPortKey] = "8443"
+			_, err := cb.getHTTPListenerPortAnnotation(ingress)
+			Expect(err).To(Equal(ErrHTTPListenerPortConflict))
+			Expect(cb.getHTTPListenerPort(ingress, environment.GetFakeEnv(), true)).To(Equal(Port(80)))
+		})
+	})
+
+	It("creates the HTTP listener on the custom port and redirects it to HTTPS", func() {
+		ingress := tests.NewIngressFixture()
+		ingress.Annotations[annotations.HTTPListenerPortKey] = "8080"
+		cb, cbCtx := build(ingress, environment.GetFakeEnv())
+
+		Expect(frontendPorts(cb)).To(ConsistOf(int32(443), int32(8080)))
+		listeners := cb.groupListenersByListenerIdentifier(cbCtx)
+		Expect(listeners).To(HaveLen(2))
+		Expect(listeners[listenerID443].Protocol).To(Equal(n.HTTPS))
+		Expect(listeners[listenerID8080].Protocol).To(Equal(n.HTTP))
+
+		redirectID := *cb.getSslRedirectConfigResourceReference(listenerID443).ID
+		pathMap := cb.getPathMaps(cbCtx)[listenerID8080]
+		for _, rule := range *pathMap.PathRules {
+			Expect(*rule.RedirectConfiguration.ID).To(Equal(redirectID))
+		}
+	})
+
+	It("creates the HTTP listener on the custom port without ssl-redirect", func() {
+		ingress := plainIngress()
+		ingress.Annotations[annotations.HTTPListenerPortKey] = "8080"
+		cb, cbCtx := build(ingress, environment.GetFakeEnv())
+
+		Expect(frontendPorts(cb)).To(ConsistOf(int32(8080)))
+		Expect(*cb.getRedirectConfigurations(cbCtx)).To(BeEmpty())
+		pathMap := cb.getPathMaps(cbCtx)[listenerID8080]
+		Expect(*pathMap.PathRules).ToNot(BeEmpty())
+		for _, rule := range *pathMap.PathRules {
+			Expect(rule.RedirectConfiguration).To(BeNil())
+			Expect(*rule.BackendAddressPool.ID).To(ContainSubstring(tests.ServiceName))
+		}
+	})
+
+	It("creates the HTTP listeners on the port of APPGW_HTTP_LISTENER_PORT, unless the ingress sets its own", func() {
+		env := environment.GetFakeEnv()
+		env.HTTPListenerPort = "8080"
+		cb, cbCtx := build(plainIngress(), env)
+		Expect(frontendPorts(cb)).To(ConsistOf(int32(8080)))
+		Expect(cb.groupListenersByListenerIdentifier(cbCtx)).To(HaveKey(listenerID8080))
+
+		ingress := plainIngress()
+		ingress.Annotations[annotations.HTTPListenerPortKey] = "8081"
+		cb, _ = build(ingress, env)
+		Expect(frontendPorts(cb)).To(ConsistOf(int32(8081)))
+	})
+
+	It("creates the default listener on the port of APPGW_HTTP_LISTENER_PORT", func() {
+		env := environment.GetFakeEnv()
+		env.HTTPListenerPort = "8080"
+		cb := newConfigBuilderFixture(nil)
+		cbCtx := &ConfigBuilderContext{
+			EnvVariables:          env,
+			DefaultAddressPoolID:  to.StringPtr("xx"),
+			DefaultHTTPSettingsID: to.StringPtr("yy"),
+		}
+		Expect(cb.Listeners(cbCtx)).To(Succeed())
+		Expect(frontendPorts(&cb)).To(ConsistOf(int32(8080)))
+	})
+})
@@ -0,0 +1,24 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+)
+
+// getMaintenancePageErrors returns the custom error configurations of a listener serving the maintenance page.
+// App Gateway answers with a 502 when the backend pool of a request has no healthy member, or no member at all, and
+// serves the custom error page of that status code instead; it switches back once a member is healthy, without any
+// change to its config.
+func getMaintenancePageErrors(pageURL string) *[]n.ApplicationGatewayCustomError {
+	return &[]n.ApplicationGatewayCustomError{
+		{
+			StatusCode:         n.HTTPStatus502,
+			CustomErrorPageURL: to.StringPtr(pageURL),
+		},
+	}
+}
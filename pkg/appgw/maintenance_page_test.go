@@ -0,0 +1,139 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"time"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
+)
+
+var _ = Describe("maintenance page of the listeners", func() {
+	const pageURL = "https://contoso.blob.core.windows.net/pages/maintenance.html"
+
+	var cb appGwConfigBuilder
+	var cbCtx *ConfigBuilderContext
+	var service *v1.Service
+
+	newIngress := func(name, host, path string, created time.Time) *v1beta1.Ingress {
+		ingress := tests.NewIngressFixture()
+		ingress.Name = name
+		ingress.CreationTimestamp = metav1.NewTime(created)
+		ingress.Annotations[annotations.SslRedirectKey] = "false"
+		ingress.Spec.TLS = nil
+		backend := tests.NewIngressBackendFixture(tests.ServiceName, 80)
+		ingress.Spec.Rules = []v1beta1.IngressRule{tests.NewIngressRuleFixture(host, path, *backend)}
+		return ingress
+	}
+
+	// newBuilder returns a config builder for the service; without endpoints, its backend pool has no member.
+	newBuilder := func(withEndpoints bool) appGwConfigBuilder {
+		builder := newConfigBuilderFixture(nil)
+		_ = builder.k8sContext.Caches.Service.Add(service)
+		if withEndpoints {
+			_ = builder.k8sContext.Caches.Endpoints.Add(tests.NewEndpointsFixture())
+		}
+		return builder
+	}
+
+	BeforeEach(func() {
+		service = tests.NewServiceFixture(*tests.NewServicePortsFixture()...)
+		cb = newBuilder(true)
+		cbCtx = &ConfigBuilderContext{
+			ServiceList:           []*v1.Service{service},
+			EnvVariables:          environment.GetFakeEnv(),
+			DefaultAddressPoolID:  to.StringPtr("xx"),
+			DefaultHTTPSettingsID: to.StringPtr("yy"),
+		}
+	})
+
+	// customErrors builds the config of the ingresses and returns the custom errors of the listener of each host.
+	customErrors := func(ingresses ...*v1beta1.Ingress) map[string]*[]n.ApplicationGatewayCustomError {
+		cbCtx.IngressList = ingresses
+		Expect(cb.BackendHTTPSettingsCollection(cbCtx)).ToNot(HaveOccurred())
+		Expect(cb.BackendAddressPools(cbCtx)).ToNot(HaveOccurred())
+		Expect(cb.Listeners(cbCtx)).ToNot(HaveOccurred())
+		Expect(cb.RequestRoutingRules(cbCtx)).ToNot(HaveOccurred())
+
+		errorsByHost := make(map[string]*[]n.ApplicationGatewayCustomError)
+		for _, listener := range *cb.appGw.HTTPListeners {
+			host := ""
+			if listener.HostName != nil {
+				host = *listener.HostName
+			}
+			errorsByHost[host] = listener.CustomErrorConfigurations
+		}
+		return errorsByHost
+	}
+
+	It("serves the maintenance page instead of the 502 error on the listeners of the ingress", func() {
+		ingress := newIngress("web", "www.contoso.com", "/", time.Now())
+		ingress.Annotations[annotations.MaintenancePageURLKey] = pageURL
+		other := newIngress("api", "api.contoso.com", "/", time.Now())
+
+		errorsByHost := customErrors(ingress, other)
+		Expect(errorsByHost["www.contoso.com"]).To(Equal(&[]n.ApplicationGatewayCustomError{
+			{StatusCode: n.HTTPStatus502, CustomErrorPageURL: to.StringPtr(pageURL)},
+		}))
+		Expect(errorsByHost["api.contoso.com"]).To(BeNil())
+	})
+
+	It("keeps the same config when the backend pool has no healthy member, for App Gateway to switch to the page", func() {
+		// Without ready endpoints App Gateway answers with a 502, and serves the maintenance page instead.
+		ingress := newIngress("web", "www.contoso.com", "/", time.Now())
+		ingress.Annotations[annotations.MaintenancePageURLKey] = pageURL
+		healthy := customErrors(ingress)
+
+		cb = newBuilder(false)
+		unhealthy := customErrors(ingress)
+
+		Expect(unhealthy["www.contoso.com"]).ToNot(BeNil())
+		Expect(unhealthy["www.contoso.com"]).To(Equal(healthy["www.contoso.com"]))
+	})
+
+	It("serves the page of the ingress taking precedence on a listener shared by ingresses", func() {
+		older := newIngress("older", "www.contoso.com", "/", time.Now().Add(-time.Hour))
+		newer := newIngress("newer", "www.contoso.com", "/api", time.Now())
+		newer.Annotations[annotations.MaintenancePageURLKey] = "https://status.contoso.com/api.html"
+		noPage := newIngress("no-page", "www.contoso.com", "/login", time.Now().Add(time.Hour))
+
+		// The older ingress without a page lets the newer one define it.
+		errorsByHost := customErrors(older, newer, noPage)
+		Expect(*(*errorsByHost["www.contoso.com"])[0].CustomErrorPageURL).To(Equal("https://status.contoso.com/api.html"))
+
+		cb = newBuilder(true)
+		older.Annotations[annotations.MaintenancePageURLKey] = pageURL
+		errorsByHost = customErrors(newer, older, noPage)
+		Expect(*(*errorsByHost["www.contoso.com"])[0].CustomErrorPageURL).To(Equal(pageURL))
+
+		recorder := cb.recorder.(*record.FakeRecorder)
+		Expect(recorder.Events).To(HaveLen(1))
+		event := <-recorder.Events
+		Expect(event).To(HavePrefix("Warning " + string(events.ReasonConflictingIngress)))
+		Expect(event).To(ContainSubstring("newer"))
+	})
+
+	It("ignores an invalid maintenance page and emits an event", func() {
+		ingress := newIngress("web", "www.contoso.com", "/", time.Now())
+		ingress.Annotations[annotations.MaintenancePageURLKey] = "https://status.contoso.com/maintenance.png"
+
+		Expect(customErrors(ingress)["www.contoso.com"]).To(BeNil())
+		recorder := cb.recorder.(*record.FakeRecorder)
+		Expect(<-recorder.Events).To(HavePrefix("Warning " + string(events.ReasonInvalidAnnotation)))
+	})
+})
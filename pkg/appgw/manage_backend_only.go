@@ -0,0 +1,188 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"sort"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/golang/glog"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/brownfield"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/sorter"
+)
+
+// preserveBackendOnlyIngresses replaces the generated objects of ingresses annotated with manage-backend-only
+// with the objects of the same name found on the existing App Gateway, so that out-of-band changes survive.
+// Managed: backend address pools (and their addresses), frontend ports.
+// Preserved: listeners, request routing rules, URL path maps, redirect configurations, backend HTTP settings and
+// custom health probes. Objects not yet on the gateway are created as generated. Objects shared with other
+// ingresses (a listener for the same host and port) are preserved as well.
+func (c *appGwConfigBuilder) preserveBackendOnlyIngresses(cbCtx *ConfigBuilderContext, existing brownfield.ExistingResources) {
+	preserved := make(map[string]interface{})
+	for _, ingress := range cbCtx.IngressList {
+		if backendOnly, _ := annotations.IsManageBackendOnly(ingress); !backendOnly {
+			continue
+		}
+		for listenerID, config := range c.getListenersFromIngress(ingress, cbCtx.EnvVariables) {
+			preserved[generateListenerName(listenerID)] = nil
+			preserved[generateRequestRoutingRuleName(listenerID)] = nil
+			preserved[generateURLPathMapName(listenerID)] = nil
+			if config.SslRedirectConfigurationName != "" {
+				preserved[config.SslRedirectConfigurationName] = nil
+			}
+		}
+		if c.mem.settingsByBackend != nil {
+			for backendID, setting := range *c.mem.settingsByBackend {
+				if backendID.Ingress == ingress && setting != nil {
+					preserved[*setting.Name] = nil
+				}
+			}
+		}
+		if c.mem.probesByBackend != nil {
+			for backendID, probe := range *c.mem.probesByBackend {
+				// Default probes are shared by all ingresses and remain managed.
+				if backendID.Ingress == ingress && probe != nil && !isDefaultProbeName(*probe.Name) {
+					preserved[*probe.Name] = nil
+				}
+			}
+		}
+	}
+
+	if len(preserved) == 0 {
+		return
+	}
+
+	if c.appGw.HTTPListeners != nil {
+		existingListeners := make(map[string]n.ApplicationGatewayHTTPListener)
+		for _, listener := range existing.Listeners {
+			existingListeners[*listener.Name] = listener
+		}
+		listeners := *c.appGw.HTTPListeners
+		for idx, listener := range listeners {
+			if found, ok := existingListeners[*listener.Name]; ok {
+				if _, isPreserved := preserved[*listener.Name]; isPreserved {
+					listeners[idx] = found
+				}
+			}
+		}
+		c.retainCertificatesOf(listeners, existing)
+	}
+
+	if c.appGw.RequestRoutingRules != nil {
+		existingRules := make(map[string]n.ApplicationGatewayRequestRoutingRule)
+		for _, rule := range existing.RoutingRules {
+			existingRules[*rule.Name] = rule
+		}
+		rules := *c.appGw.RequestRoutingRules
+		for idx, rule := range rules {
+			if found, ok := existingRules[*rule.Name]; ok {
+				if _, isPreserved := preserved[*rule.Name]; isPreserved {
+					rules[idx] = found
+				}
+			}
+		}
+	}
+
+	if c.appGw.URLPathMaps != nil {
+		existingPathMaps := make(map[string]n.ApplicationGatewayURLPathMap)
+		for _, pathMap := range existing.URLPathMaps {
+			existingPathMaps[*pathMap.Name] = pathMap
+		}
+		pathMaps := *c.appGw.URLPathMaps
+		for idx, pathMap := range pathMaps {
+			if found, ok := existingPathMaps[*pathMap.Name]; ok {
+				if _, isPreserved := preserved[*pathMap.Name]; isPreserved {
+					pathMaps[idx] = found
+				}
+			}
+		}
+	}
+
+	if c.appGw.RedirectConfigurations != nil {
+		existingRedirects := make(map[string]n.ApplicationGatewayRedirectConfiguration)
+		for _, redirect := range existing.Redirects {
+			existingRedirects[*redirect.Name] = redirect
+		}
+		redirects := *c.appGw.RedirectConfigurations
+		for idx, redirect := range redirects {
+			if found, ok := existingRedirects[*redirect.Name]; ok {
+				if _, isPreserved := preserved[*redirect.Name]; isPreserved {
+					redirects[idx] = found
+				}
+			}
+		}
+	}
+
+	if c.appGw.BackendHTTPSettingsCollection != nil {
+		existingSettings := make(map[string]n.ApplicationGatewayBackendHTTPSettings)
+		for _, setting := range existing.HTTPSettings {
+			existingSettings[*setting.Name] = setting
+		}
+		settings := *c.appGw.BackendHTTPSettingsCollection
+		for idx, setting := range settings {
+			if found, ok := existingSettings[*setting.Name]; ok {
+				if _, isPreserved := preserved[*setting.Name]; isPreserved {
+					settings[idx] = found
+				}
+			}
+		}
+	}
+
+	if c.appGw.Probes != nil {
+		existingProbes := make(map[string]n.ApplicationGatewayProbe)
+		for _, probe := range existing.Probes {
+			existingProbes[*probe.Name] = probe
+		}
+		probes := *c.appGw.Probes
+		for idx, probe := range probes {
+			if found, ok := existingProbes[*probe.Name]; ok {
+				if _, isPreserved := preserved[*probe.Name]; isPreserved {
+					probes[idx] = found
+				}
+			}
+		}
+	}
+
+	glog.V(5).Infof("Preserved %d existing objects of ingresses annotated with %s", len(preserved), annotations.ManageBackendOnlyKey)
+}
+
+// retainCertificatesOf adds the existing certificates referenced by the given listeners, which are missing from the
+// generated config. A listener preserved from the gateway may refer to a certificate installed out-of-band.
+func (c *appGwConfigBuilder) retainCertificatesOf(listeners []n.ApplicationGatewayHTTPListener, existing brownfield.ExistingResources) {
+	var certs []n.ApplicationGatewaySslCertificate
+	if c.appGw.SslCertificates != nil {
+		certs = *c.appGw.SslCertificates
+	}
+	certIDs := make(map[string]interface{})
+	for _, cert := range certs {
+		if cert.ID != nil {
+			certIDs[*cert.ID] = nil
+		}
+	}
+	for _, listener := range listeners {
+		if listener.SslCertificate == nil || listener.SslCertificate.ID == nil {
+			continue
+		}
+		if _, exists := certIDs[*listener.SslCertificate.ID]; exists {
+			continue
+		}
+		for _, cert := range existing.Certificates {
+			if cert.ID != nil && *cert.ID == *listener.SslCertificate.ID {
+				certs = append(certs, cert)
+				certIDs[*cert.ID] = nil
+				break
+			}
+		}
+	}
+	sort.Sort(sorter.ByCertificateName(certs))
+	c.appGw.SslCertificates = &certs
+}
+
+func isDefaultProbeName(name string) bool {
+	return name == defaultProbeName(n.HTTP) || name == defaultProbeName(n.HTTPS)
+}
@@ -0,0 +1,134 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/brownfield"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
+)
+
+var _ = Describe("Test manage-backend-only annotation", func() {
+	// Builds the config for the fixture ingress, with the given pod IP, on top of the given App Gateway.
+	build := func(appGw n.ApplicationGatewayPropertiesFormat, backendOnly bool, podIP string) n.ApplicationGatewayPropertiesFormat {
+		cb := newConfigBuilderFixture(nil)
+		cb.appGw.ApplicationGatewayPropertiesFormat = &appGw
+
+		endpoint := tests.NewEndpointsFixture()
+		endpoint.Subsets[0].Addresses[0].IP = podIP
+		service := tests.NewServiceFixture(*tests.NewServicePortsFixture()...)
+		ingress := tests.NewIngressFixture()
+		ingress.Annotations[annotations.SslRedirectKey] = "false"
+		ingress.Annotations[annotations.ManageBackendOnlyKey] = "false"
+		if backendOnly {
+			ingress.Annotations[annotations.ManageBackendOnlyKey] = "true"
+		}
+		ingress.Spec.TLS = nil
+		ingress.Spec.Rules = ingress.Spec.Rules[:1]
+		_ = cb.k8sContext.Caches.Endpoints.Add(endpoint)
+		_ = cb.k8sContext.Caches.Service.Add(service)
+		_ = cb.k8sContext.Caches.Ingress.Add(ingress)
+
+		cbCtx := &ConfigBuilderContext{
+			IngressList:           []*v1beta1.Ingress{ingress},
+			ServiceList:           []*v1.Service{service},
+			EnvVariables:          environment.GetFakeEnv(),
+			DefaultAddressPoolID:  to.StringPtr(cb.appGwIdentifier.AddressPoolID(DefaultBackendAddressPoolName)),
+			DefaultHTTPSettingsID: to.StringPtr(cb.appGwIdentifier.HTTPSettingsID(DefaultBackendHTTPSettingsName)),
+			ExistingPortsByNumber: make(map[Port]n.ApplicationGatewayFrontendPort),
+		}
+
+		// Same steps as Build(), which also tags the gateway and requires a Kubernetes client.
+		existing := brownfield.NewExistingResources(cb.appGw, nil, nil)
+		Expect(cb.HealthProbesCollection(cbCtx)).ToNot(HaveOccurred())
+		Expect(cb.BackendHTTPSettingsCollection(cbCtx)).ToNot(HaveOccurred())
+		Expect(cb.BackendAddressPools(cbCtx)).ToNot(HaveOccurred())
+		Expect(cb.Listeners(cbCtx)).ToNot(HaveOccurred())
+		Expect(cb.RequestRoutingRules(cbCtx)).ToNot(HaveOccurred())
+		cb.preserveBackendOnlyIngresses(cbCtx, existing)
+		return *cb.appGw.ApplicationGatewayPropertiesFormat
+	}
+
+	// Simulates changes made to the gateway out-of-band, in the portal for instance.
+	modify := func(appGw n.ApplicationGatewayPropertiesFormat) {
+		for idx := range *appGw.HTTPListeners {
+			(*appGw.HTTPListeners)[idx].CustomErrorConfigurations = &[]n.ApplicationGatewayCustomError{
+				{
+					StatusCode:         n.HTTPStatus502,
+					CustomErrorPageURL: to.StringPtr("https://contoso.com/502.html"),
+				},
+			}
+		}
+		for idx, setting := range *appGw.BackendHTTPSettingsCollection {
+			if *setting.Name != DefaultBackendHTTPSettingsName {
+				(*appGw.BackendHTTPSettingsCollection)[idx].RequestTimeout = to.Int32Ptr(99)
+			}
+		}
+	}
+
+	poolAddresses := func(appGw n.ApplicationGatewayPropertiesFormat) []string {
+		var addresses []string
+		for _, pool := range *appGw.BackendAddressPools {
+			if *pool.Name == DefaultBackendAddressPoolName || pool.BackendAddresses == nil {
+				continue
+			}
+			for _, address := range *pool.BackendAddresses {
+				addresses = append(addresses, *address.IPAddress)
+			}
+		}
+		return addresses
+	}
+
+	var appGw n.ApplicationGatewayPropertiesFormat
+
+	BeforeEach(func() {
+		appGw = build(*newConfigBuilderFixture(nil).appGw.ApplicationGatewayPropertiesFormat, true, "10.9.8.7")
+		modify(appGw)
+	})
+
+	Context("ingress annotated with manage-backend-only", func() {
+		It("preserves listeners and HTTP settings while updating pool membership", func() {
+			updated := build(appGw, true, "10.1.2.3")
+
+			Expect(*updated.HTTPListeners).ToNot(BeEmpty())
+			for _, listener := range *updated.HTTPListeners {
+				Expect(listener.CustomErrorConfigurations).ToNot(BeNil())
+				Expect(*(*listener.CustomErrorConfigurations)[0].CustomErrorPageURL).To(Equal("https://contoso.com/502.html"))
+			}
+			for _, setting := range *updated.BackendHTTPSettingsCollection {
+				if *setting.Name != DefaultBackendHTTPSettingsName {
+					Expect(*setting.RequestTimeout).To(Equal(int32(99)))
+				}
+			}
+			Expect(*updated.RequestRoutingRules).To(Equal(*appGw.RequestRoutingRules))
+
+			Expect(poolAddresses(appGw)).To(ConsistOf("10.9.8.7"))
+			Expect(poolAddresses(updated)).To(ConsistOf("10.1.2.3"))
+		})
+	})
+
+	Context("ingress not annotated with manage-backend-only", func() {
+		It("overwrites out-of-band changes", func() {
+			updated := build(appGw, false, "10.1.2.3")
+
+			for _, listener := range *updated.HTTPListeners {
+				Expect(listener.CustomErrorConfigurations).To(BeNil())
+			}
+			for _, setting := range *updated.BackendHTTPSettingsCollection {
+				Expect(setting.RequestTimeout).ToNot(Equal(to.Int32Ptr(99)))
+			}
+			Expect(poolAddresses(updated)).To(ConsistOf("10.1.2.3"))
+		})
+	})
+})
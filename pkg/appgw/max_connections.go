@@ -0,0 +1,43 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"k8s.io/api/extensions/v1beta1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+)
+
+const (
+	minMaxConnections int32 = 1
+	maxMaxConnections int32 = 65535
+)
+
+// getMaxConnections returns the connection limit requested by the ingress, or nil when the ingress does not set one.
+func getMaxConnections(ingress *v1beta1.Ingress) (*int32, error) {
+	limit, err := annotations.MaxConnections(ingress)
+	if annotations.IsMissingAnnotations(err) {
+		return nil, nil
+	}
+	if err != nil || limit < minMaxConnections || limit > maxMaxConnections {
+		return nil, ErrInvalidMaxConnections
+	}
+	return &limit, nil
+}
+
+// validateMaxConnections ensures the connection limit requested by the ingress can be honored.
+// The HTTP settings of the API version AGIC is built with have no connection limit; until AGIC moves
+// to an API version which does, a valid limit is reported as an error and connections are not limited.
+func validateMaxConnections(ingress *v1beta1.Ingress) error {
+	limit, err := getMaxConnections(ingress)
+	if err != nil {
+		return err
+	}
+	if limit == nil {
+		return nil
+	}
+	return ErrMaxConnectionsNotSupported
+}
@@ -0,0 +1,228 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"sort"
+	"strings"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/golang/glog"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/brownfield"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/sorter"
+)
+
+// isOwnedResource determines whether the App Gateway sub-resource with the given name was created by this AGIC instance.
+// App Gateway sub-resources can not be tagged, so the ownership is encoded in the names AGIC generates:
+// the APPGW_CONFIG_NAME_PREFIX, followed by the prefix of the resource type, acts as the ID of the AGIC instance.
+func isOwnedResource(name *string) bool {
+	if name == nil {
+		return false
+	}
+	for _, prefix := range ownedNamePrefixes() {
+		if strings.HasPrefix(*name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func ownedNamePrefixes() []string {
+	prefixes := []string{
+		agPrefix + "defaulthttpsetting",
+		agPrefix + "defaultaddresspool",
+		agPrefix + "defaultprobe-",
+	}
+	for _, resourcePrefix := range []string{prefixHTTPSettings, prefixProbe, prefixPool, prefixPort, prefixListener, prefixPathMap, prefixRoutingRule, prefixRedirect, prefixRewrite} {
+		prefixes = append(prefixes, agPrefix+resourcePrefix+"-")
+	}
+	return prefixes
+}
+
+// retainUnownedResources adds the objects of the existing App Gateway config, which this AGIC instance does not own,
+// to the generated config. This allows multiple AGIC instances to share an App Gateway without pruning each other's objects.
+// Frontend ports and certificates are shared; these are retained when referenced by a listener.
+func (c *appGwConfigBuilder) retainUnownedResources(existing brownfield.ExistingResources) {
+	var names []string
+
+	var listeners []n.ApplicationGatewayHTTPListener
+	if c.appGw.HTTPListeners != nil {
+		listeners = *c.appGw.HTTPListeners
+	}
+	listenerNames := make(map[string]interface{})
+	for _, listener := range listeners {
+		listenerNames[*listener.Name] = nil
+	}
+	var unownedListeners []n.ApplicationGatewayHTTPListener
+	for _, listener := range existing.Listeners {
+		if _, exists := listenerNames[*listener.Name]; !exists && !isOwnedResource(listener.Name) {
+			unownedListeners = append(unownedListeners, listener)
+			names = append(names, *listener.Name)
+		}
+	}
+	listeners = append(listeners, unownedListeners...)
+	sort.Sort(sorter.ByListenerName(listeners))
+	c.appGw.HTTPListeners = &listeners
+
+	var rules []n.ApplicationGatewayRequestRoutingRule
+	if c.appGw.RequestRoutingRules != nil {
+		rules = *c.appGw.RequestRoutingRules
+	}
+	ruleNames := make(map[string]interface{})
+	for _, rule := range rules {
+		ruleNames[*rule.Name] = nil
+	}
+	for _, rule := range existing.RoutingRules {
+		if _, exists := ruleNames[*rule.Name]; !exists && !isOwnedResource(rule.Name) {
+			rules = append(rules, rule)
+			names = append(names, *rule.Name)
+		}
+	}
+	sort.Sort(sorter.ByRequestRoutingRuleName(rules))
+	c.sortBasicListenerRulesLast(rules)
+	c.appGw.RequestRoutingRules = &rules
+
+	var pathMaps []n.ApplicationGatewayURLPathMap
+	if c.appGw.URLPathMaps != nil {
+		pathMaps = *c.appGw.URLPathMaps
+	}
+	pathMapNames := make(map[string]interface{})
+	for _, pathMap := range pathMaps {
+		pathMapNames[*pathMap.Name] = nil
+	}
+	for _, pathMap := range existing.URLPathMaps {
+		if _, exists := pathMapNames[*pathMap.Name]; !exists && !isOwnedResource(pathMap.Name) {
+			pathMaps = append(pathMaps, pathMap)
+			names = append(names, *pathMap.Name)
+		}
+	}
+	sort.Sort(sorter.ByPathMap(pathMaps))
+	c.appGw.URLPathMaps = &pathMaps
+
+	var redirects []n.ApplicationGatewayRedirectConfiguration
+	if c.appGw.RedirectConfigurations != nil {
+		redirects = *c.appGw.RedirectConfigurations
+	}
+	redirectNames := make(map[string]interface{})
+	for _, redirect := range redirects {
+		redirectNames[*redirect.Name] = nil
+	}
+	for _, redirect := range existing.Redirects {
+		if _, exists := redirectNames[*redirect.Name]; !exists && !isOwnedResource(redirect.Name) {
+			redirects = append(redirects, redirect)
+			names = append(names, *redirect.Name)
+		}
+	}
+	sort.Sort(sorter.ByRedirectName(redirects))
+	c.appGw.RedirectConfigurations = &redirects
+
+	var pools []n.ApplicationGatewayBackendAddressPool
+	if c.appGw.BackendAddressPools != nil {
+		pools = *c.appGw.BackendAddressPools
+	}
+	poolNames := make(map[string]interface{})
+	for _, pool := range pools {
+		poolNames[*pool.Name] = nil
+	}
+	for _, pool := range existing.BackendPools {
+		if _, exists := poolNames[*pool.Name]; !exists && !isOwnedResource(pool.Name) {
+			pools = append(pools, pool)
+			names = append(names, *pool.Name)
+		}
+	}
+	sort.Sort(sorter.ByBackendPoolName(pools))
+	c.appGw.BackendAddressPools = &pools
+
+	var settings []n.ApplicationGatewayBackendHTTPSettings
+	if c.appGw.BackendHTTPSettingsCollection != nil {
+		settings = *c.appGw.BackendHTTPSettingsCollection
+	}
+	settingsNames := make(map[string]interface{})
+	for _, setting := range settings {
+		settingsNames[*setting.Name] = nil
+	}
+	for _, setting := range existing.HTTPSettings {
+		if _, exists := settingsNames[*setting.Name]; !exists && !isOwnedResource(setting.Name) {
+			settings = append(settings, setting)
+			names = append(names, *setting.Name)
+		}
+	}
+	sort.Sort(sorter.BySettingsName(settings))
+	c.appGw.BackendHTTPSettingsCollection = &settings
+
+	var probes []n.ApplicationGatewayProbe
+	if c.appGw.Probes != nil {
+		probes = *c.appGw.Probes
+	}
+	probeNames := make(map[string]interface{})
+	for _, probe := range probes {
+		probeNames[*probe.Name] = nil
+	}
+	for _, probe := range existing.Probes {
+		if _, exists := probeNames[*probe.Name]; !exists && !isOwnedResource(probe.Name) {
+			probes = append(probes, probe)
+			names = append(names, *probe.Name)
+		}
+	}
+	sort.Sort(sorter.ByHealthProbeName(probes))
+	c.appGw.Probes = &probes
+
+	// Frontend ports are shared by the listeners of all instances; keep every port a listener refers to.
+	portIDs := make(map[string]interface{})
+	for _, listener := range listeners {
+		if listener.FrontendPort != nil && listener.FrontendPort.ID != nil {
+			portIDs[*listener.FrontendPort.ID] = nil
+		}
+	}
+	var ports []n.ApplicationGatewayFrontendPort
+	if c.appGw.FrontendPorts != nil {
+		ports = *c.appGw.FrontendPorts
+	}
+	for _, port := range ports {
+		delete(portIDs, *port.ID)
+	}
+	for _, port := range existing.Ports {
+		if _, exists := portIDs[*port.ID]; exists {
+			ports = append(ports, port)
+			delete(portIDs, *port.ID)
+		}
+	}
+	sort.Sort(sorter.ByFrontendPortName(ports))
+	c.appGw.FrontendPorts = &ports
+
+	// Certificates are named after the secrets they were created from; keep the ones the retained listeners refer to.
+	certIDs := make(map[string]interface{})
+	for _, listener := range unownedListeners {
+		if listener.SslCertificate != nil && listener.SslCertificate.ID != nil {
+			certIDs[*listener.SslCertificate.ID] = nil
+		}
+	}
+	var certs []n.ApplicationGatewaySslCertificate
+	if c.appGw.SslCertificates != nil {
+		certs = *c.appGw.SslCertificates
+	}
+	for _, cert := range certs {
+		if cert.ID != nil {
+			delete(certIDs, *cert.ID)
+		}
+	}
+	for _, cert := range existing.Certificates {
+		if cert.ID == nil {
+			continue
+		}
+		if _, exists := certIDs[*cert.ID]; exists {
+			certs = append(certs, cert)
+			delete(certIDs, *cert.ID)
+		}
+	}
+	sort.Sort(sorter.ByCertificateName(certs))
+	c.appGw.SslCertificates = &certs
+
+	if len(names) > 0 {
+		glog.V(3).Infof("[multi-instance] Retained objects not owned by this AGIC instance: %s", strings.Join(names, ", "))
+	}
+}
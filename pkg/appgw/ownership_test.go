@@ -0,0 +1,146 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"strings"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/brownfield"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
+)
+
+var _ = Describe("Test ownership of App Gateway objects by multiple AGIC instances", func() {
+	// Simulates the AGIC instance with the given name prefix applying its config on top of the given App Gateway.
+	build := func(prefix string, host string, appGw n.ApplicationGatewayPropertiesFormat, multiInstance bool) n.ApplicationGatewayPropertiesFormat {
+		originalPrefix := agPrefix
+		agPrefix = prefix
+		defer func() { agPrefix = originalPrefix }()
+
+		cb := newConfigBuilderFixture(nil)
+		cb.appGw.ApplicationGatewayPropertiesFormat = &appGw
+
+		endpoint := tests.NewEndpointsFixture()
+		service := tests.NewServiceFixture(*tests.NewServicePortsFixture()...)
+		ingress := tests.NewIngressFixture()
+		ingress.Annotations[annotations.SslRedirectKey] = "false"
+		ingress.Spec.TLS = nil
+		ingress.Spec.Rules = ingress.Spec.Rules[:1]
+		ingress.Spec.Rules[0].Host = host
+		_ = cb.k8sContext.Caches.Endpoints.Add(endpoint)
+		_ = cb.k8sContext.Caches.Service.Add(service)
+		_ = cb.k8sContext.Caches.Ingress.Add(ingress)
+
+		env := environment.GetFakeEnv()
+		cbCtx := &ConfigBuilderContext{
+			IngressList:           []*v1beta1.Ingress{ingress},
+			ServiceList:           []*v1.Service{service},
+			EnvVariables:          env,
+			DefaultAddressPoolID:  to.StringPtr(cb.appGwIdentifier.AddressPoolID(DefaultBackendAddressPoolName)),
+			DefaultHTTPSettingsID: to.StringPtr(cb.appGwIdentifier.HTTPSettingsID(DefaultBackendHTTPSettingsName)),
+			ExistingPortsByNumber: make(map[Port]n.ApplicationGatewayFrontendPort),
+		}
+		if appGw.FrontendPorts != nil {
+			for _, port := range *appGw.FrontendPorts {
+				cbCtx.ExistingPortsByNumber[Port(*port.Port)] = port
+			}
+		}
+
+		// Same steps as Build(), which also tags the gateway and requires a Kubernetes client.
+		existing := brownfield.NewExistingResources(cb.appGw, nil, nil)
+		Expect(cb.HealthProbesCollection(cbCtx)).ToNot(HaveOccurred())
+		Expect(cb.BackendHTTPSettingsCollection(cbCtx)).ToNot(HaveOccurred())
+		Expect(cb.BackendAddressPools(cbCtx)).ToNot(HaveOccurred())
+		Expect(cb.Listeners(cbCtx)).ToNot(HaveOccurred())
+		Expect(cb.RequestRoutingRules(cbCtx)).ToNot(HaveOccurred())
+		if multiInstance {
+			cb.retainUnownedResources(existing)
+		}
+		return *cb.appGw.ApplicationGatewayPropertiesFormat
+	}
+
+	hostsOf := func(appGw n.ApplicationGatewayPropertiesFormat) []string {
+		var hosts []string
+		for _, listener := range *appGw.HTTPListeners {
+			if listener.HostName != nil {
+				hosts = append(hosts, *listener.HostName)
+			}
+		}
+		return hosts
+	}
+
+	Context("test isOwnedResource", func() {
+		It("should own only the objects named with its prefix", func() {
+			originalPrefix := agPrefix
+			agPrefix = "a-"
+			defer func() { agPrefix = originalPrefix }()
+
+			Expect(isOwnedResource(to.StringPtr("a-fl-1234"))).To(BeTrue())
+			Expect(isOwnedResource(to.StringPtr("a-pool-svc-80-bp-8080"))).To(BeTrue())
+			Expect(isOwnedResource(to.StringPtr("a-defaultaddresspool"))).To(BeTrue())
+			Expect(isOwnedResource(to.StringPtr("b-fl-1234"))).To(BeFalse())
+			Expect(isOwnedResource(to.StringPtr("fl-1234"))).To(BeFalse())
+			Expect(isOwnedResource(to.StringPtr("hand-made-listener"))).To(BeFalse())
+			Expect(isOwnedResource(nil)).To(BeFalse())
+		})
+	})
+
+	Context("two instances share an App Gateway", func() {
+		var appGwA, appGwB, appGwA2 n.ApplicationGatewayPropertiesFormat
+
+		BeforeEach(func() {
+			appGwA = build("", "a.contoso.com", *NewAppGwyConfigFixture(), true)
+			appGwB = build("b-", "b.contoso.com", appGwA, true)
+			appGwA2 = build("", "a.contoso.com", appGwB, true)
+		})
+
+		It("should retain the listeners and rules of the other instance", func() {
+			Expect(hostsOf(appGwB)).To(ConsistOf("a.contoso.com", "b.contoso.com"))
+			Expect(len(*appGwB.RequestRoutingRules)).To(Equal(2))
+			Expect(len(*appGwB.BackendAddressPools)).To(Equal(len(*appGwA.BackendAddressPools) + 1))
+		})
+
+		It("should not be undone by the next update of the first instance", func() {
+			Expect(hostsOf(appGwA2)).To(ConsistOf("a.contoso.com", "b.contoso.com"))
+			Expect(len(*appGwA2.RequestRoutingRules)).To(Equal(2))
+			var bListeners []string
+			for _, listener := range *appGwA2.HTTPListeners {
+				if strings.HasPrefix(*listener.Name, "b-") {
+					bListeners = append(bListeners, *listener.Name)
+				}
+			}
+			Expect(len(bListeners)).To(Equal(1))
+		})
+
+		It("should share the frontend port", func() {
+			Expect(len(*appGwA2.FrontendPorts)).To(Equal(1))
+			for _, listener := range *appGwA2.HTTPListeners {
+				Expect(*listener.FrontendPort.ID).To(Equal(*(*appGwA2.FrontendPorts)[0].ID))
+			}
+		})
+	})
+
+	Context("an instance without multi-instance enabled", func() {
+		var appGwB n.ApplicationGatewayPropertiesFormat
+
+		BeforeEach(func() {
+			appGwA := build("", "a.contoso.com", *NewAppGwyConfigFixture(), true)
+			appGwB = build("b-", "b.contoso.com", appGwA, false)
+		})
+
+		It("should prune the objects of the other instance", func() {
+			Expect(hostsOf(appGwB)).To(ConsistOf("b.contoso.com"))
+		})
+	})
+})
@@ -0,0 +1,92 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"encoding/json"
+	"sort"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/golang/glog"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/sorter"
+)
+
+// shareURLPathMaps replaces the URL path maps AGIC generated with identical paths, backends, redirects and rewrites
+// for several listeners with a single path map referenced by the routing rules of all of them. The shared path map
+// keeps the lowest name of the group, so that the name does not change between builds as long as that listener exists.
+// Path maps AGIC did not generate (brownfield, manage-backend-only ingresses) are left as they are.
+func (c *appGwConfigBuilder) shareURLPathMaps(cbCtx *ConfigBuilderContext) {
+	if c.appGw.URLPathMaps == nil || c.appGw.RequestRoutingRules == nil || c.mem.pathMaps == nil {
+		return
+	}
+
+	candidates := make(map[string]interface{})
+	for _, pathMap := range *c.mem.pathMaps {
+		candidates[*pathMap.Name] = nil
+	}
+	for _, ingress := range cbCtx.IngressList {
+		if backendOnly, _ := annotations.IsManageBackendOnly(ingress); !backendOnly {
+			continue
+		}
+		for listenerID := range c.getListenersFromIngress(ingress, cbCtx.EnvVariables) {
+			delete(candidates, generateURLPathMapName(listenerID))
+		}
+	}
+
+	// Group the candidates by their content; with the path maps sorted by name, the first of a group is the lowest.
+	sorted := append([]n.ApplicationGatewayURLPathMap{}, *c.appGw.URLPathMaps...)
+	sort.Sort(sorter.ByPathMap(sorted))
+	sharedNameByContent := make(map[string]string)
+	sharedIDOf := make(map[string]string)
+	var pathMaps []n.ApplicationGatewayURLPathMap
+	for _, pathMap := range sorted {
+		if _, isCandidate := candidates[*pathMap.Name]; !isCandidate {
+			pathMaps = append(pathMaps, pathMap)
+			continue
+		}
+		content, err := pathMapContent(pathMap)
+		if err != nil {
+			glog.Errorf("Unable to compare url path map %s, it will not be shared: %s", *pathMap.Name, err)
+			pathMaps = append(pathMaps, pathMap)
+			continue
+		}
+		if sharedName, exists := sharedNameByContent[content]; exists {
+			glog.V(5).Infof("Url path map %s is identical to %s and will be replaced by it", *pathMap.Name, sharedName)
+			sharedIDOf[c.appGwIdentifier.urlPathMapID(*pathMap.Name)] = c.appGwIdentifier.urlPathMapID(sharedName)
+			continue
+		}
+		sharedNameByContent[content] = *pathMap.Name
+		pathMaps = append(pathMaps, pathMap)
+	}
+
+	if len(sharedIDOf) == 0 {
+		return
+	}
+
+	for idx := range *c.appGw.RequestRoutingRules {
+		rule := &(*c.appGw.RequestRoutingRules)[idx]
+		if rule.ApplicationGatewayRequestRoutingRulePropertiesFormat == nil || rule.URLPathMap == nil || rule.URLPathMap.ID == nil {
+			continue
+		}
+		if sharedID, exists := sharedIDOf[*rule.URLPathMap.ID]; exists {
+			rule.URLPathMap = &n.SubResource{ID: to.StringPtr(sharedID)}
+		}
+	}
+
+	c.appGw.URLPathMaps = &pathMaps
+}
+
+// pathMapContent serializes what the routing of a path map depends on, leaving out its name, ID and etag.
+func pathMapContent(pathMap n.ApplicationGatewayURLPathMap) (string, error) {
+	pathMap.Name = nil
+	pathMap.ID = nil
+	pathMap.Etag = nil
+	content, err := json.Marshal(pathMap)
+	return string(content), err
+}
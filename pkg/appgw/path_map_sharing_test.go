@@ -0,0 +1,109 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"sort"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
+)
+
+var _ = Describe("URL path map sharing", func() {
+	var cb appGwConfigBuilder
+	var cbCtx *ConfigBuilderContext
+	var ingress *v1beta1.Ingress
+	hosts := []string{"a.contoso.com", "b.contoso.com", "c.contoso.com"}
+
+	BeforeEach(func() {
+		cb = newConfigBuilderFixture(nil)
+		service := tests.NewServiceFixture(*tests.NewServicePortsFixture()...)
+		_ = cb.k8sContext.Caches.Service.Add(service)
+		_ = cb.k8sContext.Caches.Endpoints.Add(tests.NewEndpointsFixture())
+
+		ingress = tests.NewIngressFixture()
+		ingress.Annotations[annotations.SslRedirectKey] = "false"
+		ingress.Spec.TLS = nil
+		backend := tests.NewIngressBackendFixture(tests.ServiceName, 80)
+		ingress.Spec.Rules = nil
+		for _, host := range hosts {
+			ingress.Spec.Rules = append(ingress.Spec.Rules, tests.NewIngressRuleFixture(host, "/api/*", *backend))
+		}
+
+		cbCtx = &ConfigBuilderContext{
+			IngressList:           []*v1beta1.Ingress{ingress},
+			ServiceList:           []*v1.Service{service},
+			EnvVariables:          environment.GetFakeEnv(),
+			DefaultAddressPoolID:  to.StringPtr("xx"),
+			DefaultHTTPSettingsID: to.StringPtr("yy"),
+		}
+	})
+
+	build := func() {
+		Expect(cb.BackendHTTPSettingsCollection(cbCtx)).ToNot(HaveOccurred())
+		Expect(cb.BackendAddressPools(cbCtx)).ToNot(HaveOccurred())
+		Expect(cb.Listeners(cbCtx)).ToNot(HaveOccurred())
+		Expect(cb.RequestRoutingRules(cbCtx)).ToNot(HaveOccurred())
+		cb.shareURLPathMaps(cbCtx)
+	}
+
+	// referencedPathMaps returns the IDs of the path maps referenced by the path-based routing rules.
+	referencedPathMaps := func() []string {
+		var ids []string
+		for _, rule := range *cb.appGw.RequestRoutingRules {
+			Expect(rule.RuleType).To(Equal(n.PathBasedRouting))
+			ids = append(ids, *rule.URLPathMap.ID)
+		}
+		return ids
+	}
+
+	It("shares one path map among the listeners of hosts with identical paths", func() {
+		build()
+		Expect(*cb.appGw.URLPathMaps).To(HaveLen(1))
+		sharedID := cb.appGwIdentifier.urlPathMapID(*(*cb.appGw.URLPathMaps)[0].Name)
+		Expect(referencedPathMaps()).To(Equal([]string{sharedID, sharedID, sharedID}))
+	})
+
+	It("names the shared path map after the lowest name of the listeners' path maps", func() {
+		build()
+		var names []string
+		for _, pathMap := range *cb.mem.pathMaps {
+			names = append(names, *pathMap.Name)
+		}
+		Expect(names).To(HaveLen(len(hosts)))
+		sort.Strings(names)
+		Expect(*(*cb.appGw.URLPathMaps)[0].Name).To(Equal(names[0]))
+	})
+
+	It("keeps distinct path maps for hosts with different paths", func() {
+		ingress.Spec.Rules[1].HTTP.Paths[0].Path = "/web/*"
+		build()
+		Expect(*cb.appGw.URLPathMaps).To(HaveLen(2))
+
+		referenced := make(map[string]int)
+		for _, id := range referencedPathMaps() {
+			referenced[id]++
+		}
+		Expect(referenced).To(HaveLen(2))
+		for _, pathMap := range *cb.appGw.URLPathMaps {
+			Expect(referenced).To(HaveKey(cb.appGwIdentifier.urlPathMapID(*pathMap.Name)))
+		}
+	})
+
+	It("leaves the path maps of manage-backend-only ingresses to themselves", func() {
+		ingress.Annotations[annotations.ManageBackendOnlyKey] = "true"
+		build()
+		Expect(*cb.appGw.URLPathMaps).To(HaveLen(len(hosts)))
+	})
+})
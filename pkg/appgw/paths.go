@@ -0,0 +1,55 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"strings"
+
+	"github.com/golang/glog"
+	"k8s.io/api/extensions/v1beta1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+)
+
+// canonicalPath returns the path of an ingress in the form App Gateway matches it: without surrounding spaces, with a
+// leading slash, without repeated slashes, and with at most one trailing wildcard. An empty path stays empty.
+// A trailing "/*" matches the subpaths of the path, while a trailing "*" matches all paths starting with the path;
+// both are kept as they are.
+func canonicalPath(path string) string {
+	canonical := strings.TrimSpace(path)
+	if canonical == "" {
+		return ""
+	}
+	for strings.Contains(canonical, "//") {
+		canonical = strings.Replace(canonical, "//", "/", -1)
+	}
+	if !strings.HasPrefix(canonical, "/") {
+		canonical = "/" + canonical
+	}
+	if strings.HasSuffix(canonical, "**") {
+		canonical = strings.TrimRight(canonical, "*") + "*"
+	}
+	return canonical
+}
+
+// ingressPath returns the path of the path rule of the path of an ingress, canonicalized unless path normalization is
+// turned off.
+func ingressPath(cbCtx *ConfigBuilderContext, ingress *v1beta1.Ingress, path string) string {
+	if cbCtx.EnvVariables.PathNormalization == environment.PathNormalizationNone {
+		return path
+	}
+	canonical := canonicalPath(path)
+	if canonical != path {
+		glog.V(5).Infof("[paths] Ingress %s/%s: path %q is rewritten to %q", ingress.Namespace, ingress.Name, path, canonical)
+	}
+	return canonical
+}
+
+// isDefaultPath tells whether the path of an ingress matches all paths, which makes its backend the default backend
+// of the URL path map.
+func isDefaultPath(path string) bool {
+	return path == "" || path == "/*" || path == "/"
+}
@@ -0,0 +1,92 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
+)
+
+var _ = Describe("ingress paths", func() {
+	It("are canonicalized", func() {
+		canonical := map[string]string{
+			"/api":       "/api",
+			"/api/*":     "/api/*",
+			"/api*":      "/api*",
+			"":           "",
+			"api":        "/api",
+			"api/*":      "/api/*",
+			"*":          "/*",
+			"//api//v1/": "/api/v1/",
+			"/api/**":    "/api/*",
+			" /api ":     "/api",
+			"  ":         "",
+		}
+		for path, expected := range canonical {
+			Expect(canonicalPath(path)).To(Equal(expected), "path %q", path)
+		}
+	})
+
+	Context("path rules", func() {
+		var cb appGwConfigBuilder
+		var cbCtx *ConfigBuilderContext
+		var ingress *v1beta1.Ingress
+
+		BeforeEach(func() {
+			cb = newConfigBuilderFixture(nil)
+			service := tests.NewServiceFixture(*tests.NewServicePortsFixture()...)
+			_ = cb.k8sContext.Caches.Service.Add(service)
+			_ = cb.k8sContext.Caches.Endpoints.Add(tests.NewEndpointsFixture())
+
+			ingress = tests.NewIngressFixture()
+			ingress.Annotations[annotations.SslRedirectKey] = "false"
+			ingress.Spec.TLS = nil
+			backend := tests.NewIngressBackendFixture(tests.ServiceName, 80)
+			rule := tests.NewIngressRuleFixture(tests.Host, "api//v1", *backend)
+			rule.HTTP.Paths = append(rule.HTTP.Paths, v1beta1.HTTPIngressPath{Path: "*", Backend: *backend})
+			ingress.Spec.Rules = []v1beta1.IngressRule{rule}
+
+			cbCtx = &ConfigBuilderContext{
+				IngressList:           []*v1beta1.Ingress{ingress},
+				ServiceList:           []*v1.Service{service},
+				EnvVariables:          environment.GetFakeEnv(),
+				DefaultAddressPoolID:  to.StringPtr("xx"),
+				DefaultHTTPSettingsID: to.StringPtr("yy"),
+			}
+		})
+
+		pathRulePaths := func() []string {
+			_ = cb.BackendHTTPSettingsCollection(cbCtx)
+			_ = cb.BackendAddressPools(cbCtx)
+			_ = cb.Listeners(cbCtx)
+			pathMaps := cb.getPathMaps(cbCtx)
+			Expect(pathMaps).To(HaveLen(1))
+			var paths []string
+			for _, pathMap := range pathMaps {
+				for _, pathRule := range *pathMap.PathRules {
+					paths = append(paths, *pathRule.Paths...)
+				}
+			}
+			return paths
+		}
+
+		It("are made of the canonical paths", func() {
+			Expect(pathRulePaths()).To(Equal([]string{"/api/v1"}), "the wildcard path is the default of the path map")
+		})
+
+		It("are made of the paths as they are written without path normalization", func() {
+			cbCtx.EnvVariables.PathNormalization = environment.PathNormalizationNone
+			Expect(pathRulePaths()).To(Equal([]string{"api//v1", "*"}))
+		})
+	})
+})
@@ -0,0 +1,173 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"sync"
+	"time"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/golang/glog"
+)
+
+// PoolDrains tracks the backend pools, whose addresses were replaced wholesale (a blue-green swap for instance).
+// Such a pool is updated in two phases:
+//  1. the new addresses are added to the pool, next to the old addresses, which keep serving for the drain window
+//  2. once the drain window ends, the old addresses are removed; App Gateway's connection draining completes
+//     the requests in flight to the removed addresses
+//
+// Pools are only drained when connection draining is enabled on their backends; the drain window is the
+// connection draining timeout. PoolDrains outlives the config builder; it is shared by consecutive builds.
+type PoolDrains struct {
+	sync.Mutex
+	draining    map[string]poolDrain
+	reconcileAt time.Time
+}
+
+type poolDrain struct {
+	addresses []n.ApplicationGatewayBackendAddress
+	until     time.Time
+}
+
+// NewPoolDrains creates a new PoolDrains struct.
+func NewPoolDrains() *PoolDrains {
+	return &PoolDrains{
+		draining: make(map[string]poolDrain),
+	}
+}
+
+// PendingReconcile returns the delay until the earliest drain window ends, unless a reconcile was already requested for it.
+// The caller is expected to reconcile after the delay, so that the old addresses are removed even if nothing else changes.
+func (d *PoolDrains) PendingReconcile(now time.Time) (time.Duration, bool) {
+	d.Lock()
+	defer d.Unlock()
+	var earliest time.Time
+	for _, drain := range d.draining {
+		if earliest.IsZero() || drain.until.Before(earliest) {
+			earliest = drain.until
+		}
+	}
+	if earliest.IsZero() || earliest.Equal(d.reconcileAt) {
+		return 0, false
+	}
+	d.reconcileAt = earliest
+	return earliest.Sub(now), true
+}
+
+// getDrainWindows returns the drain window of each pool with connection draining enabled on one of its backends.
+func (c *appGwConfigBuilder) getDrainWindows(cbCtx *ConfigBuilderContext) map[string]time.Duration {
+	windows := make(map[string]time.Duration)
+	_, _, serviceBackendPairMap, _ := c.getBackendsAndSettingsMap(cbCtx)
+	for backendID, serviceBackendPair := range serviceBackendPairMap {
+		draining, timeout, _ := getConnectionDraining(backendID.Ingress, cbCtx.EnvVariables)
+		if !draining {
+			continue
+		}
+		poolName := c.getAddressPoolName(backendID, serviceBackendPair)
+		if window := time.Duration(timeout) * time.Second; window > windows[poolName] {
+			windows[poolName] = window
+		}
+	}
+	return windows
+}
+
+// drainSwappedPools keeps the old addresses of the pools, whose addresses were swapped wholesale, for the drain window.
+// existingPools are the pools on the App Gateway before this build.
+func (c *appGwConfigBuilder) drainSwappedPools(cbCtx *ConfigBuilderContext, pools []n.ApplicationGatewayBackendAddressPool, existingPools []n.ApplicationGatewayBackendAddressPool) []n.ApplicationGatewayBackendAddressPool {
+	drains := cbCtx.PoolDrains
+	drains.Lock()
+	defer drains.Unlock()
+
+	existingAddresses := make(map[string][]n.ApplicationGatewayBackendAddress)
+	for _, pool := range existingPools {
+		if pool.Name != nil && pool.ApplicationGatewayBackendAddressPoolPropertiesFormat != nil && pool.BackendAddresses != nil {
+			existingAddresses[*pool.Name] = *pool.BackendAddresses
+		}
+	}
+
+	now := c.clock.Now()
+	windows := c.getDrainWindows(cbCtx)
+	drained := make([]n.ApplicationGatewayBackendAddressPool, len(pools))
+	for idx, pool := range pools {
+		drained[idx] = pool
+		window, drainingEnabled := windows[*pool.Name]
+		if !drainingEnabled || pool.ApplicationGatewayBackendAddressPoolPropertiesFormat == nil {
+			delete(drains.draining, *pool.Name)
+			continue
+		}
+		var addresses []n.ApplicationGatewayBackendAddress
+		if pool.BackendAddresses != nil {
+			addresses = *pool.BackendAddresses
+		}
+
+		if drain, exists := drains.draining[*pool.Name]; exists {
+			if now.Before(drain.until) {
+				// Phase 1: the old addresses keep serving until the drain window ends.
+				drained[idx] = withAddresses(pool, mergeAddresses(addresses, drain.addresses))
+				continue
+			}
+			// Phase 2: the drain window ended; the old addresses are removed.
+			glog.V(3).Infof("Drain window of backend pool %s ended; removing %d old addresses", *pool.Name, len(drain.addresses))
+			delete(drains.draining, *pool.Name)
+			continue
+		}
+
+		old := existingAddresses[*pool.Name]
+		if len(old) == 0 || len(addresses) == 0 || sharesAddress(old, addresses) {
+			continue
+		}
+		glog.V(3).Infof("Addresses of backend pool %s were replaced; draining %d old addresses for %+v", *pool.Name, len(old), window)
+		drains.draining[*pool.Name] = poolDrain{
+			addresses: old,
+			until:     now.Add(window),
+		}
+		drained[idx] = withAddresses(pool, mergeAddresses(addresses, old))
+	}
+	return drained
+}
+
+func withAddresses(pool n.ApplicationGatewayBackendAddressPool, addresses []n.ApplicationGatewayBackendAddress) n.ApplicationGatewayBackendAddressPool {
+	properties := *pool.ApplicationGatewayBackendAddressPoolPropertiesFormat
+	properties.BackendAddresses = &addresses
+	pool.ApplicationGatewayBackendAddressPoolPropertiesFormat = &properties
+	return pool
+}
+
+func addressKey(address n.ApplicationGatewayBackendAddress) string {
+	if address.IPAddress != nil {
+		return *address.IPAddress
+	}
+	if address.Fqdn != nil {
+		return *address.Fqdn
+	}
+	return ""
+}
+
+func sharesAddress(a []n.ApplicationGatewayBackendAddress, b []n.ApplicationGatewayBackendAddress) bool {
+	keys := make(map[string]interface{})
+	for _, address := range a {
+		keys[addressKey(address)] = nil
+	}
+	for _, address := range b {
+		if _, exists := keys[addressKey(address)]; exists {
+			return true
+		}
+	}
+	return false
+}
+
+func mergeAddresses(addresses []n.ApplicationGatewayBackendAddress, more []n.ApplicationGatewayBackendAddress) []n.ApplicationGatewayBackendAddress {
+	keys := make(map[string]interface{})
+	var merged []n.ApplicationGatewayBackendAddress
+	for _, address := range append(append([]n.ApplicationGatewayBackendAddress{}, addresses...), more...) {
+		if _, exists := keys[addressKey(address)]; exists {
+			continue
+		}
+		keys[addressKey(address)] = nil
+		merged = append(merged, address)
+	}
+	return merged
+}
@@ -0,0 +1,130 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"time"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+var _ = Describe("Test draining of swapped backend pools", func() {
+	var clock *fakeClock
+	var drains *PoolDrains
+	var appGw n.ApplicationGatewayPropertiesFormat
+
+	// Builds the backend pools for the fixture ingress, backed by the given pod IPs, on top of the current App Gateway.
+	build := func(draining bool, podIPs ...string) []string {
+		cb := newConfigBuilderFixture(nil)
+		cb.clock = clock
+		cb.appGw.ApplicationGatewayPropertiesFormat = &appGw
+
+		endpoint := tests.NewEndpointsFixture()
+		var addresses []v1.EndpointAddress
+		for _, ip := range podIPs {
+			addresses = append(addresses, v1.EndpointAddress{IP: ip})
+		}
+		endpoint.Subsets[0].Addresses = addresses
+		service := tests.NewServiceFixture(*tests.NewServicePortsFixture()...)
+		ingress := tests.NewIngressFixture()
+		ingress.Spec.Rules = ingress.Spec.Rules[:1]
+		ingress.Spec.Rules[0].HTTP.Paths = ingress.Spec.Rules[0].HTTP.Paths[:1]
+		if draining {
+			ingress.Annotations[annotations.ConnectionDrainingKey] = "true"
+			ingress.Annotations[annotations.ConnectionDrainingTimeoutKey] = "60"
+		}
+		_ = cb.k8sContext.Caches.Endpoints.Add(endpoint)
+		_ = cb.k8sContext.Caches.Service.Add(service)
+		_ = cb.k8sContext.Caches.Ingress.Add(ingress)
+
+		cbCtx := &ConfigBuilderContext{
+			IngressList:           []*v1beta1.Ingress{ingress},
+			ServiceList:           []*v1.Service{service},
+			EnvVariables:          environment.GetFakeEnv(),
+			DefaultAddressPoolID:  to.StringPtr(cb.appGwIdentifier.AddressPoolID(DefaultBackendAddressPoolName)),
+			DefaultHTTPSettingsID: to.StringPtr(cb.appGwIdentifier.HTTPSettingsID(DefaultBackendHTTPSettingsName)),
+			PoolDrains:            drains,
+		}
+		Expect(cb.BackendAddressPools(cbCtx)).ToNot(HaveOccurred())
+		appGw = *cb.appGw.ApplicationGatewayPropertiesFormat
+
+		var ips []string
+		for _, pool := range *appGw.BackendAddressPools {
+			if *pool.Name == DefaultBackendAddressPoolName || pool.BackendAddresses == nil {
+				continue
+			}
+			for _, address := range *pool.BackendAddresses {
+				ips = append(ips, *address.IPAddress)
+			}
+		}
+		return ips
+	}
+
+	BeforeEach(func() {
+		clock = &fakeClock{now: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+		drains = NewPoolDrains()
+		appGw = *NewAppGwyConfigFixture()
+	})
+
+	Context("ingress with connection draining", func() {
+		It("adds the new addresses, drains the old addresses and removes them after the drain window", func() {
+			Expect(build(true, "10.0.0.1", "10.0.0.2")).To(ConsistOf("10.0.0.1", "10.0.0.2"))
+			_, pending := drains.PendingReconcile(clock.Now())
+			Expect(pending).To(BeFalse())
+
+			// Blue-green swap: every address is replaced.
+			Expect(build(true, "10.0.1.1", "10.0.1.2")).To(ConsistOf("10.0.1.1", "10.0.1.2", "10.0.0.1", "10.0.0.2"))
+			delay, pending := drains.PendingReconcile(clock.Now())
+			Expect(pending).To(BeTrue())
+			Expect(delay).To(Equal(60 * time.Second))
+			_, pending = drains.PendingReconcile(clock.Now())
+			Expect(pending).To(BeFalse(), "reconcile is already scheduled")
+
+			// Within the drain window the old addresses keep serving.
+			clock.now = clock.now.Add(30 * time.Second)
+			Expect(build(true, "10.0.1.1", "10.0.1.2")).To(ConsistOf("10.0.1.1", "10.0.1.2", "10.0.0.1", "10.0.0.2"))
+
+			// Once the drain window ends the old addresses are removed.
+			clock.now = clock.now.Add(30 * time.Second)
+			Expect(build(true, "10.0.1.1", "10.0.1.2")).To(ConsistOf("10.0.1.1", "10.0.1.2"))
+			_, pending = drains.PendingReconcile(clock.Now())
+			Expect(pending).To(BeFalse())
+		})
+
+		It("does not drain when some addresses remain", func() {
+			Expect(build(true, "10.0.0.1", "10.0.0.2")).To(ConsistOf("10.0.0.1", "10.0.0.2"))
+			Expect(build(true, "10.0.0.2", "10.0.1.1")).To(ConsistOf("10.0.0.2", "10.0.1.1"))
+			_, pending := drains.PendingReconcile(clock.Now())
+			Expect(pending).To(BeFalse())
+		})
+	})
+
+	Context("ingress without connection draining", func() {
+		It("swaps the addresses at once", func() {
+			Expect(build(false, "10.0.0.1", "10.0.0.2")).To(ConsistOf("10.0.0.1", "10.0.0.2"))
+			Expect(build(false, "10.0.1.1", "10.0.1.2")).To(ConsistOf("10.0.1.1", "10.0.1.2"))
+			_, pending := drains.PendingReconcile(clock.Now())
+			Expect(pending).To(BeFalse())
+		})
+	})
+})
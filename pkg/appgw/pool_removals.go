@@ -0,0 +1,192 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/golang/glog"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+)
+
+// DefaultMinHealthyRemovalInterval is the interval used unless APPGW_MIN_HEALTHY_REMOVAL_INTERVAL_SECONDS is set.
+const DefaultMinHealthyRemovalInterval = 30 * time.Second
+
+// PoolRemovals keeps the backend pools from dropping below a minimum number of established addresses, when the
+// addresses of the removed or unready pods are removed from them, as a PodDisruptionBudget would:
+//  1. an address counts as established once it was in the pool for the interval; the addresses found on the App Gateway
+//     when AGIC first builds the pool count as established right away
+//  2. addresses are removed right away, as long as the pool keeps the minimum of established addresses
+//  3. the removals, which would breach the minimum, are deferred; the removed addresses stay in the pool, and are
+//     removed one per interval, or as soon as enough new addresses are established
+//
+// PoolRemovals outlives the config builder; it is shared by consecutive builds.
+type PoolRemovals struct {
+	sync.Mutex
+	firstSeen   map[string]map[string]time.Time
+	lastRemoval map[string]time.Time
+	pending     map[string]time.Time
+	reconcileAt time.Time
+}
+
+// NewPoolRemovals creates a new PoolRemovals struct.
+func NewPoolRemovals() *PoolRemovals {
+	return &PoolRemovals{
+		firstSeen:   make(map[string]map[string]time.Time),
+		lastRemoval: make(map[string]time.Time),
+		pending:     make(map[string]time.Time),
+	}
+}
+
+// GetMinHealthyPoolAddresses returns the minimum set with APPGW_MIN_HEALTHY_POOL_ADDRESSES, or 0 when it is not set.
+func GetMinHealthyPoolAddresses(env environment.EnvVariables) int {
+	if env.MinHealthyPoolAddresses == "" {
+		return 0
+	}
+	min, err := strconv.Atoi(env.MinHealthyPoolAddresses)
+	if err != nil {
+		return 0
+	}
+	return min
+}
+
+// GetMinHealthyRemovalInterval returns the interval set with APPGW_MIN_HEALTHY_REMOVAL_INTERVAL_SECONDS, or
+// DefaultMinHealthyRemovalInterval when it is not set.
+func GetMinHealthyRemovalInterval(env environment.EnvVariables) time.Duration {
+	if env.MinHealthyRemovalInterval == "" {
+		return DefaultMinHealthyRemovalInterval
+	}
+	seconds, err := strconv.Atoi(env.MinHealthyRemovalInterval)
+	if err != nil {
+		return DefaultMinHealthyRemovalInterval
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// PendingReconcile returns the delay until the earliest deferred removal may proceed, unless a reconcile was already
+// requested for it. The caller is expected to reconcile after the delay, so that the deferred removals are made even if
+// nothing else changes.
+func (r *PoolRemovals) PendingReconcile(now time.Time) (time.Duration, bool) {
+	r.Lock()
+	defer r.Unlock()
+	var earliest time.Time
+	for _, at := range r.pending {
+		if earliest.IsZero() || at.Before(earliest) {
+			earliest = at
+		}
+	}
+	if earliest.IsZero() || earliest.Equal(r.reconcileAt) {
+		return 0, false
+	}
+	r.reconcileAt = earliest
+	return earliest.Sub(now), true
+}
+
+// deferPoolRemovals keeps the addresses removed from the pools, which would leave fewer established addresses than the
+// minimum. existingPools are the pools on the App Gateway before this build.
+func (c *appGwConfigBuilder) deferPoolRemovals(cbCtx *ConfigBuilderContext, pools []n.ApplicationGatewayBackendAddressPool, existingPools []n.ApplicationGatewayBackendAddressPool) []n.ApplicationGatewayBackendAddressPool {
+	removals := cbCtx.PoolRemovals
+	removals.Lock()
+	defer removals.Unlock()
+
+	existingAddresses := make(map[string][]n.ApplicationGatewayBackendAddress)
+	for _, pool := range existingPools {
+		if pool.Name != nil && pool.ApplicationGatewayBackendAddressPoolPropertiesFormat != nil && pool.BackendAddresses != nil {
+			existingAddresses[*pool.Name] = *pool.BackendAddresses
+		}
+	}
+
+	minHealthy := GetMinHealthyPoolAddresses(cbCtx.EnvVariables)
+	interval := GetMinHealthyRemovalInterval(cbCtx.EnvVariables)
+	now := c.clock.Now()
+	built := make(map[string]interface{})
+	deferred := make([]n.ApplicationGatewayBackendAddressPool, len(pools))
+	for idx, pool := range pools {
+		deferred[idx] = pool
+		if pool.ApplicationGatewayBackendAddressPoolPropertiesFormat == nil {
+			continue
+		}
+		built[*pool.Name] = nil
+		var addresses []n.ApplicationGatewayBackendAddress
+		if pool.BackendAddresses != nil {
+			addresses = *pool.BackendAddresses
+		}
+
+		existing := make(map[string]interface{})
+		for _, address := range existingAddresses[*pool.Name] {
+			existing[addressKey(address)] = nil
+		}
+		seen := make(map[string]time.Time)
+		established := 0
+		for _, address := range addresses {
+			key := addressKey(address)
+			firstSeen, exists := removals.firstSeen[*pool.Name][key]
+			if !exists {
+				firstSeen = now
+				if _, onAppGw := existing[key]; onAppGw {
+					firstSeen = time.Time{}
+				}
+			}
+			seen[key] = firstSeen
+			if now.Sub(firstSeen) >= interval {
+				established++
+			}
+		}
+		removals.firstSeen[*pool.Name] = seen
+
+		var removed []n.ApplicationGatewayBackendAddress
+		for _, address := range existingAddresses[*pool.Name] {
+			if _, exists := seen[addressKey(address)]; !exists {
+				removed = append(removed, address)
+			}
+		}
+		keep := minHealthy - established
+		if keep > len(removed) {
+			keep = len(removed)
+		}
+		if keep > 0 {
+			if lastRemoval, exists := removals.lastRemoval[*pool.Name]; !exists {
+				removals.lastRemoval[*pool.Name] = now
+			} else if now.Sub(lastRemoval) >= interval {
+				keep--
+				removals.lastRemoval[*pool.Name] = now
+			}
+		}
+		if keep <= 0 {
+			delete(removals.lastRemoval, *pool.Name)
+			delete(removals.pending, *pool.Name)
+			continue
+		}
+
+		sort.Slice(removed, func(i, j int) bool { return addressKey(removed[i]) < addressKey(removed[j]) })
+		glog.V(3).Infof("Backend pool %s keeps %d established addresses, fewer than the minimum of %d; deferring the removal of %d of %d addresses",
+			*pool.Name, established, minHealthy, keep, len(removed))
+		deferred[idx] = withAddresses(pool, mergeAddresses(addresses, removed[:keep]))
+
+		// The next removal is made once the interval ends, or once a new address is established.
+		next := removals.lastRemoval[*pool.Name].Add(interval)
+		for _, firstSeen := range seen {
+			if at := firstSeen.Add(interval); at.After(now) && at.Before(next) {
+				next = at
+			}
+		}
+		removals.pending[*pool.Name] = next
+	}
+
+	for poolName := range removals.firstSeen {
+		if _, exists := built[poolName]; !exists {
+			delete(removals.firstSeen, poolName)
+			delete(removals.lastRemoval, poolName)
+			delete(removals.pending, poolName)
+		}
+	}
+	return deferred
+}
@@ -0,0 +1,139 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"time"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
+)
+
+var _ = Describe("removal of backend pool addresses with a minimum of healthy addresses", func() {
+	var clock *fakeClock
+	var removals *PoolRemovals
+	var appGw n.ApplicationGatewayPropertiesFormat
+
+	// Builds the backend pools for the fixture ingress, backed by the given pod IPs, on top of the current App Gateway.
+	build := func(podIPs ...string) []string {
+		cb := newConfigBuilderFixture(nil)
+		cb.clock = clock
+		cb.appGw.ApplicationGatewayPropertiesFormat = &appGw
+
+		endpoint := tests.NewEndpointsFixture()
+		var addresses []v1.EndpointAddress
+		for _, ip := range podIPs {
+			addresses = append(addresses, v1.EndpointAddress{IP: ip})
+		}
+		endpoint.Subsets[0].Addresses = addresses
+		service := tests.NewServiceFixture(*tests.NewServicePortsFixture()...)
+		ingress := tests.NewIngressFixture()
+		ingress.Spec.Rules = ingress.Spec.Rules[:1]
+		ingress.Spec.Rules[0].HTTP.Paths = ingress.Spec.Rules[0].HTTP.Paths[:1]
+		_ = cb.k8sContext.Caches.Endpoints.Add(endpoint)
+		_ = cb.k8sContext.Caches.Service.Add(service)
+		_ = cb.k8sContext.Caches.Ingress.Add(ingress)
+
+		env := environment.GetFakeEnv()
+		env.MinHealthyPoolAddresses = "2"
+		cbCtx := &ConfigBuilderContext{
+			IngressList:           []*v1beta1.Ingress{ingress},
+			ServiceList:           []*v1.Service{service},
+			EnvVariables:          env,
+			DefaultAddressPoolID:  to.StringPtr(cb.appGwIdentifier.AddressPoolID(DefaultBackendAddressPoolName)),
+			DefaultHTTPSettingsID: to.StringPtr(cb.appGwIdentifier.HTTPSettingsID(DefaultBackendHTTPSettingsName)),
+			PoolRemovals:          removals,
+		}
+		Expect(cb.BackendAddressPools(cbCtx)).ToNot(HaveOccurred())
+		appGw = *cb.appGw.ApplicationGatewayPropertiesFormat
+
+		var ips []string
+		for _, pool := range *appGw.BackendAddressPools {
+			if *pool.Name == DefaultBackendAddressPoolName || pool.BackendAddresses == nil {
+				continue
+			}
+			for _, address := range *pool.BackendAddresses {
+				ips = append(ips, *address.IPAddress)
+			}
+		}
+		return ips
+	}
+
+	// Builds the pool with the addresses and waits for them to be established.
+	established := func(podIPs ...string) {
+		Expect(build(podIPs...)).To(ConsistOf(podIPs))
+		clock.now = clock.now.Add(DefaultMinHealthyRemovalInterval)
+		Expect(build(podIPs...)).To(ConsistOf(podIPs))
+	}
+
+	BeforeEach(func() {
+		clock = &fakeClock{now: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+		removals = NewPoolRemovals()
+		appGw = *NewAppGwyConfigFixture()
+	})
+
+	It("removes the addresses right away while the minimum of established addresses remains", func() {
+		established("10.0.0.1", "10.0.0.2", "10.0.0.3")
+		Expect(build("10.0.0.1", "10.0.0.2")).To(ConsistOf("10.0.0.1", "10.0.0.2"))
+		_, pending := removals.PendingReconcile(clock.Now())
+		Expect(pending).To(BeFalse())
+	})
+
+	It("defers the removals breaching the minimum and removes the addresses one per interval", func() {
+		established("10.0.0.1", "10.0.0.2", "10.0.0.3")
+
+		// Scaling in to one pod would leave a single established address.
+		Expect(build("10.0.0.1")).To(ConsistOf("10.0.0.1", "10.0.0.2"))
+		delay, pending := removals.PendingReconcile(clock.Now())
+		Expect(pending).To(BeTrue())
+		Expect(delay).To(Equal(DefaultMinHealthyRemovalInterval))
+		_, pending = removals.PendingReconcile(clock.Now())
+		Expect(pending).To(BeFalse(), "reconcile is already scheduled")
+
+		// Within the interval the deferred address stays.
+		clock.now = clock.now.Add(DefaultMinHealthyRemovalInterval / 2)
+		Expect(build("10.0.0.1")).To(ConsistOf("10.0.0.1", "10.0.0.2"))
+
+		// Once the interval ends the next address is removed.
+		clock.now = clock.now.Add(DefaultMinHealthyRemovalInterval / 2)
+		Expect(build("10.0.0.1")).To(ConsistOf("10.0.0.1"))
+		_, pending = removals.PendingReconcile(clock.Now())
+		Expect(pending).To(BeFalse())
+	})
+
+	It("removes the deferred addresses as soon as the new addresses are established", func() {
+		established("10.0.0.1", "10.0.0.2")
+
+		// A rolling update replaces a pod; the new address is not established yet.
+		Expect(build("10.0.0.2", "10.0.1.1")).To(ConsistOf("10.0.0.1", "10.0.0.2", "10.0.1.1"))
+		clock.now = clock.now.Add(DefaultMinHealthyRemovalInterval / 3)
+		Expect(build("10.0.0.2", "10.0.1.1", "10.0.1.2")).To(ConsistOf("10.0.0.1", "10.0.0.2", "10.0.1.1", "10.0.1.2"))
+
+		clock.now = clock.now.Add(DefaultMinHealthyRemovalInterval * 2 / 3)
+		Expect(build("10.0.0.2", "10.0.1.1", "10.0.1.2")).To(ConsistOf("10.0.0.2", "10.0.1.1", "10.0.1.2"))
+	})
+
+	It("counts the addresses found on the App Gateway as established", func() {
+		established("10.0.0.1", "10.0.0.2", "10.0.0.3")
+
+		// AGIC restarted.
+		removals = NewPoolRemovals()
+		Expect(build("10.0.0.1", "10.0.0.2")).To(ConsistOf("10.0.0.1", "10.0.0.2"))
+	})
+
+	It("removes the addresses right away without a minimum", func() {
+		established("10.0.0.1", "10.0.0.2", "10.0.0.3")
+		removals = nil
+		Expect(build("10.0.0.1")).To(ConsistOf("10.0.0.1"))
+	})
+})
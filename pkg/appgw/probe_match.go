@@ -0,0 +1,90 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"strconv"
+	"strings"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"k8s.io/api/extensions/v1beta1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+)
+
+const (
+	// App Gateway limits the string a probe response body must contain to 4090 characters.
+	maxProbeMatchBodyLength = 4090
+
+	// App Gateway only accepts status codes in this range for a probe match.
+	minProbeStatusCode = 200
+	maxProbeStatusCode = 499
+)
+
+// defaultProbeStatusCodes are the status codes App Gateway considers healthy when a probe has no match.
+var defaultProbeStatusCodes = []string{"200-399"}
+
+// getProbeMatchBody returns the string the body of a healthy probe response must contain, or nil when the ingress
+// does not set one.
+func getProbeMatchBody(ingress *v1beta1.Ingress) (*string, error) {
+	body, err := annotations.HealthProbeMatchBody(ingress)
+	if annotations.IsMissingAnnotations(err) {
+		return nil, nil
+	}
+	if err != nil || len(body) > maxProbeMatchBodyLength {
+		return nil, ErrInvalidProbeMatchBody
+	}
+	return &body, nil
+}
+
+// getProbeStatusCodes returns the status codes and ranges of a healthy probe response, or nil when the ingress
+// does not set them.
+func getProbeStatusCodes(ingress *v1beta1.Ingress) ([]string, error) {
+	value, err := annotations.HealthProbeStatusCodes(ingress)
+	if annotations.IsMissingAnnotations(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, ErrInvalidProbeStatusCodes
+	}
+
+	var statusCodes []string
+	for _, statusCode := range strings.Split(value, ",") {
+		statusCode = strings.TrimSpace(statusCode)
+		if statusCode == "" {
+			continue
+		}
+		bounds := strings.SplitN(statusCode, "-", 2)
+		low, lowErr := strconv.Atoi(bounds[0])
+		high, highErr := low, lowErr
+		if len(bounds) == 2 {
+			high, highErr = strconv.Atoi(bounds[1])
+		}
+		if lowErr != nil || highErr != nil || low < minProbeStatusCode || high > maxProbeStatusCode || low > high {
+			return nil, ErrInvalidProbeStatusCodes
+		}
+		statusCodes = append(statusCodes, statusCode)
+	}
+	if len(statusCodes) == 0 {
+		return nil, ErrInvalidProbeStatusCodes
+	}
+	return statusCodes, nil
+}
+
+// newProbeMatch returns the conditions of a healthy probe response; a body match is combined with the default
+// status codes unless the status codes are given as well.
+func newProbeMatch(body *string, statusCodes []string) *n.ApplicationGatewayProbeHealthResponseMatch {
+	if body == nil && statusCodes == nil {
+		return nil
+	}
+	if statusCodes == nil {
+		statusCodes = defaultProbeStatusCodes
+	}
+	return &n.ApplicationGatewayProbeHealthResponseMatch{
+		Body:        body,
+		StatusCodes: &statusCodes,
+	}
+}
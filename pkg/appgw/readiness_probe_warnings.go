@@ -0,0 +1,79 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/golang/glog"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+)
+
+// DefaultProbeWarningInterval is how long ProbeWarnings stays silent about a service after it warned about it.
+const DefaultProbeWarningInterval = time.Hour
+
+// ProbeWarnings throttles the events on the ingresses, whose backend pods have no HTTP readiness probe for the health
+// probe of App Gateway to follow: it warns about each service at most once per interval, however many ingresses refer
+// to it and however often AGIC reconciles.
+// ProbeWarnings outlives the config builder; it is shared by consecutive builds.
+type ProbeWarnings struct {
+	sync.Mutex
+	interval time.Duration
+	warned   map[string]time.Time
+}
+
+// NewProbeWarnings creates a new ProbeWarnings warning about a service at most once per interval.
+func NewProbeWarnings(interval time.Duration) *ProbeWarnings {
+	return &ProbeWarnings{
+		interval: interval,
+		warned:   make(map[string]time.Time),
+	}
+}
+
+// due returns true when the service was not warned about within the interval.
+func (pw *ProbeWarnings) due(serviceKey string, now time.Time) bool {
+	pw.Lock()
+	defer pw.Unlock()
+	warnedAt, exists := pw.warned[serviceKey]
+	return !exists || now.Sub(warnedAt) >= pw.interval
+}
+
+func (pw *ProbeWarnings) warn(serviceKey string, now time.Time) {
+	pw.Lock()
+	defer pw.Unlock()
+	pw.warned[serviceKey] = now
+}
+
+// warnMissingReadinessProbe emits an event on the ingress of the backend, when the pods of its service have no HTTP
+// readiness probe, nor liveness probe, for the health probe to follow; App Gateway then probes the path of the ingress,
+// or "/", which the pods may not serve.
+func (c *appGwConfigBuilder) warnMissingReadinessProbe(cbCtx *ConfigBuilderContext, backendID backendIdentifier, probe *n.ApplicationGatewayProbe) {
+	if cbCtx.ProbeWarnings == nil || probe == nil || probe.Path == nil {
+		return
+	}
+	service := c.k8sContext.GetService(backendID.serviceKey())
+	if service == nil || c.isExternalNameBackend(backendID) {
+		return
+	}
+	now := c.clock.Now()
+	if !cbCtx.ProbeWarnings.due(backendID.serviceKey(), now) {
+		return
+	}
+	if c.getProbeForServiceContainer(service, backendID) != nil || len(c.k8sContext.ListPodsByServiceSelector(service)) == 0 {
+		return
+	}
+
+	cbCtx.ProbeWarnings.warn(backendID.serviceKey(), now)
+	message := fmt.Sprintf("Pods of service %s have no HTTP readiness probe on the backend port; App Gateway probes the path %s instead."+
+		" Add an HTTP readiness probe to the pods, or set the health probe annotations of the ingress, if the pods do not serve the path",
+		backendID.serviceKey(), *probe.Path)
+	glog.Infof("[%s/%s] %s", backendID.Ingress.Namespace, backendID.Ingress.Name, message)
+	events.Normal(c.recorder, backendID.Ingress, events.ReasonMissingReadinessProbe, message)
+}
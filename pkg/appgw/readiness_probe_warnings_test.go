@@ -0,0 +1,107 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"time"
+
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
+)
+
+var _ = Describe("warnings about backend pods without a readiness probe", func() {
+	var clock *fakeClock
+	var warnings *ProbeWarnings
+	var recorder *record.FakeRecorder
+	var pod *v1.Pod
+
+	// Builds the probes for the fixture ingress, backed by the pod.
+	build := func() {
+		cb := newConfigBuilderFixture(nil)
+		cb.clock = clock
+		cb.recorder = recorder
+
+		service := tests.NewServiceFixture(*tests.NewServicePortsFixture()...)
+		_ = cb.k8sContext.Caches.Endpoints.Add(tests.NewEndpointsFixture())
+		_ = cb.k8sContext.Caches.Service.Add(service)
+		if pod != nil {
+			_ = cb.k8sContext.Caches.Pods.Add(pod)
+		}
+
+		cbCtx := &ConfigBuilderContext{
+			IngressList:           []*v1beta1.Ingress{tests.NewIngressFixture()},
+			ServiceList:           []*v1.Service{service},
+			DefaultAddressPoolID:  to.StringPtr("xx"),
+			DefaultHTTPSettingsID: to.StringPtr("yy"),
+			ProbeWarnings:         warnings,
+		}
+		Expect(cb.HealthProbesCollection(cbCtx)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		clock = &fakeClock{now: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+		warnings = NewProbeWarnings(time.Hour)
+		recorder = record.NewFakeRecorder(100)
+		pod = tests.NewPodFixture(tests.ServiceName, tests.Namespace, tests.ContainerName, tests.ContainerPort)
+		pod.Spec.Containers[0].ReadinessProbe = nil
+		pod.Spec.Containers[0].LivenessProbe = nil
+	})
+
+	It("emits an event when the pods of the service have no HTTP readiness probe", func() {
+		build()
+		Expect(recorder.Events).To(Receive(And(
+			HavePrefix(v1.EventTypeNormal+" "+string(events.ReasonMissingReadinessProbe)),
+			ContainSubstring(tests.Namespace+"/"+tests.ServiceName),
+		)))
+	})
+
+	It("treats a readiness probe other than an HTTP one as missing", func() {
+		pod.Spec.Containers[0].ReadinessProbe = &v1.Probe{Handler: v1.Handler{Exec: &v1.ExecAction{Command: []string{"true"}}}}
+		build()
+		Expect(recorder.Events).To(Receive(ContainSubstring(string(events.ReasonMissingReadinessProbe))))
+	})
+
+	It("emits no event when the pods have an HTTP readiness probe", func() {
+		pod.Spec.Containers[0].ReadinessProbe = tests.NewProbeFixture(tests.ContainerName)
+		build()
+		Expect(recorder.Events).ToNot(Receive())
+	})
+
+	It("emits no event when the service has no pods", func() {
+		pod = nil
+		build()
+		Expect(recorder.Events).ToNot(Receive())
+	})
+
+	It("emits no event unless enabled", func() {
+		warnings = nil
+		build()
+		Expect(recorder.Events).ToNot(Receive())
+	})
+
+	It("warns about a service once per interval", func() {
+		build()
+		Expect(recorder.Events).To(Receive())
+		// The fixture ingress refers to the service through several backends.
+		Expect(recorder.Events).ToNot(Receive())
+
+		clock.now = clock.now.Add(30 * time.Minute)
+		build()
+		Expect(recorder.Events).ToNot(Receive())
+
+		clock.now = clock.now.Add(30 * time.Minute)
+		build()
+		Expect(recorder.Events).To(Receive())
+		Expect(recorder.Events).ToNot(Receive())
+	})
+})
@@ -45,6 +45,10 @@ func (c *appGwConfigBuilder) getRedirectConfigurations(cbCtx *ConfigBuilderConte
 		}
 	}
 
+	if isGatewayHealthPathEnabled(cbCtx.EnvVariables) {
+		redirectConfigs = append(redirectConfigs, c.newGatewayHealthRedirectConfig(cbCtx.EnvVariables))
+	}
+
 	if cbCtx.EnvVariables.EnableBrownfieldDeployment {
 		er := brownfield.NewExistingResources(c.appGw, cbCtx.ProhibitedTargets, nil)
 
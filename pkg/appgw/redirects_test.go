@@ -12,9 +12,11 @@ import (
 	"github.com/Azure/go-autorest/autorest/to"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
 	"k8s.io/api/extensions/v1beta1"
 
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
 )
 
@@ -152,4 +154,76 @@ var _ = Describe("Test SSL Redirect Annotations", func() {
 			Expect(actualListeners[listenerID2].SslRedirectConfigurationName).To(Equal(""), fmt.Sprintf("Actual: %+v", actualListeners))
 		})
 	})
+
+	Context("Test SSL Redirect enabled for the whole cluster", func() {
+		var cb appGwConfigBuilder
+		var ingress *v1beta1.Ingress
+		var cbCtx *ConfigBuilderContext
+
+		BeforeEach(func() {
+			cb = newConfigBuilderFixture(nil)
+			service := tests.NewServiceFixture(*tests.NewServicePortsFixture()...)
+			_ = cb.k8sContext.Caches.Service.Add(service)
+			_ = cb.k8sContext.Caches.Endpoints.Add(tests.NewEndpointsFixture())
+
+			ingress = tests.NewIngressFixture()
+			delete(ingress.Annotations, annotations.SslRedirectKey)
+			cbCtx = &ConfigBuilderContext{
+				IngressList:           []*v1beta1.Ingress{ingress},
+				ServiceList:           []*v1.Service{service},
+				EnvVariables:          environment.GetFakeEnv(),
+				DefaultAddressPoolID:  to.StringPtr("xx"),
+				DefaultHTTPSettingsID: to.StringPtr("yy"),
+			}
+			cbCtx.EnvVariables.DefaultSslRedirect = true
+		})
+
+		// redirectedRules returns whether the routing rule of each listener redirects all of its requests.
+		redirectedRules := func() map[n.ApplicationGatewayProtocol]bool {
+			Expect(cb.BackendHTTPSettingsCollection(cbCtx)).ToNot(HaveOccurred())
+			Expect(cb.BackendAddressPools(cbCtx)).ToNot(HaveOccurred())
+			Expect(cb.Listeners(cbCtx)).ToNot(HaveOccurred())
+			Expect(cb.RequestRoutingRules(cbCtx)).ToNot(HaveOccurred())
+
+			protocols := make(map[string]n.ApplicationGatewayProtocol)
+			for _, listener := range *cb.appGw.HTTPListeners {
+				protocols[*listener.ID] = listener.Protocol
+			}
+			pathMaps := make(map[string]n.ApplicationGatewayURLPathMap)
+			for _, pathMap := range *cb.appGw.URLPathMaps {
+				pathMaps[*pathMap.ID] = pathMap
+			}
+			redirected := make(map[n.ApplicationGatewayProtocol]bool)
+			for _, rule := range *cb.appGw.RequestRoutingRules {
+				protocol := protocols[*rule.HTTPListener.ID]
+				if rule.RuleType == n.Basic {
+					redirected[protocol] = rule.RedirectConfiguration != nil
+					continue
+				}
+				pathMap := pathMaps[*rule.URLPathMap.ID]
+				redirected[protocol] = pathMap.DefaultRedirectConfiguration != nil
+				for _, pathRule := range *pathMap.PathRules {
+					redirected[protocol] = redirected[protocol] && pathRule.RedirectConfiguration != nil
+				}
+			}
+			return redirected
+		}
+
+		It("should redirect HTTP to HTTPS for an ingress with TLS without the annotation", func() {
+			Expect(*cb.getRedirectConfigurations(cbCtx)).To(HaveLen(1))
+			Expect(redirectedRules()).To(Equal(map[n.ApplicationGatewayProtocol]bool{n.HTTP: true, n.HTTPS: false}))
+		})
+
+		It("should not redirect an ingress, which opts out with the annotation", func() {
+			ingress.Annotations[annotations.SslRedirectKey] = "false"
+			Expect(*cb.getRedirectConfigurations(cbCtx)).To(BeEmpty())
+			Expect(redirectedRules()).To(Equal(map[n.ApplicationGatewayProtocol]bool{n.HTTPS: false}))
+		})
+
+		It("should serve an ingress without TLS over HTTP", func() {
+			ingress.Spec.TLS = nil
+			Expect(*cb.getRedirectConfigurations(cbCtx)).To(BeEmpty())
+			Expect(redirectedRules()).To(Equal(map[n.ApplicationGatewayProtocol]bool{n.HTTP: false}))
+		})
+	})
 })
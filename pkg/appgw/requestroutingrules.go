@@ -17,6 +17,7 @@ import (
 
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/brownfield"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/sorter"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/utils"
 )
@@ -56,11 +57,38 @@ func (c *appGwConfigBuilder) RequestRoutingRules(cbCtx *ConfigBuilderContext) er
 	}
 
 	sort.Sort(sorter.ByRequestRoutingRuleName(requestRoutingRules))
+	c.sortBasicListenerRulesLast(requestRoutingRules)
 	c.appGw.RequestRoutingRules = &requestRoutingRules
 
 	return nil
 }
 
+// sortBasicListenerRulesLast moves the rules bound to listeners without a host name (catch-all listeners, created
+// for ingress rules without a host) after the rules bound to host-specific listeners. App Gateway evaluates the rules
+// in order; a rule with a catch-all listener placed first would accept the requests for all hosts on that port.
+func (c *appGwConfigBuilder) sortBasicListenerRulesLast(rules []n.ApplicationGatewayRequestRoutingRule) {
+	basicListeners := make(map[string]interface{})
+	if c.appGw.HTTPListeners != nil {
+		for _, listener := range *c.appGw.HTTPListeners {
+			if listener.ID != nil && isBasicListener(listener) {
+				basicListeners[*listener.ID] = nil
+			}
+		}
+	}
+
+	isBasicRule := func(rule n.ApplicationGatewayRequestRoutingRule) bool {
+		if rule.ApplicationGatewayRequestRoutingRulePropertiesFormat == nil || rule.HTTPListener == nil || rule.HTTPListener.ID == nil {
+			return false
+		}
+		_, exists := basicListeners[*rule.HTTPListener.ID]
+		return exists
+	}
+
+	sort.SliceStable(rules, func(i, j int) bool {
+		return !isBasicRule(rules[i]) && isBasicRule(rules[j])
+	})
+}
+
 func (c *appGwConfigBuilder) getRules(cbCtx *ConfigBuilderContext) ([]n.ApplicationGatewayRequestRoutingRule, []n.ApplicationGatewayURLPathMap) {
 	if c.mem.routingRules != nil && c.mem.pathMaps != nil {
 		return *c.mem.routingRules, *c.mem.pathMaps
@@ -131,10 +159,10 @@ func (c *appGwConfigBuilder) noRulesIngress(cbCtx *ConfigBuilderContext, ingress
 		glog.Error("Error fetching Backends and Settings: ", err)
 	}
 	if serviceBackendPair, exists := serviceBackendPairMap[backendID]; exists {
-		poolName := generateAddressPoolName(backendID.serviceFullName(), backendID.Backend.ServicePort.String(), serviceBackendPair.BackendPort)
+		poolName := c.getAddressPoolName(backendID, serviceBackendPair)
 		defaultAddressPoolID := c.appGwIdentifier.AddressPoolID(poolName)
 		defaultHTTPSettingsID := c.appGwIdentifier.HTTPSettingsID(DefaultBackendHTTPSettingsName)
-		listenerID := defaultFrontendListenerIdentifier()
+		listenerID := defaultFrontendListenerIdentifier(cbCtx.EnvVariables)
 		(*urlPathMaps)[listenerID] = &n.ApplicationGatewayURLPathMap{
 			Etag: to.StringPtr("*"),
 			Name: to.StringPtr(generateURLPathMapName(listenerID)),
@@ -149,17 +177,18 @@ func (c *appGwConfigBuilder) noRulesIngress(cbCtx *ConfigBuilderContext, ingress
 
 func (c *appGwConfigBuilder) getPathMaps(cbCtx *ConfigBuilderContext) map[listenerIdentifier]*n.ApplicationGatewayURLPathMap {
 	urlPathMaps := make(map[listenerIdentifier]*n.ApplicationGatewayURLPathMap)
-	for ingressIdx := range cbCtx.IngressList {
-		ingress := cbCtx.IngressList[ingressIdx]
+	// Ingresses defining the same host and path are resolved deterministically; see sortIngressesByPrecedence.
+	owners := newPathOwners()
+	for _, ingress := range sortIngressesByPrecedence(cbCtx.IngressList) {
 
 		if len(ingress.Spec.Rules) == 0 {
 			c.noRulesIngress(cbCtx, ingress, &urlPathMaps)
 		}
 
 		for ruleIdx := range ingress.Spec.Rules {
-			rule := &ingress.Spec.Rules[ruleIdx]
+			rule := getHTTPRule(ingress, &ingress.Spec.Rules[ruleIdx])
 			// skip no http rule
-			if rule.HTTP == nil {
+			if rule == nil {
 				continue
 			}
 
@@ -178,6 +207,7 @@ func (c *appGwConfigBuilder) getPathMaps(cbCtx *ConfigBuilderContext) map[listen
 				}
 
 				pathMap := c.getPathMap(cbCtx, listenerID, listenerAzConfig, ingress, rule)
+				c.resolveConflicts(owners, listenerID, ingress, pathMap, cbCtx)
 				urlPathMaps[listenerID] = c.mergePathMap(urlPathMaps[listenerID], pathMap, cbCtx)
 			}
 		}
@@ -188,7 +218,7 @@ func (c *appGwConfigBuilder) getPathMaps(cbCtx *ConfigBuilderContext) map[listen
 	if len(urlPathMaps) == 0 {
 		defaultAddressPoolID := c.appGwIdentifier.AddressPoolID(DefaultBackendAddressPoolName)
 		defaultHTTPSettingsID := c.appGwIdentifier.HTTPSettingsID(DefaultBackendHTTPSettingsName)
-		listenerID := defaultFrontendListenerIdentifier()
+		listenerID := defaultFrontendListenerIdentifier(cbCtx.EnvVariables)
 		urlPathMaps[listenerID] = &n.ApplicationGatewayURLPathMap{
 			Etag: to.StringPtr("*"),
 			Name: to.StringPtr(generateURLPathMapName(listenerID)),
@@ -200,6 +230,8 @@ func (c *appGwConfigBuilder) getPathMaps(cbCtx *ConfigBuilderContext) map[listen
 		}
 	}
 
+	c.addGatewayHealthPathRule(cbCtx, urlPathMaps)
+
 	if cbCtx.EnvVariables.EnableIstioIntegration {
 		for listenerID, pathMap := range c.getIstioPathMaps(cbCtx) {
 			if _, exists := urlPathMaps[listenerID]; !exists {
@@ -237,9 +269,23 @@ func (c *appGwConfigBuilder) getPathMap(cbCtx *ConfigBuilderContext, listenerID
 }
 
 func (c *appGwConfigBuilder) getDefaultFromRule(cbCtx *ConfigBuilderContext, listenerID listenerIdentifier, listenerAzConfig listenerAzConfig, ingress *v1beta1.Ingress, rule *v1beta1.IngressRule) (*string, *string, *string) {
-	if sslRedirect, _ := annotations.IsSslRedirect(ingress); sslRedirect && listenerAzConfig.Protocol == n.HTTP {
+	var defRule *v1beta1.IngressRule
+	var defPath *v1beta1.HTTPIngressPath
+	defBackend := ingress.Spec.Backend
+	for pathIdx := range rule.HTTP.Paths {
+		path := &rule.HTTP.Paths[pathIdx]
+		if isDefaultPath(ingressPath(cbCtx, ingress, path.Path)) {
+			defBackend = &path.Backend
+			defPath = path
+			defRule = rule
+		}
+	}
+
+	// The default path can be excluded from the SSL redirect, in which case it is served by its backend over HTTP.
+	defaultExcluded := defPath != nil && isSslRedirectExcluded(cbCtx, ingress, defPath.Path)
+	if IsSslRedirect(ingress, cbCtx.EnvVariables) && listenerAzConfig.Protocol == n.HTTP && !defaultExcluded {
 		targetListener := listenerID
-		targetListener.FrontendPort = 443
+		targetListener.FrontendPort = c.getHTTPSListenerPort(ingress, cbCtx.EnvVariables)
 
 		// We could end up in a situation where we are attempting to attach a redirect, which does not exist.
 		redirectRef := c.getSslRedirectConfigResourceReference(targetListener)
@@ -252,18 +298,6 @@ func (c *appGwConfigBuilder) getDefaultFromRule(cbCtx *ConfigBuilderContext, lis
 		glog.Errorf("Will not attach default redirect to rule; SSL Redirect does not exist: %s", *redirectRef.ID)
 	}
 
-	var defRule *v1beta1.IngressRule
-	var defPath *v1beta1.HTTPIngressPath
-	defBackend := ingress.Spec.Backend
-	for pathIdx := range rule.HTTP.Paths {
-		path := &rule.HTTP.Paths[pathIdx]
-		if path.Path == "" || path.Path == "/*" || path.Path == "/" {
-			defBackend = &path.Backend
-			defPath = path
-			defRule = rule
-		}
-	}
-
 	backendPools := c.newBackendPoolMap(cbCtx)
 	_, backendHTTPSettingsMap, _, _ := c.getBackendsAndSettingsMap(cbCtx)
 	if defBackend != nil {
@@ -287,7 +321,8 @@ func (c *appGwConfigBuilder) getPathRules(cbCtx *ConfigBuilderContext, listenerI
 	pathRules := make([]n.ApplicationGatewayPathRule, 0)
 	for pathIdx := range rule.HTTP.Paths {
 		path := &rule.HTTP.Paths[pathIdx]
-		if len(path.Path) == 0 || path.Path == "/*" || path.Path == "/" {
+		rulePath := ingressPath(cbCtx, ingress, path.Path)
+		if isDefaultPath(rulePath) {
 			continue
 		}
 
@@ -295,11 +330,11 @@ func (c *appGwConfigBuilder) getPathRules(cbCtx *ConfigBuilderContext, listenerI
 			Etag: to.StringPtr("*"),
 			Name: to.StringPtr(generatePathRuleName(ingress.Namespace, ingress.Name, strconv.Itoa(pathIdx))),
 			ApplicationGatewayPathRulePropertiesFormat: &n.ApplicationGatewayPathRulePropertiesFormat{
-				Paths: &[]string{path.Path},
+				Paths: &[]string{rulePath},
 			},
 		}
 
-		if wafPolicy, err := annotations.WAFPolicy(ingress); err == nil {
+		if wafPolicy, exists := getFirewallPolicy(cbCtx, ingress); exists {
 			pathRule.FirewallPolicy = &n.SubResource{ID: to.StringPtr(string(wafPolicy))}
 			var paths string
 			if pathRule.Paths != nil {
@@ -308,9 +343,9 @@ func (c *appGwConfigBuilder) getPathRules(cbCtx *ConfigBuilderContext, listenerI
 			glog.V(5).Infof("Attach Firewall Policy %s to Path Rule %s", wafPolicy, paths)
 		}
 
-		if sslRedirect, _ := annotations.IsSslRedirect(ingress); sslRedirect && listenerAzConfig.Protocol == n.HTTP {
+		if IsSslRedirect(ingress, cbCtx.EnvVariables) && listenerAzConfig.Protocol == n.HTTP && !isSslRedirectExcluded(cbCtx, ingress, path.Path) {
 			targetListener := listenerID
-			targetListener.FrontendPort = 443
+			targetListener.FrontendPort = c.getHTTPSListenerPort(ingress, cbCtx.EnvVariables)
 
 			// We could end up in a situation where we are attempting to attach a redirect, which does not exist.
 			redirectRef := c.getSslRedirectConfigResourceReference(targetListener)
@@ -345,6 +380,37 @@ func (c *appGwConfigBuilder) getPathRules(cbCtx *ConfigBuilderContext, listenerI
 	return &pathRules
 }
 
+// IsSslRedirect determines whether the HTTP listeners of the ingress redirect to HTTPS: as the ssl-redirect annotation
+// sets, or, for an ingress with TLS and without the annotation, when SSL redirect is enabled for the whole cluster.
+func IsSslRedirect(ingress *v1beta1.Ingress, env environment.EnvVariables) bool {
+	if sslRedirect, err := annotations.IsSslRedirect(ingress); err == nil {
+		return sslRedirect
+	} else if !annotations.IsMissingAnnotations(err) {
+		return false
+	}
+	return env.DefaultSslRedirect && len(ingress.Spec.TLS) > 0
+}
+
+// isSslRedirectExcluded determines whether the given path has been excluded from the SSL redirect with the
+// ssl-redirect-exclude-paths annotation. The paths are compared as the path rules hold them, canonicalized unless path
+// normalization is turned off.
+func isSslRedirectExcluded(cbCtx *ConfigBuilderContext, ingress *v1beta1.Ingress, path string) bool {
+	excludedPaths, err := annotations.SslRedirectExcludePaths(ingress)
+	if err != nil {
+		return false
+	}
+	path = ingressPath(cbCtx, ingress, path)
+	for _, excludedPath := range excludedPaths {
+		if cbCtx.EnvVariables.PathNormalization != environment.PathNormalizationNone {
+			excludedPath = canonicalPath(excludedPath)
+		}
+		if excludedPath == path {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *appGwConfigBuilder) mergePathMap(existingPathMap *n.ApplicationGatewayURLPathMap, pathMapToMerge *n.ApplicationGatewayURLPathMap, cbCtx *ConfigBuilderContext) *n.ApplicationGatewayURLPathMap {
 	if pathMapToMerge.DefaultBackendAddressPool != nil && *pathMapToMerge.DefaultBackendAddressPool.ID != *cbCtx.DefaultAddressPoolID {
 		existingPathMap.DefaultBackendAddressPool = pathMapToMerge.DefaultBackendAddressPool
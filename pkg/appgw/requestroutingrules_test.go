@@ -6,6 +6,9 @@
 package appgw
 
 import (
+	"strings"
+	"time"
+
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/utils"
 	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
 	"github.com/Azure/go-autorest/autorest/to"
@@ -13,8 +16,12 @@ import (
 	. "github.com/onsi/gomega"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
 
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests/fixtures"
 )
@@ -228,6 +235,142 @@ var _ = Describe("Test routing rules generations", func() {
 		})
 	})
 
+	Context("test ssl redirect is skipped for paths excluded with ssl-redirect-exclude-paths", func() {
+		configBuilder := newConfigBuilderFixture(nil)
+		endpoint := tests.NewEndpointsFixture()
+		service := tests.NewServiceFixture(*tests.NewServicePortsFixture()...)
+		ingress := tests.NewIngressFixture()
+		ingress.Annotations[annotations.SslRedirectExcludePathsKey] = tests.URLPath1
+
+		_ = configBuilder.k8sContext.Caches.Endpoints.Add(endpoint)
+		_ = configBuilder.k8sContext.Caches.Service.Add(service)
+		_ = configBuilder.k8sContext.Caches.Ingress.Add(ingress)
+
+		cbCtx := &ConfigBuilderContext{
+			IngressList:           []*v1beta1.Ingress{ingress},
+			ServiceList:           []*v1.Service{service},
+			DefaultAddressPoolID:  to.StringPtr("xx"),
+			DefaultHTTPSettingsID: to.StringPtr("yy"),
+		}
+
+		_ = configBuilder.BackendHTTPSettingsCollection(cbCtx)
+		_ = configBuilder.BackendAddressPools(cbCtx)
+		_ = configBuilder.Listeners(cbCtx)
+
+		rule := &ingress.Spec.Rules[0]
+		pathMap := configBuilder.getPathMaps(cbCtx)
+		listenerID := generateListenerID(ingress, rule, n.HTTP, nil, false)
+
+		expectedListenerID, _ := newTestListenerID(Port(443), []string{rule.Host}, false)
+		expectedRedirectID := configBuilder.appGwIdentifier.redirectConfigurationID(
+			generateSSLRedirectConfigurationName(expectedListenerID))
+
+		It("should still redirect the default path", func() {
+			Expect(pathMap[listenerID].DefaultRedirectConfiguration).ToNot(BeNil())
+			Expect(*pathMap[listenerID].DefaultRedirectConfiguration.ID).To(Equal(expectedRedirectID))
+		})
+
+		It("should serve the excluded path over HTTP and redirect the other one", func() {
+			Expect(len(*pathMap[listenerID].PathRules)).To(Equal(2))
+			for _, pathRule := range *pathMap[listenerID].PathRules {
+				if (*pathRule.Paths)[0] == tests.URLPath1 {
+					Expect(pathRule.RedirectConfiguration).To(BeNil())
+					Expect(pathRule.BackendAddressPool).ToNot(BeNil())
+					Expect(pathRule.BackendHTTPSettings).ToNot(BeNil())
+				} else {
+					Expect((*pathRule.Paths)[0]).To(Equal(tests.URLPath2))
+					Expect(pathRule.RedirectConfiguration).ToNot(BeNil())
+					Expect(*pathRule.RedirectConfiguration.ID).To(Equal(expectedRedirectID))
+					Expect(pathRule.BackendAddressPool).To(BeNil())
+					Expect(pathRule.BackendHTTPSettings).To(BeNil())
+				}
+			}
+		})
+	})
+
+	Context("test ssl-redirect-exclude-paths matches the canonical paths", func() {
+		configBuilder := newConfigBuilderFixture(nil)
+		endpoint := tests.NewEndpointsFixture()
+		service := tests.NewServiceFixture(*tests.NewServicePortsFixture()...)
+		ingress := tests.NewIngressFixture()
+		// The path rule of "api1" holds "/api1", and the one of "/api2" is excluded by "//api2".
+		ingress.Spec.Rules[0].HTTP.Paths[0].Path = strings.TrimPrefix(tests.URLPath1, "/")
+		ingress.Annotations[annotations.SslRedirectExcludePathsKey] = tests.URLPath1 + ", /" + tests.URLPath2
+
+		_ = configBuilder.k8sContext.Caches.Endpoints.Add(endpoint)
+		_ = configBuilder.k8sContext.Caches.Service.Add(service)
+		_ = configBuilder.k8sContext.Caches.Ingress.Add(ingress)
+
+		cbCtx := &ConfigBuilderContext{
+			IngressList:           []*v1beta1.Ingress{ingress},
+			ServiceList:           []*v1.Service{service},
+			EnvVariables:          environment.GetFakeEnv(),
+			DefaultAddressPoolID:  to.StringPtr("xx"),
+			DefaultHTTPSettingsID: to.StringPtr("yy"),
+		}
+
+		_ = configBuilder.BackendHTTPSettingsCollection(cbCtx)
+		_ = configBuilder.BackendAddressPools(cbCtx)
+		_ = configBuilder.Listeners(cbCtx)
+
+		rule := &ingress.Spec.Rules[0]
+		pathMap := configBuilder.getPathMaps(cbCtx)
+		listenerID := generateListenerID(ingress, rule, n.HTTP, nil, false)
+
+		It("should serve both excluded paths over HTTP", func() {
+			Expect(len(*pathMap[listenerID].PathRules)).To(Equal(2))
+			for _, pathRule := range *pathMap[listenerID].PathRules {
+				Expect(*pathRule.Paths).To(Or(Equal([]string{tests.URLPath1}), Equal([]string{tests.URLPath2})))
+				Expect(pathRule.RedirectConfiguration).To(BeNil())
+				Expect(pathRule.BackendAddressPool).ToNot(BeNil())
+			}
+		})
+	})
+
+	Context("test ssl redirect is skipped for the default path when it is excluded", func() {
+		configBuilder := newConfigBuilderFixture(nil)
+		endpoint := tests.NewEndpointsFixture()
+		service := tests.NewServiceFixture(*tests.NewServicePortsFixture()...)
+		ingress := tests.NewIngressFixture()
+		// A single rule with a catch-all path and one more path
+		ingress.Spec.Rules[0].HTTP.Paths[0].Path = "/"
+		ingress.Spec.Rules[0].HTTP.Paths = append(ingress.Spec.Rules[0].HTTP.Paths, ingress.Spec.Rules[1].HTTP.Paths...)
+		ingress.Spec.Rules = ingress.Spec.Rules[:1]
+		ingress.Annotations[annotations.SslRedirectExcludePathsKey] = "/, /does-not-exist"
+
+		_ = configBuilder.k8sContext.Caches.Endpoints.Add(endpoint)
+		_ = configBuilder.k8sContext.Caches.Service.Add(service)
+		_ = configBuilder.k8sContext.Caches.Ingress.Add(ingress)
+
+		cbCtx := &ConfigBuilderContext{
+			IngressList:           []*v1beta1.Ingress{ingress},
+			ServiceList:           []*v1.Service{service},
+			DefaultAddressPoolID:  to.StringPtr("xx"),
+			DefaultHTTPSettingsID: to.StringPtr("yy"),
+		}
+
+		_ = configBuilder.BackendHTTPSettingsCollection(cbCtx)
+		_ = configBuilder.BackendAddressPools(cbCtx)
+		_ = configBuilder.Listeners(cbCtx)
+
+		rule := &ingress.Spec.Rules[0]
+		pathMap := configBuilder.getPathMaps(cbCtx)
+		listenerID := generateListenerID(ingress, rule, n.HTTP, nil, false)
+
+		It("should have a default backend instead of a default redirect", func() {
+			Expect(pathMap[listenerID].DefaultRedirectConfiguration).To(BeNil())
+			Expect(pathMap[listenerID].DefaultBackendAddressPool).ToNot(BeNil())
+			Expect(pathMap[listenerID].DefaultBackendHTTPSettings).ToNot(BeNil())
+		})
+
+		It("should redirect the path which is not excluded", func() {
+			Expect(len(*pathMap[listenerID].PathRules)).To(Equal(1))
+			pathRule := (*pathMap[listenerID].PathRules)[0]
+			Expect(*pathRule.Paths).To(Equal([]string{tests.URLPath2}))
+			Expect(pathRule.RedirectConfiguration).ToNot(BeNil())
+		})
+	})
+
 	Context("test ssl redirect is configured correctly when a basic rule is created", func() {
 		configBuilder := newConfigBuilderFixture(nil)
 		secret := tests.NewSecretTestFixture()
@@ -364,4 +507,228 @@ var _ = Describe("Test routing rules generations", func() {
 			Expect(len(*configBuilder.appGw.URLPathMaps)).To(Equal(0))
 		})
 	})
+
+	Context("test hostless ingress rule combined with host-specific rules on the same port", func() {
+		configBuilder := newConfigBuilderFixture(nil)
+		endpoint := tests.NewEndpointsFixture()
+		service := tests.NewServiceFixture(*tests.NewServicePortsFixture()...)
+		be80 := tests.NewIngressBackendFixture(tests.ServiceName, 80)
+		ingress := tests.NewIngressFixture()
+		ingress.Annotations[annotations.SslRedirectKey] = "false"
+		ingress.Spec.TLS = nil
+		ingress.Spec.Rules = []v1beta1.IngressRule{
+			tests.NewIngressRuleFixture("", "/", *be80),
+			tests.NewIngressRuleFixture("a.contoso.com", tests.URLPath1, *be80),
+			tests.NewIngressRuleFixture("b.contoso.com", tests.URLPath2, *be80),
+			tests.NewIngressRuleFixture("c.contoso.com", tests.URLPath3, *be80),
+		}
+
+		_ = configBuilder.k8sContext.Caches.Endpoints.Add(endpoint)
+		_ = configBuilder.k8sContext.Caches.Service.Add(service)
+		_ = configBuilder.k8sContext.Caches.Ingress.Add(ingress)
+
+		cbCtx := &ConfigBuilderContext{
+			IngressList:           []*v1beta1.Ingress{ingress},
+			ServiceList:           []*v1.Service{service},
+			DefaultAddressPoolID:  to.StringPtr("xx"),
+			DefaultHTTPSettingsID: to.StringPtr("yy"),
+		}
+
+		_ = configBuilder.BackendHTTPSettingsCollection(cbCtx)
+		_ = configBuilder.BackendAddressPools(cbCtx)
+		_ = configBuilder.Listeners(cbCtx)
+		_ = configBuilder.RequestRoutingRules(cbCtx)
+
+		hostlessListenerID := generateListenerID(ingress, &ingress.Spec.Rules[0], n.HTTP, nil, false)
+		hostlessListenerName := generateListenerName(hostlessListenerID)
+
+		It("should create a catch-all listener without a host name next to the host-specific ones", func() {
+			Expect(len(*configBuilder.appGw.HTTPListeners)).To(Equal(4))
+			Expect(len(*configBuilder.appGw.FrontendPorts)).To(Equal(1))
+			for _, listener := range *configBuilder.appGw.HTTPListeners {
+				if *listener.Name == hostlessListenerName {
+					Expect(listener.HostName).To(BeNil())
+					Expect(listener.Hostnames).To(BeNil())
+				} else {
+					Expect(listener.HostName).ToNot(BeNil())
+				}
+			}
+		})
+
+		It("should route the hostless rule to its backend", func() {
+			path := &ingress.Spec.Rules[0].HTTP.Paths[0]
+			pool := configBuilder.newBackendPoolMap(cbCtx)[generateBackendID(ingress, &ingress.Spec.Rules[0], path, &path.Backend)]
+			Expect(pool).ToNot(BeNil())
+			for _, rule := range *configBuilder.appGw.RequestRoutingRules {
+				if *rule.HTTPListener.ID == configBuilder.appGwIdentifier.listenerID(hostlessListenerName) {
+					Expect(rule.RuleType).To(Equal(n.Basic))
+					Expect(*rule.BackendAddressPool.ID).To(Equal(*pool.ID))
+				}
+			}
+		})
+
+		It("should place the rule of the catch-all listener after the host-specific rules", func() {
+			rules := *configBuilder.appGw.RequestRoutingRules
+			Expect(len(rules)).To(Equal(4))
+			Expect(*rules[len(rules)-1].HTTPListener.ID).To(Equal(configBuilder.appGwIdentifier.listenerID(hostlessListenerName)))
+		})
+	})
+
+	Context("test sortBasicListenerRulesLast", func() {
+		configBuilder := newConfigBuilderFixture(nil)
+		configBuilder.appGw.HTTPListeners = &[]n.ApplicationGatewayHTTPListener{
+			{
+				ID: to.StringPtr("basic"),
+				ApplicationGatewayHTTPListenerPropertiesFormat: &n.ApplicationGatewayHTTPListenerPropertiesFormat{},
+			},
+			{
+				ID: to.StringPtr("host"),
+				ApplicationGatewayHTTPListenerPropertiesFormat: &n.ApplicationGatewayHTTPListenerPropertiesFormat{
+					HostName: to.StringPtr(tests.Host),
+				},
+			},
+			{
+				ID: to.StringPtr("hosts"),
+				ApplicationGatewayHTTPListenerPropertiesFormat: &n.ApplicationGatewayHTTPListenerPropertiesFormat{
+					Hostnames: &[]string{tests.Host, tests.OtherHost},
+				},
+			},
+		}
+		newRule := func(name, listenerID string) n.ApplicationGatewayRequestRoutingRule {
+			return n.ApplicationGatewayRequestRoutingRule{
+				Name: to.StringPtr(name),
+				ApplicationGatewayRequestRoutingRulePropertiesFormat: &n.ApplicationGatewayRequestRoutingRulePropertiesFormat{
+					HTTPListener: resourceRef(listenerID),
+				},
+			}
+		}
+
+		It("should keep the name order within host-specific and catch-all rules", func() {
+			rules := []n.ApplicationGatewayRequestRoutingRule{
+				newRule("a", "basic"),
+				newRule("b", "host"),
+				newRule("c", "unknown"),
+				newRule("d", "hosts"),
+			}
+			configBuilder.sortBasicListenerRulesLast(rules)
+			var names []string
+			for _, rule := range rules {
+				names = append(names, *rule.Name)
+			}
+			Expect(names).To(Equal([]string{"b", "c", "d", "a"}))
+		})
+	})
+})
+
+var _ = Describe("Test deterministic resolution of conflicting ingresses", func() {
+	var configBuilder appGwConfigBuilder
+	var recorder *record.FakeRecorder
+	var older, newer *v1beta1.Ingress
+	var service *v1.Service
+
+	newIngress := func(namespace, name string, created time.Time) *v1beta1.Ingress {
+		ingress := tests.NewIngressFixture()
+		ingress.Namespace = namespace
+		ingress.Name = name
+		ingress.CreationTimestamp = metav1.NewTime(created)
+		ingress.Annotations[annotations.SslRedirectKey] = "false"
+		ingress.Spec.TLS = nil
+		return ingress
+	}
+
+	// Returns the names of the path rules generated for the HTTP listener of the conflicting ingresses.
+	pathRuleNames := func(ingresses ...*v1beta1.Ingress) []string {
+		configBuilder = newConfigBuilderFixture(nil)
+		recorder = record.NewFakeRecorder(100)
+		configBuilder.recorder = recorder
+		_ = configBuilder.k8sContext.Caches.Endpoints.Add(tests.NewEndpointsFixture())
+		_ = configBuilder.k8sContext.Caches.Service.Add(service)
+		cbCtx := &ConfigBuilderContext{
+			IngressList:           ingresses,
+			ServiceList:           []*v1.Service{service},
+			DefaultAddressPoolID:  to.StringPtr("xx"),
+			DefaultHTTPSettingsID: to.StringPtr("yy"),
+		}
+		pathMaps := configBuilder.getPathMaps(cbCtx)
+		listenerID := generateListenerID(ingresses[0], &ingresses[0].Spec.Rules[0], n.HTTP, nil, false)
+		Expect(pathMaps).To(HaveKey(listenerID))
+		var names []string
+		for _, pathRule := range *pathMaps[listenerID].PathRules {
+			names = append(names, *pathRule.Name)
+		}
+		return names
+	}
+
+	BeforeEach(func() {
+		service = tests.NewServiceFixture(*tests.NewServicePortsFixture()...)
+		created := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+		older = newIngress(tests.Namespace, "zzz-older", created)
+		newer = newIngress(tests.Namespace, "aaa-newer", created.Add(time.Hour))
+	})
+
+	Context("two ingresses defining the same host and paths", func() {
+		It("keeps the paths of the oldest ingress regardless of the order of the ingresses", func() {
+			expected := []string{
+				generatePathRuleName(tests.Namespace, "zzz-older", "0"),
+				generatePathRuleName(tests.Namespace, "zzz-older", "0"),
+			}
+			Expect(pathRuleNames(older, newer)).To(Equal(expected))
+			Expect(pathRuleNames(newer, older)).To(Equal(expected))
+		})
+
+		It("emits a Warning event on the losing ingress naming the winner", func() {
+			_ = pathRuleNames(newer, older)
+			var emitted []string
+			for len(recorder.Events) > 0 {
+				emitted = append(emitted, <-recorder.Events)
+			}
+			Expect(emitted).To(ContainElement(And(
+				ContainSubstring(string(events.ReasonConflictingIngress)),
+				ContainSubstring("Ingress "+tests.Namespace+"/aaa-newer defines path"),
+				ContainSubstring("Ingress "+tests.Namespace+"/zzz-older takes precedence"),
+			)))
+			for _, event := range emitted {
+				Expect(event).ToNot(ContainSubstring("Ingress " + tests.Namespace + "/zzz-older defines"))
+			}
+		})
+
+		It("emits each Warning event once per build", func() {
+			_ = pathRuleNames(newer, older)
+			emitted := len(recorder.Events)
+			Expect(emitted).ToNot(BeZero())
+
+			// The path maps are generated several times in a build.
+			_ = configBuilder.getPathMaps(&ConfigBuilderContext{
+				IngressList:           []*v1beta1.Ingress{newer, older},
+				ServiceList:           []*v1.Service{service},
+				DefaultAddressPoolID:  to.StringPtr("xx"),
+				DefaultHTTPSettingsID: to.StringPtr("yy"),
+			})
+			Expect(recorder.Events).To(HaveLen(emitted))
+		})
+
+		It("breaks ties on creationTimestamp by namespace, then by name", func() {
+			newer.CreationTimestamp = older.CreationTimestamp
+			expected := []string{
+				generatePathRuleName(tests.Namespace, "aaa-newer", "0"),
+				generatePathRuleName(tests.Namespace, "aaa-newer", "0"),
+			}
+			Expect(pathRuleNames(older, newer)).To(Equal(expected))
+
+			newer.Namespace = "zzz-namespace"
+			expected = []string{
+				generatePathRuleName(tests.Namespace, "zzz-older", "0"),
+				generatePathRuleName(tests.Namespace, "zzz-older", "0"),
+			}
+			Expect(pathRuleNames(newer, older)).To(Equal(expected))
+		})
+	})
+
+	Context("test sortIngressesByPrecedence", func() {
+		It("does not modify the given list", func() {
+			ingresses := []*v1beta1.Ingress{newer, older}
+			Expect(sortIngressesByPrecedence(ingresses)).To(Equal([]*v1beta1.Ingress{older, newer}))
+			Expect(ingresses).To(Equal([]*v1beta1.Ingress{newer, older}))
+		})
+	})
 })
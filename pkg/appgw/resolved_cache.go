@@ -0,0 +1,109 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"container/list"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// DefaultResolvedCacheSize is the number of entries a ResolvedCache keeps; one entry per service and target ports.
+const DefaultResolvedCacheSize = 4096
+
+// ResolvedCache memoizes what the config builder resolves from the Kubernetes resources, which is costly and rarely
+// changes between reconciles: the container probe of the pods of a service, found by scanning all the pods.
+// Each entry is valid for the versions of its inputs; a reconcile finding any of them changed resolves the entry again
+// and replaces it. Beyond the capacity the least recently used entries are evicted.
+// ResolvedCache outlives the config builder; it is shared by consecutive builds.
+type ResolvedCache struct {
+	sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	lru      *list.List
+}
+
+type resolvedEntry struct {
+	key     string
+	version string
+	value   interface{}
+}
+
+// NewResolvedCache creates a new ResolvedCache keeping up to capacity entries.
+func NewResolvedCache(capacity int) *ResolvedCache {
+	return &ResolvedCache{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+// Len returns the number of entries in the cache.
+func (rc *ResolvedCache) Len() int {
+	rc.Lock()
+	defer rc.Unlock()
+	return rc.lru.Len()
+}
+
+// get returns the value resolved for the key, when it was resolved from the same version of the inputs.
+func (rc *ResolvedCache) get(key, version string) (interface{}, bool) {
+	rc.Lock()
+	defer rc.Unlock()
+	element, exists := rc.entries[key]
+	if !exists {
+		return nil, false
+	}
+	entry := element.Value.(*resolvedEntry)
+	if entry.version != version {
+		// An input changed; the entry is replaced once it is resolved again.
+		return nil, false
+	}
+	rc.lru.MoveToFront(element)
+	return entry.value, true
+}
+
+func (rc *ResolvedCache) set(key, version string, value interface{}) {
+	rc.Lock()
+	defer rc.Unlock()
+	if element, exists := rc.entries[key]; exists {
+		element.Value = &resolvedEntry{key: key, version: version, value: value}
+		rc.lru.MoveToFront(element)
+		return
+	}
+	rc.entries[key] = rc.lru.PushFront(&resolvedEntry{key: key, version: version, value: value})
+	for rc.lru.Len() > rc.capacity {
+		oldest := rc.lru.Back()
+		rc.lru.Remove(oldest)
+		delete(rc.entries, oldest.Value.(*resolvedEntry).key)
+	}
+}
+
+// getCachedProbeForServiceContainer returns the container probe of the pods of the service for the target ports,
+// resolving it only when the service, or the pods it may select, changed since it was last resolved.
+func (c *appGwConfigBuilder) getCachedProbeForServiceContainer(service *v1.Service, targetPorts map[int32]interface{}) *v1.Probe {
+	if c.resolvedCache == nil {
+		return c.findProbeForServiceContainer(service, targetPorts)
+	}
+
+	var ports []string
+	for port := range targetPorts {
+		ports = append(ports, fmt.Sprint(port))
+	}
+	sort.Strings(ports)
+	key := fmt.Sprintf("probe/%s/%s/%s", service.Namespace, service.Name, strings.Join(ports, ","))
+	version := fmt.Sprintf("%s/%s/%d", service.UID, service.ResourceVersion, c.k8sContext.PodsGeneration(service.Namespace))
+
+	if probe, exists := c.resolvedCache.get(key, version); exists {
+		return probe.(*v1.Probe)
+	}
+	probe := c.findProbeForServiceContainer(service, targetPorts)
+	c.resolvedCache.set(key, version, probe)
+	return probe
+}
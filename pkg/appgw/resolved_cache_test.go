@@ -0,0 +1,189 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
+)
+
+var _ = Describe("resolved cache", func() {
+	Context("entries", func() {
+		It("are valid for the version they were resolved from", func() {
+			rc := NewResolvedCache(2)
+			rc.set("a", "1", "value")
+			value, exists := rc.get("a", "1")
+			Expect(exists).To(BeTrue())
+			Expect(value).To(Equal("value"))
+
+			_, exists = rc.get("a", "2")
+			Expect(exists).To(BeFalse())
+
+			rc.set("a", "2", "other")
+			value, exists = rc.get("a", "2")
+			Expect(exists).To(BeTrue())
+			Expect(value).To(Equal("other"))
+			Expect(rc.Len()).To(Equal(1))
+		})
+
+		It("are evicted beyond the capacity, least recently used first", func() {
+			rc := NewResolvedCache(2)
+			rc.set("a", "1", "a")
+			rc.set("b", "1", "b")
+			_, _ = rc.get("a", "1")
+			rc.set("c", "1", "c")
+
+			Expect(rc.Len()).To(Equal(2))
+			_, exists := rc.get("b", "1")
+			Expect(exists).To(BeFalse())
+			_, exists = rc.get("a", "1")
+			Expect(exists).To(BeTrue())
+			_, exists = rc.get("c", "1")
+			Expect(exists).To(BeTrue())
+		})
+	})
+
+	Context("health probes", func() {
+		var cb appGwConfigBuilder
+		var service *v1.Service
+		var pod *v1.Pod
+		var cbCtx *ConfigBuilderContext
+
+		probePaths := func() []string {
+			cb.mem = memoization{}
+			Expect(cb.HealthProbesCollection(cbCtx)).ToNot(HaveOccurred())
+			var paths []string
+			for _, probe := range *cb.appGw.Probes {
+				paths = append(paths, *probe.Path)
+			}
+			return paths
+		}
+
+		BeforeEach(func() {
+			cb = newConfigBuilderFixture(nil)
+			cb.resolvedCache = NewResolvedCache(DefaultResolvedCacheSize)
+
+			service = tests.NewServiceFixture(*tests.NewServicePortsFixture()...)
+			service.UID = "uid"
+			service.ResourceVersion = "1"
+			_ = cb.k8sContext.Caches.Service.Add(service)
+			_ = cb.k8sContext.Caches.Endpoints.Add(tests.NewEndpointsFixture())
+
+			pod = tests.NewPodFixture(tests.ServiceName, tests.Namespace, tests.ContainerName, tests.ContainerPort)
+			_ = cb.k8sContext.Caches.Pods.Add(pod)
+
+			cbCtx = &ConfigBuilderContext{
+				IngressList:           []*v1beta1.Ingress{tests.NewIngressFixture()},
+				ServiceList:           []*v1.Service{service},
+				EnvVariables:          environment.GetFakeEnv(),
+				DefaultAddressPoolID:  to.StringPtr("xx"),
+				DefaultHTTPSettingsID: to.StringPtr("yy"),
+			}
+		})
+
+		It("reuses the probes of the pods while the service is unchanged", func() {
+			Expect(probePaths()).To(ContainElement(tests.HealthPath))
+			Expect(cb.resolvedCache.Len()).To(Equal(1))
+
+			pod.Spec.Containers[0].ReadinessProbe.HTTPGet.Path = "/cached"
+			paths := probePaths()
+			Expect(paths).To(ContainElement(tests.HealthPath))
+			Expect(paths).ToNot(ContainElement("/cached"))
+		})
+
+		It("resolves the probes again once the service changed", func() {
+			Expect(probePaths()).To(ContainElement(tests.HealthPath))
+
+			pod.Spec.Containers[0].ReadinessProbe.HTTPGet.Path = "/changed"
+			service.ResourceVersion = "2"
+			paths := probePaths()
+			Expect(paths).To(ContainElement("/changed"))
+			Expect(paths).ToNot(ContainElement(tests.HealthPath))
+			Expect(cb.resolvedCache.Len()).To(Equal(1))
+		})
+	})
+})
+
+// newLargeClusterFixture makes a builder for a cluster with an ingress for each of the services, each selecting its pods.
+func newLargeClusterFixture(serviceCount, podsPerService int) (appGwConfigBuilder, *ConfigBuilderContext) {
+	cb := newConfigBuilderFixture(nil)
+	cbCtx := &ConfigBuilderContext{
+		EnvVariables:          environment.GetFakeEnv(),
+		DefaultAddressPoolID:  to.StringPtr("xx"),
+		DefaultHTTPSettingsID: to.StringPtr("yy"),
+	}
+	for svcIdx := 0; svcIdx < serviceCount; svcIdx++ {
+		name := fmt.Sprintf("service-%d", svcIdx)
+		service := tests.NewServiceFixture(*tests.NewServicePortsFixture()...)
+		service.Name = name
+		service.UID = types.UID(name)
+		service.ResourceVersion = "1"
+		service.Spec.Selector = map[string]string{"app": name}
+		_ = cb.k8sContext.Caches.Service.Add(service)
+		cbCtx.ServiceList = append(cbCtx.ServiceList, service)
+
+		endpoints := tests.NewEndpointsFixture()
+		endpoints.Name = name
+		_ = cb.k8sContext.Caches.Endpoints.Add(endpoints)
+
+		for podIdx := 0; podIdx < podsPerService; podIdx++ {
+			pod := tests.NewPodFixture(name, tests.Namespace, tests.ContainerName, tests.ContainerPort)
+			pod.Name = fmt.Sprintf("%s-%d", name, podIdx)
+			pod.Labels = map[string]string{"app": name}
+			_ = cb.k8sContext.Caches.Pods.Add(pod)
+		}
+
+		backend := tests.NewIngressBackendFixture(name, 80)
+		cbCtx.IngressList = append(cbCtx.IngressList, &v1beta1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: tests.Namespace,
+				Annotations: map[string]string{
+					annotations.IngressClassKey: annotations.ApplicationGatewayIngressClass,
+				},
+			},
+			Spec: v1beta1.IngressSpec{
+				Rules: []v1beta1.IngressRule{
+					tests.NewIngressRuleFixture(fmt.Sprintf("%s.contoso.com", name), tests.URLPath1, *backend),
+				},
+			},
+		})
+	}
+	return cb, cbCtx
+}
+
+// BenchmarkResolvedCache measures the health probes of successive reconciles of a large cluster, in which a single
+// ingress changes between reconciles.
+func BenchmarkResolvedCache(b *testing.B) {
+	run := func(b *testing.B, resolvedCache *ResolvedCache) {
+		cb, cbCtx := newLargeClusterFixture(200, 20)
+		cb.resolvedCache = resolvedCache
+		_ = cb.HealthProbesCollection(cbCtx)
+
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			changed := cbCtx.ServiceList[i%len(cbCtx.ServiceList)]
+			changed.ResourceVersion = fmt.Sprint(i + 2)
+			cb.mem = memoization{}
+			_ = cb.HealthProbesCollection(cbCtx)
+		}
+	}
+
+	b.Run("uncached", func(b *testing.B) { run(b, nil) })
+	b.Run("cached", func(b *testing.B) { run(b, NewResolvedCache(DefaultResolvedCacheSize)) })
+}
@@ -0,0 +1,282 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/golang/glog"
+	"k8s.io/api/extensions/v1beta1"
+
+	rwv1 "github.com/Azure/application-gateway-kubernetes-ingress/pkg/apis/azureapplicationgatewayrewrite/v1"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+)
+
+// rewriteQueryStringVariable is the App Gateway server variable holding the query string of the request.
+const rewriteQueryStringVariable = "var_query_string"
+
+// rewriteRequestMethodVariable is the App Gateway server variable holding the method of the request.
+const rewriteRequestMethodVariable = "var_request_method"
+
+// The rule sequence of the first rewrite rule; App Gateway evaluates the rules of a set in ascending sequence.
+const rewriteRuleSequenceStart = 100
+
+var headerNameValidator = regexp.MustCompile("^[!#$%&'*+.^_`|~0-9A-Za-z-]+$")
+
+// requestMethods are the HTTP methods a rewrite condition can match.
+var requestMethods = map[string]interface{}{
+	"GET":     nil,
+	"HEAD":    nil,
+	"POST":    nil,
+	"PUT":     nil,
+	"PATCH":   nil,
+	"DELETE":  nil,
+	"OPTIONS": nil,
+	"TRACE":   nil,
+	"CONNECT": nil,
+}
+
+// RewriteRuleSets generates a rewrite rule set for each AzureApplicationGatewayRewrite and attaches it to the request
+// routing rules, path maps and path rules of the listeners of its ingress. Rules redirecting to HTTPS are skipped,
+// as they never reach a backend. The host header rewrites of backend-host-port are attached to the rules left without
+// a rewrite. Rewrite rule sets AGIC does not own are kept.
+func (c *appGwConfigBuilder) RewriteRuleSets(cbCtx *ConfigBuilderContext) {
+	var ruleSets []n.ApplicationGatewayRewriteRuleSet
+
+	// A routing rule can only have one rewrite rule set; the first rewrite (by namespace and name) wins.
+	attachedTo := make(map[listenerIdentifier]*rwv1.AzureApplicationGatewayRewrite)
+	for _, rewrite := range cbCtx.Rewrites {
+		ruleSet, err := c.getRewriteRuleSet(rewrite)
+		if err != nil {
+			events.Warning(c.recorder, rewrite, events.ReasonInvalidRewrite, err.Error())
+			glog.Errorf("Rewrite %s/%s: %s", rewrite.Namespace, rewrite.Name, err)
+			continue
+		}
+
+		ingress := findIngress(cbCtx.IngressList, rewrite.Namespace, rewrite.Spec.Ingress)
+		if ingress == nil {
+			msg := fmt.Sprintf("Ingress %s/%s of the rewrite does not exist or is not handled by AGIC", rewrite.Namespace, rewrite.Spec.Ingress)
+			events.Warning(c.recorder, rewrite, events.ReasonInvalidRewrite, msg)
+			glog.Error(msg)
+			continue
+		}
+
+		rewriteListeners := c.getRewriteListeners(cbCtx, ingress, rewrite.Spec.Host)
+		if len(rewriteListeners) == 0 {
+			msg := fmt.Sprintf("Ingress %s/%s of the rewrite has no listener for host %q", rewrite.Namespace, rewrite.Spec.Ingress, rewrite.Spec.Host)
+			events.Warning(c.recorder, rewrite, events.ReasonInvalidRewrite, msg)
+			glog.Error(msg)
+			continue
+		}
+
+		var listenerIDs []listenerIdentifier
+		for _, listenerID := range rewriteListeners {
+			if other, exists := attachedTo[listenerID]; exists {
+				msg := fmt.Sprintf("Listener for host %q on port %d already has the rewrite %s/%s", listenerID.HostName, listenerID.FrontendPort, other.Namespace, other.Name)
+				events.Warning(c.recorder, rewrite, events.ReasonInvalidRewrite, msg)
+				glog.Error(msg)
+				continue
+			}
+			attachedTo[listenerID] = rewrite
+			listenerIDs = append(listenerIDs, listenerID)
+		}
+		if len(listenerIDs) == 0 {
+			continue
+		}
+
+		c.attachRewriteRuleSet(listenerIDs, resourceRef(*ruleSet.ID))
+		ruleSets = append(ruleSets, *ruleSet)
+	}
+
+	ruleSets = append(ruleSets, c.getHostPortRewriteRuleSets(cbCtx)...)
+
+	if c.appGw.RewriteRuleSets != nil {
+		for _, ruleSet := range *c.appGw.RewriteRuleSets {
+			if !isOwnedResource(ruleSet.Name) {
+				ruleSets = append(ruleSets, ruleSet)
+			}
+		}
+	}
+
+	if len(ruleSets) == 0 && c.appGw.RewriteRuleSets == nil {
+		return
+	}
+	sort.Slice(ruleSets, func(i, j int) bool {
+		return *ruleSets[i].Name < *ruleSets[j].Name
+	})
+	c.appGw.RewriteRuleSets = &ruleSets
+}
+
+// getRewriteRuleSet validates the rewrite and converts it to a rewrite rule set.
+func (c *appGwConfigBuilder) getRewriteRuleSet(rewrite *rwv1.AzureApplicationGatewayRewrite) (*n.ApplicationGatewayRewriteRuleSet, error) {
+	var rules []n.ApplicationGatewayRewriteRule
+	for idx, rule := range rewrite.Spec.Rules {
+		if rule.Actions.URLPath != "" {
+			return nil, ErrRewriteURLPathNotSupported
+		}
+
+		var conditions []n.ApplicationGatewayRewriteRuleCondition
+		for _, condition := range rule.Conditions {
+			rewriteCondition, err := getRewriteCondition(condition)
+			if err != nil {
+				return nil, err
+			}
+			conditions = append(conditions, *rewriteCondition)
+		}
+
+		var headers []n.ApplicationGatewayHeaderConfiguration
+		for _, header := range rule.Actions.RequestHeaders {
+			if !headerNameValidator.MatchString(header.Name) {
+				return nil, ErrInvalidRewriteHeader
+			}
+			headers = append(headers, n.ApplicationGatewayHeaderConfiguration{
+				HeaderName:  to.StringPtr(header.Name),
+				HeaderValue: to.StringPtr(header.Value),
+			})
+		}
+
+		rules = append(rules, n.ApplicationGatewayRewriteRule{
+			Name:         to.StringPtr(rule.Name),
+			RuleSequence: to.Int32Ptr(int32(rewriteRuleSequenceStart + idx)),
+			Conditions:   &conditions,
+			ActionSet: &n.ApplicationGatewayRewriteRuleActionSet{
+				RequestHeaderConfigurations: &headers,
+			},
+		})
+	}
+
+	name := generateRewriteRuleSetName(rewrite.Namespace, rewrite.Name)
+	return &n.ApplicationGatewayRewriteRuleSet{
+		Etag: to.StringPtr("*"),
+		Name: to.StringPtr(name),
+		ID:   to.StringPtr(c.appGwIdentifier.rewriteRuleSetID(name)),
+		ApplicationGatewayRewriteRuleSetPropertiesFormat: &n.ApplicationGatewayRewriteRuleSetPropertiesFormat{
+			RewriteRules: &rules,
+		},
+	}, nil
+}
+
+// getRewriteCondition validates the condition and converts it to a condition on the query string or on the method of
+// the request. The methods are matched as an anchored alternation, as App Gateway matches the pattern anywhere in the
+// server variable.
+func getRewriteCondition(condition rwv1.RewriteCondition) (*n.ApplicationGatewayRewriteRuleCondition, error) {
+	if (condition.QueryString == "") == (len(condition.RequestMethods) == 0) {
+		return nil, ErrInvalidRewriteCondition
+	}
+
+	if condition.QueryString != "" {
+		if _, err := regexp.Compile(condition.QueryString); err != nil {
+			return nil, ErrInvalidRewriteCondition
+		}
+		return &n.ApplicationGatewayRewriteRuleCondition{
+			Variable:   to.StringPtr(rewriteQueryStringVariable),
+			Pattern:    to.StringPtr(condition.QueryString),
+			IgnoreCase: to.BoolPtr(condition.IgnoreCase),
+			Negate:     to.BoolPtr(condition.Negate),
+		}, nil
+	}
+
+	for _, method := range condition.RequestMethods {
+		if _, exists := requestMethods[method]; !exists {
+			return nil, ErrInvalidRewriteMethod
+		}
+	}
+	return &n.ApplicationGatewayRewriteRuleCondition{
+		Variable:   to.StringPtr(rewriteRequestMethodVariable),
+		Pattern:    to.StringPtr(fmt.Sprintf("^(%s)$", strings.Join(condition.RequestMethods, "|"))),
+		IgnoreCase: to.BoolPtr(false),
+		Negate:     to.BoolPtr(condition.Negate),
+	}, nil
+}
+
+// getRewriteListeners returns the listeners of the ingress, limited to the listeners of the given host when it is set.
+func (c *appGwConfigBuilder) getRewriteListeners(cbCtx *ConfigBuilderContext, ingress *v1beta1.Ingress, host string) []listenerIdentifier {
+	listeners := c.getListenersFromIngress(ingress, cbCtx.EnvVariables)
+	if len(ingress.Spec.Rules) == 0 && ingress.Spec.Backend != nil {
+		listeners[defaultFrontendListenerIdentifier(cbCtx.EnvVariables)] = listenerAzConfig{}
+	}
+
+	var listenerIDs []listenerIdentifier
+	for listenerID := range listeners {
+		if host != "" && !listenerHasHost(listenerID, host) {
+			continue
+		}
+		listenerIDs = append(listenerIDs, listenerID)
+	}
+	sort.Slice(listenerIDs, func(i, j int) bool {
+		return generateListenerName(listenerIDs[i]) < generateListenerName(listenerIDs[j])
+	})
+	return listenerIDs
+}
+
+// attachRewriteRuleSet references the rewrite rule set from the routing rules of the listeners and their path maps.
+func (c *appGwConfigBuilder) attachRewriteRuleSet(listenerIDs []listenerIdentifier, ruleSetRef *n.SubResource) {
+	ruleNames := make(map[string]interface{})
+	pathMapNames := make(map[string]interface{})
+	for _, listenerID := range listenerIDs {
+		ruleNames[generateRequestRoutingRuleName(listenerID)] = nil
+		pathMapNames[generateURLPathMapName(listenerID)] = nil
+	}
+
+	if c.appGw.RequestRoutingRules != nil {
+		for idx := range *c.appGw.RequestRoutingRules {
+			rule := &(*c.appGw.RequestRoutingRules)[idx]
+			if _, exists := ruleNames[*rule.Name]; !exists || rule.ApplicationGatewayRequestRoutingRulePropertiesFormat == nil {
+				continue
+			}
+			if rule.RuleType == n.Basic && rule.RedirectConfiguration == nil {
+				rule.RewriteRuleSet = ruleSetRef
+			}
+		}
+	}
+
+	if c.appGw.URLPathMaps != nil {
+		for idx := range *c.appGw.URLPathMaps {
+			pathMap := &(*c.appGw.URLPathMaps)[idx]
+			if _, exists := pathMapNames[*pathMap.Name]; !exists || pathMap.ApplicationGatewayURLPathMapPropertiesFormat == nil {
+				continue
+			}
+			if pathMap.DefaultRedirectConfiguration == nil {
+				pathMap.DefaultRewriteRuleSet = ruleSetRef
+			}
+			if pathMap.PathRules == nil {
+				continue
+			}
+			for ruleIdx := range *pathMap.PathRules {
+				pathRule := &(*pathMap.PathRules)[ruleIdx]
+				if pathRule.ApplicationGatewayPathRulePropertiesFormat != nil && pathRule.RedirectConfiguration == nil {
+					pathRule.RewriteRuleSet = ruleSetRef
+				}
+			}
+		}
+	}
+}
+
+func listenerHasHost(listenerID listenerIdentifier, host string) bool {
+	if listenerID.HostName == host {
+		return true
+	}
+	for _, hostName := range listenerID.HostNames {
+		if hostName == host {
+			return true
+		}
+	}
+	return false
+}
+
+func findIngress(ingressList []*v1beta1.Ingress, namespace, name string) *v1beta1.Ingress {
+	for _, ingress := range ingressList {
+		if ingress.Namespace == namespace && ingress.Name == name {
+			return ingress
+		}
+	}
+	return nil
+}
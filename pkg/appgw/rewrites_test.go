@@ -0,0 +1,264 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	rwv1 "github.com/Azure/application-gateway-kubernetes-ingress/pkg/apis/azureapplicationgatewayrewrite/v1"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
+)
+
+var _ = Describe("Test AzureApplicationGatewayRewrite", func() {
+	var configBuilder appGwConfigBuilder
+	var recorder *record.FakeRecorder
+	var ingress *v1beta1.Ingress
+	var rewrite *rwv1.AzureApplicationGatewayRewrite
+	var cbCtx *ConfigBuilderContext
+
+	build := func() {
+		Expect(configBuilder.BackendHTTPSettingsCollection(cbCtx)).ToNot(HaveOccurred())
+		Expect(configBuilder.BackendAddressPools(cbCtx)).ToNot(HaveOccurred())
+		Expect(configBuilder.Listeners(cbCtx)).ToNot(HaveOccurred())
+		Expect(configBuilder.RequestRoutingRules(cbCtx)).ToNot(HaveOccurred())
+		configBuilder.RewriteRuleSets(cbCtx)
+	}
+
+	// rewrittenHosts returns the hosts of the listeners whose routing rule, path map and path rules have the rule set.
+	rewrittenHosts := func(ruleSetName string) []string {
+		ruleSetID := configBuilder.appGwIdentifier.rewriteRuleSetID(ruleSetName)
+		listenerHosts := make(map[string]string)
+		for _, listener := range *configBuilder.appGw.HTTPListeners {
+			listenerHosts[*listener.ID] = *listener.HostName
+		}
+		pathMaps := make(map[string]n.ApplicationGatewayURLPathMap)
+		for _, pathMap := range *configBuilder.appGw.URLPathMaps {
+			pathMaps[*pathMap.ID] = pathMap
+		}
+
+		var hosts []string
+		for _, rule := range *configBuilder.appGw.RequestRoutingRules {
+			if rule.RuleType == n.Basic {
+				if rule.RewriteRuleSet != nil && *rule.RewriteRuleSet.ID == ruleSetID {
+					hosts = append(hosts, listenerHosts[*rule.HTTPListener.ID])
+				}
+				continue
+			}
+			pathMap := pathMaps[*rule.URLPathMap.ID]
+			if pathMap.DefaultRewriteRuleSet == nil || *pathMap.DefaultRewriteRuleSet.ID != ruleSetID {
+				continue
+			}
+			for _, pathRule := range *pathMap.PathRules {
+				Expect(pathRule.RewriteRuleSet).ToNot(BeNil())
+				Expect(*pathRule.RewriteRuleSet.ID).To(Equal(ruleSetID))
+			}
+			hosts = append(hosts, listenerHosts[*rule.HTTPListener.ID])
+		}
+		return hosts
+	}
+
+	BeforeEach(func() {
+		configBuilder = newConfigBuilderFixture(nil)
+		recorder = record.NewFakeRecorder(100)
+		configBuilder.recorder = recorder
+
+		service := tests.NewServiceFixture(*tests.NewServicePortsFixture()...)
+		_ = configBuilder.k8sContext.Caches.Service.Add(service)
+		_ = configBuilder.k8sContext.Caches.Endpoints.Add(tests.NewEndpointsFixture())
+
+		ingress = tests.NewIngressFixture()
+		ingress.Spec.TLS = nil
+		delete(ingress.Annotations, annotations.SslRedirectKey)
+		ingress.Spec.Rules[1].Host = tests.OtherHost
+		_ = configBuilder.k8sContext.Caches.Ingress.Add(ingress)
+
+		rewrite = &rwv1.AzureApplicationGatewayRewrite{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: tests.Namespace,
+				Name:      "canary-hint",
+			},
+			Spec: rwv1.AzureApplicationGatewayRewriteSpec{
+				Ingress: tests.Name,
+				Rules: []rwv1.RewriteRule{
+					{
+						Name: "canary",
+						Conditions: []rwv1.RewriteCondition{
+							{QueryString: "(^|&)canary=true(&|$)", IgnoreCase: true},
+						},
+						Actions: rwv1.RewriteActions{
+							RequestHeaders: []rwv1.RewriteHeader{
+								{Name: "X-Canary", Value: "true"},
+							},
+						},
+					},
+					{
+						Name: "region",
+						Conditions: []rwv1.RewriteCondition{
+							{QueryString: "region=", Negate: true},
+						},
+						Actions: rwv1.RewriteActions{
+							RequestHeaders: []rwv1.RewriteHeader{
+								{Name: "X-Region", Value: "default"},
+							},
+						},
+					},
+				},
+			},
+		}
+
+		cbCtx = &ConfigBuilderContext{
+			IngressList:           []*v1beta1.Ingress{ingress},
+			ServiceList:           []*v1.Service{service},
+			Rewrites:              []*rwv1.AzureApplicationGatewayRewrite{rewrite},
+			EnvVariables:          environment.GetFakeEnv(),
+			DefaultAddressPoolID:  to.StringPtr(configBuilder.appGwIdentifier.AddressPoolID(DefaultBackendAddressPoolName)),
+			DefaultHTTPSettingsID: to.StringPtr(configBuilder.appGwIdentifier.HTTPSettingsID(DefaultBackendHTTPSettingsName)),
+			ExistingPortsByNumber: make(map[Port]n.ApplicationGatewayFrontendPort),
+		}
+	})
+
+	It("generates query string conditions and request header actions", func() {
+		build()
+		Expect(*configBuilder.appGw.RewriteRuleSets).To(HaveLen(1))
+		ruleSet := (*configBuilder.appGw.RewriteRuleSets)[0]
+		Expect(*ruleSet.Name).To(Equal(generateRewriteRuleSetName(tests.Namespace, "canary-hint")))
+
+		rules := *ruleSet.RewriteRules
+		Expect(rules).To(HaveLen(2))
+		Expect(*rules[0].Name).To(Equal("canary"))
+		Expect(*rules[0].RuleSequence).To(Equal(int32(100)))
+		Expect(*rules[0].Conditions).To(Equal([]n.ApplicationGatewayRewriteRuleCondition{
+			{
+				Variable:   to.StringPtr("var_query_string"),
+				Pattern:    to.StringPtr("(^|&)canary=true(&|$)"),
+				IgnoreCase: to.BoolPtr(true),
+				Negate:     to.BoolPtr(false),
+			},
+		}))
+		Expect(*rules[0].ActionSet.RequestHeaderConfigurations).To(Equal([]n.ApplicationGatewayHeaderConfiguration{
+			{HeaderName: to.StringPtr("X-Canary"), HeaderValue: to.StringPtr("true")},
+		}))
+		Expect(*rules[1].RuleSequence).To(Equal(int32(101)))
+		Expect(*(*rules[1].Conditions)[0].Negate).To(BeTrue())
+
+		Expect(rewrittenHosts(*ruleSet.Name)).To(ConsistOf(tests.Host, tests.OtherHost))
+	})
+
+	It("attaches the rule set only to the listener of the host", func() {
+		rewrite.Spec.Host = tests.OtherHost
+		build()
+		Expect(rewrittenHosts(generateRewriteRuleSetName(tests.Namespace, "canary-hint"))).To(Equal([]string{tests.OtherHost}))
+	})
+
+	It("rejects a query string, which is not a valid regular expression", func() {
+		rewrite.Spec.Rules[1].Conditions[0].QueryString = "region=("
+		build()
+		Expect(configBuilder.appGw.RewriteRuleSets).To(BeNil())
+		Expect(rewrittenHosts(generateRewriteRuleSetName(tests.Namespace, "canary-hint"))).To(BeEmpty())
+		Expect(recorder.Events).To(Receive(ContainSubstring("APPG027")))
+	})
+
+	It("generates request method conditions", func() {
+		rewrite.Spec.Host = tests.Host
+		rewrite.Spec.Rules = []rwv1.RewriteRule{
+			{
+				Name: "commands",
+				Conditions: []rwv1.RewriteCondition{
+					{RequestMethods: []string{"POST", "PUT", "DELETE"}},
+				},
+				Actions: rwv1.RewriteActions{
+					RequestHeaders: []rwv1.RewriteHeader{
+						{Name: "X-Backend", Value: "commands"},
+					},
+				},
+			},
+			{
+				Name: "queries",
+				Conditions: []rwv1.RewriteCondition{
+					{RequestMethods: []string{"GET", "HEAD"}},
+				},
+				Actions: rwv1.RewriteActions{
+					RequestHeaders: []rwv1.RewriteHeader{
+						{Name: "X-Backend", Value: "queries"},
+					},
+				},
+			},
+		}
+		build()
+
+		rules := *(*configBuilder.appGw.RewriteRuleSets)[0].RewriteRules
+		Expect(rules).To(HaveLen(2))
+		Expect(*rules[0].Conditions).To(Equal([]n.ApplicationGatewayRewriteRuleCondition{
+			{
+				Variable:   to.StringPtr("var_request_method"),
+				Pattern:    to.StringPtr("^(POST|PUT|DELETE)$"),
+				IgnoreCase: to.BoolPtr(false),
+				Negate:     to.BoolPtr(false),
+			},
+		}))
+		Expect(*(*rules[1].Conditions)[0].Pattern).To(Equal("^(GET|HEAD)$"))
+		Expect(rewrittenHosts(generateRewriteRuleSetName(tests.Namespace, "canary-hint"))).To(Equal([]string{tests.Host}))
+	})
+
+	It("rejects a request method, which is not an HTTP method", func() {
+		rewrite.Spec.Rules[0].Conditions = []rwv1.RewriteCondition{
+			{RequestMethods: []string{"GET", "get"}},
+		}
+		build()
+		Expect(configBuilder.appGw.RewriteRuleSets).To(BeNil())
+		Expect(recorder.Events).To(Receive(ContainSubstring("APPG038")))
+	})
+
+	It("rejects a condition on both the query string and the request method", func() {
+		rewrite.Spec.Rules[0].Conditions[0].RequestMethods = []string{"GET"}
+		build()
+		Expect(configBuilder.appGw.RewriteRuleSets).To(BeNil())
+		Expect(recorder.Events).To(Receive(ContainSubstring("APPG027")))
+	})
+
+	It("emits an event for a host, which has no listener of the ingress", func() {
+		rewrite.Spec.Host = "unknown.contoso.com"
+		build()
+		Expect(configBuilder.appGw.RewriteRuleSets).To(BeNil())
+		Expect(recorder.Events).To(Receive(ContainSubstring("unknown.contoso.com")))
+	})
+
+	It("rejects a URL path rewrite", func() {
+		rewrite.Spec.Rules[0].Actions.URLPath = "/canary"
+		build()
+		Expect(configBuilder.appGw.RewriteRuleSets).To(BeNil())
+		Expect(recorder.Events).To(Receive(ContainSubstring("APPG028")))
+	})
+
+	It("emits an event for an ingress, which does not exist", func() {
+		rewrite.Spec.Ingress = "missing-ingress"
+		build()
+		Expect(configBuilder.appGw.RewriteRuleSets).To(BeNil())
+		Expect(recorder.Events).To(Receive(ContainSubstring("missing-ingress")))
+	})
+
+	It("keeps the rewrite rule sets AGIC does not own", func() {
+		configBuilder.appGw.RewriteRuleSets = &[]n.ApplicationGatewayRewriteRuleSet{
+			{Name: to.StringPtr("manually-created")},
+			{Name: to.StringPtr(generateRewriteRuleSetName(tests.Namespace, "deleted-rewrite"))},
+		}
+		build()
+		var names []string
+		for _, ruleSet := range *configBuilder.appGw.RewriteRuleSets {
+			names = append(names, *ruleSet.Name)
+		}
+		Expect(names).To(ConsistOf("manually-created", generateRewriteRuleSetName(tests.Namespace, "canary-hint")))
+	})
+})
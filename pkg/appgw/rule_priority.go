@@ -0,0 +1,149 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/golang/glog"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/brownfield"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+)
+
+const (
+	// rulePriorityStep leaves room between the priorities AGIC assigns, for rules added out-of-band.
+	rulePriorityStep = 10
+
+	// maxRulePriority is the highest priority App Gateway accepts.
+	maxRulePriority = 20000
+)
+
+// setRulePriorities sets the priority of the request routing rules generated by AGIC according to the configured rule
+// evaluation. With priority evaluation, the rules are given ascending priorities in the order AGIC lists them, which keeps
+// the rules of catch-all listeners last; with classic evaluation, the rules have no priority.
+// A rule already on the gateway keeps its priority, as long as the order holds, so that adding a rule does not renumber
+// the others; new rules are given a free priority between their neighbours.
+// App Gateway rejects a config mixing both kinds of rules: rules, which AGIC does not generate (brownfield, other AGIC
+// instances, manage-backend-only ingresses), must already be of the configured kind.
+func (c *appGwConfigBuilder) setRulePriorities(cbCtx *ConfigBuilderContext, existing brownfield.ExistingResources) error {
+	if c.appGw.RequestRoutingRules == nil {
+		return nil
+	}
+	usePriority := cbCtx.EnvVariables.RoutingRuleEvaluation == environment.RoutingRuleEvaluationPriority
+
+	generated := make(map[string]interface{})
+	if c.mem.routingRules != nil {
+		for _, rule := range *c.mem.routingRules {
+			generated[*rule.Name] = nil
+		}
+	}
+
+	usedPriorities := make(map[int32]interface{})
+	rules := *c.appGw.RequestRoutingRules
+	var generatedIdx []int
+	for idx, rule := range rules {
+		if rule.ApplicationGatewayRequestRoutingRulePropertiesFormat == nil {
+			continue
+		}
+		if _, isGenerated := generated[*rule.Name]; isGenerated {
+			generatedIdx = append(generatedIdx, idx)
+			continue
+		}
+		hasPriority := rule.Priority != nil
+		if usePriority && !hasPriority {
+			glog.Errorf("Request routing rule %s has no priority", *rule.Name)
+			return ErrClassicRulesInPriorityMode
+		}
+		if !usePriority && hasPriority {
+			glog.Errorf("Request routing rule %s has priority %d", *rule.Name, *rule.Priority)
+			return ErrPriorityRulesInClassicMode
+		}
+		if hasPriority {
+			usedPriorities[*rule.Priority] = nil
+		}
+	}
+
+	var priorities []int32
+	if usePriority {
+		existingPriorities := make(map[string]int32)
+		for _, rule := range existing.RoutingRules {
+			if rule.ApplicationGatewayRequestRoutingRulePropertiesFormat != nil && rule.Priority != nil {
+				existingPriorities[*rule.Name] = *rule.Priority
+			}
+		}
+		names := make([]string, len(generatedIdx))
+		for i, idx := range generatedIdx {
+			names[i] = *rules[idx].Name
+		}
+		var ok bool
+		if priorities, ok = stableRulePriorities(names, existingPriorities, usedPriorities); !ok {
+			// There is no room left between the kept priorities; renumber all the rules.
+			glog.V(3).Info("Renumbering the priorities of the request routing rules")
+			if priorities, ok = stableRulePriorities(names, nil, usedPriorities); !ok {
+				return ErrGeneratingRoutingRules
+			}
+		}
+	}
+
+	for i, idx := range generatedIdx {
+		// Rules may be shared with the cached config; update a copy of the properties.
+		properties := *rules[idx].ApplicationGatewayRequestRoutingRulePropertiesFormat
+		properties.Priority = nil
+		if usePriority {
+			properties.Priority = to.Int32Ptr(priorities[i])
+		}
+		rules[idx].ApplicationGatewayRequestRoutingRulePropertiesFormat = &properties
+	}
+	return nil
+}
+
+// stableRulePriorities returns ascending priorities for the rules with the given names, none of them in used. A rule
+// keeps its priority in existing, when it is free and above the priority of the rules before it; any other rule is
+// given the next multiple of rulePriorityStep, or else the first free priority, below the next priority kept.
+// The second return value is false when there is no free priority left for a rule.
+func stableRulePriorities(names []string, existing map[string]int32, used map[int32]interface{}) ([]int32, bool) {
+	isFree := func(priority int32) bool {
+		_, isUsed := used[priority]
+		return !isUsed
+	}
+
+	priorities := make([]int32, len(names))
+	last := int32(0)
+	for i, name := range names {
+		if priority, exists := existing[name]; exists && priority > last && isFree(priority) {
+			priorities[i] = priority
+			last = priority
+			continue
+		}
+
+		// The new priority has to stay below the next rule keeping its priority.
+		upper := int32(maxRulePriority + 1)
+		for _, next := range names[i+1:] {
+			if priority, exists := existing[next]; exists && priority > last && isFree(priority) {
+				upper = priority
+				break
+			}
+		}
+
+		priority := (last/rulePriorityStep + 1) * rulePriorityStep
+		for priority < upper && !isFree(priority) {
+			priority += rulePriorityStep
+		}
+		if priority >= upper {
+			// No multiple of the step fits; take the first free priority.
+			priority = last + 1
+			for priority < upper && !isFree(priority) {
+				priority++
+			}
+		}
+		if priority >= upper {
+			return nil, false
+		}
+		priorities[i] = priority
+		last = priority
+	}
+	return priorities, true
+}
@@ -0,0 +1,174 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/brownfield"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+)
+
+var _ = Describe("Test request routing rule priorities", func() {
+	newRule := func(name string, priority *int32) n.ApplicationGatewayRequestRoutingRule {
+		return n.ApplicationGatewayRequestRoutingRule{
+			Name: to.StringPtr(name),
+			ApplicationGatewayRequestRoutingRulePropertiesFormat: &n.ApplicationGatewayRequestRoutingRulePropertiesFormat{
+				RuleType: n.Basic,
+				Priority: priority,
+			},
+		}
+	}
+
+	priorities := func(rules []n.ApplicationGatewayRequestRoutingRule) map[string]*int32 {
+		result := make(map[string]*int32)
+		for _, rule := range rules {
+			result[*rule.Name] = rule.Priority
+		}
+		return result
+	}
+
+	var configBuilder appGwConfigBuilder
+	var cbCtx *ConfigBuilderContext
+	var generated []n.ApplicationGatewayRequestRoutingRule
+	var existing brownfield.ExistingResources
+
+	// Sets the rules on the gateway: the generated rules, in the order AGIC lists them, and the given unmanaged rules.
+	setRules := func(unmanaged ...n.ApplicationGatewayRequestRoutingRule) {
+		configBuilder.mem.routingRules = &generated
+		rules := append(append([]n.ApplicationGatewayRequestRoutingRule{}, generated...), unmanaged...)
+		configBuilder.appGw.RequestRoutingRules = &rules
+	}
+
+	BeforeEach(func() {
+		configBuilder = newConfigBuilderFixture(nil)
+		cbCtx = &ConfigBuilderContext{
+			EnvVariables: environment.GetFakeEnv(),
+		}
+		generated = []n.ApplicationGatewayRequestRoutingRule{
+			newRule("rr-host-a", nil),
+			newRule("rr-host-b", nil),
+			newRule("rr-catch-all", nil),
+		}
+		existing = brownfield.ExistingResources{}
+	})
+
+	Context("priority evaluation", func() {
+		BeforeEach(func() {
+			cbCtx.EnvVariables.RoutingRuleEvaluation = environment.RoutingRuleEvaluationPriority
+		})
+
+		It("assigns ascending priorities in the order of the rules", func() {
+			setRules()
+			Expect(configBuilder.setRulePriorities(cbCtx, existing)).ToNot(HaveOccurred())
+			Expect(priorities(*configBuilder.appGw.RequestRoutingRules)).To(Equal(map[string]*int32{
+				"rr-host-a":    to.Int32Ptr(10),
+				"rr-host-b":    to.Int32Ptr(20),
+				"rr-catch-all": to.Int32Ptr(30),
+			}))
+			Expect(generated[0].Priority).To(BeNil(), "cached rules are not modified")
+		})
+
+		It("skips the priorities of the rules AGIC does not manage", func() {
+			setRules(newRule("portal-rule", to.Int32Ptr(20)))
+			Expect(configBuilder.setRulePriorities(cbCtx, existing)).ToNot(HaveOccurred())
+			Expect(priorities(*configBuilder.appGw.RequestRoutingRules)).To(Equal(map[string]*int32{
+				"rr-host-a":    to.Int32Ptr(10),
+				"rr-host-b":    to.Int32Ptr(30),
+				"rr-catch-all": to.Int32Ptr(40),
+				"portal-rule":  to.Int32Ptr(20),
+			}))
+		})
+
+		It("keeps the priorities of the rules already on the gateway", func() {
+			existing.RoutingRules = []n.ApplicationGatewayRequestRoutingRule{
+				newRule("rr-host-a", to.Int32Ptr(10)),
+				newRule("rr-catch-all", to.Int32Ptr(20)),
+			}
+			setRules()
+			Expect(configBuilder.setRulePriorities(cbCtx, existing)).ToNot(HaveOccurred())
+			Expect(priorities(*configBuilder.appGw.RequestRoutingRules)).To(Equal(map[string]*int32{
+				"rr-host-a":    to.Int32Ptr(10),
+				"rr-host-b":    to.Int32Ptr(11),
+				"rr-catch-all": to.Int32Ptr(20),
+			}), "the new rule is evaluated before the rule of the catch-all listener")
+		})
+
+		It("does not renumber the rules when a rule is removed", func() {
+			existing.RoutingRules = []n.ApplicationGatewayRequestRoutingRule{
+				newRule("rr-host-a", to.Int32Ptr(10)),
+				newRule("rr-host-removed", to.Int32Ptr(20)),
+				newRule("rr-host-b", to.Int32Ptr(30)),
+				newRule("rr-catch-all", to.Int32Ptr(40)),
+			}
+			setRules()
+			Expect(configBuilder.setRulePriorities(cbCtx, existing)).ToNot(HaveOccurred())
+			Expect(priorities(*configBuilder.appGw.RequestRoutingRules)).To(Equal(map[string]*int32{
+				"rr-host-a":    to.Int32Ptr(10),
+				"rr-host-b":    to.Int32Ptr(30),
+				"rr-catch-all": to.Int32Ptr(40),
+			}))
+		})
+
+		It("gives a new priority to a rule out of order", func() {
+			existing.RoutingRules = []n.ApplicationGatewayRequestRoutingRule{
+				newRule("rr-host-a", to.Int32Ptr(30)),
+				newRule("rr-host-b", to.Int32Ptr(20)),
+				newRule("rr-catch-all", to.Int32Ptr(40)),
+			}
+			setRules()
+			Expect(configBuilder.setRulePriorities(cbCtx, existing)).ToNot(HaveOccurred())
+			Expect(priorities(*configBuilder.appGw.RequestRoutingRules)).To(Equal(map[string]*int32{
+				"rr-host-a":    to.Int32Ptr(30),
+				"rr-host-b":    to.Int32Ptr(31),
+				"rr-catch-all": to.Int32Ptr(40),
+			}))
+		})
+
+		It("renumbers the rules when there is no room between the kept priorities", func() {
+			existing.RoutingRules = []n.ApplicationGatewayRequestRoutingRule{
+				newRule("rr-host-a", to.Int32Ptr(10)),
+				newRule("rr-catch-all", to.Int32Ptr(11)),
+			}
+			setRules()
+			Expect(configBuilder.setRulePriorities(cbCtx, existing)).ToNot(HaveOccurred())
+			Expect(priorities(*configBuilder.appGw.RequestRoutingRules)).To(Equal(map[string]*int32{
+				"rr-host-a":    to.Int32Ptr(10),
+				"rr-host-b":    to.Int32Ptr(20),
+				"rr-catch-all": to.Int32Ptr(30),
+			}))
+		})
+
+		It("rejects classic rules AGIC does not manage", func() {
+			setRules(newRule("portal-rule", nil))
+			Expect(configBuilder.setRulePriorities(cbCtx, existing)).To(Equal(ErrClassicRulesInPriorityMode))
+		})
+	})
+
+	Context("classic evaluation", func() {
+		BeforeEach(func() {
+			cbCtx.EnvVariables.RoutingRuleEvaluation = environment.RoutingRuleEvaluationClassic
+		})
+
+		It("removes the priorities of the generated rules", func() {
+			// A manage-backend-only ingress keeps the rule found on the gateway, with its priority.
+			generated[0].ApplicationGatewayRequestRoutingRulePropertiesFormat.Priority = to.Int32Ptr(10)
+			setRules(newRule("portal-rule", nil))
+			Expect(configBuilder.setRulePriorities(cbCtx, existing)).ToNot(HaveOccurred())
+			for _, priority := range priorities(*configBuilder.appGw.RequestRoutingRules) {
+				Expect(priority).To(BeNil())
+			}
+		})
+
+		It("rejects priority rules AGIC does not manage", func() {
+			setRules(newRule("portal-rule", to.Int32Ptr(100)))
+			Expect(configBuilder.setRulePriorities(cbCtx, existing)).To(Equal(ErrPriorityRulesInClassicMode))
+		})
+	})
+})
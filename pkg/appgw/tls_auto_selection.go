@@ -0,0 +1,128 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"strings"
+
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/golang/glog"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+)
+
+// How well the name of a certificate matches a host; a higher value takes precedence.
+const (
+	hostMatchNone = iota
+	hostMatchWildcard
+	hostMatchExact
+)
+
+// getAutoSelectedCertificate returns the certificate of the TLS secret in the namespace of the ingress, which best matches
+// the host: a certificate for the exact host takes precedence over a wildcard certificate. Among secrets matching equally
+// well, the first one by name is selected.
+func (c *appGwConfigBuilder) getAutoSelectedCertificate(ingress *v1beta1.Ingress, host string) (*string, *secretIdentifier) {
+	var selected *secretIdentifier
+	var selectedCert []byte
+	bestMatch := hostMatchNone
+	for _, secret := range c.k8sContext.ListTLSSecrets(ingress.Namespace) {
+		match := hostMatchNone
+		for _, certHost := range getCertificateHosts(secret) {
+			if m := matchCertificateHost(certHost, host); m > match {
+				match = m
+			}
+		}
+		if match <= bestMatch {
+			continue
+		}
+
+		secretID := secretIdentifier{
+			Namespace: secret.Namespace,
+			Name:      secret.Name,
+		}
+		cert := c.getPfxCertificate(secretID.secretKey())
+		if cert == nil {
+			// the secret could not be converted to a certificate App Gateway accepts
+			continue
+		}
+		selected, selectedCert, bestMatch = &secretID, cert, match
+	}
+
+	if selected == nil {
+		return nil, nil
+	}
+	glog.V(5).Infof("[%s/%s] Selected the TLS secret %s for host %s", ingress.Namespace, ingress.Name, selected.secretKey(), host)
+	return to.StringPtr(base64.StdEncoding.EncodeToString(selectedCert)), selected
+}
+
+// getAutoSelectedSecretToCertificateMap returns the certificates auto-selected for the hosts of the ingress, which are
+// not listed in the TLS section of the ingress.
+func (c *appGwConfigBuilder) getAutoSelectedSecretToCertificateMap(ingress *v1beta1.Ingress, env environment.EnvVariables) map[secretIdentifier]*string {
+	secretIDCertificateMap := make(map[secretIdentifier]*string)
+	if !env.AutoSelectTLSSecrets {
+		return secretIDCertificateMap
+	}
+	hostnameSecretIDMap := c.newHostToSecretMap(ingress)
+	for _, rule := range ingress.Spec.Rules {
+		if rule.Host == "" {
+			continue
+		}
+		if cert, _ := c.getCertificate(ingress, rule.Host, hostnameSecretIDMap); cert != nil {
+			continue
+		}
+		if cert, secID := c.getAutoSelectedCertificate(ingress, rule.Host); cert != nil {
+			secretIDCertificateMap[*secID] = cert
+		}
+	}
+	return secretIDCertificateMap
+}
+
+// getCertificateHosts returns the DNS names of the certificate in the secret or, without DNS names, its common name.
+func getCertificateHosts(secret *v1.Secret) []string {
+	block, _ := pem.Decode(secret.Data[v1.TLSCertKey])
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		glog.V(5).Infof("Unable to parse the certificate of secret %s/%s: %s", secret.Namespace, secret.Name, err)
+		return nil
+	}
+	if len(cert.DNSNames) > 0 {
+		return cert.DNSNames
+	}
+	if cert.Subject.CommonName != "" {
+		return []string{cert.Subject.CommonName}
+	}
+	return nil
+}
+
+// matchCertificateHost determines how well a name of a certificate matches the host. A wildcard name (*.contoso.com)
+// matches hosts with exactly one more label (www.contoso.com), but not contoso.com or a.b.contoso.com.
+func matchCertificateHost(certHost, host string) int {
+	certHost = strings.ToLower(certHost)
+	host = strings.ToLower(host)
+	if certHost == host {
+		return hostMatchExact
+	}
+	if !strings.HasPrefix(certHost, "*.") {
+		return hostMatchNone
+	}
+	domain := certHost[1:]
+	if !strings.HasSuffix(host, domain) {
+		return hostMatchNone
+	}
+	label := strings.TrimSuffix(host, domain)
+	if label == "" || label == "*" || strings.Contains(label, ".") {
+		return hostMatchNone
+	}
+	return hostMatchWildcard
+}
@@ -0,0 +1,153 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package appgw
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"time"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
+)
+
+// newTLSSecretFixture returns a TLS secret with a self-signed certificate for the hosts.
+func newTLSSecretFixture(name string, hosts ...string) *v1.Secret {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).ToNot(HaveOccurred())
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: hosts[0]},
+		DNSNames:     hosts,
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	Expect(err).ToNot(HaveOccurred())
+	return &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: tests.Namespace,
+			Name:      name,
+		},
+		Type: v1.SecretTypeTLS,
+		Data: map[string][]byte{
+			v1.TLSCertKey: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		},
+	}
+}
+
+var _ = Describe("Test TLS secret auto-selection", func() {
+	var configBuilder appGwConfigBuilder
+	var ingress *v1beta1.Ingress
+	var env environment.EnvVariables
+
+	BeforeEach(func() {
+		secrets := []*v1.Secret{
+			newTLSSecretFixture("a-wildcard", "*.contoso.com"),
+			newTLSSecretFixture("b-www", "www.contoso.com", "contoso.com"),
+			newTLSSecretFixture("c-wildcard", "*.contoso.com"),
+		}
+		certs := make(map[string]interface{})
+		for _, secret := range secrets {
+			certs[secret.Namespace+"/"+secret.Name] = []byte(secret.Name)
+		}
+		configBuilder = newConfigBuilderFixture(&certs)
+		for _, secret := range secrets {
+			_ = configBuilder.k8sContext.Caches.Secret.Add(secret)
+		}
+
+		ingress = tests.NewIngressFixture()
+		ingress.Spec.TLS = nil
+		delete(ingress.Annotations, annotations.SslRedirectKey)
+		ingress.Spec.Rules = ingress.Spec.Rules[:1]
+
+		env = environment.GetFakeEnv()
+		env.AutoSelectTLSSecrets = true
+	})
+
+	// listenerSecrets returns the secret of each listener of the ingress; HTTP listeners have no secret.
+	listenerSecrets := func(host string) []string {
+		ingress.Spec.Rules[0].Host = host
+		var secrets []string
+		for _, config := range configBuilder.getListenersFromIngress(ingress, env) {
+			if config.Protocol == n.HTTPS {
+				secrets = append(secrets, config.Secret.Name)
+			} else {
+				secrets = append(secrets, "")
+			}
+		}
+		return secrets
+	}
+
+	It("prefers the certificate for the exact host over a wildcard certificate", func() {
+		Expect(listenerSecrets("www.contoso.com")).To(Equal([]string{"b-www"}))
+		Expect(listenerSecrets("contoso.com")).To(Equal([]string{"b-www"}))
+	})
+
+	It("selects the first wildcard certificate by name for the other hosts of the domain", func() {
+		Expect(listenerSecrets("api.contoso.com")).To(Equal([]string{"a-wildcard"}))
+		Expect(listenerSecrets("API.Contoso.com")).To(Equal([]string{"a-wildcard"}))
+	})
+
+	It("does not match a wildcard certificate to subdomains of its hosts", func() {
+		Expect(listenerSecrets("a.api.contoso.com")).To(Equal([]string{""}))
+		Expect(listenerSecrets("www.fabrikam.com")).To(Equal([]string{""}))
+	})
+
+	It("adds the selected certificates to the App Gateway", func() {
+		ingress.Spec.Rules[0].Host = "api.contoso.com"
+		cbCtx := &ConfigBuilderContext{
+			IngressList:  []*v1beta1.Ingress{ingress},
+			EnvVariables: env,
+		}
+		var names []string
+		for _, cert := range *configBuilder.getSslCertificates(cbCtx) {
+			names = append(names, *cert.Name)
+		}
+		Expect(names).To(Equal([]string{secretIdentifier{Namespace: tests.Namespace, Name: "a-wildcard"}.secretFullName()}))
+	})
+
+	It("gives precedence to the TLS section of the ingress", func() {
+		ingress.Spec.TLS = []v1beta1.IngressTLS{
+			{Hosts: []string{"www.contoso.com"}, SecretName: tests.NameOfSecret},
+		}
+		Expect(listenerSecrets("www.contoso.com")).To(Equal([]string{tests.NameOfSecret}))
+	})
+
+	It("does not select certificates in other namespaces", func() {
+		ingress.Namespace = "other-namespace"
+		Expect(listenerSecrets("www.contoso.com")).To(Equal([]string{""}))
+	})
+
+	It("does not select certificates unless enabled", func() {
+		env.AutoSelectTLSSecrets = false
+		Expect(listenerSecrets("www.contoso.com")).To(Equal([]string{""}))
+	})
+
+	It("matches hosts to the names of certificates", func() {
+		Expect(matchCertificateHost("www.contoso.com", "WWW.contoso.com")).To(Equal(hostMatchExact))
+		Expect(matchCertificateHost("*.contoso.com", "*.contoso.com")).To(Equal(hostMatchExact))
+		Expect(matchCertificateHost("*.contoso.com", "www.contoso.com")).To(Equal(hostMatchWildcard))
+		Expect(matchCertificateHost("*.contoso.com", "contoso.com")).To(Equal(hostMatchNone))
+		Expect(matchCertificateHost("*.contoso.com", ".contoso.com")).To(Equal(hostMatchNone))
+		Expect(matchCertificateHost("*.contoso.com", "a.b.contoso.com")).To(Equal(hostMatchNone))
+		Expect(matchCertificateHost("*.contoso.com", "wwwcontoso.com")).To(Equal(hostMatchNone))
+		Expect(matchCertificateHost("www.contoso.com", "api.contoso.com")).To(Equal(hostMatchNone))
+	})
+})
@@ -7,11 +7,13 @@ package appgw
 
 import (
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/metricstore"
 	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
 	"github.com/knative/pkg/apis/istio/v1alpha3"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/api/extensions/v1beta1"
 
+	rwv1 "github.com/Azure/application-gateway-kubernetes-ingress/pkg/apis/azureapplicationgatewayrewrite/v1"
 	ptv1 "github.com/Azure/application-gateway-kubernetes-ingress/pkg/apis/azureingressprohibitedtarget/v1"
 )
 
@@ -21,6 +23,7 @@ type ConfigBuilderContext struct {
 	IngressList          []*v1beta1.Ingress
 	ServiceList          []*v1.Service
 	ProhibitedTargets    []*ptv1.AzureIngressProhibitedTarget
+	Rewrites             []*rwv1.AzureApplicationGatewayRewrite
 	EnvVariables         environment.EnvVariables
 	IstioGateways        []*v1alpha3.Gateway
 	IstioVirtualServices []*v1alpha3.VirtualService
@@ -29,6 +32,34 @@ type ConfigBuilderContext struct {
 	DefaultHTTPSettingsID *string
 
 	ExistingPortsByNumber map[Port]n.ApplicationGatewayFrontendPort
+
+	// PoolDrains, when set, drains the old addresses of backend pools swapped wholesale; see PoolDrains.
+	PoolDrains *PoolDrains
+
+	// PoolRemovals, when set, keeps the backend pools from dropping below a minimum of established addresses; see
+	// PoolRemovals.
+	PoolRemovals *PoolRemovals
+
+	// ResolvedCache, when set, memoizes what the builds resolve from the Kubernetes resources; see ResolvedCache.
+	ResolvedCache *ResolvedCache
+
+	// FQDNResolutions, when set, resolves the FQDNs of ExternalName services; see FQDNResolutions.
+	FQDNResolutions *FQDNResolutions
+
+	// ProbeWarnings, when set, throttles the events on the ingresses, whose backend pods have no HTTP readiness probe;
+	// see ProbeWarnings.
+	ProbeWarnings *ProbeWarnings
+
+	// AutoscaleSchedule, when set, sets the capacity of App Gateway by the time of day; see AutoscaleSchedule.
+	AutoscaleSchedule *AutoscaleSchedule
+
+	// DanglingFirewallPolicies are the IDs of the WAF policies, which are referenced by ingresses, but no longer exist;
+	// they are left out of the config.
+	DanglingFirewallPolicies map[string]interface{}
+
+	// MetricStore, when set, counts the TLS secrets referenced by ingresses, which are missing or can not be used, the
+	// services whose pool holds their cluster IP and the changes of the resolved FQDNs.
+	MetricStore metricstore.MetricStore
 }
 
 // InIngressList returns true if an ingress is in the ingress list
@@ -7,7 +7,9 @@ package appgw
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
+	"strings"
 
 	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
 	"github.com/golang/glog"
@@ -26,6 +28,7 @@ const (
 	errKeyEitherBorR     = "either-backend-or-redirect"
 	errKeyNoPrivateIP    = "no-private-ip"
 	errKeyNoPublicIP     = "no-public-ip"
+	errKeyNoIPConfName   = "no-ip-configuration-name"
 )
 
 var validationErrors = map[string]error{
@@ -35,6 +38,7 @@ var validationErrors = map[string]error{
 	errKeyEitherBorR:     ErrKeyEitherBorR,
 	errKeyNoPrivateIP:    ErrKeyNoPrivateIP,
 	errKeyNoPublicIP:     ErrKeyNoPublicIP,
+	errKeyNoIPConfName:   ErrFrontendIPConfigurationNotFound,
 }
 
 func validateServiceDefinition(eventRecorder record.EventRecorder, config *n.ApplicationGatewayPropertiesFormat, envVariables environment.EnvVariables, ingressList []*v1beta1.Ingress, serviceList []*v1.Service) error {
@@ -63,7 +67,7 @@ func validateServiceDefinition(eventRecorder record.EventRecorder, config *n.App
 	for be := range backendIDs {
 		if _, exists := serviceSet[be.serviceKey()]; !exists {
 			logLine := fmt.Sprintf("Ingress %s/%s references non existent Service %s. Please correct the Service section of your Kubernetes YAML", be.Ingress.Namespace, be.Ingress.Name, be.serviceKey())
-			eventRecorder.Event(be.Ingress, v1.EventTypeWarning, events.ReasonIngressServiceTargetMatch, logLine)
+			events.Warning(eventRecorder, be.Ingress, events.ReasonIngressServiceTargetMatch, logLine)
 			// NOTE: We could and should return a new error here.
 			// However this could be enabled at a later point in time once we know with certainty taht there are no valid
 			// scenarios where one could have Ingress pointing to a missing Service targets.
@@ -72,6 +76,58 @@ func validateServiceDefinition(eventRecorder record.EventRecorder, config *n.App
 	return nil
 }
 
+// validateTLSHosts warns about the hosts of an ingress with TLS, which are served over HTTP because no TLS entry lists
+// them, and about the hosts listed by TLS entries, which no rule serves. Certificates apply to the hosts TLS entries
+// list, or to all the hosts when a TLS entry lists none.
+func validateTLSHosts(eventRecorder record.EventRecorder, config *n.ApplicationGatewayPropertiesFormat, envVariables environment.EnvVariables, ingressList []*v1beta1.Ingress, serviceList []*v1.Service) error {
+	for _, ingress := range ingressList {
+		if len(ingress.Spec.TLS) == 0 {
+			continue
+		}
+		tlsHosts := make(map[string]interface{})
+		allHosts := false
+		for _, tls := range ingress.Spec.TLS {
+			if len(tls.Hosts) == 0 {
+				allHosts = true
+			}
+			for _, host := range tls.Hosts {
+				if host == "" {
+					allHosts = true
+					continue
+				}
+				tlsHosts[host] = nil
+			}
+		}
+
+		ruleHosts := make(map[string]interface{})
+		for _, rule := range ingress.Spec.Rules {
+			ruleHosts[rule.Host] = nil
+			_, hasTLS := tlsHosts[rule.Host]
+			// A certificate selected automatically may yet apply to the host; see APPGW_AUTO_SELECT_TLS_SECRETS.
+			if rule.Host == "" || hasTLS || allHosts || envVariables.AutoSelectTLSSecrets {
+				continue
+			}
+			logLine := fmt.Sprintf("Host %s of ingress %s/%s is served over HTTP, as no TLS entry of the ingress lists it", rule.Host, ingress.Namespace, ingress.Name)
+			glog.Warning(logLine)
+			events.Warning(eventRecorder, ingress, events.ReasonTLSHostMismatch, logLine)
+		}
+
+		var orphans []string
+		for host := range tlsHosts {
+			if _, served := ruleHosts[host]; !served {
+				orphans = append(orphans, host)
+			}
+		}
+		sort.Strings(orphans)
+		for _, host := range orphans {
+			logLine := fmt.Sprintf("TLS host %s of ingress %s/%s is not the host of any rule of the ingress; its certificate is not used", host, ingress.Namespace, ingress.Name)
+			glog.Warning(logLine)
+			events.Warning(eventRecorder, ingress, events.ReasonTLSHostMismatch, logLine)
+		}
+	}
+	return nil
+}
+
 func validateURLPathMaps(eventRecorder record.EventRecorder, config *n.ApplicationGatewayPropertiesFormat, envVariables environment.EnvVariables, ingressList []*v1beta1.Ingress, serviceList []*v1.Service) error {
 	if config.URLPathMaps == nil {
 		return nil
@@ -130,6 +186,12 @@ func validateFrontendIPConfiguration(eventRecorder record.EventRecorder, config
 			(ip.ApplicationGatewayFrontendIPConfigurationPropertiesFormat != nil && ip.PublicIPAddress != nil)
 	}
 
+	if envVariables.FrontendIPConfiguration != "" &&
+		LookupIPConfigurationByName(config.FrontendIPConfigurations, envVariables.FrontendIPConfiguration) == nil {
+		glog.Errorf("Frontend IP configuration %s not found in %s", envVariables.FrontendIPConfiguration, strings.Join(jsonConfigs, ", "))
+		return validationErrors[errKeyNoIPConfName]
+	}
+
 	if usePrivateIP, _ := strconv.ParseBool(envVariables.UsePrivateIP); usePrivateIP && !privateIPPresent {
 		return validationErrors[errKeyNoPrivateIP]
 	}
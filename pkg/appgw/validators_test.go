@@ -185,5 +185,73 @@ var _ = Describe("Test ConfigBuilder validator functions", func() {
 			err := validateFrontendIPConfiguration(eventRecorder, config, envVariables)
 			Expect(err).To(Equal(validationErrors[errKeyNoPublicIP]))
 		})
+
+		It("should not error out when the frontend IP configuration named by APPGW_FRONTEND_IP_CONFIGURATION is present.", func() {
+			envVariablesNew := environment.GetFakeEnv()
+			envVariablesNew.FrontendIPConfiguration = "yy3"
+			config.FrontendIPConfigurations = &[]n.ApplicationGatewayFrontendIPConfiguration{publicIPConf, privateIPConf}
+			err := validateFrontendIPConfiguration(eventRecorder, config, envVariablesNew)
+			Expect(err).To(BeNil())
+		})
+
+		It("should error out when the frontend IP configuration named by APPGW_FRONTEND_IP_CONFIGURATION is not present.", func() {
+			envVariablesNew := environment.GetFakeEnv()
+			envVariablesNew.FrontendIPConfiguration = "yy3"
+			config.FrontendIPConfigurations = &[]n.ApplicationGatewayFrontendIPConfiguration{publicIPConf}
+			err := validateFrontendIPConfiguration(eventRecorder, config, envVariablesNew)
+			Expect(err).To(Equal(validationErrors[errKeyNoIPConfName]))
+			Expect(err).To(Equal(ErrFrontendIPConfigurationNotFound))
+		})
+	})
+
+	Context("test validateTLSHosts", func() {
+		var eventRecorder *record.FakeRecorder
+		var ingress *v1beta1.Ingress
+
+		BeforeEach(func() {
+			eventRecorder = record.NewFakeRecorder(100)
+			ingress = tests.NewIngressFixture()
+			ingress.Spec.Rules = []v1beta1.IngressRule{{Host: "a.contoso.com"}, {Host: "b.contoso.com"}}
+			ingress.Spec.TLS = []v1beta1.IngressTLS{{Hosts: []string{"a.contoso.com", "b.contoso.com"}, SecretName: tests.NameOfSecret}}
+		})
+
+		validate := func() []string {
+			err := validateTLSHosts(eventRecorder, nil, environment.GetFakeEnv(), []*v1beta1.Ingress{ingress}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			close(eventRecorder.Events)
+			var events []string
+			for event := range eventRecorder.Events {
+				events = append(events, event)
+			}
+			return events
+		}
+
+		It("should not warn when the TLS hosts match the hosts of the rules", func() {
+			Expect(validate()).To(BeEmpty())
+		})
+
+		It("should not warn when a TLS entry applies to all the hosts", func() {
+			ingress.Spec.TLS = []v1beta1.IngressTLS{{SecretName: tests.NameOfSecret}}
+			Expect(validate()).To(BeEmpty())
+		})
+
+		It("should not warn about an ingress without TLS", func() {
+			ingress.Spec.TLS = nil
+			Expect(validate()).To(BeEmpty())
+		})
+
+		It("should warn about a host served over HTTP", func() {
+			ingress.Spec.TLS[0].Hosts = []string{"a.contoso.com"}
+			Expect(validate()).To(ConsistOf(
+				"Warning TLSHostMismatch Host b.contoso.com of ingress --namespace--/--name-- is served over HTTP, as no TLS entry of the ingress lists it",
+			))
+		})
+
+		It("should warn about a TLS host without a rule", func() {
+			ingress.Spec.TLS[0].Hosts = append(ingress.Spec.TLS[0].Hosts, "c.contoso.com")
+			Expect(validate()).To(ConsistOf(
+				"Warning TLSHostMismatch TLS host c.contoso.com of ingress --namespace--/--name-- is not the host of any rule of the ingress; its certificate is not used",
+			))
+		})
 	})
 })
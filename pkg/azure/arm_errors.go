@@ -0,0 +1,88 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package azure
+
+import (
+	"net/http"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure"
+)
+
+// subnetFullErrorCodes are the codes of the ARM errors of an update, which needs more private IP addresses than are
+// left in the subnet of the App Gateway: when a private frontend IP is added, or when the gateway scales out.
+var subnetFullErrorCodes = map[string]interface{}{
+	"SubnetIsFull":           nil,
+	"InsufficientSubnetSize": nil,
+}
+
+// IsSubnetFull tells whether the error of an App Gateway update is ARM refusing it, because the subnet of the
+// gateway has no private IP addresses left.
+func IsSubnetFull(err error) bool {
+	for _, code := range armErrorCodes(err) {
+		if _, exists := subnetFullErrorCodes[code]; exists {
+			return true
+		}
+	}
+	return false
+}
+
+// IsNotFound tells whether the error of an ARM request is the requested resource not existing.
+func IsNotFound(err error) bool {
+	response := responseOfError(err)
+	return response != nil && response.StatusCode == http.StatusNotFound
+}
+
+// throttledErrorCodes are the codes of the ARM errors of a request, which ARM refused as the subscription or the
+// gateway made too many requests.
+var throttledErrorCodes = map[string]interface{}{
+	"TooManyRequests":               nil,
+	"SubscriptionRequestsThrottled": nil,
+}
+
+// IsThrottled tells whether the error of an ARM request is ARM throttling the requests, with HTTP status 429.
+func IsThrottled(err error) bool {
+	if response := responseOfError(err); response != nil && response.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	for _, code := range armErrorCodes(err) {
+		if _, exists := throttledErrorCodes[code]; exists {
+			return true
+		}
+	}
+	return false
+}
+
+// armErrorCodes returns the codes of the ARM error and of its details. The error is returned either by the request
+// of the update or, once it was accepted, by the polling of the long running operation.
+func armErrorCodes(err error) []string {
+	var serviceError *azure.ServiceError
+	switch e := err.(type) {
+	case autorest.DetailedError:
+		return armErrorCodes(e.Original)
+	case *autorest.DetailedError:
+		return armErrorCodes(e.Original)
+	case *azure.RequestError:
+		serviceError = e.ServiceError
+	case azure.RequestError:
+		serviceError = e.ServiceError
+	case *azure.ServiceError:
+		serviceError = e
+	case azure.ServiceError:
+		serviceError = &e
+	}
+	if serviceError == nil {
+		return nil
+	}
+
+	codes := []string{serviceError.Code}
+	for _, detail := range serviceError.Details {
+		if code, ok := detail["code"].(string); ok {
+			codes = append(codes, code)
+		}
+	}
+	return codes
+}
@@ -0,0 +1,98 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package azure
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Test IsSubnetFull", func() {
+	subnetIsFull := &azure.ServiceError{
+		Code:    "SubnetIsFull",
+		Message: "Subnet appgw-subnet with address prefix 10.0.0.0/29 does not have enough capacity for 1 IP addresses.",
+	}
+
+	It("should detect the error of the update request", func() {
+		err := autorest.DetailedError{
+			Original:   &azure.RequestError{ServiceError: subnetIsFull},
+			StatusCode: 400,
+		}
+		Expect(IsSubnetFull(err)).To(BeTrue())
+	})
+
+	It("should detect the error of the long running operation", func() {
+		Expect(IsSubnetFull(subnetIsFull)).To(BeTrue())
+	})
+
+	It("should detect the error in the details", func() {
+		err := &azure.ServiceError{
+			Code:    "InvalidResourceReference",
+			Details: []map[string]interface{}{{"code": "InsufficientSubnetSize"}},
+		}
+		Expect(IsSubnetFull(err)).To(BeTrue())
+	})
+
+	It("should not detect other errors", func() {
+		Expect(IsSubnetFull(&azure.ServiceError{Code: "InvalidResourceReference"})).To(BeFalse())
+		Expect(IsSubnetFull(errors.New("SubnetIsFull"))).To(BeFalse())
+		Expect(IsSubnetFull(ErrUpdateGatewayTimeout)).To(BeFalse())
+		Expect(IsSubnetFull(nil)).To(BeFalse())
+	})
+})
+
+var _ = Describe("Test IsNotFound", func() {
+	It("should detect a resource, which does not exist", func() {
+		err := autorest.DetailedError{
+			Original:   &azure.RequestError{ServiceError: &azure.ServiceError{Code: "ResourceNotFound"}},
+			StatusCode: http.StatusNotFound,
+			Response:   &http.Response{StatusCode: http.StatusNotFound},
+		}
+		Expect(IsNotFound(err)).To(BeTrue())
+	})
+
+	It("should not detect other errors", func() {
+		err := autorest.DetailedError{
+			Original:   &azure.RequestError{ServiceError: &azure.ServiceError{Code: "AuthorizationFailed"}},
+			StatusCode: http.StatusForbidden,
+			Response:   &http.Response{StatusCode: http.StatusForbidden},
+		}
+		Expect(IsNotFound(err)).To(BeFalse())
+		Expect(IsNotFound(errors.New("not found"))).To(BeFalse())
+		Expect(IsNotFound(nil)).To(BeFalse())
+	})
+})
+
+var _ = Describe("Test IsThrottled", func() {
+	It("should detect a request refused with HTTP status 429", func() {
+		err := autorest.DetailedError{
+			Original:   &azure.RequestError{ServiceError: &azure.ServiceError{Code: "RetryableError"}},
+			StatusCode: http.StatusTooManyRequests,
+			Response:   &http.Response{StatusCode: http.StatusTooManyRequests},
+		}
+		Expect(IsThrottled(err)).To(BeTrue())
+	})
+
+	It("should detect the error of the long running operation", func() {
+		Expect(IsThrottled(&azure.ServiceError{Code: "SubscriptionRequestsThrottled"})).To(BeTrue())
+	})
+
+	It("should not detect other errors", func() {
+		err := autorest.DetailedError{
+			Original:   &azure.RequestError{ServiceError: &azure.ServiceError{Code: "AuthorizationFailed"}},
+			StatusCode: http.StatusForbidden,
+			Response:   &http.Response{StatusCode: http.StatusForbidden},
+		}
+		Expect(IsThrottled(err)).To(BeFalse())
+		Expect(IsThrottled(errors.New("too many requests"))).To(BeFalse())
+		Expect(IsThrottled(nil)).To(BeFalse())
+	})
+})
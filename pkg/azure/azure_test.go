@@ -36,6 +36,13 @@ var _ = Describe("Azure", func() {
 				Expect(resGp).To(Equal(outResGp))
 				Expect(resName).To(Equal(outResName))
 			})
+
+			It("should return empty parts for a malformed resourceId", func() {
+				outSubID, outResGp, outResName := ParseResourceID("/subscriptions/xxxx/resourceGroups/yyyy")
+				Expect(outSubID).To(BeEmpty())
+				Expect(outResGp).To(BeEmpty())
+				Expect(outResName).To(BeEmpty())
+			})
 		})
 
 		Context("ensure ConvertToClusterResourceGroup works as expected", func() {
@@ -10,6 +10,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/version"
 	r "github.com/Azure/azure-sdk-for-go/profiles/latest/resources/mgmt/resources"
@@ -22,6 +24,8 @@ import (
 // AzClient is an interface for client to Azure
 type AzClient interface {
 	SetAuthorizer(authorizer autorest.Authorizer)
+	SetUpdatePolling(pollInterval time.Duration, timeout time.Duration)
+	WithContext(ctx context.Context) AzClient
 
 	GetGateway() (n.ApplicationGateway, error)
 	UpdateGateway(*n.ApplicationGateway) error
@@ -29,6 +33,8 @@ type AzClient interface {
 	DeployGatewayWithSubnet(string) error
 
 	GetPublicIP(string) (n.PublicIPAddress, error)
+	GetSubnet(string) (n.Subnet, error)
+	GetFirewallPolicy(string) (n.WebApplicationFirewallPolicy, error)
 }
 
 type azClient struct {
@@ -36,6 +42,7 @@ type azClient struct {
 	publicIPsClient       n.PublicIPAddressesClient
 	virtualNetworksClient n.VirtualNetworksClient
 	subnetsClient         n.SubnetsClient
+	wafPoliciesClient     n.WebApplicationFirewallPoliciesClient
 	groupsClient          r.GroupsClient
 	deploymentsClient     r.DeploymentsClient
 
@@ -44,6 +51,9 @@ type azClient struct {
 	appGwName         ResourceName
 	memoizedIPs       map[string]n.PublicIPAddress
 
+	updatePollInterval time.Duration
+	updateTimeout      time.Duration
+
 	ctx context.Context
 }
 
@@ -60,6 +70,7 @@ func NewAzClient(subscriptionID SubscriptionID, resourceGroupName ResourceGroup,
 		publicIPsClient:       n.NewPublicIPAddressesClientWithBaseURI(settings.Environment.ResourceManagerEndpoint, string(subscriptionID)),
 		virtualNetworksClient: n.NewVirtualNetworksClientWithBaseURI(settings.Environment.ResourceManagerEndpoint, string(subscriptionID)),
 		subnetsClient:         n.NewSubnetsClientWithBaseURI(settings.Environment.ResourceManagerEndpoint, string(subscriptionID)),
+		wafPoliciesClient:     n.NewWebApplicationFirewallPoliciesClientWithBaseURI(settings.Environment.ResourceManagerEndpoint, string(subscriptionID)),
 		groupsClient:          r.NewGroupsClientWithBaseURI(settings.Environment.ResourceManagerEndpoint, string(subscriptionID)),
 		deploymentsClient:     r.NewDeploymentsClientWithBaseURI(settings.Environment.ResourceManagerEndpoint, string(subscriptionID)),
 
@@ -68,9 +79,14 @@ func NewAzClient(subscriptionID SubscriptionID, resourceGroupName ResourceGroup,
 		appGwName:         appGwName,
 		memoizedIPs:       make(map[string]n.PublicIPAddress),
 
+		updatePollInterval: DefaultUpdatePollInterval,
+		updateTimeout:      DefaultUpdateTimeout,
+
 		ctx: context.Background(),
 	}
 
+	az.appGatewaysClient.RequestInspector = withCorrelationRequestID()
+
 	if err := az.appGatewaysClient.AddToUserAgent(userAgent); err != nil {
 		glog.Error("Error adding User Agent to App Gateway client: ", userAgent)
 	}
@@ -83,6 +99,9 @@ func NewAzClient(subscriptionID SubscriptionID, resourceGroupName ResourceGroup,
 	if err := az.subnetsClient.AddToUserAgent(userAgent); err != nil {
 		glog.Error("Error adding User Agent to Subnets client: ", userAgent)
 	}
+	if err := az.wafPoliciesClient.AddToUserAgent(userAgent); err != nil {
+		glog.Error("Error adding User Agent to WAF Policies client: ", userAgent)
+	}
 	if err := az.groupsClient.AddToUserAgent(userAgent); err != nil {
 		glog.Error("Error adding User Agent to Groups client: ", userAgent)
 	}
@@ -98,22 +117,43 @@ func (az *azClient) SetAuthorizer(authorizer autorest.Authorizer) {
 	az.publicIPsClient.Authorizer = authorizer
 	az.virtualNetworksClient.Authorizer = authorizer
 	az.subnetsClient.Authorizer = authorizer
+	az.wafPoliciesClient.Authorizer = authorizer
 	az.groupsClient.Authorizer = authorizer
 	az.deploymentsClient.Authorizer = authorizer
 }
 
-func (az *azClient) GetGateway() (n.ApplicationGateway, error) {
-	return az.appGatewaysClient.Get(az.ctx, string(az.resourceGroupName), string(az.appGwName))
+func (az *azClient) SetUpdatePolling(pollInterval time.Duration, timeout time.Duration) {
+	az.updatePollInterval = pollInterval
+	az.updateTimeout = timeout
+}
+
+// WithContext returns a copy of the client making its calls with the context; the spans of the calls are children of
+// the span the context carries.
+func (az *azClient) WithContext(ctx context.Context) AzClient {
+	withContext := *az
+	withContext.ctx = ctx
+	return &withContext
+}
+
+func (az *azClient) GetGateway() (appGw n.ApplicationGateway, err error) {
+	span, ctx := az.startARMSpan("arm.GetGateway")
+	defer func() { endARMSpan(span, appGw.Response.Response, err) }()
+
+	return az.appGatewaysClient.Get(ctx, string(az.resourceGroupName), string(az.appGwName))
 }
 
 func (az *azClient) UpdateGateway(appGwObj *n.ApplicationGateway) (err error) {
-	appGwFuture, err := az.appGatewaysClient.CreateOrUpdate(az.ctx, string(az.resourceGroupName), string(az.appGwName), *appGwObj)
+	span, ctx := az.startARMSpan("arm.UpdateGateway")
+	appGwFuture, err := az.appGatewaysClient.CreateOrUpdate(ctx, string(az.resourceGroupName), string(az.appGwName), *appGwObj)
+	// The request ID is the one of the PUT, not of the polls which follow.
+	putResponse := appGwFuture.Response()
+	defer func() { endARMSpan(span, putResponse, err) }()
 	if err != nil {
 		return
 	}
 
 	// Wait until deployment finshes and save the error message
-	err = appGwFuture.WaitForCompletionRef(az.ctx, az.appGatewaysClient.BaseClient.Client)
+	err = waitForCompletion(ctx, &appGwFuture, az.appGatewaysClient.BaseClient.Client, az.updatePollInterval, az.updateTimeout)
 	return
 }
 
@@ -132,6 +172,26 @@ func (az *azClient) GetPublicIP(resourceID string) (n.PublicIPAddress, error) {
 	return ip, nil
 }
 
+func (az *azClient) GetSubnet(resourceID string) (n.Subnet, error) {
+	split := strings.Split(resourceID, "/")
+	if len(split) < 11 {
+		return n.Subnet{}, fmt.Errorf("subnet resourceID %s is invalid", resourceID)
+	}
+	_, resourceGroupName, vnetName := ParseResourceID(resourceID)
+	return az.subnetsClient.Get(az.ctx, string(resourceGroupName), string(vnetName), split[10], "")
+}
+
+// GetFirewallPolicy gets the WAF policy; the policy may be in another subscription or resource group than the gateway.
+func (az *azClient) GetFirewallPolicy(resourceID string) (policy n.WebApplicationFirewallPolicy, err error) {
+	span, ctx := az.startARMSpan("arm.GetFirewallPolicy")
+	defer func() { endARMSpan(span, policy.Response.Response, err) }()
+
+	subscriptionID, resourceGroupName, policyName := ParseResourceID(resourceID)
+	client := az.wafPoliciesClient
+	client.SubscriptionID = string(subscriptionID)
+	return client.Get(ctx, string(resourceGroupName), string(policyName))
+}
+
 // DeployGateway is a method that deploy the appgw and related resources
 func (az *azClient) DeployGatewayWithVnet(resourceGroupName ResourceGroup, vnetName ResourceName, subnetName ResourceName, subnetPrefix string) (err error) {
 	vnet, err := az.getVnet(resourceGroupName, vnetName)
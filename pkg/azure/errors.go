@@ -24,4 +24,25 @@ var (
 
 	// ErrAppGatewayNotFound is an error message.
 	ErrAppGatewayNotFound = errors.New("not found (AZUR005)")
+
+	// ErrGatewayProvisioningFailed is an error message.
+	ErrGatewayProvisioningFailed = errors.New("application gateway is in a failed provisioning state (AZUR006)")
+
+	// ErrGatewayMissingSubnet is an error message.
+	ErrGatewayMissingSubnet = errors.New("application gateway has no gateway IP configuration with a subnet (AZUR007)")
+
+	// ErrGatewaySubnetDelegated is an error message.
+	ErrGatewaySubnetDelegated = errors.New("application gateway subnet is delegated to a service (AZUR008)")
+
+	// ErrGatewaySubnetNotDedicated is an error message.
+	ErrGatewaySubnetNotDedicated = errors.New("application gateway subnet contains resources other than application gateways (AZUR009)")
+
+	// ErrGatewayMissingIdentity is an error message.
+	ErrGatewayMissingIdentity = errors.New("application gateway has Key Vault certificates but no user assigned identity (AZUR010)")
+
+	// ErrUpdateGatewayTimeout is an error message.
+	ErrUpdateGatewayTimeout = errors.New("timed out waiting for application gateway update to complete (AZUR011)")
+
+	// ErrGatewaySubnetFull is an error message.
+	ErrGatewaySubnetFull = errors.New("application gateway subnet has no private IP addresses left; the subnet needs more address space (AZUR012)")
 )
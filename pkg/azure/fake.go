@@ -6,6 +6,9 @@
 package azure
 
 import (
+	"context"
+	"time"
+
 	"github.com/Azure/go-autorest/autorest"
 
 	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
@@ -23,12 +26,20 @@ type DeployGatewayFunc func(string) error
 // GetPublicIPFunc is a function type
 type GetPublicIPFunc func(string) (n.PublicIPAddress, error)
 
+// GetSubnetFunc is a function type
+type GetSubnetFunc func(string) (n.Subnet, error)
+
+// GetFirewallPolicyFunc is a function type
+type GetFirewallPolicyFunc func(string) (n.WebApplicationFirewallPolicy, error)
+
 // FakeAzClient is a fake struct for AzClient
 type FakeAzClient struct {
 	GetGatewayFunc
 	UpdateGatewayFunc
 	DeployGatewayFunc
 	GetPublicIPFunc
+	GetSubnetFunc
+	GetFirewallPolicyFunc
 }
 
 // NewFakeAzClient returns a fake Azure Client
@@ -40,6 +51,15 @@ func NewFakeAzClient() *FakeAzClient {
 func (az *FakeAzClient) SetAuthorizer(authorizer autorest.Authorizer) {
 }
 
+// SetUpdatePolling is an empty function
+func (az *FakeAzClient) SetUpdatePolling(pollInterval time.Duration, timeout time.Duration) {
+}
+
+// WithContext returns the fake client
+func (az *FakeAzClient) WithContext(ctx context.Context) AzClient {
+	return az
+}
+
 // GetGateway runs GetGatewayFunc and return a gateway
 func (az *FakeAzClient) GetGateway() (n.ApplicationGateway, error) {
 	if az.GetGatewayFunc != nil {
@@ -79,3 +99,19 @@ func (az *FakeAzClient) GetPublicIP(resourceID string) (n.PublicIPAddress, error
 	}
 	return n.PublicIPAddress{}, nil
 }
+
+// GetSubnet runs GetSubnetFunc
+func (az *FakeAzClient) GetSubnet(resourceID string) (n.Subnet, error) {
+	if az.GetSubnetFunc != nil {
+		return az.GetSubnetFunc(resourceID)
+	}
+	return n.Subnet{}, nil
+}
+
+// GetFirewallPolicy runs GetFirewallPolicyFunc
+func (az *FakeAzClient) GetFirewallPolicy(resourceID string) (n.WebApplicationFirewallPolicy, error) {
+	if az.GetFirewallPolicyFunc != nil {
+		return az.GetFirewallPolicyFunc(resourceID)
+	}
+	return n.WebApplicationFirewallPolicy{}, nil
+}
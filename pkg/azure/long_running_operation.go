@@ -0,0 +1,77 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package azure
+
+import (
+	"context"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/golang/glog"
+)
+
+const (
+	// DefaultUpdatePollInterval is the default time between polls for the status of an App Gateway update.
+	DefaultUpdatePollInterval = 10 * time.Second
+
+	// DefaultUpdateTimeout is the default time to wait for an App Gateway update to complete.
+	// Updates of large gateways may take several minutes.
+	DefaultUpdateTimeout = 30 * time.Minute
+
+	// maxPollingMargin caps how long before the timeout the polling of the operation stops.
+	maxPollingMargin = 5 * time.Second
+)
+
+// longRunningOperation is the future of an asynchronous ARM operation.
+type longRunningOperation interface {
+	WaitForCompletionRef(context.Context, autorest.Client) error
+}
+
+// waitForCompletion polls the operation with the given interval until it completes or the timeout elapses.
+// The polling stops a little before the deadline of the context, so that the client gives up polling on its own
+// rather than racing the cancellation of its request.
+func waitForCompletion(ctx context.Context, operation longRunningOperation, client autorest.Client, pollInterval time.Duration, timeout time.Duration) error {
+	client.PollingDelay = pollInterval
+	client.PollingDuration = pollingDuration(timeout)
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan interface{})
+	defer close(done)
+	go logProgress(done, pollInterval, timeout)
+
+	start := time.Now()
+	err := operation.WaitForCompletionRef(ctx, client)
+	if err != nil && (ctx.Err() == context.DeadlineExceeded || time.Since(start) >= client.PollingDuration) {
+		glog.Errorf("App Gateway update did not complete within %+v: %s", timeout, err)
+		return ErrUpdateGatewayTimeout
+	}
+	return err
+}
+
+// pollingDuration returns how long the operation is polled for: a tenth of the timeout less, up to maxPollingMargin.
+func pollingDuration(timeout time.Duration) time.Duration {
+	margin := timeout / 10
+	if margin > maxPollingMargin {
+		margin = maxPollingMargin
+	}
+	return timeout - margin
+}
+
+func logProgress(done chan interface{}, pollInterval time.Duration, timeout time.Duration) {
+	start := time.Now()
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			glog.V(5).Infof("Waiting for App Gateway update to complete; %+v elapsed, timeout is %+v", time.Since(start).Round(time.Second), timeout)
+		case <-done:
+			return
+		}
+	}
+}
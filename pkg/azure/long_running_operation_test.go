@@ -0,0 +1,68 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package azure
+
+import (
+	"context"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// fakeOperation is a long running operation which completes after the given duration. Like the futures of the SDK,
+// it gives up once the polling duration of the client elapses.
+type fakeOperation struct {
+	duration time.Duration
+	client   autorest.Client
+}
+
+func (op *fakeOperation) WaitForCompletionRef(ctx context.Context, client autorest.Client) error {
+	op.client = client
+	ctx, cancel := context.WithTimeout(ctx, client.PollingDuration)
+	defer cancel()
+	select {
+	case <-time.After(op.duration):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+var _ = Describe("Test waitForCompletion", func() {
+	pollInterval := 10 * time.Millisecond
+	timeout := 200 * time.Millisecond
+
+	It("should succeed when the operation completes just under the timeout", func() {
+		op := &fakeOperation{duration: timeout - 50*time.Millisecond}
+		Expect(waitForCompletion(context.Background(), op, autorest.NewClientWithUserAgent(""), pollInterval, timeout)).To(Succeed())
+	})
+
+	It("should time out when the operation completes just over the timeout", func() {
+		op := &fakeOperation{duration: timeout + 50*time.Millisecond}
+		err := waitForCompletion(context.Background(), op, autorest.NewClientWithUserAgent(""), pollInterval, timeout)
+		Expect(err).To(Equal(ErrUpdateGatewayTimeout))
+	})
+
+	It("should poll with the given interval", func() {
+		op := &fakeOperation{}
+		Expect(waitForCompletion(context.Background(), op, autorest.NewClientWithUserAgent(""), pollInterval, timeout)).To(Succeed())
+		Expect(op.client.PollingDelay).To(Equal(pollInterval))
+		Expect(op.client.PollingDuration).To(Equal(180 * time.Millisecond))
+	})
+
+	It("should stop polling a little before the timeout", func() {
+		Expect(pollingDuration(timeout)).To(Equal(timeout - 20*time.Millisecond))
+		Expect(pollingDuration(DefaultUpdateTimeout)).To(Equal(DefaultUpdateTimeout - maxPollingMargin))
+	})
+
+	It("should time out when the polling duration elapses", func() {
+		op := &fakeOperation{duration: timeout - 10*time.Millisecond}
+		err := waitForCompletion(context.Background(), op, autorest.NewClientWithUserAgent(""), pollInterval, timeout)
+		Expect(err).To(Equal(ErrUpdateGatewayTimeout))
+	})
+})
@@ -0,0 +1,104 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package azure
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"net/http"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tracing"
+)
+
+// The attributes of the spans of the ARM calls.
+const (
+	AttributeCorrelationRequestID = "arm.correlation_request_id"
+	AttributeRequestID            = "arm.request_id"
+	AttributeStatusCode           = "http.status_code"
+	AttributeResourceGroup        = "appgw.resource_group"
+	AttributeAppGwName            = "appgw.name"
+)
+
+// correlationRequestIDHeader is the header of the ID ARM logs a call, and the operations it starts, under.
+const correlationRequestIDHeader = "x-ms-correlation-request-id"
+
+type correlationRequestIDKey struct{}
+
+// startARMSpan starts the span of an ARM call, as a child of the span the context of the client carries, and returns
+// the context to make the call with. A traced call is sent with a correlation ID of its own, which ARM logs it under.
+func (az *azClient) startARMSpan(name string) (*tracing.Span, context.Context) {
+	span := tracing.SpanFromContext(az.ctx).StartChild(name)
+	if span == nil {
+		return nil, az.ctx
+	}
+	correlationRequestID := newCorrelationRequestID()
+	span.SetAttribute(AttributeCorrelationRequestID, correlationRequestID)
+	span.SetAttribute(AttributeResourceGroup, string(az.resourceGroupName))
+	span.SetAttribute(AttributeAppGwName, string(az.appGwName))
+	return span, context.WithValue(az.ctx, correlationRequestIDKey{}, correlationRequestID)
+}
+
+// endARMSpan ends the span of an ARM call with the status code and the request ID of the response.
+func endARMSpan(span *tracing.Span, response *http.Response, err error) {
+	if span == nil {
+		return
+	}
+	if response == nil {
+		response = responseOfError(err)
+	}
+	if response != nil {
+		span.SetAttribute(AttributeStatusCode, response.StatusCode)
+		if id := response.Header.Get("x-ms-request-id"); id != "" {
+			span.SetAttribute(AttributeRequestID, id)
+		}
+	}
+	span.SetError(err)
+	span.End()
+}
+
+// withCorrelationRequestID is the RequestInspector of the ARM clients. It sends the requests of a call, including
+// the polls of the operation it starts, with the correlation ID the context of the call carries.
+func withCorrelationRequestID() autorest.PrepareDecorator {
+	return func(p autorest.Preparer) autorest.Preparer {
+		return autorest.PreparerFunc(func(r *http.Request) (*http.Request, error) {
+			r, err := p.Prepare(r)
+			if err != nil {
+				return r, err
+			}
+			if id, ok := r.Context().Value(correlationRequestIDKey{}).(string); ok {
+				r.Header.Set(correlationRequestIDHeader, id)
+			}
+			return r, nil
+		})
+	}
+}
+
+// newCorrelationRequestID returns a random (version 4) UUID.
+func newCorrelationRequestID() string {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	id[6] = id[6]&0x0f | 0x40
+	id[8] = id[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:16])
+}
+
+func responseOfError(err error) *http.Response {
+	switch e := err.(type) {
+	case autorest.DetailedError:
+		return e.Response
+	case *autorest.DetailedError:
+		return e.Response
+	case *azure.RequestError:
+		return e.Response
+	case azure.RequestError:
+		return e.Response
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package azure
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tracing"
+)
+
+var _ = Describe("Test spans of ARM calls", func() {
+	var arm *httptest.Server
+	var correlationRequestIDs chan string
+	var exporter *tracing.FakeExporter
+	var span *tracing.Span
+	var client AzClient
+
+	BeforeEach(func() {
+		correlationRequestIDs = make(chan string, 10)
+		arm = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			correlationRequestIDs <- r.Header.Get("x-ms-correlation-request-id")
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("x-ms-request-id", "request-"+r.Method)
+			if r.Method == http.MethodPut {
+				w.WriteHeader(http.StatusConflict)
+				_, _ = w.Write([]byte(`{"error": {"code": "AnotherOperationInProgress", "message": "busy"}}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"name": "gateway", "properties": {"provisioningState": "Succeeded"}}`))
+		}))
+
+		exporter = tracing.NewFakeExporter()
+		span = tracing.NewTracerWithExporter(exporter).StartSpan("reconcile")
+		appGatewaysClient := n.NewApplicationGatewaysClientWithBaseURI(arm.URL, "subscription")
+		appGatewaysClient.RequestInspector = withCorrelationRequestID()
+		az := &azClient{
+			appGatewaysClient:  appGatewaysClient,
+			resourceGroupName:  "group",
+			appGwName:          "gateway",
+			updatePollInterval: time.Millisecond,
+			updateTimeout:      time.Second,
+			ctx:                context.Background(),
+		}
+		client = az.WithContext(tracing.ContextWithSpan(context.Background(), span))
+	})
+
+	AfterEach(func() {
+		arm.Close()
+	})
+
+	It("should record the IDs ARM correlates a GET with", func() {
+		_, err := client.GetGateway()
+		Expect(err).ToNot(HaveOccurred())
+		span.End()
+
+		var correlationRequestID string
+		Expect(correlationRequestIDs).To(Receive(&correlationRequestID))
+		Expect(correlationRequestID).To(MatchRegexp(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`))
+
+		Eventually(exporter.Spans).Should(HaveLen(2))
+		get := exporter.Span("arm.GetGateway")
+		Expect(get.ParentSpanID()).To(Equal(span.SpanID()))
+		Expect(get.Attributes()).To(Equal(map[string]interface{}{
+			AttributeCorrelationRequestID: correlationRequestID,
+			AttributeRequestID:            "request-GET",
+			AttributeStatusCode:           http.StatusOK,
+			AttributeResourceGroup:        "group",
+			AttributeAppGwName:            "gateway",
+		}))
+		Expect(get.Err()).ToNot(HaveOccurred())
+	})
+
+	It("should record the IDs ARM correlates a failed PUT with", func() {
+		Expect(client.UpdateGateway(&n.ApplicationGateway{})).ToNot(Succeed())
+		span.End()
+
+		var correlationRequestID string
+		Expect(correlationRequestIDs).To(Receive(&correlationRequestID))
+
+		Eventually(exporter.Spans).Should(HaveLen(2))
+		put := exporter.Span("arm.UpdateGateway")
+		Expect(put.ParentSpanID()).To(Equal(span.SpanID()))
+		Expect(put.Attributes()).To(HaveKeyWithValue(AttributeCorrelationRequestID, correlationRequestID))
+		Expect(put.Attributes()).To(HaveKeyWithValue(AttributeAppGwName, "gateway"))
+		Expect(put.Err()).To(HaveOccurred())
+	})
+
+	It("should not record spans without a span in the context", func() {
+		untraced := client.WithContext(context.Background())
+		_, err := untraced.GetGateway()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(correlationRequestIDs).To(Receive(BeEmpty()))
+		Consistently(exporter.Spans).Should(BeEmpty())
+	})
+})
@@ -0,0 +1,107 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package azure
+
+import (
+	"strings"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/golang/glog"
+)
+
+// remediation is the guidance logged along with each of the errors found by ValidateGateway.
+var remediation = map[error]string{
+	ErrGatewayProvisioningFailed: "Inspect the Application Gateway's Activity Log for the failed operation;" +
+		" an update of the gateway from the Portal or CLI with the same config usually brings it back to 'Succeeded'.",
+	ErrGatewayMissingSubnet: "Application Gateway must be deployed in a subnet;" +
+		" verify the gateway IP configuration of the Application Gateway.",
+	ErrGatewaySubnetDelegated: "Application Gateway requires a dedicated subnet;" +
+		" remove the delegation from the subnet or move the Application Gateway to a subnet without delegations.",
+	ErrGatewaySubnetNotDedicated: "Application Gateway requires a dedicated subnet;" +
+		" move the other resources (VMs, AKS nodes, private endpoints etc.) out of the Application Gateway's subnet.",
+	ErrGatewayMissingIdentity: "Application Gateway requires a user assigned identity with 'get' access to secrets of the Key Vault" +
+		" to fetch Key Vault certificates; assign the identity to the gateway with 'az network application-gateway identity assign'.",
+}
+
+// ValidateGateway inspects the Application Gateway, and the subnet it is deployed in, for common setup errors,
+// which would otherwise surface as an ARM error while applying the config. Remediation guidance is logged for each error found.
+func ValidateGateway(azClient AzClient, appGw n.ApplicationGateway) []error {
+	var errs []error
+	if appGw.ApplicationGatewayPropertiesFormat == nil {
+		return errs
+	}
+
+	if appGw.ProvisioningState == n.Failed {
+		errs = append(errs, ErrGatewayProvisioningFailed)
+	}
+
+	if subnetID := getGatewaySubnetID(appGw); subnetID == nil {
+		errs = append(errs, ErrGatewayMissingSubnet)
+	} else if subnet, err := azClient.GetSubnet(*subnetID); err != nil {
+		// AGIC's identity does not necessarily have access to the Virtual Network.
+		glog.V(3).Infof("Unable to get subnet %s of the Application Gateway; Skipping subnet validation: %s", *subnetID, err)
+	} else {
+		errs = append(errs, validateGatewaySubnet(subnet)...)
+	}
+
+	if usesKeyVaultCertificates(appGw) && !hasUserAssignedIdentity(appGw) {
+		errs = append(errs, ErrGatewayMissingIdentity)
+	}
+
+	for _, err := range errs {
+		glog.Errorf("Application Gateway validation failed: %s; %s", err, remediation[err])
+	}
+	return errs
+}
+
+func getGatewaySubnetID(appGw n.ApplicationGateway) *string {
+	if appGw.GatewayIPConfigurations == nil {
+		return nil
+	}
+	for _, ipConf := range *appGw.GatewayIPConfigurations {
+		if ipConf.ApplicationGatewayIPConfigurationPropertiesFormat != nil && ipConf.Subnet != nil && ipConf.Subnet.ID != nil {
+			return ipConf.Subnet.ID
+		}
+	}
+	return nil
+}
+
+func validateGatewaySubnet(subnet n.Subnet) []error {
+	var errs []error
+	if subnet.SubnetPropertiesFormat == nil {
+		return errs
+	}
+
+	if subnet.Delegations != nil && len(*subnet.Delegations) > 0 {
+		errs = append(errs, ErrGatewaySubnetDelegated)
+	}
+
+	if subnet.IPConfigurations != nil {
+		for _, ipConf := range *subnet.IPConfigurations {
+			if ipConf.ID != nil && !strings.Contains(strings.ToLower(*ipConf.ID), "/providers/microsoft.network/applicationgateways/") {
+				errs = append(errs, ErrGatewaySubnetNotDedicated)
+				break
+			}
+		}
+	}
+	return errs
+}
+
+func usesKeyVaultCertificates(appGw n.ApplicationGateway) bool {
+	if appGw.SslCertificates == nil {
+		return false
+	}
+	for _, cert := range *appGw.SslCertificates {
+		if cert.ApplicationGatewaySslCertificatePropertiesFormat != nil && cert.KeyVaultSecretID != nil && *cert.KeyVaultSecretID != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func hasUserAssignedIdentity(appGw n.ApplicationGateway) bool {
+	return appGw.Identity != nil && len(appGw.Identity.UserAssignedIdentities) > 0
+}
@@ -0,0 +1,99 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package azure
+
+import (
+	"errors"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Test ValidateGateway", func() {
+	subnetID := "/subscriptions/xxxx/resourceGroups/yyyy/providers/Microsoft.Network/virtualNetworks/vnet/subnets/appgw-subnet"
+	appGwIPConfID := "/subscriptions/xxxx/resourceGroups/yyyy/providers/Microsoft.Network/applicationGateways/appgw/gatewayIPConfigurations/appgw-ip"
+
+	var client *FakeAzClient
+	var appGw n.ApplicationGateway
+	var subnet n.Subnet
+
+	BeforeEach(func() {
+		appGw = n.ApplicationGateway{
+			ApplicationGatewayPropertiesFormat: &n.ApplicationGatewayPropertiesFormat{
+				ProvisioningState: n.Succeeded,
+				GatewayIPConfigurations: &[]n.ApplicationGatewayIPConfiguration{
+					{
+						ApplicationGatewayIPConfigurationPropertiesFormat: &n.ApplicationGatewayIPConfigurationPropertiesFormat{
+							Subnet: &n.SubResource{ID: to.StringPtr(subnetID)},
+						},
+					},
+				},
+			},
+		}
+		subnet = n.Subnet{
+			SubnetPropertiesFormat: &n.SubnetPropertiesFormat{
+				IPConfigurations: &[]n.IPConfiguration{{ID: to.StringPtr(appGwIPConfID)}},
+			},
+		}
+		client = NewFakeAzClient()
+		client.GetSubnetFunc = func(resourceID string) (n.Subnet, error) {
+			Expect(resourceID).To(Equal(subnetID))
+			return subnet, nil
+		}
+	})
+
+	It("should not find errors in a valid setup", func() {
+		Expect(ValidateGateway(client, appGw)).To(BeEmpty())
+	})
+
+	It("should find a failed provisioning state", func() {
+		appGw.ProvisioningState = n.Failed
+		Expect(ValidateGateway(client, appGw)).To(ConsistOf(ErrGatewayProvisioningFailed))
+	})
+
+	It("should find a missing subnet", func() {
+		appGw.GatewayIPConfigurations = nil
+		Expect(ValidateGateway(client, appGw)).To(ConsistOf(ErrGatewayMissingSubnet))
+	})
+
+	It("should find a delegated subnet", func() {
+		subnet.Delegations = &[]n.Delegation{{Name: to.StringPtr("aci")}}
+		Expect(ValidateGateway(client, appGw)).To(ConsistOf(ErrGatewaySubnetDelegated))
+	})
+
+	It("should find a subnet shared with other resources", func() {
+		nicIPConfID := "/subscriptions/xxxx/resourceGroups/yyyy/providers/Microsoft.Network/networkInterfaces/nic/ipConfigurations/ipconfig1"
+		subnet.IPConfigurations = &[]n.IPConfiguration{{ID: to.StringPtr(appGwIPConfID)}, {ID: to.StringPtr(nicIPConfID)}}
+		Expect(ValidateGateway(client, appGw)).To(ConsistOf(ErrGatewaySubnetNotDedicated))
+	})
+
+	It("should skip subnet validation when the subnet can not be fetched", func() {
+		client.GetSubnetFunc = func(resourceID string) (n.Subnet, error) {
+			return n.Subnet{}, errors.New("403")
+		}
+		Expect(ValidateGateway(client, appGw)).To(BeEmpty())
+	})
+
+	It("should find Key Vault certificates without an identity", func() {
+		appGw.SslCertificates = &[]n.ApplicationGatewaySslCertificate{
+			{
+				ApplicationGatewaySslCertificatePropertiesFormat: &n.ApplicationGatewaySslCertificatePropertiesFormat{
+					KeyVaultSecretID: to.StringPtr("https://vault.vault.azure.net/secrets/cert"),
+				},
+			},
+		}
+		Expect(ValidateGateway(client, appGw)).To(ConsistOf(ErrGatewayMissingIdentity))
+
+		appGw.Identity = &n.ManagedServiceIdentity{
+			UserAssignedIdentities: map[string]*n.ManagedServiceIdentityUserAssignedIdentitiesValue{
+				"/subscriptions/xxxx/resourceGroups/yyyy/providers/Microsoft.ManagedIdentity/userAssignedIdentities/id": {},
+			},
+		}
+		Expect(ValidateGateway(client, appGw)).To(BeEmpty())
+	})
+})
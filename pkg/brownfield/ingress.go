@@ -27,13 +27,11 @@ func PruneIngressRules(ing *v1beta1.Ingress, prohibitedTargets []*ptv1.AzureIngr
 	var rules []v1beta1.IngressRule
 
 	for _, rule := range ing.Spec.Rules {
-		if rule.HTTP == nil {
-			continue
-		}
 		target := Target{
 			Hostname: rule.Host,
 		}
-		if rule.HTTP.Paths == nil {
+		// A rule without paths is served by the default backend of the ingress.
+		if rule.HTTP == nil || rule.HTTP.Paths == nil {
 			if target.IsBlacklisted(blacklist) {
 				continue
 			}
@@ -6,6 +6,7 @@
 package brownfield
 
 import (
+	"sort"
 	"strings"
 
 	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
@@ -34,38 +35,49 @@ func (er ExistingResources) GetBlacklistedListeners() ([]n.ApplicationGatewayHTT
 }
 
 type uniqueListenerConfig struct {
-	HostName                string
+	HostNames               string
 	Protocol                n.ApplicationGatewayProtocol
 	FrontendPortID          string
 	FrontendIPConfiguration string
 }
 
-// MergeListeners merges list of lists of listeners into a single list, maintaining uniqueness.
+// MergeListeners merges list of lists of listeners into a single list, maintaining uniqueness. Listeners bound to the
+// same frontend IP configuration and port, for the same host names, are duplicates App Gateway refuses; the one in
+// the earliest list is kept, so that the existing listeners passed first are preferred over new ones.
+// ARM does not preserve the case of the resource IDs, so they are compared regardless of case.
 func MergeListeners(listenerBuckets ...[]n.ApplicationGatewayHTTPListener) []n.ApplicationGatewayHTTPListener {
-	uniq := make(map[uniqueListenerConfig]n.ApplicationGatewayHTTPListener)
+	uniq := make(map[uniqueListenerConfig]interface{})
+	var merged []n.ApplicationGatewayHTTPListener
 	for _, bucket := range listenerBuckets {
 		for _, listener := range bucket {
 			listenerConfig := uniqueListenerConfig{
 				Protocol: listener.Protocol,
 			}
-			if listener.HostName != nil {
-				listenerConfig.HostName = *listener.HostName
+			var hostNames []string
+			if listener.Hostnames != nil {
+				hostNames = append(hostNames, *listener.Hostnames...)
+			} else if listener.HostName != nil {
+				hostNames = append(hostNames, *listener.HostName)
+			}
+			for idx := range hostNames {
+				hostNames[idx] = strings.ToLower(hostNames[idx])
 			}
+			sort.Strings(hostNames)
+			listenerConfig.HostNames = strings.Join(hostNames, ",")
 			if listener.FrontendIPConfiguration != nil && listener.FrontendIPConfiguration.ID != nil {
-				listenerConfig.FrontendIPConfiguration = *listener.FrontendIPConfiguration.ID
+				listenerConfig.FrontendIPConfiguration = strings.ToLower(*listener.FrontendIPConfiguration.ID)
 			}
 			if listener.FrontendPort != nil && listener.FrontendPort.ID != nil {
-				listenerConfig.FrontendPortID = *listener.FrontendPort.ID
+				listenerConfig.FrontendPortID = strings.ToLower(*listener.FrontendPort.ID)
 			}
-			if _, exists := uniq[listenerConfig]; !exists {
-				uniq[listenerConfig] = listener
+			if _, exists := uniq[listenerConfig]; exists {
+				glog.V(3).Infof("[brownfield] Listener %s duplicates an existing listener; it is not added", *listener.Name)
+				continue
 			}
+			uniq[listenerConfig] = nil
+			merged = append(merged, listener)
 		}
 	}
-	var merged []n.ApplicationGatewayHTTPListener
-	for _, listener := range uniq {
-		merged = append(merged, listener)
-	}
 	return merged
 }
 
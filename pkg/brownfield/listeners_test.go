@@ -6,6 +6,8 @@
 package brownfield
 
 import (
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
@@ -128,4 +130,42 @@ var _ = Describe("Test blacklist listeners", func() {
 		})
 	})
 
+	Context("Test MergeListeners()", func() {
+		newListener := func(name, ipID, portID string, hostNames ...string) n.ApplicationGatewayHTTPListener {
+			listener := n.ApplicationGatewayHTTPListener{
+				Name: to.StringPtr(name),
+				ApplicationGatewayHTTPListenerPropertiesFormat: &n.ApplicationGatewayHTTPListenerPropertiesFormat{
+					FrontendIPConfiguration: &n.SubResource{ID: to.StringPtr(ipID)},
+					FrontendPort:            &n.SubResource{ID: to.StringPtr(portID)},
+					Protocol:                n.HTTP,
+				},
+			}
+			if len(hostNames) == 1 {
+				listener.HostName = to.StringPtr(hostNames[0])
+			} else if len(hostNames) > 1 {
+				listener.Hostnames = &hostNames
+			}
+			return listener
+		}
+
+		It("should keep the existing listener over a new one on the same frontend IP and port, regardless of the case of the IDs", func() {
+			existing := newListener("existing", "/x/y/z/frontendIPConfigurations/appGatewayFrontendIP", "/x/y/z/frontendPorts/fp-80", tests.Host)
+			managed := newListener("fl-80", "/X/Y/Z/frontendIPConfigurations/appGatewayFrontendIP", "/X/Y/Z/frontendPorts/fp-80", tests.Host)
+			Expect(MergeListeners([]n.ApplicationGatewayHTTPListener{existing}, []n.ApplicationGatewayHTTPListener{managed})).To(Equal([]n.ApplicationGatewayHTTPListener{existing}))
+		})
+
+		It("should detect duplicates among listeners with several host names", func() {
+			existing := newListener("existing", "/x/y/z/ip", "/x/y/z/port", "a.com", "b.com")
+			managed := newListener("managed", "/x/y/z/ip", "/x/y/z/port", "B.com", "a.com")
+			Expect(MergeListeners([]n.ApplicationGatewayHTTPListener{existing}, []n.ApplicationGatewayHTTPListener{managed})).To(Equal([]n.ApplicationGatewayHTTPListener{existing}))
+		})
+
+		It("should keep the listeners on other frontend IPs, in order", func() {
+			public := newListener("public", "/x/y/z/public", "/x/y/z/port", tests.Host)
+			private := newListener("private", "/x/y/z/private", "/x/y/z/port", tests.Host)
+			other := newListener("other", "/x/y/z/public", "/x/y/z/port", tests.OtherHost)
+			merged := MergeListeners([]n.ApplicationGatewayHTTPListener{public}, []n.ApplicationGatewayHTTPListener{private, other})
+			Expect(merged).To(Equal([]n.ApplicationGatewayHTTPListener{public, private, other}))
+		})
+	})
 })
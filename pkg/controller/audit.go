@@ -0,0 +1,245 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/golang/glog"
+	"k8s.io/api/extensions/v1beta1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+)
+
+// Keys ignored when comparing App Gateway sub-resources: ARM returns the etag and provisioning state,
+// but does not return the data and password of certificates.
+var keysToDeleteForAudit = []string{
+	"etag",
+	"provisioningState",
+	"data",
+	"password",
+}
+
+// auditChange summarizes the changes to one type of App Gateway sub-resource, such as httpListeners, by name.
+type auditChange struct {
+	Kind     string   `json:"kind"`
+	Added    []string `json:"added,omitempty"`
+	Removed  []string `json:"removed,omitempty"`
+	Modified []string `json:"modified,omitempty"`
+}
+
+// auditEntry is logged for each App Gateway config AGIC applies.
+type auditEntry struct {
+	AppGateway string        `json:"appGateway"`
+	Ingresses  []string      `json:"ingresses"`
+	Changes    []auditChange `json:"changes"`
+}
+
+// ingressVersions holds the resource versions of the ingresses as of the last applied App Gateway config.
+type ingressVersions map[string]string
+
+// update records the resource versions of the given ingresses and returns the ingresses (namespace/name), which were
+// added, changed or deleted since the previous update. These are the ingresses a change to the App Gateway is attributed to.
+func (versions ingressVersions) update(ingresses []*v1beta1.Ingress) []string {
+	current := make(map[string]string)
+	for _, ingress := range ingresses {
+		current[ingress.Namespace+"/"+ingress.Name] = ingress.ResourceVersion
+	}
+
+	var changed []string
+	for key, version := range current {
+		if previous, exists := versions[key]; !exists || previous != version {
+			changed = append(changed, key)
+		}
+	}
+	for key := range versions {
+		if _, exists := current[key]; !exists {
+			changed = append(changed, key)
+			delete(versions, key)
+		}
+	}
+	for key, version := range current {
+		versions[key] = version
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// getAuditChanges compares the sub-resources of two App Gateway configs, given as JSON. A sub-resource is modified
+// when a property set in the updated config differs from the existing config; properties ARM adds are not compared.
+func getAuditChanges(existingJSON, updatedJSON []byte) ([]auditChange, error) {
+	existing, err := getAuditSubResources(existingJSON)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := getAuditSubResources(updatedJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	kinds := make(map[string]interface{})
+	for kind := range existing {
+		kinds[kind] = nil
+	}
+	for kind := range updated {
+		kinds[kind] = nil
+	}
+	var sortedKinds []string
+	for kind := range kinds {
+		sortedKinds = append(sortedKinds, kind)
+	}
+	sort.Strings(sortedKinds)
+
+	var changes []auditChange
+	for _, kind := range sortedKinds {
+		change := auditChange{Kind: kind}
+		for name, updatedObj := range updated[kind] {
+			existingObj, exists := existing[kind][name]
+			if !exists {
+				change.Added = append(change.Added, name)
+			} else if !isAuditSubset(updatedObj, existingObj) {
+				change.Modified = append(change.Modified, name)
+			}
+		}
+		for name := range existing[kind] {
+			if _, exists := updated[kind][name]; !exists {
+				change.Removed = append(change.Removed, name)
+			}
+		}
+		if len(change.Added)+len(change.Removed)+len(change.Modified) == 0 {
+			continue
+		}
+		sort.Strings(change.Added)
+		sort.Strings(change.Removed)
+		sort.Strings(change.Modified)
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// getAuditSubResources returns the named sub-resources of an App Gateway config, by kind and name.
+func getAuditSubResources(appGwJSON []byte) (map[string]map[string]interface{}, error) {
+	sanitized, err := deleteKeyFromJSON(appGwJSON, keysToDeleteForAudit...)
+	if err != nil {
+		return nil, err
+	}
+	var appGw map[string]interface{}
+	if err := json.Unmarshal(sanitized, &appGw); err != nil {
+		return nil, err
+	}
+
+	subResources := make(map[string]map[string]interface{})
+	properties, _ := appGw["properties"].(map[string]interface{})
+	for kind, value := range properties {
+		list, isList := value.([]interface{})
+		if !isList {
+			continue
+		}
+		for _, item := range list {
+			obj, isMap := item.(map[string]interface{})
+			if !isMap {
+				continue
+			}
+			name, hasName := obj["name"].(string)
+			if !hasName {
+				continue
+			}
+			if _, exists := subResources[kind]; !exists {
+				subResources[kind] = make(map[string]interface{})
+			}
+			subResources[kind][name] = obj
+		}
+	}
+	return subResources, nil
+}
+
+// isAuditSubset determines whether every property of the updated value has the same value in the existing value.
+func isAuditSubset(updated, existing interface{}) bool {
+	switch updatedValue := updated.(type) {
+	case map[string]interface{}:
+		existingValue, isMap := existing.(map[string]interface{})
+		if !isMap {
+			return false
+		}
+		for key, value := range updatedValue {
+			if !isAuditSubset(value, existingValue[key]) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		existingValue, isList := existing.([]interface{})
+		if !isList || len(existingValue) != len(updatedValue) {
+			return false
+		}
+		for idx := range updatedValue {
+			if !isAuditSubset(updatedValue[idx], existingValue[idx]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return reflect.DeepEqual(updated, existing)
+	}
+}
+
+// summarizeAuditChanges counts the added (+), removed (-) and modified (~) sub-resources of each kind.
+func summarizeAuditChanges(changes []auditChange) string {
+	if len(changes) == 0 {
+		return "no sub-resources changed"
+	}
+	var summary []string
+	for _, change := range changes {
+		summary = append(summary, fmt.Sprintf("%s +%d -%d ~%d", change.Kind, len(change.Added), len(change.Removed), len(change.Modified)))
+	}
+	return strings.Join(summary, ", ")
+}
+
+// auditAppliedConfig logs a summary of the changes AGIC applied to the App Gateway, attributed to the ingresses which
+// changed since the last applied config, and emits an event on these ingresses and on the AGIC pod.
+// Changes caused by other resources, such as endpoints, are logged without ingresses.
+func (c AppGwIngressController) auditAppliedConfig(existingJSON []byte, appliedAppGw *n.ApplicationGateway, ingressList []*v1beta1.Ingress) {
+	changedIngresses := c.auditIngressVersions.update(ingressList)
+
+	appliedJSON, err := appliedAppGw.MarshalJSON()
+	if err != nil {
+		glog.Error("[audit] Could not marshal the applied App Gateway config: ", err)
+		return
+	}
+	changes, err := getAuditChanges(existingJSON, appliedJSON)
+	if err != nil {
+		glog.Error("[audit] Could not compare the existing and the applied App Gateway config: ", err)
+		return
+	}
+
+	entry := auditEntry{
+		AppGateway: c.appGwIdentifier.AppGwName,
+		Ingresses:  changedIngresses,
+		Changes:    changes,
+	}
+	entryJSON, _ := json.Marshal(entry)
+	glog.Infof("[audit] %s", entryJSON)
+
+	message := fmt.Sprintf("Applied App Gateway config: %s", summarizeAuditChanges(changes))
+	if c.agicPod != nil {
+		events.Normal(c.recorder, c.agicPod, events.ReasonAppGwConfigApplied, message)
+	}
+
+	changed := make(map[string]interface{})
+	for _, key := range changedIngresses {
+		changed[key] = nil
+	}
+	for _, ingress := range ingressList {
+		if _, exists := changed[ingress.Namespace+"/"+ingress.Name]; exists {
+			events.Normal(c.recorder, ingress, events.ReasonAppGwConfigApplied, message)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/appgw"
+)
+
+var _ = Describe("audit log of applied App Gateway configs", func() {
+	newListener := func(name string, hostName string) n.ApplicationGatewayHTTPListener {
+		return n.ApplicationGatewayHTTPListener{
+			Name: to.StringPtr(name),
+			Etag: to.StringPtr("*"),
+			ApplicationGatewayHTTPListenerPropertiesFormat: &n.ApplicationGatewayHTTPListenerPropertiesFormat{
+				HostName: to.StringPtr(hostName),
+				Protocol: n.HTTP,
+			},
+		}
+	}
+	newPool := func(name string, ips ...string) n.ApplicationGatewayBackendAddressPool {
+		var addresses []n.ApplicationGatewayBackendAddress
+		for _, ip := range ips {
+			addresses = append(addresses, n.ApplicationGatewayBackendAddress{IPAddress: to.StringPtr(ip)})
+		}
+		return n.ApplicationGatewayBackendAddressPool{
+			Name: to.StringPtr(name),
+			ApplicationGatewayBackendAddressPoolPropertiesFormat: &n.ApplicationGatewayBackendAddressPoolPropertiesFormat{
+				BackendAddresses: &addresses,
+			},
+		}
+	}
+	newIngress := func(name, resourceVersion string) *v1beta1.Ingress {
+		return &v1beta1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:       "default",
+				Name:            name,
+				ResourceVersion: resourceVersion,
+			},
+		}
+	}
+
+	var existing, applied n.ApplicationGateway
+
+	BeforeEach(func() {
+		existingPool := newPool("pool-web", "10.0.0.1")
+		// ARM adds the provisioning state and read-only properties to the objects it returns.
+		existingPool.ProvisioningState = n.Succeeded
+		existingPool.BackendIPConfigurations = &[]n.InterfaceIPConfiguration{}
+		existing = n.ApplicationGateway{
+			ApplicationGatewayPropertiesFormat: &n.ApplicationGatewayPropertiesFormat{
+				HTTPListeners: &[]n.ApplicationGatewayHTTPListener{
+					newListener("fl-web", "www.contoso.com"),
+					newListener("fl-old", "old.contoso.com"),
+				},
+				BackendAddressPools: &[]n.ApplicationGatewayBackendAddressPool{
+					existingPool,
+					newPool("pool-api", "10.0.1.1"),
+				},
+				SslCertificates: &[]n.ApplicationGatewaySslCertificate{
+					{
+						Name: to.StringPtr("cert-web"),
+						ApplicationGatewaySslCertificatePropertiesFormat: &n.ApplicationGatewaySslCertificatePropertiesFormat{
+							PublicCertData: to.StringPtr("--public--"),
+						},
+					},
+				},
+			},
+		}
+		applied = n.ApplicationGateway{
+			ApplicationGatewayPropertiesFormat: &n.ApplicationGatewayPropertiesFormat{
+				HTTPListeners: &[]n.ApplicationGatewayHTTPListener{
+					newListener("fl-web", "www.contoso.com"),
+					newListener("fl-new", "new.contoso.com"),
+				},
+				BackendAddressPools: &[]n.ApplicationGatewayBackendAddressPool{
+					newPool("pool-web", "10.0.0.1"),
+					newPool("pool-api", "10.0.1.1", "10.0.1.2"),
+				},
+				SslCertificates: &[]n.ApplicationGatewaySslCertificate{
+					{
+						Name: to.StringPtr("cert-web"),
+						ApplicationGatewaySslCertificatePropertiesFormat: &n.ApplicationGatewaySslCertificatePropertiesFormat{
+							Data:     to.StringPtr("--pfx--"),
+							Password: to.StringPtr("--password--"),
+						},
+					},
+				},
+			},
+		}
+	})
+
+	It("summarizes the added, removed and modified sub-resources", func() {
+		existingJSON, _ := existing.MarshalJSON()
+		appliedJSON, _ := applied.MarshalJSON()
+		changes, err := getAuditChanges(existingJSON, appliedJSON)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changes).To(Equal([]auditChange{
+			{Kind: "backendAddressPools", Modified: []string{"pool-api"}},
+			{Kind: "httpListeners", Added: []string{"fl-new"}, Removed: []string{"fl-old"}},
+		}))
+		Expect(summarizeAuditChanges(changes)).To(Equal("backendAddressPools +0 -0 ~1, httpListeners +1 -1 ~0"))
+	})
+
+	It("attributes the changes to the ingresses changed since the last applied config", func() {
+		versions := make(ingressVersions)
+		Expect(versions.update([]*v1beta1.Ingress{newIngress("web", "1"), newIngress("api", "1")})).To(Equal([]string{"default/api", "default/web"}))
+		Expect(versions.update([]*v1beta1.Ingress{newIngress("web", "1"), newIngress("api", "1")})).To(BeEmpty())
+		Expect(versions.update([]*v1beta1.Ingress{newIngress("web", "2"), newIngress("new", "1")})).To(Equal([]string{"default/api", "default/new", "default/web"}))
+		Expect(versions).To(Equal(ingressVersions{"default/web": "2", "default/new": "1"}))
+	})
+
+	It("emits an event on the ingresses, which changed", func() {
+		recorder := record.NewFakeRecorder(10)
+		controller := AppGwIngressController{
+			appGwIdentifier:      appgw.Identifier{AppGwName: "--app-gw--"},
+			recorder:             recorder,
+			auditIngressVersions: ingressVersions{"default/web": "1"},
+		}
+		existingJSON, _ := existing.MarshalJSON()
+		controller.auditAppliedConfig(existingJSON, &applied, []*v1beta1.Ingress{newIngress("web", "1"), newIngress("api", "3")})
+
+		Expect(recorder.Events).To(HaveLen(1))
+		Expect(<-recorder.Events).To(Equal("Normal AppGwConfigApplied Applied App Gateway config: backendAddressPools +0 -0 ~1, httpListeners +1 -1 ~0"))
+	})
+})
@@ -0,0 +1,79 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/golang/glog"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/appgw"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+)
+
+// cleanupStopTimeout is how long the cleanup waits for the pods of the Deployment of AGIC to terminate.
+const cleanupStopTimeout = 5 * time.Minute
+
+// Cleanup removes the objects owned by this AGIC instance from App Gateway; it is run when AGIC is uninstalled.
+// Objects protected by AzureIngressProhibitedTargets and objects not owned by this instance are left untouched.
+// The Deployment of AGIC, when named by AGIC_DEPLOYMENT_NAME, is scaled to 0 replicas first: its pods would otherwise
+// re-apply the removed config on their next reconcile.
+func (c *AppGwIngressController) Cleanup(envVariables environment.EnvVariables) error {
+	if envVariables.AGICDeploymentName != "" {
+		if err := c.k8sContext.StopDeployment(envVariables.AGICPodNamespace, envVariables.AGICDeploymentName, cleanupStopTimeout); err != nil {
+			glog.Error("Could not stop the Deployment of AGIC: ", err)
+			return err
+		}
+	}
+
+	// Informers are needed for the brownfield prohibited targets; the worker is not started as no ingress is reconciled.
+	if err := c.k8sContext.Run(c.stopChannel, false, envVariables); err != nil {
+		glog.Error("Could not start Kubernetes Context: ", err)
+		return err
+	}
+
+	appGw, cbCtx, err := c.getAppGw()
+	if err != nil {
+		return err
+	}
+	cbCtx.EnvVariables = envVariables
+	c.setProhibitedTargets(cbCtx)
+
+	existingConfigJSON, _ := dumpSanitizedJSON(appGw, false, to.StringPtr("-- Existing App Gwy Config --"))
+	glog.V(5).Info("Existing App Gateway config: ", string(existingConfigJSON))
+
+	configBuilder := appgw.NewConfigBuilder(c.k8sContext, &c.appGwIdentifier, appGw, c.recorder, realClock{})
+	generatedAppGw, err := configBuilder.Cleanup(cbCtx)
+	if err != nil {
+		errorLine := fmt.Sprint("ConfigBuilder Cleanup returned error:", err)
+		glog.Error(errorLine)
+		if c.agicPod != nil {
+			events.Warning(c.recorder, c.agicPod, events.ReasonValidatonError, errorLine)
+		}
+		return err
+	}
+
+	glog.V(3).Info("BEGIN AppGateway cleanup")
+	defer glog.V(3).Info("END AppGateway cleanup")
+
+	deploymentStart := time.Now()
+	if err = c.azClient.UpdateGateway(generatedAppGw); err != nil {
+		errorLine := fmt.Sprint("Failed removing AGIC config from App Gateway: ", err)
+		glog.Error(errorLine)
+		if c.agicPod != nil {
+			events.Warning(c.recorder, c.agicPod, events.ReasonFailedApplyingAppGwConfig, errorLine)
+		}
+		c.metricStore.IncArmAPIUpdateCallFailureCounter()
+		return err
+	}
+
+	glog.V(1).Infof("Removed AGIC config from App Gateway in %+v", time.Now().Sub(deploymentStart).String())
+	c.metricStore.IncArmAPIUpdateCallSuccessCounter()
+	return nil
+}
@@ -0,0 +1,51 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/golang/glog"
+	"k8s.io/api/extensions/v1beta1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+)
+
+// getConfigHash returns the hash of the App Gateway config in the cache, which is the last config applied, without
+// the ETags and tags changing with each update; or "" when the cache is empty.
+func (c AppGwIngressController) getConfigHash() string {
+	if c.configCache == nil || len(*c.configCache) == 0 {
+		return ""
+	}
+	sum := sha256.Sum256(*c.configCache)
+	return hex.EncodeToString(sum[:])
+}
+
+// stampConfigHash annotates the ingresses, which the App Gateway config just applied was built from, with its hash.
+// Ingresses already annotated with it, and the tombstones of removed ingresses, are left alone. AGIC does not reconcile
+// on changes to the annotation, so writing it does not lead to another apply.
+func (c AppGwIngressController) stampConfigHash(ingressList []*v1beta1.Ingress) {
+	hash := c.getConfigHash()
+	if hash == "" {
+		return
+	}
+	glog.V(3).Infof("[config_hash] Applied App Gateway config %s", hash)
+	for _, ingress := range ingressList {
+		if ingress.Annotations[annotations.AppliedConfigHashKey] == hash {
+			continue
+		}
+		if c.ingressTombstones != nil && c.ingressTombstones.IsTombstone(ingress) {
+			continue
+		}
+		if err := c.k8sContext.SetIngressAnnotation(*ingress, annotations.AppliedConfigHashKey, hash); err != nil {
+			events.Warning(c.recorder, ingress, events.ReasonUnableToAnnotateIngress, err.Error())
+			continue
+		}
+		glog.V(5).Infof("[config_hash] Annotated ingress %s/%s with the hash of the applied config", ingress.Namespace, ingress.Name)
+	}
+}
@@ -0,0 +1,146 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"time"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	testclient "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/appgw"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/agic_crd_client/clientset/versioned/fake"
+	istioFake "github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/istio_crd_client/clientset/versioned/fake"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/k8scontext"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/metricstore"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
+)
+
+var _ = Describe("annotating ingresses with the hash of the applied App Gateway config", func() {
+	var k8sClient *testclient.Clientset
+	var recorder *record.FakeRecorder
+	var controller AppGwIngressController
+
+	newAppGw := func(etag string, requestTimeout int32) *n.ApplicationGateway {
+		return &n.ApplicationGateway{
+			Etag: to.StringPtr(etag),
+			ApplicationGatewayPropertiesFormat: &n.ApplicationGatewayPropertiesFormat{
+				BackendHTTPSettingsCollection: &[]n.ApplicationGatewayBackendHTTPSettings{{
+					Name: to.StringPtr("bp-web-80"),
+					ApplicationGatewayBackendHTTPSettingsPropertiesFormat: &n.ApplicationGatewayBackendHTTPSettingsPropertiesFormat{
+						RequestTimeout: to.Int32Ptr(requestTimeout),
+					},
+				}},
+			},
+		}
+	}
+
+	// Returns the ingresses, as AGIC would next list them, along with the number of patches made to them.
+	getIngresses := func() ([]*v1beta1.Ingress, int) {
+		ingress, err := k8sClient.ExtensionsV1beta1().Ingresses(tests.Namespace).Get(tests.Name, metav1.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		patches := 0
+		for _, action := range k8sClient.Actions() {
+			if action.GetVerb() == "patch" {
+				patches++
+			}
+		}
+		return []*v1beta1.Ingress{ingress}, patches
+	}
+
+	BeforeEach(func() {
+		k8sClient = testclient.NewSimpleClientset()
+		_, err := k8sClient.ExtensionsV1beta1().Ingresses(tests.Namespace).Create(tests.NewIngressFixture())
+		Expect(err).ToNot(HaveOccurred())
+		recorder = record.NewFakeRecorder(100)
+		controller = AppGwIngressController{
+			k8sContext:  k8scontext.NewContext(k8sClient, fake.NewSimpleClientset(), istioFake.NewSimpleClientset(), nil, time.Second, k8scontext.DefaultWorkQueueDepth, metricstore.NewFakeMetricStore()),
+			recorder:    recorder,
+			configCache: to.ByteSlicePtr([]byte{}),
+		}
+	})
+
+	It("annotates the ingresses with the hash of each config applied", func() {
+		controller.updateCache(newAppGw("1", 30))
+		ingresses, _ := getIngresses()
+		controller.stampConfigHash(ingresses)
+
+		ingresses, patches := getIngresses()
+		firstHash := ingresses[0].Annotations[annotations.AppliedConfigHashKey]
+		Expect(firstHash).To(HaveLen(64))
+		Expect(patches).To(Equal(1))
+		// The other annotations of the ingress are kept.
+		Expect(ingresses[0].Annotations).To(HaveKeyWithValue(annotations.IngressClassKey, annotations.ApplicationGatewayIngressClass))
+
+		// A long request timeout makes another config, with another hash.
+		controller.updateCache(newAppGw("2", 3600))
+		controller.stampConfigHash(ingresses)
+
+		ingresses, patches = getIngresses()
+		Expect(ingresses[0].Annotations[annotations.AppliedConfigHashKey]).ToNot(BeEmpty())
+		Expect(ingresses[0].Annotations[annotations.AppliedConfigHashKey]).ToNot(Equal(firstHash))
+		Expect(patches).To(Equal(2))
+		Expect(recorder.Events).To(BeEmpty())
+	})
+
+	It("does not annotate the ingresses again with the hash of the same config", func() {
+		controller.updateCache(newAppGw("1", 30))
+		ingresses, _ := getIngresses()
+		controller.stampConfigHash(ingresses)
+
+		// The config is the same, whatever its ETag.
+		controller.updateCache(newAppGw("2", 30))
+		ingresses, _ = getIngresses()
+		controller.stampConfigHash(ingresses)
+
+		_, patches := getIngresses()
+		Expect(patches).To(Equal(1))
+	})
+
+	It("emits an event when an ingress cannot be annotated", func() {
+		missing := tests.NewIngressFixture()
+		missing.Name = "missing"
+		controller.updateCache(newAppGw("1", 30))
+		controller.stampConfigHash([]*v1beta1.Ingress{missing})
+
+		Expect(recorder.Events).To(HaveLen(1))
+		Expect(<-recorder.Events).To(HavePrefix("Warning " + string(events.ReasonUnableToAnnotateIngress)))
+	})
+
+	It("does not annotate the tombstones of removed ingresses", func() {
+		removed := tests.NewIngressFixture()
+		removed.Name = "removed"
+		ingresses, _ := getIngresses()
+		controller.ingressTombstones = appgw.NewIngressTombstones()
+		controller.ingressTombstones.Retain(append(ingresses, removed), time.Minute, time.Now())
+		retained := controller.ingressTombstones.Retain(ingresses, time.Minute, time.Now())
+		Expect(retained).To(ContainElement(removed))
+
+		controller.updateCache(newAppGw("1", 30))
+		controller.stampConfigHash(retained)
+
+		ingresses, patches := getIngresses()
+		Expect(ingresses[0].Annotations[annotations.AppliedConfigHashKey]).ToNot(BeEmpty())
+		Expect(patches).To(Equal(1))
+		Expect(recorder.Events).To(BeEmpty(), "the removed ingress is not patched")
+	})
+
+	It("does not annotate the ingresses without an applied config", func() {
+		ingresses, _ := getIngresses()
+		controller.stampConfigHash(ingresses)
+
+		_, patches := getIngresses()
+		Expect(patches).To(Equal(0))
+	})
+})
@@ -6,6 +6,8 @@
 package controller
 
 import (
+	"net"
+
 	"github.com/Azure/go-autorest/autorest/to"
 	"github.com/golang/glog"
 	v1 "k8s.io/api/core/v1"
@@ -16,6 +18,7 @@ import (
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/k8scontext"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/metricstore"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tracing"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/worker"
 )
 
@@ -36,24 +39,77 @@ type AppGwIngressController struct {
 	metricStore metricstore.MetricStore
 
 	stopChannel chan struct{}
+
+	// stopping is closed as soon as the controller begins to shut down; workerDone once the worker has stopped.
+	stopping   chan struct{}
+	workerDone chan struct{}
+
+	syncStatus *syncStatus
+
+	poolDrains   *appgw.PoolDrains
+	poolRemovals *appgw.PoolRemovals
+
+	ingressTombstones *appgw.IngressTombstones
+
+	fqdnResolutions *appgw.FQDNResolutions
+
+	autoscaleSchedule *appgw.AutoscaleSchedule
+
+	resolvedCache *appgw.ResolvedCache
+	probeWarnings *appgw.ProbeWarnings
+
+	firewallPolicies *firewallPolicies
+
+	auditIngressVersions   ingressVersions
+	summaryIngressVersions ingressVersions
+
+	ingressClassMismatches ingressClassMismatches
+
+	lastDesiredConfig *desiredConfig
+
+	lastKnownGood *lastKnownGood
+
+	// tracer records the spans of the reconciles; nil unless an OTLP endpoint is set.
+	tracer *tracing.Tracer
+
+	// staging is the App Gateway each changed config is applied to first; nil unless a staging App Gateway is set.
+	staging *stagingGateway
 }
 
 // NewAppGwIngressController constructs a controller object.
 func NewAppGwIngressController(azClient azure.AzClient, appGwIdentifier appgw.Identifier, k8sContext *k8scontext.Context, recorder record.EventRecorder, metricStore metricstore.MetricStore, agicPod *v1.Pod) *AppGwIngressController {
 	controller := &AppGwIngressController{
-		azClient:        azClient,
-		appGwIdentifier: appGwIdentifier,
-		k8sContext:      k8sContext,
-		recorder:        recorder,
-		configCache:     to.ByteSlicePtr([]byte{}),
-		ipAddressMap:    map[string]k8scontext.IPAddress{},
-		stopChannel:     make(chan struct{}),
-		agicPod:         agicPod,
-		metricStore:     metricStore,
+		azClient:          azClient,
+		appGwIdentifier:   appGwIdentifier,
+		k8sContext:        k8sContext,
+		recorder:          recorder,
+		configCache:       to.ByteSlicePtr([]byte{}),
+		ipAddressMap:      map[string]k8scontext.IPAddress{},
+		stopChannel:       make(chan struct{}),
+		stopping:          make(chan struct{}),
+		agicPod:           agicPod,
+		metricStore:       metricStore,
+		syncStatus:        &syncStatus{},
+		poolDrains:        appgw.NewPoolDrains(),
+		poolRemovals:      appgw.NewPoolRemovals(),
+		ingressTombstones: appgw.NewIngressTombstones(),
+		fqdnResolutions:   appgw.NewFQDNResolutions(net.DefaultResolver),
+		autoscaleSchedule: appgw.NewAutoscaleSchedule(),
+		resolvedCache:     appgw.NewResolvedCache(appgw.DefaultResolvedCacheSize),
+		probeWarnings:     appgw.NewProbeWarnings(appgw.DefaultProbeWarningInterval),
+		firewallPolicies:  newFirewallPolicies(firewallPolicyTTL),
+
+		auditIngressVersions:   make(ingressVersions),
+		summaryIngressVersions: make(ingressVersions),
+		ingressClassMismatches: make(ingressClassMismatches),
+		lastDesiredConfig:      &desiredConfig{},
+		lastKnownGood:          &lastKnownGood{},
 	}
 
 	controller.worker = &worker.Worker{
 		EventProcessor: controller,
+		MetricStore:    metricStore,
+		ReconcileAfter: k8sContext.ReconcileAfter,
 	}
 	return controller
 }
@@ -61,6 +117,8 @@ func NewAppGwIngressController(azClient azure.AzClient, appGwIdentifier appgw.Id
 // Start function runs the k8scontext and continues to listen to the
 // event channel and enqueue events before stopChannel is closed
 func (c *AppGwIngressController) Start(envVariables environment.EnvVariables) error {
+	c.tracer = tracing.NewTracer(envVariables.OTLPEndpoint)
+
 	// Starts k8scontext which contains all the informers
 	// This will start individual go routines for informers
 	if err := c.k8sContext.Run(c.stopChannel, false, envVariables); err != nil {
@@ -69,14 +127,28 @@ func (c *AppGwIngressController) Start(envVariables environment.EnvVariables) er
 	}
 
 	// Starts Worker processing events from k8sContext
-	go c.worker.Run(c.k8sContext.Work, c.stopChannel)
+	c.runWorker()
 	return nil
 }
 
-// Stop function terminates the k8scontext and signal the stopchannel
+func (c *AppGwIngressController) runWorker() {
+	c.workerDone = make(chan struct{})
+	go func() {
+		c.worker.Run(c.k8sContext.Work, c.stopChannel)
+		close(c.workerDone)
+	}()
+}
+
+// Stop shuts the controller down in order: the pod reports not ready, the k8scontext and the worker are signaled to stop,
+// and Stop returns once the worker has finished the App Gateway update in progress, so that it is not cut off half way.
 func (c *AppGwIngressController) Stop() {
-	c.metricStore.Stop()
+	close(c.stopping)
 	close(c.stopChannel)
+	if c.workerDone != nil {
+		glog.V(1).Infoln("Waiting for the reconcile in progress to finish")
+		<-c.workerDone
+	}
+	c.metricStore.Stop()
 }
 
 // Liveness fulfills the health.HealthProbe interface; It is evaluated when K8s liveness-checks the AGIC pod.
@@ -87,7 +159,18 @@ func (c *AppGwIngressController) Liveness() bool {
 
 // Readiness fulfills the health.HealthProbe interface; It is evaluated when K8s readiness-checks the AGIC pod.
 func (c *AppGwIngressController) Readiness() bool {
-	_, isOpen := <-c.k8sContext.CacheSynced
-	// When the channel is CLOSED we have synced cache and are READY!
-	return !isOpen
+	select {
+	case <-c.stopping:
+		// shutting down
+		return false
+	default:
+	}
+
+	select {
+	case _, isOpen := <-c.k8sContext.CacheSynced:
+		// When the channel is CLOSED we have synced cache and are READY!
+		return !isOpen
+	default:
+		return false
+	}
 }
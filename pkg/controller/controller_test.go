@@ -6,6 +6,8 @@
 package controller
 
 import (
+	"time"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"k8s.io/client-go/tools/record"
@@ -13,8 +15,10 @@ import (
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/appgw"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/azure"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/k8scontext"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/metricstore"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/worker"
 )
 
 var _ = Describe("test NewAppGwIngressController", func() {
@@ -32,4 +36,50 @@ var _ = Describe("test NewAppGwIngressController", func() {
 			controller.Stop()
 		})
 	})
+
+	Context("ensure the controller shuts down in order", func() {
+		It("reports not ready first and stops after the reconcile in progress", func() {
+			k8sContext := &k8scontext.Context{
+				Work:        make(chan events.Event, 1),
+				CacheSynced: make(chan interface{}),
+			}
+			close(k8sContext.CacheSynced)
+			controller := NewAppGwIngressController(azure.NewFakeAzClient(), appgw.Identifier{}, k8sContext, record.NewFakeRecorder(0), metricstore.NewFakeMetricStore(), nil)
+
+			reconciling := make(chan interface{})
+			finishReconcile := make(chan interface{})
+			reconciles := 0
+			mutateAppGw := func() error {
+				reconciles++
+				close(reconciling)
+				<-finishReconcile
+				return nil
+			}
+			mutateAKS := func() error { return nil }
+			controller.worker = &worker.Worker{
+				EventProcessor: worker.NewFakeProcessor(mutateAppGw, mutateAKS),
+				MetricStore:    metricstore.NewFakeMetricStore(),
+			}
+			controller.runWorker()
+			Expect(controller.Readiness()).To(BeTrue())
+
+			k8sContext.Work <- events.Event{Type: events.Update}
+			Eventually(reconciling).Should(BeClosed())
+
+			stopped := make(chan interface{})
+			go func() {
+				controller.Stop()
+				close(stopped)
+			}()
+
+			Eventually(controller.Readiness).Should(BeFalse())
+			Consistently(stopped, 100*time.Millisecond).ShouldNot(BeClosed())
+
+			// an event arriving while shutting down is not processed
+			k8sContext.Work <- events.Event{Type: events.Update}
+			close(finishReconcile)
+			Eventually(stopped).Should(BeClosed())
+			Expect(reconciles).To(Equal(1))
+		})
+	})
 })
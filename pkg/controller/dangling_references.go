@@ -0,0 +1,131 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/golang/glog"
+	"k8s.io/api/extensions/v1beta1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/appgw"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/azure"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+)
+
+// firewallPolicyTTL is how long the existence of a WAF policy is taken as known; the ingresses are pruned twice per
+// reconcile, and reconcile often, while the policies seldom change.
+const firewallPolicyTTL = 5 * time.Minute
+
+// firewallPolicies caches whether the WAF policies exist, for the TTL, saving an ARM call per policy and prune.
+type firewallPolicies struct {
+	sync.Mutex
+	ttl     time.Duration
+	checked map[string]firewallPolicyCheck
+}
+
+type firewallPolicyCheck struct {
+	exists    bool
+	checkedAt time.Time
+}
+
+func newFirewallPolicies(ttl time.Duration) *firewallPolicies {
+	return &firewallPolicies{
+		ttl:     ttl,
+		checked: make(map[string]firewallPolicyCheck),
+	}
+}
+
+// get returns whether the policy exists, and false when it was not checked within the TTL.
+func (fp *firewallPolicies) get(policyID string, now time.Time) (bool, bool) {
+	fp.Lock()
+	defer fp.Unlock()
+	check, known := fp.checked[policyID]
+	if !known || now.Sub(check.checkedAt) >= fp.ttl {
+		return false, false
+	}
+	return check.exists, true
+}
+
+func (fp *firewallPolicies) set(policyID string, exists bool, now time.Time) {
+	fp.Lock()
+	defer fp.Unlock()
+	fp.checked[policyID] = firewallPolicyCheck{exists: exists, checkedAt: now}
+}
+
+// reset forgets all the policies. A policy deleted since it was checked fails the update of App Gateway, so the
+// policies are checked again once an update fails, rather than once the TTL ends.
+func (fp *firewallPolicies) reset() {
+	fp.Lock()
+	defer fp.Unlock()
+	fp.checked = make(map[string]firewallPolicyCheck)
+}
+
+// pruneDanglingReferences handles the ingresses, which reference a WAF policy that no longer exists. App Gateway
+// rejects a config referencing it, so the config of all ingresses would fail to apply. By default the config of the
+// ingress is built without the policy; with APPGW_DANGLING_REFERENCE_ACTION set to skip, the ingress is filtered.
+func pruneDanglingReferences(c *AppGwIngressController, appGw *n.ApplicationGateway, cbCtx *appgw.ConfigBuilderContext, ingressList []*v1beta1.Ingress) []*v1beta1.Ingress {
+	policyExists := make(map[string]bool)
+	var prunedIngresses []*v1beta1.Ingress
+	for _, ingress := range ingressList {
+		policy, err := annotations.WAFPolicy(ingress)
+		if err != nil || policy == "" {
+			prunedIngresses = append(prunedIngresses, ingress)
+			continue
+		}
+		if _, checked := policyExists[policy]; !checked {
+			policyExists[policy] = c.firewallPolicyExists(policy)
+		}
+		if policyExists[policy] {
+			prunedIngresses = append(prunedIngresses, ingress)
+			continue
+		}
+
+		var errorLine string
+		if cbCtx.EnvVariables.DanglingReferenceAction == environment.DanglingReferenceSkip {
+			errorLine = fmt.Sprintf("ignoring Ingress %s/%s as the WAF policy %s it references does not exist", ingress.Namespace, ingress.Name, policy)
+		} else {
+			errorLine = fmt.Sprintf("Ingress %s/%s references the WAF policy %s, which does not exist; its config is built without the policy", ingress.Namespace, ingress.Name, policy)
+			if cbCtx.DanglingFirewallPolicies == nil {
+				cbCtx.DanglingFirewallPolicies = make(map[string]interface{})
+			}
+			cbCtx.DanglingFirewallPolicies[policy] = nil
+			prunedIngresses = append(prunedIngresses, ingress)
+		}
+		glog.Error(errorLine)
+		events.Warning(c.recorder, ingress, events.ReasonDanglingReference, errorLine)
+	}
+
+	return prunedIngresses
+}
+
+// firewallPolicyExists tells whether the WAF policy exists. When it can not be told, such as when AGIC is not allowed
+// to read the policy, the policy is assumed to exist, and left for App Gateway to validate; this is not cached.
+func (c *AppGwIngressController) firewallPolicyExists(policyID string) bool {
+	now := time.Now()
+	if c.firewallPolicies != nil {
+		if exists, known := c.firewallPolicies.get(policyID, now); known {
+			return exists
+		}
+	}
+
+	_, err := c.azClient.GetFirewallPolicy(policyID)
+	c.metricStore.IncArmAPICallCounter()
+	if err == nil || azure.IsNotFound(err) {
+		exists := err == nil
+		if c.firewallPolicies != nil {
+			c.firewallPolicies.set(policyID, exists, now)
+		}
+		return exists
+	}
+	glog.Warningf("Unable to get the WAF policy %s; assuming it exists: %s", policyID, err)
+	return true
+}
@@ -0,0 +1,175 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/Azure/go-autorest/autorest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	testclient "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/appgw"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/azure"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/agic_crd_client/clientset/versioned/fake"
+	istioFake "github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/istio_crd_client/clientset/versioned/fake"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/k8scontext"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/metricstore"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests/fixtures"
+)
+
+var _ = Describe("ingresses referencing WAF policies, which no longer exist", func() {
+	const existingPolicy = "/subscriptions/xxxx/resourceGroups/xxxx/providers/Microsoft.Network/ApplicationGatewayWebApplicationFirewallPolicies/existing"
+	const deletedPolicy = "/subscriptions/xxxx/resourceGroups/xxxx/providers/Microsoft.Network/ApplicationGatewayWebApplicationFirewallPolicies/deleted"
+
+	var controller *AppGwIngressController
+	var recorder *record.FakeRecorder
+	var lookups map[string]int
+	var cbCtx *appgw.ConfigBuilderContext
+	var withoutPolicy, withExistingPolicy, withDeletedPolicy, alsoWithDeletedPolicy *v1beta1.Ingress
+
+	newIngress := func(name, policy string) *v1beta1.Ingress {
+		ingress := tests.NewIngressFixture()
+		ingress.Name = name
+		if policy != "" {
+			ingress.Annotations[annotations.FirewallPolicy] = policy
+		}
+		return ingress
+	}
+
+	prune := func() []*v1beta1.Ingress {
+		appGw := fixtures.GetAppGateway()
+		return pruneDanglingReferences(controller, &appGw, cbCtx, cbCtx.IngressList)
+	}
+
+	BeforeEach(func() {
+		lookups = make(map[string]int)
+		azClient := azure.NewFakeAzClient()
+		azClient.GetFirewallPolicyFunc = func(resourceID string) (n.WebApplicationFirewallPolicy, error) {
+			lookups[resourceID]++
+			if resourceID == deletedPolicy {
+				return n.WebApplicationFirewallPolicy{}, autorest.DetailedError{
+					StatusCode: http.StatusNotFound,
+					Response:   &http.Response{StatusCode: http.StatusNotFound},
+				}
+			}
+			return n.WebApplicationFirewallPolicy{}, nil
+		}
+		recorder = record.NewFakeRecorder(100)
+		controller = &AppGwIngressController{
+			azClient:    azClient,
+			recorder:    recorder,
+			metricStore: metricstore.NewFakeMetricStore(),
+		}
+
+		withoutPolicy = newIngress("without-policy", "")
+		withExistingPolicy = newIngress("with-existing-policy", existingPolicy)
+		withDeletedPolicy = newIngress("with-deleted-policy", deletedPolicy)
+		alsoWithDeletedPolicy = newIngress("also-with-deleted-policy", deletedPolicy)
+		cbCtx = &appgw.ConfigBuilderContext{
+			IngressList:  []*v1beta1.Ingress{withoutPolicy, withExistingPolicy, withDeletedPolicy, alsoWithDeletedPolicy},
+			EnvVariables: environment.GetFakeEnv(),
+		}
+	})
+
+	It("keeps the ingresses, but leaves out the policy, by default", func() {
+		Expect(prune()).To(Equal([]*v1beta1.Ingress{withoutPolicy, withExistingPolicy, withDeletedPolicy, alsoWithDeletedPolicy}))
+		Expect(cbCtx.DanglingFirewallPolicies).To(Equal(map[string]interface{}{deletedPolicy: nil}))
+		Expect(recorder.Events).To(Receive(And(
+			HavePrefix("Warning DanglingReference"),
+			ContainSubstring("with-deleted-policy"),
+			ContainSubstring("built without the policy"),
+		)))
+		Expect(recorder.Events).To(Receive(ContainSubstring("also-with-deleted-policy")))
+		Expect(recorder.Events).ToNot(Receive())
+		Expect(lookups).To(Equal(map[string]int{existingPolicy: 1, deletedPolicy: 1}), "each policy is looked up once")
+	})
+
+	It("skips the ingresses referencing the policy, when configured to", func() {
+		cbCtx.EnvVariables.DanglingReferenceAction = environment.DanglingReferenceSkip
+		Expect(prune()).To(Equal([]*v1beta1.Ingress{withoutPolicy, withExistingPolicy}))
+		Expect(cbCtx.DanglingFirewallPolicies).To(BeEmpty())
+		Expect(recorder.Events).To(Receive(And(
+			HavePrefix("Warning DanglingReference"),
+			ContainSubstring("ignoring Ingress"),
+			ContainSubstring("with-deleted-policy"),
+		)))
+	})
+
+	It("looks each policy up once per TTL", func() {
+		controller.firewallPolicies = newFirewallPolicies(firewallPolicyTTL)
+		prune()
+		prune()
+		Expect(lookups).To(Equal(map[string]int{existingPolicy: 1, deletedPolicy: 1}))
+
+		now := time.Now()
+		exists, known := controller.firewallPolicies.get(deletedPolicy, now)
+		Expect(known).To(BeTrue())
+		Expect(exists).To(BeFalse())
+		_, known = controller.firewallPolicies.get(deletedPolicy, now.Add(firewallPolicyTTL))
+		Expect(known).To(BeFalse(), "the policy is looked up again once the TTL ends")
+	})
+
+	It("assumes the policy exists, when it can not be looked up", func() {
+		controller.azClient.(*azure.FakeAzClient).GetFirewallPolicyFunc = func(resourceID string) (n.WebApplicationFirewallPolicy, error) {
+			return n.WebApplicationFirewallPolicy{}, errors.New("AuthorizationFailed")
+		}
+		controller.firewallPolicies = newFirewallPolicies(firewallPolicyTTL)
+		Expect(prune()).To(HaveLen(4))
+		Expect(cbCtx.DanglingFirewallPolicies).To(BeEmpty())
+		Expect(recorder.Events).ToNot(Receive())
+		_, known := controller.firewallPolicies.get(existingPolicy, time.Now())
+		Expect(known).To(BeFalse(), "a failed lookup is not cached")
+	})
+})
+
+var _ = Describe("WAF policies cached once an update of App Gateway fails", func() {
+	const policy = "/subscriptions/xxxx/resourceGroups/xxxx/providers/Microsoft.Network/ApplicationGatewayWebApplicationFirewallPolicies/policy"
+
+	var controller *AppGwIngressController
+	var stopChannel chan struct{}
+
+	BeforeEach(func() {
+		azClient := azure.NewFakeAzClient()
+		azClient.GetGatewayFunc = func() (n.ApplicationGateway, error) {
+			config := appgw.NewAppGwyConfigFixture()
+			config.FrontendPorts = &[]n.ApplicationGatewayFrontendPort{}
+			return n.ApplicationGateway{ApplicationGatewayPropertiesFormat: config}, nil
+		}
+		azClient.UpdateGatewayFunc = func(*n.ApplicationGateway) error {
+			return errors.New("ApplicationGatewayFirewallPolicyNotFound")
+		}
+
+		stopChannel = make(chan struct{})
+		k8sContext := k8scontext.NewContext(testclient.NewSimpleClientset(), fake.NewSimpleClientset(), istioFake.NewSimpleClientset(), nil, time.Second, k8scontext.DefaultWorkQueueDepth, metricstore.NewFakeMetricStore())
+		Expect(k8sContext.Run(stopChannel, true, environment.GetFakeEnv())).To(Succeed())
+
+		identifier := appgw.Identifier{SubscriptionID: tests.Subscription, ResourceGroup: tests.ResourceGroup, AppGwName: tests.AppGwName}
+		controller = NewAppGwIngressController(azClient, identifier, k8sContext, record.NewFakeRecorder(100), metricstore.NewFakeMetricStore(), &v1.Pod{})
+	})
+
+	AfterEach(func() {
+		close(stopChannel)
+	})
+
+	It("forgets that the policies exist, as one may have been deleted since", func() {
+		controller.firewallPolicies.set(policy, true, time.Now())
+		Expect(controller.MutateAppGateway()).ToNot(Succeed())
+		_, known := controller.firewallPolicies.get(policy, time.Now())
+		Expect(known).To(BeFalse())
+	})
+})
+
@@ -0,0 +1,80 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/golang/glog"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+)
+
+// Keys removed from the App Gateway config served by the debug server: the PFX certificates and their passwords.
+var keysToDeleteForDebug = []string{
+	"data",
+	"password",
+}
+
+// DebugSnapshot is the view AGIC has of the cluster and the last App Gateway config it generated from it.
+// Secrets are not included.
+type DebugSnapshot struct {
+	Ingresses []*v1beta1.Ingress `json:"ingresses"`
+	Services  []*v1.Service      `json:"services"`
+	Endpoints []*v1.Endpoints    `json:"endpoints"`
+
+	LastDesiredConfig          json.RawMessage `json:"lastDesiredConfig,omitempty"`
+	LastDesiredConfigGenerated *time.Time      `json:"lastDesiredConfigGenerated,omitempty"`
+}
+
+// desiredConfig holds the last App Gateway config generated by the config builder, whether it was applied or not.
+type desiredConfig struct {
+	sync.RWMutex
+	appGwJSON []byte
+	generated time.Time
+}
+
+func (d *desiredConfig) set(appGw *n.ApplicationGateway, generated time.Time) {
+	jsonConfig, err := appGw.MarshalJSON()
+	if err != nil {
+		glog.Error("Could not marshal the generated App Gateway config for the debug server: ", err)
+		return
+	}
+	sanitized, err := deleteKeyFromJSON(jsonConfig, keysToDeleteForDebug...)
+	if err != nil {
+		glog.Error("Could not remove the certificates from the generated App Gateway config for the debug server: ", err)
+		return
+	}
+
+	d.Lock()
+	defer d.Unlock()
+	d.appGwJSON = sanitized
+	d.generated = generated
+}
+
+func (d *desiredConfig) get() (json.RawMessage, *time.Time) {
+	d.RLock()
+	defer d.RUnlock()
+	if d.appGwJSON == nil {
+		return nil, nil
+	}
+	generated := d.generated
+	return d.appGwJSON, &generated
+}
+
+// DebugSnapshot is evaluated when the debug endpoint is requested.
+func (c *AppGwIngressController) DebugSnapshot() DebugSnapshot {
+	snapshot := DebugSnapshot{
+		Ingresses: c.k8sContext.ListHTTPIngresses(),
+		Services:  c.k8sContext.ListServices(),
+		Endpoints: c.k8sContext.ListEndpoints(),
+	}
+	snapshot.LastDesiredConfig, snapshot.LastDesiredConfigGenerated = c.lastDesiredConfig.get()
+	return snapshot
+}
@@ -0,0 +1,75 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"encoding/json"
+	"time"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	testclient "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/appgw"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/azure"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/agic_crd_client/clientset/versioned/fake"
+	istioFake "github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/istio_crd_client/clientset/versioned/fake"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/k8scontext"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/metricstore"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
+)
+
+var _ = Describe("debug snapshot", func() {
+	var controller *AppGwIngressController
+
+	BeforeEach(func() {
+		k8sContext := k8scontext.NewContext(testclient.NewSimpleClientset(), fake.NewSimpleClientset(), istioFake.NewSimpleClientset(), nil, time.Second, k8scontext.DefaultWorkQueueDepth, metricstore.NewFakeMetricStore())
+		_ = k8sContext.Caches.Ingress.Add(tests.NewIngressFixture())
+		_ = k8sContext.Caches.Service.Add(tests.NewServiceFixture(*tests.NewServicePortsFixture()...))
+		_ = k8sContext.Caches.Endpoints.Add(tests.NewEndpointsFixture())
+		controller = NewAppGwIngressController(azure.NewFakeAzClient(), appgw.Identifier{}, k8sContext, record.NewFakeRecorder(0), metricstore.NewFakeMetricStore(), nil)
+	})
+
+	It("dumps the caches", func() {
+		snapshot := controller.DebugSnapshot()
+		Expect(snapshot.Ingresses).To(HaveLen(1))
+		Expect(snapshot.Services).To(HaveLen(1))
+		Expect(snapshot.Endpoints).To(HaveLen(1))
+		Expect(snapshot.LastDesiredConfig).To(BeNil())
+		Expect(snapshot.LastDesiredConfigGenerated).To(BeNil())
+	})
+
+	It("dumps the last desired config without the certificates", func() {
+		generated := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+		controller.lastDesiredConfig.set(&n.ApplicationGateway{
+			ApplicationGatewayPropertiesFormat: &n.ApplicationGatewayPropertiesFormat{
+				SslCertificates: &[]n.ApplicationGatewaySslCertificate{
+					{
+						Name: to.StringPtr("cert-web"),
+						ApplicationGatewaySslCertificatePropertiesFormat: &n.ApplicationGatewaySslCertificatePropertiesFormat{
+							Data:           to.StringPtr("--pfx--"),
+							Password:       to.StringPtr("--password--"),
+							PublicCertData: to.StringPtr("--public--"),
+						},
+					},
+				},
+			},
+		}, generated)
+
+		snapshot := controller.DebugSnapshot()
+		Expect(*snapshot.LastDesiredConfigGenerated).To(Equal(generated))
+
+		body, err := json.Marshal(snapshot)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(body)).To(ContainSubstring("cert-web"))
+		Expect(string(body)).To(ContainSubstring("--public--"))
+		Expect(string(body)).ToNot(ContainSubstring("--pfx--"))
+		Expect(string(body)).ToNot(ContainSubstring("--password--"))
+	})
+})
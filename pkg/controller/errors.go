@@ -13,4 +13,10 @@ var (
 
 	// ErrDeployingAppGatewayConfig is an error.
 	ErrDeployingAppGatewayConfig = errors.New("unable to deploy App Gateway config (CTRL002)")
+
+	// ErrInvalidIngresses is an error.
+	ErrInvalidIngresses = errors.New("invalid ingresses; App Gateway config is not applied in strict ingress validation (CTRL003)")
+
+	// ErrStagingFailed is an error.
+	ErrStagingFailed = errors.New("App Gateway config failed on the staging App Gateway; it is not applied to the App Gateway of AGIC (CTRL004)")
 )
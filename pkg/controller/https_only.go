@@ -0,0 +1,105 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/golang/glog"
+	"k8s.io/api/extensions/v1beta1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/appgw"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/utils"
+)
+
+// pruneHTTPSOnly filters the ingresses, which would have a plaintext HTTP listener serving their backends, when the
+// HTTPS-only policy is enabled. The HTTP listeners of ingresses with ssl-redirect only redirect to HTTPS, and are allowed.
+func pruneHTTPSOnly(c *AppGwIngressController, appGw *n.ApplicationGateway, cbCtx *appgw.ConfigBuilderContext, ingressList []*v1beta1.Ingress) []*v1beta1.Ingress {
+	if !cbCtx.EnvVariables.HTTPSOnly {
+		return ingressList
+	}
+
+	var prunedIngresses []*v1beta1.Ingress
+	for _, ingress := range ingressList {
+		if cause := c.getPlaintextHTTPCause(ingress, cbCtx.EnvVariables); cause != "" {
+			errorLine := fmt.Sprintf("ignoring Ingress %s/%s as the HTTPS-only policy is enabled and %s; add a TLS secret for all its hosts, or ssl-redirect without excluded paths", ingress.Namespace, ingress.Name, cause)
+			glog.Error(errorLine)
+			events.Warning(c.recorder, ingress, events.ReasonHTTPSOnlyPolicy, errorLine)
+			if c.agicPod != nil {
+				events.Warning(c.recorder, c.agicPod, events.ReasonHTTPSOnlyPolicy, errorLine)
+			}
+		} else {
+			prunedIngresses = append(prunedIngresses, ingress)
+		}
+	}
+
+	return prunedIngresses
+}
+
+// getPlaintextHTTPCause tells why the ingress would be served over plaintext HTTP, or returns an empty string when it
+// is served over HTTPS only. A host without a usable TLS secret is served over HTTP, and so are the paths excluded from
+// the ssl redirect; an ingress without rules is served by the default listener, which is HTTP.
+func (c *AppGwIngressController) getPlaintextHTTPCause(ingress *v1beta1.Ingress, env environment.EnvVariables) string {
+	if len(ingress.Spec.Rules) == 0 {
+		if ingress.Spec.Backend != nil {
+			return "its default backend is served by the default HTTP listener"
+		}
+		return ""
+	}
+
+	hasTLS := c.getHostsWithTLS(ingress)
+	for _, rule := range ingress.Spec.Rules {
+		if rule.HTTP == nil && ingress.Spec.Backend == nil {
+			// The rule has nothing to route to, and no listener.
+			continue
+		}
+		if _, exists := hasTLS[rule.Host]; exists {
+			continue
+		}
+		if _, exists := hasTLS[""]; exists {
+			continue
+		}
+		if rule.Host == "" {
+			return "it has a rule for all hosts without a TLS secret"
+		}
+		return fmt.Sprintf("host %q has no TLS secret", rule.Host)
+	}
+
+	if !appgw.IsSslRedirect(ingress, env) {
+		return ""
+	}
+	if excludedPaths, err := annotations.SslRedirectExcludePaths(ingress); err == nil && len(excludedPaths) > 0 {
+		return fmt.Sprintf("paths %s are excluded from the ssl redirect", strings.Join(excludedPaths, ", "))
+	}
+	return ""
+}
+
+// getHostsWithTLS returns the hosts of the TLS entries of the ingress, whose secret holds a certificate AGIC can bind
+// to the HTTPS listener; the empty host stands for a TLS entry without hosts, which applies to all hosts.
+func (c *AppGwIngressController) getHostsWithTLS(ingress *v1beta1.Ingress) map[string]interface{} {
+	hosts := make(map[string]interface{})
+	for _, tls := range ingress.Spec.TLS {
+		if tls.SecretName == "" {
+			continue
+		}
+		secretKey := utils.GetResourceKey(ingress.Namespace, tls.SecretName)
+		if c.k8sContext.CertificateSecretStore.GetPfxCertificate(secretKey) == nil || c.k8sContext.IsCertificatePending(secretKey) {
+			continue
+		}
+		if len(tls.Hosts) == 0 {
+			hosts[""] = nil
+		}
+		for _, host := range tls.Hosts {
+			hosts[host] = nil
+		}
+	}
+	return hosts
+}
@@ -0,0 +1,113 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	testclient "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/appgw"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/agic_crd_client/clientset/versioned/fake"
+	istioFake "github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/istio_crd_client/clientset/versioned/fake"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/k8scontext"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/metricstore"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/utils"
+)
+
+var _ = Describe("pruning ingresses served over plaintext HTTP with the HTTPS-only policy", func() {
+	var controller *AppGwIngressController
+	var recorder *record.FakeRecorder
+	var cbCtx *appgw.ConfigBuilderContext
+
+	// Builds an ingress with a rule for each host, and the TLS entry with the given hosts and a usable certificate.
+	newIngress := func(name string, tlsHosts []string, hosts ...string) *v1beta1.Ingress {
+		ingress := tests.NewIngressFixture()
+		ingress.Name = name
+		ingress.Annotations = map[string]string{}
+		rule := ingress.Spec.Rules[0]
+		ingress.Spec.Rules = nil
+		for _, host := range hosts {
+			rule.Host = host
+			ingress.Spec.Rules = append(ingress.Spec.Rules, rule)
+		}
+		ingress.Spec.TLS = nil
+		if tlsHosts != nil {
+			ingress.Spec.TLS = []v1beta1.IngressTLS{{Hosts: tlsHosts, SecretName: tests.NameOfSecret}}
+		}
+		return ingress
+	}
+
+	BeforeEach(func() {
+		recorder = record.NewFakeRecorder(100)
+		k8sContext := k8scontext.NewContext(testclient.NewSimpleClientset(), fake.NewSimpleClientset(), istioFake.NewSimpleClientset(), nil, time.Second, k8scontext.DefaultWorkQueueDepth, metricstore.NewFakeMetricStore())
+		secretKey := utils.GetResourceKey(tests.Namespace, tests.NameOfSecret)
+		k8sContext.CertificateSecretStore.(*k8scontext.SecretsStore).Cache.Add(secretKey, []byte("pfx"))
+		controller = &AppGwIngressController{
+			k8sContext:  k8sContext,
+			recorder:    recorder,
+			metricStore: metricstore.NewFakeMetricStore(),
+			agicPod:     &v1.Pod{},
+		}
+		cbCtx = &appgw.ConfigBuilderContext{}
+		cbCtx.EnvVariables.HTTPSOnly = true
+	})
+
+	It("keeps the ingresses served over HTTPS only, or redirecting HTTP to HTTPS", func() {
+		httpsOnly := newIngress("https-only", []string{"www.contoso.com", "api.contoso.com"}, "www.contoso.com", "api.contoso.com")
+		allHosts := newIngress("all-hosts", []string{}, "www.contoso.com", "")
+		redirect := newIngress("redirect", []string{"www.contoso.com"}, "www.contoso.com")
+		redirect.Annotations[annotations.SslRedirectKey] = "true"
+
+		cbCtx.IngressList = []*v1beta1.Ingress{httpsOnly, allHosts, redirect}
+		Expect(pruneHTTPSOnly(controller, nil, cbCtx, cbCtx.IngressList)).To(Equal(cbCtx.IngressList))
+		Expect(recorder.Events).To(BeEmpty())
+	})
+
+	It("removes the ingresses with a backend served over plaintext HTTP and emits events", func() {
+		noTLS := newIngress("no-tls", nil, "www.contoso.com")
+		partialTLS := newIngress("partial-tls", []string{"www.contoso.com"}, "www.contoso.com", "api.contoso.com")
+		catchAll := newIngress("catch-all", []string{"www.contoso.com"}, "")
+		excludedPaths := newIngress("excluded-paths", []string{"www.contoso.com"}, "www.contoso.com")
+		excludedPaths.Annotations[annotations.SslRedirectKey] = "true"
+		excludedPaths.Annotations[annotations.SslRedirectExcludePathsKey] = "/.well-known/acme-challenge"
+		defaultBackend := newIngress("default-backend", []string{}, "")
+		defaultBackend.Spec.Rules = nil
+		defaultBackend.Spec.Backend = tests.NewIngressBackendFixture(tests.ServiceName, 80)
+		compliant := newIngress("compliant", []string{"www.contoso.com"}, "www.contoso.com")
+
+		cbCtx.IngressList = []*v1beta1.Ingress{noTLS, partialTLS, catchAll, excludedPaths, defaultBackend, compliant}
+		Expect(pruneHTTPSOnly(controller, nil, cbCtx, cbCtx.IngressList)).To(ConsistOf(compliant))
+
+		// An event on the ingress and on AGIC for each of the 5 ingresses removed.
+		Expect(recorder.Events).To(HaveLen(10))
+		event := <-recorder.Events
+		Expect(event).To(HavePrefix("Warning " + string(events.ReasonHTTPSOnlyPolicy)))
+		Expect(event).To(ContainSubstring("HTTPS-only policy"))
+		Expect(event).To(ContainSubstring(`host "www.contoso.com" has no TLS secret`))
+	})
+
+	It("counts a host, whose TLS secret has no usable certificate, as served over HTTP", func() {
+		missingSecret := newIngress("missing-secret", []string{"www.contoso.com"}, "www.contoso.com")
+		missingSecret.Spec.TLS[0].SecretName = "missing"
+		cbCtx.IngressList = []*v1beta1.Ingress{missingSecret}
+		Expect(pruneHTTPSOnly(controller, nil, cbCtx, cbCtx.IngressList)).To(BeEmpty())
+	})
+
+	It("keeps all ingresses when the policy is disabled", func() {
+		cbCtx.EnvVariables.HTTPSOnly = false
+		cbCtx.IngressList = []*v1beta1.Ingress{newIngress("no-tls", nil, "www.contoso.com")}
+		Expect(pruneHTTPSOnly(controller, nil, cbCtx, cbCtx.IngressList)).To(Equal(cbCtx.IngressList))
+	})
+})
@@ -0,0 +1,56 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	"k8s.io/api/extensions/v1beta1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/utils"
+)
+
+// ingressClassMismatchInterval is how long AGIC waits before emitting the ingress class mismatch event of an ingress again.
+const ingressClassMismatchInterval = time.Hour
+
+// ingressClassMismatches holds when the ingress class mismatch of each ingress (namespace/name) was last reported.
+type ingressClassMismatches map[string]reportedMismatch
+
+type reportedMismatch struct {
+	class string
+	at    time.Time
+}
+
+// report emits an event on the ingresses, which AGIC skips for their ingress class only. An ingress is reported again
+// when its ingress class changes, or once the interval ends.
+func (mismatches ingressClassMismatches) report(recorder record.EventRecorder, ingresses []*v1beta1.Ingress, now time.Time) {
+	current := make(map[string]bool)
+	for _, ingress := range ingresses {
+		key := utils.GetResourceKey(ingress.Namespace, ingress.Name)
+		current[key] = true
+		class := annotations.IngressClass(ingress)
+		if last, exists := mismatches[key]; exists && last.class == class && now.Before(last.at.Add(ingressClassMismatchInterval)) {
+			continue
+		}
+		mismatches[key] = reportedMismatch{class: class, at: now}
+
+		message := fmt.Sprintf("Ingress %s has annotations of AGIC, but AGIC skips it for its ingress class %q; AGIC handles the ingresses with annotation %s: %s",
+			key, class, annotations.IngressClassKey, annotations.ApplicationGatewayIngressClass)
+		glog.V(3).Info(message)
+		events.Normal(recorder, ingress, events.ReasonIngressClassMismatch, message)
+	}
+
+	for key := range mismatches {
+		if !current[key] {
+			delete(mismatches, key)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/api/extensions/v1beta1"
+	testclient "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/agic_crd_client/clientset/versioned/fake"
+	istioFake "github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/istio_crd_client/clientset/versioned/fake"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/k8scontext"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/metricstore"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
+)
+
+var _ = Describe("ingresses skipped for their ingress class", func() {
+	var recorder *record.FakeRecorder
+	var k8sContext *k8scontext.Context
+	var mismatches ingressClassMismatches
+	var now time.Time
+
+	addIngress := func(name string, ingressAnnotations map[string]string) *v1beta1.Ingress {
+		ingress := tests.NewIngressFixture()
+		ingress.Name = name
+		ingress.Annotations = ingressAnnotations
+		Expect(k8sContext.Caches.Ingress.Add(ingress)).To(Succeed())
+		return ingress
+	}
+
+	report := func() {
+		mismatches.report(recorder, k8sContext.ListIngressesOfOtherClasses(), now)
+	}
+
+	BeforeEach(func() {
+		recorder = record.NewFakeRecorder(10)
+		k8sContext = k8scontext.NewContext(testclient.NewSimpleClientset(), fake.NewSimpleClientset(), istioFake.NewSimpleClientset(), nil, time.Second, k8scontext.DefaultWorkQueueDepth, metricstore.NewFakeMetricStore())
+		mismatches = make(ingressClassMismatches)
+		now = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	})
+
+	It("emits an event naming the expected class on ingresses with annotations of AGIC", func() {
+		addIngress("meant-for-agic", map[string]string{
+			annotations.IngressClassKey: "nginx",
+			annotations.SslRedirectKey:  "true",
+		})
+		addIngress("without-class", map[string]string{
+			annotations.CookieBasedAffinityKey: "true",
+		})
+		report()
+
+		Expect(recorder.Events).To(Receive(And(
+			HavePrefix("Normal IngressClassMismatch"),
+			ContainSubstring("meant-for-agic"),
+			ContainSubstring(`ingress class "nginx"`),
+			ContainSubstring(annotations.ApplicationGatewayIngressClass),
+		)))
+		Expect(recorder.Events).To(Receive(And(
+			ContainSubstring("without-class"),
+			ContainSubstring(`ingress class ""`),
+		)))
+		Expect(recorder.Events).ToNot(Receive())
+	})
+
+	It("skips the ingresses of other controllers silently", func() {
+		addIngress("nginx", map[string]string{
+			annotations.IngressClassKey:                  "nginx",
+			"nginx.ingress.kubernetes.io/rewrite-target": "/",
+		})
+		addIngress("agic", map[string]string{
+			annotations.IngressClassKey: annotations.ApplicationGatewayIngressClass,
+			annotations.SslRedirectKey:  "true",
+		})
+		report()
+		Expect(recorder.Events).ToNot(Receive())
+	})
+
+	It("emits the event of an ingress again only after the interval or a class change", func() {
+		ingress := addIngress("meant-for-agic", map[string]string{
+			annotations.IngressClassKey: "nginx",
+			annotations.SslRedirectKey:  "true",
+		})
+		report()
+		Expect(recorder.Events).To(Receive())
+
+		now = now.Add(time.Minute)
+		report()
+		Expect(recorder.Events).ToNot(Receive(), "the event is throttled")
+
+		ingress.Annotations[annotations.IngressClassKey] = "traefik"
+		Expect(k8sContext.Caches.Ingress.Update(ingress)).To(Succeed())
+		report()
+		Expect(recorder.Events).To(Receive(ContainSubstring(`ingress class "traefik"`)))
+
+		now = now.Add(ingressClassMismatchInterval)
+		report()
+		Expect(recorder.Events).To(Receive(ContainSubstring(`ingress class "traefik"`)))
+	})
+})
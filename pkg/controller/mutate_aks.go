@@ -15,11 +15,9 @@ import (
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/appgw"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/azure"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
-	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/k8scontext"
 )
 
 type ipResource string
-type ipAddress string
 
 // MutateAKS applies changes to Kubernetes resources.
 func (c AppGwIngressController) MutateAKS() error {
@@ -38,31 +36,38 @@ func (c AppGwIngressController) MutateAKS() error {
 	return nil
 }
 
-func (c AppGwIngressController) updateIngressStatus(appGw *n.ApplicationGateway, cbCtx *appgw.ConfigBuilderContext, ingress *v1beta1.Ingress, ips map[ipResource]ipAddress) {
+// updateIngressStatus sets the IP address of the frontend IP configuration of App Gateway, which serves the ingress, in
+// its status; along with the FQDN of a public IP with a DNS name. An ingress of a gateway with a private IP only gets
+// the private IP.
+func (c AppGwIngressController) updateIngressStatus(appGw *n.ApplicationGateway, cbCtx *appgw.ConfigBuilderContext, ingress *v1beta1.Ingress, ips map[ipResource]v1.LoadBalancerIngress) {
 
 	// determine what ipAddress to attach
 	usePrivateIP, _ := annotations.UsePrivateIP(ingress)
-	usePrivateIP = usePrivateIP || cbCtx.EnvVariables.UsePrivateIP == "true"
+	usePrivateIP = usePrivateIP || appgw.UsePrivateIPByDefault(appGw.FrontendIPConfigurations, cbCtx.EnvVariables)
 
-	ipConf := appgw.LookupIPConfigurationByType(appGw.FrontendIPConfigurations, usePrivateIP)
+	ipConf := appgw.LookupIPConfigurationForListener(appGw.FrontendIPConfigurations, appGw.HTTPListeners, cbCtx.EnvVariables, usePrivateIP)
+	if ipConf == nil && !usePrivateIP {
+		// The gateway has no public IP; the ingress is served on its private IP.
+		ipConf = appgw.LookupIPConfigurationForListener(appGw.FrontendIPConfigurations, appGw.HTTPListeners, cbCtx.EnvVariables, true)
+	}
 	if ipConf == nil {
 		glog.V(9).Info("[mutate_aks] No IP config for App Gwy: ", appGw.Name)
 		return
 	}
 
 	glog.V(5).Infof("[mutate_aks] Resolving IP for ID (%s)", *ipConf.ID)
-	if newIP, found := ips[ipResource(*ipConf.ID)]; found {
-		if err := c.k8sContext.UpdateIngressStatus(*ingress, k8scontext.IPAddress(newIP)); err != nil {
-			c.recorder.Event(ingress, v1.EventTypeWarning, events.ReasonUnableToUpdateIngressStatus, err.Error())
-			glog.Errorf("[mutate_aks] Error updating ingress %s/%s IP to %+v", ingress.Namespace, ingress.Name, newIP)
+	if newLoadBalancer, found := ips[ipResource(*ipConf.ID)]; found {
+		if err := c.k8sContext.UpdateIngressLoadBalancer(*ingress, newLoadBalancer); err != nil {
+			events.Warning(c.recorder, ingress, events.ReasonUnableToUpdateIngressStatus, err.Error())
+			glog.Errorf("[mutate_aks] Error updating ingress %s/%s IP to %+v", ingress.Namespace, ingress.Name, newLoadBalancer)
 			return
 		}
-		glog.V(5).Infof("[mutate_aks] Updated Ingress %s/%s IP to %+v", ingress.Namespace, ingress.Name, newIP)
+		glog.V(5).Infof("[mutate_aks] Updated Ingress %s/%s IP to %+v", ingress.Namespace, ingress.Name, newLoadBalancer)
 	}
 }
 
-func getIPsFromAppGateway(appGw *n.ApplicationGateway, azClient azure.AzClient) map[ipResource]ipAddress {
-	ips := make(map[ipResource]ipAddress)
+func getIPsFromAppGateway(appGw *n.ApplicationGateway, azClient azure.AzClient) map[ipResource]v1.LoadBalancerIngress {
+	ips := make(map[ipResource]v1.LoadBalancerIngress)
 	for _, ipConf := range *appGw.FrontendIPConfigurations {
 		ipID := ipResource(*ipConf.ID)
 		if _, ok := ips[ipID]; ok {
@@ -70,24 +75,31 @@ func getIPsFromAppGateway(appGw *n.ApplicationGateway, azClient azure.AzClient)
 		}
 
 		if ipConf.PrivateIPAddress != nil {
-			ips[ipID] = ipAddress(*ipConf.PrivateIPAddress)
-		} else if ipAddress := getPublicIPAddress(*ipConf.PublicIPAddress.ID, azClient); ipAddress != nil {
-			ips[ipID] = *ipAddress
+			ips[ipID] = v1.LoadBalancerIngress{IP: *ipConf.PrivateIPAddress}
+		} else if publicIP := getPublicIPAddress(*ipConf.PublicIPAddress.ID, azClient); publicIP != nil {
+			ips[ipID] = *publicIP
 		}
 	}
 	glog.V(5).Infof("[mutate_aks] Found IPs: %+v", ips)
 	return ips
 }
 
-// getPublicIPAddress gets the ipAddress address associated to public ipAddress on Azure
-func getPublicIPAddress(publicIPID string, azClient azure.AzClient) *ipAddress {
+// getPublicIPAddress gets the address associated to public IP on Azure, along with its FQDN when it has a DNS name
+func getPublicIPAddress(publicIPID string, azClient azure.AzClient) *v1.LoadBalancerIngress {
 	// get public ipAddress
 	publicIP, err := azClient.GetPublicIP(publicIPID)
 	if err != nil {
 		glog.Errorf("[mutate_aks] Unable to get Public IP Address %s. Error %s", publicIPID, err)
 		return nil
 	}
+	if publicIP.PublicIPAddressPropertiesFormat == nil || publicIP.IPAddress == nil {
+		glog.Errorf("[mutate_aks] Public IP Address %s has no IP address allocated", publicIPID)
+		return nil
+	}
 
-	ipAddress := ipAddress(*publicIP.IPAddress)
-	return &ipAddress
+	loadBalancer := v1.LoadBalancerIngress{IP: *publicIP.IPAddress}
+	if publicIP.DNSSettings != nil && publicIP.DNSSettings.Fqdn != nil {
+		loadBalancer.Hostname = *publicIP.DNSSettings.Fqdn
+	}
+	return &loadBalancer
 }
@@ -20,6 +20,7 @@ import (
 
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/appgw"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/azure"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/agic_crd_client/clientset/versioned/fake"
 	istio_fake "github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/istio_crd_client/clientset/versioned/fake"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/k8scontext"
@@ -43,7 +44,7 @@ var _ = Describe("process function tests", func() {
 	}
 	publicIP := k8scontext.IPAddress("xxxx")
 	privateIP := k8scontext.IPAddress("yyyy")
-	var ips map[ipResource]ipAddress
+	var ips map[ipResource]v1.LoadBalancerIngress
 
 	BeforeEach(func() {
 		stopChannel = make(chan struct{})
@@ -55,7 +56,7 @@ var _ = Describe("process function tests", func() {
 		ingress = tests.NewIngressFixture()
 
 		// Create a `k8scontext` to start listening to ingress resources.
-		ctxt = k8scontext.NewContext(k8sClient, crdClient, istioCrdClient, []string{tests.Namespace}, 1000*time.Second, metricstore.NewFakeMetricStore())
+		ctxt = k8scontext.NewContext(k8sClient, crdClient, istioCrdClient, []string{tests.Namespace}, 1000*time.Second, k8scontext.DefaultWorkQueueDepth, metricstore.NewFakeMetricStore())
 
 		_, err := k8sClient.CoreV1().Namespaces().Create(ns)
 		Expect(err).Should(BeNil(), "Unable to create the namespace %s: %v", tests.Name, err)
@@ -84,7 +85,7 @@ var _ = Describe("process function tests", func() {
 			DefaultHTTPSettingsID: to.StringPtr("yy"),
 		}
 
-		ips = map[ipResource]ipAddress{"PublicIP": "xxxx", "PrivateIP": "yyyy"}
+		ips = map[ipResource]v1.LoadBalancerIngress{"PublicIP": {IP: "xxxx"}, "PrivateIP": {IP: "yyyy"}}
 	})
 
 	AfterEach(func() {
@@ -116,5 +117,41 @@ var _ = Describe("process function tests", func() {
 			}))
 			Expect(len(updatedIngress.Status.LoadBalancer.Ingress)).To(Equal(1))
 		})
+
+		It("ensure that updateIngressStatus adds the FQDN of the public IP and follows its changes", func() {
+			azClient := azure.NewFakeAzClient()
+			publicIPAddress := "1.2.3.4"
+			azClient.GetPublicIPFunc = func(resourceID string) (n.PublicIPAddress, error) {
+				return n.PublicIPAddress{
+					PublicIPAddressPropertiesFormat: &n.PublicIPAddressPropertiesFormat{
+						IPAddress:   to.StringPtr(publicIPAddress),
+						DNSSettings: &n.PublicIPAddressDNSSettings{Fqdn: to.StringPtr("agw.westus.cloudapp.azure.com")},
+					},
+				}, nil
+			}
+
+			controller.updateIngressStatus(&appGw, cbCtx, ingress, getIPsFromAppGateway(&appGw, azClient))
+			updatedIngress, _ := k8sClient.ExtensionsV1beta1().Ingresses(ingress.Namespace).Get(ingress.Name, metav1.GetOptions{})
+			Expect(updatedIngress.Status.LoadBalancer.Ingress).To(Equal([]v1.LoadBalancerIngress{
+				{IP: "1.2.3.4", Hostname: "agw.westus.cloudapp.azure.com"},
+			}))
+
+			publicIPAddress = "5.6.7.8"
+			controller.updateIngressStatus(&appGw, cbCtx, ingress, getIPsFromAppGateway(&appGw, azClient))
+			updatedIngress, _ = k8sClient.ExtensionsV1beta1().Ingresses(ingress.Namespace).Get(ingress.Name, metav1.GetOptions{})
+			Expect(updatedIngress.Status.LoadBalancer.Ingress).To(Equal([]v1.LoadBalancerIngress{
+				{IP: "5.6.7.8", Hostname: "agw.westus.cloudapp.azure.com"},
+			}))
+		})
+
+		It("ensure that updateIngressStatus adds the private ipAddress of a gateway without public IP", func() {
+			appGw.FrontendIPConfigurations = &[]n.ApplicationGatewayFrontendIPConfiguration{fixtures.GetPrivateIPConfiguration()}
+
+			controller.updateIngressStatus(&appGw, cbCtx, ingress, getIPsFromAppGateway(&appGw, azure.NewFakeAzClient()))
+			updatedIngress, _ := k8sClient.ExtensionsV1beta1().Ingresses(ingress.Namespace).Get(ingress.Name, metav1.GetOptions{})
+			Expect(updatedIngress.Status.LoadBalancer.Ingress).To(Equal([]v1.LoadBalancerIngress{
+				{IP: *fixtures.GetPrivateIPConfiguration().PrivateIPAddress},
+			}))
+		})
 	})
 })
@@ -6,19 +6,21 @@
 package controller
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/appgw"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/azure"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/brownfield"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tracing"
 	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
 	"github.com/Azure/go-autorest/autorest/to"
 	"github.com/golang/glog"
-	v1 "k8s.io/api/core/v1"
 )
 
 type realClock struct{}
@@ -33,7 +35,7 @@ func (c AppGwIngressController) getAppGw() (*n.ApplicationGateway, *appgw.Config
 		errorLine := fmt.Sprintf("unable to get specified AppGateway [%v], check AppGateway identifier, error=[%v]", c.appGwIdentifier.AppGwName, err)
 		glog.Errorf(errorLine)
 		if c.agicPod != nil {
-			c.recorder.Event(c.agicPod, v1.EventTypeWarning, events.ReasonUnableToFetchAppGw, errorLine)
+			events.Warning(c.recorder, c.agicPod, events.ReasonUnableToFetchAppGw, errorLine)
 		}
 		return nil, nil, ErrFetchingAppGatewayConfig
 	}
@@ -47,6 +49,20 @@ func (c AppGwIngressController) getAppGw() (*n.ApplicationGateway, *appgw.Config
 		DefaultHTTPSettingsID: to.StringPtr(c.appGwIdentifier.HTTPSettingsID(appgw.DefaultBackendHTTPSettingsName)),
 
 		ExistingPortsByNumber: make(map[appgw.Port]n.ApplicationGatewayFrontendPort),
+
+		PoolDrains:        c.poolDrains,
+		ResolvedCache:     c.resolvedCache,
+		FQDNResolutions:   c.fqdnResolutions,
+		AutoscaleSchedule: c.autoscaleSchedule,
+		MetricStore:       c.metricStore,
+	}
+
+	if appgw.GetMinHealthyPoolAddresses(cbCtx.EnvVariables) > 0 {
+		cbCtx.PoolRemovals = c.poolRemovals
+	}
+
+	if cbCtx.EnvVariables.WarnMissingReadinessProbe {
+		cbCtx.ProbeWarnings = c.probeWarnings
 	}
 
 	for _, port := range *appGw.FrontendPorts {
@@ -56,30 +72,67 @@ func (c AppGwIngressController) getAppGw() (*n.ApplicationGateway, *appgw.Config
 	return &appGw, cbCtx, nil
 }
 
+// setProhibitedTargets lists the AzureIngressProhibitedTargets when brownfield deployment is enabled.
+func (c AppGwIngressController) setProhibitedTargets(cbCtx *appgw.ConfigBuilderContext) {
+	if !cbCtx.EnvVariables.EnableBrownfieldDeployment {
+		return
+	}
+	prohibitedTargets := c.k8sContext.ListAzureProhibitedTargets()
+	if len(prohibitedTargets) > 0 {
+		cbCtx.ProhibitedTargets = prohibitedTargets
+		var prohibitedTargetsList []string
+		for _, target := range *brownfield.GetTargetBlacklist(prohibitedTargets) {
+			targetJSON, _ := json.Marshal(target)
+			prohibitedTargetsList = append(prohibitedTargetsList, string(targetJSON))
+		}
+		glog.V(3).Infof("[brownfield] Prohibited targets: %s", strings.Join(prohibitedTargetsList, ", "))
+	} else {
+		glog.Warning("Brownfield Deployment is enabled, but AGIC did not find any AzureProhibitedTarget CRDs; Disabling brownfield deployment feature.")
+		cbCtx.EnvVariables.EnableBrownfieldDeployment = false
+	}
+}
+
 // MutateAppGateway applies App Gateway config.
-func (c AppGwIngressController) MutateAppGateway() error {
+func (c AppGwIngressController) MutateAppGateway() (err error) {
+	reconcileStart := time.Now()
+	span := c.tracer.StartSpan("reconcile")
+	span.SetAttribute(azure.AttributeAppGwName, c.appGwIdentifier.AppGwName)
+	defer func() {
+		span.SetError(err)
+		span.End()
+		// Without tracing the span is nil, and the duration has no exemplar.
+		c.metricStore.ObserveReconcileDurationSec(time.Since(reconcileStart), span.TraceID())
+	}()
+	// The controller is a copy; its ARM calls of this reconcile are children of the span of the reconcile.
+	c.azClient = c.azClient.WithContext(tracing.ContextWithSpan(context.Background(), span))
+
 	appGw, cbCtx, err := c.getAppGw()
 	if err != nil {
 		return err
 	}
+	span.SetAttribute("k8s.ingress.count", len(cbCtx.IngressList))
+
+	// The etag of the App Gateway as it is now; a rollback to the last applied config is made against it.
+	etag := appGw.Etag
 
 	existingConfigJSON, _ := dumpSanitizedJSON(appGw, false, to.StringPtr("-- Existing App Gwy Config --"))
 	glog.V(5).Info("Existing App Gateway config: ", string(existingConfigJSON))
 
-	if cbCtx.EnvVariables.EnableBrownfieldDeployment {
-		prohibitedTargets := c.k8sContext.ListAzureProhibitedTargets()
-		if len(prohibitedTargets) > 0 {
-			cbCtx.ProhibitedTargets = prohibitedTargets
-			var prohibitedTargetsList []string
-			for _, target := range *brownfield.GetTargetBlacklist(prohibitedTargets) {
-				targetJSON, _ := json.Marshal(target)
-				prohibitedTargetsList = append(prohibitedTargetsList, string(targetJSON))
-			}
-			glog.V(3).Infof("[brownfield] Prohibited targets: %s", strings.Join(prohibitedTargetsList, ", "))
-		} else {
-			glog.Warning("Brownfield Deployment is enabled, but AGIC did not find any AzureProhibitedTarget CRDs; Disabling brownfield deployment feature.")
-			cbCtx.EnvVariables.EnableBrownfieldDeployment = false
-		}
+	// The config builder modifies the existing config; keep a copy to audit and summarize the changes against.
+	var auditJSON []byte
+	if cbCtx.EnvVariables.EnableAuditLog || cbCtx.EnvVariables.SummaryConfigMap != "" {
+		auditJSON, _ = appGw.MarshalJSON()
+	}
+
+	c.setProhibitedTargets(cbCtx)
+
+	// Ingresses meant for AGIC, but of another ingress class, are skipped; their owners are told why.
+	if c.ingressClassMismatches != nil {
+		c.ingressClassMismatches.report(c.recorder, c.k8sContext.ListIngressesOfOtherClasses(), time.Now())
+	}
+
+	if cbCtx.EnvVariables.EnableRewrites {
+		cbCtx.Rewrites = c.k8sContext.ListAzureApplicationGatewayRewrites()
 	}
 
 	if cbCtx.EnvVariables.EnableIstioIntegration {
@@ -94,7 +147,16 @@ func (c AppGwIngressController) MutateAppGateway() error {
 		}
 	}
 
+	// The ingresses removed within the pruning grace period keep their objects, until they are recreated or it ends.
+	if c.ingressTombstones != nil {
+		cbCtx.IngressList = c.ingressTombstones.Retain(cbCtx.IngressList, appgw.GetPruningGracePeriod(cbCtx.EnvVariables), time.Now())
+	}
+
+	ingressList := cbCtx.IngressList
 	cbCtx.IngressList = c.PruneIngress(appGw, cbCtx)
+	if err := c.validateIngresses(cbCtx.EnvVariables, ingressList, cbCtx.IngressList); err != nil {
+		return err
+	}
 
 	if cbCtx.EnvVariables.EnableIstioIntegration {
 		var gatewaysInfo []string
@@ -109,11 +171,13 @@ func (c AppGwIngressController) MutateAppGateway() error {
 		errorLine := fmt.Sprint("Got a fatal validation error on existing Application Gateway config. Will retry getting Application Gateway until error is resolved:", err)
 		glog.Error(errorLine)
 		if c.agicPod != nil {
-			c.recorder.Event(c.agicPod, v1.EventTypeWarning, events.ReasonInvalidAppGwConfig, errorLine)
+			events.Warning(c.recorder, c.agicPod, events.ReasonInvalidAppGwConfig, errorLine)
 		}
 		return err
 	}
 
+	buildSpan := span.StartChild("build")
+
 	// Create a configbuilder based on current appgw config
 	configBuilder := appgw.NewConfigBuilder(c.k8sContext, &c.appGwIdentifier, appGw, c.recorder, realClock{})
 
@@ -122,7 +186,7 @@ func (c AppGwIngressController) MutateAppGateway() error {
 		errorLine := fmt.Sprint("ConfigBuilder PostBuildValidate returned error:", err)
 		glog.Error(errorLine)
 		if c.agicPod != nil {
-			c.recorder.Event(c.agicPod, v1.EventTypeWarning, events.ReasonValidatonError, errorLine)
+			events.Warning(c.recorder, c.agicPod, events.ReasonValidatonError, errorLine)
 		}
 	}
 
@@ -132,25 +196,105 @@ func (c AppGwIngressController) MutateAppGateway() error {
 		errorLine := fmt.Sprint("ConfigBuilder Build returned error:", err)
 		glog.Error(errorLine)
 		if c.agicPod != nil {
-			c.recorder.Event(c.agicPod, v1.EventTypeWarning, events.ReasonValidatonError, errorLine)
+			events.Warning(c.recorder, c.agicPod, events.ReasonValidatonError, errorLine)
 		}
+		buildSpan.SetError(err)
+		buildSpan.End()
 		return err
 	}
 
+	if cbCtx.EnvVariables.EnableDebugServer && c.lastDesiredConfig != nil {
+		c.lastDesiredConfig.set(generatedAppGw, time.Now())
+	}
+
+	if cbCtx.EnvVariables.EnableZoneMetrics {
+		c.updateZoneMetrics(generatedAppGw)
+	}
+
+	// Old addresses of swapped backend pools are removed when their drain window ends, even if nothing else changes.
+	if c.poolDrains != nil {
+		if delay, pending := c.poolDrains.PendingReconcile(time.Now()); pending {
+			c.k8sContext.ReconcileAfter(delay)
+		}
+	}
+
+	// The deferred removals of addresses of backend pools are made when they may proceed, even if nothing else changes.
+	if c.poolRemovals != nil {
+		if delay, pending := c.poolRemovals.PendingReconcile(time.Now()); pending {
+			c.k8sContext.ReconcileAfter(delay)
+		}
+	}
+
+	// The objects of removed ingresses are pruned when their grace period ends, even if nothing else changes.
+	if c.ingressTombstones != nil {
+		if delay, pending := c.ingressTombstones.PendingReconcile(time.Now()); pending {
+			c.k8sContext.ReconcileAfter(delay)
+		}
+	}
+
+	// The FQDNs of ExternalName services are resolved again when their refresh interval ends, even if nothing else changes.
+	if interval := appgw.GetFQDNRefreshInterval(cbCtx.EnvVariables); c.fqdnResolutions != nil && interval != 0 {
+		if delay, pending := c.fqdnResolutions.PendingReconcile(interval, time.Now()); pending {
+			c.k8sContext.ReconcileAfter(delay)
+		}
+	}
+
+	// The capacity of App Gateway follows the autoscale schedule, even if nothing else changes.
+	if c.autoscaleSchedule != nil {
+		if delay, pending := c.autoscaleSchedule.PendingReconcile(time.Now()); pending {
+			c.k8sContext.ReconcileAfter(delay)
+		}
+	}
+
 	// Run post validations to report errors in the config generation.
 	if err = configBuilder.PostBuildValidate(cbCtx); err != nil {
 		errorLine := fmt.Sprint("ConfigBuilder PostBuildValidate returned error:", err)
 		glog.Error(errorLine)
 		if c.agicPod != nil {
-			c.recorder.Event(c.agicPod, v1.EventTypeWarning, events.ReasonValidatonError, errorLine)
+			events.Warning(c.recorder, c.agicPod, events.ReasonValidatonError, errorLine)
+		}
+	}
+	buildSpan.End()
+
+	configChanged := !c.configIsSame(appGw)
+	span.SetAttribute("appgw.config_changed", configChanged)
+
+	// While paused, AGIC keeps watching and building the config, but does not apply it.
+	// The cache is left untouched, so the config is applied on the first reconcile after the pause is lifted.
+	paused := c.k8sContext.IsReconcilePaused()
+	c.metricStore.SetReconcilePaused(paused)
+	if paused {
+		logLine := fmt.Sprintf("Reconcile is paused by ConfigMap %s; App Gateway config has not changed", c.k8sContext.PauseConfigMapKey())
+		if configChanged {
+			generatedConfigJSON, _ := dumpSanitizedJSON(generatedAppGw, false, to.StringPtr("-- Paused App Gwy Config --"))
+			glog.V(3).Info("App Gateway config, which would be applied: ", string(generatedConfigJSON))
+			logLine = fmt.Sprintf("Reconcile is paused by ConfigMap %s; App Gateway config has changed and will be applied once the pause is lifted", c.k8sContext.PauseConfigMapKey())
+		}
+		glog.Warning(logLine)
+		if c.agicPod != nil {
+			events.Warning(c.recorder, c.agicPod, events.ReasonReconcilePaused, logLine)
 		}
+		return nil
 	}
 
-	if c.configIsSame(appGw) {
+	if !configChanged {
 		glog.V(3).Info("cache: Config has NOT changed! No need to connect to ARM.")
+		c.syncStatus.setLastSuccessfulSync(time.Now())
 		return nil
 	}
 
+	// The config is rolled forward only once it passed on the staging App Gateway; it is tried again on the next
+	// reconcile otherwise.
+	if c.staging != nil {
+		if err = c.applyToStaging(cbCtx, span); err != nil {
+			c.configCache = nil
+			if c.firewallPolicies != nil {
+				c.firewallPolicies.reset()
+			}
+			return err
+		}
+	}
+
 	glog.V(3).Info("BEGIN AppGateway deployment")
 	defer glog.V(3).Info("END AppGateway deployment")
 
@@ -160,17 +304,29 @@ func (c AppGwIngressController) MutateAppGateway() error {
 	if err != nil {
 		// Reset cache
 		c.configCache = nil
-		configJSON, _ := dumpSanitizedJSON(appGw, cbCtx.EnvVariables.EnableSaveConfigToFile, nil)
-		glogIt := glog.Errorf
-		if cbCtx.EnvVariables.EnablePanicOnPutError {
-			glogIt = glog.Fatalf
+		if c.firewallPolicies != nil {
+			c.firewallPolicies.reset()
 		}
-		errorLine := fmt.Sprintf("Failed applying App Gwy configuration:\n%s\n\nerror: %s", string(configJSON), err)
-		glogIt(errorLine)
-		if c.agicPod != nil {
-			c.recorder.Event(c.agicPod, v1.EventTypeWarning, events.ReasonFailedApplyingAppGwConfig, errorLine)
+		if azure.IsSubnetFull(err) {
+			// The config is valid; it does not fit the subnet, which only its owner can grow.
+			c.reportSubnetFull(generatedAppGw, err)
+		} else {
+			configJSON, _ := dumpSanitizedJSON(appGw, cbCtx.EnvVariables.EnableSaveConfigToFile, nil)
+			glogIt := glog.Errorf
+			if cbCtx.EnvVariables.EnablePanicOnPutError {
+				glogIt = glog.Fatalf
+			}
+			errorLine := fmt.Sprintf("Failed applying App Gwy configuration:\n%s\n\nerror: %s", string(configJSON), err)
+			glogIt(errorLine)
+			if c.agicPod != nil {
+				events.Warning(c.recorder, c.agicPod, failedUpdateReason(err), errorLine)
+			}
 		}
 		c.metricStore.IncArmAPIUpdateCallFailureCounter()
+		if cbCtx.EnvVariables.SummaryConfigMap != "" {
+			c.writeAppliedSummary(cbCtx.EnvVariables, auditJSON, generatedAppGw, cbCtx.IngressList, err, time.Now())
+		}
+		c.rollbackOnFailedUpdate(cbCtx.EnvVariables, etag)
 		return err
 	}
 	// Wait until deployment finshes and save the error message
@@ -189,7 +345,7 @@ func (c AppGwIngressController) MutateAppGateway() error {
 		errorLine := fmt.Sprint("Unable to deploy App Gateway config.", err)
 		glog.Warning(errorLine)
 		if c.agicPod != nil {
-			c.recorder.Event(c.agicPod, v1.EventTypeWarning, events.ReasonFailedApplyingAppGwConfig, errorLine)
+			events.Warning(c.recorder, c.agicPod, failedUpdateReason(err), errorLine)
 		}
 		c.metricStore.IncArmAPIUpdateCallFailureCounter()
 		return ErrDeployingAppGatewayConfig
@@ -198,7 +354,37 @@ func (c AppGwIngressController) MutateAppGateway() error {
 	glog.V(3).Info("cache: Updated with latest applied config.")
 	c.updateCache(appGw)
 
+	if c.autoscaleSchedule != nil {
+		c.autoscaleSchedule.Applied()
+	}
+
+	if c.lastKnownGood != nil {
+		c.lastKnownGood.applied(generatedAppGw)
+	}
+
+	if cbCtx.EnvVariables.EnableAuditLog {
+		c.auditAppliedConfig(auditJSON, generatedAppGw, cbCtx.IngressList)
+	}
+
+	if cbCtx.EnvVariables.SummaryConfigMap != "" {
+		c.writeAppliedSummary(cbCtx.EnvVariables, auditJSON, generatedAppGw, cbCtx.IngressList, nil, time.Now())
+	}
+
+	if cbCtx.EnvVariables.StampConfigHash {
+		c.stampConfigHash(cbCtx.IngressList)
+	}
+
 	c.metricStore.IncArmAPIUpdateCallSuccessCounter()
+	c.syncStatus.setLastSuccessfulSync(time.Now())
 
 	return nil
 }
+
+// failedUpdateReason returns the reason of the event about the failed update of the App Gateway: tools may retry
+// later, without looking into the config, when ARM throttled the update.
+func failedUpdateReason(err error) events.Reason {
+	if azure.IsThrottled(err) {
+		return events.ReasonARMThrottled
+	}
+	return events.ReasonFailedApplyingAppGwConfig
+}
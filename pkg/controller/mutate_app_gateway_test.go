@@ -0,0 +1,39 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Azure/go-autorest/autorest"
+	goazure "github.com/Azure/go-autorest/autorest/azure"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+)
+
+var _ = Describe("reason of the event about a failed update", func() {
+	It("tells that ARM throttled the update", func() {
+		err := autorest.DetailedError{
+			Original:   &goazure.RequestError{ServiceError: &goazure.ServiceError{Code: "TooManyRequests"}},
+			StatusCode: http.StatusTooManyRequests,
+			Response:   &http.Response{StatusCode: http.StatusTooManyRequests},
+		}
+		Expect(failedUpdateReason(err)).To(Equal(events.ReasonARMThrottled))
+	})
+
+	It("tells that the update failed otherwise", func() {
+		err := autorest.DetailedError{
+			Original:   &goazure.RequestError{ServiceError: &goazure.ServiceError{Code: "ApplicationGatewayInvalidConfig"}},
+			StatusCode: http.StatusBadRequest,
+			Response:   &http.Response{StatusCode: http.StatusBadRequest},
+		}
+		Expect(failedUpdateReason(err)).To(Equal(events.ReasonFailedApplyingAppGwConfig))
+		Expect(failedUpdateReason(errors.New("timeout"))).To(Equal(events.ReasonFailedApplyingAppGwConfig))
+	})
+})
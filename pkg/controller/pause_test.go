@@ -0,0 +1,114 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"time"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	testclient "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/appgw"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/azure"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/agic_crd_client/clientset/versioned/fake"
+	istioFake "github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/istio_crd_client/clientset/versioned/fake"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/k8scontext"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/metricstore"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
+)
+
+// pausedMetricStore records whether the reconcile is reported as paused.
+type pausedMetricStore struct {
+	metricstore.MetricStore
+	paused bool
+}
+
+func (ms *pausedMetricStore) SetReconcilePaused(paused bool) {
+	ms.paused = paused
+}
+
+var _ = Describe("pausing the reconcile", func() {
+	const agicNamespace = "agic"
+	const pauseName = "agic-pause"
+
+	var controller *AppGwIngressController
+	var k8sClient kubernetes.Interface
+	var recorder *record.FakeRecorder
+	var metricStore *pausedMetricStore
+	var stopChannel chan struct{}
+	var updated int
+
+	setPause := func(pause string) {
+		configMap := &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: pauseName, Namespace: agicNamespace},
+			Data:       map[string]string{k8scontext.PauseKey: pause},
+		}
+		_, err := k8sClient.CoreV1().ConfigMaps(agicNamespace).Update(configMap)
+		Expect(err).ToNot(HaveOccurred())
+		Eventually(controller.k8sContext.IsReconcilePaused).Should(Equal(pause == "true"))
+	}
+
+	BeforeEach(func() {
+		updated = 0
+		azClient := azure.NewFakeAzClient()
+		azClient.GetGatewayFunc = func() (n.ApplicationGateway, error) {
+			config := appgw.NewAppGwyConfigFixture()
+			config.FrontendPorts = &[]n.ApplicationGatewayFrontendPort{}
+			return n.ApplicationGateway{ApplicationGatewayPropertiesFormat: config}, nil
+		}
+		azClient.UpdateGatewayFunc = func(*n.ApplicationGateway) error {
+			updated++
+			return nil
+		}
+
+		stopChannel = make(chan struct{})
+		k8sClient = testclient.NewSimpleClientset(&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: pauseName, Namespace: agicNamespace},
+			Data:       map[string]string{k8scontext.PauseKey: "false"},
+		})
+		k8sContext := k8scontext.NewContext(k8sClient, fake.NewSimpleClientset(), istioFake.NewSimpleClientset(), nil, time.Second, k8scontext.DefaultWorkQueueDepth, metricstore.NewFakeMetricStore())
+		env := environment.GetFakeEnv()
+		env.AGICPodNamespace = agicNamespace
+		env.PauseConfigMap = pauseName
+		Expect(k8sContext.Run(stopChannel, true, env)).To(Succeed())
+
+		recorder = record.NewFakeRecorder(100)
+		metricStore = &pausedMetricStore{MetricStore: metricstore.NewFakeMetricStore()}
+		identifier := appgw.Identifier{SubscriptionID: tests.Subscription, ResourceGroup: tests.ResourceGroup, AppGwName: tests.AppGwName}
+		controller = NewAppGwIngressController(azClient, identifier, k8sContext, recorder, metricStore, &v1.Pod{})
+	})
+
+	AfterEach(func() {
+		close(stopChannel)
+	})
+
+	It("reports the pause even when the config has not changed", func() {
+		Expect(controller.MutateAppGateway()).To(Succeed())
+		Expect(updated).To(Equal(1))
+		Expect(metricStore.paused).To(BeFalse())
+
+		setPause("true")
+		Expect(controller.MutateAppGateway()).To(Succeed())
+		Expect(updated).To(Equal(1))
+		Expect(metricStore.paused).To(BeTrue())
+		event := <-recorder.Events
+		Expect(event).To(HavePrefix("Warning " + string(events.ReasonReconcilePaused)))
+		Expect(event).To(ContainSubstring("has not changed"))
+
+		setPause("false")
+		Expect(controller.MutateAppGateway()).To(Succeed())
+		Expect(updated).To(Equal(1))
+		Expect(metricStore.paused).To(BeFalse())
+	})
+})
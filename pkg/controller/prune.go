@@ -2,16 +2,17 @@ package controller
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 
 	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
 	"github.com/golang/glog"
-	v1 "k8s.io/api/core/v1"
 	"k8s.io/api/extensions/v1beta1"
 
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/appgw"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/brownfield"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
 )
 
@@ -28,6 +29,10 @@ func (c *AppGwIngressController) PruneIngress(appGw *n.ApplicationGateway, cbCtx
 		}
 		pruneFuncList = append(pruneFuncList, pruneNoPrivateIP)
 		pruneFuncList = append(pruneFuncList, pruneRedirectWithNoTLS)
+		pruneFuncList = append(pruneFuncList, pruneDisallowedHosts)
+		pruneFuncList = append(pruneFuncList, pruneHTTPSOnly)
+		pruneFuncList = append(pruneFuncList, pruneOutOfScopeServices)
+		pruneFuncList = append(pruneFuncList, pruneDanglingReferences)
 	})
 	prunedIngresses := cbCtx.IngressList
 	for _, prune := range pruneFuncList {
@@ -37,6 +42,48 @@ func (c *AppGwIngressController) PruneIngress(appGw *n.ApplicationGateway, cbCtx
 	return prunedIngresses
 }
 
+// The modes of the invalid ingress counter: the invalid ingress was ignored, or it failed the reconcile.
+const (
+	invalidIngressIgnored = "ignored"
+	invalidIngressFailed  = "failed"
+)
+
+// validateIngresses counts the ingresses, which the prune functions filtered as invalid. With strict ingress validation
+// an invalid ingress fails the reconcile, so that the config of the valid ingresses is not applied without it either.
+func (c *AppGwIngressController) validateIngresses(env environment.EnvVariables, ingressList []*v1beta1.Ingress, validIngresses []*v1beta1.Ingress) error {
+	valid := make(map[*v1beta1.Ingress]interface{})
+	for _, ingress := range validIngresses {
+		valid[ingress] = nil
+	}
+
+	mode := invalidIngressIgnored
+	if env.StrictIngressValidation {
+		mode = invalidIngressFailed
+	}
+	var invalidIngresses []*v1beta1.Ingress
+	for _, ingress := range ingressList {
+		if _, exists := valid[ingress]; !exists {
+			invalidIngresses = append(invalidIngresses, ingress)
+			c.metricStore.IncInvalidIngressCounter(mode)
+		}
+	}
+	if len(invalidIngresses) == 0 || !env.StrictIngressValidation {
+		return nil
+	}
+
+	var names []string
+	for _, ingress := range invalidIngresses {
+		names = append(names, fmt.Sprintf("%s/%s", ingress.Namespace, ingress.Name))
+		events.Warning(c.recorder, ingress, events.ReasonInvalidIngresses, "App Gateway config is not applied until this Ingress is corrected, as strict ingress validation is enabled")
+	}
+	errorLine := fmt.Sprintf("App Gateway config is not applied, as strict ingress validation is enabled and these Ingresses are invalid: %s", strings.Join(names, ", "))
+	glog.Error(errorLine)
+	if c.agicPod != nil {
+		events.Warning(c.recorder, c.agicPod, events.ReasonInvalidIngresses, errorLine)
+	}
+	return ErrInvalidIngresses
+}
+
 // pruneProhibitedIngress filters rules that are specified by prohibited target CRD
 func pruneProhibitedIngress(c *AppGwIngressController, appGw *n.ApplicationGateway, cbCtx *appgw.ConfigBuilderContext, ingressList []*v1beta1.Ingress) []*v1beta1.Ingress {
 	// Mutate the list of Ingresses by removing ones that AGIC should not be creating configuration.
@@ -59,13 +106,13 @@ func pruneNoPrivateIP(c *AppGwIngressController, appGw *n.ApplicationGateway, cb
 			glog.Errorf("Ingress %s/%s has invalid value for annotation %s", ingress.Namespace, ingress.Name, annotations.UsePrivateIPKey)
 		}
 
-		usePrivateIP = usePrivateIP || cbCtx.EnvVariables.UsePrivateIP == "true"
+		usePrivateIP = usePrivateIP || appgw.UsePrivateIPByDefault(appGw.FrontendIPConfigurations, cbCtx.EnvVariables)
 		if usePrivateIP && !appGwHasPrivateIP {
 			errorLine := fmt.Sprintf("ignoring Ingress %s/%s as it requires Application Gateway %s has a private IP adress", ingress.Namespace, ingress.Name, c.appGwIdentifier.AppGwName)
 			glog.Error(errorLine)
-			c.recorder.Event(ingress, v1.EventTypeWarning, events.ReasonNoPrivateIPError, errorLine)
+			events.Warning(c.recorder, ingress, events.ReasonNoPrivateIPError, errorLine)
 			if c.agicPod != nil {
-				c.recorder.Event(c.agicPod, v1.EventTypeWarning, events.ReasonNoPrivateIPError, errorLine)
+				events.Warning(c.recorder, c.agicPod, events.ReasonNoPrivateIPError, errorLine)
 			}
 		} else {
 			prunedIngresses = append(prunedIngresses, ingress)
@@ -84,9 +131,34 @@ func pruneRedirectWithNoTLS(c *AppGwIngressController, appGw *n.ApplicationGatew
 		if !hasTLS && sslRedirect {
 			errorLine := fmt.Sprintf("ignoring Ingress %s/%s as it has an invalid spec. It is annotated with ssl-redirect: true but is missing a TLS secret. Please add a TLS secret or remove ssl-redirect annotation", ingress.Namespace, ingress.Name)
 			glog.Error(errorLine)
-			c.recorder.Event(ingress, v1.EventTypeWarning, events.ReasonRedirectWithNoTLS, errorLine)
+			events.Warning(c.recorder, ingress, events.ReasonRedirectWithNoTLS, errorLine)
+			if c.agicPod != nil {
+				events.Warning(c.recorder, c.agicPod, events.ReasonRedirectWithNoTLS, errorLine)
+			}
+		} else {
+			prunedIngresses = append(prunedIngresses, ingress)
+		}
+	}
+
+	return prunedIngresses
+}
+
+// pruneDisallowedHosts filters ingresses with hosts outside of the allowed host suffixes, when these are configured.
+// Ingresses with a rule without a host, which would create a listener for all hosts, are filtered as well.
+func pruneDisallowedHosts(c *AppGwIngressController, appGw *n.ApplicationGateway, cbCtx *appgw.ConfigBuilderContext, ingressList []*v1beta1.Ingress) []*v1beta1.Ingress {
+	suffixes := parseAllowedHostSuffixes(cbCtx.EnvVariables.AllowedHostSuffixes)
+	if len(suffixes) == 0 {
+		return ingressList
+	}
+
+	var prunedIngresses []*v1beta1.Ingress
+	for _, ingress := range ingressList {
+		if host, allowed := hasAllowedHosts(ingress, suffixes); !allowed {
+			errorLine := fmt.Sprintf("ignoring Ingress %s/%s as host %q is not within the allowed host suffixes %s", ingress.Namespace, ingress.Name, host, strings.Join(suffixes, ", "))
+			glog.Error(errorLine)
+			events.Warning(c.recorder, ingress, events.ReasonDisallowedHost, errorLine)
 			if c.agicPod != nil {
-				c.recorder.Event(c.agicPod, v1.EventTypeWarning, events.ReasonRedirectWithNoTLS, errorLine)
+				events.Warning(c.recorder, c.agicPod, events.ReasonDisallowedHost, errorLine)
 			}
 		} else {
 			prunedIngresses = append(prunedIngresses, ingress)
@@ -95,3 +167,61 @@ func pruneRedirectWithNoTLS(c *AppGwIngressController, appGw *n.ApplicationGatew
 
 	return prunedIngresses
 }
+
+func parseAllowedHostSuffixes(value string) []string {
+	var suffixes []string
+	for _, suffix := range strings.Split(value, ",") {
+		if suffix = strings.ToLower(strings.TrimSpace(suffix)); suffix != "" {
+			suffixes = append(suffixes, suffix)
+		}
+	}
+	return suffixes
+}
+
+// hasAllowedHosts returns the first host of the ingress, which is not allowed; an empty host stands for all hosts.
+func hasAllowedHosts(ingress *v1beta1.Ingress, suffixes []string) (string, bool) {
+	extendedHostNames, _ := annotations.GetHostNameExtensions(ingress)
+	var hostnames []string
+	for _, rule := range ingress.Spec.Rules {
+		if rule.Host != "" {
+			hostnames = append(hostnames, rule.Host)
+		} else if len(extendedHostNames) == 0 {
+			return "", false
+		}
+	}
+	if ingress.Spec.Backend != nil && len(extendedHostNames) == 0 {
+		return "", false
+	}
+	for _, host := range append(hostnames, extendedHostNames...) {
+		if !isHostAllowed(host, suffixes) {
+			return host, false
+		}
+	}
+	return "", true
+}
+
+// isHostAllowed determines whether the host, which may contain wildcards, only matches hosts within the allowed suffixes.
+// The suffix ourteam.example.com allows the domain and its subdomains; *.ourteam.example.com only allows the subdomains.
+func isHostAllowed(host string, suffixes []string) bool {
+	host = strings.ToLower(host)
+	if idx := strings.LastIndexAny(host, "*?"); idx >= 0 {
+		// The wildcard host matches any host ending with what follows the last wildcard.
+		host = host[idx+1:]
+		for _, suffix := range suffixes {
+			if strings.HasSuffix(host, "."+strings.TrimPrefix(suffix, "*.")) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, suffix := range suffixes {
+		if strings.HasPrefix(suffix, "*.") {
+			if strings.HasSuffix(host, suffix[1:]) {
+				return true
+			}
+		} else if host == suffix || strings.HasSuffix(host, "."+suffix) {
+			return true
+		}
+	}
+	return false
+}
@@ -16,6 +16,8 @@ import (
 
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/appgw"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/metricstore"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests/fixtures"
 )
@@ -30,7 +32,8 @@ var _ = Describe("prune function tests", func() {
 				ResourceGroup:  "xxxx",
 				AppGwName:      "appgw",
 			},
-			recorder: record.NewFakeRecorder(100),
+			recorder:    record.NewFakeRecorder(100),
+			metricStore: metricstore.NewFakeMetricStore(),
 		}
 	})
 
@@ -126,4 +129,130 @@ var _ = Describe("prune function tests", func() {
 			Expect(prunedIngresses).To(ContainElement(ingressValid2))
 		})
 	})
+
+	Context("ensure pruneDisallowedHosts prunes ingress", func() {
+		suffixes := []string{"ourteam.example.com", "*.apps.contoso.com"}
+
+		newIngress := func(hosts ...string) *v1beta1.Ingress {
+			ingress := tests.NewIngressFixture()
+			rule := ingress.Spec.Rules[0]
+			ingress.Spec.Rules = nil
+			for _, host := range hosts {
+				rule.Host = host
+				ingress.Spec.Rules = append(ingress.Spec.Rules, rule)
+			}
+			return ingress
+		}
+
+		It("matches hosts within the allowed suffixes", func() {
+			Expect(isHostAllowed("ourteam.example.com", suffixes)).To(BeTrue())
+			Expect(isHostAllowed("www.ourteam.example.com", suffixes)).To(BeTrue())
+			Expect(isHostAllowed("WWW.OurTeam.Example.com", suffixes)).To(BeTrue())
+			Expect(isHostAllowed("www.apps.contoso.com", suffixes)).To(BeTrue())
+
+			Expect(isHostAllowed("apps.contoso.com", suffixes)).To(BeFalse())
+			Expect(isHostAllowed("evilourteam.example.com", suffixes)).To(BeFalse())
+			Expect(isHostAllowed("example.com", suffixes)).To(BeFalse())
+			Expect(isHostAllowed("ourteam.example.com.evil.com", suffixes)).To(BeFalse())
+		})
+
+		It("matches wildcard hosts within the allowed suffixes", func() {
+			Expect(isHostAllowed("*.ourteam.example.com", suffixes)).To(BeTrue())
+			Expect(isHostAllowed("www.*.ourteam.example.com", suffixes)).To(BeTrue())
+			Expect(isHostAllowed("*.apps.contoso.com", suffixes)).To(BeTrue())
+			Expect(isHostAllowed("w?w.apps.contoso.com", suffixes)).To(BeTrue())
+
+			Expect(isHostAllowed("*.example.com", suffixes)).To(BeFalse())
+			Expect(isHostAllowed("*ourteam.example.com", suffixes)).To(BeFalse())
+			Expect(isHostAllowed("*", suffixes)).To(BeFalse())
+		})
+
+		It("keeps all ingresses when no suffixes are configured", func() {
+			cbCtx := &appgw.ConfigBuilderContext{
+				IngressList: []*v1beta1.Ingress{newIngress("www.contoso.com"), newIngress("")},
+			}
+			Expect(pruneDisallowedHosts(controller, nil, cbCtx, cbCtx.IngressList)).To(Equal(cbCtx.IngressList))
+		})
+
+		It("removes ingresses with hosts outside of the allowed suffixes and emits events", func() {
+			recorder := record.NewFakeRecorder(100)
+			controller.recorder = recorder
+			allowed := newIngress("www.ourteam.example.com", "api.apps.contoso.com")
+			disallowed := newIngress("www.ourteam.example.com", "www.otherteam.example.com")
+			catchAll := newIngress("")
+			extended := newIngress("")
+			extended.Annotations[annotations.HostNameExtensionKey] = "*.ourteam.example.com"
+			extendedDisallowed := newIngress("www.ourteam.example.com")
+			extendedDisallowed.Annotations[annotations.HostNameExtensionKey] = "*.example.com"
+
+			cbCtx := &appgw.ConfigBuilderContext{
+				IngressList: []*v1beta1.Ingress{allowed, disallowed, catchAll, extended, extendedDisallowed},
+			}
+			cbCtx.EnvVariables.AllowedHostSuffixes = "ourteam.example.com, *.apps.contoso.com"
+
+			prunedIngresses := pruneDisallowedHosts(controller, nil, cbCtx, cbCtx.IngressList)
+			Expect(prunedIngresses).To(ConsistOf(allowed, extended))
+			Expect(recorder.Events).To(HaveLen(3))
+			Expect(<-recorder.Events).To(ContainSubstring("www.otherteam.example.com"))
+		})
+	})
+
+	Context("ensure validateIngresses fails the reconcile in strict ingress validation", func() {
+		var recorder *record.FakeRecorder
+		var ingressList []*v1beta1.Ingress
+		var validIngresses []*v1beta1.Ingress
+		var ingressValid, ingressNoTLS, ingressPrivate *v1beta1.Ingress
+
+		BeforeEach(func() {
+			recorder = record.NewFakeRecorder(100)
+			controller.recorder = recorder
+			controller.agicPod = tests.NewPodFixture("agic", "agic-ns", "agic", 8080)
+
+			ingressValid = tests.NewIngressFixture()
+			ingressValid.Name = "valid"
+			ingressNoTLS = tests.NewIngressFixture()
+			ingressNoTLS.Name = "no-tls"
+			ingressNoTLS.Annotations = map[string]string{annotations.SslRedirectKey: "true"}
+			ingressNoTLS.Spec.TLS = nil
+			ingressPrivate = tests.NewIngressFixture()
+			ingressPrivate.Name = "private"
+			ingressPrivate.Annotations = map[string]string{annotations.UsePrivateIPKey: "true"}
+
+			ingressList = []*v1beta1.Ingress{ingressNoTLS, ingressValid, ingressPrivate}
+			cbCtx := &appgw.ConfigBuilderContext{IngressList: ingressList}
+			appGw := fixtures.GetAppGateway()
+			validIngresses = pruneRedirectWithNoTLS(controller, &appGw, cbCtx, pruneNoPrivateIP(controller, &appGw, cbCtx, ingressList))
+			Expect(validIngresses).To(ConsistOf(ingressValid))
+			for len(recorder.Events) > 0 {
+				<-recorder.Events
+			}
+		})
+
+		It("ignores the invalid ingresses by default", func() {
+			Expect(controller.validateIngresses(environment.EnvVariables{}, ingressList, validIngresses)).ToNot(HaveOccurred())
+			Expect(recorder.Events).To(BeEmpty())
+		})
+
+		It("fails with events on the invalid ingresses and AGIC", func() {
+			env := environment.EnvVariables{StrictIngressValidation: true}
+			err := controller.validateIngresses(env, ingressList, validIngresses)
+			Expect(err).To(Equal(ErrInvalidIngresses))
+
+			Expect(recorder.Events).To(HaveLen(3))
+			var messages []string
+			for len(recorder.Events) > 0 {
+				messages = append(messages, <-recorder.Events)
+			}
+			Expect(messages[2]).To(ContainSubstring("--namespace--/no-tls, --namespace--/private"))
+			for _, message := range messages {
+				Expect(message).To(HavePrefix("Warning InvalidIngresses"))
+			}
+		})
+
+		It("succeeds when all ingresses are valid", func() {
+			env := environment.EnvVariables{StrictIngressValidation: true}
+			Expect(controller.validateIngresses(env, validIngresses, validIngresses)).ToNot(HaveOccurred())
+			Expect(recorder.Events).To(BeEmpty())
+		})
+	})
 })
@@ -0,0 +1,100 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/golang/glog"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+)
+
+// lastKnownGood tracks the last App Gateway config AGIC applied successfully and the failed updates since.
+// The config is kept in memory only: it holds the certificates of the listeners.
+type lastKnownGood struct {
+	sync.Mutex
+	appGwJSON     []byte
+	failedUpdates int
+}
+
+func (l *lastKnownGood) applied(appGw *n.ApplicationGateway) {
+	jsonConfig, err := appGw.MarshalJSON()
+	if err != nil {
+		glog.Error("Could not marshal the applied App Gateway config; Rollback to it will not be possible: ", err)
+	}
+
+	l.Lock()
+	defer l.Unlock()
+	l.appGwJSON = jsonConfig
+	l.failedUpdates = 0
+}
+
+// failed counts a failed update. Once the threshold is reached it returns the config to roll back to, if there is one,
+// along with the number of consecutive failed updates, and starts counting again.
+func (l *lastKnownGood) failed(threshold int) (*n.ApplicationGateway, int) {
+	l.Lock()
+	defer l.Unlock()
+	l.failedUpdates++
+	failedUpdates := l.failedUpdates
+	if threshold <= 0 || failedUpdates < threshold || l.appGwJSON == nil {
+		return nil, failedUpdates
+	}
+	l.failedUpdates = 0
+
+	var appGw n.ApplicationGateway
+	if err := appGw.UnmarshalJSON(l.appGwJSON); err != nil {
+		glog.Error("Could not unmarshal the last applied App Gateway config: ", err)
+		return nil, failedUpdates
+	}
+	return &appGw, failedUpdates
+}
+
+// getRollbackThreshold returns the number of consecutive failed updates after which AGIC rolls back, or 0 when disabled.
+func getRollbackThreshold(env environment.EnvVariables) int {
+	threshold, err := strconv.Atoi(env.RollbackAfterFailures)
+	if err != nil {
+		return 0
+	}
+	return threshold
+}
+
+// rollbackOnFailedUpdate counts a failed update of the App Gateway. Once APPGW_ROLLBACK_AFTER_FAILED_UPDATES consecutive
+// updates failed, it puts the last config applied successfully back on the App Gateway to restore service.
+// The desired config is not given up on: it is applied again on the next reconcile.
+func (c AppGwIngressController) rollbackOnFailedUpdate(env environment.EnvVariables, etag *string) {
+	if c.lastKnownGood == nil {
+		return
+	}
+	lastGood, failedUpdates := c.lastKnownGood.failed(getRollbackThreshold(env))
+	if lastGood == nil {
+		return
+	}
+
+	// The last applied config carries the etag of the App Gateway at that time.
+	lastGood.Etag = etag
+	err := c.azClient.UpdateGateway(lastGood)
+	c.metricStore.IncArmAPICallCounter()
+	if err != nil {
+		errorLine := fmt.Sprintf("Failed rolling back to the last applied App Gateway config after %d failed updates: %s", failedUpdates, err)
+		glog.Error(errorLine)
+		if c.agicPod != nil {
+			events.Warning(c.recorder, c.agicPod, events.ReasonFailedApplyingAppGwConfig, errorLine)
+		}
+		return
+	}
+
+	c.metricStore.IncRollbackCounter()
+	errorLine := fmt.Sprintf("Rolled back to the last applied App Gateway config after %d failed updates; the current ingress config could not be applied and will be retried", failedUpdates)
+	glog.Error(errorLine)
+	if c.agicPod != nil {
+		events.Warning(c.recorder, c.agicPod, events.ReasonAppGwConfigRolledBack, errorLine)
+	}
+}
@@ -0,0 +1,92 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"time"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	testclient "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/appgw"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/azure"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/agic_crd_client/clientset/versioned/fake"
+	istioFake "github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/istio_crd_client/clientset/versioned/fake"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/k8scontext"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/metricstore"
+)
+
+var _ = Describe("rollback to the last applied config", func() {
+	var controller *AppGwIngressController
+	var recorder *record.FakeRecorder
+	var rolledBack []*n.ApplicationGateway
+	env := environment.EnvVariables{RollbackAfterFailures: "2"}
+	goodAppGw := &n.ApplicationGateway{
+		Etag: to.StringPtr("old-etag"),
+		ApplicationGatewayPropertiesFormat: &n.ApplicationGatewayPropertiesFormat{
+			BackendAddressPools: &[]n.ApplicationGatewayBackendAddressPool{
+				{Name: to.StringPtr("pool-good")},
+			},
+		},
+	}
+
+	BeforeEach(func() {
+		rolledBack = nil
+		azClient := azure.NewFakeAzClient()
+		azClient.UpdateGatewayFunc = func(appGw *n.ApplicationGateway) error {
+			rolledBack = append(rolledBack, appGw)
+			return nil
+		}
+		recorder = record.NewFakeRecorder(10)
+		k8sContext := k8scontext.NewContext(testclient.NewSimpleClientset(), fake.NewSimpleClientset(), istioFake.NewSimpleClientset(), nil, time.Second, k8scontext.DefaultWorkQueueDepth, metricstore.NewFakeMetricStore())
+		controller = NewAppGwIngressController(azClient, appgw.Identifier{}, k8sContext, recorder, metricstore.NewFakeMetricStore(), &v1.Pod{})
+	})
+
+	It("rolls back once the failed updates reach the threshold", func() {
+		controller.lastKnownGood.applied(goodAppGw)
+
+		controller.rollbackOnFailedUpdate(env, to.StringPtr("new-etag"))
+		Expect(rolledBack).To(BeEmpty())
+
+		controller.rollbackOnFailedUpdate(env, to.StringPtr("new-etag"))
+		Expect(rolledBack).To(HaveLen(1))
+		Expect(*rolledBack[0].Etag).To(Equal("new-etag"))
+		Expect(*(*rolledBack[0].BackendAddressPools)[0].Name).To(Equal("pool-good"))
+		Expect(recorder.Events).To(Receive(ContainSubstring("AppGwConfigRolledBack")))
+
+		// counting starts again after a rollback
+		controller.rollbackOnFailedUpdate(env, to.StringPtr("new-etag"))
+		Expect(rolledBack).To(HaveLen(1))
+	})
+
+	It("does not roll back without a config applied before", func() {
+		controller.rollbackOnFailedUpdate(env, to.StringPtr("new-etag"))
+		controller.rollbackOnFailedUpdate(env, to.StringPtr("new-etag"))
+		Expect(rolledBack).To(BeEmpty())
+	})
+
+	It("resets the failed updates on a successful update", func() {
+		controller.lastKnownGood.applied(goodAppGw)
+		controller.rollbackOnFailedUpdate(env, to.StringPtr("new-etag"))
+		controller.lastKnownGood.applied(goodAppGw)
+		controller.rollbackOnFailedUpdate(env, to.StringPtr("new-etag"))
+		Expect(rolledBack).To(BeEmpty())
+	})
+
+	It("does not roll back when disabled", func() {
+		controller.lastKnownGood.applied(goodAppGw)
+		for i := 0; i < 5; i++ {
+			controller.rollbackOnFailedUpdate(environment.EnvVariables{}, to.StringPtr("new-etag"))
+		}
+		Expect(rolledBack).To(BeEmpty())
+	})
+})
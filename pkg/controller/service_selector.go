@@ -0,0 +1,68 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"fmt"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/golang/glog"
+	"k8s.io/api/extensions/v1beta1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/appgw"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/utils"
+)
+
+// pruneOutOfScopeServices filters the ingresses with a backend service, which AGIC does not watch, when the service
+// selector is set. The services outside of the selector are not cached; without it, a missing backend service leaves
+// the ingress valid and its backend uses the default backend pool.
+func pruneOutOfScopeServices(c *AppGwIngressController, appGw *n.ApplicationGateway, cbCtx *appgw.ConfigBuilderContext, ingressList []*v1beta1.Ingress) []*v1beta1.Ingress {
+	selector := cbCtx.EnvVariables.ServiceSelector
+	if selector == "" {
+		return ingressList
+	}
+
+	var prunedIngresses []*v1beta1.Ingress
+	for _, ingress := range ingressList {
+		if serviceName := c.getOutOfScopeService(ingress); serviceName != "" {
+			errorLine := fmt.Sprintf("ignoring Ingress %s/%s as its backend service %q does not exist, or does not match the service selector %q", ingress.Namespace, ingress.Name, serviceName, selector)
+			glog.Error(errorLine)
+			events.Warning(c.recorder, ingress, events.ReasonServiceOutOfScope, errorLine)
+			if c.agicPod != nil {
+				events.Warning(c.recorder, c.agicPod, events.ReasonServiceOutOfScope, errorLine)
+			}
+		} else {
+			prunedIngresses = append(prunedIngresses, ingress)
+		}
+	}
+
+	return prunedIngresses
+}
+
+// getOutOfScopeService returns the name of the first backend service of the ingress missing from the cache, or an
+// empty string when all its backend services are cached.
+func (c *AppGwIngressController) getOutOfScopeService(ingress *v1beta1.Ingress) string {
+	backends := []*v1beta1.IngressBackend{ingress.Spec.Backend}
+	for _, rule := range ingress.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for pathIdx := range rule.HTTP.Paths {
+			backends = append(backends, &rule.HTTP.Paths[pathIdx].Backend)
+		}
+	}
+
+	for _, backend := range backends {
+		if backend == nil || backend.ServiceName == "" {
+			continue
+		}
+		if c.k8sContext.GetService(utils.GetResourceKey(ingress.Namespace, backend.ServiceName)) == nil {
+			return backend.ServiceName
+		}
+	}
+	return ""
+}
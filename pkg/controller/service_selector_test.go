@@ -0,0 +1,91 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	testclient "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/appgw"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/agic_crd_client/clientset/versioned/fake"
+	istioFake "github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/istio_crd_client/clientset/versioned/fake"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/k8scontext"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/metricstore"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
+)
+
+var _ = Describe("pruning ingresses with backend services outside of the service selector", func() {
+	const outOfScope = "out-of-scope"
+
+	var controller *AppGwIngressController
+	var recorder *record.FakeRecorder
+	var cbCtx *appgw.ConfigBuilderContext
+
+	// Builds an ingress with its rule and default backend routing to the given services.
+	newIngress := func(name, ruleService, defaultService string) *v1beta1.Ingress {
+		ingress := tests.NewIngressFixture()
+		ingress.Name = name
+		ingress.Spec.Rules[0].HTTP.Paths[0].Backend.ServiceName = ruleService
+		ingress.Spec.Backend = nil
+		if defaultService != "" {
+			ingress.Spec.Backend = tests.NewIngressBackendFixture(defaultService, 80)
+		}
+		return ingress
+	}
+
+	BeforeEach(func() {
+		recorder = record.NewFakeRecorder(100)
+		// Only the services matching the selector are cached.
+		k8sContext := k8scontext.NewContext(testclient.NewSimpleClientset(), fake.NewSimpleClientset(), istioFake.NewSimpleClientset(), nil, time.Second, k8scontext.DefaultWorkQueueDepth, metricstore.NewFakeMetricStore())
+		Expect(k8sContext.Caches.Service.Add(tests.NewServiceFixture())).To(Succeed())
+		controller = &AppGwIngressController{
+			k8sContext:  k8sContext,
+			recorder:    recorder,
+			metricStore: metricstore.NewFakeMetricStore(),
+			agicPod:     &v1.Pod{},
+		}
+		cbCtx = &appgw.ConfigBuilderContext{}
+		cbCtx.EnvVariables.ServiceSelector = "app-gateway=true"
+	})
+
+	It("keeps the ingresses with all backend services in scope", func() {
+		ruleOnly := newIngress("rule-only", tests.ServiceName, "")
+		withDefault := newIngress("with-default", tests.ServiceName, tests.ServiceName)
+
+		cbCtx.IngressList = []*v1beta1.Ingress{ruleOnly, withDefault}
+		Expect(pruneOutOfScopeServices(controller, nil, cbCtx, cbCtx.IngressList)).To(Equal(cbCtx.IngressList))
+		Expect(recorder.Events).To(BeEmpty())
+	})
+
+	It("removes the ingresses with a backend service out of scope and emits events", func() {
+		ruleOutOfScope := newIngress("rule-out-of-scope", outOfScope, "")
+		defaultOutOfScope := newIngress("default-out-of-scope", tests.ServiceName, outOfScope)
+		inScope := newIngress("in-scope", tests.ServiceName, tests.ServiceName)
+
+		cbCtx.IngressList = []*v1beta1.Ingress{ruleOutOfScope, defaultOutOfScope, inScope}
+		Expect(pruneOutOfScopeServices(controller, nil, cbCtx, cbCtx.IngressList)).To(ConsistOf(inScope))
+
+		// An event on the ingress and on AGIC for each of the 2 ingresses removed.
+		Expect(recorder.Events).To(HaveLen(4))
+		event := <-recorder.Events
+		Expect(event).To(HavePrefix("Warning " + string(events.ReasonServiceOutOfScope)))
+		Expect(event).To(ContainSubstring(`backend service "out-of-scope"`))
+		Expect(event).To(ContainSubstring(`service selector "app-gateway=true"`))
+	})
+
+	It("keeps all ingresses without a service selector", func() {
+		cbCtx.EnvVariables.ServiceSelector = ""
+		cbCtx.IngressList = []*v1beta1.Ingress{newIngress("rule-out-of-scope", outOfScope, "")}
+		Expect(pruneOutOfScopeServices(controller, nil, cbCtx, cbCtx.IngressList)).To(Equal(cbCtx.IngressList))
+	})
+})
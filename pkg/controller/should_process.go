@@ -33,5 +33,11 @@ func (c AppGwIngressController) ShouldProcess(event events.Event) (bool, *string
 		return c.k8sContext.IsEndpointReferencedByAnyIngress(endpoints), to.StringPtr(reason)
 	}
 
+	if service, ok := event.Value.(*v1.Service); ok {
+		// a change to this service does not change any backend of the App Gateway
+		reason := fmt.Sprintf("service %s/%s is not used by any Ingress", service.Namespace, service.Name)
+		return c.k8sContext.IsServiceReferencedByAnyIngress(service), to.StringPtr(reason)
+	}
+
 	return true, nil
 }
@@ -0,0 +1,143 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/golang/glog"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/appgw"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/azure"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tracing"
+)
+
+const (
+	// stagingProbeAttempts is how many times the probe URL is requested before the staging App Gateway fails.
+	stagingProbeAttempts = 3
+
+	// stagingProbeTimeout bounds each request of the probe URL.
+	stagingProbeTimeout = 10 * time.Second
+)
+
+// stagingGateway is an App Gateway each changed config is applied to, and checked on, before the App Gateway of AGIC.
+type stagingGateway struct {
+	azClient   azure.AzClient
+	identifier appgw.Identifier
+
+	// probeURL is served by the staging App Gateway; the config passes once it answers with a 2xx or 3xx status code.
+	probeURL      string
+	httpClient    *http.Client
+	probeInterval time.Duration
+}
+
+// SetStagingGateway makes the controller apply each changed config to the staging App Gateway first, and to its own
+// App Gateway only once the update of the staging App Gateway, and the request of the probe URL when set, succeed.
+func (c *AppGwIngressController) SetStagingGateway(azClient azure.AzClient, identifier appgw.Identifier, probeURL string) {
+	c.staging = &stagingGateway{
+		azClient:   azClient,
+		identifier: identifier,
+		probeURL:   probeURL,
+		httpClient: &http.Client{
+			Timeout: stagingProbeTimeout,
+			// A redirect is an answer of the staging App Gateway; its target need not be reachable.
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		probeInterval: 5 * time.Second,
+	}
+}
+
+// applyToStaging builds the config of the Kubernetes resources on top of the config of the staging App Gateway,
+// applies it and probes it. An error means the config must not be rolled forward.
+func (c AppGwIngressController) applyToStaging(cbCtx *appgw.ConfigBuilderContext, span *tracing.Span) error {
+	stagingSpan := span.StartChild("staging")
+	defer stagingSpan.End()
+	stagingSpan.SetAttribute(azure.AttributeAppGwName, c.staging.identifier.AppGwName)
+
+	err := c.stageConfig(cbCtx, c.staging.azClient.WithContext(tracing.ContextWithSpan(context.Background(), stagingSpan)))
+	if err != nil {
+		stagingSpan.SetError(err)
+		errorLine := fmt.Sprintf("Config is not applied to App Gateway %s, as it failed on staging App Gateway %s: %s", c.appGwIdentifier.AppGwName, c.staging.identifier.AppGwName, err)
+		glog.Error(errorLine)
+		if c.agicPod != nil {
+			events.Warning(c.recorder, c.agicPod, events.ReasonStagingFailed, errorLine)
+		}
+		return ErrStagingFailed
+	}
+	glog.V(3).Infof("Config passed on staging App Gateway %s", c.staging.identifier.AppGwName)
+	return nil
+}
+
+func (c AppGwIngressController) stageConfig(cbCtx *appgw.ConfigBuilderContext, azClient azure.AzClient) error {
+	stagingAppGw, err := azClient.GetGateway()
+	c.metricStore.IncArmAPICallCounter()
+	if err != nil {
+		return err
+	}
+
+	// The staging App Gateway gets the config of the same Kubernetes resources, with the IDs of its own sub-resources.
+	// The pool drains, the autoscale schedule and the metrics belong to the App Gateway of AGIC, and are left out.
+	stagingCtx := *cbCtx
+	stagingCtx.DefaultAddressPoolID = to.StringPtr(c.staging.identifier.AddressPoolID(appgw.DefaultBackendAddressPoolName))
+	stagingCtx.DefaultHTTPSettingsID = to.StringPtr(c.staging.identifier.HTTPSettingsID(appgw.DefaultBackendHTTPSettingsName))
+	stagingCtx.ExistingPortsByNumber = make(map[appgw.Port]n.ApplicationGatewayFrontendPort)
+	if stagingAppGw.FrontendPorts != nil {
+		for _, port := range *stagingAppGw.FrontendPorts {
+			stagingCtx.ExistingPortsByNumber[appgw.Port(*port.Port)] = port
+		}
+	}
+	stagingCtx.PoolDrains = nil
+	stagingCtx.PoolRemovals = nil
+	stagingCtx.AutoscaleSchedule = nil
+	stagingCtx.ProbeWarnings = nil
+	stagingCtx.MetricStore = nil
+
+	// The events of the build were emitted by the build for the App Gateway of AGIC already.
+	configBuilder := appgw.NewConfigBuilder(c.k8sContext, &c.staging.identifier, &stagingAppGw, &record.FakeRecorder{}, realClock{})
+	generatedAppGw, err := configBuilder.Build(&stagingCtx)
+	if err != nil {
+		return err
+	}
+
+	// The update counters are of the App Gateway of AGIC; the update of the staging App Gateway is not counted in them.
+	if err = azClient.UpdateGateway(generatedAppGw); err != nil {
+		return err
+	}
+
+	if c.staging.probeURL == "" {
+		return nil
+	}
+	return c.staging.probe()
+}
+
+// probe requests the probe URL until the staging App Gateway answers it with a 2xx or 3xx status code.
+func (s *stagingGateway) probe() error {
+	var err error
+	for attempt := 1; attempt <= stagingProbeAttempts; attempt++ {
+		if attempt > 1 {
+			time.Sleep(s.probeInterval)
+		}
+		var response *http.Response
+		if response, err = s.httpClient.Get(s.probeURL); err != nil {
+			glog.V(3).Infof("Probe %d of %s failed: %s", attempt, s.probeURL, err)
+			continue
+		}
+		response.Body.Close()
+		if response.StatusCode >= 200 && response.StatusCode < 400 {
+			return nil
+		}
+		err = fmt.Errorf("probe %s returned status code %d", s.probeURL, response.StatusCode)
+		glog.V(3).Infof("Probe %d of %s failed: %s", attempt, s.probeURL, err)
+	}
+	return err
+}
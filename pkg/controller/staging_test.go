@@ -0,0 +1,135 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	testclient "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/appgw"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/azure"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/agic_crd_client/clientset/versioned/fake"
+	istioFake "github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/istio_crd_client/clientset/versioned/fake"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/k8scontext"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/metricstore"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
+)
+
+// invalidSecretMetricStore counts the TLS secrets reported invalid to the metric store.
+type invalidSecretMetricStore struct {
+	metricstore.MetricStore
+	invalidSecrets int
+}
+
+func (ms *invalidSecretMetricStore) IncInvalidTLSSecretCounter(reason string) {
+	ms.invalidSecrets++
+}
+
+var _ = Describe("staging App Gateway", func() {
+	var controller *AppGwIngressController
+	var recorder *record.FakeRecorder
+	var stagingAzClient *azure.FakeAzClient
+	var updated, staged []*n.ApplicationGateway
+	var probeStatus int
+	var probe *httptest.Server
+	var k8sContext *k8scontext.Context
+	var metricStore *invalidSecretMetricStore
+
+	getGateway := func() (n.ApplicationGateway, error) {
+		config := appgw.NewAppGwyConfigFixture()
+		config.FrontendPorts = &[]n.ApplicationGatewayFrontendPort{}
+		return n.ApplicationGateway{ApplicationGatewayPropertiesFormat: config}, nil
+	}
+
+	BeforeEach(func() {
+		updated, staged = nil, nil
+		azClient := azure.NewFakeAzClient()
+		azClient.GetGatewayFunc = getGateway
+		azClient.UpdateGatewayFunc = func(appGw *n.ApplicationGateway) error {
+			updated = append(updated, appGw)
+			return nil
+		}
+
+		stagingAzClient = azure.NewFakeAzClient()
+		stagingAzClient.GetGatewayFunc = getGateway
+		stagingAzClient.UpdateGatewayFunc = func(appGw *n.ApplicationGateway) error {
+			staged = append(staged, appGw)
+			return nil
+		}
+
+		probeStatus = http.StatusOK
+		probe = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(probeStatus)
+		}))
+
+		recorder = record.NewFakeRecorder(100)
+		k8sContext = k8scontext.NewContext(testclient.NewSimpleClientset(), fake.NewSimpleClientset(), istioFake.NewSimpleClientset(), nil, time.Second, k8scontext.DefaultWorkQueueDepth, metricstore.NewFakeMetricStore())
+		identifier := appgw.Identifier{SubscriptionID: tests.Subscription, ResourceGroup: tests.ResourceGroup, AppGwName: tests.AppGwName}
+		metricStore = &invalidSecretMetricStore{MetricStore: metricstore.NewFakeMetricStore()}
+		controller = NewAppGwIngressController(azClient, identifier, k8sContext, recorder, metricStore, &v1.Pod{})
+
+		stagingIdentifier := appgw.Identifier{SubscriptionID: tests.Subscription, ResourceGroup: tests.ResourceGroup, AppGwName: "staging"}
+		controller.SetStagingGateway(stagingAzClient, stagingIdentifier, probe.URL)
+		controller.staging.probeInterval = 0
+	})
+
+	AfterEach(func() {
+		probe.Close()
+	})
+
+	It("applies the config to the App Gateway once it passed on the staging App Gateway", func() {
+		Expect(controller.MutateAppGateway()).To(Succeed())
+		Expect(staged).To(HaveLen(1))
+		Expect(updated).To(HaveLen(1))
+
+		// The config of the staging App Gateway refers to its own sub-resources.
+		Expect(*(*staged[0].RequestRoutingRules)[0].BackendAddressPool.ID).To(ContainSubstring("/applicationGateways/staging/"))
+		Expect(*(*updated[0].RequestRoutingRules)[0].BackendAddressPool.ID).To(ContainSubstring("/applicationGateways/" + tests.AppGwName + "/"))
+	})
+
+	It("does not apply the config to the App Gateway when the update of the staging App Gateway fails", func() {
+		stagingAzClient.UpdateGatewayFunc = func(*n.ApplicationGateway) error {
+			return errors.New("conflict")
+		}
+		Expect(controller.MutateAppGateway()).To(Equal(ErrStagingFailed))
+		Expect(updated).To(BeEmpty())
+		Expect(recorder.Events).To(Receive(ContainSubstring("StagingFailed")))
+	})
+
+	It("does not apply the config to the App Gateway when the staging App Gateway fails the probe", func() {
+		probeStatus = http.StatusBadGateway
+		Expect(controller.MutateAppGateway()).To(Equal(ErrStagingFailed))
+		Expect(staged).To(HaveLen(1))
+		Expect(updated).To(BeEmpty())
+		Expect(recorder.Events).To(Receive(ContainSubstring("status code 502")))
+
+		// The config is tried again on the next reconcile.
+		probeStatus = http.StatusFound
+		Expect(controller.MutateAppGateway()).To(Succeed())
+		Expect(staged).To(HaveLen(2))
+		Expect(updated).To(HaveLen(1))
+	})
+
+	It("counts the metrics of the build once, for the App Gateway of AGIC", func() {
+		// The TLS secrets of the two TLS sections of the ingress are missing.
+		Expect(k8sContext.Caches.Ingress.Add(tests.NewIngressFixture())).To(Succeed())
+		Expect(k8sContext.Caches.Service.Add(tests.NewServiceFixture(*tests.NewServicePortsFixture()...))).To(Succeed())
+		Expect(k8sContext.Caches.Endpoints.Add(tests.NewEndpointsFixture())).To(Succeed())
+
+		Expect(controller.MutateAppGateway()).To(Succeed())
+		Expect(staged).To(HaveLen(1))
+		Expect(metricStore.invalidSecrets).To(Equal(2))
+	})
+})
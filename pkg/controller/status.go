@@ -0,0 +1,53 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/health"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/version"
+)
+
+// syncStatus tracks the last time the App Gateway was found to be, or was brought, in sync with the cluster.
+type syncStatus struct {
+	sync.RWMutex
+	lastSuccessfulSync time.Time
+}
+
+func (s *syncStatus) setLastSuccessfulSync(t time.Time) {
+	s.Lock()
+	defer s.Unlock()
+	s.lastSuccessfulSync = t
+}
+
+func (s *syncStatus) getLastSuccessfulSync() *time.Time {
+	s.RLock()
+	defer s.RUnlock()
+	if s.lastSuccessfulSync.IsZero() {
+		return nil
+	}
+	t := s.lastSuccessfulSync
+	return &t
+}
+
+// Status fulfills the health.StatusReporter interface; It is evaluated when the status endpoint is requested.
+func (c *AppGwIngressController) Status() health.Status {
+	return health.Status{
+		Version:            version.Version,
+		GitCommit:          version.GitCommit,
+		BuildDate:          version.BuildDate,
+		SubscriptionID:     c.appGwIdentifier.SubscriptionID,
+		ResourceGroup:      c.appGwIdentifier.ResourceGroup,
+		AppGwName:          c.appGwIdentifier.AppGwName,
+		LastSuccessfulSync: c.syncStatus.getLastSuccessfulSync(),
+		Live:               c.Liveness(),
+		Ready:              c.Readiness(),
+		// AGIC does not run leader election; every running instance reconciles its App Gateway.
+		Leader: true,
+	}
+}
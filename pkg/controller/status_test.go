@@ -0,0 +1,80 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/appgw"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/azure"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/health"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/k8scontext"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/metricstore"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/version"
+)
+
+var _ = Describe("test status endpoint", func() {
+	var controller *AppGwIngressController
+	var cacheSynced chan interface{}
+
+	BeforeEach(func() {
+		version.Version = "1.2.3"
+		cacheSynced = make(chan interface{})
+		appGwIdentifier := appgw.Identifier{
+			SubscriptionID: "subscription",
+			ResourceGroup:  "resource-group",
+			AppGwName:      "gateway",
+		}
+		k8sContext := &k8scontext.Context{CacheSynced: cacheSynced}
+		controller = NewAppGwIngressController(azure.NewFakeAzClient(), appGwIdentifier, k8sContext, record.NewFakeRecorder(0), metricstore.NewFakeMetricStore(), nil)
+	})
+
+	Context("ensure Status reports the state of the controller", func() {
+		It("should report the gateway and an unsynced, unready controller", func() {
+			status := controller.Status()
+			Expect(status.Version).To(Equal("1.2.3"))
+			Expect(status.SubscriptionID).To(Equal("subscription"))
+			Expect(status.ResourceGroup).To(Equal("resource-group"))
+			Expect(status.AppGwName).To(Equal("gateway"))
+			Expect(status.LastSuccessfulSync).To(BeNil())
+			Expect(status.Live).To(BeTrue())
+			Expect(status.Ready).To(BeFalse())
+			Expect(status.Leader).To(BeTrue())
+		})
+
+		It("should report the last successful sync and readiness once the cache synced", func() {
+			syncTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+			controller.syncStatus.setLastSuccessfulSync(syncTime)
+			close(cacheSynced)
+
+			status := controller.Status()
+			Expect(*status.LastSuccessfulSync).To(Equal(syncTime))
+			Expect(status.Ready).To(BeTrue())
+		})
+	})
+
+	Context("ensure the status handler serves JSON", func() {
+		It("should serve the status", func() {
+			recorder := httptest.NewRecorder()
+			health.StatusHandler(controller).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/status", nil))
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			Expect(recorder.Header().Get("Content-Type")).To(Equal("application/json"))
+
+			var status map[string]interface{}
+			Expect(json.Unmarshal(recorder.Body.Bytes(), &status)).ToNot(HaveOccurred())
+			Expect(status).To(HaveKeyWithValue("applicationGatewayName", "gateway"))
+			Expect(status).To(HaveKeyWithValue("version", "1.2.3"))
+			Expect(status).ToNot(HaveKey("lastSuccessfulSync"))
+		})
+	})
+})
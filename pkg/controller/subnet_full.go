@@ -0,0 +1,42 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"fmt"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/golang/glog"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/azure"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+)
+
+// subnetFullMessage explains an update of the App Gateway refused by ARM, because its subnet has no private IP
+// addresses left, in place of the ARM error.
+func subnetFullMessage(appGw *n.ApplicationGateway, err error) string {
+	subnet := "of App Gateway"
+	if appGw.ApplicationGatewayPropertiesFormat != nil && appGw.GatewayIPConfigurations != nil {
+		for _, ipConf := range *appGw.GatewayIPConfigurations {
+			if ipConf.ApplicationGatewayIPConfigurationPropertiesFormat != nil && ipConf.Subnet != nil && ipConf.Subnet.ID != nil {
+				subnet = *ipConf.Subnet.ID
+				break
+			}
+		}
+	}
+	return fmt.Sprintf("App Gateway config was not applied: %s. Add address space to the subnet %s, or move App Gateway to a larger subnet; App Gateway keeps the last config applied. ARM error: %s", azure.ErrGatewaySubnetFull, subnet, err)
+}
+
+// reportSubnetFull reports an update of the App Gateway refused by ARM, because its subnet has no private IP addresses
+// left for the frontend IP configurations and the instances of the gateway.
+func (c *AppGwIngressController) reportSubnetFull(appGw *n.ApplicationGateway, err error) {
+	message := subnetFullMessage(appGw, err)
+	glog.Error(message)
+	if c.agicPod != nil {
+		events.Warning(c.recorder, c.agicPod, events.ReasonSubnetFull, message)
+	}
+	c.metricStore.IncSubnetFullCounter()
+}
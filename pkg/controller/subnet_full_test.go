@@ -0,0 +1,73 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"time"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/Azure/go-autorest/autorest"
+	goazure "github.com/Azure/go-autorest/autorest/azure"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	testclient "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/appgw"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/azure"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/agic_crd_client/clientset/versioned/fake"
+	istioFake "github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/istio_crd_client/clientset/versioned/fake"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/k8scontext"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/metricstore"
+)
+
+var _ = Describe("subnet without private IP addresses left", func() {
+	subnetID := "/subscriptions/xxxx/resourceGroups/yyyy/providers/Microsoft.Network/virtualNetworks/vnet/subnets/appgw-subnet"
+	appGw := &n.ApplicationGateway{
+		ApplicationGatewayPropertiesFormat: &n.ApplicationGatewayPropertiesFormat{
+			GatewayIPConfigurations: &[]n.ApplicationGatewayIPConfiguration{
+				{
+					ApplicationGatewayIPConfigurationPropertiesFormat: &n.ApplicationGatewayIPConfigurationPropertiesFormat{
+						Subnet: &n.SubResource{ID: to.StringPtr(subnetID)},
+					},
+				},
+			},
+		},
+	}
+	armErr := autorest.DetailedError{
+		Original: &goazure.RequestError{
+			ServiceError: &goazure.ServiceError{
+				Code:    "SubnetIsFull",
+				Message: "Subnet appgw-subnet with address prefix 10.0.0.0/29 does not have enough capacity for 1 IP addresses.",
+			},
+		},
+		StatusCode: 400,
+	}
+
+	It("explains that the subnet needs more address space", func() {
+		Expect(azure.IsSubnetFull(armErr)).To(BeTrue())
+
+		message := subnetFullMessage(appGw, armErr)
+		Expect(message).To(HavePrefix("App Gateway config was not applied: application gateway subnet has no private IP addresses left; the subnet needs more address space (AZUR012)."))
+		Expect(message).To(ContainSubstring("Add address space to the subnet " + subnetID))
+		Expect(message).To(ContainSubstring("App Gateway keeps the last config applied"))
+		Expect(message).To(ContainSubstring("SubnetIsFull"))
+	})
+
+	It("emits a warning event on the AGIC pod", func() {
+		recorder := record.NewFakeRecorder(10)
+		k8sContext := k8scontext.NewContext(testclient.NewSimpleClientset(), fake.NewSimpleClientset(), istioFake.NewSimpleClientset(), nil, time.Second, k8scontext.DefaultWorkQueueDepth, metricstore.NewFakeMetricStore())
+		controller := NewAppGwIngressController(azure.NewFakeAzClient(), appgw.Identifier{}, k8sContext, recorder, metricstore.NewFakeMetricStore(), &v1.Pod{})
+
+		controller.reportSubnetFull(appGw, armErr)
+		Expect(recorder.Events).To(Receive(And(
+			HavePrefix("Warning SubnetFull"),
+			ContainSubstring(subnetID),
+		)))
+	})
+})
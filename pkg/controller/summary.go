@@ -0,0 +1,111 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"encoding/json"
+	"time"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/golang/glog"
+	"k8s.io/api/extensions/v1beta1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+)
+
+// SummaryKey is the key of the summary ConfigMap, which holds the JSON summary of the last App Gateway config.
+const SummaryKey = "summary.json"
+
+// summaryChange counts the added, removed and modified sub-resources of one type, such as httpListeners.
+type summaryChange struct {
+	Kind     string `json:"kind"`
+	Added    int    `json:"added"`
+	Removed  int    `json:"removed"`
+	Modified int    `json:"modified"`
+}
+
+// appliedSummary is written to the summary ConfigMap each time AGIC applies, or fails to apply, an App Gateway config.
+type appliedSummary struct {
+	AppGateway string          `json:"appGateway"`
+	Timestamp  string          `json:"timestamp"`
+	Succeeded  bool            `json:"succeeded"`
+	Error      string          `json:"error,omitempty"`
+	Ingresses  []string        `json:"ingresses"`
+	Objects    map[string]int  `json:"objects"`
+	Changes    []summaryChange `json:"changes"`
+}
+
+// getSummaryConfigMapNamespace returns the namespace of the summary ConfigMap: the one set with
+// APPGW_SUMMARY_CONFIGMAP_NAMESPACE, or else the namespace of AGIC.
+func getSummaryConfigMapNamespace(env environment.EnvVariables) string {
+	if env.SummaryConfigMapNamespace != "" {
+		return env.SummaryConfigMapNamespace
+	}
+	return env.AGICPodNamespace
+}
+
+// writeAppliedSummary writes the summary of the App Gateway config AGIC applied, or failed to apply with applyErr, to
+// the ConfigMap named by APPGW_SUMMARY_CONFIGMAP: the number of sub-resources of each type, the changes to the existing
+// config and the ingresses changed since the last config applied. Failing to write the summary does not fail the reconcile.
+func (c AppGwIngressController) writeAppliedSummary(env environment.EnvVariables, existingJSON []byte, appGw *n.ApplicationGateway, ingressList []*v1beta1.Ingress, applyErr error, now time.Time) {
+	// A config, which failed to apply, does not account for the changed ingresses; the next one applied does.
+	versions := c.summaryIngressVersions
+	if applyErr != nil {
+		versions = make(ingressVersions)
+		for key, version := range c.summaryIngressVersions {
+			versions[key] = version
+		}
+	}
+
+	summary := appliedSummary{
+		AppGateway: c.appGwIdentifier.AppGwName,
+		Timestamp:  now.UTC().Format(time.RFC3339),
+		Succeeded:  applyErr == nil,
+		Ingresses:  versions.update(ingressList),
+		Objects:    make(map[string]int),
+		Changes:    []summaryChange{},
+	}
+	if applyErr != nil {
+		summary.Error = applyErr.Error()
+	}
+	if summary.Ingresses == nil {
+		summary.Ingresses = []string{}
+	}
+
+	appGwJSON, err := appGw.MarshalJSON()
+	if err != nil {
+		glog.Error("[summary] Could not marshal the App Gateway config: ", err)
+		return
+	}
+	subResources, err := getAuditSubResources(appGwJSON)
+	if err != nil {
+		glog.Error("[summary] Could not read the App Gateway config: ", err)
+		return
+	}
+	for kind, objects := range subResources {
+		summary.Objects[kind] = len(objects)
+	}
+	changes, err := getAuditChanges(existingJSON, appGwJSON)
+	if err != nil {
+		glog.Error("[summary] Could not compare the existing and the applied App Gateway config: ", err)
+		return
+	}
+	for _, change := range changes {
+		summary.Changes = append(summary.Changes, summaryChange{
+			Kind:     change.Kind,
+			Added:    len(change.Added),
+			Removed:  len(change.Removed),
+			Modified: len(change.Modified),
+		})
+	}
+
+	summaryJSON, _ := json.Marshal(summary)
+	namespace := getSummaryConfigMapNamespace(env)
+	if err := c.k8sContext.WriteConfigMapData(namespace, env.SummaryConfigMap, map[string]string{SummaryKey: string(summaryJSON)}); err != nil {
+		return
+	}
+	glog.V(3).Infof("[summary] Wrote the summary of the App Gateway config to ConfigMap %s/%s", namespace, env.SummaryConfigMap)
+}
@@ -0,0 +1,137 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	testclient "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/appgw"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/agic_crd_client/clientset/versioned/fake"
+	istioFake "github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/istio_crd_client/clientset/versioned/fake"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/k8scontext"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/metricstore"
+)
+
+var _ = Describe("summary ConfigMap of applied App Gateway configs", func() {
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	newIngress := func(name, resourceVersion string) *v1beta1.Ingress {
+		return &v1beta1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:       "default",
+				Name:            name,
+				ResourceVersion: resourceVersion,
+			},
+		}
+	}
+	newListener := func(name string) n.ApplicationGatewayHTTPListener {
+		return n.ApplicationGatewayHTTPListener{
+			Name: to.StringPtr(name),
+			ApplicationGatewayHTTPListenerPropertiesFormat: &n.ApplicationGatewayHTTPListenerPropertiesFormat{
+				Protocol: n.HTTP,
+			},
+		}
+	}
+
+	var k8sClient *testclient.Clientset
+	var controller AppGwIngressController
+	var env environment.EnvVariables
+	var existingJSON []byte
+	var applied n.ApplicationGateway
+
+	readSummary := func(namespace string) appliedSummary {
+		configMap, err := k8sClient.CoreV1().ConfigMaps(namespace).Get("agic-summary", metav1.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		var summary appliedSummary
+		Expect(json.Unmarshal([]byte(configMap.Data[SummaryKey]), &summary)).To(Succeed())
+		return summary
+	}
+
+	BeforeEach(func() {
+		k8sClient = testclient.NewSimpleClientset()
+		k8sContext := k8scontext.NewContext(k8sClient, fake.NewSimpleClientset(), istioFake.NewSimpleClientset(), nil, time.Second, k8scontext.DefaultWorkQueueDepth, metricstore.NewFakeMetricStore())
+		controller = AppGwIngressController{
+			appGwIdentifier:        appgw.Identifier{AppGwName: "--app-gw--"},
+			k8sContext:             k8sContext,
+			summaryIngressVersions: make(ingressVersions),
+		}
+		env = environment.GetFakeEnv()
+		env.AGICPodNamespace = "agic"
+		env.SummaryConfigMap = "agic-summary"
+
+		existing := n.ApplicationGateway{
+			ApplicationGatewayPropertiesFormat: &n.ApplicationGatewayPropertiesFormat{
+				HTTPListeners: &[]n.ApplicationGatewayHTTPListener{newListener("fl-web"), newListener("fl-old")},
+			},
+		}
+		existingJSON, _ = existing.MarshalJSON()
+		applied = n.ApplicationGateway{
+			ApplicationGatewayPropertiesFormat: &n.ApplicationGatewayPropertiesFormat{
+				HTTPListeners: &[]n.ApplicationGatewayHTTPListener{newListener("fl-web"), newListener("fl-new"), newListener("fl-api")},
+				BackendAddressPools: &[]n.ApplicationGatewayBackendAddressPool{
+					{Name: to.StringPtr("pool-web"), ApplicationGatewayBackendAddressPoolPropertiesFormat: &n.ApplicationGatewayBackendAddressPoolPropertiesFormat{}},
+				},
+			},
+		}
+	})
+
+	It("creates the ConfigMap with the summary of the applied config", func() {
+		controller.writeAppliedSummary(env, existingJSON, &applied, []*v1beta1.Ingress{newIngress("web", "1")}, nil, now)
+		Expect(readSummary("agic")).To(Equal(appliedSummary{
+			AppGateway: "--app-gw--",
+			Timestamp:  "2020-01-01T12:00:00Z",
+			Succeeded:  true,
+			Ingresses:  []string{"default/web"},
+			Objects:    map[string]int{"httpListeners": 3, "backendAddressPools": 1},
+			Changes: []summaryChange{
+				{Kind: "backendAddressPools", Added: 1},
+				{Kind: "httpListeners", Added: 2, Removed: 1},
+			},
+		}))
+	})
+
+	It("updates the ConfigMap with the ingresses changed since the last applied config", func() {
+		controller.writeAppliedSummary(env, existingJSON, &applied, []*v1beta1.Ingress{newIngress("web", "1")}, nil, now)
+		appliedJSON, _ := applied.MarshalJSON()
+		controller.writeAppliedSummary(env, appliedJSON, &applied, []*v1beta1.Ingress{newIngress("web", "1"), newIngress("api", "1")}, nil, now.Add(time.Minute))
+
+		summary := readSummary("agic")
+		Expect(summary.Timestamp).To(Equal("2020-01-01T12:01:00Z"))
+		Expect(summary.Ingresses).To(Equal([]string{"default/api"}))
+		Expect(summary.Changes).To(BeEmpty())
+	})
+
+	It("reports a failed update, and attributes the changed ingresses to the next applied config", func() {
+		ingresses := []*v1beta1.Ingress{newIngress("web", "1")}
+		controller.writeAppliedSummary(env, existingJSON, &applied, ingresses, errors.New("ARM refused the update"), now)
+		summary := readSummary("agic")
+		Expect(summary.Succeeded).To(BeFalse())
+		Expect(summary.Error).To(Equal("ARM refused the update"))
+		Expect(summary.Ingresses).To(Equal([]string{"default/web"}))
+
+		controller.writeAppliedSummary(env, existingJSON, &applied, ingresses, nil, now)
+		summary = readSummary("agic")
+		Expect(summary.Succeeded).To(BeTrue())
+		Expect(summary.Error).To(BeEmpty())
+		Expect(summary.Ingresses).To(Equal([]string{"default/web"}))
+	})
+
+	It("writes the ConfigMap in the configured namespace", func() {
+		env.SummaryConfigMapNamespace = "monitoring"
+		controller.writeAppliedSummary(env, existingJSON, &applied, nil, nil, now)
+		Expect(readSummary("monitoring").Ingresses).To(BeEmpty())
+	})
+})
@@ -0,0 +1,80 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	"errors"
+	"time"
+
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	testclient "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/appgw"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/azure"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/agic_crd_client/clientset/versioned/fake"
+	istioFake "github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/istio_crd_client/clientset/versioned/fake"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/k8scontext"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/metricstore"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tracing"
+)
+
+var _ = Describe("tracing of the reconciles", func() {
+	var controller *AppGwIngressController
+	var azClient *azure.FakeAzClient
+	var exporter *tracing.FakeExporter
+
+	BeforeEach(func() {
+		azClient = azure.NewFakeAzClient()
+		azClient.GetGatewayFunc = func() (n.ApplicationGateway, error) {
+			config := appgw.NewAppGwyConfigFixture()
+			config.FrontendPorts = &[]n.ApplicationGatewayFrontendPort{}
+			return n.ApplicationGateway{ApplicationGatewayPropertiesFormat: config}, nil
+		}
+		k8sContext := k8scontext.NewContext(testclient.NewSimpleClientset(), fake.NewSimpleClientset(), istioFake.NewSimpleClientset(), nil, time.Second, k8scontext.DefaultWorkQueueDepth, metricstore.NewFakeMetricStore())
+		identifier := appgw.Identifier{SubscriptionID: tests.Subscription, ResourceGroup: tests.ResourceGroup, AppGwName: tests.AppGwName}
+		controller = NewAppGwIngressController(azClient, identifier, k8sContext, record.NewFakeRecorder(100), metricstore.NewFakeMetricStore(), &v1.Pod{})
+
+		exporter = tracing.NewFakeExporter()
+		controller.tracer = tracing.NewTracerWithExporter(exporter)
+	})
+
+	It("records a span per reconcile with a child span for the config build", func() {
+		Expect(controller.MutateAppGateway()).To(Succeed())
+
+		Eventually(exporter.Spans).Should(HaveLen(2))
+		reconcile := exporter.Span("reconcile")
+		Expect(reconcile.ParentSpanID()).To(BeEmpty())
+		Expect(reconcile.Attributes()).To(HaveKeyWithValue(azure.AttributeAppGwName, tests.AppGwName))
+		Expect(reconcile.Attributes()).To(HaveKeyWithValue("k8s.ingress.count", 0))
+		Expect(reconcile.Attributes()).To(HaveKeyWithValue("appgw.config_changed", true))
+		Expect(reconcile.Err()).ToNot(HaveOccurred())
+
+		build := exporter.Span("build")
+		Expect(build.TraceID()).To(Equal(reconcile.TraceID()))
+		Expect(build.ParentSpanID()).To(Equal(reconcile.SpanID()))
+	})
+
+	It("marks the span of a failed reconcile", func() {
+		azClient.UpdateGatewayFunc = func(*n.ApplicationGateway) error {
+			return errors.New("conflict")
+		}
+		Expect(controller.MutateAppGateway()).ToNot(Succeed())
+
+		Eventually(exporter.Spans).Should(HaveLen(2))
+		Expect(exporter.Span("reconcile").Err()).To(MatchError("conflict"))
+	})
+
+	It("records nothing without a tracer", func() {
+		controller.tracer = nil
+		Expect(controller.MutateAppGateway()).To(Succeed())
+		Consistently(exporter.Spans).Should(BeEmpty())
+	})
+})
@@ -0,0 +1,57 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/golang/glog"
+)
+
+// noZone is the zone reported for pool members on nodes without a zone label.
+const noZone = "none"
+
+// getZoneDistribution counts the members of each backend pool in each availability zone.
+// Members, which are not endpoints in the cluster, such as the FQDNs of ExternalName services, are not counted.
+func getZoneDistribution(pools []n.ApplicationGatewayBackendAddressPool, zonesByAddress map[string]string) map[string]map[string]int {
+	distribution := make(map[string]map[string]int)
+	for _, pool := range pools {
+		if pool.Name == nil || pool.ApplicationGatewayBackendAddressPoolPropertiesFormat == nil || pool.BackendAddresses == nil {
+			continue
+		}
+		for _, address := range *pool.BackendAddresses {
+			if address.IPAddress == nil {
+				continue
+			}
+			zone, isEndpoint := zonesByAddress[*address.IPAddress]
+			if !isEndpoint {
+				continue
+			}
+			if zone == "" {
+				zone = noZone
+			}
+			if _, ok := distribution[*pool.Name]; !ok {
+				distribution[*pool.Name] = make(map[string]int)
+			}
+			distribution[*pool.Name][zone]++
+		}
+	}
+	return distribution
+}
+
+// updateZoneMetrics reports the availability zones of the members of the backend pools of the App Gateway.
+// App Gateway balances requests across all members of a pool; it can not be told to prefer members in its own zone.
+func (c AppGwIngressController) updateZoneMetrics(appGw *n.ApplicationGateway) {
+	if appGw == nil || appGw.ApplicationGatewayPropertiesFormat == nil || appGw.BackendAddressPools == nil {
+		return
+	}
+	distribution := getZoneDistribution(*appGw.BackendAddressPools, c.k8sContext.GetZonesByAddress())
+	for pool, endpointsByZone := range distribution {
+		if _, ok := endpointsByZone[noZone]; ok {
+			glog.V(5).Infof("Backend pool %s has members on nodes without the topology.kubernetes.io/zone label", pool)
+		}
+	}
+	c.metricStore.SetBackendPoolZoneEndpoints(distribution)
+}
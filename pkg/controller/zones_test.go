@@ -0,0 +1,48 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package controller
+
+import (
+	n "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2019-09-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("zone distribution of backend pools", func() {
+	newPool := func(name string, addresses ...n.ApplicationGatewayBackendAddress) n.ApplicationGatewayBackendAddressPool {
+		return n.ApplicationGatewayBackendAddressPool{
+			Name: to.StringPtr(name),
+			ApplicationGatewayBackendAddressPoolPropertiesFormat: &n.ApplicationGatewayBackendAddressPoolPropertiesFormat{
+				BackendAddresses: &addresses,
+			},
+		}
+	}
+	ip := func(address string) n.ApplicationGatewayBackendAddress {
+		return n.ApplicationGatewayBackendAddress{IPAddress: to.StringPtr(address)}
+	}
+
+	It("counts the members of each pool in each zone", func() {
+		zonesByAddress := map[string]string{
+			"10.0.0.1": "westeurope-1",
+			"10.0.0.2": "westeurope-1",
+			"10.0.0.3": "westeurope-2",
+			"10.0.0.4": "",
+		}
+		pools := []n.ApplicationGatewayBackendAddressPool{
+			newPool("pool-a", ip("10.0.0.1"), ip("10.0.0.2"), ip("10.0.0.3")),
+			newPool("pool-b", ip("10.0.0.3"), ip("10.0.0.4")),
+			newPool("external", n.ApplicationGatewayBackendAddress{Fqdn: to.StringPtr("www.contoso.com")}, ip("192.168.1.1")),
+			newPool("empty"),
+			{Name: to.StringPtr("no-properties")},
+		}
+
+		Expect(getZoneDistribution(pools, zonesByAddress)).To(Equal(map[string]map[string]int{
+			"pool-a": {"westeurope-1": 2, "westeurope-2": 1},
+			"pool-b": {"westeurope-2": 1, noZone: 1},
+		}))
+	})
+})
@@ -19,6 +19,7 @@ limitations under the License.
 package versioned
 
 import (
+	azureapplicationgatewayrewritesv1 "github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/agic_crd_client/clientset/versioned/typed/azureapplicationgatewayrewrite/v1"
 	azureingressprohibitedtargetsv1 "github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/agic_crd_client/clientset/versioned/typed/azureingressprohibitedtarget/v1"
 	discovery "k8s.io/client-go/discovery"
 	rest "k8s.io/client-go/rest"
@@ -27,6 +28,7 @@ import (
 
 type Interface interface {
 	Discovery() discovery.DiscoveryInterface
+	AzureapplicationgatewayrewritesV1() azureapplicationgatewayrewritesv1.AzureapplicationgatewayrewritesV1Interface
 	AzureingressprohibitedtargetsV1() azureingressprohibitedtargetsv1.AzureingressprohibitedtargetsV1Interface
 }
 
@@ -34,7 +36,13 @@ type Interface interface {
 // version included in a Clientset.
 type Clientset struct {
 	*discovery.DiscoveryClient
-	azureingressprohibitedtargetsV1 *azureingressprohibitedtargetsv1.AzureingressprohibitedtargetsV1Client
+	azureapplicationgatewayrewritesV1 *azureapplicationgatewayrewritesv1.AzureapplicationgatewayrewritesV1Client
+	azureingressprohibitedtargetsV1   *azureingressprohibitedtargetsv1.AzureingressprohibitedtargetsV1Client
+}
+
+// AzureapplicationgatewayrewritesV1 retrieves the AzureapplicationgatewayrewritesV1Client
+func (c *Clientset) AzureapplicationgatewayrewritesV1() azureapplicationgatewayrewritesv1.AzureapplicationgatewayrewritesV1Interface {
+	return c.azureapplicationgatewayrewritesV1
 }
 
 // AzureingressprohibitedtargetsV1 retrieves the AzureingressprohibitedtargetsV1Client
@@ -58,6 +66,11 @@ func NewForConfig(c *rest.Config) (*Clientset, error) {
 	}
 	var cs Clientset
 	var err error
+	cs.azureapplicationgatewayrewritesV1, err = azureapplicationgatewayrewritesv1.NewForConfig(&configShallowCopy)
+	if err != nil {
+		return nil, err
+	}
+
 	cs.azureingressprohibitedtargetsV1, err = azureingressprohibitedtargetsv1.NewForConfig(&configShallowCopy)
 	if err != nil {
 		return nil, err
@@ -74,6 +87,7 @@ func NewForConfig(c *rest.Config) (*Clientset, error) {
 // panics if there is an error in the config.
 func NewForConfigOrDie(c *rest.Config) *Clientset {
 	var cs Clientset
+	cs.azureapplicationgatewayrewritesV1 = azureapplicationgatewayrewritesv1.NewForConfigOrDie(c)
 	cs.azureingressprohibitedtargetsV1 = azureingressprohibitedtargetsv1.NewForConfigOrDie(c)
 
 	cs.DiscoveryClient = discovery.NewDiscoveryClientForConfigOrDie(c)
@@ -83,6 +97,7 @@ func NewForConfigOrDie(c *rest.Config) *Clientset {
 // New creates a new Clientset for the given RESTClient.
 func New(c rest.Interface) *Clientset {
 	var cs Clientset
+	cs.azureapplicationgatewayrewritesV1 = azureapplicationgatewayrewritesv1.New(c)
 	cs.azureingressprohibitedtargetsV1 = azureingressprohibitedtargetsv1.New(c)
 
 	cs.DiscoveryClient = discovery.NewDiscoveryClient(c)
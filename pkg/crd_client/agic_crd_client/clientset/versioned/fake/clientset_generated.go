@@ -20,6 +20,8 @@ package fake
 
 import (
 	clientset "github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/agic_crd_client/clientset/versioned"
+	azureapplicationgatewayrewritesv1 "github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/agic_crd_client/clientset/versioned/typed/azureapplicationgatewayrewrite/v1"
+	fakeazureapplicationgatewayrewritesv1 "github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/agic_crd_client/clientset/versioned/typed/azureapplicationgatewayrewrite/v1/fake"
 	azureingressprohibitedtargetsv1 "github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/agic_crd_client/clientset/versioned/typed/azureingressprohibitedtarget/v1"
 	fakeazureingressprohibitedtargetsv1 "github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/agic_crd_client/clientset/versioned/typed/azureingressprohibitedtarget/v1/fake"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -76,6 +78,11 @@ func (c *Clientset) Tracker() testing.ObjectTracker {
 
 var _ clientset.Interface = &Clientset{}
 
+// AzureapplicationgatewayrewritesV1 retrieves the AzureapplicationgatewayrewritesV1Client
+func (c *Clientset) AzureapplicationgatewayrewritesV1() azureapplicationgatewayrewritesv1.AzureapplicationgatewayrewritesV1Interface {
+	return &fakeazureapplicationgatewayrewritesv1.FakeAzureapplicationgatewayrewritesV1{Fake: &c.Fake}
+}
+
 // AzureingressprohibitedtargetsV1 retrieves the AzureingressprohibitedtargetsV1Client
 func (c *Clientset) AzureingressprohibitedtargetsV1() azureingressprohibitedtargetsv1.AzureingressprohibitedtargetsV1Interface {
 	return &fakeazureingressprohibitedtargetsv1.FakeAzureingressprohibitedtargetsV1{Fake: &c.Fake}
@@ -21,6 +21,7 @@ package externalversions
 import (
 	"fmt"
 
+	azureapplicationgatewayrewritev1 "github.com/Azure/application-gateway-kubernetes-ingress/pkg/apis/azureapplicationgatewayrewrite/v1"
 	azureingressprohibitedtargetv1 "github.com/Azure/application-gateway-kubernetes-ingress/pkg/apis/azureingressprohibitedtarget/v1"
 	schema "k8s.io/apimachinery/pkg/runtime/schema"
 	cache "k8s.io/client-go/tools/cache"
@@ -52,6 +53,10 @@ func (f *genericInformer) Lister() cache.GenericLister {
 // TODO extend this to unknown resources with a client pool
 func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource) (GenericInformer, error) {
 	switch resource {
+	// Group=azureapplicationgatewayrewrites.appgw.ingress.k8s.io, Version=v1
+	case azureapplicationgatewayrewritev1.SchemeGroupVersion.WithResource("azureapplicationgatewayrewrites"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Azureapplicationgatewayrewrites().V1().AzureApplicationGatewayRewrites().Informer()}, nil
+
 	// Group=azureingressprohibitedtargets.appgw.ingress.k8s.io, Version=v1
 	case azureingressprohibitedtargetv1.SchemeGroupVersion.WithResource("azureingressprohibitedtargets"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Azureingressprohibitedtargets().V1().AzureIngressProhibitedTargets().Informer()}, nil
@@ -8,8 +8,11 @@ package environment
 import (
 	"os"
 	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 const (
@@ -73,67 +76,449 @@ const (
 	// AGICPodNamespaceVarName is an environment variable name.
 	AGICPodNamespaceVarName = "AGIC_POD_NAMESPACE"
 
+	// AGICDeploymentNameVarName is an environment variable name. It names the Deployment of AGIC, in the namespace of
+	// the pod, which the cleanup scales to 0 replicas before it removes the config of AGIC from the App Gateway.
+	AGICDeploymentNameVarName = "AGIC_DEPLOYMENT_NAME"
+
 	// UseManagedIdentityForPodVarName is an environment variable name.
 	UseManagedIdentityForPodVarName = "USE_MANAGED_IDENTITY_FOR_POD"
 
 	// AttachWAFPolicyToListenerVarName is an environment variable name.
 	AttachWAFPolicyToListenerVarName = "ATTACH_WAF_POLICY_TO_LISTENER"
+
+	// EnableMultiInstanceVarName is a feature flag allowing multiple AGIC instances to share an App Gateway.
+	// Each instance only prunes the objects it owns, which are the objects named with its APPGW_CONFIG_NAME_PREFIX.
+	EnableMultiInstanceVarName = "APPGW_ENABLE_MULTI_INSTANCE"
+
+	// ConfigNamePrefixVarName is an environment variable name. It sets the prefix of the names of the App Gateway
+	// objects AGIC generates.
+	ConfigNamePrefixVarName = "APPGW_CONFIG_NAME_PREFIX"
+
+	// EventQueueDepthVarName is an environment variable name. It sets the maximum number of pending Kubernetes events.
+	EventQueueDepthVarName = "APPGW_EVENT_QUEUE_DEPTH"
+
+	// UpdatePollIntervalVarName is an environment variable name. It sets the seconds between polls for the status of an App Gateway update.
+	UpdatePollIntervalVarName = "APPGW_UPDATE_POLL_INTERVAL_SECONDS"
+
+	// UpdateTimeoutVarName is an environment variable name. It sets the seconds to wait for an App Gateway update to complete.
+	UpdateTimeoutVarName = "APPGW_UPDATE_TIMEOUT_SECONDS"
+
+	// EnableFIPSVarName is a feature flag requiring FIPS-validated crypto for certificate handling.
+	EnableFIPSVarName = "APPGW_ENABLE_FIPS"
+
+	// AnnotationPrefixVarName is an environment variable name. It replaces appgw.ingress.kubernetes.io in the annotations AGIC reads.
+	AnnotationPrefixVarName = "APPGW_ANNOTATION_PREFIX"
+
+	// EnableNginxAnnotationsVarName is a feature flag translating a set of NGINX Ingress Controller annotations to AGIC annotations.
+	EnableNginxAnnotationsVarName = "APPGW_ENABLE_NGINX_ANNOTATIONS"
+
+	// AllowedHostSuffixesVarName is an environment variable name. It is a comma separated list of domains (ourteam.example.com)
+	// or wildcard domains (*.ourteam.example.com); ingresses with hosts outside of these domains are ignored.
+	AllowedHostSuffixesVarName = "APPGW_ALLOWED_HOST_SUFFIXES"
+
+	// RoutingRuleEvaluationVarName is an environment variable name. It selects whether AGIC generates request routing rules
+	// evaluated in order (classic) or by priority (priority).
+	RoutingRuleEvaluationVarName = "APPGW_ROUTING_RULE_EVALUATION"
+
+	// PauseConfigMapVarName is an environment variable name. It names a ConfigMap in the namespace of AGIC;
+	// while its "pause" key is "true", AGIC does not apply changes to the App Gateway.
+	PauseConfigMapVarName = "APPGW_PAUSE_CONFIGMAP"
+
+	// EnableZoneMetricsVarName is a feature flag, which enables watching nodes to report the availability zones of backend pool members.
+	EnableZoneMetricsVarName = "APPGW_ENABLE_ZONE_METRICS"
+
+	// EnableRewritesVarName is a feature flag enabling observation of the AzureApplicationGatewayRewrite CRD.
+	EnableRewritesVarName = "APPGW_ENABLE_REWRITES"
+
+	// EnableAuditLogVarName is a feature flag, which enables logging a summary of each change applied to the App Gateway.
+	EnableAuditLogVarName = "APPGW_ENABLE_AUDIT_LOG"
+
+	// DefaultRequestTimeoutVarName is an environment variable name. It sets the request timeout in seconds of the backend
+	// HTTP settings of ingresses without the request-timeout annotation.
+	DefaultRequestTimeoutVarName = "APPGW_DEFAULT_REQUEST_TIMEOUT_SECONDS"
+
+	// AutoSelectTLSSecretsVarName is a feature flag, which attaches the TLS secret whose certificate matches the host
+	// to the listeners of hosts not listed in the TLS section of their ingress.
+	AutoSelectTLSSecretsVarName = "APPGW_AUTO_SELECT_TLS_SECRETS"
+
+	// EnableDebugServerVarName is a feature flag, which starts an HTTP server dumping the caches of AGIC and the last
+	// App Gateway config it generated.
+	EnableDebugServerVarName = "APPGW_ENABLE_DEBUG_SERVER"
+
+	// DebugServerAddressVarName is an environment variable name. It sets the address the debug server listens on.
+	DebugServerAddressVarName = "APPGW_DEBUG_SERVER_ADDRESS"
+
+	// RollbackAfterFailuresVarName is an environment variable name. After this number of consecutive failed updates of
+	// the App Gateway, AGIC puts the last config it applied successfully back on the App Gateway.
+	RollbackAfterFailuresVarName = "APPGW_ROLLBACK_AFTER_FAILED_UPDATES"
+
+	// DefaultConnectionDrainingVarName is an environment variable name. It enables connection draining on the backend
+	// HTTP settings of ingresses without the connection-draining annotation.
+	DefaultConnectionDrainingVarName = "APPGW_DEFAULT_CONNECTION_DRAINING"
+
+	// DefaultDrainTimeoutVarName is an environment variable name. It sets the drain timeout in seconds of the backend
+	// HTTP settings of ingresses without the connection-draining-timeout annotation.
+	DefaultDrainTimeoutVarName = "APPGW_DEFAULT_CONNECTION_DRAINING_TIMEOUT_SECONDS"
+
+	// FrontendIPConfigurationVarName is an environment variable name. It names the frontend IP configuration of the
+	// App Gateway, which the listeners are bound to instead of the first public or private IP configuration.
+	FrontendIPConfigurationVarName = "APPGW_FRONTEND_IP_CONFIGURATION"
+
+	// EnableMultiClusterServicesVarName is a feature flag enabling the resolution of ingress backends to the
+	// ServiceImports of Kubernetes multi-cluster services, when there is no local service of the same name.
+	EnableMultiClusterServicesVarName = "APPGW_ENABLE_MULTI_CLUSTER_SERVICES"
+
+	// StrictIngressValidationVarName is a feature flag. When any ingress is invalid, AGIC fails the reconcile and does
+	// not apply the config, instead of ignoring the invalid ingress and applying the config of the valid ones.
+	StrictIngressValidationVarName = "APPGW_STRICT_INGRESS_VALIDATION"
+
+	// EnableCertManagerVarName is a feature flag, which defers binding the TLS secret of a cert-manager Certificate
+	// to the listeners until cert-manager issued the certificate.
+	EnableCertManagerVarName = "APPGW_ENABLE_CERT_MANAGER"
+
+	// OTLPEndpointVarName is an environment variable name. It sets the OTLP/HTTP endpoint of the OpenTelemetry collector
+	// AGIC exports the traces of the reconciles to; no traces are recorded without it.
+	OTLPEndpointVarName = "APPGW_OTLP_ENDPOINT"
+
+	// IngressAPIVersionVarName is an environment variable name. It sets the API version of the Ingresses AGIC consumes:
+	// networking.k8s.io/v1, networking.k8s.io/v1beta1 or extensions/v1beta1. By default AGIC consumes the newest one
+	// the API server serves.
+	IngressAPIVersionVarName = "APPGW_INGRESS_API_VERSION"
+
+	// PathNormalizationVarName is an environment variable name. It selects whether AGIC canonicalizes the paths of
+	// ingresses for the path rules (canonical, default), or uses them as they are written (none).
+	PathNormalizationVarName = "APPGW_PATH_NORMALIZATION"
+
+	// DefaultSslRedirectVarName is an environment variable name. It redirects HTTP to HTTPS on the listeners of
+	// ingresses with TLS, which do not have the ssl-redirect annotation.
+	DefaultSslRedirectVarName = "APPGW_DEFAULT_SSL_REDIRECT"
+
+	// DefaultAnnotationsConfigMapVarName is an environment variable name. It names the ConfigMap, which holds in each
+	// namespace the AGIC annotations applied to the ingresses of the namespace without them.
+	DefaultAnnotationsConfigMapVarName = "APPGW_DEFAULT_ANNOTATIONS_CONFIGMAP"
+
+	// MaxPoolAddressesVarName is an environment variable name. It sets the number of ready endpoints of a service,
+	// above which the backend pool of the service holds its cluster IP instead of the IPs of its pods.
+	MaxPoolAddressesVarName = "APPGW_MAX_POOL_ADDRESSES"
+
+	// GatewayHealthPathVarName is an environment variable name. It sets a path, such as /agw-health, which App Gateway
+	// answers on the listener without a host name, without any backend, for the health checks of its frontend.
+	GatewayHealthPathVarName = "APPGW_GATEWAY_HEALTH_PATH"
+
+	// GatewayHealthTargetURLVarName is an environment variable name. It sets the URL App Gateway redirects the
+	// requests for the gateway health path to.
+	GatewayHealthTargetURLVarName = "APPGW_GATEWAY_HEALTH_TARGET_URL"
+
+	// PruningGracePeriodVarName is an environment variable name. It sets for how many seconds AGIC keeps the App Gateway
+	// objects of an ingress, which was removed, so that an ingress recreated in the meantime reuses them.
+	PruningGracePeriodVarName = "APPGW_PRUNING_GRACE_PERIOD_SECONDS"
+
+	// FQDNRefreshIntervalVarName is an environment variable name. It makes AGIC resolve the FQDNs of ExternalName
+	// services, and put their IPs in the backend pools, resolving them again after this many seconds.
+	FQDNRefreshIntervalVarName = "APPGW_FQDN_REFRESH_INTERVAL_SECONDS"
+
+	// AutoscaleScheduleVarName is an environment variable name. It sets the minimum and maximum capacity of the
+	// autoscaling App Gateway by time of day (UTC), such as "08:00-20:00=4-20,20:00-08:00=2-10".
+	AutoscaleScheduleVarName = "APPGW_AUTOSCALE_SCHEDULE"
+
+	// AutoscaleCooldownVarName is an environment variable name. It sets for how many seconds after changing the
+	// capacity of App Gateway AGIC does not change it again.
+	AutoscaleCooldownVarName = "APPGW_AUTOSCALE_COOLDOWN_SECONDS"
+
+	// LogAnnotationsAllowlistVarName is an environment variable name. It sets the comma separated keys of the
+	// annotations, whose values AGIC logs; the values of all other annotations are redacted.
+	LogAnnotationsAllowlistVarName = "APPGW_LOG_ANNOTATIONS_ALLOWLIST"
+
+	// LogAnnotationsDenylistVarName is an environment variable name. It sets the comma separated keys of the
+	// annotations, whose values AGIC redacts in logs and events.
+	LogAnnotationsDenylistVarName = "APPGW_LOG_ANNOTATIONS_DENYLIST"
+
+	// DanglingReferenceActionVarName is an environment variable name. It selects what AGIC does with an ingress, which
+	// references a WAF policy that no longer exists: build it without the policy (fallback, default), or skip it (skip).
+	DanglingReferenceActionVarName = "APPGW_DANGLING_REFERENCE_ACTION"
+
+	// StagingResourceIDVarName is an environment variable name. It sets the resource ID of a staging App Gateway;
+	// when set, AGIC applies each changed config to it first, and to its own App Gateway only if that succeeds.
+	StagingResourceIDVarName = "APPGW_STAGING_RESOURCE_ID"
+
+	// StagingProbeURLVarName is an environment variable name. It sets a URL served by the staging App Gateway, which
+	// must answer with a 2xx or 3xx status code once the config is applied to it, for the config to be rolled forward.
+	StagingProbeURLVarName = "APPGW_STAGING_PROBE_URL"
+
+	// AdoptExistingConfigVarName is a feature flag. It makes AGIC keep the names of the objects found on the App Gateway,
+	// which match the objects it generates for the ingresses, instead of replacing them with objects of its own names.
+	AdoptExistingConfigVarName = "APPGW_ADOPT_EXISTING_CONFIG"
+
+	// InformerSyncTimeoutVarName is an environment variable name. It sets the number of seconds AGIC waits for the
+	// initial sync of its caches of the Kubernetes resources before it fails; AGIC waits indefinitely when not set.
+	InformerSyncTimeoutVarName = "APPGW_INFORMER_SYNC_TIMEOUT_SECONDS"
+
+	// WarnMissingReadinessProbeVarName is a feature flag. It makes AGIC emit an event on the ingresses, whose backend pods
+	// have no HTTP readiness probe for App Gateway to follow.
+	WarnMissingReadinessProbeVarName = "APPGW_WARN_MISSING_READINESS_PROBE"
+
+	// MinHealthyPoolAddressesVarName is an environment variable name. It sets the number of established addresses a
+	// backend pool keeps, when AGIC removes addresses from it; the removals breaching it are deferred and made one at a time.
+	MinHealthyPoolAddressesVarName = "APPGW_MIN_HEALTHY_POOL_ADDRESSES"
+
+	// MinHealthyRemovalIntervalVarName is an environment variable name. It sets the number of seconds a new address
+	// takes to count as established, and the number of seconds between the deferred removals of addresses of a pool.
+	MinHealthyRemovalIntervalVarName = "APPGW_MIN_HEALTHY_REMOVAL_INTERVAL_SECONDS"
+
+	// HTTPListenerPortVarName is an environment variable name. It sets the frontend port of the HTTP listeners, instead
+	// of 80, unless an ingress sets its own.
+	HTTPListenerPortVarName = "APPGW_HTTP_LISTENER_PORT"
+
+	// SummaryConfigMapVarName is an environment variable name. It names a ConfigMap, which AGIC writes a JSON summary of
+	// the last App Gateway config it applied to.
+	SummaryConfigMapVarName = "APPGW_SUMMARY_CONFIGMAP"
+
+	// SummaryConfigMapNamespaceVarName is an environment variable name. It sets the namespace of the summary ConfigMap,
+	// instead of the namespace of AGIC.
+	SummaryConfigMapNamespaceVarName = "APPGW_SUMMARY_CONFIGMAP_NAMESPACE"
+
+	// EventDedupIntervalVarName is an environment variable name. It sets the number of seconds, within which AGIC does
+	// not record an event identical to one it recorded, but counts it.
+	EventDedupIntervalVarName = "APPGW_EVENT_DEDUP_INTERVAL_SECONDS"
+
+	// HTTPSOnlyVarName is a feature flag enforcing the HTTPS-only policy: ingresses, which would have a plaintext HTTP
+	// listener serving their backends, are ignored. HTTP listeners, which only redirect to HTTPS, are allowed.
+	HTTPSOnlyVarName = "APPGW_HTTPS_ONLY"
+
+	// EnableGatewayAPIVarName is a feature flag, which translates the HTTPRoutes of the Gateways of the Gateway API with
+	// the GatewayClass of AGIC to App Gateway config, along with the ingresses. It is experimental.
+	EnableGatewayAPIVarName = "APPGW_ENABLE_GATEWAY_API"
+
+	// StampConfigHashVarName is a feature flag, which annotates the ingresses with the hash of the App Gateway config
+	// AGIC last applied for them.
+	StampConfigHashVarName = "APPGW_STAMP_CONFIG_HASH"
+
+	// ServiceSelectorVarName is an environment variable name. It sets the label selector of the services, and of their
+	// endpoints, AGIC watches; the other services are ignored, and ingresses referencing them are invalid.
+	ServiceSelectorVarName = "APPGW_SERVICE_SELECTOR"
+
+	// StrictHeaderHandlingVarName is a feature flag, which would make App Gateway reject requests with malformed or
+	// oversized headers. The App Gateway API AGIC uses has no such setting, so AGIC refuses to start when it is set.
+	StrictHeaderHandlingVarName = "APPGW_STRICT_HEADER_HANDLING"
+)
+
+const (
+	// RoutingRuleEvaluationClassic evaluates the request routing rules in the order they are listed.
+	RoutingRuleEvaluationClassic = "classic"
+
+	// RoutingRuleEvaluationPriority evaluates the request routing rules by their priority.
+	RoutingRuleEvaluationPriority = "priority"
+
+	// PathNormalizationCanonical canonicalizes the paths of ingresses: with a leading slash, without repeated slashes
+	// and with at most one trailing wildcard.
+	PathNormalizationCanonical = "canonical"
+
+	// PathNormalizationNone uses the paths of ingresses as they are written.
+	PathNormalizationNone = "none"
+
+	// DanglingReferenceFallback builds the config of an ingress without the Azure resources it references, which no
+	// longer exist.
+	DanglingReferenceFallback = "fallback"
+
+	// DanglingReferenceSkip leaves out the ingresses, which reference Azure resources that no longer exist.
+	DanglingReferenceSkip = "skip"
 )
 
 // EnvVariables is a struct storing values for environment variables.
 type EnvVariables struct {
-	AzContextLocation          string
-	SubscriptionID             string
-	ResourceGroupName          string
-	AppGwName                  string
-	AppGwSubnetName            string
-	AppGwSubnetPrefix          string
-	AppGwResourceID            string
-	AppGwSubnetID              string
-	AuthLocation               string
-	WatchNamespace             string
-	UsePrivateIP               string
-	VerbosityLevel             string
-	AGICPodName                string
-	AGICPodNamespace           string
-	EnableBrownfieldDeployment bool
-	EnableIstioIntegration     bool
-	EnableSaveConfigToFile     bool
-	EnablePanicOnPutError      bool
-	EnableDeployAppGateway     bool
-	UseManagedIdentityForPod   bool
-	HTTPServicePort            string
-	AttachWAFPolicyToListener  bool
+	AzContextLocation           string
+	SubscriptionID              string
+	ResourceGroupName           string
+	AppGwName                   string
+	AppGwSubnetName             string
+	AppGwSubnetPrefix           string
+	AppGwResourceID             string
+	AppGwSubnetID               string
+	AuthLocation                string
+	WatchNamespace              string
+	UsePrivateIP                string
+	VerbosityLevel              string
+	AGICPodName                 string
+	AGICPodNamespace            string
+	AGICDeploymentName          string
+	EnableBrownfieldDeployment  bool
+	EnableIstioIntegration      bool
+	EnableSaveConfigToFile      bool
+	EnablePanicOnPutError       bool
+	EnableDeployAppGateway      bool
+	UseManagedIdentityForPod    bool
+	HTTPServicePort             string
+	AttachWAFPolicyToListener   bool
+	EnableMultiInstance         bool
+	ConfigNamePrefix            string
+	EventQueueDepth             string
+	UpdatePollInterval          string
+	UpdateTimeout               string
+	EnableFIPS                  bool
+	AnnotationPrefix            string
+	EnableNginxAnnotations      bool
+	AllowedHostSuffixes         string
+	RoutingRuleEvaluation       string
+	PauseConfigMap              string
+	EnableZoneMetrics           bool
+	EnableRewrites              bool
+	EnableAuditLog              bool
+	DefaultRequestTimeout       string
+	AutoSelectTLSSecrets        bool
+	EnableDebugServer           bool
+	DebugServerAddress          string
+	RollbackAfterFailures       string
+	DefaultConnectionDraining   bool
+	DefaultDrainTimeout         string
+	FrontendIPConfiguration     string
+	EnableMultiClusterServices  bool
+	StrictIngressValidation     bool
+	EnableCertManager           bool
+	OTLPEndpoint                string
+	IngressAPIVersion           string
+	PathNormalization           string
+	DefaultSslRedirect          bool
+	DefaultAnnotationsConfigMap string
+	MaxPoolAddresses            string
+	GatewayHealthPath           string
+	GatewayHealthTargetURL      string
+	PruningGracePeriod          string
+	FQDNRefreshInterval         string
+	AutoscaleSchedule           string
+	AutoscaleCooldown           string
+	LogAnnotationsAllowlist     string
+	LogAnnotationsDenylist      string
+	DanglingReferenceAction     string
+	StagingResourceID           string
+	StagingProbeURL             string
+	AdoptExistingConfig         bool
+	InformerSyncTimeout         string
+	WarnMissingReadinessProbe   bool
+	MinHealthyPoolAddresses     string
+	MinHealthyRemovalInterval   string
+	HTTPListenerPort            string
+	SummaryConfigMap            string
+	SummaryConfigMapNamespace   string
+	EventDedupInterval          string
+	HTTPSOnly                   bool
+	EnableGatewayAPI            bool
+	StampConfigHash             bool
+	ServiceSelector             string
+	StrictHeaderHandling        bool
 }
 
 var portNumberValidator = regexp.MustCompile(`^[0-9]{4,5}$`)
 var boolValidator = regexp.MustCompile(`^(?i)(true|false)$`)
+var queueDepthValidator = regexp.MustCompile(`^[1-9][0-9]*$`)
+var secondsValidator = regexp.MustCompile(`^[1-9][0-9]*$`)
+var failureCountValidator = regexp.MustCompile(`^[1-9][0-9]{0,2}$`) // 1 - 999
+var listenAddressValidator = regexp.MustCompile(`^([a-zA-Z0-9.-]*|\[[0-9a-fA-F:]+\]):[0-9]{1,5}$`)
+var requestTimeoutValidator = regexp.MustCompile(`^([1-9][0-9]{0,3}|[1-7][0-9]{4}|8[0-5][0-9]{3}|86[0-3][0-9]{2}|86400)$`) // 1 - 86400 seconds
+var drainTimeoutValidator = regexp.MustCompile(`^([1-9][0-9]{0,2}|[1-2][0-9]{3}|3[0-5][0-9]{2}|3600)$`)                    // 1 - 3600 seconds
+var allowedHostSuffixesValidator = regexp.MustCompile(`^(?i)\s*(\*\.)?[a-z0-9]([-a-z0-9.]*[a-z0-9])?(\s*,\s*(\*\.)?[a-z0-9]([-a-z0-9.]*[a-z0-9])?)*\s*$`)
+var otlpEndpointValidator = regexp.MustCompile(`^https?://[^\s]+$`)
+var ingressAPIVersionValidator = regexp.MustCompile(`^(networking\.k8s\.io/v1|networking\.k8s\.io/v1beta1|extensions/v1beta1)$`)
+var routingRuleEvaluationValidator = regexp.MustCompile(`^(?i)(classic|priority)$`)
+var pathNormalizationValidator = regexp.MustCompile(`^(?i)(canonical|none)$`)
+var maxPoolAddressesValidator = regexp.MustCompile(`^[1-9][0-9]*$`)
+var minHealthyPoolAddressesValidator = regexp.MustCompile(`^[1-9][0-9]*$`)
+var httpListenerPortValidator = regexp.MustCompile(`^[1-9][0-9]{0,4}$`)
+var gatewayHealthPathValidator = regexp.MustCompile(`^/[-a-zA-Z0-9._~/]*$`)
+var redirectURLValidator = regexp.MustCompile(`^https?://[^\s]+$`)
+var configMapNameValidator = regexp.MustCompile(`^[a-z0-9]([-a-z0-9.]*[a-z0-9])?$`)
+var applicationGatewayIDValidator = regexp.MustCompile(`^(?i)/subscriptions/[^/]+/resourceGroups/[^/]+/providers/Microsoft\.Network/applicationGateways/[^/]+$`)
+var autoscaleScheduleValidator = regexp.MustCompile(`^\s*[0-9]{2}:[0-9]{2}-[0-9]{2}:[0-9]{2}=[0-9]{1,3}-[0-9]{1,3}(\s*,\s*[0-9]{2}:[0-9]{2}-[0-9]{2}:[0-9]{2}=[0-9]{1,3}-[0-9]{1,3})*\s*$`)
+var annotationKeysValidator = regexp.MustCompile(`^\s*[-a-zA-Z0-9._/]+\*?(\s*,\s*[-a-zA-Z0-9._/]+\*?)*\s*$`)
+var danglingReferenceActionValidator = regexp.MustCompile(`^(?i)(fallback|skip)$`)
+var resourceNameValidator = regexp.MustCompile(`^[a-zA-Z0-9]([-a-zA-Z0-9_.]{0,78}[a-zA-Z0-9_])?$`)
+var annotationPrefixValidator = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+
+// ConfigNamePrefixValidator validates the prefix of the names of the App Gateway objects AGIC generates.
+// Max length for a property name is 80 characters. We hash w/ MD5 when length is > 80, which is 32 characters
+var ConfigNamePrefixValidator = regexp.MustCompile(`^[0-9a-zA-Z\-]{0,47}$`)
 
 // GetEnv returns values for defined environment variables for Ingress Controller.
 func GetEnv() EnvVariables {
 	env := EnvVariables{
-		AzContextLocation:          os.Getenv(AzContextLocationVarName),
-		SubscriptionID:             os.Getenv(SubscriptionIDVarName),
-		ResourceGroupName:          os.Getenv(ResourceGroupNameVarName),
-		AppGwName:                  os.Getenv(AppGwNameVarName),
-		AppGwSubnetName:            os.Getenv(AppGwSubnetNameVarName),
-		AppGwSubnetPrefix:          os.Getenv(AppGwSubnetPrefixVarName),
-		AppGwResourceID:            os.Getenv(AppGwResourceIDVarName),
-		AppGwSubnetID:              os.Getenv(AppGwSubnetIDVarName),
-		AuthLocation:               os.Getenv(AuthLocationVarName),
-		WatchNamespace:             os.Getenv(WatchNamespaceVarName),
-		UsePrivateIP:               os.Getenv(UsePrivateIPVarName),
-		VerbosityLevel:             os.Getenv(VerbosityLevelVarName),
-		AGICPodName:                os.Getenv(AGICPodNameVarName),
-		AGICPodNamespace:           os.Getenv(AGICPodNamespaceVarName),
-		EnableBrownfieldDeployment: GetEnvironmentVariable(EnableBrownfieldDeploymentVarName, "false", boolValidator) == "true",
-		EnableIstioIntegration:     GetEnvironmentVariable(EnableIstioIntegrationVarName, "false", boolValidator) == "true",
-		EnableSaveConfigToFile:     GetEnvironmentVariable(EnableSaveConfigToFileVarName, "false", boolValidator) == "true",
-		EnablePanicOnPutError:      GetEnvironmentVariable(EnablePanicOnPutErrorVarName, "false", boolValidator) == "true",
-		EnableDeployAppGateway:     GetEnvironmentVariable(EnableDeployAppGatewayVarName, "false", boolValidator) == "true",
-		UseManagedIdentityForPod:   GetEnvironmentVariable(UseManagedIdentityForPodVarName, "false", boolValidator) == "true",
-		HTTPServicePort:            GetEnvironmentVariable(HTTPServicePortVarName, "8123", portNumberValidator),
-		AttachWAFPolicyToListener:  GetEnvironmentVariable(AttachWAFPolicyToListenerVarName, "false", boolValidator) == "true",
+		AzContextLocation:           os.Getenv(AzContextLocationVarName),
+		SubscriptionID:              os.Getenv(SubscriptionIDVarName),
+		ResourceGroupName:           os.Getenv(ResourceGroupNameVarName),
+		AppGwName:                   os.Getenv(AppGwNameVarName),
+		AppGwSubnetName:             os.Getenv(AppGwSubnetNameVarName),
+		AppGwSubnetPrefix:           os.Getenv(AppGwSubnetPrefixVarName),
+		AppGwResourceID:             os.Getenv(AppGwResourceIDVarName),
+		AppGwSubnetID:               os.Getenv(AppGwSubnetIDVarName),
+		AuthLocation:                os.Getenv(AuthLocationVarName),
+		WatchNamespace:              os.Getenv(WatchNamespaceVarName),
+		UsePrivateIP:                os.Getenv(UsePrivateIPVarName),
+		VerbosityLevel:              os.Getenv(VerbosityLevelVarName),
+		AGICPodName:                 os.Getenv(AGICPodNameVarName),
+		AGICPodNamespace:            os.Getenv(AGICPodNamespaceVarName),
+		AGICDeploymentName:          os.Getenv(AGICDeploymentNameVarName),
+		EnableBrownfieldDeployment:  GetEnvironmentVariable(EnableBrownfieldDeploymentVarName, "false", boolValidator) == "true",
+		EnableIstioIntegration:      GetEnvironmentVariable(EnableIstioIntegrationVarName, "false", boolValidator) == "true",
+		EnableSaveConfigToFile:      GetEnvironmentVariable(EnableSaveConfigToFileVarName, "false", boolValidator) == "true",
+		EnablePanicOnPutError:       GetEnvironmentVariable(EnablePanicOnPutErrorVarName, "false", boolValidator) == "true",
+		EnableDeployAppGateway:      GetEnvironmentVariable(EnableDeployAppGatewayVarName, "false", boolValidator) == "true",
+		UseManagedIdentityForPod:    GetEnvironmentVariable(UseManagedIdentityForPodVarName, "false", boolValidator) == "true",
+		HTTPServicePort:             GetEnvironmentVariable(HTTPServicePortVarName, "8123", portNumberValidator),
+		AttachWAFPolicyToListener:   GetEnvironmentVariable(AttachWAFPolicyToListenerVarName, "false", boolValidator) == "true",
+		EnableMultiInstance:         GetEnvironmentVariable(EnableMultiInstanceVarName, "false", boolValidator) == "true",
+		ConfigNamePrefix:            GetEnvironmentVariable(ConfigNamePrefixVarName, "", ConfigNamePrefixValidator),
+		EventQueueDepth:             GetEnvironmentVariable(EventQueueDepthVarName, "1024", queueDepthValidator),
+		UpdatePollInterval:          GetEnvironmentVariable(UpdatePollIntervalVarName, "10", secondsValidator),
+		UpdateTimeout:               GetEnvironmentVariable(UpdateTimeoutVarName, "1800", secondsValidator),
+		EnableFIPS:                  GetEnvironmentVariable(EnableFIPSVarName, "false", boolValidator) == "true",
+		AnnotationPrefix:            GetEnvironmentVariable(AnnotationPrefixVarName, "appgw.ingress.kubernetes.io", annotationPrefixValidator),
+		EnableNginxAnnotations:      GetEnvironmentVariable(EnableNginxAnnotationsVarName, "false", boolValidator) == "true",
+		AllowedHostSuffixes:         GetEnvironmentVariable(AllowedHostSuffixesVarName, "", allowedHostSuffixesValidator),
+		RoutingRuleEvaluation:       strings.ToLower(GetEnvironmentVariable(RoutingRuleEvaluationVarName, RoutingRuleEvaluationClassic, routingRuleEvaluationValidator)),
+		PauseConfigMap:              GetEnvironmentVariable(PauseConfigMapVarName, "", configMapNameValidator),
+		EnableZoneMetrics:           GetEnvironmentVariable(EnableZoneMetricsVarName, "false", boolValidator) == "true",
+		EnableRewrites:              GetEnvironmentVariable(EnableRewritesVarName, "false", boolValidator) == "true",
+		EnableAuditLog:              GetEnvironmentVariable(EnableAuditLogVarName, "false", boolValidator) == "true",
+		DefaultRequestTimeout:       GetEnvironmentVariable(DefaultRequestTimeoutVarName, "", requestTimeoutValidator),
+		AutoSelectTLSSecrets:        GetEnvironmentVariable(AutoSelectTLSSecretsVarName, "false", boolValidator) == "true",
+		EnableDebugServer:           GetEnvironmentVariable(EnableDebugServerVarName, "false", boolValidator) == "true",
+		DebugServerAddress:          GetEnvironmentVariable(DebugServerAddressVarName, "localhost:8124", listenAddressValidator),
+		RollbackAfterFailures:       GetEnvironmentVariable(RollbackAfterFailuresVarName, "", failureCountValidator),
+		DefaultConnectionDraining:   GetEnvironmentVariable(DefaultConnectionDrainingVarName, "false", boolValidator) == "true",
+		DefaultDrainTimeout:         GetEnvironmentVariable(DefaultDrainTimeoutVarName, "", drainTimeoutValidator),
+		FrontendIPConfiguration:     GetEnvironmentVariable(FrontendIPConfigurationVarName, "", resourceNameValidator),
+		EnableMultiClusterServices:  GetEnvironmentVariable(EnableMultiClusterServicesVarName, "false", boolValidator) == "true",
+		StrictIngressValidation:     GetEnvironmentVariable(StrictIngressValidationVarName, "false", boolValidator) == "true",
+		EnableCertManager:           GetEnvironmentVariable(EnableCertManagerVarName, "false", boolValidator) == "true",
+		OTLPEndpoint:                GetEnvironmentVariable(OTLPEndpointVarName, "", otlpEndpointValidator),
+		IngressAPIVersion:           GetEnvironmentVariable(IngressAPIVersionVarName, "", ingressAPIVersionValidator),
+		PathNormalization:           strings.ToLower(GetEnvironmentVariable(PathNormalizationVarName, PathNormalizationCanonical, pathNormalizationValidator)),
+		DefaultSslRedirect:          GetEnvironmentVariable(DefaultSslRedirectVarName, "false", boolValidator) == "true",
+		DefaultAnnotationsConfigMap: GetEnvironmentVariable(DefaultAnnotationsConfigMapVarName, "", configMapNameValidator),
+		MaxPoolAddresses:            GetEnvironmentVariable(MaxPoolAddressesVarName, "", maxPoolAddressesValidator),
+		GatewayHealthPath:           GetEnvironmentVariable(GatewayHealthPathVarName, "", gatewayHealthPathValidator),
+		GatewayHealthTargetURL:      GetEnvironmentVariable(GatewayHealthTargetURLVarName, "", redirectURLValidator),
+		PruningGracePeriod:          GetEnvironmentVariable(PruningGracePeriodVarName, "", secondsValidator),
+		FQDNRefreshInterval:         GetEnvironmentVariable(FQDNRefreshIntervalVarName, "", secondsValidator),
+		AutoscaleSchedule:           GetEnvironmentVariable(AutoscaleScheduleVarName, "", autoscaleScheduleValidator),
+		AutoscaleCooldown:           GetEnvironmentVariable(AutoscaleCooldownVarName, "900", secondsValidator),
+		LogAnnotationsAllowlist:     GetEnvironmentVariable(LogAnnotationsAllowlistVarName, "", annotationKeysValidator),
+		LogAnnotationsDenylist:      GetEnvironmentVariable(LogAnnotationsDenylistVarName, "", annotationKeysValidator),
+		DanglingReferenceAction:     strings.ToLower(GetEnvironmentVariable(DanglingReferenceActionVarName, DanglingReferenceFallback, danglingReferenceActionValidator)),
+		StagingResourceID:           GetEnvironmentVariable(StagingResourceIDVarName, "", applicationGatewayIDValidator),
+		StagingProbeURL:             GetEnvironmentVariable(StagingProbeURLVarName, "", redirectURLValidator),
+		AdoptExistingConfig:         GetEnvironmentVariable(AdoptExistingConfigVarName, "false", boolValidator) == "true",
+		InformerSyncTimeout:         GetEnvironmentVariable(InformerSyncTimeoutVarName, "", secondsValidator),
+		WarnMissingReadinessProbe:   GetEnvironmentVariable(WarnMissingReadinessProbeVarName, "false", boolValidator) == "true",
+		MinHealthyPoolAddresses:     GetEnvironmentVariable(MinHealthyPoolAddressesVarName, "", minHealthyPoolAddressesValidator),
+		MinHealthyRemovalInterval:   GetEnvironmentVariable(MinHealthyRemovalIntervalVarName, "", secondsValidator),
+		HTTPListenerPort:            GetEnvironmentVariable(HTTPListenerPortVarName, "", httpListenerPortValidator),
+		SummaryConfigMap:            GetEnvironmentVariable(SummaryConfigMapVarName, "", configMapNameValidator),
+		SummaryConfigMapNamespace:   GetEnvironmentVariable(SummaryConfigMapNamespaceVarName, "", configMapNameValidator),
+		EventDedupInterval:          GetEnvironmentVariable(EventDedupIntervalVarName, "", secondsValidator),
+		HTTPSOnly:                   GetEnvironmentVariable(HTTPSOnlyVarName, "false", boolValidator) == "true",
+		EnableGatewayAPI:            GetEnvironmentVariable(EnableGatewayAPIVarName, "false", boolValidator) == "true",
+		StampConfigHash:             GetEnvironmentVariable(StampConfigHashVarName, "false", boolValidator) == "true",
+		ServiceSelector:             GetEnvironmentVariable(ServiceSelectorVarName, "", nil),
+		StrictHeaderHandling:        GetEnvironmentVariable(StrictHeaderHandlingVarName, "false", boolValidator) == "true",
 	}
 
 	return env
@@ -162,6 +547,52 @@ func ValidateEnv(env EnvVariables) error {
 		if len(env.AppGwName) == 0 && len(env.AppGwResourceID) == 0 {
 			return ErrorMissingApplicationGatewayNameOrApplicationGatewayID
 		}
+
+		// the resource ID takes precedence over APPGW_SUBSCRIPTION_ID, APPGW_RESOURCE_GROUP and APPGW_NAME
+		if len(env.AppGwResourceID) != 0 && !applicationGatewayIDValidator.MatchString(env.AppGwResourceID) {
+			return ErrorInvalidApplicationGatewayID
+		}
+	}
+
+	if len(env.GatewayHealthPath) != 0 && len(env.GatewayHealthTargetURL) == 0 {
+		return ErrorMissingGatewayHealthTargetURL
+	}
+
+	if len(env.StagingProbeURL) != 0 && len(env.StagingResourceID) == 0 {
+		return ErrorMissingStagingResourceID
+	}
+
+	if len(env.StagingResourceID) != 0 && strings.EqualFold(env.StagingResourceID, env.AppGwResourceID) {
+		return ErrorStagingIsTheAppGateway
+	}
+
+	if len(env.HTTPListenerPort) != 0 {
+		// App Gateway reserves the ports from 65200 on v2 SKUs for its infrastructure; 443 serves the HTTPS listeners.
+		if port, err := strconv.Atoi(env.HTTPListenerPort); err != nil || port < 1 || port >= 65200 || port == 443 {
+			return ErrorInvalidHTTPListenerPort
+		}
+	}
+
+	// The prefix of the names is what tells the objects of one instance from those of the others.
+	if env.EnableMultiInstance && env.ConfigNamePrefix == "" {
+		return ErrorMissingConfigNamePrefix
+	}
+
+	// The objects of the other instances are not owned by this one, so adoption would rename them after its ingresses.
+	if env.AdoptExistingConfig && env.EnableMultiInstance {
+		return ErrorAdoptionWithMultiInstance
+	}
+
+	// The network API 2019-09-01 has no gateway or listener setting for the handling of malformed or oversized headers.
+	if env.StrictHeaderHandling {
+		return ErrorStrictHeaderHandlingNotSupported
+	}
+
+	if len(env.ServiceSelector) != 0 {
+		// A selector without requirements would select all services.
+		if selector, err := labels.Parse(env.ServiceSelector); err != nil || selector.Empty() {
+			return ErrorInvalidServiceSelector
+		}
 	}
 
 	if env.WatchNamespace == "" {
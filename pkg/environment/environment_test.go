@@ -8,6 +8,7 @@ package environment
 import (
 	"os"
 	"regexp"
+	"strings"
 	"testing"
 
 	. "github.com/onsi/ginkgo"
@@ -83,12 +84,123 @@ var _ = Describe("Environment", func() {
 					EnableSaveConfigToFile:     false,
 					EnablePanicOnPutError:      true,
 					HTTPServicePort:            "8123",
+					EventQueueDepth:            "1024",
+					UpdatePollInterval:         "10",
+					UpdateTimeout:              "1800",
+					AnnotationPrefix:           "appgw.ingress.kubernetes.io",
+					RoutingRuleEvaluation:      "classic",
+					DebugServerAddress:         "localhost:8124",
+					PathNormalization:          "canonical",
+					AutoscaleCooldown:          "900",
+					DanglingReferenceAction:    "fallback",
 				}
 
 				Expect(GetEnv()).To(Equal(expected))
 			})
 		})
 
+		Context("Testing the default request timeout", func() {
+			AfterEach(func() {
+				_ = os.Unsetenv(DefaultRequestTimeoutVarName)
+			})
+
+			It("is not set without the env var", func() {
+				Expect(GetEnv().DefaultRequestTimeout).To(BeEmpty())
+			})
+
+			It("accepts the timeouts App Gateway allows", func() {
+				for _, timeout := range []string{"1", "30", "9999", "86399", "86400"} {
+					_ = os.Setenv(DefaultRequestTimeoutVarName, timeout)
+					Expect(GetEnv().DefaultRequestTimeout).To(Equal(timeout))
+				}
+			})
+
+			It("ignores invalid timeouts", func() {
+				for _, timeout := range []string{"0", "86401", "100000", "-5", "30s", ""} {
+					_ = os.Setenv(DefaultRequestTimeoutVarName, timeout)
+					Expect(GetEnv().DefaultRequestTimeout).To(BeEmpty(), timeout)
+				}
+			})
+		})
+
+		Context("Testing the default connection draining", func() {
+			AfterEach(func() {
+				_ = os.Unsetenv(DefaultConnectionDrainingVarName)
+				_ = os.Unsetenv(DefaultDrainTimeoutVarName)
+			})
+
+			It("is disabled without the env vars", func() {
+				Expect(GetEnv().DefaultConnectionDraining).To(BeFalse())
+				Expect(GetEnv().DefaultDrainTimeout).To(BeEmpty())
+			})
+
+			It("accepts the drain timeouts App Gateway allows", func() {
+				_ = os.Setenv(DefaultConnectionDrainingVarName, "true")
+				Expect(GetEnv().DefaultConnectionDraining).To(BeTrue())
+				for _, timeout := range []string{"1", "30", "999", "1000", "3599", "3600"} {
+					_ = os.Setenv(DefaultDrainTimeoutVarName, timeout)
+					Expect(GetEnv().DefaultDrainTimeout).To(Equal(timeout))
+				}
+			})
+
+			It("ignores invalid values", func() {
+				_ = os.Setenv(DefaultConnectionDrainingVarName, "yes")
+				Expect(GetEnv().DefaultConnectionDraining).To(BeFalse())
+				for _, timeout := range []string{"0", "3601", "9999", "-5", "30s"} {
+					_ = os.Setenv(DefaultDrainTimeoutVarName, timeout)
+					Expect(GetEnv().DefaultDrainTimeout).To(BeEmpty(), timeout)
+				}
+			})
+		})
+
+		Context("Testing the frontend IP configuration", func() {
+			AfterEach(func() {
+				_ = os.Unsetenv(FrontendIPConfigurationVarName)
+			})
+
+			It("is not set without the env var", func() {
+				Expect(GetEnv().FrontendIPConfiguration).To(BeEmpty())
+			})
+
+			It("accepts the names App Gateway allows", func() {
+				for _, name := range []string{"appGatewayFrontendIP", "private-ip_2", "a", "ip.v4_"} {
+					_ = os.Setenv(FrontendIPConfigurationVarName, name)
+					Expect(GetEnv().FrontendIPConfiguration).To(Equal(name))
+				}
+			})
+
+			It("ignores invalid names", func() {
+				for _, name := range []string{"-ip", "ip-", "ip config", "ip/config", strings.Repeat("a", 81)} {
+					_ = os.Setenv(FrontendIPConfigurationVarName, name)
+					Expect(GetEnv().FrontendIPConfiguration).To(BeEmpty(), name)
+				}
+			})
+		})
+
+		Context("Testing the rollback threshold", func() {
+			AfterEach(func() {
+				_ = os.Unsetenv(RollbackAfterFailuresVarName)
+			})
+
+			It("is not set without the env var", func() {
+				Expect(GetEnv().RollbackAfterFailures).To(BeEmpty())
+			})
+
+			It("accepts 1 - 999 failed updates", func() {
+				for _, failures := range []string{"1", "3", "999"} {
+					_ = os.Setenv(RollbackAfterFailuresVarName, failures)
+					Expect(GetEnv().RollbackAfterFailures).To(Equal(failures))
+				}
+			})
+
+			It("ignores invalid thresholds", func() {
+				for _, failures := range []string{"0", "1000", "-1", "03", "three"} {
+					_ = os.Setenv(RollbackAfterFailuresVarName, failures)
+					Expect(GetEnv().RollbackAfterFailures).To(BeEmpty(), failures)
+				}
+			})
+		})
+
 		Context("Test ValidateEnv when APPGW_ENABLE_DEPLOY is FALSE", func() {
 			It("should throw error when neither applicationGatewayName or applicationGatewayID is passed when APPGW_ENABLE_DEPLOY is FALSE", func() {
 				env := EnvVariables{
@@ -99,12 +211,39 @@ var _ = Describe("Environment", func() {
 
 			It("should allow passing applicationGatewayID when APPGW_ENABLE_DEPLOY is FALSE", func() {
 				env := EnvVariables{
-					AppGwResourceID:        "id",
+					AppGwResourceID:        "/subscriptions/xxxx/resourceGroups/yyyy/providers/Microsoft.Network/applicationGateways/zzzz",
+					EnableDeployAppGateway: false,
+				}
+				Expect(ValidateEnv(env)).To(BeNil())
+			})
+
+			It("should allow passing applicationGatewayID along with applicationGatewayName when APPGW_ENABLE_DEPLOY is FALSE", func() {
+				env := EnvVariables{
+					AppGwResourceID:        "/subscriptions/xxxx/resourcegroups/yyyy/providers/microsoft.network/applicationgateways/zzzz",
+					AppGwName:              "name",
 					EnableDeployAppGateway: false,
 				}
 				Expect(ValidateEnv(env)).To(BeNil())
 			})
 
+			It("should throw error when applicationGatewayID is malformed when APPGW_ENABLE_DEPLOY is FALSE", func() {
+				for _, id := range []string{
+					"id",
+					"zzzz",
+					"/subscriptions/xxxx/resourceGroups/yyyy/providers/Microsoft.Network/applicationGateways/",
+					"/subscriptions/xxxx/resourceGroups/yyyy/providers/Microsoft.Network/publicIPAddresses/zzzz",
+					"/subscriptions/xxxx/resourceGroups/yyyy/providers/Microsoft.Network/applicationGateways/zzzz/httpListeners/l",
+					"subscriptions/xxxx/resourceGroups/yyyy/providers/Microsoft.Network/applicationGateways/zzzz",
+				} {
+					env := EnvVariables{
+						AppGwResourceID:        id,
+						AppGwName:              "name",
+						EnableDeployAppGateway: false,
+					}
+					Expect(ValidateEnv(env)).To(Equal(ErrorInvalidApplicationGatewayID), id)
+				}
+			})
+
 			It("should allow passing applicationGatewayName when APPGW_ENABLE_DEPLOY is FALSE", func() {
 				env := EnvVariables{
 					AppGwName:              "name",
@@ -114,6 +253,87 @@ var _ = Describe("Environment", func() {
 			})
 		})
 
+		Context("Test ValidateEnv with a gateway health path", func() {
+			It("should throw error when the gateway health path has no target URL", func() {
+				env := EnvVariables{
+					AppGwName:         "name",
+					GatewayHealthPath: "/agw-health",
+				}
+				Expect(ValidateEnv(env)).To(Equal(ErrorMissingGatewayHealthTargetURL))
+
+				env.GatewayHealthTargetURL = "https://www.contoso.com/"
+				Expect(ValidateEnv(env)).To(BeNil())
+			})
+		})
+
+		Context("Test ValidateEnv with a staging App Gateway", func() {
+			It("should throw error when the staging probe URL has no staging App Gateway", func() {
+				env := EnvVariables{
+					AppGwName:       "name",
+					StagingProbeURL: "https://staging.contoso.com/",
+				}
+				Expect(ValidateEnv(env)).To(Equal(ErrorMissingStagingResourceID))
+
+				env.StagingResourceID = "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/applicationGateways/staging"
+				Expect(ValidateEnv(env)).To(BeNil())
+			})
+
+			It("should throw error when the staging App Gateway is the App Gateway of AGIC", func() {
+				env := EnvVariables{
+					AppGwResourceID:   "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/applicationGateways/appgw",
+					StagingResourceID: "/subscriptions/sub/resourceGroups/RG/providers/Microsoft.Network/applicationGateways/AppGw",
+				}
+				Expect(ValidateEnv(env)).To(Equal(ErrorStagingIsTheAppGateway))
+			})
+
+			It("should throw error when the HTTP listener port is reserved by App Gateway", func() {
+				env := EnvVariables{AppGwName: "appgw", HTTPListenerPort: "8080"}
+				Expect(ValidateEnv(env)).To(BeNil())
+				env.HTTPListenerPort = "65200"
+				Expect(ValidateEnv(env)).To(Equal(ErrorInvalidHTTPListenerPort))
+			})
+
+			It("should throw error when the HTTP listener port is the HTTPS port", func() {
+				env := EnvVariables{AppGwName: "appgw", HTTPListenerPort: "443"}
+				Expect(ValidateEnv(env)).To(Equal(ErrorInvalidHTTPListenerPort))
+			})
+
+			It("should throw error when multiple instances are enabled without a config name prefix", func() {
+				env := EnvVariables{AppGwName: "appgw", EnableMultiInstance: true}
+				Expect(ValidateEnv(env)).To(Equal(ErrorMissingConfigNamePrefix))
+				env.ConfigNamePrefix = "team-a-"
+				Expect(ValidateEnv(env)).To(BeNil())
+			})
+
+			It("should throw error when strict header handling is enabled", func() {
+				_ = os.Setenv(StrictHeaderHandlingVarName, "true")
+				defer os.Unsetenv(StrictHeaderHandlingVarName)
+				env := GetEnv()
+				Expect(env.StrictHeaderHandling).To(BeTrue())
+
+				env.AppGwName = "appgw"
+				Expect(ValidateEnv(env)).To(Equal(ErrorStrictHeaderHandlingNotSupported))
+				env.StrictHeaderHandling = false
+				Expect(ValidateEnv(env)).To(BeNil())
+			})
+
+			It("should throw error when adoption is enabled with multiple instances", func() {
+				env := EnvVariables{AppGwName: "appgw", AdoptExistingConfig: true, ConfigNamePrefix: "team-a-"}
+				Expect(ValidateEnv(env)).To(BeNil())
+				env.EnableMultiInstance = true
+				Expect(ValidateEnv(env)).To(Equal(ErrorAdoptionWithMultiInstance))
+			})
+
+			It("should throw error when the service selector is invalid or selects all services", func() {
+				env := EnvVariables{AppGwName: "appgw", ServiceSelector: "app-gateway=true, tier in (frontend)"}
+				Expect(ValidateEnv(env)).To(BeNil())
+				for _, selector := range []string{"=true", " "} {
+					env.ServiceSelector = selector
+					Expect(ValidateEnv(env)).To(Equal(ErrorInvalidServiceSelector), selector)
+				}
+			})
+		})
+
 		Context("Test ValidateEnv when APPGW_ENABLE_DEPLOY is TRUE", func() {
 			It("should throw error when applicationGatewayName is missing when APPGW_ENABLE_DEPLOY is TRUE", func() {
 				env := EnvVariables{
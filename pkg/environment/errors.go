@@ -25,4 +25,43 @@ var (
 		"AGIC requires APPGW_SUBNET_PREFIX (helm var name: appgw.subnetPrefix) or APPGW_SUBNET_ID (helm var name: appgw.subnetID) of an existing subnet. " +
 		"If subnetPrefix is specified, AGIC will look up a subnet with matching address prefix in the AKS cluster vnet. " +
 		"If a subnet is not found, then a new subnet will be created. This will be used to deploy the Application Gateway (ENVT004)")
+
+	// ErrorInvalidApplicationGatewayID is an error.
+	ErrorInvalidApplicationGatewayID = errors.New("APPGW_RESOURCE_ID (helm var name: .appgw.applicationGatewayID) is not the resource ID of an Application Gateway; " +
+		"expected /subscriptions/<subscription>/resourceGroups/<resource group>/providers/Microsoft.Network/applicationGateways/<name> (ENVT005)")
+
+	// ErrorMissingGatewayHealthTargetURL is an error.
+	ErrorMissingGatewayHealthTargetURL = errors.New("Missing required Environment variables: " +
+		"APPGW_GATEWAY_HEALTH_PATH (helm var name: appgw.gatewayHealth.path) requires APPGW_GATEWAY_HEALTH_TARGET_URL (helm var name: appgw.gatewayHealth.targetURL), " +
+		"the URL requests for the path are redirected to (ENVT006)")
+
+	// ErrorMissingStagingResourceID is an error.
+	ErrorMissingStagingResourceID = errors.New("Missing required Environment variables: " +
+		"APPGW_STAGING_PROBE_URL (helm var name: appgw.staging.probeURL) requires APPGW_STAGING_RESOURCE_ID (helm var name: appgw.staging.resourceID), " +
+		"the staging App Gateway, which serves the URL (ENVT007)")
+
+	// ErrorStagingIsTheAppGateway is an error.
+	ErrorStagingIsTheAppGateway = errors.New("APPGW_STAGING_RESOURCE_ID (helm var name: appgw.staging.resourceID) is the App Gateway AGIC manages; " +
+		"the staging App Gateway must be another one (ENVT008)")
+
+	// ErrorInvalidHTTPListenerPort is an error.
+	ErrorInvalidHTTPListenerPort = errors.New("APPGW_HTTP_LISTENER_PORT (helm var name: appgw.httpListenerPort) must be a port between 1 and 65199, other than 443; " +
+		"App Gateway reserves the ports from 65200 and 443 serves the HTTPS listeners (ENVT009)")
+
+	// ErrorInvalidServiceSelector is an error.
+	ErrorInvalidServiceSelector = errors.New("APPGW_SERVICE_SELECTOR (helm var name: kubernetes.serviceSelector) must be a label selector " +
+		"with at least one requirement, such as app-gateway=true (ENVT010)")
+
+	// ErrorAdoptionWithMultiInstance is an error.
+	ErrorAdoptionWithMultiInstance = errors.New("APPGW_ADOPT_EXISTING_CONFIG (helm var name: appgw.adoptExistingConfig) can not be used with " +
+		"APPGW_ENABLE_MULTI_INSTANCE (helm var name: appgw.multiInstance); an instance would adopt the objects of the other instances (ENVT011)")
+
+	// ErrorMissingConfigNamePrefix is an error.
+	ErrorMissingConfigNamePrefix = errors.New("Missing required Environment variables: " +
+		"APPGW_ENABLE_MULTI_INSTANCE (helm var name: appgw.multiInstance) requires APPGW_CONFIG_NAME_PREFIX (helm var name: appgw.configNamePrefix), " +
+		"which tells the objects of the instance from those of the other instances (ENVT012)")
+
+	// ErrorStrictHeaderHandlingNotSupported is an error.
+	ErrorStrictHeaderHandlingNotSupported = errors.New("APPGW_STRICT_HEADER_HANDLING (helm var name: appgw.strictHeaderHandling) is not supported: " +
+		"the App Gateway API version AGIC uses (2019-09-01) has no setting to reject malformed or oversized headers (ENVT013)")
 )
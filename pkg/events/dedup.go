@@ -0,0 +1,131 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package events
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+)
+
+// GetEventDedupInterval returns the interval set with APPGW_EVENT_DEDUP_INTERVAL_SECONDS, or 0 when it is not set.
+func GetEventDedupInterval(env environment.EnvVariables) time.Duration {
+	if env.EventDedupInterval == "" {
+		return 0
+	}
+	seconds, err := strconv.Atoi(env.EventDedupInterval)
+	if err != nil {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+type dedupKey struct {
+	object    string
+	eventType string
+	reason    string
+	message   string
+}
+
+type dedupEntry struct {
+	reportedAt time.Time
+	lastSeen   time.Time
+	repeated   int
+}
+
+// DedupRecorder coalesces the events AGIC emits on every reconcile, such as a missing secret: an event with the same
+// object, reason and message as the one recorded within the interval is not recorded again, but counted. Once the
+// interval ends, the next such event is recorded with the number of times it was seen since it was last recorded.
+// Events with other messages are coalesced apart from it, so alternating messages are each recorded once per interval.
+type DedupRecorder struct {
+	record.EventRecorder
+	sync.Mutex
+	interval  time.Duration
+	now       func() time.Time
+	seen      map[dedupKey]*dedupEntry
+	lastPurge time.Time
+}
+
+// NewDedupRecorder creates a new DedupRecorder recording the events through the given recorder.
+func NewDedupRecorder(recorder record.EventRecorder, interval time.Duration) *DedupRecorder {
+	return &DedupRecorder{
+		EventRecorder: recorder,
+		interval:      interval,
+		now:           time.Now,
+		seen:          make(map[dedupKey]*dedupEntry),
+	}
+}
+
+// Event records the event, unless the same event was recorded within the interval.
+func (r *DedupRecorder) Event(object runtime.Object, eventType, reason, message string) {
+	if message, record := r.coalesce(object, eventType, reason, message); record {
+		r.EventRecorder.Event(object, eventType, reason, message)
+	}
+}
+
+// Eventf is just like Event, but with Sprintf for the message.
+func (r *DedupRecorder) Eventf(object runtime.Object, eventType, reason, messageFmt string, args ...interface{}) {
+	r.Event(object, eventType, reason, fmt.Sprintf(messageFmt, args...))
+}
+
+// coalesce returns the message to record, and false when the event is a repeat within the interval.
+func (r *DedupRecorder) coalesce(object runtime.Object, eventType, reason, message string) (string, bool) {
+	r.Lock()
+	defer r.Unlock()
+	now := r.now()
+	r.purge(now)
+
+	key := dedupKey{object: objectKey(object), eventType: eventType, reason: reason, message: message}
+	entry, exists := r.seen[key]
+	if exists {
+		entry.lastSeen = now
+		if now.Sub(entry.reportedAt) < r.interval {
+			entry.repeated++
+			glog.V(5).Infof("[events] Event %s on %s repeated %d times since %s", reason, key.object, entry.repeated, entry.reportedAt.Format(time.RFC3339))
+			return "", false
+		}
+		if entry.repeated > 0 {
+			message = fmt.Sprintf("%s (seen %d times since %s)", message, entry.repeated+1, entry.reportedAt.UTC().Format(time.RFC3339))
+		}
+	} else {
+		entry = &dedupEntry{}
+		r.seen[key] = entry
+	}
+	entry.reportedAt = now
+	entry.lastSeen = now
+	entry.repeated = 0
+	return message, true
+}
+
+// purge forgets the events not seen within the interval, once per interval.
+func (r *DedupRecorder) purge(now time.Time) {
+	if now.Sub(r.lastPurge) < r.interval {
+		return
+	}
+	r.lastPurge = now
+	for key, entry := range r.seen {
+		if now.Sub(entry.lastSeen) >= r.interval {
+			delete(r.seen, key)
+		}
+	}
+}
+
+// objectKey identifies the object of an event.
+func objectKey(object runtime.Object) string {
+	accessor, err := meta.Accessor(object)
+	if err != nil {
+		return fmt.Sprintf("%T", object)
+	}
+	return fmt.Sprintf("%T/%s/%s/%s", object, accessor.GetNamespace(), accessor.GetName(), accessor.GetUID())
+}
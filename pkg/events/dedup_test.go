@@ -0,0 +1,101 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package events
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+)
+
+var _ = Describe("deduplicating events", func() {
+	const interval = 5 * time.Minute
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	var fake *record.FakeRecorder
+	var recorder *DedupRecorder
+	var now time.Time
+	frontend := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "frontend", UID: "1"}}
+	backend := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "backend", UID: "2"}}
+
+	BeforeEach(func() {
+		fake = record.NewFakeRecorder(10)
+		recorder = NewDedupRecorder(fake, interval)
+		now = start
+		recorder.now = func() time.Time { return now }
+	})
+
+	It("coalesces repeated identical events within the interval and records the count after it", func() {
+		for i := 0; i < 3; i++ {
+			Warning(recorder, frontend, ReasonSecretNotFound, "Secret not found")
+			now = now.Add(time.Minute)
+		}
+		Expect(fake.Events).To(Receive(Equal("Warning SecretNotFound Secret not found")))
+		Expect(fake.Events).ToNot(Receive())
+
+		now = start.Add(interval)
+		Warning(recorder, frontend, ReasonSecretNotFound, "Secret not found")
+		Expect(fake.Events).To(Receive(Equal("Warning SecretNotFound Secret not found (seen 3 times since 2020-01-01T00:00:00Z)")))
+
+		// The count starts over once recorded.
+		now = now.Add(time.Minute)
+		Warning(recorder, frontend, ReasonSecretNotFound, "Secret not found")
+		Expect(fake.Events).ToNot(Receive())
+	})
+
+	It("records an event with another message, reason, type or object right away", func() {
+		Warning(recorder, frontend, ReasonSecretNotFound, "Secret not found")
+		Warning(recorder, frontend, ReasonSecretNotFound, "Another secret not found")
+		Warning(recorder, frontend, ReasonInvalidSecret, "Another secret not found")
+		Normal(recorder, frontend, ReasonInvalidSecret, "Another secret not found")
+		Warning(recorder, backend, ReasonSecretNotFound, "Secret not found")
+		Expect(fake.Events).To(HaveLen(5))
+	})
+
+	It("coalesces interleaved messages on the same object and reason apart", func() {
+		for i := 0; i < 3; i++ {
+			Warning(recorder, frontend, ReasonSecretNotFound, "Secret tls not found")
+			Warning(recorder, frontend, ReasonSecretNotFound, "Secret auth not found")
+			now = now.Add(time.Minute)
+		}
+		Expect(fake.Events).To(Receive(Equal("Warning SecretNotFound Secret tls not found")))
+		Expect(fake.Events).To(Receive(Equal("Warning SecretNotFound Secret auth not found")))
+		Expect(fake.Events).ToNot(Receive())
+
+		now = start.Add(interval)
+		Warning(recorder, frontend, ReasonSecretNotFound, "Secret tls not found")
+		Warning(recorder, frontend, ReasonSecretNotFound, "Secret auth not found")
+		Expect(fake.Events).To(Receive(Equal("Warning SecretNotFound Secret tls not found (seen 3 times since 2020-01-01T00:00:00Z)")))
+		Expect(fake.Events).To(Receive(Equal("Warning SecretNotFound Secret auth not found (seen 3 times since 2020-01-01T00:00:00Z)")))
+	})
+
+	It("records the event without a count when it was not repeated", func() {
+		recorder.Eventf(frontend, v1.EventTypeWarning, string(ReasonSecretNotFound), "Secret %s not found", "tls")
+		now = now.Add(interval)
+		recorder.Eventf(frontend, v1.EventTypeWarning, string(ReasonSecretNotFound), "Secret %s not found", "tls")
+		Expect(fake.Events).To(Receive(Equal("Warning SecretNotFound Secret tls not found")))
+		Expect(fake.Events).To(Receive(Equal("Warning SecretNotFound Secret tls not found")))
+	})
+
+	It("forgets the events not seen within the interval", func() {
+		Warning(recorder, frontend, ReasonSecretNotFound, "Secret not found")
+		now = now.Add(interval)
+		Warning(recorder, backend, ReasonSecretNotFound, "Secret not found")
+		Expect(recorder.seen).To(HaveLen(1))
+	})
+
+	It("reads the interval from the environment", func() {
+		env := environment.GetFakeEnv()
+		Expect(GetEventDedupInterval(env)).To(BeZero())
+		env.EventDedupInterval = "300"
+		Expect(GetEventDedupInterval(env)).To(Equal(interval))
+	})
+})
@@ -0,0 +1,45 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package events
+
+import (
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+)
+
+// Warning records a Warning event on the object, with one of the reasons above and a message for humans.
+func Warning(recorder record.EventRecorder, object runtime.Object, reason Reason, message string) {
+	recorder.Event(involvedObject(object), v1.EventTypeWarning, string(reason), message)
+}
+
+// Normal records a Normal event on the object, with one of the reasons above and a message for humans.
+func Normal(recorder record.EventRecorder, object runtime.Object, reason Reason, message string) {
+	recorder.Event(involvedObject(object), v1.EventTypeNormal, string(reason), message)
+}
+
+// involvedObject returns the object the event is recorded against: the HTTPRoute for an ingress translated from one,
+// as no such ingress exists in the cluster, or else the object itself.
+func involvedObject(object runtime.Object) runtime.Object {
+	ingress, ok := object.(*v1beta1.Ingress)
+	if !ok || ingress == nil {
+		return object
+	}
+	apiVersion := annotations.HTTPRouteAPIVersion(ingress)
+	if apiVersion == "" {
+		return object
+	}
+	return &v1.ObjectReference{
+		APIVersion: apiVersion,
+		Kind:       "HTTPRoute",
+		Namespace:  ingress.Namespace,
+		Name:       annotations.HTTPRouteName(ingress),
+		UID:        ingress.UID,
+	}
+}
@@ -0,0 +1,65 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package events
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+)
+
+// objectRecorder keeps the objects the events are recorded against.
+type objectRecorder struct {
+	record.FakeRecorder
+	objects []runtime.Object
+}
+
+func (r *objectRecorder) Event(object runtime.Object, eventType, reason, message string) {
+	r.objects = append(r.objects, object)
+}
+
+var _ = Describe("recording events", func() {
+	var recorder *record.FakeRecorder
+
+	BeforeEach(func() {
+		recorder = record.NewFakeRecorder(10)
+	})
+
+	It("records a warning with the reason and the message", func() {
+		Warning(recorder, &v1.Pod{}, ReasonInvalidAnnotation, "Invalid value for the annotation")
+		Expect(recorder.Events).To(Receive(Equal("Warning InvalidAnnotation Invalid value for the annotation")))
+	})
+
+	It("records a normal event with the reason and the message", func() {
+		Normal(recorder, &v1.Pod{}, ReasonAppGwConfigApplied, "Applied App Gateway config")
+		Expect(recorder.Events).To(Receive(Equal("Normal AppGwConfigApplied Applied App Gateway config")))
+	})
+
+	It("records the events of an ingress translated from an HTTPRoute against the HTTPRoute", func() {
+		objects := &objectRecorder{}
+		ingress := &v1beta1.Ingress{ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "httproute-web", UID: "1"}}
+		Warning(objects, ingress, ReasonInvalidAnnotation, "Invalid value for the annotation")
+
+		ingress.Annotations = map[string]string{annotations.HTTPRouteKey: "gateway.networking.k8s.io/v1"}
+		Warning(objects, ingress, ReasonInvalidAnnotation, "Invalid value for the annotation")
+
+		Expect(objects.objects).To(HaveLen(2))
+		Expect(objects.objects[0]).To(BeIdenticalTo(ingress))
+		Expect(objects.objects[1]).To(Equal(&v1.ObjectReference{
+			APIVersion: "gateway.networking.k8s.io/v1",
+			Kind:       "HTTPRoute",
+			Namespace:  "shop",
+			Name:       "web",
+			UID:        "1",
+		}))
+	})
+})
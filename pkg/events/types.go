@@ -5,58 +5,123 @@
 
 package events
 
+// Reason is the machine-readable reason of the Kubernetes events AGIC emits; the message of the event is meant for
+// humans. The reasons are part of the interface of AGIC: tools key off them, so a reason is never renamed.
+// See docs/features/events.md.
+type Reason string
+
 const (
 	// ReasonBackendPortTargetMatch is a reason for an event to be emitted.
-	ReasonBackendPortTargetMatch = "BackendPortTargetMatch"
+	ReasonBackendPortTargetMatch Reason = "BackendPortTargetMatch"
 
 	// ReasonEndpointsEmpty is a reason for an event to be emitted.
-	ReasonEndpointsEmpty = "EndpointsEmpty"
+	ReasonEndpointsEmpty Reason = "EndpointsEmpty"
 
 	// ReasonIngressServiceTargetMatch is a reason for an event to be emitted.
-	ReasonIngressServiceTargetMatch = "IngressServiceTargetMatch"
+	ReasonIngressServiceTargetMatch Reason = "IngressServiceTargetMatch"
 
 	// ReasonSecretNotFound is a reason for an event to be emitted.
-	ReasonSecretNotFound = "SecretNotFound"
+	ReasonSecretNotFound Reason = "SecretNotFound"
+
+	// ReasonInvalidSecret is a reason for an event to be emitted.
+	ReasonInvalidSecret Reason = "InvalidSecret"
 
 	// ReasonServiceNotFound is a reason for an event to be emitted.
-	ReasonServiceNotFound = "ServiceNotFound"
+	ReasonServiceNotFound Reason = "ServiceNotFound"
 
 	// ReasonPortResolutionError is a reason for an event to be emitted.
-	ReasonPortResolutionError = "PortResolutionError"
+	ReasonPortResolutionError Reason = "PortResolutionError"
 
 	// ReasonNoPrivateIPError is a reason for an event to be emitted.
-	ReasonNoPrivateIPError = "NoPrivateIP"
+	ReasonNoPrivateIPError Reason = "NoPrivateIP"
 
 	// ReasonRedirectWithNoTLS is a reason for an event to be emitted.
-	ReasonRedirectWithNoTLS = "RedirectWithNoTLS"
+	ReasonRedirectWithNoTLS Reason = "RedirectWithNoTLS"
 
 	// ReasonUnableToUpdateIngressStatus is a reason for an event to be emitted.
-	ReasonUnableToUpdateIngressStatus = "UnableToUpdateIngressStatus"
+	ReasonUnableToUpdateIngressStatus Reason = "UnableToUpdateIngressStatus"
+
+	// ReasonUnableToAnnotateIngress is a reason for an event to be emitted.
+	ReasonUnableToAnnotateIngress Reason = "UnableToAnnotateIngress"
 
 	// ReasonInvalidAnnotation is a reason for an event to be emitted.
-	ReasonInvalidAnnotation = "InvalidAnnotation"
+	ReasonInvalidAnnotation Reason = "InvalidAnnotation"
 
 	// ReasonUnableToFetchAppGw is a reason for an event to be emitted.
-	ReasonUnableToFetchAppGw = "UnableToFetchAppGw"
+	ReasonUnableToFetchAppGw Reason = "UnableToFetchAppGw"
 
 	// ReasonNoValidIngress is a reason for an event to be emitted.
-	ReasonNoValidIngress = "NoValidIngress"
+	ReasonNoValidIngress Reason = "NoValidIngress"
 
 	// ReasonInvalidAppGwConfig is a reason for an event to be emitted.
-	ReasonInvalidAppGwConfig = "InvalidAppGwConfig"
+	ReasonInvalidAppGwConfig Reason = "InvalidAppGwConfig"
 
 	// ReasonFailedApplyingAppGwConfig is a reason for an event to be emitted.
-	ReasonFailedApplyingAppGwConfig = "FailedApplyingAppGwConfig"
+	ReasonFailedApplyingAppGwConfig Reason = "FailedApplyingAppGwConfig"
 
 	// ReasonFailedDeployingAppGw is a reason for an event to be emitted.
-	ReasonFailedDeployingAppGw = "FailedDeployingAppGw"
+	ReasonFailedDeployingAppGw Reason = "FailedDeployingAppGw"
 
 	// ReasonValidatonError is a reason for an event to be emitted.
-	ReasonValidatonError = "FailedValidatonError"
+	ReasonValidatonError Reason = "FailedValidatonError"
+
+	// ReasonInvalidExternalName is a reason for an event to be emitted.
+	ReasonInvalidExternalName Reason = "InvalidExternalName"
+
+	// ReasonConflictingIngress is a reason for an event to be emitted.
+	ReasonConflictingIngress Reason = "ConflictingIngress"
+
+	// ReasonDisallowedHost is a reason for an event to be emitted.
+	ReasonDisallowedHost Reason = "DisallowedHost"
+
+	// ReasonHTTPSOnlyPolicy is a reason for an event to be emitted.
+	ReasonHTTPSOnlyPolicy Reason = "HTTPSOnlyPolicy"
+
+	// ReasonServiceOutOfScope is a reason for an event to be emitted.
+	ReasonServiceOutOfScope Reason = "ServiceOutOfScope"
+
+	// ReasonReconcilePaused is a reason for an event to be emitted.
+	ReasonReconcilePaused Reason = "ReconcilePaused"
+
+	// ReasonInvalidRewrite is a reason for an event to be emitted.
+	ReasonInvalidRewrite Reason = "InvalidRewrite"
+
+	// ReasonAppGwConfigApplied is a reason for an event to be emitted.
+	ReasonAppGwConfigApplied Reason = "AppGwConfigApplied"
+
+	// ReasonAppGwConfigRolledBack is a reason for an event to be emitted.
+	ReasonAppGwConfigRolledBack Reason = "AppGwConfigRolledBack"
+
+	// ReasonCertificatePending is a reason for an event to be emitted.
+	ReasonCertificatePending Reason = "CertificatePending"
+
+	// ReasonInvalidIngresses is a reason for an event to be emitted.
+	ReasonInvalidIngresses Reason = "InvalidIngresses"
+
+	// ReasonSubnetFull is a reason for an event to be emitted.
+	ReasonSubnetFull Reason = "SubnetFull"
 
 	// ReasonARMAuthFailure is a reason for an event to be emitted.
-	ReasonARMAuthFailure = "ARMAuthFailure"
+	ReasonARMAuthFailure Reason = "ARMAuthFailure"
+
+	// ReasonTLSHostMismatch is a reason for an event to be emitted.
+	ReasonTLSHostMismatch Reason = "TLSHostMismatch"
+
+	// ReasonIngressClassMismatch is a reason for an event to be emitted.
+	ReasonIngressClassMismatch Reason = "IngressClassMismatch"
+
+	// ReasonDanglingReference is a reason for an event to be emitted.
+	ReasonDanglingReference Reason = "DanglingReference"
+
+	// ReasonStagingFailed is a reason for an event to be emitted.
+	ReasonStagingFailed Reason = "StagingFailed"
+
+	// ReasonMissingReadinessProbe is a reason for an event to be emitted.
+	ReasonMissingReadinessProbe Reason = "MissingReadinessProbe"
+
+	// ReasonARMThrottled is a reason for an event to be emitted.
+	ReasonARMThrottled Reason = "ARMThrottled"
 
-	// UnsupportedAppGatewaySKUTier is a reason for an event to be emitted.
-	UnsupportedAppGatewaySKUTier = "UnsupportedAppGatewaySKUTier"
+	// ReasonUnsupportedAppGatewaySKUTier is a reason for an event to be emitted.
+	ReasonUnsupportedAppGatewaySKUTier Reason = "UnsupportedAppGatewaySKUTier"
 )
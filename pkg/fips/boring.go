@@ -0,0 +1,18 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+// +build fips
+
+package fips
+
+import (
+	"crypto/boring"
+	// Restricts TLS connections to ARM and the Kubernetes API server to FIPS-approved settings.
+	_ "crypto/tls/fipsonly"
+)
+
+func available() bool {
+	return boring.Enabled()
+}
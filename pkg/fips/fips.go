@@ -0,0 +1,40 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+// Package fips tracks whether AGIC handles certificates with FIPS-validated crypto only.
+// FIPS mode requires a binary built with the "fips" build tag using a BoringCrypto enabled Go toolchain:
+//   GOEXPERIMENT=boringcrypto go build -tags fips ./cmd/appgw-ingress
+package fips
+
+import (
+	"errors"
+
+	"github.com/golang/glog"
+)
+
+// ErrFIPSUnavailable is an error message.
+var ErrFIPSUnavailable = errors.New("FIPS mode requested but this binary was not built with FIPS-validated crypto (FIPS001)")
+
+var enabled bool
+
+// Enable turns on FIPS mode; returns an error when the binary does not use FIPS-validated crypto.
+func Enable() error {
+	if !available() {
+		return ErrFIPSUnavailable
+	}
+	enabled = true
+	glog.Info("FIPS mode enabled; using FIPS-validated crypto")
+	return nil
+}
+
+// Enabled determines whether FIPS mode has been turned on.
+func Enabled() bool {
+	return enabled
+}
+
+// Available determines whether the binary uses FIPS-validated crypto.
+func Available() bool {
+	return available()
+}
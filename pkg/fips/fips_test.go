@@ -0,0 +1,40 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package fips
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestFips(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "FIPS Suite")
+}
+
+var _ = Describe("Test FIPS mode", func() {
+	AfterEach(func() {
+		enabled = false
+	})
+
+	It("should fail loudly when FIPS-validated crypto is not available", func() {
+		if Available() {
+			Skip("binary built with FIPS-validated crypto")
+		}
+		Expect(Enable()).To(Equal(ErrFIPSUnavailable))
+		Expect(Enabled()).To(BeFalse())
+	})
+
+	It("should enable FIPS mode when FIPS-validated crypto is available", func() {
+		if !Available() {
+			Skip("binary built without FIPS-validated crypto")
+		}
+		Expect(Enable()).To(Succeed())
+		Expect(Enabled()).To(BeTrue())
+	})
+})
@@ -0,0 +1,48 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// Status is the state of the AGIC instance reported by the status endpoint.
+// It must not contain secrets: the endpoint is not authenticated.
+type Status struct {
+	Version            string     `json:"version"`
+	GitCommit          string     `json:"gitCommit"`
+	BuildDate          string     `json:"buildDate"`
+	SubscriptionID     string     `json:"subscriptionId"`
+	ResourceGroup      string     `json:"resourceGroup"`
+	AppGwName          string     `json:"applicationGatewayName"`
+	LastSuccessfulSync *time.Time `json:"lastSuccessfulSync,omitempty"`
+	Live               bool       `json:"live"`
+	Ready              bool       `json:"ready"`
+	Leader             bool       `json:"leader"`
+}
+
+// StatusReporter is the interface for the status endpoint
+type StatusReporter interface {
+	Status() Status
+}
+
+// StatusHandler returns the http handler serving the status as JSON
+func StatusHandler(reporter StatusReporter) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, err := json.Marshal(reporter.Status())
+		if err != nil {
+			glog.Error("Unable to serialize status: ", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	})
+}
@@ -7,6 +7,7 @@ package httpserver
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"time"
@@ -46,12 +47,39 @@ func NewHTTPServer(controller *controller.AppGwIngressController, metricStore me
 			Handler: NewHealthMux(map[string]http.Handler{
 				"/health/ready": health.ReadinessHandler(controller),
 				"/health/alive": health.LivenessHandler(controller),
+				"/status":       health.StatusHandler(controller),
 				"/metrics":      metricStore.Handler(),
 			}),
 		},
 	}
 }
 
+// NewDebugServer creates a server dumping the caches of AGIC and the last App Gateway config it generated.
+// It is not protected by authentication; bind it to localhost and use kubectl port-forward to reach it.
+func NewDebugServer(controller *controller.AppGwIngressController, address string) HTTPServer {
+	return &httpServer{
+		server: &http.Server{
+			Addr: address,
+			Handler: NewHealthMux(map[string]http.Handler{
+				"/debug/snapshot": debugSnapshotHandler(controller),
+			}),
+		},
+	}
+}
+
+func debugSnapshotHandler(controller *controller.AppGwIngressController) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, err := json.MarshalIndent(controller.DebugSnapshot(), "", "  ")
+		if err != nil {
+			glog.Error("Unable to serialize debug snapshot: ", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	})
+}
+
 func (s *httpServer) Start() {
 	go func() {
 		glog.Infof("Starting API Server on %s", s.server.Addr)
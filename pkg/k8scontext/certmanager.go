@@ -0,0 +1,101 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package k8scontext
+
+import (
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+)
+
+// CertificateGVRs are the resources of the cert-manager Certificates in order of preference.
+var CertificateGVRs = []schema.GroupVersionResource{
+	{Group: "cert-manager.io", Version: "v1", Resource: "certificates"},
+	{Group: "cert-manager.io", Version: "v1alpha2", Resource: "certificates"},
+	{Group: "certmanager.k8s.io", Version: "v1alpha1", Resource: "certificates"},
+}
+
+// watchCertificates creates the informer for the Certificates of cert-manager. Without the cert-manager CRDs in the
+// cluster no informer is created and TLS secrets are bound to listeners as soon as they hold a certificate.
+func (c *Context) watchCertificates() []cache.SharedInformer {
+	if c.dynamicClient == nil {
+		glog.Warning("[k8scontext] No dynamic client; Certificates of cert-manager will not be watched")
+		return nil
+	}
+	var certificateGVR *schema.GroupVersionResource
+	for idx := range CertificateGVRs {
+		if c.isResourceServed(CertificateGVRs[idx]) {
+			certificateGVR = &CertificateGVRs[idx]
+			break
+		}
+	}
+	if certificateGVR == nil {
+		glog.Warning("[k8scontext] Certificates are not served by the API server; the cert-manager CRDs may not be installed")
+		return nil
+	}
+
+	informerFactory := dynamicinformer.NewDynamicSharedInformerFactory(c.dynamicClient, 0)
+	certificates := informerFactory.ForResource(*certificateGVR).Informer()
+	certificates.AddEventHandler(c.newDynamicResourceHandler())
+
+	c.informers.Certificate = certificates
+	c.Caches.Certificate = certificates.GetStore()
+	glog.V(1).Infof("[k8scontext] Watching %s", certificateGVR.GroupVersion().WithResource(certificateGVR.Resource))
+	return []cache.SharedInformer{certificates}
+}
+
+// IsCertificatePending tells whether the secret is the secret of a cert-manager Certificate, whose certificate has not
+// been issued yet. Until then the secret may be missing, or hold a temporary certificate, and is not bound to listeners.
+// A Certificate issued before keeps its secret bound while it is renewed.
+func (c *Context) IsCertificatePending(secretKey string) bool {
+	if c.Caches.Certificate == nil {
+		return false
+	}
+	namespace, name, err := cache.SplitMetaNamespaceKey(secretKey)
+	if err != nil {
+		return false
+	}
+
+	pending := false
+	for _, obj := range c.Caches.Certificate.List() {
+		certificate, ok := obj.(*unstructured.Unstructured)
+		if !ok || certificate.GetNamespace() != namespace {
+			continue
+		}
+		if secretName, _, _ := unstructured.NestedString(certificate.Object, "spec", "secretName"); secretName != name {
+			continue
+		}
+		if isCertificateReady(certificate) || isCertificateIssued(certificate) {
+			return false
+		}
+		pending = true
+	}
+	return pending
+}
+
+// isCertificateReady tells whether the Ready condition of the Certificate is true.
+func isCertificateReady(certificate *unstructured.Unstructured) bool {
+	conditions, _, _ := unstructured.NestedSlice(certificate.Object, "status", "conditions")
+	for _, obj := range conditions {
+		condition, ok := obj.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if condition["type"] == "Ready" {
+			return condition["status"] == "True"
+		}
+	}
+	return false
+}
+
+// isCertificateIssued tells whether cert-manager issued a certificate for the Certificate before, which it records
+// with the expiry of the certificate.
+func isCertificateIssued(certificate *unstructured.Unstructured) bool {
+	notAfter, _, _ := unstructured.NestedString(certificate.Object, "status", "notAfter")
+	return notAfter != ""
+}
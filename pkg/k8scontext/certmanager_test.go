@@ -0,0 +1,103 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package k8scontext
+
+import (
+	"time"
+
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	testclient "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/agic_crd_client/clientset/versioned/fake"
+	istioFake "github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/istio_crd_client/clientset/versioned/fake"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/metricstore"
+)
+
+var _ = ginkgo.Describe("cert-manager Certificates", func() {
+	const namespace = "ns"
+
+	var k8sClient *testclient.Clientset
+	var ctxt *Context
+	var stopChannel chan struct{}
+	var env environment.EnvVariables
+
+	newCertificate := func(name, secretName string, status map[string]interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "cert-manager.io/v1",
+			"kind":       "Certificate",
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": namespace,
+			},
+			"spec": map[string]interface{}{
+				"secretName": secretName,
+			},
+			"status": status,
+		}}
+	}
+
+	readyStatus := func(ready string) map[string]interface{} {
+		return map[string]interface{}{
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Ready", "status": ready},
+			},
+		}
+	}
+
+	ginkgo.BeforeEach(func() {
+		stopChannel = make(chan struct{})
+		k8sClient = testclient.NewSimpleClientset()
+		ctxt = NewContext(k8sClient, fake.NewSimpleClientset(), istioFake.NewSimpleClientset(), []string{namespace}, 1000*time.Second, DefaultWorkQueueDepth, metricstore.NewFakeMetricStore())
+		env = environment.GetFakeEnv()
+		env.EnableCertManager = true
+	})
+
+	ginkgo.AfterEach(func() {
+		close(stopChannel)
+	})
+
+	ginkgo.It("tells the secrets of the Certificates which are not issued yet", func() {
+		k8sClient.Fake.Resources = []*metav1.APIResourceList{
+			{
+				GroupVersion: "cert-manager.io/v1",
+				APIResources: []metav1.APIResource{{Name: "certificates", Namespaced: true, Kind: "Certificate"}},
+			},
+		}
+		ctxt.SetDynamicClient(dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(),
+			newCertificate("issuing", "issuing-tls", readyStatus("False")),
+			newCertificate("ready", "ready-tls", readyStatus("True")),
+			newCertificate("renewing", "renewing-tls", map[string]interface{}{
+				"conditions": readyStatus("False")["conditions"],
+				"notAfter":   "2030-01-01T00:00:00Z",
+			}),
+			newCertificate("new", "new-tls", map[string]interface{}{}),
+		))
+		Expect(ctxt.Run(stopChannel, true, env)).ToNot(HaveOccurred())
+
+		Expect(ctxt.IsCertificatePending(namespace + "/issuing-tls")).To(BeTrue())
+		Expect(ctxt.IsCertificatePending(namespace + "/new-tls")).To(BeTrue())
+		Expect(ctxt.IsCertificatePending(namespace + "/ready-tls")).To(BeFalse())
+		Expect(ctxt.IsCertificatePending(namespace + "/renewing-tls")).To(BeFalse())
+		Expect(ctxt.IsCertificatePending(namespace + "/other-tls")).To(BeFalse())
+		Expect(ctxt.IsCertificatePending("other-ns/issuing-tls")).To(BeFalse())
+	})
+
+	ginkgo.It("does not watch Certificates without the cert-manager CRDs", func() {
+		ctxt.SetDynamicClient(dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(),
+			newCertificate("issuing", "issuing-tls", readyStatus("False")),
+		))
+		Expect(ctxt.Run(stopChannel, true, env)).ToNot(HaveOccurred())
+
+		Expect(ctxt.Caches.Certificate).To(BeNil())
+		Expect(ctxt.IsCertificatePending(namespace + "/issuing-tls")).To(BeFalse())
+	})
+})
@@ -0,0 +1,54 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package k8scontext
+
+import (
+	"github.com/golang/glog"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+)
+
+// WriteConfigMapData sets the given keys of the ConfigMap, creating it when it does not exist. The ConfigMap is read
+// again and the write retried when another writer changed, or created, it in the meantime.
+func (c *Context) WriteConfigMapData(namespace, name string, data map[string]string) error {
+	configMaps := c.kubeClient.CoreV1().ConfigMaps(namespace)
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		configMap, err := configMaps.Get(name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			configMap = &v1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: namespace,
+				},
+				Data: data,
+			}
+			_, err = configMaps.Create(configMap)
+			if apierrors.IsAlreadyExists(err) {
+				return apierrors.NewConflict(v1.Resource("configmaps"), name, err)
+			}
+			return err
+		}
+		if err != nil {
+			return err
+		}
+
+		if configMap.Data == nil {
+			configMap.Data = make(map[string]string)
+		}
+		for key, value := range data {
+			configMap.Data[key] = value
+		}
+		_, err = configMaps.Update(configMap)
+		return err
+	})
+	if err != nil {
+		glog.Errorf("[k8scontext] Unable to write ConfigMap %s/%s: %s", namespace, name, err)
+		return ErrorUnableToWriteConfigMap
+	}
+	return nil
+}
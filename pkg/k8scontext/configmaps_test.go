@@ -0,0 +1,81 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package k8scontext
+
+import (
+	"errors"
+	"time"
+
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	testclient "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/agic_crd_client/clientset/versioned/fake"
+	istioFake "github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/istio_crd_client/clientset/versioned/fake"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/metricstore"
+)
+
+var _ = ginkgo.Describe("writing a ConfigMap", func() {
+	const namespace = "agic"
+	const name = "agic-summary"
+
+	var k8sClient *testclient.Clientset
+	var ctxt *Context
+
+	getData := func() map[string]string {
+		configMap, err := k8sClient.CoreV1().ConfigMaps(namespace).Get(name, metav1.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		return configMap.Data
+	}
+
+	ginkgo.BeforeEach(func() {
+		k8sClient = testclient.NewSimpleClientset()
+		ctxt = NewContext(k8sClient, fake.NewSimpleClientset(), istioFake.NewSimpleClientset(), nil, time.Second, DefaultWorkQueueDepth, metricstore.NewFakeMetricStore())
+	})
+
+	ginkgo.It("creates the ConfigMap", func() {
+		Expect(ctxt.WriteConfigMapData(namespace, name, map[string]string{"summary.json": "{}"})).To(Succeed())
+		Expect(getData()).To(Equal(map[string]string{"summary.json": "{}"}))
+	})
+
+	ginkgo.It("updates the keys and keeps the other keys of the ConfigMap", func() {
+		_, _ = k8sClient.CoreV1().ConfigMaps(namespace).Create(&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Data:       map[string]string{"summary.json": "{}", "other": "value"},
+		})
+		Expect(ctxt.WriteConfigMapData(namespace, name, map[string]string{"summary.json": `{"succeeded":true}`})).To(Succeed())
+		Expect(getData()).To(Equal(map[string]string{"summary.json": `{"succeeded":true}`, "other": "value"}))
+	})
+
+	ginkgo.It("retries the write on a conflict", func() {
+		_, _ = k8sClient.CoreV1().ConfigMaps(namespace).Create(&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		})
+		conflicts := 2
+		k8sClient.PrependReactor("update", "configmaps", func(k8stesting.Action) (bool, runtime.Object, error) {
+			if conflicts == 0 {
+				return false, nil, nil
+			}
+			conflicts--
+			return true, nil, apierrors.NewConflict(v1.Resource("configmaps"), name, errors.New("the object has been modified"))
+		})
+		Expect(ctxt.WriteConfigMapData(namespace, name, map[string]string{"summary.json": "{}"})).To(Succeed())
+		Expect(conflicts).To(Equal(0))
+		Expect(getData()).To(Equal(map[string]string{"summary.json": "{}"}))
+	})
+
+	ginkgo.It("fails when the write is refused", func() {
+		k8sClient.PrependReactor("create", "configmaps", func(k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, apierrors.NewForbidden(v1.Resource("configmaps"), name, errors.New("forbidden"))
+		})
+		Expect(ctxt.WriteConfigMapData(namespace, name, map[string]string{"summary.json": "{}"})).To(Equal(ErrorUnableToWriteConfigMap))
+	})
+})
@@ -6,9 +6,11 @@
 package k8scontext
 
 import (
+	"encoding/json"
 	"fmt"
 	"sort"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	mapset "github.com/deckarep/golang-set"
@@ -17,11 +19,14 @@ import (
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/api/extensions/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	rewritev1 "github.com/Azure/application-gateway-kubernetes-ingress/pkg/apis/azureapplicationgatewayrewrite/v1"
 	prohibitedv1 "github.com/Azure/application-gateway-kubernetes-ingress/pkg/apis/azureingressprohibitedtarget/v1"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/azure"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/agic_crd_client/clientset/versioned"
@@ -36,7 +41,6 @@ import (
 )
 
 const providerPrefix = "azure://"
-const workBuffer = 1024
 
 var namespacesToIgnore = map[string]interface{}{
 	"kube-system": nil,
@@ -44,36 +48,44 @@ var namespacesToIgnore = map[string]interface{}{
 }
 
 // NewContext creates a context based on a Kubernetes client instance.
-func NewContext(kubeClient kubernetes.Interface, crdClient versioned.Interface, istioCrdClient istio_versioned.Interface, namespaces []string, resyncPeriod time.Duration, metricStore metricstore.MetricStore) *Context {
+func NewContext(kubeClient kubernetes.Interface, crdClient versioned.Interface, istioCrdClient istio_versioned.Interface, namespaces []string, resyncPeriod time.Duration, workQueueDepth int, metricStore metricstore.MetricStore) *Context {
 	informerFactory := informers.NewSharedInformerFactory(kubeClient, resyncPeriod)
 	crdInformerFactory := externalversions.NewSharedInformerFactory(crdClient, resyncPeriod)
 	istioCrdInformerFactory := istio_externalversions.NewSharedInformerFactoryWithOptions(istioCrdClient, resyncPeriod)
 
+	// The services and their endpoints are listed with the service selector, which Run sets before the informers list.
+	var context *Context
+	serviceInformerFactory := informers.NewSharedInformerFactoryWithOptions(kubeClient, resyncPeriod, informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+		options.LabelSelector = context.serviceSelector
+	}))
+
 	informerCollection := InformerCollection{
-		Endpoints: informerFactory.Core().V1().Endpoints().Informer(),
-		Ingress:   informerFactory.Extensions().V1beta1().Ingresses().Informer(),
+		Endpoints: serviceInformerFactory.Core().V1().Endpoints().Informer(),
+		Nodes:     informerFactory.Core().V1().Nodes().Informer(),
 		Pods:      informerFactory.Core().V1().Pods().Informer(),
 		Secret:    informerFactory.Core().V1().Secrets().Informer(),
-		Service:   informerFactory.Core().V1().Services().Informer(),
+		Service:   serviceInformerFactory.Core().V1().Services().Informer(),
 
-		AzureIngressProhibitedTarget: crdInformerFactory.Azureingressprohibitedtargets().V1().AzureIngressProhibitedTargets().Informer(),
+		AzureIngressProhibitedTarget:   crdInformerFactory.Azureingressprohibitedtargets().V1().AzureIngressProhibitedTargets().Informer(),
+		AzureApplicationGatewayRewrite: crdInformerFactory.Azureapplicationgatewayrewrites().V1().AzureApplicationGatewayRewrites().Informer(),
 
 		IstioGateway:        istioCrdInformerFactory.Networking().V1alpha3().Gateways().Informer(),
 		IstioVirtualService: istioCrdInformerFactory.Networking().V1alpha3().VirtualServices().Informer(),
 	}
 
 	cacheCollection := CacheCollection{
-		Endpoints:                    informerCollection.Endpoints.GetStore(),
-		Ingress:                      informerCollection.Ingress.GetStore(),
-		Pods:                         informerCollection.Pods.GetStore(),
-		Secret:                       informerCollection.Secret.GetStore(),
-		Service:                      informerCollection.Service.GetStore(),
-		AzureIngressProhibitedTarget: informerCollection.AzureIngressProhibitedTarget.GetStore(),
-		IstioGateway:                 informerCollection.IstioGateway.GetStore(),
-		IstioVirtualService:          informerCollection.IstioVirtualService.GetStore(),
-	}
-
-	context := &Context{
+		Endpoints:                      informerCollection.Endpoints.GetStore(),
+		Nodes:                          informerCollection.Nodes.GetStore(),
+		Pods:                           informerCollection.Pods.GetStore(),
+		Secret:                         informerCollection.Secret.GetStore(),
+		Service:                        informerCollection.Service.GetStore(),
+		AzureIngressProhibitedTarget:   informerCollection.AzureIngressProhibitedTarget.GetStore(),
+		AzureApplicationGatewayRewrite: informerCollection.AzureApplicationGatewayRewrite.GetStore(),
+		IstioGateway:                   informerCollection.IstioGateway.GetStore(),
+		IstioVirtualService:            informerCollection.IstioVirtualService.GetStore(),
+	}
+
+	context = &Context{
 		kubeClient:     kubeClient,
 		crdClient:      crdClient,
 		istioCrdClient: istioCrdClient,
@@ -82,17 +94,21 @@ func NewContext(kubeClient kubernetes.Interface, crdClient versioned.Interface,
 		ingressSecretsMap:      utils.NewThreadsafeMultimap(),
 		Caches:                 &cacheCollection,
 		CertificateSecretStore: NewSecretStore(),
-		Work:                   make(chan events.Event, workBuffer),
+		Work:                   make(chan events.Event, workQueueDepth),
 		CacheSynced:            make(chan interface{}),
 
 		metricStore: metricStore,
 		namespaces:  make(map[string]interface{}),
+		ingressGVR:  ExtensionsIngressGVR,
 	}
 
 	for _, ns := range namespaces {
 		context.namespaces[ns] = nil
 	}
 
+	informerCollection.Ingress = context.newIngressInformer(resyncPeriod)
+	cacheCollection.Ingress = informerCollection.Ingress.GetStore()
+
 	h := handlers{context}
 
 	resourceHandler := cache.ResourceEventHandlerFuncs{
@@ -113,13 +129,24 @@ func NewContext(kubeClient kubernetes.Interface, crdClient versioned.Interface,
 		DeleteFunc: h.secretDelete,
 	}
 
+	serviceResourceHandler := cache.ResourceEventHandlerFuncs{
+		AddFunc:    h.addFunc,
+		UpdateFunc: h.serviceUpdate,
+		DeleteFunc: h.deleteFunc,
+	}
+
 	// Register event handlers.
 	informerCollection.Endpoints.AddEventHandler(resourceHandler)
 	informerCollection.Ingress.AddEventHandler(ingressResourceHandler)
-	informerCollection.Pods.AddEventHandler(resourceHandler)
+	informerCollection.Pods.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    h.podAdd,
+		UpdateFunc: h.podUpdate,
+		DeleteFunc: h.podDelete,
+	})
 	informerCollection.Secret.AddEventHandler(secretResourceHandler)
-	informerCollection.Service.AddEventHandler(resourceHandler)
+	informerCollection.Service.AddEventHandler(serviceResourceHandler)
 	informerCollection.AzureIngressProhibitedTarget.AddEventHandler(resourceHandler)
+	informerCollection.AzureApplicationGatewayRewrite.AddEventHandler(resourceHandler)
 
 	return context
 }
@@ -127,15 +154,16 @@ func NewContext(kubeClient kubernetes.Interface, crdClient versioned.Interface,
 // Run executes informer collection.
 func (c *Context) Run(stopChannel chan struct{}, omitCRDs bool, envVariables environment.EnvVariables) error {
 	glog.V(1).Infoln("k8s context run started")
-	var hasSynced []cache.InformerSynced
+	hasSynced := make(map[string][]cache.InformerSynced)
 
 	if c.informers == nil {
 		return ErrorInformersNotInitialized
 	}
 	crds := map[cache.SharedInformer]interface{}{
-		c.informers.AzureIngressProhibitedTarget: nil,
-		c.informers.IstioGateway:                 nil,
-		c.informers.IstioVirtualService:          nil,
+		c.informers.AzureIngressProhibitedTarget:   nil,
+		c.informers.AzureApplicationGatewayRewrite: nil,
+		c.informers.IstioGateway:                   nil,
+		c.informers.IstioVirtualService:            nil,
 	}
 
 	sharedInformers := []cache.SharedInformer{
@@ -146,15 +174,89 @@ func (c *Context) Run(stopChannel chan struct{}, omitCRDs bool, envVariables env
 		c.informers.Ingress,
 	}
 
+	// The informers are reported by the resources they watch, when they do not sync.
+	informerNames := map[cache.SharedInformer]string{
+		c.informers.Endpoints:                      "endpoints",
+		c.informers.Pods:                           "pods",
+		c.informers.Service:                        "services",
+		c.informers.Secret:                         "secrets",
+		c.informers.Ingress:                        "ingresses",
+		c.informers.AzureIngressProhibitedTarget:   "azureingressprohibitedtargets",
+		c.informers.AzureApplicationGatewayRewrite: "azureapplicationgatewayrewrites",
+		c.informers.IstioGateway:                   "istio-gateways",
+		c.informers.IstioVirtualService:            "istio-virtualservices",
+		c.informers.Nodes:                          "nodes",
+	}
+
 	// For AGIC to watch for these CRDs the EnableBrownfieldDeploymentVarName env variable must be set to true
 	if envVariables.EnableBrownfieldDeployment {
 		sharedInformers = append(sharedInformers, c.informers.AzureIngressProhibitedTarget)
 	}
 
+	if envVariables.EnableRewrites {
+		sharedInformers = append(sharedInformers, c.informers.AzureApplicationGatewayRewrite)
+	}
+
 	if envVariables.EnableIstioIntegration {
 		sharedInformers = append(sharedInformers, c.informers.IstioGateway, c.informers.IstioVirtualService)
 	}
 
+	// Nodes change often; AGIC only reads their zone labels and does not reconcile on node events.
+	if envVariables.EnableZoneMetrics {
+		sharedInformers = append(sharedInformers, c.informers.Nodes)
+	}
+
+	// Set before the informers run: the secret handlers convert all TLS secrets when auto-selection is enabled.
+	c.autoSelectTLSSecrets = envVariables.AutoSelectTLSSecrets
+
+	// Set before the informers run: the ingress informer lists the Ingresses of this API version.
+	c.ingressGVR = c.selectIngressGVR(envVariables.IngressAPIVersion)
+	glog.V(1).Infof("[k8scontext] Consuming Ingresses of %s", c.ingressGVR.GroupVersion())
+
+	// Set before the informers run: the service and endpoints informers only list the services matching the selector.
+	c.serviceSelector = envVariables.ServiceSelector
+	if c.serviceSelector != "" {
+		glog.V(1).Infof("[k8scontext] Watching the services matching the selector %q, and their endpoints", c.serviceSelector)
+	}
+
+	if envVariables.EnableMultiClusterServices {
+		for _, informer := range c.watchServiceImports() {
+			sharedInformers = append(sharedInformers, informer)
+			informerNames[informer] = "multi-cluster-services"
+		}
+	}
+
+	if envVariables.EnableCertManager {
+		for _, informer := range c.watchCertificates() {
+			sharedInformers = append(sharedInformers, informer)
+			informerNames[informer] = "certificates"
+		}
+	}
+
+	if envVariables.EnableGatewayAPI {
+		for _, informer := range c.watchGatewayAPI() {
+			sharedInformers = append(sharedInformers, informer)
+			informerNames[informer] = "gateway-api"
+		}
+	}
+
+	if envVariables.PauseConfigMap != "" {
+		informer := c.watchPauseConfigMap(envVariables.AGICPodNamespace, envVariables.PauseConfigMap)
+		sharedInformers = append(sharedInformers, informer)
+		informerNames[informer] = "pause-configmap"
+	}
+
+	if envVariables.DefaultAnnotationsConfigMap != "" {
+		informer := c.watchDefaultAnnotations(envVariables.DefaultAnnotationsConfigMap)
+		sharedInformers = append(sharedInformers, informer)
+		informerNames[informer] = "default-annotations-configmap"
+	}
+
+	// The informers list all resources when they start: rather than reconciling after each of these add events, the
+	// worker builds the config once, from the complete caches, and applies it to App Gateway in a single ARM call.
+	syncStarted := time.Now()
+	atomic.StoreInt32(&c.initialSync, 1)
+
 	for _, informer := range sharedInformers {
 		go informer.Run(stopChannel)
 		// NOTE: Delyan could not figure out how to make informer.HasSynced == true for the CRDs in unit tests
@@ -162,18 +264,24 @@ func (c *Context) Run(stopChannel chan struct{}, omitCRDs bool, envVariables env
 		if _, isCRD := crds[informer]; isCRD {
 			continue
 		}
-		hasSynced = append(hasSynced, informer.HasSynced)
+		name := informerNames[informer]
+		hasSynced[name] = append(hasSynced[name], informer.HasSynced)
 	}
 
 	glog.V(1).Infoln("Waiting for initial cache sync")
-	if !cache.WaitForCacheSync(stopChannel, hasSynced...) {
-		return ErrorFailedInitialCacheSync
+	if _, err := waitForCacheSync(stopChannel, GetInformerSyncTimeout(envVariables), hasSynced, c.metricStore); err != nil {
+		return err
 	}
+	atomic.StoreInt32(&c.initialSync, 0)
+	c.metricStore.SetInitialSyncDurationSec(time.Since(syncStarted))
 
 	// Closing the cacheSynced channel signals to the rest of the system that... caches have been synced.
 	close(c.CacheSynced)
 
-	glog.V(1).Infoln("Initial cache sync done")
+	glog.V(1).Infof("Initial cache sync done in %+v", time.Since(syncStarted))
+	c.enqueue(events.Event{
+		Type: events.Update,
+	})
 	glog.V(1).Infoln("k8s context run finished")
 	return nil
 }
@@ -200,9 +308,48 @@ func (c *Context) ListServices() []*v1.Service {
 			serviceList = append(serviceList, service)
 		}
 	}
+
+	// A local service takes precedence over a ServiceImport of the same name.
+	localServices := make(map[string]interface{})
+	for _, service := range serviceList {
+		localServices[service.Namespace+"/"+service.Name] = nil
+	}
+	for _, service := range c.listImportedServices() {
+		if _, exists := localServices[service.Namespace+"/"+service.Name]; !exists && hasTCPPort(service) {
+			serviceList = append(serviceList, service)
+		}
+	}
 	return serviceList
 }
 
+// ListEndpoints returns a list of all the Endpoints from cache.
+func (c *Context) ListEndpoints() []*v1.Endpoints {
+	var endpointsList []*v1.Endpoints
+	for _, endpointsInterface := range c.Caches.Endpoints.List() {
+		endpoints := endpointsInterface.(*v1.Endpoints)
+		if _, exists := c.namespaces[endpoints.Namespace]; len(c.namespaces) > 0 && !exists {
+			continue
+		}
+		endpointsList = append(endpointsList, endpoints)
+	}
+	return endpointsList
+}
+
+// ListTLSSecrets returns the secrets of type kubernetes.io/tls in the namespace, sorted by name.
+func (c *Context) ListTLSSecrets(namespace string) []*v1.Secret {
+	var secrets []*v1.Secret
+	for _, secretInterface := range c.Caches.Secret.List() {
+		secret := secretInterface.(*v1.Secret)
+		if secret.Namespace == namespace && secret.Type == v1.SecretTypeTLS {
+			secrets = append(secrets, secret)
+		}
+	}
+	sort.Slice(secrets, func(i, j int) bool {
+		return secrets[i].Name < secrets[j].Name
+	})
+	return secrets
+}
+
 // GetEndpointsByService returns the endpoints associated with a specific service.
 func (c *Context) GetEndpointsByService(serviceKey string) (*v1.Endpoints, error) {
 	endpointsInterface, exist, err := c.Caches.Endpoints.GetByKey(serviceKey)
@@ -213,6 +360,9 @@ func (c *Context) GetEndpointsByService(serviceKey string) (*v1.Endpoints, error
 	}
 
 	if !exist {
+		if endpoints := c.getImportedEndpoints(serviceKey); endpoints != nil {
+			return endpoints, nil
+		}
 		glog.Error("Error fetching endpoints from store! Service does not exist: ", serviceKey)
 		return nil, ErrorFetchingEnpdoints
 	}
@@ -222,6 +372,11 @@ func (c *Context) GetEndpointsByService(serviceKey string) (*v1.Endpoints, error
 
 // ListPodsByServiceSelector returns pods that are associated with a specific service.
 func (c *Context) ListPodsByServiceSelector(service *v1.Service) []*v1.Pod {
+	// A service without selector, such as an imported multi-cluster service, selects no pods.
+	if len(service.Spec.Selector) == 0 {
+		return nil
+	}
+
 	selectorSet := mapset.NewSet()
 	for k, v := range service.Spec.Selector {
 		selectorSet.Add(k + ":" + v)
@@ -246,6 +401,20 @@ func (c *Context) ListPodsByServiceSelector(service *v1.Service) []*v1.Pod {
 	return podList
 }
 
+// IsPodTerminating provides whether the endpoint address refers to a pod, which is being deleted: the pod has a
+// deletion timestamp and serves its in-flight requests until its termination grace period ends.
+func (c *Context) IsPodTerminating(address v1.EndpointAddress) bool {
+	if address.TargetRef == nil || address.TargetRef.Kind != "Pod" {
+		return false
+	}
+	podInterface, exists, err := c.Caches.Pods.GetByKey(utils.GetResourceKey(address.TargetRef.Namespace, address.TargetRef.Name))
+	if err != nil || !exists {
+		return false
+	}
+	pod, ok := podInterface.(*v1.Pod)
+	return ok && pod.DeletionTimestamp != nil
+}
+
 // IsPodReferencedByAnyIngress provides whether a POD is useful i.e. a POD is used by an ingress
 func (c *Context) IsPodReferencedByAnyIngress(pod *v1.Pod) bool {
 	// first find all the services
@@ -266,6 +435,12 @@ func (c *Context) IsEndpointReferencedByAnyIngress(endpoints *v1.Endpoints) bool
 	return service != nil && c.isServiceReferencedByAnyIngress(service)
 }
 
+// IsServiceReferencedByAnyIngress provides whether a Service is used by an ingress, as a backend, as an additional backend
+// service or as a service matching the backend service selector of the ingress.
+func (c *Context) IsServiceReferencedByAnyIngress(service *v1.Service) bool {
+	return c.isServiceReferencedByAnyIngress(service)
+}
+
 // ListHTTPIngresses returns a list of all the ingresses for HTTP from cache.
 func (c *Context) ListHTTPIngresses() []*v1beta1.Ingress {
 	var ingressList []*v1beta1.Ingress
@@ -274,7 +449,10 @@ func (c *Context) ListHTTPIngresses() []*v1beta1.Ingress {
 		if _, exists := c.namespaces[ingress.Namespace]; len(c.namespaces) > 0 && !exists {
 			continue
 		}
-		ingressList = append(ingressList, ingress)
+		ingressList = append(ingressList, c.withDefaultAnnotations(ingress))
+	}
+	for _, ingress := range c.listHTTPRouteIngresses() {
+		ingressList = append(ingressList, c.withDefaultAnnotations(ingress))
 	}
 	return filterAndSort(ingressList)
 }
@@ -285,7 +463,7 @@ func filterAndSort(ingList []*v1beta1.Ingress) []*v1beta1.Ingress {
 		if !IsIngressApplicationGateway(ingress) {
 			continue
 		}
-		if len(ingress.Spec.Rules) > 0 && !hasHTTPRule(ingress) {
+		if len(ingress.Spec.Rules) > 0 && !hasHTTPRule(ingress) && ingress.Spec.Backend == nil {
 			continue
 		}
 		ingressList = append(ingressList, ingress)
@@ -297,6 +475,27 @@ func filterAndSort(ingList []*v1beta1.Ingress) []*v1beta1.Ingress {
 	return ingressList
 }
 
+// ListIngressesOfOtherClasses returns the ingresses, which AGIC skips for their ingress class, although they have
+// annotations of AGIC. Default annotations are not applied to them.
+func (c *Context) ListIngressesOfOtherClasses() []*v1beta1.Ingress {
+	var ingressList []*v1beta1.Ingress
+	for _, ingressInterface := range c.Caches.Ingress.List() {
+		ingress := ingressInterface.(*v1beta1.Ingress)
+		if _, exists := namespacesToIgnore[ingress.Namespace]; exists {
+			continue
+		}
+		if _, exists := c.namespaces[ingress.Namespace]; len(c.namespaces) > 0 && !exists {
+			continue
+		}
+		if IsIngressApplicationGateway(ingress) || !annotations.HasApplicationGatewayAnnotations(ingress) {
+			continue
+		}
+		ingressList = append(ingressList, ingress)
+	}
+	sort.Sort(sorter.ByIngressName(ingressList))
+	return ingressList
+}
+
 // ListAzureProhibitedTargets returns a list of App Gwy configs, for which AGIC is not allowed to modify config.
 func (c *Context) ListAzureProhibitedTargets() []*prohibitedv1.AzureIngressProhibitedTarget {
 	var targets []*prohibitedv1.AzureIngressProhibitedTarget
@@ -328,6 +527,9 @@ func (c *Context) GetService(serviceKey string) *v1.Service {
 	}
 
 	if !exist {
+		if service := c.getImportedService(serviceKey); service != nil {
+			return service
+		}
 		glog.V(9).Infof("Service %s does not exist", serviceKey)
 		return nil
 	}
@@ -426,25 +628,33 @@ func (c *Context) GetInfrastructureResourceGroupID() (azure.SubscriptionID, azur
 
 // UpdateIngressStatus adds IP address in Ingress Status
 func (c *Context) UpdateIngressStatus(ingressToUpdate v1beta1.Ingress, newIP IPAddress) error {
+	return c.UpdateIngressLoadBalancer(ingressToUpdate, v1.LoadBalancerIngress{IP: string(newIP)})
+}
+
+// UpdateIngressLoadBalancer sets the IP address and the host name of App Gateway in the status of the ingress, which
+// tools such as external-dns read.
+func (c *Context) UpdateIngressLoadBalancer(ingressToUpdate v1beta1.Ingress, newLoadBalancer v1.LoadBalancerIngress) error {
+	if IsHTTPRouteIngress(&ingressToUpdate) {
+		// The ingress was translated from an HTTPRoute; there is no such ingress to update.
+		return nil
+	}
+	switch c.ingressGVR {
+	case NetworkingV1IngressGVR:
+		return c.updateNetworkingV1IngressStatus(ingressToUpdate, newLoadBalancer)
+	case NetworkingV1beta1IngressGVR:
+		return c.updateNetworkingV1beta1IngressStatus(ingressToUpdate, newLoadBalancer)
+	}
+
 	ingressClient := c.kubeClient.ExtensionsV1beta1().Ingresses(ingressToUpdate.Namespace)
 	ingress, err := ingressClient.Get(ingressToUpdate.Name, metav1.GetOptions{})
 	if err != nil {
 		return fmt.Errorf("Unable to get ingress %s/%s", ingressToUpdate.Namespace, ingressToUpdate.Name)
 	}
 
-	for _, lbi := range ingress.Status.LoadBalancer.Ingress {
-		existingIP := lbi.IP
-		if existingIP == string(newIP) {
-			glog.V(5).Infof("IP %s already set on Ingress %s/%s", lbi.IP, ingress.Namespace, ingress.Name)
-			return nil
-		}
-	}
-
-	loadBalancerIngresses := []v1.LoadBalancerIngress{}
-	if newIP != "" {
-		loadBalancerIngresses = append(loadBalancerIngresses, v1.LoadBalancerIngress{
-			IP: string(newIP),
-		})
+	loadBalancerIngresses, changed := loadBalancerIngressesWith(ingress.Status.LoadBalancer.Ingress, newLoadBalancer)
+	if !changed {
+		glog.V(5).Infof("Load balancer %+v already set on Ingress %s/%s", newLoadBalancer, ingress.Namespace, ingress.Name)
+		return nil
 	}
 	ingress.Status.LoadBalancer.Ingress = loadBalancerIngresses
 
@@ -457,6 +667,50 @@ func (c *Context) UpdateIngressStatus(ingressToUpdate v1beta1.Ingress, newIP IPA
 	return nil
 }
 
+// loadBalancerIngressesWith returns the load balancer ingresses of the status of an ingress with the IP and host name
+// of the load balancer, and whether the status needs updating to them.
+func loadBalancerIngressesWith(existing []v1.LoadBalancerIngress, newLoadBalancer v1.LoadBalancerIngress) ([]v1.LoadBalancerIngress, bool) {
+	for _, lbi := range existing {
+		if lbi.IP == newLoadBalancer.IP && lbi.Hostname == newLoadBalancer.Hostname {
+			return existing, false
+		}
+	}
+
+	loadBalancerIngresses := []v1.LoadBalancerIngress{}
+	if newLoadBalancer.IP != "" || newLoadBalancer.Hostname != "" {
+		loadBalancerIngresses = append(loadBalancerIngresses, newLoadBalancer)
+	}
+	return loadBalancerIngresses, true
+}
+
+// SetIngressAnnotation sets the annotation of the ingress to the value with a merge patch, which leaves the rest of the
+// ingress as it is, in the API version of the ingresses AGIC consumes.
+func (c *Context) SetIngressAnnotation(ingressToUpdate v1beta1.Ingress, key, value string) error {
+	if IsHTTPRouteIngress(&ingressToUpdate) {
+		// The ingress was translated from an HTTPRoute; there is no such ingress to annotate.
+		return nil
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{key: value},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if c.ingressGVR == NetworkingV1IngressGVR || c.ingressGVR == NetworkingV1beta1IngressGVR {
+		_, err = c.dynamicClient.Resource(c.ingressGVR).Namespace(ingressToUpdate.Namespace).Patch(ingressToUpdate.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+	} else {
+		_, err = c.kubeClient.ExtensionsV1beta1().Ingresses(ingressToUpdate.Namespace).Patch(ingressToUpdate.Name, types.MergePatchType, patch)
+	}
+	if err != nil {
+		glog.Errorf("Unable to annotate ingress %s/%s with %s: error %s", ingressToUpdate.Namespace, ingressToUpdate.Name, key, err)
+		return ErrorUnableToUpdateIngress
+	}
+	return nil
+}
+
 // IsIngressApplicationGateway checks if applicaiton gateway annotation is present on the ingress
 func IsIngressApplicationGateway(ingress *v1beta1.Ingress) bool {
 	val, _ := annotations.IsApplicationGatewayIngress(ingress)
@@ -504,7 +758,26 @@ func (c *Context) listServicesByPodSelector(pod *v1.Pod) []*v1.Service {
 
 func (c *Context) isServiceReferencedByAnyIngress(service *v1.Service) bool {
 	for _, ingress := range c.ListHTTPIngresses() {
+		if ingress.Namespace != service.Namespace {
+			continue
+		}
+		if ingress.Spec.Backend != nil && ingress.Spec.Backend.ServiceName == service.Name {
+			return true
+		}
+		if additionalServices, err := annotations.AdditionalBackendServices(ingress); err == nil {
+			for _, additionalService := range additionalServices {
+				if additionalService == service.Name {
+					return true
+				}
+			}
+		}
+		if selector, err := annotations.BackendServiceSelector(ingress); err == nil && selector.Matches(labels.Set(service.Labels)) {
+			return true
+		}
 		for _, rule := range ingress.Spec.Rules {
+			if rule.HTTP == nil {
+				continue
+			}
 			for _, path := range rule.HTTP.Paths {
 				// TODO(akshaysngupta) Use service ports
 				if path.Backend.ServiceName == service.Name {
@@ -516,3 +789,20 @@ func (c *Context) isServiceReferencedByAnyIngress(service *v1.Service) bool {
 
 	return false
 }
+
+// ListAzureApplicationGatewayRewrites returns the rewrite resources in the watched namespaces.
+func (c *Context) ListAzureApplicationGatewayRewrites() []*rewritev1.AzureApplicationGatewayRewrite {
+	var rewrites []*rewritev1.AzureApplicationGatewayRewrite
+	for _, obj := range c.Caches.AzureApplicationGatewayRewrite.List() {
+		rewrite := obj.(*rewritev1.AzureApplicationGatewayRewrite)
+		if _, exists := c.namespaces[rewrite.Namespace]; len(c.namespaces) > 0 && !exists {
+			continue
+		}
+		rewrites = append(rewrites, rewrite)
+	}
+	// Sorted so that the generated rule sets have a deterministic order.
+	sort.Slice(rewrites, func(i, j int) bool {
+		return rewrites[i].Namespace+"/"+rewrites[i].Name < rewrites[j].Namespace+"/"+rewrites[j].Name
+	})
+	return rewrites
+}
@@ -0,0 +1,72 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package k8scontext
+
+import (
+	"github.com/golang/glog"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/utils"
+)
+
+// watchDefaultAnnotations creates an informer for the ConfigMaps of the given name in all namespaces, which hold the
+// default annotations of the ingresses of their namespace. Any change to them enqueues an event.
+func (c *Context) watchDefaultAnnotations(name string) cache.SharedIndexInformer {
+	informerFactory := informers.NewSharedInformerFactoryWithOptions(c.kubeClient, 0,
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.FieldSelector = fields.OneTermEqualSelector("metadata.name", name).String()
+		}))
+	informer := informerFactory.Core().V1().ConfigMaps().Informer()
+
+	onChange := func(obj interface{}) {
+		if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			obj = tombstone.Obj
+		}
+		namespace := getNamespace(obj)
+		if _, exists := c.namespaces[namespace]; len(c.namespaces) > 0 && !exists {
+			return
+		}
+		glog.V(3).Infof("[k8scontext] Default annotations ConfigMap %s/%s changed", namespace, name)
+		c.enqueue(events.Event{
+			Type:  events.Update,
+			Value: obj,
+		})
+		c.metricStore.IncK8sAPIEventCounter()
+	}
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    onChange,
+		UpdateFunc: func(oldObj, newObj interface{}) { onChange(newObj) },
+		DeleteFunc: onChange,
+	})
+
+	c.informers.DefaultAnnotations = informer
+	c.Caches.DefaultAnnotations = informer.GetStore()
+	c.defaultAnnotationsConfigMap = name
+	return informer
+}
+
+// withDefaultAnnotations returns the ingress with the default annotations of its namespace, which it does not have.
+func (c *Context) withDefaultAnnotations(ingress *v1beta1.Ingress) *v1beta1.Ingress {
+	if c.Caches.DefaultAnnotations == nil {
+		return ingress
+	}
+	obj, exists, err := c.Caches.DefaultAnnotations.GetByKey(utils.GetResourceKey(ingress.Namespace, c.defaultAnnotationsConfigMap))
+	if err != nil || !exists {
+		return ingress
+	}
+	configMap, ok := obj.(*v1.ConfigMap)
+	if !ok || len(configMap.Data) == 0 {
+		return ingress
+	}
+	return annotations.WithDefaults(ingress, configMap.Data)
+}
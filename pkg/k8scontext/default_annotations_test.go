@@ -0,0 +1,111 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package k8scontext
+
+import (
+	"time"
+
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	testclient "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/agic_crd_client/clientset/versioned/fake"
+	istioFake "github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/istio_crd_client/clientset/versioned/fake"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/metricstore"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
+)
+
+var _ = ginkgo.Describe("default annotations ConfigMap", func() {
+	const namespace = "ns"
+	const defaultsName = "agic-defaults"
+
+	var k8sClient kubernetes.Interface
+	var ctxt *Context
+	var stopChannel chan struct{}
+	var env environment.EnvVariables
+
+	newConfigMap := func(name string, data map[string]string) *v1.ConfigMap {
+		return &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+			},
+			Data: data,
+		}
+	}
+
+	ingressAnnotations := func() map[string]string {
+		ingresses := ctxt.ListHTTPIngresses()
+		Expect(ingresses).To(HaveLen(1))
+		return ingresses[0].Annotations
+	}
+
+	ginkgo.BeforeEach(func() {
+		stopChannel = make(chan struct{})
+		k8sClient = testclient.NewSimpleClientset()
+		ingress := tests.NewIngressTestFixture(namespace, "ingress")
+		ingress.Annotations[annotations.SslRedirectKey] = "false"
+		_, err := k8sClient.ExtensionsV1beta1().Ingresses(namespace).Create(&ingress)
+		Expect(err).ToNot(HaveOccurred())
+
+		ctxt = NewContext(k8sClient, fake.NewSimpleClientset(), istioFake.NewSimpleClientset(), []string{namespace}, 1000*time.Second, DefaultWorkQueueDepth, metricstore.NewFakeMetricStore())
+		env = environment.GetFakeEnv()
+		env.DefaultAnnotationsConfigMap = defaultsName
+	})
+
+	ginkgo.AfterEach(func() {
+		close(stopChannel)
+	})
+
+	ginkgo.It("applies the defaults the ingress does not have", func() {
+		_, err := k8sClient.CoreV1().ConfigMaps(namespace).Create(newConfigMap(defaultsName, map[string]string{
+			"request-timeout": "60",
+			"ssl-redirect":    "true",
+		}))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ctxt.Run(stopChannel, true, env)).ToNot(HaveOccurred())
+
+		Expect(ingressAnnotations()).To(HaveKeyWithValue(annotations.RequestTimeoutKey, "60"))
+		Expect(ingressAnnotations()).To(HaveKeyWithValue(annotations.SslRedirectKey, "false"), "the annotation of the ingress wins")
+
+		cached := ctxt.Caches.Ingress.List()
+		Expect(cached).To(HaveLen(1))
+		Expect(cached[0].(metav1.Object).GetAnnotations()).ToNot(HaveKey(annotations.RequestTimeoutKey), "the cached ingress is not modified")
+	})
+
+	ginkgo.It("ignores the ConfigMaps of other names", func() {
+		_, err := k8sClient.CoreV1().ConfigMaps(namespace).Create(newConfigMap("other", map[string]string{"request-timeout": "60"}))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ctxt.Run(stopChannel, true, env)).ToNot(HaveOccurred())
+
+		Expect(ingressAnnotations()).ToNot(HaveKey(annotations.RequestTimeoutKey))
+	})
+
+	ginkgo.It("follows the changes to the ConfigMap", func() {
+		Expect(ctxt.Run(stopChannel, true, env)).ToNot(HaveOccurred())
+		Eventually(ctxt.Work).Should(Receive())
+		Expect(ingressAnnotations()).ToNot(HaveKey(annotations.RequestTimeoutKey))
+
+		_, err := k8sClient.CoreV1().ConfigMaps(namespace).Create(newConfigMap(defaultsName, map[string]string{"request-timeout": "60"}))
+		Expect(err).ToNot(HaveOccurred())
+		Eventually(ctxt.Work).Should(Receive())
+		Expect(ingressAnnotations()).To(HaveKeyWithValue(annotations.RequestTimeoutKey, "60"))
+
+		_, err = k8sClient.CoreV1().ConfigMaps(namespace).Update(newConfigMap(defaultsName, map[string]string{"request-timeout": "90"}))
+		Expect(err).ToNot(HaveOccurred())
+		Eventually(ctxt.Work).Should(Receive())
+		Expect(ingressAnnotations()).To(HaveKeyWithValue(annotations.RequestTimeoutKey, "90"))
+
+		Expect(k8sClient.CoreV1().ConfigMaps(namespace).Delete(defaultsName, &metav1.DeleteOptions{})).To(Succeed())
+		Eventually(ctxt.Work).Should(Receive())
+		Expect(ingressAnnotations()).ToNot(HaveKey(annotations.RequestTimeoutKey))
+	})
+})
@@ -0,0 +1,62 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package k8scontext
+
+import (
+	"time"
+
+	"github.com/golang/glog"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// deploymentPollPeriod is how often the pods of a Deployment scaled to 0 are checked for their termination.
+var deploymentPollPeriod = 2 * time.Second
+
+// StopDeployment scales the Deployment to 0 replicas, and waits until all of its pods are gone, or the timeout runs
+// out; a Deployment, which does not exist, counts as stopped. The cleanup runs it on the Deployment of AGIC, so that no
+// AGIC pod re-applies the config it removes.
+func (c *Context) StopDeployment(namespace, name string, timeout time.Duration) error {
+	deployments := c.kubeClient.AppsV1().Deployments(namespace)
+	deployment, err := deployments.Get(name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		glog.V(1).Infof("[k8scontext] Deployment %s/%s does not exist", namespace, name)
+		return nil
+	}
+	if err != nil {
+		glog.Errorf("[k8scontext] Unable to get Deployment %s/%s: %s", namespace, name, err)
+		return err
+	}
+
+	if deployment.Spec.Replicas == nil || *deployment.Spec.Replicas != 0 {
+		replicas := int32(0)
+		deployment.Spec.Replicas = &replicas
+		if deployment, err = deployments.Update(deployment); err != nil {
+			glog.Errorf("[k8scontext] Unable to scale Deployment %s/%s to 0 replicas: %s", namespace, name, err)
+			return err
+		}
+		glog.V(1).Infof("[k8scontext] Scaled Deployment %s/%s to 0 replicas", namespace, name)
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return err
+	}
+	err = wait.PollImmediate(deploymentPollPeriod, timeout, func() (bool, error) {
+		pods, err := c.kubeClient.CoreV1().Pods(namespace).List(metav1.ListOptions{LabelSelector: selector.String()})
+		if err != nil {
+			return false, err
+		}
+		glog.V(3).Infof("[k8scontext] Waiting for %d pods of Deployment %s/%s to terminate", len(pods.Items), namespace, name)
+		return len(pods.Items) == 0, nil
+	})
+	if err != nil {
+		glog.Errorf("[k8scontext] Pods of Deployment %s/%s did not terminate: %s", namespace, name, err)
+		return ErrorDeploymentNotStopped
+	}
+	return nil
+}
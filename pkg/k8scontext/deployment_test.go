@@ -0,0 +1,90 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package k8scontext
+
+import (
+	"time"
+
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	testclient "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/agic_crd_client/clientset/versioned/fake"
+	istioFake "github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/istio_crd_client/clientset/versioned/fake"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/metricstore"
+)
+
+var _ = ginkgo.Describe("stopping a Deployment", func() {
+	const namespace = "agic"
+	const name = "ingress-azure"
+	labels := map[string]string{"app": "ingress-azure"}
+
+	var k8sClient kubernetes.Interface
+	var ctxt *Context
+	var pollPeriod time.Duration
+
+	ginkgo.BeforeEach(func() {
+		pollPeriod = deploymentPollPeriod
+		deploymentPollPeriod = 10 * time.Millisecond
+
+		k8sClient = testclient.NewSimpleClientset()
+		replicas := int32(1)
+		_, err := k8sClient.AppsV1().Deployments(namespace).Create(&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: &replicas,
+				Selector: &metav1.LabelSelector{MatchLabels: labels},
+			},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		_, err = k8sClient.CoreV1().Pods(namespace).Create(&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name + "-pod", Namespace: namespace, Labels: labels},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		_, err = k8sClient.CoreV1().Pods(namespace).Create(&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name + "-cleanup", Namespace: namespace, Labels: map[string]string{"app": "ingress-azure-cleanup"}},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		ctxt = NewContext(k8sClient, fake.NewSimpleClientset(), istioFake.NewSimpleClientset(), []string{namespace}, 1000*time.Second, DefaultWorkQueueDepth, metricstore.NewFakeMetricStore())
+	})
+
+	ginkgo.AfterEach(func() {
+		deploymentPollPeriod = pollPeriod
+	})
+
+	replicas := func() int32 {
+		deployment, err := k8sClient.AppsV1().Deployments(namespace).Get(name, metav1.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		return *deployment.Spec.Replicas
+	}
+
+	ginkgo.It("scales the Deployment to 0 and waits for its pods to terminate", func() {
+		terminated := make(chan interface{})
+		go func() {
+			defer ginkgo.GinkgoRecover()
+			Eventually(replicas).Should(Equal(int32(0)))
+			Expect(k8sClient.CoreV1().Pods(namespace).Delete(name+"-pod", nil)).To(Succeed())
+			close(terminated)
+		}()
+
+		Expect(ctxt.StopDeployment(namespace, name, time.Minute)).To(Succeed())
+		Expect(terminated).To(BeClosed(), "returns only once the pods are gone")
+	})
+
+	ginkgo.It("fails when the pods do not terminate in time", func() {
+		Expect(ctxt.StopDeployment(namespace, name, 50*time.Millisecond)).To(Equal(ErrorDeploymentNotStopped))
+		Expect(replicas()).To(Equal(int32(0)))
+	})
+
+	ginkgo.It("treats a missing Deployment as stopped", func() {
+		Expect(ctxt.StopDeployment(namespace, "missing", time.Minute)).To(Succeed())
+	})
+})
@@ -40,4 +40,13 @@ var (
 
 	// ErrorUnableToUpdateIngress is an error.
 	ErrorUnableToUpdateIngress = errors.New("ingress status update (KCTX011)")
+
+	// ErrorInitialCacheSyncTimeout is an error.
+	ErrorInitialCacheSyncTimeout = errors.New("initial sync of resources required for ingress did not complete within APPGW_INFORMER_SYNC_TIMEOUT_SECONDS (KCTX012)")
+
+	// ErrorUnableToWriteConfigMap is an error.
+	ErrorUnableToWriteConfigMap = errors.New("unable to write ConfigMap (KCTX013)")
+
+	// ErrorDeploymentNotStopped is an error.
+	ErrorDeploymentNotStopped = errors.New("pods of the Deployment did not terminate in time (KCTX014)")
 )
@@ -0,0 +1,382 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package k8scontext
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/golang/glog"
+	"k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+)
+
+// gatewayAPIGroup is the group of the Gateway API, which parentRefs default to.
+const gatewayAPIGroup = "gateway.networking.k8s.io"
+
+// defaultHTTPPort is the port of the HTTP listeners of App Gateway, unless an ingress sets another one.
+const defaultHTTPPort = 80
+
+// HTTPRouteGVRs are the resources of the HTTPRoutes of the Gateway API in order of preference.
+var HTTPRouteGVRs = []schema.GroupVersionResource{
+	{Group: gatewayAPIGroup, Version: "v1", Resource: "httproutes"},
+	{Group: gatewayAPIGroup, Version: "v1beta1", Resource: "httproutes"},
+	{Group: gatewayAPIGroup, Version: "v1alpha2", Resource: "httproutes"},
+}
+
+// GatewayGVRs are the resources of the Gateways of the Gateway API in order of preference.
+var GatewayGVRs = []schema.GroupVersionResource{
+	{Group: gatewayAPIGroup, Version: "v1", Resource: "gateways"},
+	{Group: gatewayAPIGroup, Version: "v1beta1", Resource: "gateways"},
+	{Group: gatewayAPIGroup, Version: "v1alpha2", Resource: "gateways"},
+}
+
+// httpRoute holds the fields of an HTTPRoute AGIC reads; they are the same in all versions of the Gateway API.
+type httpRoute struct {
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              struct {
+		ParentRefs []gatewayParentRef `json:"parentRefs,omitempty"`
+		Hostnames  []string           `json:"hostnames,omitempty"`
+		Rules      []httpRouteRule    `json:"rules,omitempty"`
+	} `json:"spec,omitempty"`
+}
+
+type gatewayParentRef struct {
+	Group       *string `json:"group,omitempty"`
+	Kind        *string `json:"kind,omitempty"`
+	Namespace   *string `json:"namespace,omitempty"`
+	Name        string  `json:"name"`
+	SectionName *string `json:"sectionName,omitempty"`
+	Port        *int32  `json:"port,omitempty"`
+}
+
+type httpRouteRule struct {
+	Matches     []httpRouteMatch         `json:"matches,omitempty"`
+	Filters     []map[string]interface{} `json:"filters,omitempty"`
+	BackendRefs []httpBackendRef         `json:"backendRefs,omitempty"`
+}
+
+type httpRouteMatch struct {
+	Path *struct {
+		Type  *string `json:"type,omitempty"`
+		Value *string `json:"value,omitempty"`
+	} `json:"path,omitempty"`
+	Headers     []map[string]interface{} `json:"headers,omitempty"`
+	QueryParams []map[string]interface{} `json:"queryParams,omitempty"`
+	Method      *string                  `json:"method,omitempty"`
+}
+
+type httpBackendRef struct {
+	Group     *string `json:"group,omitempty"`
+	Kind      *string `json:"kind,omitempty"`
+	Name      string  `json:"name"`
+	Namespace *string `json:"namespace,omitempty"`
+	Port      *int32  `json:"port,omitempty"`
+	Weight    *int32  `json:"weight,omitempty"`
+}
+
+// gateway holds the fields of a Gateway AGIC reads.
+type gateway struct {
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              struct {
+		GatewayClassName string            `json:"gatewayClassName"`
+		Listeners        []gatewayListener `json:"listeners,omitempty"`
+	} `json:"spec,omitempty"`
+}
+
+type gatewayListener struct {
+	Name          string  `json:"name"`
+	Hostname      *string `json:"hostname,omitempty"`
+	Port          int32   `json:"port"`
+	Protocol      string  `json:"protocol"`
+	AllowedRoutes *struct {
+		Namespaces *struct {
+			From *string `json:"from,omitempty"`
+		} `json:"namespaces,omitempty"`
+	} `json:"allowedRoutes,omitempty"`
+}
+
+// watchGatewayAPI creates the informers for the HTTPRoutes and the Gateways of the Gateway API. Without the Gateway API
+// CRDs in the cluster no informer is created and AGIC only consumes Ingresses.
+func (c *Context) watchGatewayAPI() []cache.SharedInformer {
+	if c.dynamicClient == nil {
+		glog.Warning("[k8scontext] No dynamic client; HTTPRoutes of the Gateway API will not be consumed")
+		return nil
+	}
+	httpRouteGVR := c.selectServedResource(HTTPRouteGVRs)
+	gatewayGVR := c.selectServedResource(GatewayGVRs)
+	if httpRouteGVR == nil || gatewayGVR == nil {
+		glog.Warning("[k8scontext] HTTPRoutes or Gateways are not served by the API server; the Gateway API CRDs may not be installed")
+		return nil
+	}
+
+	informerFactory := dynamicinformer.NewDynamicSharedInformerFactory(c.dynamicClient, 0)
+	httpRoutes := informerFactory.ForResource(*httpRouteGVR).Informer()
+	gateways := informerFactory.ForResource(*gatewayGVR).Informer()
+
+	handler := c.newDynamicResourceHandler()
+	httpRoutes.AddEventHandler(handler)
+	gateways.AddEventHandler(handler)
+
+	c.informers.HTTPRoute = httpRoutes
+	c.informers.Gateway = gateways
+	c.Caches.HTTPRoute = httpRoutes.GetStore()
+	c.Caches.Gateway = gateways.GetStore()
+	glog.V(1).Infof("[k8scontext] Watching %s and %s of %s", httpRouteGVR.Resource, gatewayGVR.Resource, httpRouteGVR.GroupVersion())
+	return []cache.SharedInformer{httpRoutes, gateways}
+}
+
+func (c *Context) selectServedResource(gvrs []schema.GroupVersionResource) *schema.GroupVersionResource {
+	for idx := range gvrs {
+		if c.isResourceServed(gvrs[idx]) {
+			return &gvrs[idx]
+		}
+	}
+	return nil
+}
+
+// listHTTPRouteIngresses returns the HTTPRoutes of the Gateways of AGIC, translated to ingresses.
+func (c *Context) listHTTPRouteIngresses() []*v1beta1.Ingress {
+	if c.Caches.HTTPRoute == nil || c.Caches.Gateway == nil {
+		return nil
+	}
+	var gateways []*unstructured.Unstructured
+	for _, obj := range c.Caches.Gateway.List() {
+		if gw, ok := obj.(*unstructured.Unstructured); ok {
+			gateways = append(gateways, gw)
+		}
+	}
+
+	var ingressList []*v1beta1.Ingress
+	for _, obj := range c.Caches.HTTPRoute.List() {
+		route, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			continue
+		}
+		if _, exists := c.namespaces[route.GetNamespace()]; len(c.namespaces) > 0 && !exists {
+			continue
+		}
+		ingress, err := FromHTTPRoute(route, gateways)
+		if err != nil {
+			glog.V(3).Infof("[k8scontext] Ignoring HTTPRoute %s/%s: %s", route.GetNamespace(), route.GetName(), err)
+			continue
+		}
+		if ingress != nil {
+			ingressList = append(ingressList, ingress)
+		}
+	}
+	return ingressList
+}
+
+// FromHTTPRoute translates an HTTPRoute of the Gateway API to an extensions/v1beta1 Ingress of AGIC, or returns nil
+// when none of its parentRefs is an HTTP listener of a Gateway of the GatewayClass of AGIC, among the gateways.
+// The translation covers a subset of HTTPRoute:
+//  - the hostnames become the hosts of the rules; without hostnames, the hostname of the listener is used
+//  - a match by path prefix, or exact path, becomes a path; matches by header, query parameter or method are left
+//    out, and so are the rules with filters, which App Gateway would not apply
+//  - the first backendRef of a rule, which is a Service of the namespace of the route, becomes the backend of its
+//    paths; traffic is not split between several backendRefs
+//  - the port of the listener becomes the http-listener-port annotation, unless it is 80
+// The annotations of the HTTPRoute are kept, so that the annotations of AGIC apply to it. The name of the ingress is the
+// name of the route prefixed with "httproute-", so that it does not collide with an ingress of the same name.
+func FromHTTPRoute(obj *unstructured.Unstructured, gateways []*unstructured.Unstructured) (*v1beta1.Ingress, error) {
+	var route httpRoute
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &route); err != nil {
+		return nil, err
+	}
+
+	listener := findHTTPRouteListener(route, gateways)
+	if listener == nil {
+		return nil, nil
+	}
+
+	ingress := &v1beta1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        annotations.HTTPRouteNamePrefix + route.Name,
+			Namespace:   route.Namespace,
+			UID:         route.UID,
+			Labels:      route.Labels,
+			Annotations: make(map[string]string),
+		},
+	}
+	for key, value := range route.Annotations {
+		ingress.Annotations[key] = value
+	}
+	ingress.Annotations[annotations.IngressClassKey] = annotations.ApplicationGatewayIngressClass
+	apiVersion := obj.GetAPIVersion()
+	if apiVersion == "" {
+		apiVersion = HTTPRouteGVRs[0].GroupVersion().String()
+	}
+	ingress.Annotations[annotations.HTTPRouteKey] = apiVersion
+	if listener.Port != defaultHTTPPort {
+		ingress.Annotations[annotations.HTTPListenerPortKey] = fmt.Sprintf("%d", listener.Port)
+	}
+
+	var paths []v1beta1.HTTPIngressPath
+	for ruleIdx, rule := range route.Spec.Rules {
+		if len(rule.Filters) > 0 {
+			glog.V(3).Infof("[k8scontext] HTTPRoute %s/%s: rule %d: filters are not supported; ignoring the rule", route.Namespace, route.Name, ruleIdx)
+			continue
+		}
+		backend, err := fromHTTPBackendRefs(route.Namespace, rule.BackendRefs)
+		if err != nil {
+			glog.V(3).Infof("[k8scontext] HTTPRoute %s/%s: rule %d: %s; ignoring the rule", route.Namespace, route.Name, ruleIdx, err)
+			continue
+		}
+		matches := rule.Matches
+		if len(matches) == 0 {
+			// A rule without matches matches all paths.
+			matches = []httpRouteMatch{{}}
+		}
+		for _, match := range matches {
+			path, err := fromHTTPRouteMatch(match)
+			if err != nil {
+				glog.V(3).Infof("[k8scontext] HTTPRoute %s/%s: rule %d: %s; ignoring the match", route.Namespace, route.Name, ruleIdx, err)
+				continue
+			}
+			paths = append(paths, v1beta1.HTTPIngressPath{Path: path, Backend: *backend})
+		}
+	}
+
+	hosts := getHTTPRouteHosts(route, listener)
+	if len(paths) == 0 || len(hosts) == 0 {
+		return nil, fmt.Errorf("none of its rules, or none of its hostnames, can be served by listener %s", listener.Name)
+	}
+	for _, host := range hosts {
+		ingress.Spec.Rules = append(ingress.Spec.Rules, v1beta1.IngressRule{
+			Host: host,
+			IngressRuleValue: v1beta1.IngressRuleValue{
+				HTTP: &v1beta1.HTTPIngressRuleValue{Paths: paths},
+			},
+		})
+	}
+	return ingress, nil
+}
+
+// findHTTPRouteListener returns the first HTTP listener the parentRefs of the route attach it to, of a Gateway of the
+// GatewayClass of AGIC, which allows routes of the namespace of the route.
+func findHTTPRouteListener(route httpRoute, gateways []*unstructured.Unstructured) *gatewayListener {
+	for _, parentRef := range route.Spec.ParentRefs {
+		if (parentRef.Group != nil && *parentRef.Group != gatewayAPIGroup) || (parentRef.Kind != nil && *parentRef.Kind != "Gateway") {
+			continue
+		}
+		namespace := route.Namespace
+		if parentRef.Namespace != nil && *parentRef.Namespace != "" {
+			namespace = *parentRef.Namespace
+		}
+		for _, obj := range gateways {
+			if obj.GetNamespace() != namespace || obj.GetName() != parentRef.Name {
+				continue
+			}
+			var gw gateway
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &gw); err != nil {
+				glog.Errorf("[k8scontext] Ignoring Gateway %s/%s: %s", obj.GetNamespace(), obj.GetName(), err)
+				continue
+			}
+			if gw.Spec.GatewayClassName != annotations.ApplicationGatewayIngressClassName {
+				continue
+			}
+			for idx := range gw.Spec.Listeners {
+				listener := &gw.Spec.Listeners[idx]
+				if listener.Protocol != "HTTP" {
+					continue
+				}
+				if parentRef.SectionName != nil && *parentRef.SectionName != listener.Name {
+					continue
+				}
+				if parentRef.Port != nil && *parentRef.Port != listener.Port {
+					continue
+				}
+				if gw.Namespace != route.Namespace && !allowsAllNamespaces(listener) {
+					continue
+				}
+				return listener
+			}
+		}
+	}
+	return nil
+}
+
+// allowsAllNamespaces tells whether the listener allows routes of all namespaces; by default it only allows the routes
+// of the namespace of its Gateway. Namespace selectors are not supported.
+func allowsAllNamespaces(listener *gatewayListener) bool {
+	return listener.AllowedRoutes != nil && listener.AllowedRoutes.Namespaces != nil &&
+		listener.AllowedRoutes.Namespaces.From != nil && *listener.AllowedRoutes.Namespaces.From == "All"
+}
+
+// getHTTPRouteHosts returns the hosts of the route, which the listener accepts; the empty host stands for all hosts.
+func getHTTPRouteHosts(route httpRoute, listener *gatewayListener) []string {
+	if listener.Hostname == nil || *listener.Hostname == "" {
+		if len(route.Spec.Hostnames) == 0 {
+			return []string{""}
+		}
+		return route.Spec.Hostnames
+	}
+	if len(route.Spec.Hostnames) == 0 {
+		return []string{*listener.Hostname}
+	}
+	var hosts []string
+	for _, hostname := range route.Spec.Hostnames {
+		if hostname == *listener.Hostname || (strings.HasPrefix(*listener.Hostname, "*.") && strings.HasSuffix(hostname, (*listener.Hostname)[1:])) {
+			hosts = append(hosts, hostname)
+		}
+	}
+	return hosts
+}
+
+// fromHTTPRouteMatch returns the App Gateway path of the match.
+func fromHTTPRouteMatch(match httpRouteMatch) (string, error) {
+	if len(match.Headers) > 0 || len(match.QueryParams) > 0 || match.Method != nil {
+		return "", fmt.Errorf("matches by header, query parameter or method are not supported")
+	}
+	pathType, value := "PathPrefix", "/"
+	if match.Path != nil {
+		if match.Path.Type != nil {
+			pathType = *match.Path.Type
+		}
+		if match.Path.Value != nil {
+			value = *match.Path.Value
+		}
+	}
+	switch pathType {
+	case "PathPrefix":
+		prefix := pathTypePrefix
+		return pathOfPathType(value, &prefix), nil
+	case "Exact":
+		return value, nil
+	}
+	return "", fmt.Errorf("path match type %s is not supported", pathType)
+}
+
+// fromHTTPBackendRefs returns the backend of the first backendRef, which is a Service of the namespace of the route.
+func fromHTTPBackendRefs(namespace string, backendRefs []httpBackendRef) (*v1beta1.IngressBackend, error) {
+	for _, backendRef := range backendRefs {
+		if (backendRef.Group != nil && *backendRef.Group != "") || (backendRef.Kind != nil && *backendRef.Kind != "Service") {
+			continue
+		}
+		if backendRef.Namespace != nil && *backendRef.Namespace != namespace {
+			continue
+		}
+		if backendRef.Port == nil || (backendRef.Weight != nil && *backendRef.Weight == 0) {
+			continue
+		}
+		return &v1beta1.IngressBackend{ServiceName: backendRef.Name, ServicePort: intstr.FromInt(int(*backendRef.Port))}, nil
+	}
+	return nil, fmt.Errorf("no backendRef is a Service with a port in namespace %s", namespace)
+}
+
+// IsHTTPRouteIngress tells whether the ingress was translated from an HTTPRoute.
+func IsHTTPRouteIngress(ingress *v1beta1.Ingress) bool {
+	return annotations.HTTPRouteAPIVersion(ingress) != ""
+}
@@ -0,0 +1,238 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package k8scontext
+
+import (
+	"time"
+
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	testclient "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/agic_crd_client/clientset/versioned/fake"
+	istioFake "github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/istio_crd_client/clientset/versioned/fake"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/metricstore"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
+)
+
+var _ = ginkgo.Describe("HTTPRoutes of the Gateway API", func() {
+	const namespace = "ns"
+
+	newGateway := func(name, className string, listeners ...interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "gateway.networking.k8s.io/v1",
+			"kind":       "Gateway",
+			"metadata":   map[string]interface{}{"name": name, "namespace": namespace},
+			"spec": map[string]interface{}{
+				"gatewayClassName": className,
+				"listeners":        listeners,
+			},
+		}}
+	}
+
+	newListener := func(name, protocol string, port int64) map[string]interface{} {
+		return map[string]interface{}{"name": name, "protocol": protocol, "port": port}
+	}
+
+	newRule := func(backend string, matches ...interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"matches":     matches,
+			"backendRefs": []interface{}{map[string]interface{}{"name": backend, "port": int64(80)}},
+		}
+	}
+
+	pathMatch := func(pathType, value string) map[string]interface{} {
+		return map[string]interface{}{"path": map[string]interface{}{"type": pathType, "value": value}}
+	}
+
+	newRoute := func(name, gatewayName string, hostnames []interface{}, rules ...interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "gateway.networking.k8s.io/v1",
+			"kind":       "HTTPRoute",
+			"metadata": map[string]interface{}{
+				"name":        name,
+				"namespace":   namespace,
+				"annotations": map[string]interface{}{annotations.BackendProtocolKey: "https"},
+			},
+			"spec": map[string]interface{}{
+				"parentRefs": []interface{}{map[string]interface{}{"name": gatewayName}},
+				"hostnames":  hostnames,
+				"rules":      rules,
+			},
+		}}
+	}
+
+	agic := newGateway("agic", annotations.ApplicationGatewayIngressClassName, newListener("http", "HTTP", 80))
+
+	ginkgo.Context("translating an HTTPRoute to an ingress", func() {
+		ginkgo.It("translates the hostnames, path matches and backendRefs", func() {
+			route := newRoute("web", "agic", []interface{}{"www.contoso.com", "api.contoso.com"},
+				newRule("web", pathMatch("PathPrefix", "/"), pathMatch("Exact", "/login")),
+				newRule("api", pathMatch("PathPrefix", "/api")))
+			ingress, err := FromHTTPRoute(route, []*unstructured.Unstructured{agic})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ingress.Name).To(Equal("httproute-web"))
+			Expect(ingress.Namespace).To(Equal(namespace))
+			Expect(IsHTTPRouteIngress(ingress)).To(BeTrue())
+			Expect(ingress.Annotations).To(HaveKeyWithValue(annotations.HTTPRouteKey, "gateway.networking.k8s.io/v1"))
+			Expect(IsIngressApplicationGateway(ingress)).To(BeTrue())
+			Expect(ingress.Annotations).To(HaveKeyWithValue(annotations.BackendProtocolKey, "https"))
+			Expect(ingress.Annotations).ToNot(HaveKey(annotations.HTTPListenerPortKey))
+
+			paths := []v1beta1.HTTPIngressPath{
+				{Path: "/*", Backend: v1beta1.IngressBackend{ServiceName: "web", ServicePort: intstr.FromInt(80)}},
+				{Path: "/login", Backend: v1beta1.IngressBackend{ServiceName: "web", ServicePort: intstr.FromInt(80)}},
+				{Path: "/api*", Backend: v1beta1.IngressBackend{ServiceName: "api", ServicePort: intstr.FromInt(80)}},
+			}
+			Expect(ingress.Spec.Rules).To(HaveLen(2))
+			Expect(ingress.Spec.Rules[0].Host).To(Equal("www.contoso.com"))
+			Expect(ingress.Spec.Rules[0].HTTP.Paths).To(Equal(paths))
+			Expect(ingress.Spec.Rules[1].Host).To(Equal("api.contoso.com"))
+			Expect(ingress.Spec.Rules[1].HTTP.Paths).To(Equal(paths))
+		})
+
+		ginkgo.It("serves a route without hostnames and matches on all hosts and paths, on the port of the listener", func() {
+			gateway := newGateway("agic", annotations.ApplicationGatewayIngressClassName, newListener("http", "HTTP", 8080))
+			route := newRoute("web", "agic", nil, newRule("web"))
+			ingress, err := FromHTTPRoute(route, []*unstructured.Unstructured{gateway})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ingress.Annotations).To(HaveKeyWithValue(annotations.HTTPListenerPortKey, "8080"))
+			Expect(ingress.Spec.Rules).To(HaveLen(1))
+			Expect(ingress.Spec.Rules[0].Host).To(BeEmpty())
+			Expect(ingress.Spec.Rules[0].HTTP.Paths[0].Path).To(Equal("/*"))
+		})
+
+		ginkgo.It("leaves out the matches and rules App Gateway cannot apply", func() {
+			headerMatch := pathMatch("PathPrefix", "/beta")
+			headerMatch["headers"] = []interface{}{map[string]interface{}{"name": "x-beta", "value": "1"}}
+			withFilter := newRule("web", pathMatch("PathPrefix", "/old"))
+			withFilter["filters"] = []interface{}{map[string]interface{}{"type": "RequestRedirect"}}
+			otherNamespace := newRule("web", pathMatch("PathPrefix", "/other"))
+			otherNamespace["backendRefs"] = []interface{}{map[string]interface{}{"name": "web", "namespace": "other", "port": int64(80)}}
+
+			route := newRoute("web", "agic", []interface{}{"www.contoso.com"},
+				newRule("web", pathMatch("PathPrefix", "/app"), headerMatch, pathMatch("RegularExpression", "/v[0-9]+")),
+				withFilter, otherNamespace)
+			ingress, err := FromHTTPRoute(route, []*unstructured.Unstructured{agic})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ingress.Spec.Rules[0].HTTP.Paths).To(HaveLen(1))
+			Expect(ingress.Spec.Rules[0].HTTP.Paths[0].Path).To(Equal("/app*"))
+		})
+
+		ginkgo.It("ignores the routes of other Gateways and of other listeners", func() {
+			other := newGateway("other", "other-class", newListener("http", "HTTP", 80))
+			tls := newGateway("tls", annotations.ApplicationGatewayIngressClassName, newListener("https", "HTTPS", 443))
+			gateways := []*unstructured.Unstructured{agic, other, tls}
+
+			for _, gatewayName := range []string{"other", "tls", "missing"} {
+				ingress, err := FromHTTPRoute(newRoute("web", gatewayName, nil, newRule("web")), gateways)
+				Expect(err).ToNot(HaveOccurred(), gatewayName)
+				Expect(ingress).To(BeNil(), gatewayName)
+			}
+		})
+
+		ginkgo.It("fails for a route with nothing App Gateway can serve", func() {
+			route := newRoute("web", "agic", []interface{}{"www.contoso.com"}, newRule("web", pathMatch("RegularExpression", "/v[0-9]+")))
+			_, err := FromHTTPRoute(route, []*unstructured.Unstructured{agic})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	ginkgo.Context("watching HTTPRoutes", func() {
+		var k8sClient *testclient.Clientset
+		var ctxt *Context
+		var stopChannel chan struct{}
+		var env environment.EnvVariables
+
+		servedResources := []*metav1.APIResourceList{
+			{
+				GroupVersion: "gateway.networking.k8s.io/v1",
+				APIResources: []metav1.APIResource{
+					{Name: "httproutes", Namespaced: true, Kind: "HTTPRoute"},
+					{Name: "gateways", Namespaced: true, Kind: "Gateway"},
+				},
+			},
+		}
+
+		ginkgo.BeforeEach(func() {
+			stopChannel = make(chan struct{})
+			k8sClient = testclient.NewSimpleClientset()
+			ctxt = NewContext(k8sClient, fake.NewSimpleClientset(), istioFake.NewSimpleClientset(), []string{namespace}, 1000*time.Second, DefaultWorkQueueDepth, metricstore.NewFakeMetricStore())
+			env = environment.GetFakeEnv()
+			env.EnableGatewayAPI = true
+		})
+
+		ginkgo.AfterEach(func() {
+			close(stopChannel)
+		})
+
+		ginkgo.It("lists the HTTPRoutes of the Gateways of AGIC with the ingresses", func() {
+			k8sClient.Fake.Resources = servedResources
+			dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+			// The fake client cannot guess the resource of a Gateway from its kind, so the objects are created with theirs.
+			_, err := dynamicClient.Resource(GatewayGVRs[0]).Namespace(namespace).Create(agic, metav1.CreateOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			_, err = dynamicClient.Resource(HTTPRouteGVRs[0]).Namespace(namespace).Create(newRoute("web", "agic", nil, newRule("web")), metav1.CreateOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			ctxt.SetDynamicClient(dynamicClient)
+			Expect(ctxt.Run(stopChannel, true, env)).ToNot(HaveOccurred())
+
+			ingresses := ctxt.ListHTTPIngresses()
+			Expect(ingresses).To(HaveLen(1))
+			Expect(ingresses[0].Name).To(Equal("httproute-web"))
+			Expect(ctxt.IsServiceReferencedByAnyIngress(&v1.Service{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: namespace}})).To(BeTrue())
+
+			// There is no such ingress to update the status of.
+			Expect(ctxt.UpdateIngressStatus(*ingresses[0], "1.2.3.4")).To(Succeed())
+		})
+
+		ginkgo.It("names the ingress of an HTTPRoute apart from the ingress of the same name", func() {
+			k8sClient.Fake.Resources = servedResources
+			ingress := tests.NewIngressTestFixture(namespace, "web")
+			_, err := k8sClient.ExtensionsV1beta1().Ingresses(namespace).Create(&ingress)
+			Expect(err).ToNot(HaveOccurred())
+			dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+			_, err = dynamicClient.Resource(GatewayGVRs[0]).Namespace(namespace).Create(agic, metav1.CreateOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			_, err = dynamicClient.Resource(HTTPRouteGVRs[0]).Namespace(namespace).Create(newRoute("web", "agic", nil, newRule("web")), metav1.CreateOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			ctxt.SetDynamicClient(dynamicClient)
+			Expect(ctxt.Run(stopChannel, true, env)).ToNot(HaveOccurred())
+
+			var names []string
+			for _, ingress := range ctxt.ListHTTPIngresses() {
+				names = append(names, ingress.Namespace+"/"+ingress.Name)
+			}
+			Expect(names).To(ConsistOf(namespace+"/web", namespace+"/httproute-web"))
+		})
+
+		ginkgo.It("does not watch HTTPRoutes without the Gateway API CRDs", func() {
+			ctxt.SetDynamicClient(dynamicfake.NewSimpleDynamicClient(runtime.NewScheme()))
+			Expect(ctxt.Run(stopChannel, true, env)).ToNot(HaveOccurred())
+
+			Expect(ctxt.Caches.HTTPRoute).To(BeNil())
+			Expect(ctxt.ListHTTPIngresses()).To(BeEmpty())
+		})
+
+		ginkgo.It("does not watch HTTPRoutes unless enabled", func() {
+			k8sClient.Fake.Resources = servedResources
+			ctxt.SetDynamicClient(dynamicfake.NewSimpleDynamicClient(runtime.NewScheme()))
+			env.EnableGatewayAPI = false
+			Expect(ctxt.Run(stopChannel, true, env)).ToNot(HaveOccurred())
+
+			Expect(ctxt.Caches.HTTPRoute).To(BeNil())
+		})
+	})
+})
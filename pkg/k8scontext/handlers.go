@@ -20,10 +20,10 @@ func (h handlers) addFunc(obj interface{}) {
 		return
 	}
 
-	h.context.Work <- events.Event{
+	h.context.enqueue(events.Event{
 		Type:  events.Create,
 		Value: obj,
-	}
+	})
 	h.context.metricStore.IncK8sAPIEventCounter()
 }
 
@@ -39,10 +39,10 @@ func (h handlers) updateFunc(oldObj, newObj interface{}) {
 	if reflect.DeepEqual(oldObj, newObj) {
 		return
 	}
-	h.context.Work <- events.Event{
+	h.context.enqueue(events.Event{
 		Type:  events.Update,
 		Value: newObj,
-	}
+	})
 	h.context.metricStore.IncK8sAPIEventCounter()
 }
 
@@ -55,10 +55,10 @@ func (h handlers) deleteFunc(obj interface{}) {
 		return
 	}
 
-	h.context.Work <- events.Event{
+	h.context.enqueue(events.Event{
 		Type:  events.Delete,
 		Value: obj,
-	}
+	})
 	h.context.metricStore.IncK8sAPIEventCounter()
 }
 
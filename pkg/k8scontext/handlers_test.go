@@ -10,16 +10,21 @@ import (
 
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 
 	"github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"k8s.io/client-go/kubernetes"
 	testclient "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
 
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/agic_crd_client/clientset/versioned/fake"
 	istioFake "github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/istio_crd_client/clientset/versioned/fake"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/utils"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/worker"
 
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/metricstore"
 )
@@ -46,7 +51,7 @@ var _ = ginkgo.Describe("K8scontext General Cache Handlers", func() {
 		})
 		Expect(err).ToNot(HaveOccurred())
 
-		context = NewContext(k8sClient, fake.NewSimpleClientset(), istioFake.NewSimpleClientset(), []string{"ns"}, 1000*time.Second, metricstore.NewFakeMetricStore())
+		context = NewContext(k8sClient, fake.NewSimpleClientset(), istioFake.NewSimpleClientset(), []string{"ns"}, 1000*time.Second, DefaultWorkQueueDepth, metricstore.NewFakeMetricStore())
 		h = handlers{
 			context: context,
 		}
@@ -77,4 +82,148 @@ var _ = ginkgo.Describe("K8scontext General Cache Handlers", func() {
 			Expect(len(h.context.Work)).To(Equal(0))
 		})
 	})
+
+	ginkgo.Context("Test pod generations", func() {
+		ginkgo.It("changes when pods are added, deleted or relabeled", func() {
+			pod := tests.NewPodTestFixture("ns", "pod")
+			Expect(context.PodsGeneration("ns")).To(Equal(uint64(0)))
+
+			h.podAdd(&pod)
+			Expect(context.PodsGeneration("ns")).To(Equal(uint64(1)))
+
+			updated := pod.DeepCopy()
+			updated.Status.Phase = v1.PodRunning
+			h.podUpdate(&pod, updated)
+			Expect(context.PodsGeneration("ns")).To(Equal(uint64(1)))
+
+			relabeled := updated.DeepCopy()
+			relabeled.Labels = map[string]string{"app": "other"}
+			h.podUpdate(updated, relabeled)
+			Expect(context.PodsGeneration("ns")).To(Equal(uint64(2)))
+
+			h.podDelete(cache.DeletedFinalStateUnknown{Key: "ns/pod", Obj: relabeled})
+			Expect(context.PodsGeneration("ns")).To(Equal(uint64(3)))
+			Expect(context.PodsGeneration("ns1")).To(Equal(uint64(0)))
+		})
+	})
+
+	ginkgo.Context("Test service handlers", func() {
+		ginkgo.It("enqueues an event only when the spec of the service changed", func() {
+			service := tests.NewServiceFixture(*tests.NewServicePortsFixture()...)
+			service.Namespace = "ns"
+
+			updated := service.DeepCopy()
+			updated.Status.LoadBalancer.Ingress = []v1.LoadBalancerIngress{{IP: "1.2.3.4"}}
+			updated.ResourceVersion = "2"
+			h.serviceUpdate(service, updated)
+			Expect(len(h.context.Work)).To(Equal(0))
+
+			changed := updated.DeepCopy()
+			changed.Spec.Ports[0].TargetPort = intstr.FromInt(9090)
+			h.serviceUpdate(updated, changed)
+			Expect(len(h.context.Work)).To(Equal(1))
+			Expect((<-h.context.Work).Value).To(Equal(changed))
+		})
+
+		ginkgo.It("enqueues an event when the labels of the service changed", func() {
+			service := tests.NewServiceFixture(*tests.NewServicePortsFixture()...)
+			service.Namespace = "ns"
+
+			relabeled := service.DeepCopy()
+			relabeled.Labels = map[string]string{"group": "web"}
+			h.serviceUpdate(service, relabeled)
+			Expect(len(h.context.Work)).To(Equal(1))
+			Expect((<-h.context.Work).Value).To(Equal(relabeled))
+		})
+
+		ginkgo.It("enqueues an event with no object when the service leaves the backend service selector", func() {
+			ingress := tests.NewIngressFixture()
+			ingress.Namespace = "ns"
+			ingress.Annotations[annotations.BackendServiceSelectorKey] = "group=web"
+			Expect(context.Caches.Ingress.Add(ingress)).ToNot(HaveOccurred())
+
+			service := tests.NewServiceFixture(*tests.NewServicePortsFixture()...)
+			service.Namespace = "ns"
+			service.Name = "selected"
+			service.Labels = map[string]string{"group": "web"}
+			Expect(context.IsServiceReferencedByAnyIngress(service)).To(BeTrue())
+
+			relabeled := service.DeepCopy()
+			relabeled.Labels = map[string]string{"group": "internal"}
+			Expect(context.IsServiceReferencedByAnyIngress(relabeled)).To(BeFalse())
+
+			h.serviceUpdate(service, relabeled)
+			Expect(len(h.context.Work)).To(Equal(1))
+			event := <-h.context.Work
+			Expect(event.Type).To(Equal(events.Update))
+			Expect(event.Value).To(BeNil(), "the worker must not skip the event of the service no ingress references anymore")
+		})
+	})
+
+	ginkgo.Context("Test bounded work queue", func() {
+		ginkgo.It("should drop the oldest events instead of blocking when the queue is full", func() {
+			context.Work = make(chan events.Event, 2)
+			for _, name := range []string{"pod1", "pod2", "pod3"} {
+				pod := tests.NewPodTestFixture("ns", name)
+				h.addFunc(&pod)
+			}
+
+			Expect(len(h.context.Work)).To(Equal(2))
+			first := <-h.context.Work
+			Expect(first.Value.(*v1.Pod).Name).To(Equal("pod2"))
+			last := <-h.context.Work
+			Expect(last.Type).To(Equal(events.Update))
+			Expect(last.Value).To(BeNil(), "an event with no object takes the place of the dropped events")
+		})
+
+		ginkgo.It("should reconcile a dropped ingress event when only irrelevant pod events remain", func() {
+			context.Work = make(chan events.Event, 2)
+			ingress := tests.NewIngressFixture()
+			context.enqueue(events.Event{
+				Type:  events.Update,
+				Value: ingress,
+			})
+			for _, name := range []string{"pod1", "pod2", "pod3", "pod4"} {
+				pod := tests.NewPodTestFixture("ns", name)
+				context.enqueue(events.Event{
+					Type:  events.Update,
+					Value: &pod,
+				})
+			}
+
+			mutated := make(chan interface{}, 1)
+			w := worker.Worker{
+				EventProcessor: podSkippingProcessor{
+					mutated: mutated,
+				},
+			}
+			stopChannel := make(chan struct{})
+			defer close(stopChannel)
+			go w.Run(context.Work, stopChannel)
+
+			Eventually(mutated).Should(Receive())
+		})
+	})
 })
+
+// podSkippingProcessor skips the events of pods, like the controller does for the pods no ingress references.
+type podSkippingProcessor struct {
+	mutated chan interface{}
+}
+
+func (p podSkippingProcessor) MutateAppGateway() error {
+	select {
+	case p.mutated <- nil:
+	default:
+	}
+	return nil
+}
+
+func (p podSkippingProcessor) MutateAKS() error {
+	return nil
+}
+
+func (p podSkippingProcessor) ShouldProcess(event events.Event) (bool, *string) {
+	_, isPod := event.Value.(*v1.Pod)
+	return !isPod, nil
+}
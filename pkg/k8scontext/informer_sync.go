@@ -0,0 +1,109 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package k8scontext
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/metricstore"
+)
+
+// syncPollPeriod is how often the informers are checked for the completion of their initial sync.
+const syncPollPeriod = 100 * time.Millisecond
+
+// GetInformerSyncTimeout returns how long AGIC waits for the initial sync of its caches; 0 when it waits indefinitely.
+func GetInformerSyncTimeout(env environment.EnvVariables) time.Duration {
+	if env.InformerSyncTimeout == "" {
+		return 0
+	}
+	seconds, err := strconv.Atoi(env.InformerSyncTimeout)
+	if err != nil {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// waitForCacheSync waits for the informers, by the names of the resources they watch, to complete their initial sync;
+// a name may stand for several informers. It gives up when the stop channel is closed, or once the timeout, when not 0,
+// runs out: the names of the informers, which did not sync in time, are then returned with the error.
+// The metric store follows which informers completed their sync while AGIC waits.
+func waitForCacheSync(stopChannel <-chan struct{}, timeout time.Duration, hasSynced map[string][]cache.InformerSynced, metricStore metricstore.MetricStore) ([]string, error) {
+	synced := make(map[string]bool)
+	for name := range hasSynced {
+		metricStore.SetInformerSynced(name, false)
+	}
+
+	stop := make(chan struct{})
+	timedOut := make(chan struct{})
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		var timer <-chan time.Time
+		if timeout > 0 {
+			t := time.NewTimer(timeout)
+			defer t.Stop()
+			timer = t.C
+		}
+		select {
+		case <-stopChannel:
+		case <-timer:
+			close(timedOut)
+		case <-done:
+			return
+		}
+		close(stop)
+	}()
+
+	err := wait.PollImmediateUntil(syncPollPeriod, func() (bool, error) {
+		for name, informers := range hasSynced {
+			if synced[name] {
+				continue
+			}
+			if allSynced(informers) {
+				synced[name] = true
+				metricStore.SetInformerSynced(name, true)
+			}
+		}
+		return len(synced) == len(hasSynced), nil
+	}, stop)
+	if err == nil {
+		return nil, nil
+	}
+
+	select {
+	case <-timedOut:
+	default:
+		return nil, ErrorFailedInitialCacheSync
+	}
+
+	var unsynced []string
+	for name := range hasSynced {
+		if !synced[name] {
+			unsynced = append(unsynced, name)
+		}
+	}
+	sort.Strings(unsynced)
+	glog.Errorf("[k8scontext] Informers of %s did not complete their initial sync within %s; the API server may be slow, or AGIC may lack the permission to list them",
+		strings.Join(unsynced, ", "), timeout)
+	return unsynced, ErrorInitialCacheSyncTimeout
+}
+
+func allSynced(informers []cache.InformerSynced) bool {
+	for _, hasSynced := range informers {
+		if !hasSynced() {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,114 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package k8scontext
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	testclient "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/agic_crd_client/clientset/versioned/fake"
+	istioFake "github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/istio_crd_client/clientset/versioned/fake"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/metricstore"
+)
+
+// syncedMetricStore records the informer_synced metric.
+type syncedMetricStore struct {
+	metricstore.MetricStore
+	sync.Mutex
+	synced map[string]bool
+}
+
+func (ms *syncedMetricStore) SetInformerSynced(informer string, synced bool) {
+	ms.Lock()
+	defer ms.Unlock()
+	ms.synced[informer] = synced
+}
+
+var _ = ginkgo.Describe("timeout of the initial cache sync", func() {
+	var metricStore *syncedMetricStore
+	var stopChannel chan struct{}
+
+	synced := func() bool { return true }
+	stalled := func() bool { return false }
+
+	ginkgo.BeforeEach(func() {
+		metricStore = &syncedMetricStore{MetricStore: metricstore.NewFakeMetricStore(), synced: make(map[string]bool)}
+		stopChannel = make(chan struct{})
+	})
+
+	ginkgo.AfterEach(func() {
+		close(stopChannel)
+	})
+
+	ginkgo.It("reports the informers, which did not sync in time", func() {
+		hasSynced := map[string][]cache.InformerSynced{
+			"pods":      {stalled},
+			"services":  {synced},
+			"endpoints": {synced, stalled},
+		}
+		unsynced, err := waitForCacheSync(stopChannel, 200*time.Millisecond, hasSynced, metricStore)
+		Expect(err).To(Equal(ErrorInitialCacheSyncTimeout))
+		Expect(unsynced).To(Equal([]string{"endpoints", "pods"}))
+		Expect(metricStore.synced).To(Equal(map[string]bool{"pods": false, "services": true, "endpoints": false}))
+	})
+
+	ginkgo.It("waits for the informers to sync", func() {
+		var mutex sync.Mutex
+		podsSynced := false
+		time.AfterFunc(200*time.Millisecond, func() {
+			mutex.Lock()
+			defer mutex.Unlock()
+			podsSynced = true
+		})
+		hasSynced := map[string][]cache.InformerSynced{
+			"pods": {func() bool {
+				mutex.Lock()
+				defer mutex.Unlock()
+				return podsSynced
+			}},
+		}
+		unsynced, err := waitForCacheSync(stopChannel, 0, hasSynced, metricStore)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(unsynced).To(BeEmpty())
+		Expect(metricStore.synced).To(Equal(map[string]bool{"pods": true}))
+	})
+
+	ginkgo.It("fails without a timeout when stopped", func() {
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			close(stopChannel)
+		}()
+		_, err := waitForCacheSync(stopChannel, 0, map[string][]cache.InformerSynced{"pods": {stalled}}, metricStore)
+		Expect(err).To(Equal(ErrorFailedInitialCacheSync))
+		stopChannel = make(chan struct{})
+	})
+
+	ginkgo.It("fails the run once the timeout runs out, when AGIC may not list a resource", func() {
+		k8sClient := testclient.NewSimpleClientset()
+		k8sClient.PrependReactor("list", "pods", func(k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, apierrors.NewForbidden(v1.Resource("pods"), "", errors.New("forbidden"))
+		})
+		ctxt := NewContext(k8sClient, fake.NewSimpleClientset(), istioFake.NewSimpleClientset(), nil, time.Second, DefaultWorkQueueDepth, metricStore)
+
+		env := environment.GetFakeEnv()
+		env.WatchNamespace = ""
+		env.InformerSyncTimeout = "1"
+		Expect(ctxt.Run(stopChannel, true, env)).To(Equal(ErrorInitialCacheSyncTimeout))
+		Expect(metricStore.synced).To(HaveKeyWithValue("pods", false))
+		Expect(metricStore.synced).To(HaveKeyWithValue("services", true))
+	})
+})
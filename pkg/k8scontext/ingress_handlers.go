@@ -7,6 +7,7 @@ import (
 	"k8s.io/api/extensions/v1beta1"
 	"k8s.io/client-go/tools/cache"
 
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/utils"
 )
@@ -45,10 +46,10 @@ func (h handlers) ingressAdd(obj interface{}) {
 			h.context.ingressSecretsMap.Insert(ingKey, secKey)
 		}
 	}
-	h.context.Work <- events.Event{
+	h.context.enqueue(events.Event{
 		Type:  events.Create,
 		Value: obj,
-	}
+	})
 	h.context.metricStore.IncK8sAPIEventCounter()
 }
 
@@ -78,10 +79,10 @@ func (h handlers) ingressDelete(obj interface{}) {
 	ingKey := utils.GetResourceKey(ing.Namespace, ing.Name)
 	h.context.ingressSecretsMap.Erase(ingKey)
 
-	h.context.Work <- events.Event{
+	h.context.enqueue(events.Event{
 		Type:  events.Delete,
 		Value: obj,
-	}
+	})
 	h.context.metricStore.IncK8sAPIEventCounter()
 }
 
@@ -94,10 +95,10 @@ func (h handlers) ingressUpdate(oldObj, newObj interface{}) {
 		return
 	}
 
-	if reflect.DeepEqual(oldObj, newObj) {
+	oldIng := oldObj.(*v1beta1.Ingress)
+	if !hasRelevantChanges(oldIng, ing) {
 		return
 	}
-	oldIng := oldObj.(*v1beta1.Ingress)
 	if !IsIngressApplicationGateway(ing) && !IsIngressApplicationGateway(oldIng) {
 		return
 	}
@@ -123,9 +124,29 @@ func (h handlers) ingressUpdate(oldObj, newObj interface{}) {
 		}
 	}
 
-	h.context.Work <- events.Event{
+	h.context.enqueue(events.Event{
 		Type:  events.Update,
 		Value: newObj,
-	}
+	})
 	h.context.metricStore.IncK8sAPIEventCounter()
 }
+
+// hasRelevantChanges determines whether the update of the ingress can affect the App Gateway config.
+// Only the spec, which references the TLS secrets, and the annotations AGIC reads are compared: status updates and
+// annotations patched by other controllers do not trigger a reconcile.
+func hasRelevantChanges(oldIng, newIng *v1beta1.Ingress) bool {
+	if !reflect.DeepEqual(oldIng.Spec, newIng.Spec) {
+		return true
+	}
+	return !reflect.DeepEqual(relevantAnnotations(oldIng), relevantAnnotations(newIng))
+}
+
+func relevantAnnotations(ing *v1beta1.Ingress) map[string]string {
+	relevant := make(map[string]string)
+	for key, val := range ing.Annotations {
+		if annotations.IsRelevant(key) {
+			relevant[key] = val
+		}
+	}
+	return relevant
+}
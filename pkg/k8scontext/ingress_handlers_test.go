@@ -15,6 +15,7 @@ import (
 	"k8s.io/client-go/kubernetes"
 	testclient "k8s.io/client-go/kubernetes/fake"
 
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/agic_crd_client/clientset/versioned/fake"
 	istioFake "github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/istio_crd_client/clientset/versioned/fake"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/metricstore"
@@ -51,7 +52,7 @@ var _ = ginkgo.Describe("K8scontext Ingress Cache Handlers", func() {
 		_, err = k8sClient.CoreV1().Secrets("ns1").Create(secret)
 		Expect(err).To(BeNil())
 
-		context = NewContext(k8sClient, fake.NewSimpleClientset(), istioFake.NewSimpleClientset(), []string{"ns"}, 1000*time.Second, metricstore.NewFakeMetricStore())
+		context = NewContext(k8sClient, fake.NewSimpleClientset(), istioFake.NewSimpleClientset(), []string{"ns"}, 1000*time.Second, DefaultWorkQueueDepth, metricstore.NewFakeMetricStore())
 		h = handlers{
 			context: context,
 		}
@@ -81,5 +82,41 @@ var _ = ginkgo.Describe("K8scontext Ingress Cache Handlers", func() {
 			h.ingressUpdate(ing, ing)
 			Expect(len(h.context.Work)).To(Equal(0))
 		})
+
+		ginkgo.It("should not add events for annotations patched by other controllers", func() {
+			oldIng := fixtures.GetIngress()
+			oldIng.Namespace = "ns"
+			newIng := oldIng.DeepCopy()
+			newIng.Annotations["cert-manager.io/issuer"] = "letsencrypt"
+			newIng.Annotations["external-dns.alpha.kubernetes.io/hostname"] = "foo.baz"
+			newIng.ResourceVersion = "2"
+			newIng.Status.LoadBalancer.Ingress = []v1.LoadBalancerIngress{{IP: "1.2.3.4"}}
+			h.ingressUpdate(oldIng, newIng)
+			Expect(len(h.context.Work)).To(Equal(0))
+		})
+
+		ginkgo.It("should not add events for the applied config hash annotated by AGIC", func() {
+			oldIng := fixtures.GetIngress()
+			oldIng.Namespace = "ns"
+			newIng := oldIng.DeepCopy()
+			newIng.Annotations[annotations.AppliedConfigHashKey] = "8f14e45f"
+			h.ingressUpdate(oldIng, newIng)
+			Expect(len(h.context.Work)).To(Equal(0))
+		})
+
+		ginkgo.It("should add events for changes to AGIC annotations and to the spec", func() {
+			oldIng := fixtures.GetIngress()
+			oldIng.Namespace = "ns"
+
+			newIng := oldIng.DeepCopy()
+			newIng.Annotations[annotations.RequestTimeoutKey] = "10"
+			h.ingressUpdate(oldIng, newIng)
+			Expect(len(h.context.Work)).To(Equal(1))
+
+			newIng = oldIng.DeepCopy()
+			newIng.Spec.Rules[0].Host = "bar.baz"
+			h.ingressUpdate(oldIng, newIng)
+			Expect(len(h.context.Work)).To(Equal(2))
+		})
 	})
 })
@@ -0,0 +1,355 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package k8scontext
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+)
+
+var (
+	// NetworkingV1IngressGVR is the resource of the Ingresses of networking.k8s.io/v1, served from Kubernetes 1.19.
+	NetworkingV1IngressGVR = schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"}
+
+	// NetworkingV1beta1IngressGVR is the resource of the Ingresses of networking.k8s.io/v1beta1, served from Kubernetes 1.14 to 1.21.
+	NetworkingV1beta1IngressGVR = schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1beta1", Resource: "ingresses"}
+
+	// ExtensionsIngressGVR is the resource of the Ingresses of extensions/v1beta1, served up to Kubernetes 1.21.
+	ExtensionsIngressGVR = schema.GroupVersionResource{Group: "extensions", Version: "v1beta1", Resource: "ingresses"}
+)
+
+// IngressGVRs are the resources of the Ingresses in order of preference.
+var IngressGVRs = []schema.GroupVersionResource{
+	NetworkingV1IngressGVR,
+	NetworkingV1beta1IngressGVR,
+	ExtensionsIngressGVR,
+}
+
+// pathTypePrefix is the path type of the paths of networking.k8s.io/v1 Ingresses, which match the paths they prefix.
+const pathTypePrefix = "Prefix"
+
+// selectIngressGVR returns the resource of the Ingresses AGIC consumes: the one of the configured API version, or else
+// the newest one the API server serves. AGIC has no typed client for networking.k8s.io/v1 and reads these Ingresses
+// with the dynamic client.
+func (c *Context) selectIngressGVR(apiVersion string) schema.GroupVersionResource {
+	for _, gvr := range IngressGVRs {
+		if apiVersion != "" && gvr.GroupVersion().String() != apiVersion {
+			continue
+		}
+		if gvr == NetworkingV1IngressGVR && c.dynamicClient == nil {
+			glog.Warningf("[k8scontext] No dynamic client; Ingresses of %s will not be consumed", gvr.GroupVersion())
+			continue
+		}
+		if apiVersion != "" || c.isResourceServed(gvr) {
+			return gvr
+		}
+	}
+	return ExtensionsIngressGVR
+}
+
+// newIngressInformer creates the informer of the Ingresses. It lists and watches the Ingresses of the API version AGIC
+// consumes, selected when the context runs, and caches them as extensions/v1beta1 Ingresses, which the config builder
+// is written against.
+func (c *Context) newIngressInformer(resyncPeriod time.Duration) cache.SharedIndexInformer {
+	listWatch := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			return c.listIngresses(options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return c.watchIngresses(options)
+		},
+	}
+	return cache.NewSharedIndexInformer(listWatch, &v1beta1.Ingress{}, resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+}
+
+func (c *Context) listIngresses(options metav1.ListOptions) (*v1beta1.IngressList, error) {
+	switch c.ingressGVR {
+	case NetworkingV1IngressGVR:
+		list, err := c.dynamicClient.Resource(c.ingressGVR).Namespace(metav1.NamespaceAll).List(options)
+		if err != nil {
+			return nil, err
+		}
+		ingressList := &v1beta1.IngressList{ListMeta: metav1.ListMeta{ResourceVersion: list.GetResourceVersion(), Continue: list.GetContinue()}}
+		for idx := range list.Items {
+			ingress, err := FromNetworkingV1Ingress(&list.Items[idx])
+			if err != nil {
+				glog.Errorf("[k8scontext] Ignoring Ingress %s/%s: %s", list.Items[idx].GetNamespace(), list.Items[idx].GetName(), err)
+				continue
+			}
+			ingressList.Items = append(ingressList.Items, *ingress)
+		}
+		return ingressList, nil
+	case NetworkingV1beta1IngressGVR:
+		list, err := c.kubeClient.NetworkingV1beta1().Ingresses(metav1.NamespaceAll).List(options)
+		if err != nil {
+			return nil, err
+		}
+		ingressList := &v1beta1.IngressList{ListMeta: list.ListMeta}
+		for idx := range list.Items {
+			ingressList.Items = append(ingressList.Items, *FromNetworkingV1beta1Ingress(&list.Items[idx]))
+		}
+		return ingressList, nil
+	default:
+		return c.kubeClient.ExtensionsV1beta1().Ingresses(metav1.NamespaceAll).List(options)
+	}
+}
+
+func (c *Context) watchIngresses(options metav1.ListOptions) (watch.Interface, error) {
+	switch c.ingressGVR {
+	case NetworkingV1IngressGVR:
+		w, err := c.dynamicClient.Resource(c.ingressGVR).Namespace(metav1.NamespaceAll).Watch(options)
+		if err != nil {
+			return nil, err
+		}
+		return watch.Filter(w, func(event watch.Event) (watch.Event, bool) {
+			obj, ok := event.Object.(*unstructured.Unstructured)
+			if !ok {
+				return event, true
+			}
+			ingress, err := FromNetworkingV1Ingress(obj)
+			if err != nil {
+				glog.Errorf("[k8scontext] Ignoring Ingress %s/%s: %s", obj.GetNamespace(), obj.GetName(), err)
+				return event, false
+			}
+			event.Object = ingress
+			return event, true
+		}), nil
+	case NetworkingV1beta1IngressGVR:
+		w, err := c.kubeClient.NetworkingV1beta1().Ingresses(metav1.NamespaceAll).Watch(options)
+		if err != nil {
+			return nil, err
+		}
+		return watch.Filter(w, func(event watch.Event) (watch.Event, bool) {
+			if ingress, ok := event.Object.(*networkingv1beta1.Ingress); ok {
+				event.Object = FromNetworkingV1beta1Ingress(ingress)
+			}
+			return event, true
+		}), nil
+	default:
+		return c.kubeClient.ExtensionsV1beta1().Ingresses(metav1.NamespaceAll).Watch(options)
+	}
+}
+
+// FromNetworkingV1beta1Ingress converts a networking.k8s.io/v1beta1 Ingress, whose fields are the ones of
+// extensions/v1beta1, to an extensions/v1beta1 Ingress.
+func FromNetworkingV1beta1Ingress(ing *networkingv1beta1.Ingress) *v1beta1.Ingress {
+	ingress := &v1beta1.Ingress{
+		ObjectMeta: ing.ObjectMeta,
+		Status:     v1beta1.IngressStatus{LoadBalancer: ing.Status.LoadBalancer},
+	}
+	if ing.Spec.Backend != nil {
+		ingress.Spec.Backend = &v1beta1.IngressBackend{ServiceName: ing.Spec.Backend.ServiceName, ServicePort: ing.Spec.Backend.ServicePort}
+	}
+	for _, tls := range ing.Spec.TLS {
+		ingress.Spec.TLS = append(ingress.Spec.TLS, v1beta1.IngressTLS{Hosts: tls.Hosts, SecretName: tls.SecretName})
+	}
+	for _, rule := range ing.Spec.Rules {
+		ingressRule := v1beta1.IngressRule{Host: rule.Host}
+		if rule.HTTP != nil {
+			ingressRule.HTTP = &v1beta1.HTTPIngressRuleValue{}
+			for _, path := range rule.HTTP.Paths {
+				ingressRule.HTTP.Paths = append(ingressRule.HTTP.Paths, v1beta1.HTTPIngressPath{
+					Path:    path.Path,
+					Backend: v1beta1.IngressBackend{ServiceName: path.Backend.ServiceName, ServicePort: path.Backend.ServicePort},
+				})
+			}
+		}
+		ingress.Spec.Rules = append(ingress.Spec.Rules, ingressRule)
+	}
+	return ingress
+}
+
+// networkingV1Ingress holds the fields of a networking.k8s.io/v1 Ingress AGIC reads; the Kubernetes API types AGIC is
+// built with predate this API version.
+type networkingV1Ingress struct {
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              networkingV1IngressSpec `json:"spec,omitempty"`
+	Status            v1beta1.IngressStatus   `json:"status,omitempty"`
+}
+
+type networkingV1IngressSpec struct {
+	IngressClassName *string                     `json:"ingressClassName,omitempty"`
+	DefaultBackend   *networkingV1IngressBackend `json:"defaultBackend,omitempty"`
+	TLS              []v1beta1.IngressTLS        `json:"tls,omitempty"`
+	Rules            []networkingV1IngressRule   `json:"rules,omitempty"`
+}
+
+type networkingV1IngressRule struct {
+	Host string `json:"host,omitempty"`
+	HTTP *struct {
+		Paths []networkingV1HTTPIngressPath `json:"paths"`
+	} `json:"http,omitempty"`
+}
+
+type networkingV1HTTPIngressPath struct {
+	Path     string                     `json:"path,omitempty"`
+	PathType *string                    `json:"pathType,omitempty"`
+	Backend  networkingV1IngressBackend `json:"backend"`
+}
+
+type networkingV1IngressBackend struct {
+	Service *struct {
+		Name string `json:"name"`
+		Port struct {
+			Name   string `json:"name,omitempty"`
+			Number int32  `json:"number,omitempty"`
+		} `json:"port,omitempty"`
+	} `json:"service,omitempty"`
+	Resource *v1.TypedLocalObjectReference `json:"resource,omitempty"`
+}
+
+// FromNetworkingV1Ingress converts a networking.k8s.io/v1 Ingress to an extensions/v1beta1 Ingress. The ingressClassName
+// becomes the `kubernetes.io/ingress.class` annotation, unless the ingress has it, and the IngressClass of AGIC becomes
+// the ApplicationGatewayIngressClass. The service of a backend becomes its serviceName and servicePort; paths and
+// default backends with a resource backend are left out, as AGIC only routes to services. Paths of the Prefix type
+// get a trailing "*", with which App Gateway matches the paths they prefix.
+func FromNetworkingV1Ingress(obj *unstructured.Unstructured) (*v1beta1.Ingress, error) {
+	var ing networkingV1Ingress
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &ing); err != nil {
+		return nil, err
+	}
+
+	ingress := &v1beta1.Ingress{
+		ObjectMeta: ing.ObjectMeta,
+		Spec:       v1beta1.IngressSpec{TLS: ing.Spec.TLS},
+		Status:     ing.Status,
+	}
+	if ingressClass := ing.Spec.IngressClassName; ingressClass != nil {
+		if _, exists := ingress.Annotations[annotations.IngressClassKey]; !exists {
+			if ingress.Annotations == nil {
+				ingress.Annotations = make(map[string]string)
+			}
+			ingress.Annotations[annotations.IngressClassKey] = *ingressClass
+			if *ingressClass == annotations.ApplicationGatewayIngressClassName {
+				ingress.Annotations[annotations.IngressClassKey] = annotations.ApplicationGatewayIngressClass
+			}
+		}
+	}
+	if ing.Spec.DefaultBackend != nil {
+		backend, err := fromNetworkingV1IngressBackend(*ing.Spec.DefaultBackend)
+		if err != nil {
+			glog.Warningf("[k8scontext] Ingress %s/%s: default backend: %s", ing.Namespace, ing.Name, err)
+		} else {
+			ingress.Spec.Backend = backend
+		}
+	}
+	for _, rule := range ing.Spec.Rules {
+		ingressRule := v1beta1.IngressRule{Host: rule.Host}
+		if rule.HTTP != nil {
+			ingressRule.HTTP = &v1beta1.HTTPIngressRuleValue{}
+			for _, path := range rule.HTTP.Paths {
+				backend, err := fromNetworkingV1IngressBackend(path.Backend)
+				if err != nil {
+					glog.Warningf("[k8scontext] Ingress %s/%s: path %s: %s", ing.Namespace, ing.Name, path.Path, err)
+					continue
+				}
+				ingressRule.HTTP.Paths = append(ingressRule.HTTP.Paths, v1beta1.HTTPIngressPath{
+					Path:    pathOfPathType(path.Path, path.PathType),
+					Backend: *backend,
+				})
+			}
+		}
+		ingress.Spec.Rules = append(ingress.Spec.Rules, ingressRule)
+	}
+	return ingress, nil
+}
+
+func fromNetworkingV1IngressBackend(backend networkingV1IngressBackend) (*v1beta1.IngressBackend, error) {
+	if backend.Service == nil {
+		return nil, fmt.Errorf("backend is not a service")
+	}
+	servicePort := intstr.FromInt(int(backend.Service.Port.Number))
+	if backend.Service.Port.Name != "" {
+		servicePort = intstr.FromString(backend.Service.Port.Name)
+	}
+	return &v1beta1.IngressBackend{ServiceName: backend.Service.Name, ServicePort: servicePort}, nil
+}
+
+// pathOfPathType returns the App Gateway path matching the path of the path type. Exact and implementation specific
+// paths are matched as they are, like the paths of the earlier API versions.
+func pathOfPathType(path string, pathType *string) string {
+	if pathType == nil || *pathType != pathTypePrefix || strings.HasSuffix(path, "*") {
+		return path
+	}
+	if path == "" {
+		path = "/"
+	}
+	return path + "*"
+}
+
+func (c *Context) updateNetworkingV1beta1IngressStatus(ingressToUpdate v1beta1.Ingress, newLoadBalancer v1.LoadBalancerIngress) error {
+	ingressClient := c.kubeClient.NetworkingV1beta1().Ingresses(ingressToUpdate.Namespace)
+	ingress, err := ingressClient.Get(ingressToUpdate.Name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("Unable to get ingress %s/%s", ingressToUpdate.Namespace, ingressToUpdate.Name)
+	}
+
+	loadBalancerIngresses, changed := loadBalancerIngressesWith(ingress.Status.LoadBalancer.Ingress, newLoadBalancer)
+	if !changed {
+		glog.V(5).Infof("Load balancer %+v already set on Ingress %s/%s", newLoadBalancer, ingress.Namespace, ingress.Name)
+		return nil
+	}
+	ingress.Status.LoadBalancer.Ingress = loadBalancerIngresses
+
+	if _, err := ingressClient.UpdateStatus(ingress); err != nil {
+		glog.Errorf("Unable to update ingress %s/%s status: error %s", ingress.Namespace, ingress.Name, err)
+		return ErrorUnableToUpdateIngress
+	}
+	return nil
+}
+
+func (c *Context) updateNetworkingV1IngressStatus(ingressToUpdate v1beta1.Ingress, newLoadBalancer v1.LoadBalancerIngress) error {
+	ingressClient := c.dynamicClient.Resource(NetworkingV1IngressGVR).Namespace(ingressToUpdate.Namespace)
+	obj, err := ingressClient.Get(ingressToUpdate.Name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("Unable to get ingress %s/%s", ingressToUpdate.Namespace, ingressToUpdate.Name)
+	}
+	ingress, err := FromNetworkingV1Ingress(obj)
+	if err != nil {
+		return fmt.Errorf("Unable to read ingress %s/%s: %s", ingressToUpdate.Namespace, ingressToUpdate.Name, err)
+	}
+
+	loadBalancerIngresses, changed := loadBalancerIngressesWith(ingress.Status.LoadBalancer.Ingress, newLoadBalancer)
+	if !changed {
+		glog.V(5).Infof("Load balancer %+v already set on Ingress %s/%s", newLoadBalancer, ingress.Namespace, ingress.Name)
+		return nil
+	}
+	statusIngresses := []interface{}{}
+	for _, lbi := range loadBalancerIngresses {
+		statusIngress := map[string]interface{}{}
+		if lbi.IP != "" {
+			statusIngress["ip"] = lbi.IP
+		}
+		if lbi.Hostname != "" {
+			statusIngress["hostname"] = lbi.Hostname
+		}
+		statusIngresses = append(statusIngresses, statusIngress)
+	}
+	if err := unstructured.SetNestedSlice(obj.Object, statusIngresses, "status", "loadBalancer", "ingress"); err != nil {
+		return err
+	}
+
+	if _, err := ingressClient.UpdateStatus(obj, metav1.UpdateOptions{}); err != nil {
+		glog.Errorf("Unable to update ingress %s/%s status: error %s", ingress.Namespace, ingress.Name, err)
+		return ErrorUnableToUpdateIngress
+	}
+	return nil
+}
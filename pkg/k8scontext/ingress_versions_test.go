@@ -0,0 +1,343 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package k8scontext
+
+import (
+	"time"
+
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	testclient "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/annotations"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/agic_crd_client/clientset/versioned/fake"
+	istioFake "github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/istio_crd_client/clientset/versioned/fake"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/metricstore"
+)
+
+var _ = ginkgo.Describe("Ingress API versions", func() {
+	const namespace = "ns"
+
+	newNetworkingV1Ingress := func(name string, spec map[string]interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "networking.k8s.io/v1",
+			"kind":       "Ingress",
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": namespace,
+			},
+			"spec": spec,
+		}}
+	}
+
+	serviceBackend := func(name string, port map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"service": map[string]interface{}{"name": name, "port": port},
+		}
+	}
+
+	served := func(groupVersion string) *metav1.APIResourceList {
+		return &metav1.APIResourceList{
+			GroupVersion: groupVersion,
+			APIResources: []metav1.APIResource{{Name: "ingresses", Namespaced: true, Kind: "Ingress"}},
+		}
+	}
+
+	ginkgo.Context("networking.k8s.io/v1 field mapping", func() {
+		ginkgo.It("maps the backends, TLS and status", func() {
+			obj := newNetworkingV1Ingress("ing", map[string]interface{}{
+				"defaultBackend": serviceBackend("default", map[string]interface{}{"number": int64(8080)}),
+				"tls": []interface{}{
+					map[string]interface{}{"hosts": []interface{}{"www.contoso.com"}, "secretName": "tls-secret"},
+				},
+				"rules": []interface{}{
+					map[string]interface{}{
+						"host": "www.contoso.com",
+						"http": map[string]interface{}{
+							"paths": []interface{}{
+								map[string]interface{}{
+									"path":     "/api",
+									"pathType": "ImplementationSpecific",
+									"backend":  serviceBackend("api", map[string]interface{}{"name": "http"}),
+								},
+							},
+						},
+					},
+				},
+			})
+			Expect(unstructured.SetNestedSlice(obj.Object, []interface{}{map[string]interface{}{"ip": "1.2.3.4"}}, "status", "loadBalancer", "ingress")).To(Succeed())
+
+			ingress, err := FromNetworkingV1Ingress(obj)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ingress.Namespace).To(Equal(namespace))
+			Expect(ingress.Name).To(Equal("ing"))
+			Expect(ingress.Spec).To(Equal(v1beta1.IngressSpec{
+				Backend: &v1beta1.IngressBackend{ServiceName: "default", ServicePort: intstr.FromInt(8080)},
+				TLS:     []v1beta1.IngressTLS{{Hosts: []string{"www.contoso.com"}, SecretName: "tls-secret"}},
+				Rules: []v1beta1.IngressRule{{
+					Host: "www.contoso.com",
+					IngressRuleValue: v1beta1.IngressRuleValue{HTTP: &v1beta1.HTTPIngressRuleValue{
+						Paths: []v1beta1.HTTPIngressPath{{
+							Path:    "/api",
+							Backend: v1beta1.IngressBackend{ServiceName: "api", ServicePort: intstr.FromString("http")},
+						}},
+					}},
+				}},
+			}))
+			Expect(ingress.Status.LoadBalancer.Ingress).To(Equal([]v1.LoadBalancerIngress{{IP: "1.2.3.4"}}))
+		})
+
+		ginkgo.It("maps the path types to App Gateway paths", func() {
+			path := func(path, pathType string) interface{} {
+				return map[string]interface{}{
+					"path":     path,
+					"pathType": pathType,
+					"backend":  serviceBackend("svc", map[string]interface{}{"number": int64(80)}),
+				}
+			}
+			ingress, err := FromNetworkingV1Ingress(newNetworkingV1Ingress("ing", map[string]interface{}{
+				"rules": []interface{}{
+					map[string]interface{}{
+						"http": map[string]interface{}{
+							"paths": []interface{}{
+								path("/exact", "Exact"),
+								path("/prefix", "Prefix"),
+								path("/prefix/", "Prefix"),
+								path("/", "Prefix"),
+								path("/wildcard/*", "Prefix"),
+								path("/specific", "ImplementationSpecific"),
+							},
+						},
+					},
+				},
+			}))
+			Expect(err).ToNot(HaveOccurred())
+
+			var paths []string
+			for _, path := range ingress.Spec.Rules[0].HTTP.Paths {
+				paths = append(paths, path.Path)
+			}
+			Expect(paths).To(Equal([]string{"/exact", "/prefix*", "/prefix/*", "/*", "/wildcard/*", "/specific"}))
+		})
+
+		ginkgo.It("leaves out the paths with a resource backend", func() {
+			ingress, err := FromNetworkingV1Ingress(newNetworkingV1Ingress("ing", map[string]interface{}{
+				"defaultBackend": map[string]interface{}{
+					"resource": map[string]interface{}{"apiGroup": "k8s.example.com", "kind": "StorageBucket", "name": "static"},
+				},
+				"rules": []interface{}{
+					map[string]interface{}{
+						"http": map[string]interface{}{
+							"paths": []interface{}{
+								map[string]interface{}{
+									"path":     "/static",
+									"pathType": "Prefix",
+									"backend": map[string]interface{}{
+										"resource": map[string]interface{}{"apiGroup": "k8s.example.com", "kind": "StorageBucket", "name": "static"},
+									},
+								},
+								map[string]interface{}{
+									"path":     "/",
+									"pathType": "Prefix",
+									"backend":  serviceBackend("svc", map[string]interface{}{"number": int64(80)}),
+								},
+							},
+						},
+					},
+				},
+			}))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ingress.Spec.Backend).To(BeNil())
+			Expect(ingress.Spec.Rules[0].HTTP.Paths).To(Equal([]v1beta1.HTTPIngressPath{{
+				Path:    "/*",
+				Backend: v1beta1.IngressBackend{ServiceName: "svc", ServicePort: intstr.FromInt(80)},
+			}}))
+		})
+
+		ginkgo.It("maps the ingress class name to the ingress class annotation", func() {
+			agic, err := FromNetworkingV1Ingress(newNetworkingV1Ingress("agic", map[string]interface{}{
+				"ingressClassName": annotations.ApplicationGatewayIngressClassName,
+			}))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(IsIngressApplicationGateway(agic)).To(BeTrue())
+
+			other, err := FromNetworkingV1Ingress(newNetworkingV1Ingress("other", map[string]interface{}{
+				"ingressClassName": "nginx",
+			}))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(other.Annotations).To(HaveKeyWithValue(annotations.IngressClassKey, "nginx"))
+			Expect(IsIngressApplicationGateway(other)).To(BeFalse())
+
+			annotated := newNetworkingV1Ingress("annotated", map[string]interface{}{"ingressClassName": "nginx"})
+			annotated.SetAnnotations(map[string]string{annotations.IngressClassKey: annotations.ApplicationGatewayIngressClass})
+			ingress, err := FromNetworkingV1Ingress(annotated)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(IsIngressApplicationGateway(ingress)).To(BeTrue())
+		})
+	})
+
+	ginkgo.Context("networking.k8s.io/v1beta1 field mapping", func() {
+		ginkgo.It("maps all the fields", func() {
+			ingress := FromNetworkingV1beta1Ingress(&networkingv1beta1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "ing",
+					Namespace:   namespace,
+					Annotations: map[string]string{annotations.IngressClassKey: annotations.ApplicationGatewayIngressClass},
+				},
+				Spec: networkingv1beta1.IngressSpec{
+					Backend: &networkingv1beta1.IngressBackend{ServiceName: "default", ServicePort: intstr.FromInt(8080)},
+					TLS:     []networkingv1beta1.IngressTLS{{Hosts: []string{"www.contoso.com"}, SecretName: "tls-secret"}},
+					Rules: []networkingv1beta1.IngressRule{{
+						Host: "www.contoso.com",
+						IngressRuleValue: networkingv1beta1.IngressRuleValue{HTTP: &networkingv1beta1.HTTPIngressRuleValue{
+							Paths: []networkingv1beta1.HTTPIngressPath{{
+								Path:    "/api/*",
+								Backend: networkingv1beta1.IngressBackend{ServiceName: "api", ServicePort: intstr.FromString("http")},
+							}},
+						}},
+					}},
+				},
+				Status: networkingv1beta1.IngressStatus{
+					LoadBalancer: v1.LoadBalancerStatus{Ingress: []v1.LoadBalancerIngress{{IP: "1.2.3.4"}}},
+				},
+			})
+
+			Expect(IsIngressApplicationGateway(ingress)).To(BeTrue())
+			Expect(ingress.Spec).To(Equal(v1beta1.IngressSpec{
+				Backend: &v1beta1.IngressBackend{ServiceName: "default", ServicePort: intstr.FromInt(8080)},
+				TLS:     []v1beta1.IngressTLS{{Hosts: []string{"www.contoso.com"}, SecretName: "tls-secret"}},
+				Rules: []v1beta1.IngressRule{{
+					Host: "www.contoso.com",
+					IngressRuleValue: v1beta1.IngressRuleValue{HTTP: &v1beta1.HTTPIngressRuleValue{
+						Paths: []v1beta1.HTTPIngressPath{{
+							Path:    "/api/*",
+							Backend: v1beta1.IngressBackend{ServiceName: "api", ServicePort: intstr.FromString("http")},
+						}},
+					}},
+				}},
+			}))
+			Expect(ingress.Status.LoadBalancer.Ingress).To(Equal([]v1.LoadBalancerIngress{{IP: "1.2.3.4"}}))
+		})
+	})
+
+	ginkgo.Context("consumed API version", func() {
+		var k8sClient *testclient.Clientset
+		var ctxt *Context
+		var stopChannel chan struct{}
+		var env environment.EnvVariables
+
+		ginkgo.BeforeEach(func() {
+			stopChannel = make(chan struct{})
+			k8sClient = testclient.NewSimpleClientset()
+			ctxt = NewContext(k8sClient, fake.NewSimpleClientset(), istioFake.NewSimpleClientset(), []string{namespace}, 1000*time.Second, DefaultWorkQueueDepth, metricstore.NewFakeMetricStore())
+			env = environment.GetFakeEnv()
+		})
+
+		ginkgo.AfterEach(func() {
+			close(stopChannel)
+		})
+
+		ginkgo.It("is the newest one served", func() {
+			k8sClient.Fake.Resources = []*metav1.APIResourceList{served("extensions/v1beta1"), served("networking.k8s.io/v1beta1")}
+			Expect(ctxt.selectIngressGVR("")).To(Equal(NetworkingV1beta1IngressGVR))
+
+			k8sClient.Fake.Resources = append(k8sClient.Fake.Resources, served("networking.k8s.io/v1"))
+			Expect(ctxt.selectIngressGVR("")).To(Equal(NetworkingV1beta1IngressGVR), "networking.k8s.io/v1 needs the dynamic client")
+
+			ctxt.SetDynamicClient(dynamicfake.NewSimpleDynamicClient(runtime.NewScheme()))
+			Expect(ctxt.selectIngressGVR("")).To(Equal(NetworkingV1IngressGVR))
+		})
+
+		ginkgo.It("is extensions/v1beta1 when discovery finds none", func() {
+			Expect(ctxt.selectIngressGVR("")).To(Equal(ExtensionsIngressGVR))
+		})
+
+		ginkgo.It("is the configured one", func() {
+			k8sClient.Fake.Resources = []*metav1.APIResourceList{served("extensions/v1beta1"), served("networking.k8s.io/v1beta1")}
+			Expect(ctxt.selectIngressGVR("extensions/v1beta1")).To(Equal(ExtensionsIngressGVR))
+		})
+
+		ginkgo.It("caches the networking.k8s.io/v1 Ingresses and updates their status", func() {
+			k8sClient.Fake.Resources = []*metav1.APIResourceList{served("extensions/v1beta1"), served("networking.k8s.io/v1")}
+			ctxt.SetDynamicClient(dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(),
+				newNetworkingV1Ingress("ing", map[string]interface{}{
+					"ingressClassName": annotations.ApplicationGatewayIngressClassName,
+					"rules": []interface{}{
+						map[string]interface{}{
+							"host": "www.contoso.com",
+							"http": map[string]interface{}{
+								"paths": []interface{}{
+									map[string]interface{}{
+										"path":     "/",
+										"pathType": "Prefix",
+										"backend":  serviceBackend("svc", map[string]interface{}{"number": int64(80)}),
+									},
+								},
+							},
+						},
+					},
+				}),
+			))
+			Expect(ctxt.Run(stopChannel, true, env)).ToNot(HaveOccurred())
+
+			ingresses := ctxt.ListHTTPIngresses()
+			Expect(ingresses).To(HaveLen(1))
+			Expect(ingresses[0].Spec.Rules[0].HTTP.Paths[0].Path).To(Equal("/*"))
+
+			Expect(ctxt.UpdateIngressStatus(*ingresses[0], IPAddress("1.2.3.4"))).To(Succeed())
+			obj, err := ctxt.dynamicClient.Resource(NetworkingV1IngressGVR).Namespace(namespace).Get("ing", metav1.GetOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			statusIngresses, _, _ := unstructured.NestedSlice(obj.Object, "status", "loadBalancer", "ingress")
+			Expect(statusIngresses).To(Equal([]interface{}{map[string]interface{}{"ip": "1.2.3.4"}}))
+
+			Expect(ctxt.UpdateIngressLoadBalancer(*ingresses[0], v1.LoadBalancerIngress{IP: "5.6.7.8", Hostname: "agw.westus.cloudapp.azure.com"})).To(Succeed())
+			obj, err = ctxt.dynamicClient.Resource(NetworkingV1IngressGVR).Namespace(namespace).Get("ing", metav1.GetOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			statusIngresses, _, _ = unstructured.NestedSlice(obj.Object, "status", "loadBalancer", "ingress")
+			Expect(statusIngresses).To(Equal([]interface{}{map[string]interface{}{"ip": "5.6.7.8", "hostname": "agw.westus.cloudapp.azure.com"}}))
+		})
+
+		ginkgo.It("caches the networking.k8s.io/v1beta1 Ingresses", func() {
+			k8sClient.Fake.Resources = []*metav1.APIResourceList{served("extensions/v1beta1"), served("networking.k8s.io/v1beta1")}
+			_, err := k8sClient.NetworkingV1beta1().Ingresses(namespace).Create(&networkingv1beta1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "ing",
+					Namespace:   namespace,
+					Annotations: map[string]string{annotations.IngressClassKey: annotations.ApplicationGatewayIngressClass},
+				},
+				Spec: networkingv1beta1.IngressSpec{
+					Rules: []networkingv1beta1.IngressRule{{
+						IngressRuleValue: networkingv1beta1.IngressRuleValue{HTTP: &networkingv1beta1.HTTPIngressRuleValue{
+							Paths: []networkingv1beta1.HTTPIngressPath{{
+								Backend: networkingv1beta1.IngressBackend{ServiceName: "svc", ServicePort: intstr.FromInt(80)},
+							}},
+						}},
+					}},
+				},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ctxt.Run(stopChannel, true, env)).ToNot(HaveOccurred())
+
+			ingresses := ctxt.ListHTTPIngresses()
+			Expect(ingresses).To(HaveLen(1))
+			Expect(ingresses[0].Spec.Rules[0].HTTP.Paths[0].Backend.ServiceName).To(Equal("svc"))
+
+			Expect(ctxt.UpdateIngressStatus(*ingresses[0], IPAddress("1.2.3.4"))).To(Succeed())
+			updated, err := k8sClient.NetworkingV1beta1().Ingresses(namespace).Get("ing", metav1.GetOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(updated.Status.LoadBalancer.Ingress).To(Equal([]v1.LoadBalancerIngress{{IP: "1.2.3.4"}}))
+		})
+	})
+})
@@ -92,7 +92,7 @@ var _ = ginkgo.Describe("K8scontext", func() {
 		Expect(err).ToNot(HaveOccurred(), "Unabled to create ingress resource due to: %v", err)
 
 		// Create a `k8scontext` to start listening to ingress resources.
-		ctxt = NewContext(k8sClient, crdClient, istioCrdClient, []string{ingressNS}, 1000*time.Second, metricstore.NewFakeMetricStore())
+		ctxt = NewContext(k8sClient, crdClient, istioCrdClient, []string{ingressNS}, 1000*time.Second, DefaultWorkQueueDepth, metricstore.NewFakeMetricStore())
 
 		Expect(ctxt).ShouldNot(BeNil(), "Unable to create `k8scontext`")
 	})
@@ -101,6 +101,24 @@ var _ = ginkgo.Describe("K8scontext", func() {
 		close(stopChannel)
 	})
 
+	ginkgo.Context("Checking the initial sync", func() {
+		ginkgo.It("enqueues a single event once the caches are synced", func() {
+			_, err := k8sClient.CoreV1().Pods(ingressNS).Create(pod)
+			Expect(err).ToNot(HaveOccurred())
+
+			runErr := ctxt.Run(stopChannel, true, environment.GetFakeEnv())
+			Expect(runErr).ToNot(HaveOccurred())
+
+			Expect(ctxt.ListHTTPIngresses()).To(HaveLen(1))
+			Expect(ctxt.Caches.Pods.List()).To(HaveLen(1))
+
+			// The resources listed while the caches synced are not enqueued one by one.
+			Expect(ctxt.Work).To(HaveLen(1))
+			event := <-ctxt.Work
+			Expect(event.Value).To(BeNil())
+		})
+	})
+
 	ginkgo.Context("Checking if we are able to listen to Ingress Resources", func() {
 		ginkgo.It("Should be able to retrieve all Ingress Resources", func() {
 			// Retrieve the Ingress to make sure it was created.
@@ -300,7 +318,7 @@ var _ = ginkgo.Describe("K8scontext", func() {
 			Expect(err).ToNot(HaveOccurred(), "Unable to create service resource due to: %v", err)
 
 			// wait for sync
-			waitContextSync(ctxt, ingress, pod, service)
+			waitContextSync(ctxt, pod, service)
 
 			// check that ctxt synced the service
 			Expect(len(ctxt.ListServices())).To(Equal(1), "Context was not able to sync in time")
@@ -329,7 +347,7 @@ var _ = ginkgo.Describe("K8scontext", func() {
 			Expect(err).ToNot(HaveOccurred(), "Unable to create service resource due to: %v", err)
 
 			// wait for sync
-			waitContextSync(ctxt, ingress, pod, service)
+			waitContextSync(ctxt, pod, service)
 
 			// check that ctxt synced the service
 			Expect(len(ctxt.ListServices())).To(Equal(1), "Context was not able to sync in time")
@@ -360,7 +378,7 @@ var _ = ginkgo.Describe("K8scontext", func() {
 			Expect(err).ToNot(HaveOccurred(), "Unable to create service resource due to: %v", err)
 
 			// wait for sync
-			waitContextSync(ctxt, ingress, service, endpoints)
+			waitContextSync(ctxt, service, endpoints)
 
 			// check that ctxt synced the service
 			Expect(len(ctxt.ListServices())).To(Equal(1), "Context was not able to sync in time")
@@ -390,7 +408,7 @@ var _ = ginkgo.Describe("K8scontext", func() {
 			Expect(err).ToNot(HaveOccurred(), "Unable to create service resource due to: %v", err)
 
 			// wait for sync
-			waitContextSync(ctxt, ingress, service, endpoints)
+			waitContextSync(ctxt, service, endpoints)
 
 			// check that ctxt synced the service
 			Expect(len(ctxt.ListServices())).To(Equal(1), "Context was not able to sync in time")
@@ -0,0 +1,320 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package k8scontext
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/golang/glog"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+)
+
+// MultiClusterServiceNameLabel is the label of the EndpointSlices derived from a ServiceImport, naming the imported service.
+const MultiClusterServiceNameLabel = "multicluster.kubernetes.io/service-name"
+
+// ServiceImportGVR is the resource of the ServiceImports of the Kubernetes multi-cluster services API.
+var ServiceImportGVR = schema.GroupVersionResource{Group: "multicluster.x-k8s.io", Version: "v1alpha1", Resource: "serviceimports"}
+
+// EndpointSliceGVRs are the resources of the EndpointSlices in order of preference.
+var EndpointSliceGVRs = []schema.GroupVersionResource{
+	{Group: "discovery.k8s.io", Version: "v1", Resource: "endpointslices"},
+	{Group: "discovery.k8s.io", Version: "v1beta1", Resource: "endpointslices"},
+}
+
+// SetDynamicClient sets the client used to watch the resources AGIC has no typed client for: the Ingresses of
+// networking.k8s.io/v1, the ServiceImports of multi-cluster services and their EndpointSlices, and the Certificates
+// of cert-manager.
+func (c *Context) SetDynamicClient(dynamicClient dynamic.Interface) {
+	c.dynamicClient = dynamicClient
+}
+
+// watchServiceImports creates the informers for the ServiceImports and the EndpointSlices derived from them.
+// Without the multi-cluster services CRDs, or EndpointSlices, in the cluster no informer is created and imported
+// services are not resolved.
+func (c *Context) watchServiceImports() []cache.SharedInformer {
+	if c.dynamicClient == nil {
+		glog.Warning("[k8scontext] No dynamic client; ServiceImports of multi-cluster services will not be resolved")
+		return nil
+	}
+	if !c.isResourceServed(ServiceImportGVR) {
+		glog.Warningf("[k8scontext] %s is not served by the API server; the multi-cluster services CRDs may not be installed", ServiceImportGVR.GroupResource())
+		return nil
+	}
+	var endpointSliceGVR *schema.GroupVersionResource
+	for idx := range EndpointSliceGVRs {
+		if c.isResourceServed(EndpointSliceGVRs[idx]) {
+			endpointSliceGVR = &EndpointSliceGVRs[idx]
+			break
+		}
+	}
+	if endpointSliceGVR == nil {
+		glog.Warning("[k8scontext] EndpointSlices are not served by the API server; ServiceImports of multi-cluster services will not be resolved")
+		return nil
+	}
+
+	informerFactory := dynamicinformer.NewDynamicSharedInformerFactory(c.dynamicClient, 0)
+	serviceImports := informerFactory.ForResource(ServiceImportGVR).Informer()
+
+	// Only the EndpointSlices derived from ServiceImports are watched, not the ones of the local services.
+	sliceInformerFactory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(c.dynamicClient, 0, metav1.NamespaceAll,
+		func(options *metav1.ListOptions) {
+			options.LabelSelector = MultiClusterServiceNameLabel
+		})
+	endpointSlices := sliceInformerFactory.ForResource(*endpointSliceGVR).Informer()
+
+	handler := c.newDynamicResourceHandler()
+	serviceImports.AddEventHandler(handler)
+	endpointSlices.AddEventHandler(handler)
+
+	c.informers.ServiceImport = serviceImports
+	c.informers.EndpointSlice = endpointSlices
+	c.Caches.ServiceImport = serviceImports.GetStore()
+	c.Caches.EndpointSlice = endpointSlices.GetStore()
+	glog.V(1).Infof("[k8scontext] Watching %s and %s", ServiceImportGVR.GroupResource(), endpointSliceGVR.GroupVersion())
+	return []cache.SharedInformer{serviceImports, endpointSlices}
+}
+
+// newDynamicResourceHandler returns the event handler of the informers of the dynamic client, which enqueues the
+// events of the resources in the watched namespaces.
+func (c *Context) newDynamicResourceHandler() cache.ResourceEventHandlerFuncs {
+	onChange := func(eventType events.EventType) func(obj interface{}) {
+		return func(obj interface{}) {
+			accessor, err := meta.Accessor(obj)
+			if err != nil {
+				return
+			}
+			if _, exists := c.namespaces[accessor.GetNamespace()]; len(c.namespaces) > 0 && !exists {
+				return
+			}
+			c.enqueue(events.Event{
+				Type:  eventType,
+				Value: obj,
+			})
+			c.metricStore.IncK8sAPIEventCounter()
+		}
+	}
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc:    onChange(events.Create),
+		UpdateFunc: func(oldObj, newObj interface{}) { onChange(events.Update)(newObj) },
+		DeleteFunc: onChange(events.Delete),
+	}
+}
+
+func (c *Context) isResourceServed(gvr schema.GroupVersionResource) bool {
+	resources, err := c.kubeClient.Discovery().ServerResourcesForGroupVersion(gvr.GroupVersion().String())
+	if err != nil || resources == nil {
+		return false
+	}
+	for _, resource := range resources.APIResources {
+		if resource.Name == gvr.Resource {
+			return true
+		}
+	}
+	return false
+}
+
+// listImportedServices returns the ServiceImports as services, which select no pods.
+func (c *Context) listImportedServices() []*v1.Service {
+	if c.Caches.ServiceImport == nil {
+		return nil
+	}
+	var services []*v1.Service
+	for _, obj := range c.Caches.ServiceImport.List() {
+		serviceImport, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			continue
+		}
+		if _, exists := c.namespaces[serviceImport.GetNamespace()]; len(c.namespaces) > 0 && !exists {
+			continue
+		}
+		services = append(services, c.newImportedService(serviceImport))
+	}
+	return services
+}
+
+// getImportedService returns the ServiceImport with the key as a service, or nil when there is none.
+func (c *Context) getImportedService(serviceKey string) *v1.Service {
+	if serviceImport := c.getServiceImport(serviceKey); serviceImport != nil {
+		return c.newImportedService(serviceImport)
+	}
+	return nil
+}
+
+func (c *Context) getServiceImport(serviceKey string) *unstructured.Unstructured {
+	if c.Caches.ServiceImport == nil {
+		return nil
+	}
+	obj, exists, err := c.Caches.ServiceImport.GetByKey(serviceKey)
+	if err != nil || !exists {
+		return nil
+	}
+	serviceImport, _ := obj.(*unstructured.Unstructured)
+	return serviceImport
+}
+
+// newImportedService converts the ServiceImport to a service. The service ports target the ports of the same name of
+// the EndpointSlices; an unnamed port targets the unnamed port of the EndpointSlices.
+func (c *Context) newImportedService(serviceImport *unstructured.Unstructured) *v1.Service {
+	service := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        serviceImport.GetName(),
+			Namespace:   serviceImport.GetNamespace(),
+			Labels:      serviceImport.GetLabels(),
+			Annotations: serviceImport.GetAnnotations(),
+		},
+	}
+
+	ports, _, _ := unstructured.NestedSlice(serviceImport.Object, "spec", "ports")
+	for _, p := range ports {
+		port, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		number, _, _ := unstructured.NestedInt64(port, "port")
+		name, _, _ := unstructured.NestedString(port, "name")
+		protocol, _, _ := unstructured.NestedString(port, "protocol")
+		if protocol == "" {
+			protocol = string(v1.ProtocolTCP)
+		}
+		servicePort := v1.ServicePort{
+			Name:     name,
+			Protocol: v1.Protocol(protocol),
+			Port:     int32(number),
+		}
+		if name != "" {
+			servicePort.TargetPort = intstr.FromString(name)
+		} else if targetPort := c.getUnnamedEndpointSlicePort(serviceImport); targetPort != 0 {
+			servicePort.TargetPort = intstr.FromInt(int(targetPort))
+		}
+		service.Spec.Ports = append(service.Spec.Ports, servicePort)
+	}
+	return service
+}
+
+func (c *Context) getUnnamedEndpointSlicePort(serviceImport *unstructured.Unstructured) int32 {
+	for _, slice := range c.listEndpointSlices(serviceImport.GetNamespace(), serviceImport.GetName()) {
+		for _, port := range getEndpointSlicePorts(slice) {
+			if port.Name == "" {
+				return port.Port
+			}
+		}
+	}
+	return 0
+}
+
+// getImportedEndpoints returns the endpoints of the EndpointSlices derived from the ServiceImport with the key, or nil
+// when there is no such ServiceImport. Slices with the same ports are merged into a single subset.
+func (c *Context) getImportedEndpoints(serviceKey string) *v1.Endpoints {
+	serviceImport := c.getServiceImport(serviceKey)
+	if serviceImport == nil {
+		return nil
+	}
+	endpoints := &v1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      serviceImport.GetName(),
+			Namespace: serviceImport.GetNamespace(),
+		},
+	}
+
+	subsetsByPorts := make(map[string]int)
+	for _, slice := range c.listEndpointSlices(serviceImport.GetNamespace(), serviceImport.GetName()) {
+		if addressType, _, _ := unstructured.NestedString(slice.Object, "addressType"); addressType != "" && addressType != "IPv4" {
+			// App Gateway backends are IPv4 addresses
+			continue
+		}
+		ports := getEndpointSlicePorts(slice)
+		portsKey := getPortsKey(ports)
+		idx, exists := subsetsByPorts[portsKey]
+		if !exists {
+			endpoints.Subsets = append(endpoints.Subsets, v1.EndpointSubset{Ports: ports})
+			idx = len(endpoints.Subsets) - 1
+			subsetsByPorts[portsKey] = idx
+		}
+		subset := &endpoints.Subsets[idx]
+
+		sliceEndpoints, _, _ := unstructured.NestedSlice(slice.Object, "endpoints")
+		for _, e := range sliceEndpoints {
+			endpoint, ok := e.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			// An endpoint is ready unless its ready condition is false.
+			ready, found, _ := unstructured.NestedBool(endpoint, "conditions", "ready")
+			addresses, _, _ := unstructured.NestedStringSlice(endpoint, "addresses")
+			for _, address := range addresses {
+				if !found || ready {
+					subset.Addresses = append(subset.Addresses, v1.EndpointAddress{IP: address})
+				} else {
+					subset.NotReadyAddresses = append(subset.NotReadyAddresses, v1.EndpointAddress{IP: address})
+				}
+			}
+		}
+	}
+	return endpoints
+}
+
+// listEndpointSlices returns the EndpointSlices derived from the ServiceImport, sorted by name.
+func (c *Context) listEndpointSlices(namespace, serviceName string) []*unstructured.Unstructured {
+	if c.Caches.EndpointSlice == nil {
+		return nil
+	}
+	var slices []*unstructured.Unstructured
+	for _, obj := range c.Caches.EndpointSlice.List() {
+		slice, ok := obj.(*unstructured.Unstructured)
+		if !ok || slice.GetNamespace() != namespace || slice.GetLabels()[MultiClusterServiceNameLabel] != serviceName {
+			continue
+		}
+		slices = append(slices, slice)
+	}
+	sort.Slice(slices, func(i, j int) bool {
+		return slices[i].GetName() < slices[j].GetName()
+	})
+	return slices
+}
+
+func getEndpointSlicePorts(slice *unstructured.Unstructured) []v1.EndpointPort {
+	var endpointPorts []v1.EndpointPort
+	ports, _, _ := unstructured.NestedSlice(slice.Object, "ports")
+	for _, p := range ports {
+		port, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		number, _, _ := unstructured.NestedInt64(port, "port")
+		name, _, _ := unstructured.NestedString(port, "name")
+		protocol, _, _ := unstructured.NestedString(port, "protocol")
+		if protocol == "" {
+			protocol = string(v1.ProtocolTCP)
+		}
+		endpointPorts = append(endpointPorts, v1.EndpointPort{
+			Name:     name,
+			Port:     int32(number),
+			Protocol: v1.Protocol(protocol),
+		})
+	}
+	return endpointPorts
+}
+
+func getPortsKey(ports []v1.EndpointPort) string {
+	var keys []string
+	for _, port := range ports {
+		keys = append(keys, fmt.Sprintf("%s/%s/%d", port.Name, port.Protocol, port.Port))
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
@@ -0,0 +1,179 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package k8scontext
+
+import (
+	"time"
+
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	testclient "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/agic_crd_client/clientset/versioned/fake"
+	istioFake "github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/istio_crd_client/clientset/versioned/fake"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/metricstore"
+)
+
+var _ = ginkgo.Describe("multi-cluster services", func() {
+	const namespace = "ns"
+
+	var k8sClient *testclient.Clientset
+	var ctxt *Context
+	var stopChannel chan struct{}
+	var env environment.EnvVariables
+
+	newServiceImport := func(name string, ports ...interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "multicluster.x-k8s.io/v1alpha1",
+			"kind":       "ServiceImport",
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": namespace,
+			},
+			"spec": map[string]interface{}{
+				"type":  "ClusterSetIP",
+				"ports": ports,
+			},
+		}}
+	}
+
+	newEndpointSlice := func(name, serviceName string, port interface{}, endpoints ...interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "discovery.k8s.io/v1",
+			"kind":       "EndpointSlice",
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": namespace,
+				"labels": map[string]interface{}{
+					MultiClusterServiceNameLabel: serviceName,
+				},
+			},
+			"addressType": "IPv4",
+			"ports":       []interface{}{port},
+			"endpoints":   endpoints,
+		}}
+	}
+
+	newEndpoint := func(address string, ready bool) interface{} {
+		return map[string]interface{}{
+			"addresses":  []interface{}{address},
+			"conditions": map[string]interface{}{"ready": ready},
+		}
+	}
+
+	servedResources := []*metav1.APIResourceList{
+		{
+			GroupVersion: "multicluster.x-k8s.io/v1alpha1",
+			APIResources: []metav1.APIResource{{Name: "serviceimports", Namespaced: true, Kind: "ServiceImport"}},
+		},
+		{
+			GroupVersion: "discovery.k8s.io/v1",
+			APIResources: []metav1.APIResource{{Name: "endpointslices", Namespaced: true, Kind: "EndpointSlice"}},
+		},
+	}
+
+	ginkgo.BeforeEach(func() {
+		stopChannel = make(chan struct{})
+		k8sClient = testclient.NewSimpleClientset()
+		ctxt = NewContext(k8sClient, fake.NewSimpleClientset(), istioFake.NewSimpleClientset(), []string{namespace}, 1000*time.Second, DefaultWorkQueueDepth, metricstore.NewFakeMetricStore())
+		env = environment.GetFakeEnv()
+		env.EnableMultiClusterServices = true
+	})
+
+	ginkgo.AfterEach(func() {
+		close(stopChannel)
+	})
+
+	ginkgo.It("resolves the ServiceImport and the endpoints of its EndpointSlices", func() {
+		k8sClient.Fake.Resources = servedResources
+		ctxt.SetDynamicClient(dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(),
+			newServiceImport("imported", map[string]interface{}{"name": "http", "port": int64(80), "protocol": "TCP"}),
+			newEndpointSlice("imported-west", "imported", map[string]interface{}{"name": "http", "port": int64(8080), "protocol": "TCP"},
+				newEndpoint("10.1.0.1", true), newEndpoint("10.1.0.2", false)),
+			newEndpointSlice("imported-east", "imported", map[string]interface{}{"name": "http", "port": int64(8080), "protocol": "TCP"},
+				newEndpoint("10.2.0.1", true)),
+		))
+		Expect(ctxt.Run(stopChannel, true, env)).ToNot(HaveOccurred())
+
+		service := ctxt.GetService(namespace + "/imported")
+		Expect(service).ToNot(BeNil())
+		Expect(service.Spec.Ports).To(Equal([]v1.ServicePort{{
+			Name:       "http",
+			Protocol:   v1.ProtocolTCP,
+			Port:       80,
+			TargetPort: intstr.FromString("http"),
+		}}))
+		Expect(ctxt.ListPodsByServiceSelector(service)).To(BeEmpty())
+		Expect(ctxt.ListServices()).To(ContainElement(service))
+
+		endpoints, err := ctxt.GetEndpointsByService(namespace + "/imported")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(endpoints.Subsets).To(Equal([]v1.EndpointSubset{{
+			Addresses:         []v1.EndpointAddress{{IP: "10.2.0.1"}, {IP: "10.1.0.1"}},
+			NotReadyAddresses: []v1.EndpointAddress{{IP: "10.1.0.2"}},
+			Ports:             []v1.EndpointPort{{Name: "http", Port: 8080, Protocol: v1.ProtocolTCP}},
+		}}))
+	})
+
+	ginkgo.It("targets the unnamed port of the EndpointSlices with an unnamed port", func() {
+		k8sClient.Fake.Resources = servedResources
+		ctxt.SetDynamicClient(dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(),
+			newServiceImport("imported", map[string]interface{}{"port": int64(80)}),
+			newEndpointSlice("imported-west", "imported", map[string]interface{}{"port": int64(8080)}, newEndpoint("10.1.0.1", true)),
+		))
+		Expect(ctxt.Run(stopChannel, true, env)).ToNot(HaveOccurred())
+
+		service := ctxt.GetService(namespace + "/imported")
+		Expect(service).ToNot(BeNil())
+		Expect(service.Spec.Ports[0].TargetPort).To(Equal(intstr.FromInt(8080)))
+	})
+
+	ginkgo.It("prefers a local service of the same name", func() {
+		k8sClient.Fake.Resources = servedResources
+		ctxt.SetDynamicClient(dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(),
+			newServiceImport("web", map[string]interface{}{"port": int64(80)}),
+		))
+		local := &v1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: namespace},
+			Spec: v1.ServiceSpec{
+				Ports:    []v1.ServicePort{{Port: 443, Protocol: v1.ProtocolTCP}},
+				Selector: map[string]string{"app": "web"},
+			},
+		}
+		_, err := k8sClient.CoreV1().Services(namespace).Create(local)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ctxt.Run(stopChannel, true, env)).ToNot(HaveOccurred())
+
+		Expect(ctxt.GetService(namespace + "/web").Spec.Ports[0].Port).To(Equal(int32(443)))
+		Expect(ctxt.ListServices()).To(HaveLen(1))
+	})
+
+	ginkgo.It("does not watch ServiceImports without the multi-cluster services CRDs", func() {
+		ctxt.SetDynamicClient(dynamicfake.NewSimpleDynamicClient(runtime.NewScheme()))
+		Expect(ctxt.Run(stopChannel, true, env)).ToNot(HaveOccurred())
+
+		Expect(ctxt.Caches.ServiceImport).To(BeNil())
+		Expect(ctxt.GetService(namespace + "/imported")).To(BeNil())
+		_, err := ctxt.GetEndpointsByService(namespace + "/imported")
+		Expect(err).To(Equal(ErrorFetchingEnpdoints))
+	})
+
+	ginkgo.It("does not watch ServiceImports unless enabled", func() {
+		k8sClient.Fake.Resources = servedResources
+		ctxt.SetDynamicClient(dynamicfake.NewSimpleDynamicClient(runtime.NewScheme()))
+		env.EnableMultiClusterServices = false
+		Expect(ctxt.Run(stopChannel, true, env)).ToNot(HaveOccurred())
+
+		Expect(ctxt.Caches.ServiceImport).To(BeNil())
+	})
+})
@@ -0,0 +1,76 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package k8scontext
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/golang/glog"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+)
+
+// PauseKey is the key of the pause ConfigMap; while its value is "true" AGIC does not apply changes to the App Gateway.
+const PauseKey = "pause"
+
+// watchPauseConfigMap creates an informer for the single ConfigMap, which pauses applying changes to the App Gateway.
+// Any change to the ConfigMap enqueues an event, so that AGIC resumes right away when the pause is lifted.
+func (c *Context) watchPauseConfigMap(namespace, name string) cache.SharedIndexInformer {
+	informerFactory := informers.NewSharedInformerFactoryWithOptions(c.kubeClient, 0,
+		informers.WithNamespace(namespace),
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.FieldSelector = fields.OneTermEqualSelector("metadata.name", name).String()
+		}))
+	informer := informerFactory.Core().V1().ConfigMaps().Informer()
+
+	onChange := func(obj interface{}) {
+		glog.V(3).Infof("[k8scontext] Pause ConfigMap %s/%s changed; reconcile paused: %t", namespace, name, c.IsReconcilePaused())
+		c.enqueue(events.Event{
+			Type:  events.Update,
+			Value: obj,
+		})
+		c.metricStore.IncK8sAPIEventCounter()
+	}
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    onChange,
+		UpdateFunc: func(oldObj, newObj interface{}) { onChange(newObj) },
+		DeleteFunc: onChange,
+	})
+
+	c.informers.PauseConfigMap = informer
+	c.Caches.PauseConfigMap = informer.GetStore()
+	c.pauseConfigMapKey = fmt.Sprintf("%s/%s", namespace, name)
+	return informer
+}
+
+// IsReconcilePaused determines whether the pause ConfigMap asks AGIC not to apply changes to the App Gateway.
+func (c *Context) IsReconcilePaused() bool {
+	if c.Caches.PauseConfigMap == nil {
+		return false
+	}
+	obj, exists, err := c.Caches.PauseConfigMap.GetByKey(c.pauseConfigMapKey)
+	if err != nil || !exists {
+		return false
+	}
+	configMap, ok := obj.(*v1.ConfigMap)
+	if !ok {
+		return false
+	}
+	paused, err := strconv.ParseBool(strings.TrimSpace(configMap.Data[PauseKey]))
+	return err == nil && paused
+}
+
+// PauseConfigMapKey returns the namespace/name of the pause ConfigMap, or an empty string when it is not watched.
+func (c *Context) PauseConfigMapKey() string {
+	return c.pauseConfigMapKey
+}
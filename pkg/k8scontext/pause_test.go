@@ -0,0 +1,90 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package k8scontext
+
+import (
+	"time"
+
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	testclient "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/agic_crd_client/clientset/versioned/fake"
+	istioFake "github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/istio_crd_client/clientset/versioned/fake"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/metricstore"
+)
+
+var _ = ginkgo.Describe("pause ConfigMap", func() {
+	const agicNamespace = "agic"
+	const pauseName = "agic-pause"
+
+	var k8sClient kubernetes.Interface
+	var ctxt *Context
+	var stopChannel chan struct{}
+	var env environment.EnvVariables
+
+	newConfigMap := func(name, pause string) *v1.ConfigMap {
+		return &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: agicNamespace,
+			},
+			Data: map[string]string{PauseKey: pause},
+		}
+	}
+
+	ginkgo.BeforeEach(func() {
+		stopChannel = make(chan struct{})
+		k8sClient = testclient.NewSimpleClientset()
+		ctxt = NewContext(k8sClient, fake.NewSimpleClientset(), istioFake.NewSimpleClientset(), []string{"ns"}, 1000*time.Second, DefaultWorkQueueDepth, metricstore.NewFakeMetricStore())
+		env = environment.GetFakeEnv()
+		env.AGICPodNamespace = agicNamespace
+		env.PauseConfigMap = pauseName
+	})
+
+	ginkgo.AfterEach(func() {
+		close(stopChannel)
+	})
+
+	ginkgo.It("is not paused when no pause ConfigMap is configured", func() {
+		_, _ = k8sClient.CoreV1().ConfigMaps(agicNamespace).Create(newConfigMap(pauseName, "true"))
+		env.PauseConfigMap = ""
+		Expect(ctxt.Run(stopChannel, true, env)).ToNot(HaveOccurred())
+		Expect(ctxt.IsReconcilePaused()).To(BeFalse())
+	})
+
+	ginkgo.It("follows the pause key of the ConfigMap", func() {
+		_, _ = k8sClient.CoreV1().ConfigMaps(agicNamespace).Create(newConfigMap("other", "true"))
+		Expect(ctxt.Run(stopChannel, true, env)).ToNot(HaveOccurred())
+		Expect(ctxt.IsReconcilePaused()).To(BeFalse())
+
+		_, err := k8sClient.CoreV1().ConfigMaps(agicNamespace).Create(newConfigMap(pauseName, "true"))
+		Expect(err).ToNot(HaveOccurred())
+		Eventually(ctxt.IsReconcilePaused).Should(BeTrue())
+		Eventually(ctxt.Work).Should(Receive())
+
+		_, err = k8sClient.CoreV1().ConfigMaps(agicNamespace).Update(newConfigMap(pauseName, "false"))
+		Expect(err).ToNot(HaveOccurred())
+		Eventually(ctxt.IsReconcilePaused).Should(BeFalse())
+
+		_, err = k8sClient.CoreV1().ConfigMaps(agicNamespace).Update(newConfigMap(pauseName, " True "))
+		Expect(err).ToNot(HaveOccurred())
+		Eventually(ctxt.IsReconcilePaused).Should(BeTrue())
+
+		Expect(k8sClient.CoreV1().ConfigMaps(agicNamespace).Delete(pauseName, &metav1.DeleteOptions{})).To(Succeed())
+		Eventually(ctxt.IsReconcilePaused).Should(BeFalse())
+	})
+
+	ginkgo.It("is not paused by an invalid value", func() {
+		_, _ = k8sClient.CoreV1().ConfigMaps(agicNamespace).Create(newConfigMap(pauseName, "maybe"))
+		Expect(ctxt.Run(stopChannel, true, env)).ToNot(HaveOccurred())
+		Expect(ctxt.IsReconcilePaused()).To(BeFalse())
+	})
+})
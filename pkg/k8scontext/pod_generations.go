@@ -0,0 +1,66 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package k8scontext
+
+import (
+	"reflect"
+	"sync"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// podGenerations counts, per namespace, the pods added, deleted or relabeled: the changes which can change the pods
+// a service selects. Status updates of the pods, which are by far the most frequent, are not counted.
+type podGenerations struct {
+	sync.Mutex
+	byNamespace map[string]uint64
+}
+
+func (g *podGenerations) bump(namespace string) {
+	g.Lock()
+	defer g.Unlock()
+	if g.byNamespace == nil {
+		g.byNamespace = make(map[string]uint64)
+	}
+	g.byNamespace[namespace]++
+}
+
+func (g *podGenerations) get(namespace string) uint64 {
+	g.Lock()
+	defer g.Unlock()
+	return g.byNamespace[namespace]
+}
+
+// PodsGeneration returns the generation of the pods of the namespace. It changes whenever a pod of the namespace is
+// added, deleted or relabeled, so that what is resolved from the pods a service selects is valid while it is the same.
+func (c *Context) PodsGeneration(namespace string) uint64 {
+	return c.podGenerations.get(namespace)
+}
+
+func (h handlers) podAdd(obj interface{}) {
+	h.context.podGenerations.bump(getNamespace(obj))
+	h.addFunc(obj)
+}
+
+func (h handlers) podUpdate(oldObj, newObj interface{}) {
+	oldPod, oldOk := oldObj.(*v1.Pod)
+	newPod, newOk := newObj.(*v1.Pod)
+	if oldOk && newOk && !reflect.DeepEqual(oldPod.Labels, newPod.Labels) {
+		h.context.podGenerations.bump(newPod.Namespace)
+	}
+	h.updateFunc(oldObj, newObj)
+}
+
+func (h handlers) podDelete(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	if pod, ok := obj.(*v1.Pod); ok {
+		h.context.podGenerations.bump(pod.Namespace)
+	}
+	h.deleteFunc(obj)
+}
@@ -26,13 +26,13 @@ func (h handlers) secretAdd(obj interface{}) {
 	}
 
 	secKey := utils.GetResourceKey(sec.Namespace, sec.Name)
-	if h.context.ingressSecretsMap.ContainsValue(secKey) {
+	if h.context.ingressSecretsMap.ContainsValue(secKey) || h.isAutoSelectable(sec) {
 		// find if this secKey exists in the map[string]UnorderedSets
 		if err := h.context.CertificateSecretStore.ConvertSecret(secKey, sec); err == nil {
-			h.context.Work <- events.Event{
+			h.context.enqueue(events.Event{
 				Type:  events.Create,
 				Value: obj,
-			}
+			})
 			h.context.metricStore.IncK8sAPIEventCounter()
 		}
 	}
@@ -52,12 +52,12 @@ func (h handlers) secretUpdate(oldObj, newObj interface{}) {
 	}
 
 	secKey := utils.GetResourceKey(sec.Namespace, sec.Name)
-	if h.context.ingressSecretsMap.ContainsValue(secKey) {
+	if h.context.ingressSecretsMap.ContainsValue(secKey) || h.isAutoSelectable(sec) {
 		if err := h.context.CertificateSecretStore.ConvertSecret(secKey, sec); err == nil {
-			h.context.Work <- events.Event{
+			h.context.enqueue(events.Event{
 				Type:  events.Update,
 				Value: newObj,
-			}
+			})
 			h.context.metricStore.IncK8sAPIEventCounter()
 		}
 	}
@@ -86,11 +86,17 @@ func (h handlers) secretDelete(obj interface{}) {
 
 	secKey := utils.GetResourceKey(sec.Namespace, sec.Name)
 	h.context.CertificateSecretStore.delete(secKey)
-	if h.context.ingressSecretsMap.ContainsValue(secKey) {
-		h.context.Work <- events.Event{
+	if h.context.ingressSecretsMap.ContainsValue(secKey) || h.isAutoSelectable(sec) {
+		h.context.enqueue(events.Event{
 			Type:  events.Delete,
 			Value: obj,
-		}
+		})
 		h.context.metricStore.IncK8sAPIEventCounter()
 	}
 }
+
+// isAutoSelectable determines whether the secret is converted even when no ingress references it: with TLS secret
+// auto-selection, a TLS secret can be attached to the listener of any host its certificate is valid for.
+func (h handlers) isAutoSelectable(sec *v1.Secret) bool {
+	return h.context.autoSelectTLSSecrets && sec.Type == v1.SecretTypeTLS
+}
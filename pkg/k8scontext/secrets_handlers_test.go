@@ -46,7 +46,7 @@ var _ = ginkgo.Describe("K8scontext Secrets Cache Handlers", func() {
 		})
 		Expect(err).ToNot(HaveOccurred())
 
-		context = NewContext(k8sClient, fake.NewSimpleClientset(), istioFake.NewSimpleClientset(), []string{"ns"}, 1000*time.Second, metricstore.NewFakeMetricStore())
+		context = NewContext(k8sClient, fake.NewSimpleClientset(), istioFake.NewSimpleClientset(), []string{"ns"}, 1000*time.Second, DefaultWorkQueueDepth, metricstore.NewFakeMetricStore())
 		h = handlers{
 			context: context,
 		}
@@ -78,5 +78,27 @@ var _ = ginkgo.Describe("K8scontext Secrets Cache Handlers", func() {
 			h.secretUpdate(secret, secret)
 			Expect(len(h.context.Work)).To(Equal(0))
 		})
+
+		ginkgo.It("converts TLS secrets, which no ingress references, when they can be auto-selected", func() {
+			secret := tests.NewSecretTestFixture()
+			secret.Namespace = "ns"
+			secKey := utils.GetResourceKey(secret.Namespace, secret.Name)
+
+			h.secretAdd(secret)
+			Expect(len(h.context.Work)).To(Equal(0))
+
+			context.autoSelectTLSSecrets = true
+			h.secretAdd(secret)
+			Expect(len(h.context.Work)).To(Equal(1))
+			Expect(context.CertificateSecretStore.GetPfxCertificate(secKey)).ToNot(BeNil())
+			h.secretDelete(secret)
+			Expect(len(h.context.Work)).To(Equal(2))
+			Expect(context.CertificateSecretStore.GetPfxCertificate(secKey)).To(BeNil())
+
+			opaque := secret.DeepCopy()
+			opaque.Type = v1.SecretTypeOpaque
+			h.secretAdd(opaque)
+			Expect(len(h.context.Work)).To(Equal(2))
+		})
 	})
 })
@@ -15,6 +15,8 @@ import (
 	"github.com/golang/glog"
 	"k8s.io/api/core/v1"
 	"k8s.io/client-go/tools/cache"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/fips"
 )
 
 const (
@@ -102,7 +104,13 @@ func (s *SecretsStore) ConvertSecret(secretKey string, secret *v1.Secret) error
 
 	// both cert and key are in temp file now, call openssl
 	var cout, cerr bytes.Buffer
-	cmd := exec.Command("openssl", "pkcs12", "-export", "-in", tempfileCert.Name(), "-inkey", tempfileKey.Name(), "-password", "pass:msazure")
+	args := []string{"pkcs12", "-export", "-in", tempfileCert.Name(), "-inkey", tempfileKey.Name(), "-password", "pass:msazure"}
+	cmd := exec.Command("openssl", args...)
+	if fips.Enabled() {
+		// The default PKCS12 algorithms (RC2, 3DES, SHA1) are not FIPS-approved; OPENSSL_FIPS fails the export on a non-FIPS openssl.
+		cmd = exec.Command("openssl", append(args, "-keypbe", "AES-256-CBC", "-certpbe", "AES-256-CBC", "-macalg", "SHA256")...)
+		cmd.Env = append(os.Environ(), "OPENSSL_FIPS=1")
+	}
 	cmd.Stderr = &cerr
 	cmd.Stdout = &cout
 
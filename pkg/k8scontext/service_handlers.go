@@ -0,0 +1,41 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package k8scontext
+
+import (
+	"reflect"
+
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+)
+
+// serviceUpdate enqueues an event only when the spec or the labels of the service changed. The ports, target ports,
+// selector and type of a service shape the backend pools, HTTP settings and probes, and its labels decide whether
+// it matches the backend service selector of an ingress; changes to its status or other metadata, such as the
+// load balancer IP being assigned, do not.
+func (h handlers) serviceUpdate(oldObj, newObj interface{}) {
+	oldService, oldOk := oldObj.(*v1.Service)
+	newService, newOk := newObj.(*v1.Service)
+	if !oldOk || !newOk {
+		h.updateFunc(oldObj, newObj)
+		return
+	}
+	labelsChanged := !reflect.DeepEqual(oldService.Labels, newService.Labels)
+	if !labelsChanged && reflect.DeepEqual(oldService.Spec, newService.Spec) {
+		return
+	}
+	if labelsChanged && h.context.isServiceReferencedByAnyIngress(oldService) {
+		// The service may have stopped matching the backend service selector, which referenced it; the worker skips
+		// the events of services no ingress references, so the event has no object, which it always reconciles on.
+		h.context.enqueue(events.Event{
+			Type: events.Update,
+		})
+		h.context.metricStore.IncK8sAPIEventCounter()
+		return
+	}
+	h.updateFunc(oldObj, newObj)
+}
@@ -0,0 +1,80 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package k8scontext
+
+import (
+	"time"
+
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	testclient "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/agic_crd_client/clientset/versioned/fake"
+	istioFake "github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/istio_crd_client/clientset/versioned/fake"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/metricstore"
+)
+
+var _ = ginkgo.Describe("service selector", func() {
+	const namespace = "ns"
+
+	var k8sClient kubernetes.Interface
+	var ctxt *Context
+	var stopChannel chan struct{}
+	var env environment.EnvVariables
+
+	// Creates a service along with its endpoints, which carry the labels of the service as the endpoints controller sets them.
+	createService := func(name string, labels map[string]string) {
+		meta := metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    labels,
+		}
+		_, err := k8sClient.CoreV1().Services(namespace).Create(&v1.Service{ObjectMeta: meta})
+		Expect(err).ToNot(HaveOccurred())
+		_, err = k8sClient.CoreV1().Endpoints(namespace).Create(&v1.Endpoints{ObjectMeta: meta})
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	ginkgo.BeforeEach(func() {
+		stopChannel = make(chan struct{})
+		k8sClient = testclient.NewSimpleClientset()
+		createService("web", map[string]string{"app-gateway": "true"})
+		createService("internal", map[string]string{"app-gateway": "false"})
+		createService("unlabeled", nil)
+
+		ctxt = NewContext(k8sClient, fake.NewSimpleClientset(), istioFake.NewSimpleClientset(), []string{namespace}, 1000*time.Second, DefaultWorkQueueDepth, metricstore.NewFakeMetricStore())
+		env = environment.GetFakeEnv()
+	})
+
+	ginkgo.AfterEach(func() {
+		close(stopChannel)
+	})
+
+	ginkgo.It("only watches the services matching the selector, and their endpoints", func() {
+		env.ServiceSelector = "app-gateway=true"
+		Expect(ctxt.Run(stopChannel, true, env)).ToNot(HaveOccurred())
+
+		Expect(ctxt.GetService(namespace + "/web")).ToNot(BeNil())
+		Expect(ctxt.GetService(namespace + "/internal")).To(BeNil())
+		Expect(ctxt.GetService(namespace + "/unlabeled")).To(BeNil())
+
+		Expect(ctxt.Caches.Service.ListKeys()).To(ConsistOf(namespace + "/web"))
+		Expect(ctxt.Caches.Endpoints.ListKeys()).To(ConsistOf(namespace + "/web"))
+		_, err := ctxt.GetEndpointsByService(namespace + "/web")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	ginkgo.It("watches all services without a selector", func() {
+		Expect(ctxt.Run(stopChannel, true, env)).ToNot(HaveOccurred())
+
+		Expect(ctxt.Caches.Service.ListKeys()).To(ConsistOf(namespace+"/web", namespace+"/internal", namespace+"/unlabeled"))
+		Expect(ctxt.Caches.Endpoints.ListKeys()).To(ConsistOf(namespace+"/web", namespace+"/internal", namespace+"/unlabeled"))
+	})
+})
@@ -1,6 +1,8 @@
 package k8scontext
 
 import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 
@@ -13,30 +15,48 @@ import (
 
 // InformerCollection : all the informers for k8s resources we care about.
 type InformerCollection struct {
-	Endpoints                    cache.SharedIndexInformer
-	Ingress                      cache.SharedIndexInformer
-	Pods                         cache.SharedIndexInformer
-	Secret                       cache.SharedIndexInformer
-	Service                      cache.SharedIndexInformer
-	Namespace                    cache.SharedIndexInformer
-	AzureIngressManagedLocation  cache.SharedInformer
-	AzureIngressProhibitedTarget cache.SharedInformer
-	IstioGateway                 cache.SharedIndexInformer
-	IstioVirtualService          cache.SharedIndexInformer
+	Endpoints                      cache.SharedIndexInformer
+	Ingress                        cache.SharedIndexInformer
+	Nodes                          cache.SharedIndexInformer
+	Pods                           cache.SharedIndexInformer
+	Secret                         cache.SharedIndexInformer
+	Service                        cache.SharedIndexInformer
+	Namespace                      cache.SharedIndexInformer
+	AzureIngressManagedLocation    cache.SharedInformer
+	AzureIngressProhibitedTarget   cache.SharedInformer
+	AzureApplicationGatewayRewrite cache.SharedInformer
+	IstioGateway                   cache.SharedIndexInformer
+	IstioVirtualService            cache.SharedIndexInformer
+	PauseConfigMap                 cache.SharedIndexInformer
+	DefaultAnnotations             cache.SharedIndexInformer
+	ServiceImport                  cache.SharedIndexInformer
+	EndpointSlice                  cache.SharedIndexInformer
+	Certificate                    cache.SharedIndexInformer
+	HTTPRoute                      cache.SharedIndexInformer
+	Gateway                        cache.SharedIndexInformer
 }
 
 // CacheCollection : all the listers from the informers.
 type CacheCollection struct {
-	Endpoints                    cache.Store
-	Ingress                      cache.Store
-	Pods                         cache.Store
-	Secret                       cache.Store
-	Service                      cache.Store
-	Namespaces                   cache.Store
-	AzureIngressManagedLocation  cache.Store
-	AzureIngressProhibitedTarget cache.Store
-	IstioGateway                 cache.Store
-	IstioVirtualService          cache.Store
+	Endpoints                      cache.Store
+	Ingress                        cache.Store
+	Nodes                          cache.Store
+	Pods                           cache.Store
+	Secret                         cache.Store
+	Service                        cache.Store
+	Namespaces                     cache.Store
+	AzureIngressManagedLocation    cache.Store
+	AzureIngressProhibitedTarget   cache.Store
+	AzureApplicationGatewayRewrite cache.Store
+	IstioGateway                   cache.Store
+	IstioVirtualService            cache.Store
+	PauseConfigMap                 cache.Store
+	DefaultAnnotations             cache.Store
+	ServiceImport                  cache.Store
+	EndpointSlice                  cache.Store
+	Certificate                    cache.Store
+	HTTPRoute                      cache.Store
+	Gateway                        cache.Store
 }
 
 // Context : cache and listener for k8s resources.
@@ -45,6 +65,7 @@ type Context struct {
 	kubeClient     kubernetes.Interface
 	crdClient      versioned.Interface
 	istioCrdClient istio_versioned.Interface
+	dynamicClient  dynamic.Interface
 
 	informers              *InformerCollection
 	Caches                 *CacheCollection
@@ -58,6 +79,26 @@ type Context struct {
 
 	metricStore metricstore.MetricStore
 	namespaces  map[string]interface{}
+
+	pauseConfigMapKey string
+
+	// defaultAnnotationsConfigMap names the ConfigMap holding the default annotations of the ingresses of its namespace.
+	defaultAnnotationsConfigMap string
+
+	// autoSelectTLSSecrets is set when any TLS secret may be attached to a listener, not only the ones referenced by ingresses.
+	autoSelectTLSSecrets bool
+
+	// serviceSelector is the label selector of the services, and of their endpoints, AGIC watches; empty selects all.
+	serviceSelector string
+
+	// initialSync is 1 while the informers list the resources of the cluster into the caches; no events are enqueued then.
+	initialSync int32
+
+	// ingressGVR is the resource of the Ingresses AGIC consumes, which are converted to extensions/v1beta1 Ingresses.
+	ingressGVR schema.GroupVersionResource
+
+	// podGenerations counts the changes to the pods of each namespace, which can change the pods a service selects.
+	podGenerations podGenerations
 }
 
 // IPAddress is type for IP address string
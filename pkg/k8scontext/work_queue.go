@@ -0,0 +1,58 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package k8scontext
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/golang/glog"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+)
+
+// DefaultWorkQueueDepth is the number of events, which can be waiting to be processed, when APPGW_EVENT_QUEUE_DEPTH is not set.
+const DefaultWorkQueueDepth = 1024
+
+// enqueue adds the event to the Work channel without blocking the informer.
+// When the channel is full the oldest pending event is dropped, and an event with no object takes the place of the
+// new event. The worker skips the events of pods, endpoints and services no ingress references, and would skip the
+// dropped event with them; the event with no object always makes it reconcile. This is safe because reconcile is
+// level-based: the worker builds the App Gateway config from the state of the caches, not from the events.
+// During the initial sync of the caches no event is enqueued; Run enqueues a single event once the caches are complete.
+func (c *Context) enqueue(event events.Event) {
+	if atomic.LoadInt32(&c.initialSync) == 1 {
+		return
+	}
+	for {
+		select {
+		case c.Work <- event:
+			c.metricStore.SetEventQueueDepth(len(c.Work))
+			return
+		default:
+		}
+
+		select {
+		case <-c.Work:
+			glog.V(5).Infof("[k8scontext] Event queue is full (%d); dropped the oldest event", cap(c.Work))
+			c.metricStore.IncEventQueueDropCounter()
+			event = events.Event{
+				Type: events.Update,
+			}
+		default:
+		}
+	}
+}
+
+// ReconcileAfter enqueues an event after the given delay, which makes the worker reconcile the App Gateway config.
+func (c *Context) ReconcileAfter(delay time.Duration) {
+	glog.V(5).Infof("[k8scontext] Reconcile scheduled in %+v", delay)
+	time.AfterFunc(delay, func() {
+		c.enqueue(events.Event{
+			Type: events.Update,
+		})
+	})
+}
@@ -0,0 +1,61 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package k8scontext
+
+import (
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	// ZoneLabel is the label with the availability zone of a node.
+	ZoneLabel = "topology.kubernetes.io/zone"
+
+	// DeprecatedZoneLabel is the label with the availability zone of a node on clusters older than Kubernetes 1.17.
+	DeprecatedZoneLabel = "failure-domain.beta.kubernetes.io/zone"
+)
+
+// GetNodeZone returns the availability zone of the node, or an empty string when the node is not in the cache
+// or has no zone label.
+func (c *Context) GetNodeZone(nodeName string) string {
+	obj, exists, err := c.Caches.Nodes.GetByKey(nodeName)
+	if err != nil || !exists {
+		return ""
+	}
+	node, ok := obj.(*v1.Node)
+	if !ok {
+		return ""
+	}
+	if zone, ok := node.Labels[ZoneLabel]; ok {
+		return zone
+	}
+	return node.Labels[DeprecatedZoneLabel]
+}
+
+// GetZonesByAddress returns the availability zone of the node hosting each endpoint, keyed by the IP of the endpoint.
+// Endpoints without a node, or on a node without a zone label, have an empty zone.
+func (c *Context) GetZonesByAddress() map[string]string {
+	zones := make(map[string]string)
+	for _, obj := range c.Caches.Endpoints.List() {
+		endpoints, ok := obj.(*v1.Endpoints)
+		if !ok {
+			continue
+		}
+		for _, subset := range endpoints.Subsets {
+			for _, addresses := range [][]v1.EndpointAddress{subset.Addresses, subset.NotReadyAddresses} {
+				for _, address := range addresses {
+					if len(address.IP) == 0 {
+						continue
+					}
+					zones[address.IP] = ""
+					if address.NodeName != nil {
+						zones[address.IP] = c.GetNodeZone(*address.NodeName)
+					}
+				}
+			}
+		}
+	}
+	return zones
+}
@@ -0,0 +1,76 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package k8scontext
+
+import (
+	"time"
+
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	testclient "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/agic_crd_client/clientset/versioned/fake"
+	istioFake "github.com/Azure/application-gateway-kubernetes-ingress/pkg/crd_client/istio_crd_client/clientset/versioned/fake"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/metricstore"
+)
+
+var _ = ginkgo.Describe("node zones", func() {
+	var ctxt *Context
+
+	newNode := func(name string, labels map[string]string) *v1.Node {
+		return &v1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				Labels: labels,
+			},
+		}
+	}
+
+	ginkgo.BeforeEach(func() {
+		ctxt = NewContext(testclient.NewSimpleClientset(), fake.NewSimpleClientset(), istioFake.NewSimpleClientset(), []string{"ns"}, 1000*time.Second, DefaultWorkQueueDepth, metricstore.NewFakeMetricStore())
+		_ = ctxt.Caches.Nodes.Add(newNode("node-1", map[string]string{ZoneLabel: "westeurope-1", DeprecatedZoneLabel: "old"}))
+		_ = ctxt.Caches.Nodes.Add(newNode("node-2", map[string]string{DeprecatedZoneLabel: "westeurope-2"}))
+		_ = ctxt.Caches.Nodes.Add(newNode("node-3", nil))
+	})
+
+	ginkgo.It("reads the zone labels of nodes", func() {
+		Expect(ctxt.GetNodeZone("node-1")).To(Equal("westeurope-1"))
+		Expect(ctxt.GetNodeZone("node-2")).To(Equal("westeurope-2"))
+		Expect(ctxt.GetNodeZone("node-3")).To(Equal(""))
+		Expect(ctxt.GetNodeZone("unknown-node")).To(Equal(""))
+	})
+
+	ginkgo.It("resolves the zones of endpoints through their nodes", func() {
+		_ = ctxt.Caches.Endpoints.Add(&v1.Endpoints{
+			ObjectMeta: metav1.ObjectMeta{Name: "service", Namespace: "ns"},
+			Subsets: []v1.EndpointSubset{
+				{
+					Addresses: []v1.EndpointAddress{
+						{IP: "10.0.0.1", NodeName: to.StringPtr("node-1")},
+						{IP: "10.0.0.2", NodeName: to.StringPtr("node-2")},
+						{IP: "10.0.0.3", NodeName: to.StringPtr("node-3")},
+						{IP: "10.0.0.4"},
+						{Hostname: "no-ip"},
+					},
+					NotReadyAddresses: []v1.EndpointAddress{
+						{IP: "10.0.0.5", NodeName: to.StringPtr("node-1")},
+					},
+				},
+			},
+		})
+
+		Expect(ctxt.GetZonesByAddress()).To(Equal(map[string]string{
+			"10.0.0.1": "westeurope-1",
+			"10.0.0.2": "westeurope-2",
+			"10.0.0.3": "",
+			"10.0.0.4": "",
+			"10.0.0.5": "westeurope-1",
+		}))
+	})
+})
@@ -35,6 +35,8 @@ func (ms *fakeMetricStore) Handler() http.Handler {
 
 func (ms *fakeMetricStore) SetUpdateLatencySec(dur time.Duration) {}
 
+func (ms *fakeMetricStore) ObserveReconcileDurationSec(dur time.Duration, traceID string) {}
+
 func (ms *fakeMetricStore) IncArmAPIUpdateCallFailureCounter() {}
 
 func (ms *fakeMetricStore) IncArmAPIUpdateCallSuccessCounter() {}
@@ -42,3 +44,29 @@ func (ms *fakeMetricStore) IncArmAPIUpdateCallSuccessCounter() {}
 func (ms *fakeMetricStore) IncArmAPICallCounter() {}
 
 func (ms *fakeMetricStore) IncK8sAPIEventCounter() {}
+
+func (ms *fakeMetricStore) IncRequeueCounter(reason string) {}
+
+func (ms *fakeMetricStore) SetEventQueueDepth(depth int) {}
+
+func (ms *fakeMetricStore) IncEventQueueDropCounter() {}
+
+func (ms *fakeMetricStore) SetReconcilePaused(paused bool) {}
+
+func (ms *fakeMetricStore) SetBackendPoolZoneEndpoints(endpointsByPool map[string]map[string]int) {}
+
+func (ms *fakeMetricStore) IncInvalidTLSSecretCounter(reason string) {}
+
+func (ms *fakeMetricStore) SetInitialSyncDurationSec(duration time.Duration) {}
+
+func (ms *fakeMetricStore) SetInformerSynced(informer string, synced bool) {}
+
+func (ms *fakeMetricStore) IncRollbackCounter() {}
+
+func (ms *fakeMetricStore) IncInvalidIngressCounter(mode string) {}
+
+func (ms *fakeMetricStore) IncSubnetFullCounter() {}
+
+func (ms *fakeMetricStore) SetClusterIPFallbackServices(count int) {}
+
+func (ms *fakeMetricStore) IncFQDNResolutionChangeCounter() {}
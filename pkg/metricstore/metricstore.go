@@ -21,26 +21,59 @@ import (
 // PrometheusNamespace is the namespace for appgw ingress controller
 var PrometheusNamespace = "appgw_ingress_controller"
 
+// reconcileDurationBuckets are the upper bounds of the buckets of the reconcile duration, in seconds: from a reconcile
+// without changes to one waiting for a long update of Application Gateway.
+var reconcileDurationBuckets = []float64{0.1, 0.5, 1, 5, 10, 30, 60, 120, 300, 600}
+
 // MetricStore is store maintaining all metrics
 type MetricStore interface {
 	Start()
 	Stop()
 	Handler() http.Handler
 	SetUpdateLatencySec(time.Duration)
+	ObserveReconcileDurationSec(duration time.Duration, traceID string)
 	IncArmAPIUpdateCallFailureCounter()
 	IncArmAPIUpdateCallSuccessCounter()
 	IncArmAPICallCounter()
 	IncK8sAPIEventCounter()
+	IncRequeueCounter(reason string)
+	SetEventQueueDepth(int)
+	IncEventQueueDropCounter()
+	SetReconcilePaused(bool)
+	SetBackendPoolZoneEndpoints(map[string]map[string]int)
+	IncInvalidTLSSecretCounter(reason string)
+	SetInitialSyncDurationSec(time.Duration)
+	SetInformerSynced(informer string, synced bool)
+	IncRollbackCounter()
+	IncInvalidIngressCounter(mode string)
+	IncSubnetFullCounter()
+	SetClusterIPFallbackServices(int)
+	IncFQDNResolutionChangeCounter()
 }
 
 // AGICMetricStore is store
 type AGICMetricStore struct {
 	constLabels                    prometheus.Labels
 	updateLatency                  prometheus.Gauge
+	reconcileDuration              prometheus.Histogram
+	reconcileDurationExemplars     *exemplars
 	k8sAPIEventCounter             prometheus.Counter
 	armAPICallCounter              prometheus.Counter
 	armAPIUpdateCallFailureCounter prometheus.Counter
 	armAPIUpdateCallSuccessCounter prometheus.Counter
+	requeueCounter                 *prometheus.CounterVec
+	eventQueueDepth                prometheus.Gauge
+	eventQueueDropCounter          prometheus.Counter
+	reconcilePaused                prometheus.Gauge
+	backendPoolZoneEndpoints       *prometheus.GaugeVec
+	invalidTLSSecretCounter        *prometheus.CounterVec
+	initialSyncDuration            prometheus.Gauge
+	informerSynced                 *prometheus.GaugeVec
+	rollbackCounter                prometheus.Counter
+	invalidIngressCounter          *prometheus.CounterVec
+	subnetFullCounter              prometheus.Counter
+	clusterIPFallbackServices      prometheus.Gauge
+	fqdnResolutionChangeCounter    prometheus.Counter
 
 	registry *prometheus.Registry
 }
@@ -64,6 +97,14 @@ func NewMetricStore(envVariable environment.EnvVariables) MetricStore {
 			Name:        "update_latency_seconds",
 			Help:        "The time spent in updating Application Gateway",
 		}),
+		reconcileDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace:   PrometheusNamespace,
+			ConstLabels: constLabels,
+			Name:        "reconcile_duration_seconds",
+			Help:        "The time spent in reconciling Application Gateway with the Kubernetes resources",
+			Buckets:     reconcileDurationBuckets,
+		}),
+		reconcileDurationExemplars: &exemplars{},
 		k8sAPIEventCounter: prometheus.NewCounter(prometheus.CounterOpts{
 			Namespace:   PrometheusNamespace,
 			ConstLabels: constLabels,
@@ -88,6 +129,84 @@ func NewMetricStore(envVariable environment.EnvVariables) MetricStore {
 			Name:        "arm_api_update_call_success_counter",
 			Help:        "This counter represents the number of update API calls that successfully updated Application Gateway",
 		}),
+		requeueCounter: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   PrometheusNamespace,
+			ConstLabels: constLabels,
+			Name:        "requeue_counter",
+			Help:        "This counter represents the number of events requeued with backoff after failing to process",
+		}, []string{"reason"}),
+		eventQueueDepth: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace:   PrometheusNamespace,
+			ConstLabels: constLabels,
+			Name:        "event_queue_depth",
+			Help:        "The number of Kubernetes events waiting to be processed",
+		}),
+		eventQueueDropCounter: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   PrometheusNamespace,
+			ConstLabels: constLabels,
+			Name:        "event_queue_drop_counter",
+			Help:        "This counter represents the number of events dropped from the event queue because it was full",
+		}),
+		reconcilePaused: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace:   PrometheusNamespace,
+			ConstLabels: constLabels,
+			Name:        "reconcile_paused",
+			Help:        "Whether applying changes to Application Gateway is paused (1) or not (0)",
+		}),
+		backendPoolZoneEndpoints: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   PrometheusNamespace,
+			ConstLabels: constLabels,
+			Name:        "backend_pool_zone_endpoints",
+			Help:        "The number of members of a backend pool in each availability zone",
+		}, []string{"pool", "zone"}),
+		invalidTLSSecretCounter: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   PrometheusNamespace,
+			ConstLabels: constLabels,
+			Name:        "invalid_tls_secret_counter",
+			Help:        "This counter represents the number of times an ingress referenced a TLS secret, which is missing or can not be used",
+		}, []string{"reason"}),
+		initialSyncDuration: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace:   PrometheusNamespace,
+			ConstLabels: constLabels,
+			Name:        "initial_sync_duration_seconds",
+			Help:        "The time spent listing the Kubernetes resources into the caches when AGIC started",
+		}),
+		informerSynced: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   PrometheusNamespace,
+			ConstLabels: constLabels,
+			Name:        "informer_synced",
+			Help:        "Whether the informer of the Kubernetes resources completed the initial sync of its cache (1) or not (0)",
+		}, []string{"informer"}),
+		rollbackCounter: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   PrometheusNamespace,
+			ConstLabels: constLabels,
+			Name:        "rollback_counter",
+			Help:        "This counter represents the number of times the last applied config was put back on Application Gateway after consecutive failed updates",
+		}),
+		invalidIngressCounter: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   PrometheusNamespace,
+			ConstLabels: constLabels,
+			Name:        "invalid_ingress_counter",
+			Help:        "This counter represents the number of times an ingress was found invalid, by whether it was ignored or failed the reconcile",
+		}, []string{"mode"}),
+		subnetFullCounter: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   PrometheusNamespace,
+			ConstLabels: constLabels,
+			Name:        "subnet_full_counter",
+			Help:        "This counter represents the number of updates of Application Gateway refused by ARM, because its subnet has no private IP addresses left",
+		}),
+		clusterIPFallbackServices: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace:   PrometheusNamespace,
+			ConstLabels: constLabels,
+			Name:        "cluster_ip_fallback_services",
+			Help:        "This gauge represents the number of services, whose backend pool holds their cluster IP, because they have more ready endpoints than a pool may hold",
+		}),
+		fqdnResolutionChangeCounter: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   PrometheusNamespace,
+			ConstLabels: constLabels,
+			Name:        "fqdn_resolution_change_counter",
+			Help:        "This counter represents the number of times the IPs of the FQDN of an ExternalName service changed, when AGIC resolves them",
+		}),
 		registry: prometheus.NewRegistry(),
 	}
 }
@@ -95,19 +214,47 @@ func NewMetricStore(envVariable environment.EnvVariables) MetricStore {
 // Start store
 func (ms *AGICMetricStore) Start() {
 	ms.registry.MustRegister(ms.updateLatency)
+	ms.registry.MustRegister(ms.reconcileDuration)
 	ms.registry.MustRegister(ms.k8sAPIEventCounter)
 	ms.registry.MustRegister(ms.armAPIUpdateCallSuccessCounter)
 	ms.registry.MustRegister(ms.armAPIUpdateCallFailureCounter)
 	ms.registry.MustRegister(ms.armAPICallCounter)
+	ms.registry.MustRegister(ms.requeueCounter)
+	ms.registry.MustRegister(ms.eventQueueDepth)
+	ms.registry.MustRegister(ms.eventQueueDropCounter)
+	ms.registry.MustRegister(ms.reconcilePaused)
+	ms.registry.MustRegister(ms.backendPoolZoneEndpoints)
+	ms.registry.MustRegister(ms.invalidTLSSecretCounter)
+	ms.registry.MustRegister(ms.initialSyncDuration)
+	ms.registry.MustRegister(ms.informerSynced)
+	ms.registry.MustRegister(ms.rollbackCounter)
+	ms.registry.MustRegister(ms.invalidIngressCounter)
+	ms.registry.MustRegister(ms.subnetFullCounter)
+	ms.registry.MustRegister(ms.clusterIPFallbackServices)
+	ms.registry.MustRegister(ms.fqdnResolutionChangeCounter)
 }
 
 // Stop store
 func (ms *AGICMetricStore) Stop() {
 	ms.registry.Unregister(ms.updateLatency)
+	ms.registry.Unregister(ms.reconcileDuration)
 	ms.registry.Unregister(ms.k8sAPIEventCounter)
 	ms.registry.Unregister(ms.armAPIUpdateCallSuccessCounter)
 	ms.registry.Unregister(ms.armAPIUpdateCallFailureCounter)
 	ms.registry.Unregister(ms.armAPICallCounter)
+	ms.registry.Unregister(ms.requeueCounter)
+	ms.registry.Unregister(ms.eventQueueDepth)
+	ms.registry.Unregister(ms.eventQueueDropCounter)
+	ms.registry.Unregister(ms.reconcilePaused)
+	ms.registry.Unregister(ms.backendPoolZoneEndpoints)
+	ms.registry.Unregister(ms.invalidTLSSecretCounter)
+	ms.registry.Unregister(ms.initialSyncDuration)
+	ms.registry.Unregister(ms.informerSynced)
+	ms.registry.Unregister(ms.rollbackCounter)
+	ms.registry.Unregister(ms.invalidIngressCounter)
+	ms.registry.Unregister(ms.subnetFullCounter)
+	ms.registry.Unregister(ms.clusterIPFallbackServices)
+	ms.registry.Unregister(ms.fqdnResolutionChangeCounter)
 }
 
 // SetUpdateLatencySec updates latency
@@ -115,6 +262,14 @@ func (ms *AGICMetricStore) SetUpdateLatencySec(duration time.Duration) {
 	ms.updateLatency.Set(duration.Seconds())
 }
 
+// ObserveReconcileDurationSec records the duration of a reconcile; with the ID of the trace of the reconcile, when it
+// is traced, as the exemplar of its bucket
+func (ms *AGICMetricStore) ObserveReconcileDurationSec(duration time.Duration, traceID string) {
+	ms.reconcileDuration.Observe(duration.Seconds())
+	ms.reconcileDurationExemplars.observe(prometheus.BuildFQName(PrometheusNamespace, "", "reconcile_duration_seconds"),
+		reconcileDurationBuckets, duration.Seconds(), traceID, time.Now())
+}
+
 // IncK8sAPIEventCounter increases the counter after recieving a k8s Event
 func (ms *AGICMetricStore) IncK8sAPIEventCounter() {
 	ms.k8sAPIEventCounter.Inc()
@@ -137,10 +292,96 @@ func (ms *AGICMetricStore) IncArmAPICallCounter() {
 	ms.armAPICallCounter.Inc()
 }
 
-// Handler return the registry
+// IncRequeueCounter increases the counter of events requeued for the given reason
+func (ms *AGICMetricStore) IncRequeueCounter(reason string) {
+	ms.requeueCounter.WithLabelValues(reason).Inc()
+}
+
+// SetEventQueueDepth updates the number of events waiting to be processed
+func (ms *AGICMetricStore) SetEventQueueDepth(depth int) {
+	ms.eventQueueDepth.Set(float64(depth))
+}
+
+// IncEventQueueDropCounter increases the counter of events dropped from a full event queue
+func (ms *AGICMetricStore) IncEventQueueDropCounter() {
+	ms.eventQueueDropCounter.Inc()
+}
+
+// SetReconcilePaused updates whether applying changes to Application Gateway is paused
+func (ms *AGICMetricStore) SetReconcilePaused(paused bool) {
+	if paused {
+		ms.reconcilePaused.Set(1)
+		return
+	}
+	ms.reconcilePaused.Set(0)
+}
+
+// SetBackendPoolZoneEndpoints replaces the number of members of each backend pool in each availability zone
+func (ms *AGICMetricStore) SetBackendPoolZoneEndpoints(endpointsByPool map[string]map[string]int) {
+	ms.backendPoolZoneEndpoints.Reset()
+	for pool, endpointsByZone := range endpointsByPool {
+		for zone, count := range endpointsByZone {
+			ms.backendPoolZoneEndpoints.WithLabelValues(pool, zone).Set(float64(count))
+		}
+	}
+}
+
+// IncInvalidTLSSecretCounter increases the counter of TLS secrets referenced by ingresses, which are missing or can not be used
+func (ms *AGICMetricStore) IncInvalidTLSSecretCounter(reason string) {
+	ms.invalidTLSSecretCounter.WithLabelValues(reason).Inc()
+}
+
+// SetInitialSyncDurationSec records how long the initial sync of the caches took
+func (ms *AGICMetricStore) SetInitialSyncDurationSec(duration time.Duration) {
+	ms.initialSyncDuration.Set(duration.Seconds())
+}
+
+// SetInformerSynced records whether the informer of the resources completed its initial sync
+func (ms *AGICMetricStore) SetInformerSynced(informer string, synced bool) {
+	value := 0.0
+	if synced {
+		value = 1
+	}
+	ms.informerSynced.WithLabelValues(informer).Set(value)
+}
+
+// IncRollbackCounter increases the counter of rollbacks to the last applied config
+func (ms *AGICMetricStore) IncRollbackCounter() {
+	ms.rollbackCounter.Inc()
+}
+
+// IncInvalidIngressCounter increases the counter of invalid ingresses, which were ignored or failed the reconcile
+func (ms *AGICMetricStore) IncInvalidIngressCounter(mode string) {
+	ms.invalidIngressCounter.WithLabelValues(mode).Inc()
+}
+
+// IncSubnetFullCounter increases the counter of updates refused, because the subnet has no private IP addresses left
+func (ms *AGICMetricStore) IncSubnetFullCounter() {
+	ms.subnetFullCounter.Inc()
+}
+
+// SetClusterIPFallbackServices updates the number of services, whose backend pool holds their cluster IP
+func (ms *AGICMetricStore) SetClusterIPFallbackServices(count int) {
+	ms.clusterIPFallbackServices.Set(float64(count))
+}
+
+// IncFQDNResolutionChangeCounter increases the counter of changes of the IPs of the FQDNs AGIC resolves
+func (ms *AGICMetricStore) IncFQDNResolutionChangeCounter() {
+	ms.fqdnResolutionChangeCounter.Inc()
+}
+
+// Handler return the registry; in the OpenMetrics text format, with the exemplars, when the scrape asks for it
 func (ms *AGICMetricStore) Handler() http.Handler {
+	prometheusHandler := promhttp.HandlerFor(ms.registry, promhttp.HandlerOpts{})
+	openMetricsHandler := openMetricsHandler(ms.registry, ms.reconcileDurationExemplars)
 	return promhttp.InstrumentMetricHandler(
 		ms.registry,
-		promhttp.HandlerFor(ms.registry, promhttp.HandlerOpts{}),
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if acceptsOpenMetrics(r) {
+				openMetricsHandler.ServeHTTP(w, r)
+				return
+			}
+			prometheusHandler.ServeHTTP(w, r)
+		}),
 	)
 }
@@ -0,0 +1,198 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package metricstore
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// openMetricsContentType is the content type of the OpenMetrics text format; only it can carry exemplars.
+const openMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+// traceIDLabel is the label of the exemplars, which holds the trace ID of the observation.
+const traceIDLabel = "trace_id"
+
+// exemplar is an observation of a histogram, which links the bucket it fell in to the trace it was made in.
+type exemplar struct {
+	traceID   string
+	value     float64
+	timestamp time.Time
+}
+
+// exemplars holds the last exemplar of each bucket of the histograms, which have exemplars, by the name of the
+// histogram. The Prometheus client AGIC uses has no exemplars yet, so they are kept here, and written by the
+// OpenMetrics handler.
+type exemplars struct {
+	sync.Mutex
+	buckets map[string]map[int]exemplar
+}
+
+// observe keeps the observation as the exemplar of its bucket; observations made without a trace are not kept.
+func (e *exemplars) observe(histogram string, upperBounds []float64, value float64, traceID string, now time.Time) {
+	if traceID == "" {
+		return
+	}
+	e.Lock()
+	defer e.Unlock()
+	if e.buckets == nil {
+		e.buckets = make(map[string]map[int]exemplar)
+	}
+	if e.buckets[histogram] == nil {
+		e.buckets[histogram] = make(map[int]exemplar)
+	}
+	// The bucket index of an observation above all upper bounds is the one of the +Inf bucket.
+	bucket := sort.SearchFloat64s(upperBounds, value)
+	e.buckets[histogram][bucket] = exemplar{traceID: traceID, value: value, timestamp: now}
+}
+
+func (e *exemplars) get(histogram string, bucket int) (exemplar, bool) {
+	e.Lock()
+	defer e.Unlock()
+	ex, exists := e.buckets[histogram][bucket]
+	return ex, exists
+}
+
+// acceptsOpenMetrics tells whether the scrape request asks for the OpenMetrics text format.
+func acceptsOpenMetrics(r *http.Request) bool {
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		if mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accepted)); err == nil && mediaType == "application/openmetrics-text" {
+			return true
+		}
+	}
+	return false
+}
+
+// openMetricsHandler serves the metrics of the gatherer in the OpenMetrics text format, with the exemplars.
+func openMetricsHandler(gatherer prometheus.Gatherer, ex *exemplars) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		families, err := gatherer.Gather()
+		if err != nil {
+			http.Error(w, fmt.Sprintf("An error has occurred while gathering the metrics: %s", err), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", openMetricsContentType)
+		_ = writeOpenMetrics(w, families, ex)
+	})
+}
+
+// writeOpenMetrics writes the metric families in the OpenMetrics text format. Counters get the _total suffix the
+// format requires on their samples.
+func writeOpenMetrics(out io.Writer, families []*dto.MetricFamily, ex *exemplars) error {
+	w := bufio.NewWriter(out)
+	for _, family := range families {
+		name := family.GetName()
+		metricType := "unknown"
+		switch family.GetType() {
+		case dto.MetricType_COUNTER:
+			metricType = "counter"
+		case dto.MetricType_GAUGE:
+			metricType = "gauge"
+		case dto.MetricType_SUMMARY:
+			metricType = "summary"
+		case dto.MetricType_HISTOGRAM:
+			metricType = "histogram"
+		}
+		fmt.Fprintf(w, "# TYPE %s %s\n", name, metricType)
+		if family.GetHelp() != "" {
+			fmt.Fprintf(w, "# HELP %s %s\n", name, escapeHelp(family.GetHelp()))
+		}
+
+		for _, metric := range family.GetMetric() {
+			labels := metric.GetLabel()
+			switch family.GetType() {
+			case dto.MetricType_COUNTER:
+				writeSample(w, name+"_total", labels, "", "", metric.GetCounter().GetValue())
+			case dto.MetricType_GAUGE:
+				writeSample(w, name, labels, "", "", metric.GetGauge().GetValue())
+			case dto.MetricType_SUMMARY:
+				for _, quantile := range metric.GetSummary().GetQuantile() {
+					writeSample(w, name, labels, "quantile", formatFloat(quantile.GetQuantile()), quantile.GetValue())
+				}
+				writeSample(w, name+"_sum", labels, "", "", metric.GetSummary().GetSampleSum())
+				writeSample(w, name+"_count", labels, "", "", float64(metric.GetSummary().GetSampleCount()))
+			case dto.MetricType_HISTOGRAM:
+				histogram := metric.GetHistogram()
+				buckets := histogram.GetBucket()
+				for idx := 0; idx <= len(buckets); idx++ {
+					upperBound, count := math.Inf(1), histogram.GetSampleCount()
+					if idx < len(buckets) {
+						upperBound, count = buckets[idx].GetUpperBound(), buckets[idx].GetCumulativeCount()
+					} else if len(buckets) > 0 && math.IsInf(buckets[len(buckets)-1].GetUpperBound(), 1) {
+						break
+					}
+					writeSampleWithoutNewline(w, name+"_bucket", labels, "le", formatFloat(upperBound), float64(count))
+					if e, exists := ex.get(name, idx); exists {
+						fmt.Fprintf(w, " # {%s=\"%s\"} %s %s", traceIDLabel, escapeLabelValue(e.traceID), formatFloat(e.value),
+							strconv.FormatFloat(float64(e.timestamp.UnixNano())/1e9, 'f', 3, 64))
+					}
+					w.WriteString("\n")
+				}
+				writeSample(w, name+"_sum", labels, "", "", histogram.GetSampleSum())
+				writeSample(w, name+"_count", labels, "", "", float64(histogram.GetSampleCount()))
+			default:
+				writeSample(w, name, labels, "", "", metric.GetUntyped().GetValue())
+			}
+		}
+	}
+	w.WriteString("# EOF\n")
+	return w.Flush()
+}
+
+func writeSample(w *bufio.Writer, name string, labels []*dto.LabelPair, extraName, extraValue string, value float64) {
+	writeSampleWithoutNewline(w, name, labels, extraName, extraValue, value)
+	w.WriteString("\n")
+}
+
+func writeSampleWithoutNewline(w *bufio.Writer, name string, labels []*dto.LabelPair, extraName, extraValue string, value float64) {
+	w.WriteString(name)
+	var pairs []string
+	for _, label := range labels {
+		pairs = append(pairs, fmt.Sprintf("%s=\"%s\"", label.GetName(), escapeLabelValue(label.GetValue())))
+	}
+	if extraName != "" {
+		pairs = append(pairs, fmt.Sprintf("%s=\"%s\"", extraName, extraValue))
+	}
+	if len(pairs) > 0 {
+		w.WriteString("{" + strings.Join(pairs, ",") + "}")
+	}
+	w.WriteString(" " + formatFloat(value))
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+func escapeLabelValue(value string) string {
+	return labelValueEscaper.Replace(value)
+}
+
+func escapeHelp(help string) string {
+	return helpEscaper.Replace(help)
+}
@@ -0,0 +1,80 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package metricstore
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/environment"
+)
+
+var _ = Describe("Test the exemplars of the reconcile duration", func() {
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	var store MetricStore
+
+	scrape := func(accept string) (string, string) {
+		request := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		if accept != "" {
+			request.Header.Set("Accept", accept)
+		}
+		recorder := httptest.NewRecorder()
+		store.Handler().ServeHTTP(recorder, request)
+		body, err := ioutil.ReadAll(recorder.Body)
+		Expect(err).ToNot(HaveOccurred())
+		return recorder.Header().Get("Content-Type"), string(body)
+	}
+
+	BeforeEach(func() {
+		store = NewMetricStore(environment.GetFakeEnv())
+		store.Start()
+	})
+
+	AfterEach(func() {
+		store.Stop()
+	})
+
+	It("attaches the trace ID of the reconcile to its bucket", func() {
+		store.ObserveReconcileDurationSec(3*time.Second, traceID)
+		contentType, body := scrape("application/openmetrics-text; version=1.0.0,text/plain;version=0.0.4;q=0.5")
+		Expect(contentType).To(HavePrefix("application/openmetrics-text"))
+		Expect(body).To(MatchRegexp(`(?m)^appgw_ingress_controller_reconcile_duration_seconds_bucket\{.*le="5"\} 1 # \{trace_id="` + traceID + `"\} 3 [0-9]+\.[0-9]{3}$`))
+		Expect(body).To(MatchRegexp(`(?m)^appgw_ingress_controller_reconcile_duration_seconds_bucket\{.*le="1"\} 0$`))
+		Expect(body).To(MatchRegexp(`(?m)^appgw_ingress_controller_reconcile_duration_seconds_bucket\{.*le="\+Inf"\} 1$`))
+		Expect(body).To(MatchRegexp(`(?m)^appgw_ingress_controller_arm_api_call_counter_total\{.*\} 0$`))
+		Expect(body).To(HaveSuffix("# EOF\n"))
+	})
+
+	It("keeps the last exemplar of each bucket", func() {
+		store.ObserveReconcileDurationSec(2*time.Second, "first")
+		store.ObserveReconcileDurationSec(4*time.Second, "second")
+		store.ObserveReconcileDurationSec(20*time.Minute, "slowest")
+		_, body := scrape("application/openmetrics-text")
+		Expect(body).To(ContainSubstring(`le="5"} 2 # {trace_id="second"} 4 `))
+		Expect(body).ToNot(ContainSubstring(`"first"`))
+		Expect(body).To(ContainSubstring(`le="+Inf"} 3 # {trace_id="slowest"} 1200 `))
+	})
+
+	It("has no exemplars without tracing", func() {
+		store.ObserveReconcileDurationSec(3*time.Second, "")
+		_, body := scrape("application/openmetrics-text")
+		Expect(body).To(MatchRegexp(`(?m)^appgw_ingress_controller_reconcile_duration_seconds_bucket\{.*le="5"\} 1$`))
+		Expect(body).ToNot(ContainSubstring("trace_id"))
+	})
+
+	It("serves the Prometheus text format without exemplars by default", func() {
+		store.ObserveReconcileDurationSec(3*time.Second, traceID)
+		contentType, body := scrape("")
+		Expect(contentType).To(HavePrefix("text/plain"))
+		Expect(body).To(ContainSubstring("appgw_ingress_controller_reconcile_duration_seconds_count"))
+		Expect(body).ToNot(ContainSubstring(traceID))
+	})
+})
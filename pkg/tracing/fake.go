@@ -0,0 +1,44 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package tracing
+
+import "sync"
+
+// FakeExporter keeps the spans exported to it.
+type FakeExporter struct {
+	sync.Mutex
+	spans []*Span
+}
+
+// NewFakeExporter returns a new FakeExporter.
+func NewFakeExporter() *FakeExporter {
+	return &FakeExporter{}
+}
+
+// Export keeps the spans.
+func (e *FakeExporter) Export(spans []*Span) error {
+	e.Lock()
+	defer e.Unlock()
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+// Spans returns the spans exported so far.
+func (e *FakeExporter) Spans() []*Span {
+	e.Lock()
+	defer e.Unlock()
+	return append([]*Span{}, e.spans...)
+}
+
+// Span returns the exported span with the name, or nil.
+func (e *FakeExporter) Span(name string) *Span {
+	for _, span := range e.Spans() {
+		if span.Name() == name {
+			return span
+		}
+	}
+	return nil
+}
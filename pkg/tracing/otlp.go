@@ -0,0 +1,175 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// otlpTracesPath is the path of the traces of an OTLP/HTTP endpoint.
+const otlpTracesPath = "/v1/traces"
+
+// The OTLP span kinds and status codes AGIC uses.
+const (
+	otlpSpanKindInternal = 1
+	otlpStatusCodeOk     = 1
+	otlpStatusCodeError  = 2
+)
+
+// otlpExporter posts spans to an OpenTelemetry collector with the JSON encoding of OTLP/HTTP.
+type otlpExporter struct {
+	url         string
+	serviceName string
+	client      *http.Client
+}
+
+func newOTLPExporter(endpoint string, serviceName string) *otlpExporter {
+	url := strings.TrimRight(endpoint, "/")
+	if !strings.HasSuffix(url, otlpTracesPath) {
+		url += otlpTracesPath
+	}
+	return &otlpExporter{
+		url:         url,
+		serviceName: serviceName,
+		client:      &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Export posts the spans to the collector.
+func (e *otlpExporter) Export(spans []*Span) error {
+	body, err := json.Marshal(newOTLPRequest(e.serviceName, spans))
+	if err != nil {
+		return err
+	}
+	response, err := e.client.Post(e.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("collector at %s returned %s", e.url, response.Status)
+	}
+	return nil
+}
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+func newOTLPRequest(serviceName string, spans []*Span) otlpRequest {
+	scopeSpans := otlpScopeSpans{Scope: otlpScope{Name: ServiceName}}
+	for _, span := range spans {
+		scopeSpans.Spans = append(scopeSpans.Spans, newOTLPSpan(span))
+	}
+	return otlpRequest{
+		ResourceSpans: []otlpResourceSpans{
+			{
+				Resource:   otlpResource{Attributes: []otlpAttribute{newOTLPAttribute("service.name", serviceName)}},
+				ScopeSpans: []otlpScopeSpans{scopeSpans},
+			},
+		},
+	}
+}
+
+func newOTLPSpan(span *Span) otlpSpan {
+	span.Lock()
+	defer span.Unlock()
+	otlp := otlpSpan{
+		TraceID:           span.traceID,
+		SpanID:            span.spanID,
+		ParentSpanID:      span.parentSpanID,
+		Name:              span.name,
+		Kind:              otlpSpanKindInternal,
+		StartTimeUnixNano: strconv.FormatInt(span.start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(span.end.UnixNano(), 10),
+		Status:            otlpStatus{Code: otlpStatusCodeOk},
+	}
+	for key, value := range span.attributes {
+		otlp.Attributes = append(otlp.Attributes, newOTLPAttribute(key, value))
+	}
+	if span.err != nil {
+		otlp.Status = otlpStatus{Code: otlpStatusCodeError, Message: span.err.Error()}
+	}
+	return otlp
+}
+
+func newOTLPAttribute(key string, value interface{}) otlpAttribute {
+	attribute := otlpAttribute{Key: key}
+	switch v := value.(type) {
+	case string:
+		attribute.Value.StringValue = &v
+	case bool:
+		attribute.Value.BoolValue = &v
+	case int:
+		intValue := strconv.FormatInt(int64(v), 10)
+		attribute.Value.IntValue = &intValue
+	case int32:
+		intValue := strconv.FormatInt(int64(v), 10)
+		attribute.Value.IntValue = &intValue
+	case int64:
+		intValue := strconv.FormatInt(v, 10)
+		attribute.Value.IntValue = &intValue
+	case float64:
+		attribute.Value.DoubleValue = &v
+	default:
+		stringValue := fmt.Sprint(v)
+		attribute.Value.StringValue = &stringValue
+	}
+	return attribute
+}
@@ -0,0 +1,210 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// ServiceName is the service.name of the resource of the spans of AGIC.
+const ServiceName = "ingress-appgw"
+
+// Exporter sends the spans of a trace to a tracing backend.
+type Exporter interface {
+	Export(spans []*Span) error
+}
+
+// Tracer records spans, and exports the spans of a trace once its root span ends.
+// A nil Tracer records nothing: its spans are nil, and the methods of a nil Span do nothing.
+type Tracer struct {
+	exporter Exporter
+}
+
+// NewTracer creates a Tracer exporting to the OpenTelemetry collector at the OTLP/HTTP endpoint.
+// Without an endpoint it returns nil, which traces nothing.
+func NewTracer(endpoint string) *Tracer {
+	if endpoint == "" {
+		return nil
+	}
+	glog.Infof("[tracing] Exporting traces to %s", endpoint)
+	return NewTracerWithExporter(newOTLPExporter(endpoint, ServiceName))
+}
+
+// NewTracerWithExporter creates a Tracer exporting with the given exporter.
+func NewTracerWithExporter(exporter Exporter) *Tracer {
+	return &Tracer{exporter: exporter}
+}
+
+// StartSpan starts the root span of a new trace.
+func (t *Tracer) StartSpan(name string) *Span {
+	if t == nil {
+		return nil
+	}
+	span := &Span{
+		tracer:     t,
+		traceID:    newID(16),
+		spanID:     newID(8),
+		name:       name,
+		start:      time.Now(),
+		attributes: make(map[string]interface{}),
+	}
+	span.root = span
+	return span
+}
+
+// Span is an operation of a trace.
+type Span struct {
+	tracer       *Tracer
+	root         *Span
+	traceID      string
+	spanID       string
+	parentSpanID string
+	name         string
+	start        time.Time
+	end          time.Time
+
+	sync.Mutex
+	attributes map[string]interface{}
+	err        error
+	// ended holds the spans of the trace which ended; on the root span only.
+	ended []*Span
+}
+
+// StartChild starts a span of the same trace, whose parent is this span.
+func (s *Span) StartChild(name string) *Span {
+	if s == nil {
+		return nil
+	}
+	return &Span{
+		tracer:       s.tracer,
+		root:         s.root,
+		traceID:      s.traceID,
+		spanID:       newID(8),
+		parentSpanID: s.spanID,
+		name:         name,
+		start:        time.Now(),
+		attributes:   make(map[string]interface{}),
+	}
+}
+
+// SetAttribute sets an attribute of the span; the value is a string, a bool, an integer or a float.
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.Lock()
+	defer s.Unlock()
+	s.attributes[key] = value
+}
+
+// SetError marks the span as failed with the error; a nil error leaves the span unchanged.
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.Lock()
+	defer s.Unlock()
+	s.err = err
+}
+
+// End ends the span. Ending the root span exports the spans of the trace, which ended, in the background.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.Lock()
+	s.end = time.Now()
+	s.Unlock()
+
+	s.root.Lock()
+	s.root.ended = append(s.root.ended, s)
+	var spans []*Span
+	if s == s.root {
+		spans = s.root.ended
+		s.root.ended = nil
+	}
+	s.root.Unlock()
+
+	if spans != nil {
+		go func() {
+			if err := s.tracer.exporter.Export(spans); err != nil {
+				glog.Warningf("[tracing] Failed exporting the spans of trace %s: %s", s.traceID, err)
+			}
+		}()
+	}
+}
+
+// Name returns the name of the span.
+func (s *Span) Name() string {
+	return s.name
+}
+
+// TraceID returns the hex ID of the trace of the span; empty for a nil span.
+func (s *Span) TraceID() string {
+	if s == nil {
+		return ""
+	}
+	return s.traceID
+}
+
+// SpanID returns the hex ID of the span.
+func (s *Span) SpanID() string {
+	return s.spanID
+}
+
+// ParentSpanID returns the hex ID of the parent of the span; empty for a root span.
+func (s *Span) ParentSpanID() string {
+	return s.parentSpanID
+}
+
+// Attributes returns a copy of the attributes of the span.
+func (s *Span) Attributes() map[string]interface{} {
+	s.Lock()
+	defer s.Unlock()
+	attributes := make(map[string]interface{}, len(s.attributes))
+	for key, value := range s.attributes {
+		attributes[key] = value
+	}
+	return attributes
+}
+
+// Err returns the error the span failed with.
+func (s *Span) Err() error {
+	s.Lock()
+	defer s.Unlock()
+	return s.err
+}
+
+type spanKey struct{}
+
+// ContextWithSpan returns a copy of the context carrying the span, as the parent of the spans started from it.
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	if span == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// SpanFromContext returns the span the context carries, or nil.
+func SpanFromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+func newID(size int) string {
+	id := make([]byte, size)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,131 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Tracing", func() {
+	Context("without an OTLP endpoint", func() {
+		It("records nothing", func() {
+			tracer := NewTracer("")
+			Expect(tracer).To(BeNil())
+
+			span := tracer.StartSpan("reconcile")
+			Expect(span).To(BeNil())
+			child := span.StartChild("build")
+			child.SetAttribute("key", "value")
+			child.SetError(errors.New("failed"))
+			child.End()
+			span.End()
+			Expect(span.TraceID()).To(BeEmpty())
+
+			ctx := ContextWithSpan(context.Background(), span)
+			Expect(SpanFromContext(ctx)).To(BeNil())
+		})
+	})
+
+	Context("with an exporter", func() {
+		var exporter *FakeExporter
+		var tracer *Tracer
+
+		BeforeEach(func() {
+			exporter = NewFakeExporter()
+			tracer = NewTracerWithExporter(exporter)
+		})
+
+		It("exports the spans of a trace once the root span ends", func() {
+			span := tracer.StartSpan("reconcile")
+			span.SetAttribute("appgw.name", "gateway")
+			child := SpanFromContext(ContextWithSpan(context.Background(), span)).StartChild("build")
+			child.SetError(errors.New("failed"))
+			child.End()
+			Consistently(exporter.Spans).Should(BeEmpty())
+
+			span.End()
+			Eventually(exporter.Spans).Should(HaveLen(2))
+
+			root := exporter.Span("reconcile")
+			Expect(root.ParentSpanID()).To(BeEmpty())
+			Expect(root.TraceID()).To(HaveLen(32))
+			Expect(root.SpanID()).To(HaveLen(16))
+			Expect(root.Attributes()).To(Equal(map[string]interface{}{"appgw.name": "gateway"}))
+			Expect(root.Err()).ToNot(HaveOccurred())
+
+			build := exporter.Span("build")
+			Expect(build.TraceID()).To(Equal(root.TraceID()))
+			Expect(build.ParentSpanID()).To(Equal(root.SpanID()))
+			Expect(build.SpanID()).ToNot(Equal(root.SpanID()))
+			Expect(build.Err()).To(MatchError("failed"))
+		})
+
+		It("starts a new trace for each root span", func() {
+			first := tracer.StartSpan("reconcile")
+			second := tracer.StartSpan("reconcile")
+			Expect(first.TraceID()).ToNot(Equal(second.TraceID()))
+		})
+	})
+
+	Context("with an OTLP endpoint", func() {
+		It("posts the spans to the collector", func() {
+			requests := make(chan *http.Request, 1)
+			bodies := make(chan []byte, 1)
+			collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := ioutil.ReadAll(r.Body)
+				requests <- r
+				bodies <- body
+			}))
+			defer collector.Close()
+
+			tracer := NewTracer(collector.URL + "/")
+			span := tracer.StartSpan("reconcile")
+			span.SetAttribute("appgw.name", "gateway")
+			span.SetAttribute("k8s.ingress.count", 3)
+			span.SetAttribute("appgw.config_changed", true)
+			child := span.StartChild("arm.UpdateGateway")
+			child.SetError(errors.New("conflict"))
+			child.End()
+			span.End()
+
+			var request *http.Request
+			Eventually(requests).Should(Receive(&request))
+			Expect(request.Method).To(Equal(http.MethodPost))
+			Expect(request.URL.Path).To(Equal("/v1/traces"))
+			Expect(request.Header.Get("Content-Type")).To(Equal("application/json"))
+
+			var otlp otlpRequest
+			Expect(json.Unmarshal(<-bodies, &otlp)).To(Succeed())
+			Expect(otlp.ResourceSpans).To(HaveLen(1))
+			Expect(*otlp.ResourceSpans[0].Resource.Attributes[0].Value.StringValue).To(Equal(ServiceName))
+			spans := otlp.ResourceSpans[0].ScopeSpans[0].Spans
+			Expect(spans).To(HaveLen(2))
+
+			arm, reconcile := spans[0], spans[1]
+			Expect(arm.Name).To(Equal("arm.UpdateGateway"))
+			Expect(arm.ParentSpanID).To(Equal(reconcile.SpanID))
+			Expect(arm.Status).To(Equal(otlpStatus{Code: otlpStatusCodeError, Message: "conflict"}))
+
+			Expect(reconcile.Name).To(Equal("reconcile"))
+			Expect(reconcile.Status.Code).To(Equal(otlpStatusCodeOk))
+			attributes := make(map[string]otlpValue)
+			for _, attribute := range reconcile.Attributes {
+				attributes[attribute.Key] = attribute.Value
+			}
+			Expect(*attributes["appgw.name"].StringValue).To(Equal("gateway"))
+			Expect(*attributes["k8s.ingress.count"].IntValue).To(Equal("3"))
+			Expect(*attributes["appgw.config_changed"].BoolValue).To(BeTrue())
+		})
+	})
+})
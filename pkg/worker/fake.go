@@ -6,9 +6,14 @@
 package worker
 
 import (
+	"errors"
+
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
 )
 
+// ErrFakeMutate is an error returned by fake processors in tests.
+var ErrFakeMutate = errors.New("fake mutate error")
+
 // FakeProcessor is fake event processor type
 type FakeProcessor struct {
 	mutateAppGwy func() error
@@ -0,0 +1,56 @@
+// -------------------------------------------------------------------------------------------
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT License. See License.txt in the project root for license information.
+// --------------------------------------------------------------------------------------------
+
+package worker
+
+import (
+	"time"
+
+	"github.com/golang/glog"
+	"k8s.io/client-go/util/workqueue"
+)
+
+const (
+	// requeueBaseDelay is the delay before an event, which failed to process, is requeued for the first time.
+	requeueBaseDelay = 1 * time.Second
+
+	// requeueMaxDelay caps the exponential backoff of an event, which keeps failing.
+	requeueMaxDelay = 5 * time.Minute
+)
+
+const (
+	// RequeueReasonMutateAKS is the reason an event is requeued when updating the Kubernetes resources failed.
+	RequeueReasonMutateAKS = "MutateAKS"
+
+	// RequeueReasonMutateAppGateway is the reason an event is requeued when applying the App Gateway config failed.
+	RequeueReasonMutateAppGateway = "MutateAppGateway"
+)
+
+// NewRateLimiter creates the per-step exponential backoff rate limiter used to requeue events which failed to process.
+func NewRateLimiter() workqueue.RateLimiter {
+	return workqueue.NewItemExponentialFailureRateLimiter(requeueBaseDelay, requeueMaxDelay)
+}
+
+// requeue schedules another reconcile after a delay, which grows exponentially with every consecutive failure of the
+// step given by the reason: a reconcile is about the App Gateway and the cluster as a whole, not about the object of
+// the event which triggered it. The event is enqueued through ReconcileAfter, like any other event, and the worker
+// continues processing other events in the meantime.
+func (w *Worker) requeue(reason string) {
+	delay := w.rateLimiter.When(reason)
+	glog.V(3).Infof("[worker] Requeueing reconcile in %+v (attempt %d); reason: %s", delay, w.rateLimiter.NumRequeues(reason), reason)
+
+	if w.MetricStore != nil {
+		w.MetricStore.IncRequeueCounter(reason)
+	}
+
+	if w.ReconcileAfter != nil {
+		w.ReconcileAfter(delay)
+	}
+}
+
+// forget resets the backoff of the step given by the reason, which succeeded.
+func (w *Worker) forget(reason string) {
+	w.rateLimiter.Forget(reason)
+}
@@ -6,7 +6,12 @@
 package worker
 
 import (
+	"time"
+
+	"k8s.io/client-go/util/workqueue"
+
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/metricstore"
 )
 
 // EventProcessor provides a mechanism to act on events in the internal queue.
@@ -18,6 +23,13 @@ type EventProcessor interface {
 
 // Worker listens to the eventChannel and runs the EventProcessor.MutateAppGateway and MutateAKS
 // for each event.
+// Events which failed to process are requeued with an exponential backoff per failing step.
 type Worker struct {
 	EventProcessor
+	MetricStore metricstore.MetricStore
+
+	// ReconcileAfter enqueues an event after the given delay; the worker requeues the events which failed through it.
+	ReconcileAfter func(time.Duration)
+
+	rateLimiter workqueue.RateLimiter
 }
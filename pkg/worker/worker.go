@@ -13,7 +13,6 @@ import (
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
 )
 
-const sleepOnErrorSeconds = 5
 const minTimeBetweenUpdates = 1 * time.Second
 
 func drainChan(ch chan events.Event, defaultEvent events.Event) events.Event {
@@ -29,13 +28,33 @@ func drainChan(ch chan events.Event, defaultEvent events.Event) events.Event {
 	}
 }
 
-// Run starts the worker which listens for events in eventChannel; stops when stopChannel is closed.
+// setQueueDepth updates the number of events waiting to be processed, as the worker takes them off the work channel.
+func (w *Worker) setQueueDepth(work chan events.Event) {
+	if w.MetricStore != nil {
+		w.MetricStore.SetEventQueueDepth(len(work))
+	}
+}
+
+// Run starts the worker which listens for events in eventChannel; returns when stopChannel is closed, after the
+// reconcile in progress, if any, is finished.
 func (w *Worker) Run(work chan events.Event, stopChannel chan struct{}) {
 	lastUpdate := time.Now().Add(-1 * time.Second)
+	if w.rateLimiter == nil {
+		w.rateLimiter = NewRateLimiter()
+	}
 	glog.V(1).Infoln("Worker started")
 	for {
 		select {
 		case event := <-work:
+			w.setQueueDepth(work)
+			select {
+			case <-stopChannel:
+				// both were ready; don't start another reconcile once stopped
+				glog.V(1).Infoln("Worker stopped")
+				return
+			default:
+			}
+
 			if shouldProcess, reason := w.ShouldProcess(event); !shouldProcess {
 				if reason != nil {
 					// This log statement could potentially generate a large amount of log lines and most could be
@@ -52,20 +71,31 @@ func (w *Worker) Run(work chan events.Event, stopChannel chan struct{}) {
 				time.Sleep(sleep)
 			}
 
-			_ = drainChan(work, event)
+			drainChan(work, event)
+			w.setQueueDepth(work)
 
+			failed := false
 			if err := w.MutateAKS(); err != nil {
 				glog.Error("Error mutating AKS from k8s event. ", err)
+				w.requeue(RequeueReasonMutateAKS)
+				failed = true
+			} else {
+				w.forget(RequeueReasonMutateAKS)
 			}
 
 			if err := w.MutateAppGateway(); err != nil {
 				glog.Error("Error mutating App Gateway config from k8s event. ", err)
-				time.Sleep(sleepOnErrorSeconds * time.Second)
+				if !failed {
+					w.requeue(RequeueReasonMutateAppGateway)
+				}
+			} else {
+				w.forget(RequeueReasonMutateAppGateway)
 			}
 
 			lastUpdate = time.Now()
 		case <-stopChannel:
-			break
+			glog.V(1).Infoln("Worker stopped")
+			return
 		}
 	}
 }
@@ -10,11 +10,23 @@ import (
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"k8s.io/client-go/util/workqueue"
 
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/events"
+	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/metricstore"
 	"github.com/Azure/application-gateway-kubernetes-ingress/pkg/tests"
 )
 
+// depthMetricStore records the depth of the event queue reported to the metric store.
+type depthMetricStore struct {
+	metricstore.MetricStore
+	depth chan int
+}
+
+func (ms *depthMetricStore) SetEventQueueDepth(depth int) {
+	ms.depth <- depth
+}
+
 var _ = Describe("Worker Test", func() {
 	var stopChannel chan struct{}
 	var work chan events.Event
@@ -99,4 +111,135 @@ var _ = Describe("Worker Test", func() {
 			Expect(lastEvent).To(Equal(def))
 		})
 	})
+
+	Context("Check that worker reports the depth of the event queue", func() {
+		It("Should lower the depth once the events are drained", func() {
+			metricStore := &depthMetricStore{MetricStore: metricstore.NewFakeMetricStore(), depth: make(chan int, 10)}
+			processed := make(chan struct{}, 10)
+			mutateAppGw := func() error {
+				processed <- struct{}{}
+				return nil
+			}
+			mutateAKS := func() error {
+				return nil
+			}
+			worker := Worker{
+				EventProcessor: NewFakeProcessor(mutateAppGw, mutateAKS),
+				MetricStore:    metricStore,
+			}
+
+			// The events pile up before the worker runs.
+			work := make(chan events.Event, 10)
+			for i := 0; i < 5; i++ {
+				work <- events.Event{Type: events.Update}
+			}
+			go worker.Run(work, stopChannel)
+
+			Eventually(processed).Should(Receive())
+			Expect(metricStore.depth).To(Receive(Equal(4)), "depth after taking the first event")
+			Expect(metricStore.depth).To(Receive(Equal(0)), "depth after draining the queue")
+			Expect(processed).ToNot(Receive(), "the drained events are processed in a single reconcile")
+		})
+	})
+
+	Context("Check that worker stops", func() {
+		It("Should return once stopped, after finishing the reconcile in progress", func() {
+			reconciling := make(chan struct{})
+			finishReconcile := make(chan struct{})
+			mutateAppGw := func() error {
+				close(reconciling)
+				<-finishReconcile
+				return nil
+			}
+			mutateAKS := func() error {
+				return nil
+			}
+			worker := Worker{
+				EventProcessor: NewFakeProcessor(mutateAppGw, mutateAKS),
+			}
+			stop := make(chan struct{})
+			done := make(chan struct{})
+			go func() {
+				worker.Run(work, stop)
+				close(done)
+			}()
+
+			work <- events.Event{
+				Type:  events.Create,
+				Value: tests.NewIngressFixture(),
+			}
+			Eventually(reconciling).Should(BeClosed())
+
+			close(stop)
+			Consistently(done, 100*time.Millisecond).ShouldNot(BeClosed())
+			close(finishReconcile)
+			Eventually(done).Should(BeClosed())
+		})
+	})
+
+	Context("Check that worker requeues events which failed to process", func() {
+		It("Should retry the event with backoff until it succeeds", func() {
+			backChannel := make(chan struct{}, 10)
+			attempts := 0
+			mutateAppGw := func() error {
+				attempts++
+				backChannel <- struct{}{}
+				if attempts < 3 {
+					return ErrFakeMutate
+				}
+				return nil
+			}
+			mutateAKS := func() error {
+				return nil
+			}
+			delays := make(chan time.Duration, 10)
+			worker := Worker{
+				EventProcessor: NewFakeProcessor(mutateAppGw, mutateAKS),
+				MetricStore:    metricstore.NewFakeMetricStore(),
+				ReconcileAfter: func(delay time.Duration) {
+					delays <- delay
+					time.AfterFunc(delay, func() { work <- events.Event{Type: events.Update} })
+				},
+				rateLimiter: workqueue.NewItemExponentialFailureRateLimiter(10*time.Millisecond, 100*time.Millisecond),
+			}
+			go worker.Run(work, stopChannel)
+
+			work <- events.Event{
+				Type:  events.Create,
+				Value: tests.NewIngressFixture(),
+			}
+
+			// The retries are not about the ingress; the backoff of the App Gateway grows nonetheless.
+			for i := 0; i < 3; i++ {
+				select {
+				case <-backChannel:
+				case <-time.After(5 * time.Second):
+					Fail("Worker did not retry the failed event within timeout")
+				}
+			}
+
+			// Give the worker a moment to forget the failures after the successful attempt
+			Eventually(func() int {
+				return worker.rateLimiter.NumRequeues(RequeueReasonMutateAppGateway)
+			}, time.Second).Should(Equal(0))
+			Expect(delays).To(Receive(Equal(10 * time.Millisecond)))
+			Expect(delays).To(Receive(Equal(20 * time.Millisecond)))
+			Consistently(backChannel, 2*minTimeBetweenUpdates).ShouldNot(Receive())
+		})
+	})
+
+	Context("Verify the requeue backoff", func() {
+		It("Should grow exponentially per key and be capped", func() {
+			rateLimiter := NewRateLimiter()
+			Expect(rateLimiter.When("a")).To(Equal(requeueBaseDelay))
+			Expect(rateLimiter.When("a")).To(Equal(2 * requeueBaseDelay))
+			Expect(rateLimiter.When("b")).To(Equal(requeueBaseDelay))
+			for i := 0; i < 20; i++ {
+				_ = rateLimiter.When("a")
+			}
+			Expect(rateLimiter.When("a")).To(Equal(requeueMaxDelay))
+			rateLimiter.Forget("a")
+			Expect(rateLimiter.When("a")).To(Equal(requeueBaseDelay))
+		})
+	})
 })
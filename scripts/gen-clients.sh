@@ -13,7 +13,7 @@ echo -e "Generate AzureIngressManagedTarget, AzureIngressProhibitedTarget..."
     all \
     github.com/Azure/application-gateway-kubernetes-ingress/pkg/client \
     github.com/Azure/application-gateway-kubernetes-ingress/pkg/apis \
-    "azureingressmanagedtarget:v1 azureingressprohibitedtarget:v1"
+    "azureingressmanagedtarget:v1 azureingressprohibitedtarget:v1 azureapplicationgatewayrewrite:v1"
 
 go get github.com/knative/pkg/apis/istio/v1alpha3
 